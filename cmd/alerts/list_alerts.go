@@ -1,20 +1,32 @@
 package alerts
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/openshift/osdctl/cmd/alerts/utils"
-	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/output"
 	"github.com/spf13/cobra"
 )
 
+// alertLevels are the values of --level: severities, and alert states where firing alerts are
+// active ones and pending alerts are the ones not yet processed by Alertmanager
+var alertLevels = []string{"warning", "critical", "info", "none", "firing", "pending", "all"}
+
 // alertCmd represnts information associated with cluster and level.
 type alertCmd struct {
-	clusterID  string
-	alertLevel string
-	reason     string
+	clusterID       string
+	alertLevel      string
+	reason          string
+	matchers        []string
+	silenced        bool
+	inhibited       bool
+	alertmanagerURL string
+	output          output.Options
 }
 
 // NewCmdListAlerts implements the list alert functionality.
@@ -31,98 +43,118 @@ func NewCmdListAlerts() *cobra.Command {
   osdctl alerts list --cluster-id ${CLUSTER_ID} --level firing --reason "${REASON}"
 
   # List only critical alerts
-  osdctl alerts list --cluster-id ${CLUSTER_ID} --level critical --reason "${REASON}"`,
+  osdctl alerts list --cluster-id ${CLUSTER_ID} --level critical --reason "${REASON}"
+
+  # List the alerts of the openshift namespaces, including silenced ones, as JSON
+  osdctl alerts list --cluster-id ${CLUSTER_ID} -m 'namespace=~openshift-.*' --silenced -o json --reason "${REASON}"`,
+		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
-		Run: func(cmd *cobra.Command, args []string) {
-			ListAlerts(alertCmd)
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ListAlerts(alertCmd)
 		},
 	}
 	newCmd.Flags().StringVarP(&alertCmd.clusterID, "cluster-id", "C", "", "Provide the internal ID of the cluster")
 	_ = newCmd.MarkFlagRequired("cluster-id")
 
 	newCmd.Flags().StringVarP(&alertCmd.alertLevel, "level", "l", "all", "Alert level [warning, critical, firing, pending, all]")
+	newCmd.Flags().StringArrayVarP(&alertCmd.matchers, "matcher", "m", []string{}, "Only list alerts matching this label matcher, e.g. severity=critical or namespace=~openshift-.* (can be repeated)")
+	newCmd.Flags().BoolVar(&alertCmd.silenced, "silenced", false, "Include silenced alerts")
+	newCmd.Flags().BoolVar(&alertCmd.inhibited, "inhibited", false, "Include inhibited alerts")
 	newCmd.Flags().StringVar(&alertCmd.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usualy an OHSS or PD ticket)")
 	_ = newCmd.MarkFlagRequired("reason")
+	utils.AddAlertmanagerURLFlag(newCmd, &alertCmd.alertmanagerURL)
+	alertCmd.output.AddFlags(newCmd)
 
 	return newCmd
 }
 
 // ListAlerts provides alerts based on input severity.
-func ListAlerts(cmd *alertCmd) {
-	defer func() {
-		if err := recover(); err != nil {
-			log.Fatal("error : ", err)
-		}
-	}()
-
-	clusterID := cmd.clusterID
-	alertLevel := cmd.alertLevel
-
-	if alertLevel == "" {
-		log.Printf("No alert level specified. Defaulting to 'all'")
-		getAlertLevel(clusterID, "all", cmd.reason)
-	} else if alertLevel == "warning" || alertLevel == "critical" || alertLevel == "firing" || alertLevel == "pending" || alertLevel == "info" || alertLevel == "none" || alertLevel == "all" {
-		getAlertLevel(clusterID, alertLevel, cmd.reason)
-	} else {
-		fmt.Printf("Invalid alert level \"%s\" \n", alertLevel)
-		return
+func ListAlerts(cmd *alertCmd) error {
+	if err := cmd.output.Validate(); err != nil {
+		return err
+	}
+	filter, state, err := cmd.alertFilter()
+	if err != nil {
+		return err
 	}
-}
-
-func getAlertLevel(clusterID, alertLevel string, elevationReason string) {
-	var alerts []utils.Alert
-
-	listAlertCmd := []string{"amtool", "--alertmanager.url", utils.LocalHostUrl, "alert", "-o", "json"}
 
 	elevationReasons := []string{
-		elevationReason,
+		cmd.reason,
 		"Listing active cluster alerts",
 	}
-
-	_, kubeconfig, clientset, err := common.GetKubeConfigAndClient(clusterID, elevationReasons...)
+	client, stop, err := utils.ConnectToAlertmanager(cmd.clusterID, cmd.alertmanagerURL, elevationReasons...)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	defer stop()
 
-	output, err := utils.ExecInAlertManagerPod(kubeconfig, clientset, listAlertCmd)
-
+	alerts, err := client.ListAlerts(context.Background(), filter)
 	if err != nil {
-		fmt.Println("Execution with alertmanager-main-0 failed.", err)
+		return err
 	}
 
-	if err != nil {
-		fmt.Println("Execution with alertmanager-main-1 failed.", err)
-		return
+	selected := []utils.Alert{}
+	for _, alert := range alerts {
+		if state == "" || alert.Status.State == state {
+			selected = append(selected, alert)
+		}
 	}
 
-	outputSlice := []byte(output)
+	if len(selected) == 0 && !cmd.output.IsStructured() {
+		fmt.Printf("No such Alert found with requested \"%s\" severity.\n", cmd.alertLevel)
+		return nil
+	}
+	return cmd.output.Print(os.Stdout, output.NewTable(selected, alertColumns, alertRow))
+}
 
-	err = json.Unmarshal(outputSlice, &alerts)
+// alertFilter returns the filter of the requested alerts, and the state they must be in
+func (cmd *alertCmd) alertFilter() (utils.AlertFilter, string, error) {
+	matchers, err := utils.ParseMatchers(cmd.matchers)
 	if err != nil {
-		fmt.Println("Error in unmarshaling the labels", err)
-		return
+		return utils.AlertFilter{}, "", err
 	}
+	filter := utils.AlertFilter{Matchers: matchers, Silenced: cmd.silenced, Inhibited: cmd.inhibited}
 
-	foundAlert := false
-	fmt.Printf("Alert Information:\n")
-	for _, alert := range alerts {
-		if alertLevel == "" || alertLevel == alert.Labels.Severity || alertLevel == "all" {
-			labels, status, annotations := alert.Labels, alert.Status, alert.Annotations
-			printAlert(labels, annotations, status)
-			foundAlert = true
-		}
+	level := cmd.alertLevel
+	if level == "" {
+		level = "all"
+	}
+	if !slices.Contains(alertLevels, level) {
+		return filter, "", fmt.Errorf("invalid alert level \"%s\", valid levels are: %s", level, strings.Join(alertLevels, ", "))
 	}
 
-	if !foundAlert {
-		fmt.Printf("No such Alert found with requested \"%s\" severity.\n", alertLevel)
+	switch level {
+	case "all":
+		return filter, "", nil
+	case "firing":
+		return filter, "active", nil
+	case "pending":
+		return filter, "unprocessed", nil
+	default:
+		filter.Matchers = append(filter.Matchers, utils.Matcher{Name: "severity", Value: level, IsEqual: true})
+		return filter, "", nil
 	}
+}
 
+var alertColumns = []output.Column{
+	{Name: "ALERTNAME"},
+	{Name: "SEVERITY"},
+	{Name: "STATE"},
+	{Name: "NAMESPACE"},
+	{Name: "SUMMARY"},
+	{Name: "STARTS AT", Wide: true},
+	{Name: "SILENCED BY", Wide: true},
 }
 
-func printAlert(labels utils.AlertLabels, annotations utils.AlertAnnotations, status utils.AlertStatus) {
-	fmt.Printf("  AlertName:  %s\n", labels.Alertname)
-	fmt.Printf("  Severity:   %s\n", labels.Severity)
-	fmt.Printf("  State:      %s\n", status.State)
-	fmt.Printf("  Message:    %s\n", annotations.Summary)
-	fmt.Println()
+func alertRow(alert utils.Alert) []string {
+	return []string{
+		alert.Name(),
+		alert.Labels["severity"],
+		alert.Status.State,
+		alert.Labels["namespace"],
+		alert.Annotations["summary"],
+		alert.StartsAt.UTC().Format(time.RFC3339),
+		strings.Join(alert.Status.SilencedBy, ","),
+	}
 }
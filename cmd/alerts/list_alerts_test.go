@@ -0,0 +1,59 @@
+package alerts
+
+import (
+	"testing"
+
+	"github.com/openshift/osdctl/cmd/alerts/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertFilter(t *testing.T) {
+	tests := []struct {
+		name         string
+		cmd          alertCmd
+		wantMatchers []utils.Matcher
+		wantState    string
+		wantErr      string
+	}{
+		{
+			name:         "severity level with matcher",
+			cmd:          alertCmd{alertLevel: "critical", matchers: []string{"namespace=~openshift-.*"}},
+			wantMatchers: []utils.Matcher{{Name: "namespace", Value: "openshift-.*", IsRegex: true, IsEqual: true}, {Name: "severity", Value: "critical", IsEqual: true}},
+		},
+		{
+			name:         "firing alerts",
+			cmd:          alertCmd{alertLevel: "firing"},
+			wantMatchers: []utils.Matcher{},
+			wantState:    "active",
+		},
+		{
+			name:         "default level",
+			cmd:          alertCmd{},
+			wantMatchers: []utils.Matcher{},
+		},
+		{
+			name:    "invalid level",
+			cmd:     alertCmd{alertLevel: "urgent"},
+			wantErr: `invalid alert level "urgent"`,
+		},
+		{
+			name:    "invalid matcher",
+			cmd:     alertCmd{matchers: []string{"severity"}},
+			wantErr: "invalid matcher",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, state, err := tt.cmd.alertFilter()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMatchers, filter.Matchers)
+			assert.Equal(t, tt.wantState, state)
+		})
+	}
+}
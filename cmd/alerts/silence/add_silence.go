@@ -1,34 +1,37 @@
 package silence
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
 	"strings"
+	"time"
 
 	"github.com/openshift/osdctl/cmd/alerts/utils"
-	"github.com/openshift/osdctl/cmd/common"
 	ocmutils "github.com/openshift/osdctl/pkg/utils"
+	"github.com/prometheus/common/model"
 	"github.com/spf13/cobra"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 )
 
 type addSilenceCmd struct {
-	clusterID string
-	alertID   []string
-	duration  string
-	comment   string
-	all       bool
-	reason    string
+	clusterID       string
+	alertID         []string
+	matchers        []string
+	duration        string
+	comment         string
+	all             bool
+	reason          string
+	alertmanagerURL string
 }
 
 func NewCmdAddSilence() *cobra.Command {
 	addSilenceCmd := &addSilenceCmd{}
 	cmd := &cobra.Command{
-		Use:   "add --cluster-id <cluster-identifier> [--all --duration --comment | --alertname --duration --comment]",
+		Use:   "add --cluster-id <cluster-identifier> [--all --duration --comment | --alertname --duration --comment | --matcher --duration --comment]",
 		Short: "Add new silence for alert",
-		Long:  `Add a new silence for a specific alert or for all alerts, including a comment and duration.`,
+		Long: `Add a new silence for a specific alert or for all alerts, including a comment and duration.
+
+  Label matchers narrow down the silences: with --alertname or --all, every silence also
+  requires the matchers, otherwise a single silence is added for the alerts matching them.`,
 		Example: `  # Silence a specific alert
   osdctl alerts silence add --cluster-id ${CLUSTER_ID} --alertname "KubePodNotReady" --reason "${REASON}"
 
@@ -36,21 +39,26 @@ func NewCmdAddSilence() *cobra.Command {
   osdctl alerts silence add --cluster-id ${CLUSTER_ID} --all --reason "${REASON}"
 
   # Silence an alert with custom duration and comment
-  osdctl alerts silence add --cluster-id ${CLUSTER_ID} --alertname "KubePodNotReady" --duration 2h --comment "Investigating pod issue" --reason "${REASON}"`,
+  osdctl alerts silence add --cluster-id ${CLUSTER_ID} --alertname "KubePodNotReady" --duration 2h --comment "Investigating pod issue" --reason "${REASON}"
+
+  # Silence the warnings of the openshift-logging namespace
+  osdctl alerts silence add --cluster-id ${CLUSTER_ID} -m severity=warning -m namespace=openshift-logging --reason "${REASON}"`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
-		Run: func(cmd *cobra.Command, args []string) {
-			AddSilence(addSilenceCmd)
-
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return AddSilence(addSilenceCmd)
 		},
 	}
 
 	cmd.Flags().StringVarP(&addSilenceCmd.clusterID, "cluster-id", "C", "", "Provide the internal ID of the cluster")
 	cmd.Flags().StringSliceVar(&addSilenceCmd.alertID, "alertname", []string{}, "alertname (comma-separated)")
+	cmd.Flags().StringArrayVarP(&addSilenceCmd.matchers, "matcher", "m", []string{}, "Label matcher of the silence, e.g. severity=warning or namespace=~openshift-.* (can be repeated)")
 	cmd.Flags().StringVarP(&addSilenceCmd.comment, "comment", "c", "Adding silence using the osdctl alert command", "add comment about silence")
 	cmd.Flags().StringVarP(&addSilenceCmd.duration, "duration", "d", "15d", "Adding duration for silence as 15 days") //default duration set to 15 days
 	cmd.Flags().BoolVarP(&addSilenceCmd.all, "all", "a", false, "Adding silences for all alert")
 	cmd.Flags().StringVar(&addSilenceCmd.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usualy an OHSS or PD ticket)")
+	utils.AddAlertmanagerURLFlag(cmd, &addSilenceCmd.alertmanagerURL)
 
 	_ = cmd.MarkFlagRequired("cluster-id")
 	_ = cmd.MarkFlagRequired("reason")
@@ -58,114 +66,145 @@ func NewCmdAddSilence() *cobra.Command {
 	return cmd
 }
 
-func AddSilence(cmd *addSilenceCmd) {
-	clusterID := cmd.clusterID
-	alertID := cmd.alertID
-	comment := cmd.comment
-	duration := cmd.duration
-	all := cmd.all
+func AddSilence(cmd *addSilenceCmd) error {
+	request, err := newSilenceRequest(cmd.alertID, cmd.all, cmd.matchers, cmd.duration, cmd.comment)
+	if err != nil {
+		return err
+	}
 
-	username, clustername := GetUserAndClusterInfo(clusterID)
+	username, _ := GetUserAndClusterInfo(cmd.clusterID)
+	request.createdBy = username
 
 	elevationReasons := []string{
 		cmd.reason,
 		"Add alert silence via osdctl",
 	}
-
-	_, kubeconfig, clientset, err := common.GetKubeConfigAndClient(clusterID, elevationReasons...)
+	client, stop, err := utils.ConnectToAlertmanager(cmd.clusterID, cmd.alertmanagerURL, elevationReasons...)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	defer stop()
 
-	if all {
-		err := AddAllSilence(clusterID, duration, comment, username, clustername, kubeconfig, clientset)
-		if err != nil {
-			fmt.Printf("Failed to add silence: %s", err)
-		}
-	} else if len(alertID) > 0 {
-		err := AddAlertNameSilence(alertID, duration, comment, username, kubeconfig, clientset)
-		if err != nil {
-			fmt.Printf("Failed to add silence: %s", err)
-		}
-	} else {
-		fmt.Println("No valid option specified. Use --all or --alertname.")
+	if err := addSilences(context.Background(), client, request); err != nil {
+		return fmt.Errorf("failed to add silence: %w", err)
 	}
+	return nil
 }
 
-func AddAllSilence(clusterID, duration, comment, username, clustername string, kubeconfig *rest.Config, clientset *kubernetes.Clientset) error {
-	alerts := fetchAllAlerts(kubeconfig, clientset)
-	for _, alert := range alerts {
-		if alert.Labels.Alertname == "Watchdog" {
-			fmt.Println("Skipping Watchdog alert")
-			continue
-		}
-
-		addCmd := []string{
-			"amtool",
-			"silence",
-			"add",
-			"alertname=" + alert.Labels.Alertname,
-			"--alertmanager.url=" + utils.LocalHostUrl,
-			"--duration=" + duration,
-			"--comment=" + comment,
-		}
-
-		output, err := utils.ExecInAlertManagerPod(kubeconfig, clientset, addCmd)
-		if err != nil {
-			log.Fatal("Exiting the program")
-			return fmt.Errorf("Failed to exec in AlertManager pod: %w", err)
-		}
+// silenceRequest describes the silences to add to a cluster
+type silenceRequest struct {
+	alertnames []string
+	all        bool
+	matchers   []utils.Matcher
+	duration   time.Duration
+	comment    string
+	createdBy  string
+}
 
-		formattedOutput := strings.Replace(output, "\n", "", -1)
+// newSilenceRequest validates the flags describing the silences to add
+func newSilenceRequest(alertnames []string, all bool, matcherFlags []string, duration string, comment string) (silenceRequest, error) {
+	request := silenceRequest{alertnames: alertnames, all: all, comment: comment}
 
-		fmt.Printf("Alert %s has been silenced with id \"%s\" for a duration of %s by user \"%s\" \n", alert.Labels.Alertname, formattedOutput, duration, username)
+	var err error
+	if request.duration, err = parseSilenceDuration(duration); err != nil {
+		return request, err
 	}
-
-	return nil
+	if request.matchers, err = utils.ParseMatchers(matcherFlags); err != nil {
+		return request, err
+	}
+	if !all && len(alertnames) == 0 && len(request.matchers) == 0 {
+		return request, fmt.Errorf("no valid option specified, use --all, --alertname or --matcher")
+	}
+	return request, nil
 }
 
-func fetchAllAlerts(kubeconfig *rest.Config, clientset *kubernetes.Clientset) []utils.Alert {
-	var fetchedAlerts []utils.Alert
-
-	listAlertCmd := []string{"amtool", "--alertmanager.url", utils.LocalHostUrl, "alert", "-o", "json"}
-	output, err := utils.ExecInAlertManagerPod(kubeconfig, clientset, listAlertCmd)
+// parseSilenceDuration parses a positive duration, which may be written in days or weeks such
+// as 15d, like amtool does
+func parseSilenceDuration(value string) (time.Duration, error) {
+	duration, err := model.ParseDuration(value)
 	if err != nil {
-		log.Fatal(err)
+		return 0, fmt.Errorf("invalid duration %q: %w", value, err)
 	}
-
-	err = json.Unmarshal([]byte(output), &fetchedAlerts)
-	if err != nil {
-		log.Fatal("Error in unmarshaling the alerts", err)
+	if duration <= 0 {
+		return 0, fmt.Errorf("duration must be positive, got %q", value)
 	}
-
-	return fetchedAlerts
+	return time.Duration(duration), nil
 }
 
-func AddAlertNameSilence(alertID []string, duration, comment, username string, kubeconfig *rest.Config, clientset *kubernetes.Clientset) error {
-	for _, alertname := range alertID {
-		addCmd := []string{
-			"amtool",
-			"silence",
-			"add",
-			"alertname=" + alertname,
-			"--alertmanager.url=" + utils.LocalHostUrl,
-			"--duration=" + duration,
-			"--comment=" + comment,
+// silences returns the silences to post: one per alert name given or, with --all, firing,
+// or a single one for the matchers
+func (r silenceRequest) silences(ctx context.Context, client *utils.AlertmanagerClient, now time.Time) ([]utils.PostableSilence, error) {
+	alertnames := r.alertnames
+	if r.all {
+		alerts, err := client.ListAlerts(ctx, utils.AlertFilter{Matchers: r.matchers})
+		if err != nil {
+			return nil, err
 		}
+		alertnames = nil
+		seen := map[string]bool{}
+		for _, alert := range alerts {
+			if alert.Name() == "Watchdog" {
+				fmt.Println("Skipping Watchdog alert")
+				continue
+			}
+			if !seen[alert.Name()] {
+				seen[alert.Name()] = true
+				alertnames = append(alertnames, alert.Name())
+			}
+		}
+	}
 
-		output, err := utils.ExecInAlertManagerPod(kubeconfig, clientset, addCmd)
-		if err != nil {
-			return fmt.Errorf("failed to exec in AlertManager pod: %w", err)
+	newSilence := func(matchers []utils.Matcher) utils.PostableSilence {
+		return utils.PostableSilence{
+			Matchers:  matchers,
+			Comment:   r.comment,
+			CreatedBy: r.createdBy,
+			StartsAt:  now,
+			EndsAt:    now.Add(r.duration),
 		}
+	}
 
-		formattedOutput := strings.Replace(output, "\n", "", -1)
+	if len(alertnames) == 0 && !r.all {
+		return []utils.PostableSilence{newSilence(r.matchers)}, nil
+	}
+	var silences []utils.PostableSilence
+	for _, alertname := range alertnames {
+		matchers := append([]utils.Matcher{utils.AlertnameMatcher(alertname)}, r.matchers...)
+		silences = append(silences, newSilence(matchers))
+	}
+	return silences, nil
+}
 
-		fmt.Printf("Alert %s has been silenced with id \"%s\" for duration of %s by user \"%s\" \n", alertname, formattedOutput, duration, username)
+// addSilences posts the requested silences to the Alertmanager
+func addSilences(ctx context.Context, client *utils.AlertmanagerClient, request silenceRequest) error {
+	silences, err := request.silences(ctx, client, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if len(silences) == 0 {
+		fmt.Println("No alerts to silence")
+		return nil
 	}
 
+	for _, silence := range silences {
+		id, err := client.PostSilence(ctx, silence)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Alerts matching %s have been silenced with id \"%s\" for a duration of %s by user \"%s\" \n", formatMatchers(silence.Matchers), id, request.duration, request.createdBy)
+	}
 	return nil
 }
 
+// formatMatchers returns matchers as written on the command line
+func formatMatchers(matchers []utils.Matcher) string {
+	formatted := make([]string, len(matchers))
+	for i, matcher := range matchers {
+		formatted[i] = matcher.String()
+	}
+	return strings.Join(formatted, ",")
+}
+
 // Get User name and clustername
 func GetUserAndClusterInfo(clusterid string) (string, string) {
 	connection, err := ocmutils.CreateConnection()
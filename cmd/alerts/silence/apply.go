@@ -0,0 +1,482 @@
+package silence
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/cmd/alerts/utils"
+	orgutils "github.com/openshift/osdctl/cmd/org"
+	"github.com/openshift/osdctl/pkg/output"
+	ocmutils "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// Outcomes of applying a policy to a cluster
+const (
+	policyResultPlanned   = "planned"
+	policyResultApplied   = "applied"
+	policyResultUnchanged = "unchanged"
+	policyResultFailed    = "failed"
+)
+
+// clusterSearchSize is the number of cluster IDs searched per OCM query
+const clusterSearchSize = 100
+
+type applySilenceCmd struct {
+	filename string
+	reason   string
+	dryRun   bool
+	output   output.Options
+}
+
+// policyReport is the plan of a policy and the outcome on every cluster, printed by the
+// structured output formats
+type policyReport struct {
+	Policy  string         `json:"policy"`
+	Plan    []policyAction `json:"plan"`
+	Results []policyResult `json:"results"`
+}
+
+// policyResult is the outcome of applying a policy to a cluster
+type policyResult struct {
+	ClusterID   string `json:"cluster_id"`
+	ClusterName string `json:"cluster_name"`
+	Result      string `json:"result"`
+	Error       string `json:"error,omitempty"`
+}
+
+func NewCmdApplySilence() *cobra.Command {
+	applyCmd := &applySilenceCmd{}
+	cmd := &cobra.Command{
+		Use:   "apply -f <policy file>",
+		Short: "Apply a silence policy to a fleet of clusters",
+		Long: `Apply a silence policy to the clusters it targets.
+
+  A policy describes a silence and the clusters to keep it on:
+
+    name: logging-upgrade
+    comment: "OHSS-1234: logging stack upgrade"
+    duration: 7d
+    matchers:
+      - namespace=openshift-logging
+      - severity=~warning|info
+    targets:
+      clusterIDs: [...]
+      query: "product.id = 'rosa' and region.id = 'us-east-1'"
+      orgID: ...
+
+  The clusters targeted are the union of the cluster IDs, the clusters matching the OCM
+  search query and the active clusters of the organization.
+
+  The plan of the silences each cluster gains, keeps or loses is printed before applying it.
+  The silences of a policy are recognized by the policy name in their comment: applying a
+  policy again keeps its silences, refreshes their end, and expires those whose matchers no
+  longer match the policy. Setting 'state: absent' expires all the silences of the policy.
+
+  The clusters a policy is applied to are recorded in the user cache directory, and the
+  clusters removed from its targets lose its silences the next time it is applied. This
+  history is local: to remove clusters from a policy last applied from another machine,
+  apply it with 'state: absent' to these clusters first.`,
+		Example: `  # Show the plan of a policy
+  osdctl alerts silence apply -f policy.yaml --dry-run --reason "${REASON}"
+
+  # Apply a policy
+  osdctl alerts silence apply -f policy.yaml --reason "${REASON}"`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ApplySilencePolicy(applyCmd)
+		},
+	}
+
+	cmd.Flags().StringVarP(&applyCmd.filename, "filename", "f", "", "Silence policy file")
+	cmd.Flags().BoolVar(&applyCmd.dryRun, "dry-run", false, "Only print the plan")
+	cmd.Flags().StringVar(&applyCmd.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usualy an OHSS or PD ticket)")
+	applyCmd.output.AddFlags(cmd)
+	_ = cmd.MarkFlagRequired("filename")
+	_ = cmd.MarkFlagRequired("reason")
+
+	return cmd
+}
+
+func ApplySilencePolicy(cmd *applySilenceCmd) error {
+	if err := cmd.output.Validate(); err != nil {
+		return err
+	}
+	policy, err := readSilencePolicy(cmd.filename)
+	if err != nil {
+		return err
+	}
+
+	connection, err := ocmutils.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := connection.Close(); err != nil {
+			fmt.Println("Error closing connection:", err)
+		}
+	}()
+
+	clusters, err := policyClusters(connection, policy.Targets)
+	if err != nil {
+		return err
+	}
+	historyPath, err := policyHistoryPath(policy.Name)
+	if err != nil {
+		return err
+	}
+	history, err := readPolicyHistory(historyPath)
+	if err != nil {
+		return err
+	}
+	// The clusters no longer targeted are planned too, to expire the silences of the policy
+	removed := map[string]bool{}
+	if removedIDs := history.removed(targetedClusterIDs(clusters)); len(removedIDs) > 0 {
+		removedClusters, err := clustersByID(connection, removedIDs)
+		if err != nil {
+			return fmt.Errorf("failed to search for the clusters removed from the policy: %w", err)
+		}
+		for _, cluster := range removedClusters {
+			removed[cluster.ID()] = true
+		}
+		clusters = append(clusters, removedClusters...)
+	}
+	if len(clusters) == 0 {
+		return fmt.Errorf("the policy targets no clusters")
+	}
+	account, err := connection.AccountsMgmt().V1().CurrentAccount().Get().Send()
+	if err != nil {
+		return fmt.Errorf("failed to get the current account: %w", err)
+	}
+	createdBy := account.Body().Username()
+
+	elevationReasons := []string{
+		cmd.reason,
+		fmt.Sprintf("Apply alertmanager silence policy %s via osdctl", policy.Name),
+	}
+	applier := &policyApplier{
+		policy:    policy,
+		createdBy: createdBy,
+		removed:   removed,
+		connect: func(clusterID string) (*utils.AlertmanagerClient, func(), error) {
+			return utils.ConnectToAlertmanager(clusterID, "", elevationReasons...)
+		},
+	}
+
+	ctx := context.Background()
+	fmt.Fprintf(os.Stderr, "Planning policy %s on %d clusters\n", policy.Name, len(clusters))
+	actions, results := applier.Plan(ctx, clusters)
+
+	// The plan is reviewed before applying it, structured formats print it with the results
+	plan := output.NewTable(actions, policyActionColumns, policyActionRow)
+	planOptions, planOutput := cmd.output, os.Stdout
+	if cmd.output.IsStructured() {
+		planOptions, planOutput = output.Options{}, os.Stderr
+	}
+	if err := planOptions.Print(planOutput, plan); err != nil {
+		return err
+	}
+
+	changes := 0
+	for _, action := range actions {
+		if action.Action != policyActionUnchanged {
+			changes++
+		}
+	}
+	if !cmd.dryRun {
+		if changes == 0 {
+			fmt.Fprintln(os.Stderr, "No changes to apply")
+		} else {
+			fmt.Fprintf(os.Stderr, "Apply %d changes?\n", changes)
+			if !ocmutils.ConfirmPrompt() {
+				return nil
+			}
+			results = applier.Apply(ctx, clusters, results)
+		}
+		if err := history.Save(applier.appliedClusters(results)); err != nil {
+			return err
+		}
+	}
+
+	return printPolicyReport(cmd.output, policyReport{Policy: policy.Name, Plan: actions, Results: results})
+}
+
+// printPolicyReport prints the clusters on which the policy failed, or the whole report with the
+// structured formats, and returns an error if the policy failed on some clusters
+func printPolicyReport(out output.Options, report policyReport) error {
+	failed := []policyResult{}
+	for _, result := range report.Results {
+		if result.Result == policyResultFailed {
+			failed = append(failed, result)
+		}
+	}
+
+	if out.IsStructured() {
+		if err := out.Print(os.Stdout, output.NewObject(report, "")); err != nil {
+			return err
+		}
+	} else if len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "\nFailed on %d of %d clusters:\n", len(failed), len(report.Results))
+		if err := out.Print(os.Stdout, output.NewTable(failed, policyResultColumns, policyResultRow)); err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("the policy failed on %d of %d clusters", len(failed), len(report.Results))
+	}
+	return nil
+}
+
+// policyApplier plans and applies a policy on clusters, one cluster at a time
+type policyApplier struct {
+	policy    *silencePolicy
+	createdBy string
+	// removed are the clusters no longer targeted by the policy, which lose all its silences
+	removed map[string]bool
+	// connect returns a client of the Alertmanager of a cluster, and a function closing it
+	connect func(clusterID string) (*utils.AlertmanagerClient, func(), error)
+	now     func() time.Time
+}
+
+func (a *policyApplier) time() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+	return time.Now().UTC()
+}
+
+// clusterPolicy returns the policy to apply on a cluster, absent if the cluster is no longer
+// targeted
+func (a *policyApplier) clusterPolicy(clusterID string) *silencePolicy {
+	if !a.removed[clusterID] {
+		return a.policy
+	}
+	absent := *a.policy
+	absent.State = policyStateAbsent
+	return &absent
+}
+
+// appliedClusters returns the clusters which may keep silences of the policy: the clusters
+// targeted by a present policy, and the clusters it failed on, to retry them on the next apply
+func (a *policyApplier) appliedClusters(results []policyResult) []string {
+	clusterIDs := []string{}
+	for _, result := range results {
+		targeted := a.policy.State != policyStateAbsent && !a.removed[result.ClusterID]
+		if targeted || result.Result == policyResultFailed {
+			clusterIDs = append(clusterIDs, result.ClusterID)
+		}
+	}
+	return clusterIDs
+}
+
+// Plan returns the actions planned on every cluster, and the clusters which could not be
+// planned as failed results
+func (a *policyApplier) Plan(ctx context.Context, clusters []*cmv1.Cluster) ([]policyAction, []policyResult) {
+	actions := []policyAction{}
+	results := []policyResult{}
+	for _, cluster := range clusters {
+		clusterActions, err := a.planCluster(ctx, cluster)
+		result := policyResult{ClusterID: cluster.ID(), ClusterName: cluster.Name(), Result: policyResultPlanned}
+		if err != nil {
+			result.Result = policyResultFailed
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+		actions = append(actions, clusterActions...)
+	}
+	return actions, results
+}
+
+func (a *policyApplier) planCluster(ctx context.Context, cluster *cmv1.Cluster) ([]policyAction, error) {
+	client, stop, err := a.connect(cluster.ID())
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	silences, err := client.ListSilences(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return a.clusterPolicy(cluster.ID()).plan(cluster.ID(), cluster.Name(), silences, a.createdBy, a.time()), nil
+}
+
+// Apply applies the policy on the clusters which could be planned. The plan of every cluster is
+// computed again right before it is applied, so silences changed in between are not clobbered.
+func (a *policyApplier) Apply(ctx context.Context, clusters []*cmv1.Cluster, planned []policyResult) []policyResult {
+	plannedFailed := map[string]policyResult{}
+	for _, result := range planned {
+		if result.Result == policyResultFailed {
+			plannedFailed[result.ClusterID] = result
+		}
+	}
+
+	results := []policyResult{}
+	for _, cluster := range clusters {
+		if result, ok := plannedFailed[cluster.ID()]; ok {
+			results = append(results, result)
+			continue
+		}
+		result := policyResult{ClusterID: cluster.ID(), ClusterName: cluster.Name()}
+		changed, err := a.applyCluster(ctx, cluster)
+		switch {
+		case err != nil:
+			result.Result = policyResultFailed
+			result.Error = err.Error()
+			fmt.Fprintf(os.Stderr, "Failed to apply the policy on cluster %s: %v\n", cluster.ID(), err)
+		case changed:
+			result.Result = policyResultApplied
+		default:
+			result.Result = policyResultUnchanged
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// applyCluster applies the policy on a cluster, and reports whether its silences changed
+func (a *policyApplier) applyCluster(ctx context.Context, cluster *cmv1.Cluster) (bool, error) {
+	client, stop, err := a.connect(cluster.ID())
+	if err != nil {
+		return false, err
+	}
+	defer stop()
+
+	silences, err := client.ListSilences(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, action := range a.clusterPolicy(cluster.ID()).plan(cluster.ID(), cluster.Name(), silences, a.createdBy, a.time()) {
+		switch action.Action {
+		case policyActionAdd:
+			id, err := client.PostSilence(ctx, action.silence)
+			if err != nil {
+				return changed, err
+			}
+			fmt.Fprintf(os.Stderr, "Cluster %s: silence %s added for %s\n", cluster.ID(), id, action.Matchers)
+		case policyActionUpdate:
+			id, err := client.PostSilence(ctx, action.silence)
+			if err != nil {
+				return changed, err
+			}
+			fmt.Fprintf(os.Stderr, "Cluster %s: silence %s updated, ending at %s\n", cluster.ID(), id, action.EndsAt.Format(time.RFC3339))
+		case policyActionExpire:
+			if err := client.ExpireSilence(ctx, action.SilenceID); err != nil {
+				return changed, err
+			}
+			fmt.Fprintf(os.Stderr, "Cluster %s: silence %s expired\n", cluster.ID(), action.SilenceID)
+		default:
+			continue
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// policyClusters returns the clusters targeted by a policy
+func policyClusters(connection *sdk.Connection, targets policyTargets) ([]*cmv1.Cluster, error) {
+	var queries []string
+	for _, clusterID := range targets.ClusterIDs {
+		if err := ocmutils.IsValidClusterKey(clusterID); err != nil {
+			return nil, err
+		}
+		queries = append(queries, ocmutils.GenerateQuery(clusterID))
+	}
+	if targets.Query != "" {
+		queries = append(queries, fmt.Sprintf("(%s)", targets.Query))
+	}
+	var orgClusters []*cmv1.Cluster
+	if targets.OrgID != "" {
+		subscriptions, err := orgutils.SearchAllSubscriptionsByOrg(targets.OrgID, orgutils.StatusActive, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the clusters of organization %s: %w", targets.OrgID, err)
+		}
+		var clusterIDs []string
+		for _, subscription := range subscriptions {
+			if subscription.ClusterID() != "" {
+				clusterIDs = append(clusterIDs, subscription.ClusterID())
+			}
+		}
+		orgClusters, err = clustersByID(connection, clusterIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for the clusters of organization %s: %w", targets.OrgID, err)
+		}
+	}
+	if len(queries) == 0 {
+		return orgClusters, nil
+	}
+
+	clusters, err := ocmutils.ApplyFilters(connection, []string{strings.Join(queries, " or ")})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for the clusters of the policy: %w", err)
+	}
+	// The organization clusters can also be targeted by ID or by the query
+	found := map[string]bool{}
+	for _, cluster := range clusters {
+		found[cluster.ID()] = true
+	}
+	for _, cluster := range orgClusters {
+		if !found[cluster.ID()] {
+			clusters = append(clusters, cluster)
+		}
+	}
+	return clusters, nil
+}
+
+// clustersByID returns the clusters with the given IDs, searching them by batches to keep the
+// searches reasonably short for large organizations
+func clustersByID(connection *sdk.Connection, clusterIDs []string) ([]*cmv1.Cluster, error) {
+	var clusters []*cmv1.Cluster
+	for start := 0; start < len(clusterIDs); start += clusterSearchSize {
+		end := min(start+clusterSearchSize, len(clusterIDs))
+		found, err := ocmutils.ApplyFilters(connection, []string{ocmutils.GenerateIDQuery(clusterIDs[start:end])})
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, found...)
+	}
+	return clusters, nil
+}
+
+// targetedClusterIDs returns the IDs of the targeted clusters
+func targetedClusterIDs(clusters []*cmv1.Cluster) []string {
+	ids := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		ids = append(ids, cluster.ID())
+	}
+	return ids
+}
+
+var policyActionColumns = []output.Column{
+	{Name: "CLUSTER ID"},
+	{Name: "CLUSTER NAME"},
+	{Name: "ACTION"},
+	{Name: "SILENCE ID"},
+	{Name: "MATCHERS"},
+	{Name: "ENDS AT"},
+}
+
+func policyActionRow(action policyAction) []string {
+	return []string{action.ClusterID, action.ClusterName, action.Action, action.SilenceID, action.Matchers, action.EndsAt.UTC().Format(time.RFC3339)}
+}
+
+var policyResultColumns = []output.Column{
+	{Name: "CLUSTER ID"},
+	{Name: "CLUSTER NAME"},
+	{Name: "RESULT"},
+	{Name: "ERROR"},
+}
+
+func policyResultRow(result policyResult) []string {
+	return []string{result.ClusterID, result.ClusterName, result.Result, result.Error}
+}
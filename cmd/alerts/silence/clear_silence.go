@@ -1,22 +1,19 @@
 package silence
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"strings"
 
 	"github.com/openshift/osdctl/cmd/alerts/utils"
-	"github.com/openshift/osdctl/cmd/common"
 	"github.com/spf13/cobra"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 )
 
 type silenceCmd struct {
-	clusterID  string
-	silenceIDs []string
-	all        bool
-	reason     string
+	clusterID       string
+	silenceIDs      []string
+	all             bool
+	reason          string
+	alertmanagerURL string
 }
 
 func NewCmdClearSilence() *cobra.Command {
@@ -32,9 +29,9 @@ func NewCmdClearSilence() *cobra.Command {
   osdctl alerts silence expire --cluster-id ${CLUSTER_ID} --all --reason "${REASON}"`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
-		Run: func(cmd *cobra.Command, args []string) {
-
-			ClearSilence(silenceCmd)
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ClearSilence(silenceCmd)
 		},
 	}
 
@@ -42,6 +39,7 @@ func NewCmdClearSilence() *cobra.Command {
 	cmd.Flags().StringSliceVar(&silenceCmd.silenceIDs, "silence-id", []string{}, "silence id (comma-separated)")
 	cmd.Flags().BoolVarP(&silenceCmd.all, "all", "a", false, "clear all silences")
 	cmd.Flags().StringVar(&silenceCmd.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usualy an OHSS or PD ticket)")
+	utils.AddAlertmanagerURLFlag(cmd, &silenceCmd.alertmanagerURL)
 
 	_ = cmd.MarkFlagRequired("cluster-id")
 	_ = cmd.MarkFlagRequired("reason")
@@ -49,95 +47,69 @@ func NewCmdClearSilence() *cobra.Command {
 	return cmd
 }
 
-func ClearSilence(cmd *silenceCmd) {
-	clusterID := cmd.clusterID
-	silenceIDs := cmd.silenceIDs
-	all := cmd.all
-
+func ClearSilence(cmd *silenceCmd) error {
 	elevationReasons := []string{
 		cmd.reason,
 		"Clear alertmanager silence for a cluster via osdctl",
 	}
-
-	_, kubeconfig, clientset, err := common.GetKubeConfigAndClient(clusterID, elevationReasons...)
+	client, stop, err := utils.ConnectToAlertmanager(cmd.clusterID, cmd.alertmanagerURL, elevationReasons...)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	defer stop()
 
-	if all {
-		ClearAllSilence(kubeconfig, clientset)
-	} else if len(silenceIDs) > 0 {
-		ClearSilenceByID(silenceIDs, kubeconfig, clientset)
-	} else {
+	ctx := context.Background()
+	if len(cmd.silenceIDs) > 0 && !cmd.all {
+		return ClearSilenceByID(ctx, client, cmd.silenceIDs)
+	}
+	if !cmd.all {
 		fmt.Println("No valid option specified. Using a default option to clear all silences")
-		ClearAllSilence(kubeconfig, clientset)
 	}
+	return ClearAllSilence(ctx, client)
 }
 
-func ClearAllSilence(kubeconfig *rest.Config, clientset *kubernetes.Clientset) {
-	queryCmd := []string{
-		"amtool",
-		"silence",
-		"query",
-		"-q",
-		"--alertmanager.url=" + utils.LocalHostUrl,
-	}
-
-	queryOutput, err := utils.ExecInAlertManagerPod(kubeconfig, clientset, queryCmd)
+// ClearAllSilence expires all active and pending silences
+func ClearAllSilence(ctx context.Context, client *utils.AlertmanagerClient) error {
+	silences, err := client.ListSilences(ctx, nil)
 	if err != nil {
-		fmt.Println("Error encountered while expiring all silence:", err)
-		return
+		return err
 	}
 
-	formattedOutput := strings.Join(strings.Fields(queryOutput), " ")
-	if formattedOutput == " " || formattedOutput == "" {
+	var silenceIDs []string
+	for _, silence := range silences {
+		if silence.Status.State != utils.SilenceStateExpired {
+			silenceIDs = append(silenceIDs, silence.ID)
+		}
+	}
+	if len(silenceIDs) == 0 {
 		fmt.Println("No Silence has been set for alerts, please create new silence")
-		return
+		return nil
 	}
 
-	finalOutput := strings.Fields(formattedOutput)
-	countsilence := len(finalOutput)
-	for _, silence := range finalOutput {
-		clearCmd := []string{
-			"amtool",
-			"silence",
-			"expire",
-			silence,
-			"--alertmanager.url=" + utils.LocalHostUrl,
-		}
-
-		countsilence = countsilence - 1
-		_, err := utils.ExecInAlertManagerPod(kubeconfig, clientset, clearCmd)
-		if err != nil {
-			log.Printf("Error expiring silence ID \"%s\" : %v\n", silence, err)
-			return
-		}
-
-		fmt.Printf("SilenceID \"%s\" expired successfully.\n", silence)
-		if countsilence == 0 {
-			fmt.Println()
-			fmt.Printf("All SilenceID expired successfully.\n")
-			return
+	for _, silenceID := range silenceIDs {
+		if err := client.ExpireSilence(ctx, silenceID); err != nil {
+			return err
 		}
+		fmt.Printf("SilenceID \"%s\" expired successfully.\n", silenceID)
 	}
+	fmt.Println()
+	fmt.Printf("All SilenceID expired successfully.\n")
+	return nil
 }
 
-func ClearSilenceByID(silenceIDs []string, kubeconfig *rest.Config, clientset *kubernetes.Clientset) {
-	for _, silenceId := range silenceIDs {
-		clearCmd := []string{
-			"amtool",
-			"silence",
-			"expire",
-			silenceId,
-			"--alertmanager.url=" + utils.LocalHostUrl,
-		}
-
-		_, err := utils.ExecInAlertManagerPod(kubeconfig, clientset, clearCmd)
-		if err != nil {
-			log.Printf("Error expiring silence ID \"%s\" %v\n", silenceId, err)
+// ClearSilenceByID expires the given silences, and returns an error if any could not be expired
+func ClearSilenceByID(ctx context.Context, client *utils.AlertmanagerClient, silenceIDs []string) error {
+	failed := 0
+	for _, silenceID := range silenceIDs {
+		if err := client.ExpireSilence(ctx, silenceID); err != nil {
+			fmt.Printf("Error expiring silence ID \"%s\": %v\n", silenceID, err)
+			failed++
 			continue
 		}
-
-		fmt.Printf("Requested SilenceID \"%s\" expired successfully.\n", silenceId)
+		fmt.Printf("Requested SilenceID \"%s\" expired successfully.\n", silenceID)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to expire %d of %d silences", failed, len(silenceIDs))
 	}
+	return nil
 }
@@ -8,7 +8,7 @@ import (
 func NewCmdSilence() *cobra.Command {
 	silenceCmd := &cobra.Command{
 		Use:               "silence",
-		Short:             "add, expire and list silence associated with alerts",
+		Short:             "add, update, extend, expire and list silence associated with alerts",
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 	}
@@ -16,7 +16,10 @@ func NewCmdSilence() *cobra.Command {
 	silenceCmd.AddCommand(NewCmdAddSilence())
 	silenceCmd.AddCommand(NewCmdClearSilence())
 	silenceCmd.AddCommand(NewCmdListSilence())
+	silenceCmd.AddCommand(NewCmdUpdateSilence())
+	silenceCmd.AddCommand(NewCmdExtendSilence())
 	silenceCmd.AddCommand(NewCmdAddOrgSilence())
+	silenceCmd.AddCommand(NewCmdApplySilence())
 
 	return silenceCmd
 }
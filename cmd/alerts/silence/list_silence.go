@@ -1,18 +1,23 @@
 package silence
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
+	"os"
+	"time"
 
 	"github.com/openshift/osdctl/cmd/alerts/utils"
-	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/output"
 	"github.com/spf13/cobra"
 )
 
 type listSilenceCmd struct {
-	clusterID string
-	reason    string
+	clusterID       string
+	reason          string
+	matchers        []string
+	expired         bool
+	alertmanagerURL string
+	output          output.Options
 }
 
 func NewCmdListSilence() *cobra.Command {
@@ -22,67 +27,84 @@ func NewCmdListSilence() *cobra.Command {
 		Short: "List all silences",
 		Long:  `print the list of silences`,
 		Example: `  # List all active silences for a cluster
-  osdctl alerts silence list --cluster-id ${CLUSTER_ID} --reason "${REASON}"`,
+  osdctl alerts silence list --cluster-id ${CLUSTER_ID} --reason "${REASON}"
+
+  # List the silences of an alert, including expired ones, as JSON
+  osdctl alerts silence list --cluster-id ${CLUSTER_ID} -m alertname=KubePodNotReady --expired -o json --reason "${REASON}"`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
-		Run: func(cmd *cobra.Command, args []string) {
-			ListSilence(listSilenceCmd)
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ListSilence(listSilenceCmd)
 		},
 	}
 	cmd.Flags().StringVarP(&listSilenceCmd.clusterID, "cluster-id", "C", "", "Provide the internal ID of the cluster")
 	cmd.Flags().StringVar(&listSilenceCmd.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usualy an OHSS or PD ticket)")
+	cmd.Flags().StringArrayVarP(&listSilenceCmd.matchers, "matcher", "m", []string{}, "Only list silences having this label matcher, e.g. alertname=KubePodNotReady (can be repeated)")
+	cmd.Flags().BoolVar(&listSilenceCmd.expired, "expired", false, "Include expired silences")
+	utils.AddAlertmanagerURLFlag(cmd, &listSilenceCmd.alertmanagerURL)
+	listSilenceCmd.output.AddFlags(cmd)
 	_ = cmd.MarkFlagRequired("cluster-id")
 	_ = cmd.MarkFlagRequired("reason")
 	return cmd
 }
 
-func ListSilence(cmd *listSilenceCmd) {
-	var silences []utils.Silence
-	silenceCmd := []string{"amtool", "silence", "--alertmanager.url", utils.LocalHostUrl, "-o", "json"}
-	elevationReasons := []string{
-		cmd.reason,
-		"Clear alertmanager silence for a cluster via osdctl",
+func ListSilence(cmd *listSilenceCmd) error {
+	if err := cmd.output.Validate(); err != nil {
+		return err
 	}
-
-	_, kubeconfig, clientset, err := common.GetKubeConfigAndClient(cmd.clusterID, elevationReasons...)
+	matchers, err := utils.ParseMatchers(cmd.matchers)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	op, err := utils.ExecInAlertManagerPod(kubeconfig, clientset, silenceCmd)
+	elevationReasons := []string{
+		cmd.reason,
+		"List alertmanager silences for a cluster via osdctl",
+	}
+	client, stop, err := utils.ConnectToAlertmanager(cmd.clusterID, cmd.alertmanagerURL, elevationReasons...)
 	if err != nil {
-		fmt.Println("Error encountered while listing the silences:", err)
-		return
+		return err
 	}
+	defer stop()
 
-	opSlice := []byte(op)
-	err = json.Unmarshal(opSlice, &silences)
+	silences, err := client.ListSilences(context.Background(), matchers)
 	if err != nil {
-		fmt.Println("Error in unmarshaling the data", err)
+		return err
 	}
 
-	fmt.Printf("Silence Information:\n")
-	if len(silences) > 0 {
-		for _, silence := range silences {
-			printSilence(silence)
+	selected := []utils.Silence{}
+	for _, silence := range silences {
+		if cmd.expired || silence.Status.State != utils.SilenceStateExpired {
+			selected = append(selected, silence)
 		}
-	} else {
+	}
+
+	if len(selected) == 0 && !cmd.output.IsStructured() {
 		fmt.Println("No silences found, all silence has been cleared.")
+		return nil
 	}
+	return cmd.output.Print(os.Stdout, output.NewTable(selected, silenceColumns, silenceRow))
+}
+
+var silenceColumns = []output.Column{
+	{Name: "ID"},
+	{Name: "STATE"},
+	{Name: "MATCHERS"},
+	{Name: "CREATED BY"},
+	{Name: "STARTS AT", Wide: true},
+	{Name: "ENDS AT"},
+	{Name: "COMMENT"},
 }
 
-func printSilence(silence utils.Silence) {
-	id, matchers, status, created, starts, end, comment := silence.ID, silence.Matchers, silence.Status, silence.CreatedBy, silence.StartsAt, silence.EndsAt, silence.Comment
-	fmt.Println("-------------------------------------------")
-	fmt.Printf("SilenceID: %s\n", id)
-	fmt.Printf("Status: %s\n", status.State)
-	fmt.Printf("Created By: %s\n", created)
-	fmt.Printf("Starts At: %s\n", starts)
-	fmt.Printf("Ends At: %s\n", end)
-	fmt.Printf("Comment: %s\n", comment)
-	fmt.Println("Matchers:")
-	for _, matcher := range matchers {
-		fmt.Printf("  %s: %s\n", matcher.Name, matcher.Value)
+func silenceRow(silence utils.Silence) []string {
+	return []string{
+		silence.ID,
+		silence.Status.State,
+		formatMatchers(silence.Matchers),
+		silence.CreatedBy,
+		silence.StartsAt.UTC().Format(time.RFC3339),
+		silence.EndsAt.UTC().Format(time.RFC3339),
+		silence.Comment,
 	}
-	fmt.Println("-------------------------------------------")
 }
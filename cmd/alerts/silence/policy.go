@@ -0,0 +1,271 @@
+package silence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/cmd/alerts/utils"
+	"sigs.k8s.io/yaml"
+)
+
+// States of a silence policy
+const (
+	policyStatePresent = "present"
+	policyStateAbsent  = "absent"
+)
+
+// Actions planned for the silences of a policy on a cluster
+const (
+	policyActionAdd       = "add"
+	policyActionUpdate    = "update"
+	policyActionExpire    = "expire"
+	policyActionUnchanged = "unchanged"
+)
+
+// policyRefreshMargin is how close to its intended end a policy silence may get before
+// re-applying the policy refreshes it
+const policyRefreshMargin = time.Hour
+
+var policyNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// silencePolicy describes a silence to keep on a set of clusters:
+//
+//	name: logging-upgrade
+//	comment: "OHSS-1234: logging stack upgrade"
+//	duration: 7d
+//	matchers:
+//	  - namespace=openshift-logging
+//	  - severity=~warning|info
+//	targets:
+//	  clusterIDs: [...]
+//	  query: "product.id = 'rosa' and region.id = 'us-east-1'"
+//	  orgID: ...
+//
+// The silences added for a policy are recognized by the policy name in their comment, which
+// makes applying a policy again idempotent.
+type silencePolicy struct {
+	Name     string        `json:"name"`
+	Comment  string        `json:"comment"`
+	Duration string        `json:"duration"`
+	Matchers []string      `json:"matchers"`
+	State    string        `json:"state,omitempty"`
+	Targets  policyTargets `json:"targets"`
+
+	matchers []utils.Matcher
+	duration time.Duration
+}
+
+// policyHistory records the clusters a policy was last applied to, so that applying it again
+// expires its silences on the clusters removed from its targets
+type policyHistory struct {
+	ClusterIDs []string `json:"clusterIDs"`
+
+	path string
+}
+
+// policyTargets selects the clusters of a policy, the union of all selectors
+type policyTargets struct {
+	ClusterIDs []string `json:"clusterIDs,omitempty"`
+	Query      string   `json:"query,omitempty"`
+	OrgID      string   `json:"orgID,omitempty"`
+}
+
+// policyAction is a change planned for the silences of a policy on a cluster
+type policyAction struct {
+	ClusterID   string    `json:"cluster_id"`
+	ClusterName string    `json:"cluster_name"`
+	Action      string    `json:"action"`
+	SilenceID   string    `json:"silence_id,omitempty"`
+	Matchers    string    `json:"matchers"`
+	EndsAt      time.Time `json:"ends_at"`
+
+	// silence is posted by the add and update actions
+	silence utils.PostableSilence
+}
+
+// readSilencePolicy reads and validates a policy file
+func readSilencePolicy(path string) (*silencePolicy, error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- Potential file inclusion via variable
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	return parseSilencePolicy(data)
+}
+
+func parseSilencePolicy(data []byte) (*silencePolicy, error) {
+	policy := &silencePolicy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	if !policyNameRegex.MatchString(policy.Name) {
+		return nil, fmt.Errorf("invalid policy name %q, names are made of lower case letters, digits and dashes", policy.Name)
+	}
+	if policy.State == "" {
+		policy.State = policyStatePresent
+	}
+	if policy.State != policyStatePresent && policy.State != policyStateAbsent {
+		return nil, fmt.Errorf("invalid policy state %q, valid states are: %s, %s", policy.State, policyStatePresent, policyStateAbsent)
+	}
+	if len(policy.Targets.ClusterIDs) == 0 && policy.Targets.Query == "" && policy.Targets.OrgID == "" {
+		return nil, fmt.Errorf("the policy has no targets, set targets.clusterIDs, targets.query or targets.orgID")
+	}
+	if policy.State == policyStateAbsent {
+		return policy, nil
+	}
+
+	if strings.TrimSpace(policy.Comment) == "" {
+		return nil, fmt.Errorf("the policy has no comment")
+	}
+	if len(policy.Matchers) == 0 {
+		return nil, fmt.Errorf("the policy has no matchers")
+	}
+	var err error
+	if policy.matchers, err = utils.ParseMatchers(policy.Matchers); err != nil {
+		return nil, err
+	}
+	if policy.duration, err = parseSilenceDuration(policy.Duration); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// marker tags the comment of the silences of the policy
+func (p *silencePolicy) marker() string {
+	return fmt.Sprintf("[osdctl-silence-policy=%s]", p.Name)
+}
+
+// owns reports whether a silence has been added for the policy
+func (p *silencePolicy) owns(silence utils.Silence) bool {
+	return strings.HasSuffix(silence.Comment, p.marker())
+}
+
+// plan returns the changes bringing the silences of a cluster in line with the policy:
+//   - a policy silence with the policy matchers is kept, and refreshed when its comment changed
+//     or it ends sooner than the policy duration from now
+//   - other policy silences are expired
+//   - a silence is added when no policy silence has the policy matchers
+func (p *silencePolicy) plan(clusterID string, clusterName string, silences []utils.Silence, createdBy string, now time.Time) []policyAction {
+	comment := strings.TrimSpace(p.Comment) + " " + p.marker()
+	endsAt := now.Add(p.duration)
+	wanted := matcherKey(p.matchers)
+
+	var actions []policyAction
+	kept := false
+	for _, silence := range silences {
+		if !p.owns(silence) || silence.Status.State == utils.SilenceStateExpired {
+			continue
+		}
+		action := policyAction{
+			ClusterID:   clusterID,
+			ClusterName: clusterName,
+			SilenceID:   silence.ID,
+			Matchers:    formatMatchers(silence.Matchers),
+			EndsAt:      silence.EndsAt,
+		}
+
+		if p.State == policyStateAbsent || kept || matcherKey(silence.Matchers) != wanted {
+			action.Action = policyActionExpire
+			actions = append(actions, action)
+			continue
+		}
+
+		kept = true
+		action.Action = policyActionUnchanged
+		if silence.Comment != comment || silence.EndsAt.Before(endsAt.Add(-policyRefreshMargin)) {
+			action.Action = policyActionUpdate
+			action.EndsAt = endsAt
+			action.silence = silence.Postable()
+			action.silence.Comment = comment
+			action.silence.EndsAt = endsAt
+		}
+		actions = append(actions, action)
+	}
+
+	if p.State == policyStatePresent && !kept {
+		actions = append(actions, policyAction{
+			ClusterID:   clusterID,
+			ClusterName: clusterName,
+			Action:      policyActionAdd,
+			Matchers:    formatMatchers(p.matchers),
+			EndsAt:      endsAt,
+			silence: utils.PostableSilence{
+				Matchers:  p.matchers,
+				Comment:   comment,
+				CreatedBy: createdBy,
+				StartsAt:  now,
+				EndsAt:    endsAt,
+			},
+		})
+	}
+	return actions
+}
+
+// matcherKey identifies a set of matchers regardless of their order
+func matcherKey(matchers []utils.Matcher) string {
+	keys := make([]string, len(matchers))
+	for i, matcher := range matchers {
+		keys[i] = matcher.String()
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ",")
+}
+
+// policyHistoryPath returns the history of a policy in the user cache directory
+func policyHistoryPath(name string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "osdctl", "alerts", "silence-policies", name+".json"), nil
+}
+
+// readPolicyHistory reads the history of a policy, which is empty if it was never applied
+func readPolicyHistory(path string) (*policyHistory, error) {
+	history := &policyHistory{path: path}
+	data, err := os.ReadFile(path) //#nosec G304 -- Potential file inclusion via variable
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the policy history: %w", err)
+	}
+	if err := json.Unmarshal(data, history); err != nil {
+		return nil, fmt.Errorf("invalid policy history %s: %w", path, err)
+	}
+	return history, nil
+}
+
+// Save records the clusters the policy is applied to
+func (h *policyHistory) Save(clusterIDs []string) error {
+	h.ClusterIDs = slices.Compact(slices.Sorted(slices.Values(clusterIDs)))
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create the policy history directory: %w", err)
+	}
+	if err := os.WriteFile(h.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write the policy history: %w", err)
+	}
+	return nil
+}
+
+// removed returns the clusters the policy was applied to which it no longer targets
+func (h *policyHistory) removed(targeted []string) []string {
+	var removed []string
+	for _, clusterID := range h.ClusterIDs {
+		if !slices.Contains(targeted, clusterID) {
+			removed = append(removed, clusterID)
+		}
+	}
+	return removed
+}
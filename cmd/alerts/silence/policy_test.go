@@ -0,0 +1,242 @@
+package silence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/cmd/alerts/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+name: logging-upgrade
+comment: "OHSS-1234: logging upgrade"
+duration: 7d
+matchers:
+  - namespace=openshift-logging
+  - severity=~warning|info
+targets:
+  clusterIDs: [cluster-1]
+`
+
+func TestParseSilencePolicy(t *testing.T) {
+	policy, err := parseSilencePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	assert.Equal(t, policyStatePresent, policy.State)
+	assert.Equal(t, 7*24*time.Hour, policy.duration)
+	assert.Len(t, policy.matchers, 2)
+
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr string
+	}{
+		{name: "unknown field", policy: testPolicy + "silenced: true\n", wantErr: "unknown field"},
+		{name: "invalid name", policy: strings.Replace(testPolicy, "logging-upgrade", "Logging Upgrade", 1), wantErr: "invalid policy name"},
+		{name: "no targets", policy: strings.Replace(testPolicy, "clusterIDs: [cluster-1]", "{}", 1), wantErr: "the policy has no targets"},
+		{name: "invalid matcher", policy: strings.Replace(testPolicy, "namespace=openshift-logging", "namespace", 1), wantErr: "invalid matcher"},
+		{name: "invalid state", policy: testPolicy + "state: gone\n", wantErr: "invalid policy state"},
+		{name: "missing duration", policy: strings.Replace(testPolicy, "duration: 7d", "", 1), wantErr: "invalid duration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSilencePolicy([]byte(tt.policy))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	// Removing the silences of a policy only needs its name and targets
+	_, err = parseSilencePolicy([]byte("name: logging-upgrade\nstate: absent\ntargets:\n  orgID: org-1\n"))
+	assert.NoError(t, err)
+}
+
+func TestPolicyPlan(t *testing.T) {
+	policy, err := parseSilencePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	comment := "OHSS-1234: logging upgrade [osdctl-silence-policy=logging-upgrade]"
+
+	// The matchers of the policy, in another order
+	current := utils.Silence{
+		ID:       "current",
+		Matchers: []utils.Matcher{policy.matchers[1], policy.matchers[0]},
+		Status:   utils.SilenceStatus{State: utils.SilenceStateActive},
+		Comment:  comment,
+		EndsAt:   now.Add(7 * 24 * time.Hour),
+	}
+	ending := current
+	ending.ID = "ending"
+	ending.EndsAt = now.Add(24 * time.Hour)
+	outdated := current
+	outdated.ID = "outdated"
+	outdated.Matchers = []utils.Matcher{utils.AlertnameMatcher("KubePodNotReady")}
+	unmanaged := current
+	unmanaged.ID = "unmanaged"
+	unmanaged.Comment = "manual silence"
+	expired := current
+	expired.ID = "expired"
+	expired.Status.State = utils.SilenceStateExpired
+
+	tests := []struct {
+		name     string
+		state    string
+		silences []utils.Silence
+		want     []string
+	}{
+		{name: "new cluster", silences: []utils.Silence{unmanaged, expired}, want: []string{"add "}},
+		{name: "applied again", silences: []utils.Silence{current, unmanaged}, want: []string{"unchanged current"}},
+		{name: "ending soon", silences: []utils.Silence{ending}, want: []string{"update ending"}},
+		{name: "matchers changed", silences: []utils.Silence{outdated}, want: []string{"expire outdated", "add "}},
+		{name: "duplicates", silences: []utils.Silence{current, ending}, want: []string{"unchanged current", "expire ending"}},
+		{name: "absent", state: policyStateAbsent, silences: []utils.Silence{current, outdated, unmanaged}, want: []string{"expire current", "expire outdated"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy.State = policyStatePresent
+			if tt.state != "" {
+				policy.State = tt.state
+			}
+			var got []string
+			for _, action := range policy.plan("cluster-1", "name", tt.silences, "sre", now) {
+				got = append(got, action.Action+" "+action.SilenceID)
+				if action.Action == policyActionAdd || action.Action == policyActionUpdate {
+					assert.Equal(t, comment, action.silence.Comment)
+					assert.Equal(t, now.Add(7*24*time.Hour), action.silence.EndsAt)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// fakeSilences serves the silence endpoints of an Alertmanager
+type fakeSilences struct {
+	mu       sync.Mutex
+	silences map[string]*utils.Silence
+	nextID   int
+}
+
+func (f *fakeSilences) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/silences":
+		silences := []utils.Silence{}
+		for _, silence := range f.silences {
+			silences = append(silences, *silence)
+		}
+		_ = json.NewEncoder(w).Encode(silences)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+		var posted utils.PostableSilence
+		_ = json.NewDecoder(r.Body).Decode(&posted)
+		if posted.ID == "" {
+			f.nextID++
+			posted.ID = fmt.Sprintf("silence-%d", f.nextID)
+		}
+		f.silences[posted.ID] = &utils.Silence{
+			ID:       posted.ID,
+			Matchers: posted.Matchers,
+			Status:   utils.SilenceStatus{State: utils.SilenceStateActive},
+			Comment:  posted.Comment,
+			StartsAt: posted.StartsAt,
+			EndsAt:   posted.EndsAt,
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"silenceID": posted.ID})
+	case r.Method == http.MethodDelete:
+		f.silences[strings.TrimPrefix(r.URL.Path, "/api/v2/silence/")].Status.State = utils.SilenceStateExpired
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestPolicyApplier(t *testing.T) {
+	policy, err := parseSilencePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+
+	fake := &fakeSilences{silences: map[string]*utils.Silence{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	var clusters []*cmv1.Cluster
+	for _, id := range []string{"reachable", "unreachable"} {
+		cluster, err := cmv1.NewCluster().ID(id).Build()
+		require.NoError(t, err)
+		clusters = append(clusters, cluster)
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	applier := &policyApplier{
+		policy:    policy,
+		createdBy: "sre",
+		now:       func() time.Time { return now },
+		connect: func(clusterID string) (*utils.AlertmanagerClient, func(), error) {
+			if clusterID == "unreachable" {
+				return nil, nil, errors.New("port-forward failed")
+			}
+			return utils.NewAlertmanagerClient(server.URL, server.Client()), func() {}, nil
+		},
+	}
+	ctx := context.Background()
+
+	actions, planned := applier.Plan(ctx, clusters)
+	require.Len(t, actions, 1)
+	assert.Equal(t, policyActionAdd, actions[0].Action)
+	assert.Equal(t, []policyResult{
+		{ClusterID: "reachable", Result: policyResultPlanned},
+		{ClusterID: "unreachable", Result: policyResultFailed, Error: "port-forward failed"},
+	}, planned)
+
+	results := applier.Apply(ctx, clusters, planned)
+	assert.Equal(t, []policyResult{
+		{ClusterID: "reachable", Result: policyResultApplied},
+		{ClusterID: "unreachable", Result: policyResultFailed, Error: "port-forward failed"},
+	}, results)
+	require.Len(t, fake.silences, 1)
+
+	// Applying the policy again changes nothing
+	actions, planned = applier.Plan(ctx, clusters[:1])
+	require.Len(t, actions, 1)
+	assert.Equal(t, policyActionUnchanged, actions[0].Action)
+	results = applier.Apply(ctx, clusters[:1], planned)
+	assert.Equal(t, policyResultUnchanged, results[0].Result)
+	assert.Len(t, fake.silences, 1)
+	assert.Equal(t, []string{"reachable"}, applier.appliedClusters(results))
+
+	// A cluster removed from the targets loses the silences of the policy
+	applier.removed = map[string]bool{"reachable": true}
+	actions, planned = applier.Plan(ctx, clusters[:1])
+	require.Len(t, actions, 1)
+	assert.Equal(t, policyActionExpire, actions[0].Action)
+	results = applier.Apply(ctx, clusters[:1], planned)
+	assert.Equal(t, policyResultApplied, results[0].Result)
+	assert.Equal(t, utils.SilenceStateExpired, fake.silences[actions[0].SilenceID].Status.State)
+	assert.Empty(t, applier.appliedClusters(results))
+	assert.Equal(t, policyStatePresent, applier.policy.State)
+}
+
+func TestPolicyHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "silence-policies", "logging-upgrade.json")
+
+	history, err := readPolicyHistory(path)
+	require.NoError(t, err)
+	assert.Empty(t, history.ClusterIDs)
+
+	require.NoError(t, history.Save([]string{"cluster-2", "cluster-1", "cluster-2"}))
+	history, err = readPolicyHistory(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cluster-1", "cluster-2"}, history.ClusterIDs)
+	assert.Equal(t, []string{"cluster-2"}, history.removed([]string{"cluster-1", "cluster-3"}))
+}
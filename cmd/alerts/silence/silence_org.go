@@ -1,10 +1,11 @@
 package silence
 
 import (
+	"context"
 	"fmt"
 	"log"
 
-	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/cmd/alerts/utils"
 	orgutils "github.com/openshift/osdctl/cmd/org"
 	ocmutils "github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
@@ -13,6 +14,7 @@ import (
 type AddOrgSilenceCmd struct {
 	organization string
 	alertID      []string
+	matchers     []string
 	duration     string
 	comment      string
 	all          bool
@@ -31,13 +33,15 @@ func NewCmdAddOrgSilence() *cobra.Command {
   osdctl alerts silence org ${ORG_ID} --alertname "KubePodNotReady" --comment "${REASON}: investigating pod issue"`,
 		Args:              cobra.ExactArgs(1),
 		DisableAutoGenTag: true,
-		Run: func(cmd *cobra.Command, args []string) {
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			AddOrgSilenceCmd.organization = args[0]
-			AddOrgSilence(AddOrgSilenceCmd)
+			return AddOrgSilence(AddOrgSilenceCmd)
 		},
 	}
 
 	cmd.Flags().StringSliceVar(&AddOrgSilenceCmd.alertID, "alertname", []string{}, "alertname (comma-separated)")
+	cmd.Flags().StringArrayVarP(&AddOrgSilenceCmd.matchers, "matcher", "m", []string{}, "Label matcher of the silences, e.g. severity=warning or namespace=~openshift-.* (can be repeated)")
 	cmd.Flags().StringVarP(&AddOrgSilenceCmd.comment, "comment", "c", "", "add comment about silence. OHSS required for org-wide silence")
 	cmd.Flags().StringVarP(&AddOrgSilenceCmd.duration, "duration", "d", "15d", "add duration for silence") //default duration set to 15 days
 	cmd.Flags().BoolVarP(&AddOrgSilenceCmd.all, "all", "a", false, "add silences for all alert")
@@ -47,32 +51,34 @@ func NewCmdAddOrgSilence() *cobra.Command {
 }
 
 // AddOrgSilence adds alert silences to organization's clusters
-func AddOrgSilence(cmd *AddOrgSilenceCmd) {
-	alertID := cmd.alertID
-	comment := cmd.comment
-	duration := cmd.duration
-	all := cmd.all
+func AddOrgSilence(cmd *AddOrgSilenceCmd) error {
+	request, err := newSilenceRequest(cmd.alertID, cmd.all, cmd.matchers, cmd.duration, cmd.comment)
+	if err != nil {
+		return err
+	}
 	organizationID := cmd.organization
 
 	subscriptions, err := orgutils.SearchSubscriptions(organizationID, orgutils.StatusActive)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	} else if len(subscriptions) == 0 {
-		log.Fatal("No subscriptions found with that organization ID")
+		return fmt.Errorf("no subscriptions found with that organization ID")
 	}
 
 	connection, err := ocmutils.CreateConnection()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	organization, err := ocmutils.GetOrganization(connection, subscriptions[0].ClusterID())
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	log.Printf("Are you sure you want silence alerts for %d clusters for this organization: %s", len(subscriptions), organization.Name())
-	ocmutils.ConfirmPrompt()
+	if !ocmutils.ConfirmPrompt() {
+		return nil
+	}
 
 	for _, subscription := range subscriptions {
 		clusterID := subscription.ClusterID()
@@ -83,26 +89,19 @@ func AddOrgSilence(cmd *AddOrgSilenceCmd) {
 			log.Printf("Silencing alert(s) on cluster: %s", clusterID)
 		}
 
-		username, clustername := GetUserAndClusterInfo(clusterID)
+		username, _ := GetUserAndClusterInfo(clusterID)
+		request.createdBy = username
 
-		_, kubeconfig, clientset, err := common.GetKubeConfigAndClient(clusterID)
+		client, stop, err := utils.ConnectToAlertmanager(clusterID, "")
 		if err != nil {
 			log.Print(err)
 			continue //Skip if cluster is not in supported state
 		}
 
-		if all {
-			err := AddAllSilence(clusterID, duration, comment, username, clustername, kubeconfig, clientset)
-			if err != nil {
-				log.Print(err)
-			}
-		} else if len(alertID) > 0 {
-			err := AddAlertNameSilence(alertID, duration, comment, username, kubeconfig, clientset)
-			if err != nil {
-				log.Print(err)
-			}
-		} else {
-			fmt.Println("No valid option specified. Use --all or --alertname.")
+		if err := addSilences(context.Background(), client, request); err != nil {
+			log.Print(err)
 		}
+		stop()
 	}
+	return nil
 }
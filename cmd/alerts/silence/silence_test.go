@@ -0,0 +1,120 @@
+package silence
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openshift/osdctl/cmd/alerts/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSilenceRequest(t *testing.T) {
+	request, err := newSilenceRequest([]string{"KubePodNotReady"}, false, []string{"namespace=openshift-logging"}, "15d", "comment")
+	require.NoError(t, err)
+	assert.Equal(t, 15*24*time.Hour, request.duration)
+	assert.Equal(t, []utils.Matcher{{Name: "namespace", Value: "openshift-logging", IsEqual: true}}, request.matchers)
+
+	_, err = newSilenceRequest(nil, false, nil, "2h", "comment")
+	assert.EqualError(t, err, "no valid option specified, use --all, --alertname or --matcher")
+
+	_, err = newSilenceRequest(nil, true, nil, "forever", "comment")
+	assert.ErrorContains(t, err, `invalid duration "forever"`)
+}
+
+func TestSilenceRequestSilences(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]utils.Alert{
+			{Labels: map[string]string{"alertname": "Watchdog"}},
+			{Labels: map[string]string{"alertname": "KubePodNotReady", "pod": "a"}},
+			{Labels: map[string]string{"alertname": "KubePodNotReady", "pod": "b"}},
+			{Labels: map[string]string{"alertname": "TargetDown"}},
+		})
+	}))
+	defer server.Close()
+	client := utils.NewAlertmanagerClient(server.URL, server.Client())
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	namespace := utils.Matcher{Name: "namespace", Value: "openshift-logging", IsEqual: true}
+	tests := []struct {
+		name    string
+		request silenceRequest
+		want    [][]utils.Matcher
+	}{
+		{
+			name:    "all firing alerts but the watchdog",
+			request: silenceRequest{all: true, duration: time.Hour},
+			want: [][]utils.Matcher{
+				{utils.AlertnameMatcher("KubePodNotReady")},
+				{utils.AlertnameMatcher("TargetDown")},
+			},
+		},
+		{
+			name:    "alert names with matchers",
+			request: silenceRequest{alertnames: []string{"A", "B"}, matchers: []utils.Matcher{namespace}, duration: time.Hour},
+			want: [][]utils.Matcher{
+				{utils.AlertnameMatcher("A"), namespace},
+				{utils.AlertnameMatcher("B"), namespace},
+			},
+		},
+		{
+			name:    "matchers only",
+			request: silenceRequest{matchers: []utils.Matcher{namespace}, duration: time.Hour},
+			want:    [][]utils.Matcher{{namespace}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			silences, err := tt.request.silences(context.Background(), client, now)
+			require.NoError(t, err)
+			var got [][]utils.Matcher
+			for _, silence := range silences {
+				assert.Equal(t, now, silence.StartsAt)
+				assert.Equal(t, now.Add(time.Hour), silence.EndsAt)
+				got = append(got, silence.Matchers)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUpdateSilence(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	active := utils.Silence{
+		ID:       "active",
+		Matchers: []utils.Matcher{utils.AlertnameMatcher("KubePodNotReady")},
+		Status:   utils.SilenceStatus{State: utils.SilenceStateActive},
+		Comment:  "investigating",
+		StartsAt: now.Add(-time.Hour),
+		EndsAt:   now.Add(time.Hour),
+	}
+	expired := active
+	expired.ID = "expired"
+	expired.Status.State = utils.SilenceStateExpired
+	expired.EndsAt = now.Add(-time.Minute)
+
+	updated, err := updateSilence(active, silenceChanges{extend: 2 * time.Hour, comment: "still investigating"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "active", updated.ID)
+	assert.Equal(t, now.Add(-time.Hour), updated.StartsAt)
+	assert.Equal(t, now.Add(3*time.Hour), updated.EndsAt)
+	assert.Equal(t, "still investigating", updated.Comment)
+
+	updated, err = updateSilence(active, silenceChanges{duration: 30 * time.Minute}, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(30*time.Minute), updated.EndsAt)
+
+	// An expired silence is renewed from now
+	updated, err = updateSilence(expired, silenceChanges{extend: 24 * time.Hour}, now)
+	require.NoError(t, err)
+	assert.Equal(t, now, updated.StartsAt)
+	assert.Equal(t, now.Add(24*time.Hour), updated.EndsAt)
+
+	_, err = updateSilence(expired, silenceChanges{comment: "no end change"}, now)
+	assert.ErrorContains(t, err, "before it starts or in the past")
+}
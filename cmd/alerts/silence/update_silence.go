@@ -0,0 +1,189 @@
+package silence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openshift/osdctl/cmd/alerts/utils"
+	"github.com/spf13/cobra"
+)
+
+type updateSilenceCmd struct {
+	clusterID       string
+	silenceID       string
+	reason          string
+	comment         string
+	matchers        []string
+	duration        string
+	extend          string
+	alertmanagerURL string
+}
+
+// silenceChanges are the changes made to a silence by 'silence update' and 'silence extend'
+type silenceChanges struct {
+	comment  string
+	matchers []utils.Matcher
+	// duration sets the end of the silence this long from now
+	duration time.Duration
+	// extend postpones the end of the silence
+	extend time.Duration
+}
+
+func NewCmdUpdateSilence() *cobra.Command {
+	updateCmd := &updateSilenceCmd{}
+	cmd := &cobra.Command{
+		Use:   "update --cluster-id <cluster-identifier> --silence-id <silence-id> [--comment | --matcher | --duration]",
+		Short: "Update the comment, matchers or end of a silence",
+		Long: `Update the comment, matchers or end of a silence.
+
+  Alertmanager replaces an active silence whose matchers change by a new silence, the ID of
+  the updated silence is printed.`,
+		Example: `  # Make a silence end in 2 hours
+  osdctl alerts silence update --cluster-id ${CLUSTER_ID} --silence-id ${SILENCE_ID} --duration 2h --reason "${REASON}"
+
+  # Replace the matchers of a silence
+  osdctl alerts silence update --cluster-id ${CLUSTER_ID} --silence-id ${SILENCE_ID} -m alertname=KubePodNotReady -m namespace=openshift-logging --reason "${REASON}"`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return UpdateSilence(updateCmd)
+		},
+	}
+
+	addSilenceTargetFlags(cmd, updateCmd)
+	cmd.Flags().StringVarP(&updateCmd.comment, "comment", "c", "", "New comment of the silence")
+	cmd.Flags().StringArrayVarP(&updateCmd.matchers, "matcher", "m", []string{}, "New label matchers of the silence, replacing the current ones (can be repeated)")
+	cmd.Flags().StringVarP(&updateCmd.duration, "duration", "d", "", "Make the silence end this long from now, e.g. 2h or 1d")
+
+	return cmd
+}
+
+func NewCmdExtendSilence() *cobra.Command {
+	updateCmd := &updateSilenceCmd{}
+	cmd := &cobra.Command{
+		Use:   "extend --cluster-id <cluster-identifier> --silence-id <silence-id> --duration <duration>",
+		Short: "Extend a silence",
+		Long:  `Postpone the end of a silence. An expired silence is renewed from now.`,
+		Example: `  # Extend a silence by 3 days
+  osdctl alerts silence extend --cluster-id ${CLUSTER_ID} --silence-id ${SILENCE_ID} --duration 3d --reason "${REASON}"`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return UpdateSilence(updateCmd)
+		},
+	}
+
+	addSilenceTargetFlags(cmd, updateCmd)
+	cmd.Flags().StringVarP(&updateCmd.extend, "duration", "d", "", "Duration to extend the silence by, e.g. 2h or 1d")
+	_ = cmd.MarkFlagRequired("duration")
+
+	return cmd
+}
+
+// addSilenceTargetFlags adds the flags selecting the silence to update
+func addSilenceTargetFlags(cmd *cobra.Command, updateCmd *updateSilenceCmd) {
+	cmd.Flags().StringVarP(&updateCmd.clusterID, "cluster-id", "C", "", "Provide the internal ID of the cluster")
+	cmd.Flags().StringVar(&updateCmd.silenceID, "silence-id", "", "ID of the silence")
+	cmd.Flags().StringVar(&updateCmd.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usualy an OHSS or PD ticket)")
+	utils.AddAlertmanagerURLFlag(cmd, &updateCmd.alertmanagerURL)
+
+	_ = cmd.MarkFlagRequired("cluster-id")
+	_ = cmd.MarkFlagRequired("silence-id")
+	_ = cmd.MarkFlagRequired("reason")
+}
+
+func UpdateSilence(cmd *updateSilenceCmd) error {
+	changes, err := cmd.changes()
+	if err != nil {
+		return err
+	}
+
+	elevationReasons := []string{
+		cmd.reason,
+		"Update alertmanager silence for a cluster via osdctl",
+	}
+	client, stop, err := utils.ConnectToAlertmanager(cmd.clusterID, cmd.alertmanagerURL, elevationReasons...)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	ctx := context.Background()
+	silence, err := client.GetSilence(ctx, cmd.silenceID)
+	if err != nil {
+		return err
+	}
+	updated, err := updateSilence(*silence, changes, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	id, err := client.PostSilence(ctx, updated)
+	if err != nil {
+		return err
+	}
+
+	if id != cmd.silenceID {
+		fmt.Printf("Silence \"%s\" has been replaced by silence \"%s\", ending at %s\n", cmd.silenceID, id, updated.EndsAt.Format(time.RFC3339))
+	} else {
+		fmt.Printf("Silence \"%s\" has been updated, ending at %s\n", id, updated.EndsAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// changes validates the flags describing the changes to the silence
+func (cmd *updateSilenceCmd) changes() (silenceChanges, error) {
+	changes := silenceChanges{comment: cmd.comment}
+
+	var err error
+	if changes.matchers, err = utils.ParseMatchers(cmd.matchers); err != nil {
+		return changes, err
+	}
+	if cmd.duration != "" {
+		if changes.duration, err = parseSilenceDuration(cmd.duration); err != nil {
+			return changes, err
+		}
+	}
+	if cmd.extend != "" {
+		if changes.extend, err = parseSilenceDuration(cmd.extend); err != nil {
+			return changes, err
+		}
+	}
+
+	if changes.comment == "" && len(changes.matchers) == 0 && changes.duration == 0 && changes.extend == 0 {
+		return changes, fmt.Errorf("nothing to update, use --comment, --matcher or --duration")
+	}
+	return changes, nil
+}
+
+// updateSilence returns the silence to post to apply the changes. An expired silence starts
+// again now.
+func updateSilence(silence utils.Silence, changes silenceChanges, now time.Time) (utils.PostableSilence, error) {
+	updated := silence.Postable()
+	if silence.Status.State == utils.SilenceStateExpired {
+		updated.StartsAt = now
+		if updated.EndsAt.Before(now) {
+			updated.EndsAt = now
+		}
+	}
+
+	if changes.comment != "" {
+		updated.Comment = changes.comment
+	}
+	if len(changes.matchers) > 0 {
+		updated.Matchers = changes.matchers
+	}
+	if changes.duration > 0 {
+		updated.EndsAt = now.Add(changes.duration)
+	}
+	if changes.extend > 0 {
+		updated.EndsAt = updated.EndsAt.Add(changes.extend)
+	}
+
+	if !updated.EndsAt.After(updated.StartsAt) || !updated.EndsAt.After(now) {
+		return updated, fmt.Errorf("silence \"%s\" would end at %s, before it starts or in the past", silence.ID, updated.EndsAt.Format(time.RFC3339))
+	}
+	return updated, nil
+}
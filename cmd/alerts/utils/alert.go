@@ -1,24 +1,25 @@
 package utils
 
-// Labels represents a set of labels associated with an alert.
-type AlertLabels struct {
-	Alertname string `json:"alertname"`
-	Severity  string `json:"severity"`
-}
+import "time"
 
-// Status represents a set of state associated with an alert.
-type AlertStatus struct {
-	State string `json:"state"`
+// Alert is an alert as returned by the Alertmanager v2 API
+type Alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	Status      AlertStatus       `json:"status"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+	Fingerprint string            `json:"fingerprint"`
 }
 
-// Annotations represents a set of summary/description associated with an alert.
-type AlertAnnotations struct {
-	Summary string `json:"summary"`
+// AlertStatus is the state of an alert, and the silences and alerts suppressing it
+type AlertStatus struct {
+	State       string   `json:"state"`
+	SilencedBy  []string `json:"silencedBy"`
+	InhibitedBy []string `json:"inhibitedBy"`
 }
 
-// Alert represents a set of above declared struct Labels,Status and annoataions
-type Alert struct {
-	Labels      AlertLabels      `json:"labels"`
-	Status      AlertStatus      `json:"status"`
-	Annotations AlertAnnotations `json:"annotations"`
+// Name returns the alertname label of the alert
+func (a Alert) Name() string {
+	return a.Labels["alertname"]
 }
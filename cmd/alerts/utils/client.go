@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AlertFilter selects the alerts returned by ListAlerts
+type AlertFilter struct {
+	Matchers []Matcher
+	// Silenced and Inhibited include the alerts suppressed by silences and inhibition rules
+	Silenced  bool
+	Inhibited bool
+}
+
+// AlertmanagerClient is a client of the Alertmanager v2 API
+type AlertmanagerClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewAlertmanagerClient returns a client of the Alertmanager reachable at baseURL, e.g.
+// http://localhost:9093
+func NewAlertmanagerClient(baseURL string, httpClient *http.Client) *AlertmanagerClient {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &AlertmanagerClient{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: httpClient}
+}
+
+// ListAlerts returns the active alerts matching the filter
+func (c *AlertmanagerClient) ListAlerts(ctx context.Context, filter AlertFilter) ([]Alert, error) {
+	query := url.Values{}
+	query.Set("active", "true")
+	query.Set("silenced", strconv.FormatBool(filter.Silenced))
+	query.Set("inhibited", strconv.FormatBool(filter.Inhibited))
+	for _, matcher := range filter.Matchers {
+		query.Add("filter", matcher.String())
+	}
+
+	var alerts []Alert
+	if err := c.do(ctx, http.MethodGet, "/api/v2/alerts", query, nil, &alerts); err != nil {
+		return nil, fmt.Errorf("failed to list alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+// ListSilences returns the silences matching all matchers, including expired ones
+func (c *AlertmanagerClient) ListSilences(ctx context.Context, matchers []Matcher) ([]Silence, error) {
+	query := url.Values{}
+	for _, matcher := range matchers {
+		query.Add("filter", matcher.String())
+	}
+
+	var silences []Silence
+	if err := c.do(ctx, http.MethodGet, "/api/v2/silences", query, nil, &silences); err != nil {
+		return nil, fmt.Errorf("failed to list silences: %w", err)
+	}
+	return silences, nil
+}
+
+// GetSilence returns a silence by its ID
+func (c *AlertmanagerClient) GetSilence(ctx context.Context, id string) (*Silence, error) {
+	silence := &Silence{}
+	if err := c.do(ctx, http.MethodGet, "/api/v2/silence/"+url.PathEscape(id), nil, nil, silence); err != nil {
+		return nil, fmt.Errorf("failed to get silence %s: %w", id, err)
+	}
+	return silence, nil
+}
+
+// PostSilence creates a silence, or updates it if its ID is set, and returns its ID. Alertmanager
+// may replace an updated silence by a new one with a different ID.
+func (c *AlertmanagerClient) PostSilence(ctx context.Context, silence PostableSilence) (string, error) {
+	var response struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/v2/silences", nil, silence, &response); err != nil {
+		return "", fmt.Errorf("failed to post silence: %w", err)
+	}
+	return response.SilenceID, nil
+}
+
+// ExpireSilence expires a silence by its ID
+func (c *AlertmanagerClient) ExpireSilence(ctx context.Context, id string) error {
+	if err := c.do(ctx, http.MethodDelete, "/api/v2/silence/"+url.PathEscape(id), nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to expire silence %s: %w", id, err)
+	}
+	return nil
+}
+
+// do sends a request to the API and decodes the JSON response into out, unless out is nil
+func (c *AlertmanagerClient) do(ctx context.Context, method string, path string, query url.Values, in interface{}, out interface{}) error {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if in != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message := strings.TrimSpace(string(data))
+		// Errors are returned as a JSON string
+		var text string
+		if json.Unmarshal(data, &text) == nil {
+			message = text
+		}
+		return fmt.Errorf("alertmanager returned %s: %s", response.Status, message)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid response from alertmanager: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAlertmanager serves the parts of the Alertmanager v2 API used by the client
+type fakeAlertmanager struct {
+	mu       sync.Mutex
+	alerts   []Alert
+	silences map[string]*Silence
+	nextID   int
+	// queries records the query of every request
+	queries []string
+}
+
+func newFakeAlertmanager(t *testing.T, alerts []Alert) (*fakeAlertmanager, *AlertmanagerClient) {
+	fake := &fakeAlertmanager{alerts: alerts, silences: map[string]*Silence{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, NewAlertmanagerClient(server.URL+"/", server.Client())
+}
+
+func (f *fakeAlertmanager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, r.URL.RawQuery)
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/v2/silence/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/alerts":
+		alerts := []Alert{}
+		for _, alert := range f.alerts {
+			if len(alert.Status.SilencedBy) > 0 && r.URL.Query().Get("silenced") != "true" {
+				continue
+			}
+			alerts = append(alerts, alert)
+		}
+		f.reply(w, http.StatusOK, alerts)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/silences":
+		silences := []Silence{}
+		for _, silence := range f.silences {
+			silences = append(silences, *silence)
+		}
+		f.reply(w, http.StatusOK, silences)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+		var posted PostableSilence
+		if err := json.NewDecoder(r.Body).Decode(&posted); err != nil {
+			f.reply(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(posted.Matchers) == 0 {
+			f.reply(w, http.StatusBadRequest, "silence invalid: at least one matcher required")
+			return
+		}
+		if posted.ID != "" && f.silences[posted.ID] == nil {
+			f.reply(w, http.StatusNotFound, "silence not found")
+			return
+		}
+		if posted.ID == "" {
+			f.nextID++
+			posted.ID = fmt.Sprintf("silence-%d", f.nextID)
+		}
+		f.silences[posted.ID] = &Silence{
+			ID:        posted.ID,
+			Matchers:  posted.Matchers,
+			Status:    SilenceStatus{State: SilenceStateActive},
+			Comment:   posted.Comment,
+			CreatedBy: posted.CreatedBy,
+			StartsAt:  posted.StartsAt,
+			EndsAt:    posted.EndsAt,
+		}
+		f.reply(w, http.StatusOK, map[string]string{"silenceID": posted.ID})
+	case r.Method == http.MethodGet && f.silences[id] != nil:
+		f.reply(w, http.StatusOK, f.silences[id])
+	case r.Method == http.MethodDelete && f.silences[id] != nil:
+		f.silences[id].Status.State = SilenceStateExpired
+		w.WriteHeader(http.StatusOK)
+	default:
+		f.reply(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (f *fakeAlertmanager) reply(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestListAlerts(t *testing.T) {
+	fake, client := newFakeAlertmanager(t, []Alert{
+		{Labels: map[string]string{"alertname": "KubePodNotReady", "severity": "warning"}, Status: AlertStatus{State: "active"}},
+		{Labels: map[string]string{"alertname": "Watchdog", "severity": "none"}, Status: AlertStatus{State: "suppressed", SilencedBy: []string{"silence-1"}}},
+	})
+
+	matchers, err := ParseMatchers([]string{"severity=warning", "namespace=~openshift-.*"})
+	require.NoError(t, err)
+	alerts, err := client.ListAlerts(context.Background(), AlertFilter{Matchers: matchers})
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "KubePodNotReady", alerts[0].Name())
+	assert.Equal(t, `active=true&filter=severity%3D%22warning%22&filter=namespace%3D~%22openshift-.%2A%22&inhibited=false&silenced=false`, fake.queries[0])
+
+	alerts, err = client.ListAlerts(context.Background(), AlertFilter{Silenced: true})
+	require.NoError(t, err)
+	assert.Len(t, alerts, 2)
+}
+
+func TestSilenceLifecycle(t *testing.T) {
+	_, client := newFakeAlertmanager(t, nil)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	id, err := client.PostSilence(ctx, PostableSilence{
+		Matchers:  []Matcher{AlertnameMatcher("KubePodNotReady")},
+		Comment:   "investigating",
+		CreatedBy: "sre",
+		StartsAt:  now,
+		EndsAt:    now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "silence-1", id)
+
+	silence, err := client.GetSilence(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "investigating", silence.Comment)
+	assert.Equal(t, now.Add(time.Hour), silence.EndsAt)
+
+	update := silence.Postable()
+	update.EndsAt = now.Add(2 * time.Hour)
+	updatedID, err := client.PostSilence(ctx, update)
+	require.NoError(t, err)
+	assert.Equal(t, id, updatedID)
+
+	require.NoError(t, client.ExpireSilence(ctx, id))
+	silences, err := client.ListSilences(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	assert.Equal(t, SilenceStateExpired, silences[0].Status.State)
+	assert.Equal(t, now.Add(2*time.Hour), silences[0].EndsAt)
+}
+
+func TestClientErrors(t *testing.T) {
+	_, client := newFakeAlertmanager(t, nil)
+	ctx := context.Background()
+
+	_, err := client.PostSilence(ctx, PostableSilence{})
+	assert.EqualError(t, err, "failed to post silence: alertmanager returned 400 Bad Request: silence invalid: at least one matcher required")
+
+	err = client.ExpireSilence(ctx, "missing")
+	assert.EqualError(t, err, "failed to expire silence missing: alertmanager returned 404 Not Found: not found")
+
+	_, err = NewAlertmanagerClient("http://127.0.0.1:1", nil).ListSilences(ctx, nil)
+	assert.ErrorContains(t, err, "failed to list silences")
+}
+
+func TestParseMatcher(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Matcher
+		wantErr string
+	}{
+		{input: "alertname=KubePodNotReady", want: Matcher{Name: "alertname", Value: "KubePodNotReady", IsEqual: true}},
+		{input: `severity != "info"`, want: Matcher{Name: "severity", Value: "info"}},
+		{input: "namespace=~openshift-.*", want: Matcher{Name: "namespace", Value: "openshift-.*", IsRegex: true, IsEqual: true}},
+		{input: "namespace!~kube-.*", want: Matcher{Name: "namespace", Value: "kube-.*", IsRegex: true}},
+		{input: "namespace=~(", wantErr: "invalid matcher"},
+		{input: "no operator", wantErr: "invalid matcher"},
+		{input: `name="unterminated`, wantErr: "badly quoted value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMatcher(tt.input)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// Matchers are written back in a format they can be parsed from
+			reparsed, err := ParseMatcher(got.String())
+			require.NoError(t, err)
+			assert.Equal(t, got, reparsed)
+		})
+	}
+}
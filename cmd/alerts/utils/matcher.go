@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matcher matches the alerts having a label with the given value or, for regex matchers, a
+// value fully matching the given regular expression
+type Matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+var matcherRegex = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*(.*?)\s*$`)
+
+// ParseMatcher parses a matcher written as name=value, name!=value, name=~regex or name!~regex.
+// The value may be double quoted.
+func ParseMatcher(s string) (Matcher, error) {
+	parts := matcherRegex.FindStringSubmatch(s)
+	if parts == nil {
+		return Matcher{}, fmt.Errorf("invalid matcher %q, matchers are written as name=value, name!=value, name=~regex or name!~regex", s)
+	}
+
+	value := parts[3]
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return Matcher{}, fmt.Errorf("invalid matcher %q: badly quoted value", s)
+		}
+		value = unquoted
+	}
+
+	matcher := Matcher{
+		Name:    parts[1],
+		Value:   value,
+		IsRegex: parts[2] == "=~" || parts[2] == "!~",
+		IsEqual: parts[2] == "=" || parts[2] == "=~",
+	}
+	if matcher.IsRegex {
+		if _, err := regexp.Compile("^(?:" + value + ")$"); err != nil {
+			return Matcher{}, fmt.Errorf("invalid matcher %q: %w", s, err)
+		}
+	}
+	return matcher, nil
+}
+
+// ParseMatchers parses a list of matchers
+func ParseMatchers(values []string) ([]Matcher, error) {
+	matchers := make([]Matcher, 0, len(values))
+	for _, value := range values {
+		matcher, err := ParseMatcher(value)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+// AlertnameMatcher matches the alerts with the given name
+func AlertnameMatcher(name string) Matcher {
+	return Matcher{Name: "alertname", Value: name, IsEqual: true}
+}
+
+// String returns the matcher in the format of the filter parameter of the Alertmanager API
+func (m Matcher) String() string {
+	operator := "="
+	switch {
+	case m.IsRegex && m.IsEqual:
+		operator = "=~"
+	case m.IsRegex:
+		operator = "!~"
+	case !m.IsEqual:
+		operator = "!="
+	}
+	return m.Name + operator + strconv.Quote(m.Value)
+}
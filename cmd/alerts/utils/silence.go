@@ -1,24 +1,48 @@
 package utils
 
-type SilenceID struct {
-	ID string `json:"id"`
-}
+import "time"
+
+// States of a silence
+const (
+	SilenceStateActive  = "active"
+	SilenceStatePending = "pending"
+	SilenceStateExpired = "expired"
+)
 
-type SilenceMatchers struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
+// Silence is a silence as returned by the Alertmanager v2 API
+type Silence struct {
+	ID        string        `json:"id"`
+	Matchers  []Matcher     `json:"matchers"`
+	Status    SilenceStatus `json:"status"`
+	Comment   string        `json:"comment"`
+	CreatedBy string        `json:"createdBy"`
+	StartsAt  time.Time     `json:"startsAt"`
+	EndsAt    time.Time     `json:"endsAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
 }
 
 type SilenceStatus struct {
 	State string `json:"state"`
 }
 
-type Silence struct {
-	ID        string            `json:"id"`
-	Matchers  []SilenceMatchers `json:"matchers"`
-	Status    SilenceStatus     `json:"status"`
-	Comment   string            `json:"comment"`
-	CreatedBy string            `json:"createdBy"`
-	EndsAt    string            `json:"endsAt"`
-	StartsAt  string            `json:"startsAt"`
+// PostableSilence creates a silence, or updates the silence with the given ID
+type PostableSilence struct {
+	ID        string    `json:"id,omitempty"`
+	Matchers  []Matcher `json:"matchers"`
+	Comment   string    `json:"comment"`
+	CreatedBy string    `json:"createdBy"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+}
+
+// Postable returns the silence as it is posted to update it
+func (s Silence) Postable() PostableSilence {
+	return PostableSilence{
+		ID:        s.ID,
+		Matchers:  s.Matchers,
+		Comment:   s.Comment,
+		CreatedBy: s.CreatedBy,
+		StartsAt:  s.StartsAt,
+		EndsAt:    s.EndsAt,
+	}
 }
@@ -1,75 +1,100 @@
 package utils
 
 import (
-	"context"
 	"fmt"
+	"io"
+	"net/http"
 
-	"github.com/openshift/osdctl/cmd/cluster"
-
-	corev1 "k8s.io/api/core/v1"
+	"github.com/openshift/osdctl/cmd/common"
+	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/remotecommand"
-	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
 )
 
 const (
 	AccountNamespace = "openshift-monitoring"
-	ContainerName    = "alertmanager"
-	LocalHostUrl     = "http://localhost:9093"
+	AlertmanagerPort = 9093
 	PrimaryPod       = "alertmanager-main-0"
 	SecondaryPod     = "alertmanager-main-1"
 )
 
-// ExecInAlertManagerPod attempts to execute the given command in the primary Alertmanager pod,
-// and if that fails, it retries with the secondary Alertmanager pod.
-func ExecInAlertManagerPod(kubeconfig *rest.Config, clientset *kubernetes.Clientset, cmd []string) (string, error) {
-	var cmdOutput string
-	var err error
+// AddAlertmanagerURLFlag adds the --alertmanager-url flag to cmd
+func AddAlertmanagerURLFlag(cmd *cobra.Command, alertmanagerURL *string) {
+	cmd.Flags().StringVar(alertmanagerURL, "alertmanager-url", "", "URL of an Alertmanager already reachable, e.g. through 'ocm backplane monitoring alertmanager', instead of port-forwarding to the Alertmanager pods")
+}
 
-	cmdOutput, err = ExecWithPod(kubeconfig, clientset, PrimaryPod, AccountNamespace, cmd)
-	if err == nil {
-		return cmdOutput, err
+// ConnectToAlertmanager returns a client of the Alertmanager of a cluster. The Alertmanager is
+// reached at alertmanagerURL when set, otherwise through a port-forward to the primary
+// Alertmanager pod or, if that fails, to the secondary one. The returned function stops the
+// port-forward.
+func ConnectToAlertmanager(clusterID string, alertmanagerURL string, elevationReasons ...string) (*AlertmanagerClient, func(), error) {
+	if alertmanagerURL != "" {
+		return NewAlertmanagerClient(alertmanagerURL, nil), func() {}, nil
 	}
 
-	cmdOutput, err = ExecWithPod(kubeconfig, clientset, SecondaryPod, AccountNamespace, cmd)
-	if err == nil {
-		return cmdOutput, err
+	_, kubeconfig, clientset, err := common.GetKubeConfigAndClient(clusterID, elevationReasons...)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	return "", fmt.Errorf("exec failed, please put silence manually: %w", err)
+	return PortForwardAlertmanager(kubeconfig, clientset)
 }
 
-func ExecWithPod(kubeconfig *rest.Config, clientset *kubernetes.Clientset, podName string, AccountNamespace string, cmd []string) (string, error) {
-	req := clientset.CoreV1().RESTClient().Post().Resource("pods").Name(podName).
-		Namespace(AccountNamespace).SubResource("exec")
-	option := &corev1.PodExecOptions{
-		Container: ContainerName,
-		Command:   cmd,
-		Stdin:     false,
-		Stdout:    true,
-		Stderr:    true,
-		TTY:       false,
+// PortForwardAlertmanager port-forwards a local port to the primary Alertmanager pod or, if that
+// fails, to the secondary one, and returns a client of the forwarded Alertmanager
+func PortForwardAlertmanager(kubeconfig *rest.Config, clientset kubernetes.Interface) (*AlertmanagerClient, func(), error) {
+	port, stop, err := portForward(kubeconfig, clientset, PrimaryPod)
+	if err != nil {
+		var secondaryErr error
+		port, stop, secondaryErr = portForward(kubeconfig, clientset, SecondaryPod)
+		if secondaryErr != nil {
+			return nil, nil, fmt.Errorf("failed to port-forward to %s (%v) and %s: %w", PrimaryPod, err, SecondaryPod, secondaryErr)
+		}
 	}
+	return NewAlertmanagerClient(fmt.Sprintf("http://127.0.0.1:%d", port), nil), stop, nil
+}
 
-	req.VersionedParams(option, scheme.ParameterCodec)
-
-	exec, err := remotecommand.NewSPDYExecutor(kubeconfig, "POST", req.URL())
+// portForward forwards a random local port to the Alertmanager port of a pod, until the returned
+// function is called
+func portForward(kubeconfig *rest.Config, clientset kubernetes.Interface, podName string) (uint16, func(), error) {
+	transport, upgrader, err := spdy.RoundTripperFor(kubeconfig)
 	if err != nil {
-		return "", fmt.Errorf("failed to create executor: %w", err)
+		return 0, nil, fmt.Errorf("failed to create port-forward transport: %w", err)
 	}
+	url := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(AccountNamespace).
+		Name(podName).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
 
-	capture := &cluster.LogCapture{}
-	errorCapture := &cluster.LogCapture{}
-	err = exec.StreamWithContext(context.TODO(), remotecommand.StreamOptions{
-		Stdin:  nil,
-		Stdout: capture,
-		Stderr: errorCapture,
-		Tty:    false,
-	})
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", AlertmanagerPort)}, stopChan, readyChan, io.Discard, io.Discard)
 	if err != nil {
-		return "", fmt.Errorf("failed to stream with context: %w", err)
+		return 0, nil, fmt.Errorf("failed to create port-forward to %s: %w", podName, err)
 	}
 
-	return capture.GetStdOut(), nil
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case <-readyChan:
+	case err := <-errChan:
+		if err == nil {
+			err = fmt.Errorf("port-forward closed")
+		}
+		return 0, nil, fmt.Errorf("failed to port-forward to %s: %w", podName, err)
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil || len(ports) == 0 {
+		close(stopChan)
+		return 0, nil, fmt.Errorf("failed to get the port forwarded to %s: %v", podName, err)
+	}
+	return ports[0].Local, func() { close(stopChan) }, nil
 }
@@ -0,0 +1,242 @@
+package cloudtrail
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/spf13/cobra"
+)
+
+// analyzeOptions holds the archive source and time range shared by the analyze subcommands
+type analyzeOptions struct {
+	FromDir   string
+	FromS3    string
+	S3Region  string
+	Profile   string
+	Region    string
+	StartTime string
+	EndTime   string
+	Duration  string
+}
+
+const cloudtrailAnalyzeDescription = `Analyze CloudTrail logs archived in the format CloudTrail delivers them to S3.
+
+LookupEvents, used by the other cloudtrail commands, only returns management
+events of the last 90 days from a single region. The log files a trail delivers
+to S3 are kept for as long as the bucket retains them, cover all regions and can
+be copied around, e.g. attached to a support case.
+
+The log files (.json or .json.gz, with a top level "Records" list) are read
+either from a local directory, searched recursively, or directly from an S3
+bucket, and are analyzed with the same filters and output as write-events,
+errors and permission-denied-events. No OCM or cluster access is needed.
+
+Without --after, --until or --since all archived events are analyzed.`
+
+func newCmdAnalyze() *cobra.Command {
+	opts := &analyzeOptions{}
+
+	analyzeCmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Analyze archived CloudTrail log files offline",
+		Long:  cloudtrailAnalyzeDescription,
+		Example: `  # Write events from a local copy of the trail bucket
+  aws s3 sync s3://my-trail/AWSLogs/123456789012/CloudTrail/ ./trail
+  osdctl cloudtrail analyze write-events --from-dir ./trail --after 2025-07-15,09:00:00 --until 2025-07-15,17:00:00
+
+  # Permission errors read directly from the bucket
+  osdctl cloudtrail analyze errors --from-s3 s3://my-trail/AWSLogs/123456789012/CloudTrail/us-east-1/2025/07/ --s3-region us-east-1
+
+  # Permission denied events of a single region
+  osdctl cloudtrail analyze permission-denied-events --from-dir ./trail --region eu-west-1`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	analyzeCmd.PersistentFlags().StringVar(&opts.FromDir, "from-dir", "", "Directory containing CloudTrail log files")
+	analyzeCmd.PersistentFlags().StringVar(&opts.FromS3, "from-s3", "", "S3 location of CloudTrail log files (s3://bucket/prefix)")
+	analyzeCmd.PersistentFlags().StringVar(&opts.S3Region, "s3-region", "", "Region of the S3 bucket (default: region of the AWS profile)")
+	analyzeCmd.PersistentFlags().StringVarP(&opts.Profile, "aws-profile", "p", "", "AWS profile used to read from S3")
+	analyzeCmd.PersistentFlags().StringVar(&opts.Region, "region", "", "Only analyze events from this AWS region")
+	analyzeCmd.PersistentFlags().StringVar(&opts.StartTime, "after", "", "Only analyze events that occur after the specified time. Format \"YY-MM-DD,hh:mm:ss\".")
+	analyzeCmd.PersistentFlags().StringVar(&opts.EndTime, "until", "", "Only analyze events that occur before the specified time. Format \"YY-MM-DD,hh:mm:ss\".")
+	analyzeCmd.PersistentFlags().StringVar(&opts.Duration, "since", "", "Only analyze events within this duration (e.g. 24h), relative to --after, --until or now")
+
+	analyzeCmd.AddCommand(newCmdAnalyzeWriteEvents(opts))
+	analyzeCmd.AddCommand(newCmdAnalyzeErrors(opts))
+	analyzeCmd.AddCommand(newCmdAnalyzePermissionDenied(opts))
+
+	return analyzeCmd
+}
+
+func newCmdAnalyzeWriteEvents(opts *analyzeOptions) *cobra.Command {
+	ops := &writeEventsOptions{}
+	fil := &WriteEventFilters{}
+
+	cmd := &cobra.Command{
+		Use:   "write-events",
+		Short: "Prints archived cloudtrail write events with advanced filtering options",
+		Long:  "Prints the write events of archived CloudTrail log files, see 'osdctl cloudtrail write-events'.\n\n" + QueryHelp,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ops.setup(*fil); err != nil {
+				return err
+			}
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+			pages, err := opts.events(ctx, true)
+			if err != nil {
+				return err
+			}
+			printer := NewPrinter(ops.PrintUrl, ops.PrintRaw)
+			for page := range pages {
+				if page.errors != nil {
+					return page.errors
+				}
+				printer.PrintEvents(ops.filterEvents(page.AWSEvent), ops.PrintFields)
+			}
+			fmt.Println("")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&ops.logLevel, "log-level", "l", "info", "Options: \"info\", \"debug\", \"warn\", \"error\". (default=info)")
+	cmd.Flags().BoolVarP(&ops.PrintUrl, "url", "u", false, "Generates Url link to cloud console cloudtrail event")
+	cmd.Flags().BoolVarP(&ops.PrintRaw, "raw-event", "r", false, "Prints the cloudtrail events to the console in raw json format")
+	cmd.Flags().StringSliceVarP(&ops.PrintFields, "print-fields", "", defaultFields, "Prints all cloudtrail write events in selected format. Can specify (username, time, event, arn, resource-name, resource-type, arn). i.e --print-format username,time,event")
+	cmd.Flags().StringSliceVarP(&fil.Include, "include", "I", nil, "Filter events by inclusion. (i.e. \"-I username=, -I event=, -I resource-name=, -I resource-type=, -I arn=\")")
+	cmd.Flags().StringSliceVarP(&fil.Exclude, "exclude", "E", nil, "Filter events by exclusion. (i.e. \"-E username=, -E event=, -E resource-name=, -E resource-type=, -E arn=\")")
+	cmd.Flags().StringVarP(&fil.Query, "query", "q", "", "Filter events with an expression, e.g. 'event~^Delete && !username=system:*' (see --help)")
+
+	return cmd
+}
+
+func newCmdAnalyzeErrors(opts *analyzeOptions) *cobra.Command {
+	ops := &errorsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "errors",
+		Short: "Prints archived CloudTrail error events (permission/IAM issues)",
+		Long:  "Prints the permission and IAM related errors of archived CloudTrail log files, see 'osdctl cloudtrail errors'.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ops.validateOutput(); err != nil {
+				return err
+			}
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+			pages, err := opts.events(ctx, false)
+			if err != nil {
+				return err
+			}
+			// The archive can span regions, each event is reported with its own region
+			allEvents, eventCount, err := ops.processEvents(pages, "", ops.errorPatterns())
+			if err != nil {
+				return err
+			}
+			return ops.printResult(allEvents, eventCount)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&ops.PrintUrl, "url", "u", false, "Include console URL links for each event")
+	cmd.Flags().BoolVarP(&ops.PrintRaw, "raw-event", "r", false, "Print raw CloudTrail event JSON")
+	ops.Output.AddFormatFlag(cmd, errorsFormats...)
+	cmd.Flags().StringSliceVar(&ops.ErrorTypes, "error-types", nil, "Comma-separated list of error patterns to match (default: all common permission errors)")
+
+	return cmd
+}
+
+func newCmdAnalyzePermissionDenied(opts *analyzeOptions) *cobra.Command {
+	var printUrl, printRaw bool
+
+	cmd := &cobra.Command{
+		Use:   "permission-denied-events",
+		Short: "Prints archived cloudtrail permission-denied events",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+			pages, err := opts.events(ctx, false)
+			if err != nil {
+				return err
+			}
+			_, err = printPermissionDeniedEvents(NewPrinter(printUrl, printRaw), pages, nil)
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVarP(&printUrl, "url", "u", false, "Generates Url link to cloud console cloudtrail event")
+	cmd.Flags().BoolVarP(&printRaw, "raw-event", "r", false, "Prints the cloudtrail events to the console in raw json format")
+
+	return cmd
+}
+
+// events returns the archived events within the requested period. Cancelling ctx stops
+// reading the archive if the caller doesn't drain the channel.
+func (o *analyzeOptions) events(ctx context.Context, writeOnly bool) (<-chan EventResult, error) {
+	period, err := o.period()
+	if err != nil {
+		return nil, err
+	}
+	source, err := o.source(ctx, writeOnly)
+	if err != nil {
+		return nil, err
+	}
+	return source.GetEvents("", period), nil
+}
+
+func (o *analyzeOptions) source(ctx context.Context, writeOnly bool) (EventSource, error) {
+	switch {
+	case o.FromDir != "" && o.FromS3 != "":
+		return nil, fmt.Errorf("--from-dir and --from-s3 cannot be used together")
+	case o.FromDir != "":
+		return NewDirArchiveSource(ctx, o.FromDir, writeOnly, o.Region)
+	case o.FromS3 != "":
+		var opts []func(*config.LoadOptions) error
+		if o.Profile != "" {
+			opts = append(opts, config.WithSharedConfigProfile(o.Profile))
+		}
+		if o.S3Region != "" {
+			opts = append(opts, config.WithRegion(o.S3Region))
+		}
+		cfg, err := config.LoadDefaultConfig(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		return NewS3ArchiveSource(ctx, s3.NewFromConfig(cfg), o.FromS3, writeOnly, o.Region)
+	}
+	return nil, fmt.Errorf("one of --from-dir or --from-s3 is required")
+}
+
+// period returns the requested time range. Unlike LookupEvents, archives are not limited
+// in time, so without any time flags the period is left open.
+func (o *analyzeOptions) period() (Period, error) {
+	if o.Duration != "" {
+		startTime, endTime, err := ParseStartEndTime(o.StartTime, o.EndTime, o.Duration)
+		if err != nil {
+			return Period{}, err
+		}
+		return Period{StartTime: startTime, EndTime: endTime}, nil
+	}
+
+	var period Period
+	var err error
+	if o.StartTime != "" {
+		if period.StartTime, err = ParseTimeAndValidate(o.StartTime); err != nil {
+			return Period{}, fmt.Errorf("[ERROR] Time Format Incorrect: %w", err)
+		}
+	}
+	if o.EndTime != "" {
+		if period.EndTime, err = ParseTimeAndValidate(o.EndTime); err != nil {
+			return Period{}, fmt.Errorf("[ERROR] Time Format Incorrect: %w", err)
+		}
+	}
+	if !period.StartTime.IsZero() && !period.EndTime.IsZero() && period.StartTime.After(period.EndTime) {
+		return Period{}, fmt.Errorf("start time %v is after end time %v", period.StartTime, period.EndTime)
+	}
+	return period, nil
+}
@@ -0,0 +1,413 @@
+package cloudtrail
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// archiveFileTimeSlack is how long after the events it contains a log file may be delivered.
+// CloudTrail delivers log files every ~5 minutes, this leaves plenty of margin.
+const archiveFileTimeSlack = time.Hour
+
+// archiveFileTimeRegexp matches the delivery timestamp in CloudTrail log file names, e.g.
+// 123456789012_CloudTrail_us-east-1_20250715T0905Z_Ab12Cd34Ef56Gh78.json.gz
+var archiveFileTimeRegexp = regexp.MustCompile(`_(\d{8}T\d{4}Z)_[^_]*\.json(\.gz)?$`)
+
+// archiveLog is the format of the log files CloudTrail delivers to S3
+type archiveLog struct {
+	Records []json.RawMessage `json:"Records"`
+}
+
+// archiveRecord holds the fields of a delivered CloudTrail record that LookupEvents
+// returns as typed event fields
+type archiveRecord struct {
+	EventID      string    `json:"eventID"`
+	EventName    string    `json:"eventName"`
+	EventSource  string    `json:"eventSource"`
+	EventTime    time.Time `json:"eventTime"`
+	AWSRegion    string    `json:"awsRegion"`
+	ReadOnly     *bool     `json:"readOnly"`
+	UserIdentity struct {
+		Type        string `json:"type"`
+		PrincipalID string `json:"principalId"`
+		UserName    string `json:"userName"`
+		AccessKeyID string `json:"accessKeyId"`
+	} `json:"userIdentity"`
+	Resources []struct {
+		ARN  string `json:"ARN"`
+		Type string `json:"type"`
+	} `json:"resources"`
+}
+
+// archiveReader lists and opens CloudTrail log files
+type archiveReader interface {
+	List(ctx context.Context) ([]string, error)
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// S3API is the subset of the S3 client used to read CloudTrail log files
+type S3API interface {
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// ArchiveSource reads CloudTrail events from log files in the format CloudTrail
+// delivers them to S3, either from a local directory or directly from a bucket.
+// It implements EventSource so archived events go through the same filters and
+// printers as events returned by LookupEvents.
+type ArchiveSource struct {
+	ctx       context.Context
+	reader    archiveReader
+	writeOnly bool
+	region    string
+}
+
+// NewDirArchiveSource creates an ArchiveSource reading all .json and .json.gz files below dir.
+// If writeOnly is set, read only events are skipped. If region is set, only events from
+// that region are returned. Reading stops when ctx is done.
+func NewDirArchiveSource(ctx context.Context, dir string, writeOnly bool, region string) (*ArchiveSource, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &ArchiveSource{ctx: ctx, reader: dirArchive{root: dir}, writeOnly: writeOnly, region: region}, nil
+}
+
+// NewS3ArchiveSource creates an ArchiveSource reading all .json and .json.gz objects below
+// an s3://bucket/prefix URI. Reading stops when ctx is done.
+func NewS3ArchiveSource(ctx context.Context, client S3API, uri string, writeOnly bool, region string) (*ArchiveSource, error) {
+	bucket, prefix, err := parseS3URI(uri)
+	if err != nil {
+		return nil, err
+	}
+	return &ArchiveSource{
+		ctx:       ctx,
+		reader:    s3Archive{client: client, bucket: bucket, prefix: prefix},
+		writeOnly: writeOnly,
+		region:    region,
+	}, nil
+}
+
+func parseS3URI(uri string) (string, string, error) {
+	path, ok := strings.CutPrefix(uri, "s3://")
+	if !ok {
+		return "", "", fmt.Errorf("invalid S3 URI %q (expected s3://bucket/prefix)", uri)
+	}
+	bucket, prefix, _ := strings.Cut(path, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("invalid S3 URI %q: missing bucket", uri)
+	}
+	return bucket, prefix, nil
+}
+
+// GetEvents returns the archived events within the period, newest first like LookupEvents.
+// Zero start or end times leave the period open on that side. The cluster ID is ignored,
+// the archive is expected to only contain the cluster's account.
+//
+// Log files are read newest first and events are sent as soon as no file left to read can
+// contain newer ones, so only the events of the files around the current position are held
+// in memory. The consumer may stop reading once the source's context is done.
+func (a *ArchiveSource) GetEvents(_ string, period Period) <-chan EventResult {
+	pageChan := make(chan EventResult)
+
+	go func() {
+		defer close(pageChan)
+
+		send := func(result EventResult) bool {
+			select {
+			case pageChan <- result:
+				return true
+			case <-a.ctx.Done():
+				return false
+			}
+		}
+
+		names, err := a.reader.List(a.ctx)
+		if err != nil {
+			send(EventResult{errors: fmt.Errorf("failed to list CloudTrail log files: %w", err)})
+			return
+		}
+		files := sortArchiveFiles(names)
+
+		var pending []types.Event
+		for i, file := range files {
+			if a.ctx.Err() != nil {
+				return
+			}
+			if !archiveFileInPeriod(file.name, period) {
+				continue
+			}
+			fileEvents, err := a.readFile(a.ctx, file.name)
+			if err != nil {
+				if !send(EventResult{errors: fmt.Errorf("failed to read %s: %w", file.name, err)}) {
+					return
+				}
+				continue
+			}
+			for _, event := range fileEvents {
+				if a.keep(event, period) {
+					pending = append(pending, event)
+				}
+			}
+
+			// Files left to read were delivered at or before the next file's delivery
+			// minute, so events after the end of that minute are final. Nothing is final
+			// while files without a delivery time, holding events of any time, are left.
+			var watermark time.Time
+			if i+1 < len(files) {
+				if files[i+1].delivered.IsZero() {
+					continue
+				}
+				watermark = files[i+1].delivered.Add(time.Minute)
+			}
+			var ready []types.Event
+			ready, pending = splitArchiveEvents(pending, watermark)
+			if len(ready) > 0 && !send(EventResult{AWSEvent: ready}) {
+				return
+			}
+		}
+	}()
+
+	return pageChan
+}
+
+// archiveFile is a log file and its delivery time, zero if the name doesn't contain one
+type archiveFile struct {
+	name      string
+	delivered time.Time
+}
+
+// sortArchiveFiles orders log files by delivery time, newest first. Files without a delivery
+// time can contain events of any time and are read first.
+func sortArchiveFiles(names []string) []archiveFile {
+	files := make([]archiveFile, 0, len(names))
+	for _, name := range names {
+		file := archiveFile{name: name}
+		if match := archiveFileTimeRegexp.FindStringSubmatch(name); match != nil {
+			file.delivered, _ = time.Parse("20060102T1504Z", match[1])
+		}
+		files = append(files, file)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		fi, fj := files[i], files[j]
+		if fi.delivered.IsZero() || fj.delivered.IsZero() {
+			return fi.delivered.IsZero() && !fj.delivered.IsZero()
+		}
+		if !fi.delivered.Equal(fj.delivered) {
+			return fi.delivered.After(fj.delivered)
+		}
+		return fi.name < fj.name
+	})
+	return files
+}
+
+// splitArchiveEvents sorts events newest first and splits off the ones after the watermark.
+// A zero watermark returns all events.
+func splitArchiveEvents(events []types.Event, watermark time.Time) ([]types.Event, []types.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].EventTime.After(*events[j].EventTime)
+	})
+	if watermark.IsZero() {
+		return events, nil
+	}
+	n := sort.Search(len(events), func(i int) bool { return !events[i].EventTime.After(watermark) })
+	// Copy the remaining events so sending the ready ones doesn't share their backing array
+	return events[:n], append([]types.Event(nil), events[n:]...)
+}
+
+func (a *ArchiveSource) readFile(ctx context.Context, name string) ([]types.Event, error) {
+	rc, err := a.reader.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var r io.Reader = rc
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(rc)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return ParseArchiveLog(r)
+}
+
+func (a *ArchiveSource) keep(event types.Event, period Period) bool {
+	if a.writeOnly && event.ReadOnly != nil && *event.ReadOnly == "true" {
+		return false
+	}
+	if !period.StartTime.IsZero() && event.EventTime.Before(period.StartTime) {
+		return false
+	}
+	if !period.EndTime.IsZero() && event.EventTime.After(period.EndTime) {
+		return false
+	}
+	if a.region != "" {
+		raw, err := ExtractUserDetails(event.CloudTrailEvent)
+		if err != nil || raw.EventRegion != a.region {
+			return false
+		}
+	}
+	return true
+}
+
+// archiveFileInPeriod uses the delivery timestamp in a log file name to skip files that
+// cannot contain events of the period. Files without a timestamp are always read.
+func archiveFileInPeriod(name string, period Period) bool {
+	match := archiveFileTimeRegexp.FindStringSubmatch(name)
+	if match == nil {
+		return true
+	}
+	delivered, err := time.Parse("20060102T1504Z", match[1])
+	if err != nil {
+		return true
+	}
+	if !period.StartTime.IsZero() && delivered.Before(period.StartTime.Truncate(time.Minute)) {
+		return false
+	}
+	if !period.EndTime.IsZero() && delivered.After(period.EndTime.Add(archiveFileTimeSlack)) {
+		return false
+	}
+	return true
+}
+
+// ParseArchiveLog parses an uncompressed CloudTrail log file ({"Records": [...]}) into events
+// shaped like the ones returned by LookupEvents
+func ParseArchiveLog(r io.Reader) ([]types.Event, error) {
+	var log archiveLog
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return nil, fmt.Errorf("invalid CloudTrail log file: %w", err)
+	}
+
+	events := make([]types.Event, 0, len(log.Records))
+	for i, raw := range log.Records {
+		event, err := archiveRecordToEvent(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CloudTrail record %d: %w", i, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func archiveRecordToEvent(raw json.RawMessage) (types.Event, error) {
+	var record archiveRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return types.Event{}, err
+	}
+
+	event := types.Event{
+		EventId:         aws.String(record.EventID),
+		EventName:       aws.String(record.EventName),
+		EventSource:     aws.String(record.EventSource),
+		EventTime:       aws.Time(record.EventTime),
+		CloudTrailEvent: aws.String(string(raw)),
+	}
+	if record.ReadOnly != nil {
+		event.ReadOnly = aws.String(fmt.Sprint(*record.ReadOnly))
+	}
+	if record.UserIdentity.AccessKeyID != "" {
+		event.AccessKeyId = aws.String(record.UserIdentity.AccessKeyID)
+	}
+
+	// LookupEvents reports the IAM user name, or the role session name for assumed roles
+	switch {
+	case record.UserIdentity.UserName != "":
+		event.Username = aws.String(record.UserIdentity.UserName)
+	case strings.Contains(record.UserIdentity.PrincipalID, ":"):
+		_, session, _ := strings.Cut(record.UserIdentity.PrincipalID, ":")
+		event.Username = aws.String(session)
+	}
+
+	for _, r := range record.Resources {
+		event.Resources = append(event.Resources, types.Resource{
+			ResourceName: aws.String(r.ARN),
+			ResourceType: aws.String(r.Type),
+		})
+	}
+	return event, nil
+}
+
+func isArchiveFile(name string) bool {
+	return strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json.gz")
+}
+
+// dirArchive reads log files from a local directory tree, e.g. a synced copy of the bucket
+type dirArchive struct {
+	root string
+}
+
+func (d dirArchive) List(_ context.Context) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(d.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && isArchiveFile(entry.Name()) {
+			names = append(names, path)
+		}
+		return nil
+	})
+	return names, err
+}
+
+func (d dirArchive) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+// s3Archive reads log files directly from the CloudTrail bucket
+type s3Archive struct {
+	client S3API
+	bucket string
+	prefix string
+}
+
+func (s s3Archive) List(ctx context.Context) ([]string, error) {
+	var names []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			if object.Key != nil && isArchiveFile(*object.Key) {
+				names = append(names, *object.Key)
+			}
+		}
+	}
+	return names, nil
+}
+
+func (s s3Archive) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
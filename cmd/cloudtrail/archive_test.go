@@ -0,0 +1,282 @@
+package cloudtrail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const archiveTestDir = "testdata/archive"
+
+// fakeS3 serves the files below a local directory as objects of a bucket
+type fakeS3 struct {
+	root string
+}
+
+func (f fakeS3) ListObjectsV2(_ context.Context, params *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	output := &s3.ListObjectsV2Output{}
+	err := filepath.Walk(f.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		key, _ := filepath.Rel(f.root, path)
+		if strings.HasPrefix(key, aws.ToString(params.Prefix)) {
+			output.Contents = append(output.Contents, s3types.Object{Key: aws.String(key)})
+		}
+		return nil
+	})
+	return output, err
+}
+
+func (f fakeS3) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	file, err := os.Open(filepath.Join(f.root, aws.ToString(params.Key)))
+	if err != nil {
+		return nil, fmt.Errorf("NoSuchKey: %w", err)
+	}
+	return &s3.GetObjectOutput{Body: file}, nil
+}
+
+func collectEvents(t *testing.T, source EventSource, period Period) []types.Event {
+	var events []types.Event
+	for page := range source.GetEvents("", period) {
+		require.NoError(t, page.errors)
+		events = append(events, page.AWSEvent...)
+	}
+	return events
+}
+
+func TestArchiveSourceFromDir(t *testing.T) {
+	tests := []struct {
+		name      string
+		writeOnly bool
+		region    string
+		period    Period
+		want      []string
+	}{
+		{
+			name: "all events newest first",
+			want: []string{"CreateBucket", "DeleteBucket", "TerminateInstances", "DescribeInstances", "RunInstances"},
+		},
+		{
+			name:      "write only",
+			writeOnly: true,
+			want:      []string{"CreateBucket", "DeleteBucket", "TerminateInstances", "RunInstances"},
+		},
+		{
+			name:   "region",
+			region: "eu-west-1",
+			want:   []string{"CreateBucket", "DeleteBucket"},
+		},
+		{
+			name:   "period",
+			period: Period{StartTime: time.Date(2025, 7, 15, 9, 2, 0, 0, time.UTC), EndTime: time.Date(2025, 7, 15, 10, 0, 30, 0, time.UTC)},
+			want:   []string{"DeleteBucket", "TerminateInstances", "DescribeInstances"},
+		},
+		{
+			name:   "open ended period",
+			period: Period{StartTime: time.Date(2025, 7, 15, 9, 30, 0, 0, time.UTC)},
+			want:   []string{"CreateBucket", "DeleteBucket"},
+		},
+		{
+			name:   "period before the archive",
+			period: Period{EndTime: time.Date(2025, 7, 14, 0, 0, 0, 0, time.UTC)},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewDirArchiveSource(context.Background(), archiveTestDir, tt.writeOnly, tt.region)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, eventNames(collectEvents(t, source, tt.period)))
+		})
+	}
+
+	_, err := NewDirArchiveSource(context.Background(), filepath.Join(archiveTestDir, "missing"), false, "")
+	assert.Error(t, err)
+}
+
+// memArchive serves log files from memory
+type memArchive map[string]string
+
+func (m memArchive) List(_ context.Context) ([]string, error) {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (m memArchive) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(m[name])), nil
+}
+
+// memArchiveLog returns a log file with one event per name, at the given minute past 09:00
+func memArchiveLog(events map[string]int) string {
+	var records []string
+	for name, minute := range events {
+		records = append(records, fmt.Sprintf(`{"eventVersion": "1.8", "eventID": %q, "eventName": %q, "eventTime": "2025-07-15T09:%02d:00Z"}`, name, name, minute))
+	}
+	return `{"Records": [` + strings.Join(records, ",") + `]}`
+}
+
+func TestArchiveSourceStreamsInOrder(t *testing.T) {
+	// Files deliver events up to a few minutes late, so their time ranges overlap
+	source := &ArchiveSource{ctx: context.Background(), reader: memArchive{
+		"123456789012_CloudTrail_us-east-1_20250715T0920Z_c.json": memArchiveLog(map[string]int{"E": 18, "G": 12}),
+		"123456789012_CloudTrail_us-east-1_20250715T0915Z_b.json": memArchiveLog(map[string]int{"F": 14, "C": 7}),
+		"123456789012_CloudTrail_us-east-1_20250715T0910Z_a.json": memArchiveLog(map[string]int{"D": 9, "B": 3}),
+		"123456789012_CloudTrail_us-east-1_20250715T0905Z_z.json": memArchiveLog(map[string]int{"A": 1}),
+	}}
+
+	var pages [][]string
+	for page := range source.GetEvents("", Period{}) {
+		require.NoError(t, page.errors)
+		pages = append(pages, eventNames(page.AWSEvent))
+	}
+	assert.Equal(t, [][]string{{"E"}, {"F", "G"}, {"D", "C"}, {"B", "A"}}, pages)
+}
+
+func TestArchiveSourceStreamsUndatedFilesInOrder(t *testing.T) {
+	// Files without a delivery time are read first and can hold events of any time
+	source := &ArchiveSource{ctx: context.Background(), reader: memArchive{
+		"export-1.json": memArchiveLog(map[string]int{"B": 2}),
+		"export-2.json": memArchiveLog(map[string]int{"E": 19}),
+		"123456789012_CloudTrail_us-east-1_20250715T0910Z_a.json": memArchiveLog(map[string]int{"D": 9}),
+		"123456789012_CloudTrail_us-east-1_20250715T0905Z_z.json": memArchiveLog(map[string]int{"A": 1}),
+	}}
+
+	var pages [][]string
+	for page := range source.GetEvents("", Period{}) {
+		require.NoError(t, page.errors)
+		pages = append(pages, eventNames(page.AWSEvent))
+	}
+	assert.Equal(t, [][]string{{"E"}, {"D"}, {"B", "A"}}, pages)
+}
+
+func TestArchiveSourceStopsWhenCancelled(t *testing.T) {
+	archive := memArchive{}
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("123456789012_CloudTrail_us-east-1_20250715T09%02dZ_x.json", i)
+		archive[name] = memArchiveLog(map[string]int{fmt.Sprintf("E%d", i): i})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	source := &ArchiveSource{ctx: ctx, reader: archive}
+
+	pages := source.GetEvents("", Period{})
+	<-pages
+	cancel()
+
+	// At most the page being sent when the context was cancelled is still delivered
+	remaining := 0
+	for range pages {
+		remaining++
+	}
+	assert.LessOrEqual(t, remaining, 1)
+}
+
+func TestArchiveSourceFromS3(t *testing.T) {
+	source, err := NewS3ArchiveSource(context.Background(), fakeS3{root: archiveTestDir}, "s3://trail-bucket/AWSLogs/123456789012/CloudTrail/us-east-1/", false, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TerminateInstances", "DescribeInstances", "RunInstances"}, eventNames(collectEvents(t, source, Period{})))
+
+	for _, uri := range []string{"trail-bucket/AWSLogs", "s3:///AWSLogs"} {
+		_, err := NewS3ArchiveSource(context.Background(), fakeS3{}, uri, false, "")
+		assert.Error(t, err, uri)
+	}
+}
+
+func TestArchiveEventsMatchLookupEvents(t *testing.T) {
+	source, err := NewDirArchiveSource(context.Background(), archiveTestDir, false, "us-east-1")
+	require.NoError(t, err)
+	events := collectEvents(t, source, Period{})
+	require.Len(t, events, 3)
+
+	run := events[2]
+	assert.Equal(t, "e1", aws.ToString(run.EventId))
+	assert.Equal(t, "ec2.amazonaws.com", aws.ToString(run.EventSource))
+	assert.Equal(t, "alice", aws.ToString(run.Username))
+	assert.Equal(t, "false", aws.ToString(run.ReadOnly))
+	assert.Equal(t, "ASIAEXAMPLE", aws.ToString(run.AccessKeyId))
+	assert.Equal(t, time.Date(2025, 7, 15, 9, 1, 10, 0, time.UTC), aws.ToTime(run.EventTime))
+	require.Len(t, run.Resources, 1)
+	assert.Equal(t, "arn:aws:ec2:us-east-1:123456789012:instance/i-0123", aws.ToString(run.Resources[0].ResourceName))
+	assert.Equal(t, "AWS::EC2::Instance", aws.ToString(run.Resources[0].ResourceType))
+
+	// The raw record is kept, so the existing filters work unchanged
+	raw, err := ExtractUserDetails(run.CloudTrailEvent)
+	require.NoError(t, err)
+	assert.Equal(t, "ManagedOpenShift-Support", raw.UserIdentity.SessionContext.SessionIssuer.UserName)
+
+	forbidden, err := ApplyFilters(events, isforbiddenEvent)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TerminateInstances"}, eventNames(forbidden))
+
+	filter, err := CompileQuery("event~Instances$ && username=alice && readOnly=false")
+	require.NoError(t, err)
+	filtered, err := ApplyFilters(events, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"RunInstances"}, eventNames(filtered))
+}
+
+func TestParseArchiveLogErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"not json",
+		`{"Records": [{"eventTime": "yesterday"}]}`,
+	} {
+		_, err := ParseArchiveLog(strings.NewReader(input))
+		assert.Error(t, err, input)
+	}
+
+	events, err := ParseArchiveLog(strings.NewReader(`{"Records": []}`))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestArchiveFileInPeriod(t *testing.T) {
+	name := "AWSLogs/123456789012/CloudTrail/us-east-1/2025/07/15/123456789012_CloudTrail_us-east-1_20250715T0905Z_AbCdEf123456.json.gz"
+	at := func(hour, minute int) time.Time { return time.Date(2025, 7, 15, hour, minute, 0, 0, time.UTC) }
+
+	assert.True(t, archiveFileInPeriod(name, Period{}))
+	assert.True(t, archiveFileInPeriod(name, Period{StartTime: at(9, 0), EndTime: at(9, 1)}))
+	assert.True(t, archiveFileInPeriod(name, Period{StartTime: at(8, 30), EndTime: at(8, 45)}))
+	assert.False(t, archiveFileInPeriod(name, Period{StartTime: at(9, 10)}))
+	assert.False(t, archiveFileInPeriod(name, Period{EndTime: at(7, 0)}))
+	assert.True(t, archiveFileInPeriod("digest.json", Period{StartTime: at(9, 10)}))
+}
+
+func TestAnalyzePeriod(t *testing.T) {
+	period, err := (&analyzeOptions{}).period()
+	require.NoError(t, err)
+	assert.True(t, period.StartTime.IsZero() && period.EndTime.IsZero())
+
+	period, err = (&analyzeOptions{StartTime: "2025-07-15,09:00:00"}).period()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 7, 15, 9, 0, 0, 0, time.UTC), period.StartTime)
+	assert.True(t, period.EndTime.IsZero())
+
+	period, err = (&analyzeOptions{StartTime: "2025-07-15,09:00:00", Duration: "2h"}).period()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 7, 15, 11, 0, 0, 0, time.UTC), period.EndTime)
+
+	_, err = (&analyzeOptions{StartTime: "2025-07-15,10:00:00", EndTime: "2025-07-15,09:00:00"}).period()
+	assert.Error(t, err)
+
+	_, err = (&analyzeOptions{}).source(context.Background(), false)
+	assert.Error(t, err)
+	_, err = (&analyzeOptions{FromDir: archiveTestDir, FromS3: "s3://bucket"}).source(context.Background(), false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,341 @@
+package cloudtrail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// cacheStats summarizes the cached events of a cluster
+type cacheStats struct {
+	ClusterID  string
+	Segments   int
+	Events     int
+	Size       int64
+	Oldest     time.Time
+	Newest     time.Time
+	LastAccess time.Time
+	Periods    map[string][]Period
+	Regions    map[string]int
+	EventNames map[string]int
+}
+
+func newCmdCache() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and manage the local write-events cache",
+		Long: fmt.Sprintf(`Inspect and manage the local cache of CloudTrail events used by write-events.
+
+Events are cached per cluster in compressed segments per region and day. Once
+the cache grows beyond its maximum size, the least recently used segments are
+evicted. The maximum size defaults to %d MB and can be changed with the
+'%s' key (in MB) of the osdctl config file.`, defaultCacheMaxSizeMB, CacheMaxSizeKey),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cacheCmd.AddCommand(newCmdCacheStats())
+	cacheCmd.AddCommand(newCmdCachePrune())
+	cacheCmd.AddCommand(newCmdCacheClear())
+
+	return cacheCmd
+}
+
+func newCmdCacheStats() *cobra.Command {
+	var clusterID string
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show the size and contents of the write-events cache",
+		Example: `  # Show the cache usage of all clusters
+  osdctl cloudtrail cache stats
+
+  # Show the cached periods, regions and most frequent events of a cluster
+  osdctl cloudtrail cache stats -C ${CLUSTER_ID}`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := CacheDir()
+			if err != nil {
+				return err
+			}
+			if clusterID != "" {
+				if _, err := clusterCacheDir(root, clusterID); err != nil {
+					return err
+				}
+			}
+			stats, err := collectCacheStats(logrus.StandardLogger(), root, clusterID)
+			if err != nil {
+				return err
+			}
+			if clusterID != "" {
+				if len(stats) == 0 {
+					return fmt.Errorf("no cached events for cluster %s", clusterID)
+				}
+				return printClusterCacheStats(stats[0])
+			}
+			return printCacheStats(stats)
+		},
+	}
+
+	statsCmd.Flags().StringVarP(&clusterID, "cluster-id", "C", "", "Only show the cache of this cluster")
+
+	return statsCmd
+}
+
+func newCmdCachePrune() *cobra.Command {
+	var (
+		clusterID string
+		maxSize   int64
+		olderThan time.Duration
+	)
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove old or least recently used events from the write-events cache",
+		Example: `  # Shrink the cache to 100 MB, evicting the least recently used segments first
+  osdctl cloudtrail cache prune --max-size 100
+
+  # Remove cached events older than 30 days of a cluster
+  osdctl cloudtrail cache prune -C ${CLUSTER_ID} --older-than 720h`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := CacheDir()
+			if err != nil {
+				return err
+			}
+			if clusterID != "" {
+				if _, err := clusterCacheDir(root, clusterID); err != nil {
+					return err
+				}
+			}
+			var cutoff time.Time
+			if olderThan > 0 {
+				cutoff = time.Now().UTC().Add(-olderThan)
+			}
+			if maxSize < 0 {
+				return fmt.Errorf("--max-size must not be negative")
+			}
+			if maxSize == 0 {
+				maxSize = cacheMaxSizeMB()
+			}
+			evicted, err := pruneCache(logrus.StandardLogger(), root, maxSize*1024*1024, cutoff, clusterID)
+			if err != nil {
+				return err
+			}
+			var size int64
+			for _, e := range evicted {
+				size += e.Size
+			}
+			fmt.Printf("Removed %d segment(s), %s\n", len(evicted), formatCacheSize(size))
+			return nil
+		},
+	}
+
+	pruneCmd.Flags().StringVarP(&clusterID, "cluster-id", "C", "", "Only prune the cache of this cluster")
+	pruneCmd.Flags().Int64Var(&maxSize, "max-size", 0, "Maximum size of the whole cache in MB (default: the configured maximum size)")
+	pruneCmd.Flags().DurationVar(&olderThan, "older-than", 0, "Remove segments whose newest event is older than this duration (e.g. 720h)")
+
+	return pruneCmd
+}
+
+func newCmdCacheClear() *cobra.Command {
+	var (
+		clusterID string
+		all       bool
+		yes       bool
+	)
+
+	clearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Delete the write-events cache of a cluster or of all clusters",
+		Example: `  # Delete the cache of a cluster
+  osdctl cloudtrail cache clear -C ${CLUSTER_ID}
+
+  # Delete the whole cache without confirmation
+  osdctl cloudtrail cache clear --all --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if clusterID == "" && !all || clusterID != "" && all {
+				return fmt.Errorf("exactly one of --cluster-id or --all is required")
+			}
+			root, err := CacheDir()
+			if err != nil {
+				return err
+			}
+
+			target := root
+			if clusterID != "" {
+				if target, err = clusterCacheDir(root, clusterID); err != nil {
+					return err
+				}
+			}
+			if !yes {
+				fmt.Printf("Deleting %s\n", target)
+				if !utils.ConfirmPrompt() {
+					return nil
+				}
+			}
+			if clusterID != "" {
+				// Also remove a cache file written by previous versions
+				if err := os.Remove(filepath.Join(root, clusterID+".json")); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+			return os.RemoveAll(target)
+		},
+	}
+
+	clearCmd.Flags().StringVarP(&clusterID, "cluster-id", "C", "", "Cluster whose cache is deleted")
+	clearCmd.Flags().BoolVar(&all, "all", false, "Delete the cache of all clusters")
+	clearCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+
+	return clearCmd
+}
+
+// collectCacheStats summarizes the cache of every cluster, or of a single one, sorted by cluster ID
+func collectCacheStats(log *logrus.Logger, root, clusterID string) ([]cacheStats, error) {
+	indexes, err := readCacheIndexes(log, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []cacheStats
+	for id, index := range indexes {
+		if clusterID != "" && id != clusterID {
+			continue
+		}
+		s := cacheStats{
+			ClusterID:  id,
+			Segments:   len(index.Segments),
+			Size:       index.size(),
+			Periods:    index.Periods,
+			Regions:    map[string]int{},
+			EventNames: map[string]int{},
+		}
+		for _, segment := range index.Segments {
+			s.Events += segment.Events
+			s.Regions[segment.Region] += segment.Events
+			for name, count := range segment.EventNames {
+				s.EventNames[name] += count
+			}
+			if s.Oldest.IsZero() || segment.First.Before(s.Oldest) {
+				s.Oldest = segment.First
+			}
+			if segment.Last.After(s.Newest) {
+				s.Newest = segment.Last
+			}
+			if segment.LastAccess.After(s.LastAccess) {
+				s.LastAccess = segment.LastAccess
+			}
+		}
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ClusterID < stats[j].ClusterID })
+	return stats, nil
+}
+
+func printCacheStats(stats []cacheStats) error {
+	var total int64
+	p := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	p.AddRow([]string{"CLUSTER ID", "SEGMENTS", "EVENTS", "SIZE", "OLDEST EVENT", "NEWEST EVENT", "LAST USED"})
+	for _, s := range stats {
+		total += s.Size
+		p.AddRow([]string{
+			s.ClusterID,
+			strconv.Itoa(s.Segments),
+			strconv.Itoa(s.Events),
+			formatCacheSize(s.Size),
+			formatCacheTime(s.Oldest),
+			formatCacheTime(s.Newest),
+			formatCacheTime(s.LastAccess),
+		})
+	}
+	if err := p.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nTotal: %s\n", formatCacheSize(total))
+	return nil
+}
+
+func printClusterCacheStats(s cacheStats) error {
+	fmt.Printf("Cluster:     %s\n", s.ClusterID)
+	fmt.Printf("Segments:    %d\n", s.Segments)
+	fmt.Printf("Events:      %d\n", s.Events)
+	fmt.Printf("Size:        %s\n", formatCacheSize(s.Size))
+	fmt.Printf("Last used:   %s\n", formatCacheTime(s.LastAccess))
+
+	fmt.Println("\nFetched periods:")
+	regions := make([]string, 0, len(s.Periods))
+	for region := range s.Periods {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	for _, region := range regions {
+		fmt.Printf("  %s:\n", region)
+		for _, period := range s.Periods[region] {
+			fmt.Printf("    %s - %s\n", formatCacheTime(period.StartTime), formatCacheTime(period.EndTime))
+		}
+	}
+
+	fmt.Println("\nEvents per region:")
+	p := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	for _, region := range sortedByCount(s.Regions, 0) {
+		p.AddRow([]string{"  " + region, strconv.Itoa(s.Regions[region])})
+	}
+	if err := p.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println("\nMost frequent events:")
+	p = printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	for _, name := range sortedByCount(s.EventNames, 10) {
+		p.AddRow([]string{"  " + name, strconv.Itoa(s.EventNames[name])})
+	}
+	return p.Flush()
+}
+
+// sortedByCount returns the keys with the highest counts first, at most limit keys if limit is set
+func sortedByCount(counts map[string]int, limit int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
+func formatCacheSize(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+func formatCacheTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
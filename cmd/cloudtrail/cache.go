@@ -1,146 +1,691 @@
 package cloudtrail
 
 import (
+	"compress/gzip"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 )
 
-// Cache struct stores CloudTrail periods and their corresponding events,
+const (
+	// CacheMaxSizeKey is the osdctl config key for the maximum size of the write-events cache in MB
+	CacheMaxSizeKey = "cloudtrail_cache_max_size"
+
+	defaultCacheMaxSizeMB = 512
+	cacheIndexVersion     = 3
+	cacheIndexFile        = "index.json"
+	cacheDayFormat        = "2006-01-02"
+	unknownRegion         = "unknown"
+)
+
+// Cache stores the CloudTrail events of a cluster and the periods they were fetched for.
+//
+// Events are stored in compressed segments per region and day, so a run only reads and
+// rewrites the segments of the days it touches. An index per cluster records the fetched
+// periods of every region and the time range, event names, size and last access of every
+// segment; it is used to skip segments and to evict the least recently used ones once the
+// cache grows beyond its maximum size.
+//
+// A Cache reads and saves the fetched periods of a single region, the one LookupEvents was
+// called for.
 type Cache struct {
-	log      *logrus.Logger
-	filename string
-	Period   []Period
-	Event    []types.Event
+	log     *logrus.Logger
+	root    string
+	dir     string
+	legacy  string
+	region  string
+	maxSize int64
+	index   *cacheIndex
+
+	// Period and Event hold the fetched periods of the region and the events passed to Save
+	Period []Period
+	Event  []types.Event
+}
+
+// errInvalidCacheIndex is returned for index files that can't be used, e.g. because they
+// were written by an incompatible version of osdctl
+var errInvalidCacheIndex = errors.New("invalid cache index")
+
+// cacheIndex is the on-disk index of a cluster cache
+type cacheIndex struct {
+	Version int `json:"version"`
+	// Periods holds the fetched periods per region
+	Periods  map[string][]Period      `json:"periods"`
+	Segments map[string]*cacheSegment `json:"segments"`
+}
+
+// cacheSegment describes the events of a region and day
+type cacheSegment struct {
+	Region     string         `json:"region"`
+	Day        string         `json:"day"`
+	File       string         `json:"file"`
+	Events     int            `json:"events"`
+	Size       int64          `json:"size"`
+	First      time.Time      `json:"first"`
+	Last       time.Time      `json:"last"`
+	EventNames map[string]int `json:"eventNames"`
+	LastAccess time.Time      `json:"lastAccess"`
 }
 
-func NewCache(log *logrus.Logger, clusterID string) (*Cache, error) {
+// CacheDir returns the directory holding the write-events cache of all clusters
+func CacheDir() (string, error) {
 	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "osdctl", "cloudtrail", "write-events"), nil
+}
+
+// clusterCacheDir returns the cache directory of a cluster, refusing cluster IDs which are not
+// a cluster key or do not name a direct child of the cache directory
+func clusterCacheDir(root, clusterID string) (string, error) {
+	if err := utils.IsValidClusterKey(clusterID); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, clusterID)
+	if filepath.Dir(dir) != filepath.Clean(root) {
+		return "", fmt.Errorf("cluster %s is not in the cache directory %s", clusterID, root)
+	}
+	return dir, nil
+}
+
+// NewCache returns the cache of the events a cluster's account logged in region
+func NewCache(log *logrus.Logger, clusterID, region string) (*Cache, error) {
+	root, err := CacheDir()
 	if err != nil {
 		return nil, err
 	}
-	cacheDir = filepath.Join(cacheDir, "osdctl", "cloudtrail", "write-events")
-	filename := filepath.Join(cacheDir, clusterID+".json")
+	return newCacheAt(log, root, clusterID, region), nil
+}
+
+// cacheMaxSizeMB returns the configured maximum size of the cache in MB
+func cacheMaxSizeMB() int64 {
+	if maxSize := viper.GetInt64(CacheMaxSizeKey); maxSize > 0 {
+		return maxSize
+	}
+	return defaultCacheMaxSizeMB
+}
 
+func newCacheAt(log *logrus.Logger, root, clusterID, region string) *Cache {
 	return &Cache{
-		log:      log,
-		filename: filename,
-		Period:   []Period{},
-		Event:    []types.Event{},
-	}, nil
+		log:     log,
+		root:    root,
+		dir:     filepath.Join(root, clusterID),
+		legacy:  filepath.Join(root, clusterID+".json"),
+		region:  region,
+		maxSize: cacheMaxSizeMB() * 1024 * 1024,
+		Period:  []Period{},
+		Event:   []types.Event{},
+	}
 }
 
+// EnsureFilenameExist creates the cache directory of the cluster and migrates a cache
+// file written by previous versions of osdctl
 func (c *Cache) EnsureFilenameExist() error {
-	cacheDir := filepath.Dir(c.filename)
-
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
 		c.log.Errorf("failed to create cache directory: %v", err)
 		return err
 	}
 
-	if _, err := os.Stat(c.filename); os.IsNotExist(err) {
-		emptyCache := Cache{
-			log:      c.log,
-			filename: c.filename,
-			Period:   []Period{},
-			Event:    []types.Event{},
-		}
-
-		data, err := json.MarshalIndent(emptyCache, "", "  ")
-		if err != nil {
-			c.log.Errorf("failed to marshal empty cache: %v", err)
-			return err
-		}
-		if err := os.WriteFile(c.filename, data, 0600); err != nil {
-			c.log.Errorf("failed to create cache file: %v", err)
+	if _, err := os.Stat(c.legacy); err == nil {
+		if err := c.migrate(); err != nil {
+			c.log.Errorf("failed to migrate cache file %s: %v", c.legacy, err)
 			return err
 		}
-		c.log.Debugf("Created new cache file: %s", c.filename)
-		return nil
-
-	} else if err != nil {
+	} else if !os.IsNotExist(err) {
 		c.log.Errorf("error checking cache file: %v", err)
 		return err
 	}
 
-	c.log.Debugf("Cache file already exists: %s", c.filename)
 	return nil
 }
 
-// CacheInit initializes the cache directory.
-// Creates the directory/cache file if it doesn't exist
-func (c *Cache) Read() error {
-	data, err := os.ReadFile(c.filename)
+// migrate moves the events of a single file cache into segments
+func (c *Cache) migrate() error {
+	data, err := os.ReadFile(c.legacy)
 	if err != nil {
-		c.log.Errorf("failed to read cache file: %v", err)
 		return err
 	}
 
-	if err := json.Unmarshal(data, &c); err != nil {
-		c.log.Errorf("failed to unmarshal get events: %v", err)
+	var legacy struct {
+		Period []Period
+		Event  []types.Event
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
 		return err
 	}
 
-	if len(c.Event) == 0 {
-		c.log.Debugf("Cache file is empty")
+	if err := c.Read(); err != nil {
+		return err
+	}
+	// The file held the events of every region fetched for the cluster under the same periods
+	for region := range groupByRegion(legacy.Event) {
+		c.index.addPeriods(region, legacy.Period)
+	}
+	if err := c.Save(Cache{Event: legacy.Event}); err != nil {
+		return err
+	}
+
+	c.log.Infof("Migrated %d cached events from %s", len(legacy.Event), c.legacy)
+	return os.Remove(c.legacy)
+}
+
+// Read loads the cache index. Events are only read from the segments when looked up.
+// An index that can't be used is discarded together with the cached events of the cluster.
+func (c *Cache) Read() error {
+	index, err := readCacheIndex(c.dir)
+	if errors.Is(err, errInvalidCacheIndex) {
+		c.log.Warnf("Discarding the cache of %s: %v", c.dir, err)
+		if err := os.RemoveAll(c.dir); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(c.dir, 0755); err != nil {
+			return err
+		}
+		index, err = readCacheIndex(c.dir)
+	}
+	if err != nil {
+		c.log.Errorf("failed to read cache index: %v", err)
+		return err
+	}
+	c.index = index
+	c.Period = index.Periods[c.region]
+
+	if len(index.Segments) == 0 {
+		c.log.Debugf("Cache is empty")
 	}
 
 	return nil
 }
 
-// PutCache saves the cloudtrail events to the cache file.
-// Adding new time periods and events to the cache.
-// Merging new data with existing overlapping data.
+// Save adds new time periods and events to the cache, merging them with the
+// cached periods and the events of the same segments. Events already in the
+// cache are not duplicated.
 func (c *Cache) Save(newCacheEvents Cache) error {
-	if c == nil {
-		c = &Cache{
-			Period: []Period{},
-			Event:  []types.Event{},
+	if c.index == nil {
+		if err := c.Read(); err != nil {
+			return err
 		}
 	}
 
+	c.index.addPeriods(c.region, newCacheEvents.Period)
+	c.Period = c.index.Periods[c.region]
+
+	now := time.Now().UTC()
+	for key, events := range groupBySegment(newCacheEvents.Event) {
+		segment, ok := c.index.Segments[key]
+		if !ok {
+			segment = &cacheSegment{Region: events[0].region, Day: events[0].day, File: key + ".json.gz"}
+			c.index.Segments[key] = segment
+		}
+
+		existing, err := readSegment(filepath.Join(c.dir, segment.File))
+		if err != nil && !os.IsNotExist(err) {
+			c.log.Errorf("failed to read cache segment %s: %v", segment.File, err)
+			return err
+		}
+		merged := mergeEvents(existing, events)
+
+		size, err := writeSegment(filepath.Join(c.dir, segment.File), merged)
+		if err != nil {
+			c.log.Errorf("failed to write cache segment %s: %v", segment.File, err)
+			return err
+		}
+		segment.update(merged, size, now)
+	}
+
+	if err := c.index.write(c.dir); err != nil {
+		c.log.Errorf("failed to write cache index: %v", err)
+		return err
+	}
+
+	evicted, err := pruneCache(c.log, c.root, c.maxSize, time.Time{}, "")
+	if err != nil {
+		return err
+	}
+	for _, e := range evicted {
+		c.log.Debugf("Evicted cache segment %s of cluster %s", e.Key, e.ClusterID)
+	}
+	if len(evicted) > 0 {
+		// Eviction may have removed periods of this cluster
+		return c.Read()
+	}
+	return nil
+}
+
+// Lookup returns the cached events within the requested period, newest first. If region
+// is set only events of that region are returned, if event names are given only events
+// with one of those names. Only segments that can contain matching events are read.
+//
+// The last access of the segments read is only updated in memory and written to the index
+// by the next Save, so lookups never modify the cache.
+func (c *Cache) Lookup(requestedPeriod Period, region string, eventNames ...string) []types.Event {
+	if c.index == nil {
+		return nil
+	}
+
+	covered := false
+	for r, periods := range c.index.Periods {
+		if region != "" && r != region {
+			continue
+		}
+		for _, period := range periods {
+			if period.Overlap(requestedPeriod) {
+				covered = true
+			}
+		}
+	}
+	if !covered {
+		return nil
+	}
+
+	names := map[string]bool{}
+	for _, name := range eventNames {
+		names[name] = true
+	}
+
+	var eventsInCache []types.Event
+	for _, segment := range c.index.sortedSegments() {
+		if !segment.matches(requestedPeriod, region, names) {
+			continue
+		}
+		events, err := readSegment(filepath.Join(c.dir, segment.File))
+		if err != nil {
+			c.log.Warnf("failed to read cache segment %s: %v", segment.File, err)
+			continue
+		}
+		segment.LastAccess = time.Now().UTC()
+
+		for _, event := range events {
+			if event.EventTime == nil || event.EventTime.Before(requestedPeriod.StartTime) || event.EventTime.After(requestedPeriod.EndTime) {
+				continue
+			}
+			if len(names) > 0 && (event.EventName == nil || !names[*event.EventName]) {
+				continue
+			}
+			eventsInCache = append(eventsInCache, event)
+		}
+	}
+
+	sortEventsNewestFirst(eventsInCache)
+	return eventsInCache
+}
+
+func (s *cacheSegment) matches(period Period, region string, names map[string]bool) bool {
+	if region != "" && s.Region != region {
+		return false
+	}
+	if s.Last.Before(period.StartTime) || s.First.After(period.EndTime) {
+		return false
+	}
+	if len(names) == 0 {
+		return true
+	}
+	for name := range names {
+		if s.EventNames[name] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *cacheSegment) update(events []types.Event, size int64, now time.Time) {
+	s.Events = len(events)
+	s.Size = size
+	s.LastAccess = now
+	s.EventNames = map[string]int{}
+	s.First, s.Last = time.Time{}, time.Time{}
+	for _, event := range events {
+		if event.EventName != nil {
+			s.EventNames[*event.EventName]++
+		}
+		if event.EventTime == nil {
+			continue
+		}
+		if s.First.IsZero() || event.EventTime.Before(s.First) {
+			s.First = *event.EventTime
+		}
+		if event.EventTime.After(s.Last) {
+			s.Last = *event.EventTime
+		}
+	}
+}
+
+func (idx *cacheIndex) sortedSegments() []*cacheSegment {
+	keys := make([]string, 0, len(idx.Segments))
+	for key := range idx.Segments {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	segments := make([]*cacheSegment, 0, len(keys))
+	for _, key := range keys {
+		segments = append(segments, idx.Segments[key])
+	}
+	return segments
+}
+
+// addPeriods merges fetched periods into the periods of a region
+func (idx *cacheIndex) addPeriods(region string, periods []Period) {
+	if len(periods) == 0 {
+		return
+	}
 	var allPeriods []Period
-	allPeriods = append(allPeriods, c.Period...)
-	allPeriods = append(allPeriods, newCacheEvents.Period...)
+	allPeriods = append(allPeriods, idx.Periods[region]...)
+	allPeriods = append(allPeriods, periods...)
 	sort.Sort(Periods(allPeriods))
-	newPeriods := Merge(allPeriods)
+	idx.Periods[region] = Merge(allPeriods)
+}
+
+func (idx *cacheIndex) size() int64 {
+	var size int64
+	for _, segment := range idx.Segments {
+		size += segment.Size
+	}
+	return size
+}
+
+// readCacheIndex reads the index of a cluster cache. Unreadable JSON and indexes of other
+// versions are reported as errInvalidCacheIndex.
+func readCacheIndex(dir string) (*cacheIndex, error) {
+	index := &cacheIndex{Version: cacheIndexVersion, Periods: map[string][]Period{}, Segments: map[string]*cacheSegment{}}
+
+	data, err := os.ReadFile(filepath.Join(dir, cacheIndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the version first, older indexes have a different layout
+	var version struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidCacheIndex, err)
+	}
+	if version.Version != cacheIndexVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", errInvalidCacheIndex, version.Version)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidCacheIndex, err)
+	}
+	if index.Periods == nil {
+		index.Periods = map[string][]Period{}
+	}
+	if index.Segments == nil {
+		index.Segments = map[string]*cacheSegment{}
+	}
+	return index, nil
+}
+
+func (idx *cacheIndex) write(dir string) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, cacheIndexFile), data)
+}
+
+func readSegment(path string) ([]types.Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var events []types.Event
+	if err := json.NewDecoder(gz).Decode(&events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func writeSegment(path string, events []types.Event) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".segment-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(gz).Encode(events); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return 0, err
+	}
+	return info.Size(), os.Rename(tmp.Name(), path)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+type segmentEvent struct {
+	types.Event
+	region string
+	day    string
+}
+
+// groupBySegment groups events by their "<region>/<day>" segment key
+func groupBySegment(events []types.Event) map[string][]segmentEvent {
+	segments := map[string][]segmentEvent{}
+	for region, regionEvents := range groupByRegion(events) {
+		for _, event := range regionEvents {
+			if event.EventTime == nil {
+				continue
+			}
+			day := event.EventTime.UTC().Format(cacheDayFormat)
+			key := region + "/" + day
+			segments[key] = append(segments[key], segmentEvent{Event: event, region: region, day: day})
+		}
+	}
+	return segments
+}
+
+// groupByRegion groups events by the region they were logged in
+func groupByRegion(events []types.Event) map[string][]types.Event {
+	regions := map[string][]types.Event{}
+	for _, event := range events {
+		region := unknownRegion
+		if raw, err := ExtractUserDetails(event.CloudTrailEvent); err == nil && raw.EventRegion != "" {
+			region = raw.EventRegion
+		}
+		regions[region] = append(regions[region], event)
+	}
+	return regions
+}
+
+// mergeEvents adds new events to the cached ones, skipping events with a known event ID
+func mergeEvents(cached []types.Event, events []segmentEvent) []types.Event {
+	seen := map[string]bool{}
+	for _, event := range cached {
+		if event.EventId != nil {
+			seen[*event.EventId] = true
+		}
+	}
+
+	merged := cached
+	for _, event := range events {
+		if event.EventId != nil {
+			if seen[*event.EventId] {
+				continue
+			}
+			seen[*event.EventId] = true
+		}
+		merged = append(merged, event.Event)
+	}
+	sortEventsNewestFirst(merged)
+	return merged
+}
 
-	var allEvents []types.Event
-	allEvents = append(allEvents, c.Event...)
-	allEvents = append(allEvents, newCacheEvents.Event...)
-	sort.Slice(allEvents, func(i, j int) bool {
-		if allEvents[i].EventTime == nil {
+func sortEventsNewestFirst(events []types.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].EventTime == nil {
 			return false
 		}
-		if allEvents[j].EventTime == nil {
+		if events[j].EventTime == nil {
 			return true
 		}
-		return allEvents[j].EventTime.Before(*allEvents[i].EventTime)
+		return events[j].EventTime.Before(*events[i].EventTime)
 	})
+}
+
+// EvictedSegment identifies a cache segment removed by pruning
+type EvictedSegment struct {
+	ClusterID string
+	Key       string
+	Size      int64
+}
+
+// pruneCache removes the segments whose newest event is older than olderThan, then the least
+// recently used segments until the cache fits into maxSize bytes. A zero olderThan or maxSize
+// disables that limit. If clusterID is set only that cluster is pruned. The days of removed
+// segments are removed from the fetched periods of their region so they are fetched again
+// when needed.
+func pruneCache(log *logrus.Logger, root string, maxSize int64, olderThan time.Time, clusterID string) ([]EvictedSegment, error) {
+	indexes, err := readCacheIndexes(log, root)
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		clusterID string
+		key       string
+		segment   *cacheSegment
+	}
+	var (
+		candidates []candidate
+		total      int64
+	)
+	for id, index := range indexes {
+		total += index.size()
+		if clusterID != "" && id != clusterID {
+			continue
+		}
+		for key, segment := range index.Segments {
+			candidates = append(candidates, candidate{clusterID: id, key: key, segment: segment})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].segment.LastAccess.Equal(candidates[j].segment.LastAccess) {
+			return candidates[i].segment.LastAccess.Before(candidates[j].segment.LastAccess)
+		}
+		return candidates[i].key < candidates[j].key
+	})
+
+	var evicted []EvictedSegment
+	changed := map[string]bool{}
+	for _, c := range candidates {
+		expired := !olderThan.IsZero() && c.segment.Last.Before(olderThan)
+		oversized := maxSize > 0 && total > maxSize
+		if !expired && !oversized {
+			continue
+		}
 
-	cache := Cache{
-		log:      c.log,
-		filename: c.filename,
-		Period:   newPeriods,
-		Event:    allEvents,
+		dir := filepath.Join(root, c.clusterID)
+		if err := os.Remove(filepath.Join(dir, c.segment.File)); err != nil && !os.IsNotExist(err) {
+			return evicted, err
+		}
+		index := indexes[c.clusterID]
+		delete(index.Segments, c.key)
+		if day, err := time.Parse(cacheDayFormat, c.segment.Day); err == nil {
+			region := c.segment.Region
+			index.Periods[region] = subtractPeriod(index.Periods[region], Period{StartTime: day, EndTime: day.Add(24*time.Hour - time.Second)})
+		}
+		changed[c.clusterID] = true
+		total -= c.segment.Size
+		evicted = append(evicted, EvictedSegment{ClusterID: c.clusterID, Key: c.key, Size: c.segment.Size})
+	}
+
+	for id := range changed {
+		if err := indexes[id].write(filepath.Join(root, id)); err != nil {
+			return evicted, err
+		}
 	}
+	return evicted, nil
+}
 
-	data, err := json.MarshalIndent(cache, "", "  ")
+// readCacheIndexes returns the index of every cluster in the cache. Clusters whose index
+// can't be read are skipped with a warning, they are discarded the next time they're used.
+func readCacheIndexes(log *logrus.Logger, root string) (map[string]*cacheIndex, error) {
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]*cacheIndex{}, nil
+	}
 	if err != nil {
-		c.log.Errorf("failed to marshal cache: %v", err)
-		return err
+		return nil, err
 	}
-	if err := os.WriteFile(c.filename, data, 0600); err != nil {
-		c.log.Errorf("failed to write to cache file: %v", err)
-		return err
+
+	indexes := map[string]*cacheIndex{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		index, err := readCacheIndex(filepath.Join(root, entry.Name()))
+		if err != nil {
+			log.Warnf("Skipping the cache of cluster %s: %v", entry.Name(), err)
+			continue
+		}
+		indexes[entry.Name()] = index
 	}
+	return indexes, nil
+}
 
-	return nil
+// subtractPeriod removes a period from a list of periods, splitting periods it falls into
+func subtractPeriod(periods []Period, remove Period) []Period {
+	result := []Period{}
+	for _, p := range periods {
+		if p.EndTime.Before(remove.StartTime) || p.StartTime.After(remove.EndTime) {
+			result = append(result, p)
+			continue
+		}
+		if p.StartTime.Before(remove.StartTime) {
+			result = append(result, Period{StartTime: p.StartTime, EndTime: remove.StartTime.Add(-time.Second)})
+		}
+		if p.EndTime.After(remove.EndTime) {
+			result = append(result, Period{StartTime: remove.EndTime.Add(time.Second), EndTime: p.EndTime})
+		}
+	}
+	return result
 }
 
 // DiffMultiple takes the requested time range and compares it to the time period in the cache.
@@ -195,23 +740,6 @@ func (p Period) periodOverlap(missingPeriods []Period) bool {
 	return true
 }
 
-func (c *Cache) FilterByPeriod(requestedPeriod Period) []types.Event {
-	var eventsInCache []types.Event
-	for _, period := range c.Period {
-		if period.Overlap(requestedPeriod) {
-			for _, event := range c.Event {
-				if event.EventTime != nil {
-					eventTime := *event.EventTime
-					if !eventTime.Before(requestedPeriod.StartTime) && !eventTime.After(requestedPeriod.EndTime) {
-						eventsInCache = append(eventsInCache, event)
-					}
-				}
-			}
-		}
-	}
-	return eventsInCache
-}
-
 func FilterByRegion(region string, events []types.Event) []types.Event {
 	var filtered []types.Event
 	for _, event := range events {
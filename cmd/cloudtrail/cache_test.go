@@ -0,0 +1,240 @@
+package cloudtrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var cacheTestDay = time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC)
+
+func newCacheTestEvent(id, name string, eventTime time.Time, region string) types.Event {
+	return types.Event{
+		EventId:         aws.String(id),
+		EventName:       aws.String(name),
+		EventTime:       aws.Time(eventTime),
+		CloudTrailEvent: aws.String(fmt.Sprintf(`{"eventVersion": "1.08", "eventID": %q, "awsRegion": %q}`, id, region)),
+	}
+}
+
+func newTestLogger() *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return log
+}
+
+func newTestCache(t *testing.T, root, clusterID, region string) *Cache {
+	cache := newCacheAt(newTestLogger(), root, clusterID, region)
+	require.NoError(t, cache.EnsureFilenameExist())
+	require.NoError(t, cache.Read())
+	return cache
+}
+
+func eventIDs(events []types.Event) []string {
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, *e.EventId)
+	}
+	return ids
+}
+
+func TestCacheSaveAndLookup(t *testing.T) {
+	root := t.TempDir()
+	cache := newTestCache(t, root, "abc", "us-east-1")
+	day := Period{StartTime: cacheTestDay, EndTime: cacheTestDay.Add(48 * time.Hour)}
+
+	require.NoError(t, cache.Save(Cache{
+		Period: []Period{day},
+		Event: []types.Event{
+			newCacheTestEvent("1", "RunInstances", cacheTestDay.Add(time.Hour), "us-east-1"),
+			newCacheTestEvent("2", "CreateBucket", cacheTestDay.Add(2*time.Hour), "eu-west-1"),
+			newCacheTestEvent("3", "RunInstances", cacheTestDay.Add(25*time.Hour), "us-east-1"),
+		},
+	}))
+
+	// Saving overlapping events again doesn't duplicate them and only adds the new ones
+	require.NoError(t, cache.Save(Cache{
+		Event: []types.Event{
+			newCacheTestEvent("3", "RunInstances", cacheTestDay.Add(25*time.Hour), "us-east-1"),
+			newCacheTestEvent("4", "DeleteBucket", cacheTestDay.Add(26*time.Hour), "eu-west-1"),
+		},
+	}))
+
+	for _, segment := range []string{"us-east-1/2025-07-15.json.gz", "us-east-1/2025-07-16.json.gz", "eu-west-1/2025-07-15.json.gz", "eu-west-1/2025-07-16.json.gz"} {
+		assert.FileExists(t, filepath.Join(root, "abc", segment))
+	}
+
+	// Periods are tracked per region, eu-west-1 events are only returned once it was fetched
+	eu := newTestCache(t, root, "abc", "eu-west-1")
+	assert.Empty(t, eu.Period)
+	assert.Empty(t, eu.Lookup(day, "eu-west-1"))
+	require.NoError(t, eu.Save(Cache{Period: []Period{day}}))
+
+	// A fresh cache only reads the index until events are looked up
+	reread := newTestCache(t, root, "abc", "us-east-1")
+	assert.Equal(t, []Period{day}, reread.Period)
+
+	// Lookups don't write the index
+	indexFile := filepath.Join(root, "abc", cacheIndexFile)
+	before, err := os.ReadFile(indexFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"4", "3", "2", "1"}, eventIDs(reread.Lookup(day, "")))
+	assert.Equal(t, []string{"3", "1"}, eventIDs(reread.Lookup(day, "us-east-1")))
+	assert.Equal(t, []string{"4", "2"}, eventIDs(reread.Lookup(day, "", "CreateBucket", "DeleteBucket")))
+	assert.Equal(t, []string{"2"}, eventIDs(reread.Lookup(Period{StartTime: cacheTestDay, EndTime: cacheTestDay.Add(12 * time.Hour)}, "eu-west-1")))
+
+	// Periods that were never fetched return nothing
+	assert.Empty(t, reread.Lookup(Period{StartTime: cacheTestDay.Add(-72 * time.Hour), EndTime: cacheTestDay.Add(-71 * time.Hour)}, ""))
+
+	after, err := os.ReadFile(indexFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
+
+func TestCacheDiscardsInvalidIndex(t *testing.T) {
+	for name, index := range map[string]string{
+		"corrupt":     `{"version": 3, "segments": [`,
+		"old version": `{"version": 2, "periods": [], "segments": {}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "abc")
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "us-east-1"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, cacheIndexFile), []byte(index), 0600))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "us-east-1", "2025-07-15.json.gz"), []byte("stale"), 0600))
+
+			// Other clusters skip the index when pruning or collecting stats
+			other := newTestCache(t, root, "def", "us-east-1")
+			require.NoError(t, other.Save(Cache{
+				Period: []Period{{StartTime: cacheTestDay, EndTime: cacheTestDay.Add(time.Hour)}},
+				Event:  []types.Event{newCacheTestEvent("1", "RunInstances", cacheTestDay.Add(time.Minute), "us-east-1")},
+			}))
+			_, err := pruneCache(newTestLogger(), root, 1, time.Time{}, "")
+			require.NoError(t, err)
+			stats, err := collectCacheStats(newTestLogger(), root, "")
+			require.NoError(t, err)
+			require.Len(t, stats, 1)
+			assert.Equal(t, "def", stats[0].ClusterID)
+
+			// The cluster itself starts over with an empty cache
+			cache := newTestCache(t, root, "abc", "us-east-1")
+			assert.Empty(t, cache.Period)
+			assert.NoFileExists(t, filepath.Join(dir, "us-east-1", "2025-07-15.json.gz"))
+		})
+	}
+}
+
+func TestCacheMigration(t *testing.T) {
+	root := t.TempDir()
+	legacyPeriod := Period{StartTime: cacheTestDay, EndTime: cacheTestDay.Add(time.Hour)}
+	legacy := Cache{
+		Period: []Period{legacyPeriod},
+		Event: []types.Event{
+			newCacheTestEvent("1", "RunInstances", cacheTestDay.Add(10*time.Minute), "us-east-1"),
+			newCacheTestEvent("2", "CreateBucket", cacheTestDay.Add(20*time.Minute), "us-east-1"),
+		},
+	}
+	data, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "abc.json"), data, 0600))
+
+	cache := newTestCache(t, root, "abc", "us-east-1")
+
+	assert.NoFileExists(t, filepath.Join(root, "abc.json"))
+	assert.Equal(t, []Period{legacyPeriod}, cache.Period)
+	assert.Equal(t, []string{"2", "1"}, eventIDs(cache.Lookup(legacyPeriod, "")))
+}
+
+func TestPruneCache(t *testing.T) {
+	root := t.TempDir()
+	fetched := Period{StartTime: cacheTestDay, EndTime: cacheTestDay.Add(72*time.Hour - time.Second)}
+
+	var events []types.Event
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 20; j++ {
+			events = append(events, newCacheTestEvent(fmt.Sprintf("%d-%d", i, j), "RunInstances", cacheTestDay.Add(time.Duration(i*24+j)*time.Hour), "us-east-1"))
+		}
+	}
+	cache := newTestCache(t, root, "abc", "us-east-1")
+	cache.maxSize = 0
+	require.NoError(t, cache.Save(Cache{Period: []Period{fetched}, Event: events}))
+	eu := newTestCache(t, root, "abc", "eu-west-1")
+	eu.maxSize = 0
+	require.NoError(t, eu.Save(Cache{Period: []Period{fetched}}))
+
+	// Reading the first day makes the second day the least recently used segment
+	index, err := readCacheIndex(filepath.Join(root, "abc"))
+	require.NoError(t, err)
+	index.Segments["us-east-1/2025-07-15"].LastAccess = time.Now().Add(time.Hour)
+	index.Segments["us-east-1/2025-07-16"].LastAccess = time.Now().Add(-time.Hour)
+	require.NoError(t, index.write(filepath.Join(root, "abc")))
+
+	evicted, err := pruneCache(newTestLogger(), root, index.size()-1, time.Time{}, "")
+	require.NoError(t, err)
+	require.Len(t, evicted, 1)
+	assert.Equal(t, "us-east-1/2025-07-16", evicted[0].Key)
+
+	cache = newTestCache(t, root, "abc", "us-east-1")
+	assert.Equal(t, []Period{
+		{StartTime: cacheTestDay, EndTime: cacheTestDay.Add(24*time.Hour - time.Second)},
+		{StartTime: cacheTestDay.Add(48 * time.Hour), EndTime: fetched.EndTime},
+	}, cache.Period)
+
+	// Evicting a us-east-1 segment doesn't affect the periods fetched for other regions
+	assert.Equal(t, []Period{fetched}, newTestCache(t, root, "abc", "eu-west-1").Period)
+
+	// Prune by age
+	evicted, err = pruneCache(newTestLogger(), root, 0, cacheTestDay.Add(48*time.Hour), "")
+	require.NoError(t, err)
+	require.Len(t, evicted, 1)
+	assert.Equal(t, "us-east-1/2025-07-15", evicted[0].Key)
+
+	stats, err := collectCacheStats(newTestLogger(), root, "abc")
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, 1, stats[0].Segments)
+	assert.Equal(t, 20, stats[0].Events)
+	assert.Equal(t, map[string]int{"RunInstances": 20}, stats[0].EventNames)
+	assert.Equal(t, cacheTestDay.Add(48*time.Hour), stats[0].Oldest)
+}
+
+func TestSubtractPeriod(t *testing.T) {
+	at := func(hour int) time.Time { return cacheTestDay.Add(time.Duration(hour) * time.Hour) }
+
+	periods := []Period{
+		{StartTime: at(0), EndTime: at(10)},
+		{StartTime: at(12), EndTime: at(14)},
+		{StartTime: at(20), EndTime: at(30)},
+	}
+
+	assert.Equal(t, []Period{
+		{StartTime: at(0), EndTime: at(5).Add(-time.Second)},
+		{StartTime: at(25).Add(time.Second), EndTime: at(30)},
+	}, subtractPeriod(periods, Period{StartTime: at(5), EndTime: at(25)}))
+
+	assert.Equal(t, periods, subtractPeriod(periods, Period{StartTime: at(40), EndTime: at(50)}))
+}
+
+func TestClusterCacheDir(t *testing.T) {
+	root := t.TempDir()
+
+	dir, err := clusterCacheDir(root, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "abc"), dir)
+
+	for _, clusterID := range []string{"../..", "..", "abc/../..", "/etc", ""} {
+		_, err := clusterCacheDir(root, clusterID)
+		assert.Error(t, err, clusterID)
+	}
+}
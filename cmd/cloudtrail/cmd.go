@@ -17,6 +17,9 @@ func NewCloudtrailCmd() *cobra.Command {
 	cloudtrailCmd.AddCommand(newCmdWriteEvents())
 	cloudtrailCmd.AddCommand(newCmdPermissionDenied())
 	cloudtrailCmd.AddCommand(newCmdErrors())
+	cloudtrailCmd.AddCommand(newCmdAnalyze())
+	cloudtrailCmd.AddCommand(newCmdCache())
+	cloudtrailCmd.AddCommand(newCmdSummary())
 
 	return cloudtrailCmd
 }
@@ -1,8 +1,8 @@
 package cloudtrail
 
 import (
-	"encoding/json"
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
 	"time"
@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/output"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -32,7 +33,13 @@ type errorsOptions struct {
 	PrintUrl   bool
 	PrintRaw   bool
 	JSONOutput bool
+	Output     output.Options
 	ErrorTypes []string
+
+	CheckPermissions bool
+
+	checker *PermissionChecker
+	denied  []DeniedPermission
 }
 
 type errorEventOutput struct {
@@ -43,6 +50,8 @@ type errorEventOutput struct {
 	UserName    string `json:"userName,omitempty"`
 	Region      string `json:"region,omitempty"`
 	ConsoleLink string `json:"consoleLink,omitempty"`
+
+	Permission *DeniedPermission `json:"permission,omitempty"`
 }
 
 func newCmdErrors() *cobra.Command {
@@ -62,7 +71,15 @@ By default, matches these error patterns:
   - ExpiredToken
   - SignatureDoesNotMatch
 
-Use --error-types to filter for specific error patterns.`,
+Use --error-types to filter for specific error patterns.
+
+With --check-permissions, every error event is mapped to the IAM action and
+resource it was denied, and compared with the CredentialsRequests of the
+cluster's OpenShift release and with the policies attached to the operator
+role. The result tells which operator role is missing which action, and how
+the deployed policies of the role drifted from its CredentialsRequest: actions
+the request grants that the policies don't allow (-) and actions the policies
+allow beyond the request (+).`,
 		Example: `  # Check for permission errors in the last hour
   osdctl cloudtrail errors -C ${CLUSTER_ID} --since 1h
 
@@ -70,10 +87,13 @@ Use --error-types to filter for specific error patterns.`,
   osdctl cloudtrail errors -C ${CLUSTER_ID} --error-types AccessDenied,Forbidden
 
   # Output as JSON for scripting
-  osdctl cloudtrail errors -C ${CLUSTER_ID} --json
+  osdctl cloudtrail errors -C ${CLUSTER_ID} -o json
 
   # Include console links for each event
-  osdctl cloudtrail errors -C ${CLUSTER_ID} --url`,
+  osdctl cloudtrail errors -C ${CLUSTER_ID} --url
+
+  # Show which operator role is missing which permission
+  osdctl cloudtrail errors -C ${CLUSTER_ID} --since 24h --check-permissions`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return opts.run()
 		},
@@ -83,14 +103,22 @@ Use --error-types to filter for specific error patterns.`,
 	errorsCmd.Flags().StringVarP(&opts.StartTime, "since", "", "1h", "Time window to search (e.g., 30m, 1h, 24h). Valid units: ns, us, ms, s, m, h.")
 	errorsCmd.Flags().BoolVarP(&opts.PrintUrl, "url", "u", false, "Include console URL links for each event")
 	errorsCmd.Flags().BoolVarP(&opts.PrintRaw, "raw-event", "r", false, "Print raw CloudTrail event JSON")
+	opts.Output.AddFormatFlag(errorsCmd, errorsFormats...)
 	errorsCmd.Flags().BoolVar(&opts.JSONOutput, "json", false, "Output results as JSON")
+	_ = errorsCmd.Flags().MarkDeprecated("json", "use --output json instead")
+	errorsCmd.MarkFlagsMutuallyExclusive("output", "json")
 	errorsCmd.Flags().StringSliceVar(&opts.ErrorTypes, "error-types", nil, "Comma-separated list of error patterns to match (default: all common permission errors)")
+	errorsCmd.Flags().BoolVar(&opts.CheckPermissions, "check-permissions", false, "Compare the denied actions with the CredentialsRequests of the cluster and the policies of the operator roles")
 	_ = errorsCmd.MarkFlagRequired("cluster-id")
 
 	return errorsCmd
 }
 
 func (o *errorsOptions) run() error {
+	if err := o.validateOutput(); err != nil {
+		return err
+	}
+
 	err := utils.IsValidClusterKey(o.ClusterID)
 	if err != nil {
 		return err
@@ -126,13 +154,17 @@ func (o *errorsOptions) run() error {
 		return err
 	}
 
-	// Build error patterns to match
-	patterns := defaultErrorPatterns
-	if len(o.ErrorTypes) > 0 {
-		patterns = o.ErrorTypes
+	if o.CheckPermissions {
+		o.checker, err = NewPermissionChecker(cfg, cluster.OpenshiftVersion())
+		if err != nil {
+			return err
+		}
 	}
 
-	if !o.JSONOutput {
+	// Build error patterns to match
+	patterns := o.errorPatterns()
+
+	if !o.Output.IsStructured() {
 		fmt.Printf("[INFO] Checking error history since %v for AWS Account %v as %v\n", startTime.Format(time.RFC3339), accountID, arn)
 		fmt.Printf("[INFO] Matching error patterns: %v\n", patterns)
 		fmt.Printf("[INFO] Fetching CloudTrail error events from %v region...\n", cfg.Region)
@@ -140,90 +172,116 @@ func (o *errorsOptions) run() error {
 
 	awsAPI := NewEventAPI(cfg, false, cfg.Region)
 	requestTime := Period{StartTime: startTime, EndTime: time.Now().UTC()}
-	generator := awsAPI.GetEvents(o.ClusterID, requestTime)
 
+	// Process events from cluster region
+	allEvents, eventCount, err := o.processEvents(awsAPI.GetEvents(o.ClusterID, requestTime), cfg.Region, patterns)
+	if err != nil {
+		return err
+	}
+
+	// Also check global region if different
+	if DEFAULT_REGION != cfg.Region {
+		defaultAwsAPI := NewEventAPI(cfg, true, DEFAULT_REGION)
+
+		if !o.Output.IsStructured() && !o.PrintRaw {
+			fmt.Printf("[INFO] Fetching CloudTrail error events from %v region...\n", DEFAULT_REGION)
+		}
+
+		events, count, err := o.processEvents(defaultAwsAPI.GetEvents(o.ClusterID, requestTime), DEFAULT_REGION, patterns)
+		if err != nil {
+			return err
+		}
+		allEvents = append(allEvents, events...)
+		eventCount += count
+	}
+
+	return o.printResult(allEvents, eventCount)
+}
+
+// errorsFormats are the output formats of the errors commands. The table format prints the events
+// as they are found, the structured formats print them all at the end.
+var errorsFormats = []string{output.Table, output.JSON, output.YAML, output.JSONPath, output.GoTemplate}
+
+// validateOutput checks the output format, --json being the former name of -o json
+func (o *errorsOptions) validateOutput() error {
+	if o.JSONOutput {
+		o.Output.Format = output.JSON
+	}
+	return o.Output.Validate()
+}
+
+// processEvents filters the error events out of every page. Matching events are printed,
+// or returned for structured output. An empty region means the region of each event is used.
+func (o *errorsOptions) processEvents(pages <-chan EventResult, region string, patterns []string) ([]errorEventOutput, int, error) {
 	var allEvents []errorEventOutput
 	eventCount := 0
 
-	// Process events from cluster region
-	for page := range generator {
+	for page := range pages {
+		if page.errors != nil {
+			return nil, 0, page.errors
+		}
 		filteredEvents, err := ApplyFilters(page.AWSEvent,
 			func(event types.Event) (bool, error) {
 				return o.isErrorEvent(event, patterns)
 			},
 		)
 		if err != nil {
-			return err
+			return nil, 0, err
 		}
 
-		if o.JSONOutput {
+		if o.Output.IsStructured() {
 			for _, event := range filteredEvents {
-				output := o.eventToOutput(event, cfg.Region)
-				allEvents = append(allEvents, output)
+				out := o.eventToOutput(event, region)
+				if o.checker != nil {
+					permission := o.checker.Check(event)
+					out.Permission = &permission
+				}
+				allEvents = append(allEvents, out)
 			}
-		} else if o.PrintRaw {
+			eventCount += len(filteredEvents)
+			continue
+		}
+
+		if o.checker != nil {
+			for _, event := range filteredEvents {
+				o.denied = append(o.denied, o.checker.Check(event))
+			}
+		}
+		if o.PrintRaw {
 			for _, event := range filteredEvents {
 				if event.CloudTrailEvent != nil {
 					fmt.Println(*event.CloudTrailEvent)
 				}
 			}
 		} else if len(filteredEvents) > 0 {
-			o.printEvents(filteredEvents, cfg.Region)
+			o.printEvents(filteredEvents, region)
 		}
 		eventCount += len(filteredEvents)
 	}
 
-	// Also check global region if different
-	if DEFAULT_REGION != cfg.Region {
-		defaultAwsAPI := NewEventAPI(cfg, true, DEFAULT_REGION)
-
-		if !o.JSONOutput && !o.PrintRaw {
-			fmt.Printf("[INFO] Fetching CloudTrail error events from %v region...\n", DEFAULT_REGION)
-		}
-
-		generator := defaultAwsAPI.GetEvents(o.ClusterID, requestTime)
-
-		for page := range generator {
-			filteredEvents, err := ApplyFilters(page.AWSEvent,
-				func(event types.Event) (bool, error) {
-					return o.isErrorEvent(event, patterns)
-				},
-			)
-			if err != nil {
-				return err
-			}
+	return allEvents, eventCount, nil
+}
 
-			if o.JSONOutput {
-				for _, event := range filteredEvents {
-					output := o.eventToOutput(event, DEFAULT_REGION)
-					allEvents = append(allEvents, output)
-				}
-			} else if o.PrintRaw {
-				for _, event := range filteredEvents {
-					if event.CloudTrailEvent != nil {
-						fmt.Println(*event.CloudTrailEvent)
-					}
-				}
-			} else if len(filteredEvents) > 0 {
-				o.printEvents(filteredEvents, DEFAULT_REGION)
-			}
-			eventCount += len(filteredEvents)
-		}
+func (o *errorsOptions) printResult(allEvents []errorEventOutput, eventCount int) error {
+	if o.Output.IsStructured() {
+		return o.Output.Print(os.Stdout, output.NewObject(allEvents, ""))
 	}
 
-	if o.JSONOutput {
-		output, err := json.MarshalIndent(allEvents, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal JSON output: %w", err)
-		}
-		fmt.Println(string(output))
-	} else {
-		fmt.Printf("\n[INFO] Found %d error event(s)\n", eventCount)
+	if err := PrintDeniedPermissions(o.denied); err != nil {
+		return err
 	}
-
+	fmt.Printf("\n[INFO] Found %d error event(s)\n", eventCount)
 	return nil
 }
 
+// errorPatterns returns the error patterns to match
+func (o *errorsOptions) errorPatterns() []string {
+	if len(o.ErrorTypes) > 0 {
+		return o.ErrorTypes
+	}
+	return defaultErrorPatterns
+}
+
 func (o *errorsOptions) isErrorEvent(event types.Event, patterns []string) (bool, error) {
 	raw, err := ExtractUserDetails(event.CloudTrailEvent)
 	if err != nil {
@@ -249,34 +307,37 @@ func (o *errorsOptions) isErrorEvent(event types.Event, patterns []string) (bool
 }
 
 func (o *errorsOptions) eventToOutput(event types.Event, region string) errorEventOutput {
-	output := errorEventOutput{
-		Region: region,
-	}
+	out := errorEventOutput{}
 
 	if event.EventName != nil {
-		output.EventName = *event.EventName
+		out.EventName = *event.EventName
 	}
 	if event.EventTime != nil {
-		output.EventTime = event.EventTime.Format(time.RFC3339)
+		out.EventTime = event.EventTime.Format(time.RFC3339)
 	}
 
 	raw, err := ExtractUserDetails(event.CloudTrailEvent)
 	if err == nil {
-		output.ErrorCode = raw.ErrorCode
-		output.UserARN = raw.UserIdentity.SessionContext.SessionIssuer.Arn
-		output.UserName = raw.UserIdentity.SessionContext.SessionIssuer.UserName
+		out.ErrorCode = raw.ErrorCode
+		out.UserARN = raw.UserIdentity.SessionContext.SessionIssuer.Arn
+		out.UserName = raw.UserIdentity.SessionContext.SessionIssuer.UserName
+		if region == "" {
+			region = raw.EventRegion
+		}
 	}
+	out.Region = region
 
 	if o.PrintUrl && event.EventId != nil {
-		output.ConsoleLink = fmt.Sprintf("https://%s.console.aws.amazon.com/cloudtrailv2/home?region=%s#/events/%s",
+		out.ConsoleLink = fmt.Sprintf("https://%s.console.aws.amazon.com/cloudtrailv2/home?region=%s#/events/%s",
 			region, region, *event.EventId)
 	}
 
-	return output
+	return out
 }
 
 func (o *errorsOptions) printEvents(events []types.Event, region string) {
 	for _, event := range events {
+		region := region
 		fmt.Println("─────────────────────────────────────────────────────────────")
 
 		if event.EventName != nil {
@@ -299,6 +360,9 @@ func (o *errorsOptions) printEvents(events []types.Event, region string) {
 			if userArn != "" {
 				fmt.Printf("ARN:   %s\n", userArn)
 			}
+			if region == "" {
+				region = raw.EventRegion
+			}
 		}
 
 		fmt.Printf("Region: %s\n", region)
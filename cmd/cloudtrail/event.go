@@ -33,6 +33,12 @@ type EventResult struct {
 	errors   error
 }
 
+// EventSource returns pages of CloudTrail events within a period, e.g. from the
+// LookupEvents API (EventAPI) or from archived log files (ArchiveSource)
+type EventSource interface {
+	GetEvents(clusterID string, period Period) <-chan EventResult
+}
+
 type EventAPI struct {
 	client    *cloudtrail.Client
 	writeOnly bool
@@ -85,6 +91,7 @@ func (a *EventAPI) GetEvents(_ string, missing Period) <-chan EventResult {
 					AWSEvent: nil,
 					errors:   err,
 				}
+				return
 			}
 			alllookupEvents = append(alllookupEvents, lookupOutput.Events...)
 
@@ -5,8 +5,6 @@ import (
 	"regexp"
 	"strings"
 
-	"slices"
-
 	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
 	"github.com/sirupsen/logrus"
 )
@@ -19,6 +17,7 @@ type Filter func(types.Event) (bool, error)
 type WriteEventFilters struct {
 	Include []string
 	Exclude []string
+	Query   string
 }
 
 // ApplyFilters takes the filteredEvents slice and applies an additional filter function.
@@ -84,172 +83,89 @@ func IsIgnoredEvent(event types.Event, mergedRegex string, log *logrus.Logger) (
 	return true, nil
 }
 
-// Filters applies inclusion and exclusion filters to all Cloudtrail Events
-// applies inclusion filters then exclusion filters.
-func Filters(f WriteEventFilters, alllookupEvents []types.Event) []types.Event {
-	filtered := alllookupEvents
+// Compile builds a single Filter from the write-events filters.
+// Inclusion filters with the same key match if any of their values match, and all keys must match.
+// Events matching any exclusion filter are dropped. The query expression (see QueryHelp) must
+// match as well.
+func (f WriteEventFilters) Compile() (Filter, error) {
+	var node queryNode
+
+	and := func(n queryNode) {
+		if node == nil {
+			node = n
+			return
+		}
+		node = andNode{node, n}
+	}
 
-	if len(f.Include) > 0 {
-		filtered = inclusionFilter(filtered, f.Include)
+	include, err := keyValueNode(f.Include, true)
+	if err != nil {
+		return nil, err
 	}
-	if len(f.Exclude) > 0 {
-		filtered = exclusionFilter(filtered, f.Exclude)
+	if include != nil {
+		and(include)
 	}
-	return filtered
-}
-
-// inclusionFilter filter events by inclusion criteria.
-// Only events that match all specified filter keys and at least one value per key are included.
-func inclusionFilter(rawData []types.Event, inclusionFilters []string) []types.Event {
-	keyValuePair := parseFilters(inclusionFilters)
 
-	filterFunc := map[string]func(data types.Event, values []string) bool{
-		"username": func(data types.Event, values []string) bool {
-			if data.Username != nil {
-				if slices.Contains(values, *data.Username) {
-					return true
-				}
-			}
-			return false
-		},
-		"event": func(data types.Event, values []string) bool {
-			if data.EventName != nil {
-				if slices.Contains(values, *data.EventName) {
-					return true
-				}
-			}
-			return false
-		},
-		"resource-name": func(data types.Event, values []string) bool {
-			for _, resource := range data.Resources {
-				if resource.ResourceName != nil {
-					if slices.Contains(values, *resource.ResourceName) {
-						return true
-					}
-				}
-			}
-			return false
-		},
-		"resource-type": func(data types.Event, values []string) bool {
-			for _, resource := range data.Resources {
-				if resource.ResourceType != nil {
-					if slices.Contains(values, *resource.ResourceType) {
-						return true
-					}
-				}
-			}
-			return false
-		},
-		"arn": func(data types.Event, values []string) bool {
-			rawEventDetails, err := ExtractUserDetails(data.CloudTrailEvent)
-			if err != nil {
-				fmt.Printf("failed to extract event details: %v\n", err)
-				return false
-			}
-			val := rawEventDetails.UserIdentity.SessionContext.SessionIssuer.UserName
-			return slices.Contains(values, val)
-		},
+	exclude, err := keyValueNode(f.Exclude, false)
+	if err != nil {
+		return nil, err
+	}
+	if exclude != nil {
+		and(notNode{exclude})
 	}
 
-	var result []types.Event
-	for _, data := range rawData {
-		found := true
-		for key, values := range keyValuePair {
-			if fn, ok := filterFunc[key]; ok {
-				if !fn(data, values) {
-					found = false
-					break
-				}
-			}
-		}
-		if found {
-			result = append(result, data)
+	if strings.TrimSpace(f.Query) != "" {
+		query, err := parseQuery(f.Query)
+		if err != nil {
+			return nil, err
 		}
+		and(query)
 	}
-	return result
-}
 
-// exclusionFilter filters events by exclusion criteria.
-// All events that match any exclusion filter are removed.
-func exclusionFilter(rawData []types.Event, exclusionFilters []string) []types.Event {
-	keyValuePair := parseFilters(exclusionFilters)
+	if node == nil {
+		return func(types.Event) (bool, error) { return true, nil }, nil
+	}
+	return nodeFilter(node), nil
+}
 
-	filterFunc := map[string]func(data types.Event, values []string) bool{
-		"username": func(data types.Event, values []string) bool {
-			if data.Username != nil {
-				if slices.Contains(values, *data.Username) {
-					return true
-				}
-			}
-			return false
-		},
-		"event": func(data types.Event, values []string) bool {
-			if data.EventName != nil {
-				if slices.Contains(values, *data.EventName) {
-					return true
-				}
-			}
-			return false
-		},
-		"resource-name": func(data types.Event, values []string) bool {
-			for _, resource := range data.Resources {
-				if resource.ResourceName != nil {
-					if slices.Contains(values, *resource.ResourceName) {
-						return true
-					}
-				}
-			}
-			return false
-		},
-		"resource-type": func(data types.Event, values []string) bool {
-			for _, resource := range data.Resources {
-				if resource.ResourceType != nil {
-					if slices.Contains(values, *resource.ResourceType) {
-						return true
-					}
-				}
-			}
-			return false
-		},
-		"arn": func(data types.Event, values []string) bool {
-			rawEventDetails, err := ExtractUserDetails(data.CloudTrailEvent)
-			if err != nil {
-				fmt.Printf("failed to extract event details: %v\n", err)
-				return false
-			}
-			val := rawEventDetails.UserIdentity.SessionContext.SessionIssuer.UserName
-			return slices.Contains(values, val)
-		},
+// keyValueNode compiles "key=value" filters. Values are matched exactly, wildcards are only
+// supported in query expressions. Values of the same key are always OR-ed; different keys are
+// AND-ed when matchAllKeys is set and OR-ed otherwise.
+func keyValueNode(filters []string, matchAllKeys bool) (queryNode, error) {
+	if err := ValidateFilters(filters); err != nil {
+		return nil, err
 	}
 
-	var result []types.Event
-	for _, data := range rawData {
-		found := false
-		for key, values := range keyValuePair {
-			if fn, ok := filterFunc[key]; ok {
-				if fn(data, values) {
-					found = true
-					break
-				}
-			}
+	var keys []string
+	byKey := map[string]queryNode{}
+	for _, filter := range filters {
+		key, value, _ := strings.Cut(filter, "=")
+		match := matchNode{field: key, match: exactMatcher(value)}
+		if existing, ok := byKey[key]; ok {
+			byKey[key] = orNode{existing, match}
+			continue
 		}
-		if !found {
-			result = append(result, data)
+		keys = append(keys, key)
+		byKey[key] = match
+	}
+
+	var node queryNode
+	for _, key := range keys {
+		switch {
+		case node == nil:
+			node = byKey[key]
+		case matchAllKeys:
+			node = andNode{node, byKey[key]}
+		default:
+			node = orNode{node, byKey[key]}
 		}
 	}
-	return result
+	return node, nil
 }
 
-// parseFilters parses a slice of filter strings in the format "key=value" into a map.
-func parseFilters(filters []string) map[string][]string {
-	keyValuePair := make(map[string][]string)
-	for _, filter := range filters {
-		kv := strings.SplitN(filter, "=", 2)
-		key := kv[0]
-		value := kv[1]
-		keyValuePair[key] = append(keyValuePair[key], value)
-	}
-	return keyValuePair
+// exactMatcher matches values equal to value
+func exactMatcher(value string) func(string) bool {
+	return func(v string) bool { return v == value }
 }
 
 // ValidateFilters checks that all filters are in the correct "key=value" format
@@ -264,13 +180,12 @@ func ValidateFilters(filters []string) error {
 	}
 
 	for _, filter := range filters {
-		kv := strings.SplitN(filter, "=", 2)
-		if len(kv) != 2 {
+		key, _, ok := strings.Cut(filter, "=")
+		if !ok {
 			return fmt.Errorf("invalid filter format: %s (expected key=value)", filter)
 		}
-		key := kv[0]
 		if _, ok := allowedFilterKeys[key]; !ok {
-			return fmt.Errorf("invalid filter key: %s (allowed: username, event, resource-name, resource-type, arn)", key)
+			return fmt.Errorf("invalid filter key: %s (allowed: username, event, resource-name, resource-type, arn; use --query for other fields)", key)
 		}
 	}
 	return nil
@@ -18,6 +18,8 @@ type permissionDeniedEventsOptions struct {
 	StartTime string
 	PrintUrl  bool
 	PrintRaw  bool
+
+	CheckPermissions bool
 }
 
 func newCmdPermissionDenied() *cobra.Command {
@@ -30,7 +32,10 @@ func newCmdPermissionDenied() *cobra.Command {
   osdctl cloudtrail permission-denied-events --cluster-id ${CLUSTER_ID}
 
   # Check for permission-denied events in the last hour with URLs
-  osdctl cloudtrail permission-denied-events --cluster-id ${CLUSTER_ID} --since 1h --url`,
+  osdctl cloudtrail permission-denied-events --cluster-id ${CLUSTER_ID} --since 1h --url
+
+  # Show which operator role is missing which permission
+  osdctl cloudtrail permission-denied-events --cluster-id ${CLUSTER_ID} --since 1h --check-permissions`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return opts.run()
 		},
@@ -39,6 +44,7 @@ func newCmdPermissionDenied() *cobra.Command {
 	permissionDeniedCmd.Flags().StringVarP(&opts.StartTime, "since", "", "5m", "Specifies that only events that occur within the specified time are returned.Defaults to 5m. Valid time units are \"ns\", \"us\" (or \"µs\"), \"ms\", \"s\", \"m\", \"h\".")
 	permissionDeniedCmd.Flags().BoolVarP(&opts.PrintUrl, "url", "u", false, "Generates Url link to cloud console cloudtrail event")
 	permissionDeniedCmd.Flags().BoolVarP(&opts.PrintRaw, "raw-event", "r", false, "Prints the cloudtrail events to the console in raw json format")
+	permissionDeniedCmd.Flags().BoolVar(&opts.CheckPermissions, "check-permissions", false, "Compare the denied actions with the CredentialsRequests of the cluster and the policies of the operator roles")
 	permissionDeniedCmd.MarkFlagRequired("cluster-id")
 	return permissionDeniedCmd
 }
@@ -98,6 +104,14 @@ func (p *permissionDeniedEventsOptions) run() error {
 		return err
 	}
 
+	var checker *PermissionChecker
+	if p.CheckPermissions {
+		checker, err = NewPermissionChecker(cfg, cluster.OpenshiftVersion())
+		if err != nil {
+			return err
+		}
+	}
+
 	awsAPI := NewEventAPI(cfg, false, cfg.Region)
 	printer := NewPrinter(p.PrintUrl, p.PrintRaw)
 	requestTime := Period{StartTime: startTime, EndTime: time.Now().UTC()}
@@ -106,41 +120,52 @@ func (p *permissionDeniedEventsOptions) run() error {
 	fmt.Printf("[INFO] Checking Permission Denied History since %v for AWS Account %v as %v \n", startTime, accountId, arn)
 	fmt.Printf("[INFO] Fetching %v Event History...", cfg.Region)
 
-	for page := range generator {
+	denied, err := printPermissionDeniedEvents(printer, generator, checker)
+	if err != nil {
+		return err
+	}
+
+	if DEFAULT_REGION != cfg.Region {
+		defaultAwsAPI := NewEventAPI(cfg, true, DEFAULT_REGION)
+
+		fmt.Printf("[INFO] Fetching Cloudtrail Global Permission Denied Event History from %v Region...", DEFAULT_REGION)
+		generator := defaultAwsAPI.GetEvents(p.ClusterID, requestTime)
+
+		globalDenied, err := printPermissionDeniedEvents(printer, generator, checker)
+		if err != nil {
+			return err
+		}
+		denied = append(denied, globalDenied...)
+	}
+
+	return PrintDeniedPermissions(denied)
+
+}
+
+// printPermissionDeniedEvents prints the permission denied events of every page. If a checker
+// is given, the denied permissions of the events are returned.
+func printPermissionDeniedEvents(printer *Printer, pages <-chan EventResult, checker *PermissionChecker) ([]DeniedPermission, error) {
+	var denied []DeniedPermission
+	for page := range pages {
+		if page.errors != nil {
+			return nil, page.errors
+		}
 		filteredEvents, err := ApplyFilters(page.AWSEvent,
 			func(event types.Event) (bool, error) {
 				return isforbiddenEvent(event)
 			},
 		)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if len(filteredEvents) > 0 {
 			printer.PrintEvents(filteredEvents, defaultFields)
 		}
-	}
-
-	if DEFAULT_REGION != cfg.Region {
-		defaultAwsAPI := NewEventAPI(cfg, true, DEFAULT_REGION)
-
-		fmt.Printf("[INFO] Fetching Cloudtrail Global Permission Denied Event History from %v Region...", DEFAULT_REGION)
-		generator := defaultAwsAPI.GetEvents(p.ClusterID, requestTime)
-
-		for page := range generator {
-			filteredEvents, err := ApplyFilters(page.AWSEvent,
-				func(event types.Event) (bool, error) {
-					return isforbiddenEvent(event)
-				},
-			)
-			if err != nil {
-				return err
-			}
-			if len(filteredEvents) > 0 {
-				printer.PrintEvents(filteredEvents, defaultFields)
+		if checker != nil {
+			for _, event := range filteredEvents {
+				denied = append(denied, checker.Check(event))
 			}
 		}
 	}
-
-	return err
-
+	return denied, nil
 }
@@ -0,0 +1,467 @@
+package cloudtrail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	cco "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	"github.com/openshift/osdctl/pkg/policies"
+	"github.com/openshift/osdctl/pkg/printer"
+)
+
+// Results of comparing a denied action with the expected and the deployed policies
+const (
+	// PermissionMissingFromPolicy means the CredentialsRequest grants the action but the
+	// policies attached to the role don't
+	PermissionMissingFromPolicy = "missing-from-policy"
+	// PermissionAllowedByPolicy means the role's policies allow the action, so it was denied
+	// by something else, e.g. an SCP, a permissions boundary, a resource policy or a condition
+	PermissionAllowedByPolicy = "allowed-by-policy"
+	// PermissionNotRequested means the CredentialsRequest of the operator doesn't grant the action
+	PermissionNotRequested = "not-in-credentials-request"
+	// PermissionUnknownRole means the role isn't the role of any CredentialsRequest
+	PermissionUnknownRole = "unknown-role"
+	// PermissionPolicyUnchecked means the CredentialsRequest grants the action but the policies
+	// attached to the role couldn't be read
+	PermissionPolicyUnchecked = "policy-unchecked"
+)
+
+// maxRoleNameLength is the maximum length of IAM role names
+const maxRoleNameLength = 64
+
+// deniedActionRegexp extracts the action and resource from AccessDenied error messages, e.g.
+// "User: arn:aws:sts::123:assumed-role/x/y is not authorized to perform: s3:GetObject on resource: arn:aws:s3:::b/k"
+var deniedActionRegexp = regexp.MustCompile(`not authorized to perform: ([\w-]+:[\w*-]+)(?: on resource: ("[^"]+"|[^\s,]+))?`)
+
+// eventSourceServices maps the CloudTrail event sources whose IAM service prefix differs
+var eventSourceServices = map[string]string{
+	"monitoring": "cloudwatch",
+	"tagging":    "tag",
+	"email":      "ses",
+}
+
+// DeniedPermission describes the IAM action a denied event needed, which operator role was
+// missing it and why
+type DeniedPermission struct {
+	EventName          string `json:"eventName"`
+	Action             string `json:"action"`
+	Resource           string `json:"resource,omitempty"`
+	Role               string `json:"role,omitempty"`
+	CredentialsRequest string `json:"credentialsRequest,omitempty"`
+	Status             string `json:"status"`
+	// PolicyChecked is set once the policies deployed for the role were compared with the
+	// CredentialsRequest. MissingActions are the requested actions the deployed policies don't
+	// allow, ExtraActions the actions they allow beyond the request.
+	PolicyChecked  bool     `json:"policyChecked"`
+	MissingActions []string `json:"missingActions,omitempty"`
+	ExtraActions   []string `json:"extraActions,omitempty"`
+}
+
+// rolePolicyAPI is the subset of the IAM client used to read the policies of a role
+type rolePolicyAPI interface {
+	ListAttachedRolePolicies(ctx context.Context, params *iam.ListAttachedRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListAttachedRolePoliciesOutput, error)
+	GetPolicy(ctx context.Context, params *iam.GetPolicyInput, optFns ...func(*iam.Options)) (*iam.GetPolicyOutput, error)
+	GetPolicyVersion(ctx context.Context, params *iam.GetPolicyVersionInput, optFns ...func(*iam.Options)) (*iam.GetPolicyVersionOutput, error)
+	ListRolePolicies(ctx context.Context, params *iam.ListRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListRolePoliciesOutput, error)
+	GetRolePolicy(ctx context.Context, params *iam.GetRolePolicyInput, optFns ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error)
+}
+
+// credentialsRequestPolicy is the policy an operator requests through its CredentialsRequest
+type credentialsRequestPolicy struct {
+	namespace  string
+	name       string
+	statements []cco.StatementEntry
+}
+
+// rolePolicies are the statements of the policies attached to a role, from the default
+// version of its managed policies and from its inline policies
+type rolePolicies struct {
+	statements []policyStatement
+}
+
+// PermissionChecker maps denied events to the CredentialsRequest of the operator that made
+// them and checks the expected policy against the policies deployed in the account
+type PermissionChecker struct {
+	requests []credentialsRequestPolicy
+	iam      rolePolicyAPI
+	roles    map[string]*rolePolicies
+}
+
+// NewPermissionChecker extracts the AWS CredentialsRequests of an OpenShift release
+func NewPermissionChecker(cfg aws.Config, releaseVersion string) (*PermissionChecker, error) {
+	dir, err := policies.DownloadCredentialRequests(releaseVersion, policies.AWS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract the CredentialsRequests of %s: %w", releaseVersion, err)
+	}
+	defer os.RemoveAll(dir)
+
+	crs, err := policies.ParseCredentialsRequestsInDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return newPermissionChecker(crs, iam.NewFromConfig(cfg))
+}
+
+func newPermissionChecker(crs []*cco.CredentialsRequest, client rolePolicyAPI) (*PermissionChecker, error) {
+	checker := &PermissionChecker{iam: client, roles: map[string]*rolePolicies{}}
+	for _, cr := range crs {
+		spec, err := policies.GetAWSProviderSpec(cr)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing CredentialsRequest '%s': %w", cr.Name, err)
+		}
+		checker.requests = append(checker.requests, credentialsRequestPolicy{
+			namespace:  cr.Spec.SecretRef.Namespace,
+			name:       cr.Spec.SecretRef.Name,
+			statements: spec.StatementEntries,
+		})
+	}
+	return checker, nil
+}
+
+// Check explains a denied event
+func (c *PermissionChecker) Check(event types.Event) DeniedPermission {
+	result := DeniedPermission{Status: PermissionUnknownRole}
+	if event.EventName != nil {
+		result.EventName = *event.EventName
+	}
+
+	var raw struct {
+		EventSource  string `json:"eventSource"`
+		ErrorMessage string `json:"errorMessage"`
+		UserIdentity struct {
+			SessionContext struct {
+				SessionIssuer struct {
+					UserName string `json:"userName"`
+				} `json:"sessionIssuer"`
+			} `json:"sessionContext"`
+		} `json:"userIdentity"`
+	}
+	if event.CloudTrailEvent != nil {
+		_ = json.Unmarshal([]byte(*event.CloudTrailEvent), &raw)
+	}
+	result.Role = raw.UserIdentity.SessionContext.SessionIssuer.UserName
+	result.Action, result.Resource = deniedAction(result.EventName, raw.EventSource, raw.ErrorMessage)
+	if result.Resource == "" {
+		for _, r := range event.Resources {
+			if r.ResourceName != nil && strings.HasPrefix(*r.ResourceName, "arn:") {
+				result.Resource = *r.ResourceName
+				break
+			}
+		}
+	}
+
+	request := c.requestForRole(result.Role)
+	if request == nil {
+		return result
+	}
+	result.CredentialsRequest = request.namespace + "/" + request.name
+
+	if !statementEntriesAllow(request.statements, result.Action, result.Resource) {
+		result.Status = PermissionNotRequested
+		return result
+	}
+
+	deployed, err := c.rolePolicies(result.Role)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Failed to read the policies of role %s: %v\n", result.Role, err)
+		result.Status = PermissionPolicyUnchecked
+		return result
+	}
+	result.PolicyChecked = true
+	result.MissingActions, result.ExtraActions = policyDrift(request.statements, deployed.statements)
+	if policyAllows(deployed.statements, result.Action, result.Resource) {
+		result.Status = PermissionAllowedByPolicy
+	} else {
+		result.Status = PermissionMissingFromPolicy
+	}
+	return result
+}
+
+// deniedAction returns the IAM action and resource of a denied call, from the error message if
+// possible, otherwise from the event source and name
+func deniedAction(eventName, eventSource, errorMessage string) (string, string) {
+	if match := deniedActionRegexp.FindStringSubmatch(errorMessage); match != nil {
+		return match[1], strings.Trim(match[2], `"`)
+	}
+	service := strings.TrimSuffix(eventSource, ".amazonaws.com")
+	if mapped, ok := eventSourceServices[service]; ok {
+		service = mapped
+	}
+	return service + ":" + eventName, ""
+}
+
+// requestForRole finds the CredentialsRequest of an operator role. Operator roles are named
+// "<prefix>-<secret namespace>-<secret name>", truncated to 64 characters.
+func (c *PermissionChecker) requestForRole(role string) *credentialsRequestPolicy {
+	var (
+		best    *credentialsRequestPolicy
+		longest int
+	)
+	for i := range c.requests {
+		key := c.requests[i].namespace + "-" + c.requests[i].name
+		matched := 0
+		if role == key || strings.HasSuffix(role, "-"+key) {
+			matched = len(key)
+		} else if len(role) == maxRoleNameLength {
+			// The longest suffix after a '-' that the role name was truncated from
+			for j := 0; j < len(role); j++ {
+				if role[j] == '-' && strings.HasPrefix(key, role[j+1:]) && j+1 < len(role) {
+					matched = len(role) - j - 1
+					break
+				}
+			}
+		}
+		if matched > longest {
+			best, longest = &c.requests[i], matched
+		}
+	}
+	return best
+}
+
+func (c *PermissionChecker) rolePolicies(role string) (*rolePolicies, error) {
+	if cached, ok := c.roles[role]; ok {
+		return cached, nil
+	}
+
+	ctx := context.TODO()
+	result := &rolePolicies{}
+
+	var attached []iamTypes.AttachedPolicy
+	attachedPages := iam.NewListAttachedRolePoliciesPaginator(c.iam, &iam.ListAttachedRolePoliciesInput{RoleName: aws.String(role)})
+	for attachedPages.HasMorePages() {
+		page, err := attachedPages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		attached = append(attached, page.AttachedPolicies...)
+	}
+	for _, p := range attached {
+		arn := aws.ToString(p.PolicyArn)
+		policy, err := c.iam.GetPolicy(ctx, &iam.GetPolicyInput{PolicyArn: aws.String(arn)})
+		if err != nil {
+			return nil, err
+		}
+		version, err := c.iam.GetPolicyVersion(ctx, &iam.GetPolicyVersionInput{PolicyArn: aws.String(arn), VersionId: policy.Policy.DefaultVersionId})
+		if err != nil {
+			return nil, err
+		}
+		statements, err := parsePolicyDocument(aws.ToString(version.PolicyVersion.Document))
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy document of %s: %w", arn, err)
+		}
+		result.statements = append(result.statements, statements...)
+	}
+
+	var inline []string
+	inlinePages := iam.NewListRolePoliciesPaginator(c.iam, &iam.ListRolePoliciesInput{RoleName: aws.String(role)})
+	for inlinePages.HasMorePages() {
+		page, err := inlinePages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		inline = append(inline, page.PolicyNames...)
+	}
+	for _, name := range inline {
+		policy, err := c.iam.GetRolePolicy(ctx, &iam.GetRolePolicyInput{RoleName: aws.String(role), PolicyName: aws.String(name)})
+		if err != nil {
+			return nil, err
+		}
+		statements, err := parsePolicyDocument(aws.ToString(policy.PolicyDocument))
+		if err != nil {
+			return nil, fmt.Errorf("invalid inline policy %s: %w", name, err)
+		}
+		result.statements = append(result.statements, statements...)
+	}
+
+	c.roles[role] = result
+	return result, nil
+}
+
+// policyStatement is a statement of an IAM policy document
+type policyStatement struct {
+	Effect   string      `json:"Effect"`
+	Action   stringOrSet `json:"Action"`
+	Resource stringOrSet `json:"Resource"`
+}
+
+// stringOrSet decodes policy elements that are either a string or a list of strings
+type stringOrSet []string
+
+func (s *stringOrSet) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = []string{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// parsePolicyDocument parses a (URL encoded) policy document as returned by IAM
+func parsePolicyDocument(document string) ([]policyStatement, error) {
+	if decoded, err := url.QueryUnescape(document); err == nil {
+		document = decoded
+	}
+
+	var doc struct {
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(document), &doc); err != nil {
+		return nil, err
+	}
+	var statements []policyStatement
+	if err := json.Unmarshal(doc.Statement, &statements); err != nil {
+		var single policyStatement
+		if err := json.Unmarshal(doc.Statement, &single); err != nil {
+			return nil, err
+		}
+		statements = []policyStatement{single}
+	}
+	return statements, nil
+}
+
+// policyAllows reports whether an allow statement matches and no deny statement does.
+// Conditions are not evaluated.
+func policyAllows(statements []policyStatement, action, resource string) bool {
+	allowed := false
+	for _, s := range statements {
+		if !matchesAny(s.Action, action) || !matchesResource(s.Resource, resource) {
+			continue
+		}
+		if strings.EqualFold(s.Effect, "Deny") {
+			return false
+		}
+		allowed = true
+	}
+	return allowed
+}
+
+func statementEntriesAllow(entries []cco.StatementEntry, action, resource string) bool {
+	var statements []policyStatement
+	for _, e := range entries {
+		statements = append(statements, policyStatement{Effect: e.Effect, Action: e.Action, Resource: []string{e.Resource}})
+	}
+	return policyAllows(statements, action, resource)
+}
+
+// policyDrift compares the policies deployed for a role with its CredentialsRequest. It returns
+// the requested actions the deployed policies don't allow on the requested resources, and the
+// actions the deployed policies allow that weren't requested. Wildcard actions are compared as
+// patterns, e.g. a deployed "ec2:*" covers a requested "ec2:Describe*" but is itself extra.
+func policyDrift(requested []cco.StatementEntry, deployed []policyStatement) ([]string, []string) {
+	missing := map[string]bool{}
+	for _, entry := range requested {
+		if !strings.EqualFold(entry.Effect, "Allow") {
+			continue
+		}
+		for _, action := range entry.Action {
+			if !policyAllows(deployed, action, entry.Resource) {
+				missing[action] = true
+			}
+		}
+	}
+
+	extra := map[string]bool{}
+	for _, statement := range deployed {
+		if !strings.EqualFold(statement.Effect, "Allow") {
+			continue
+		}
+		resources := statement.Resource
+		if len(resources) == 0 {
+			resources = []string{"*"}
+		}
+		for _, action := range statement.Action {
+			for _, resource := range resources {
+				if !statementEntriesAllow(requested, action, resource) {
+					extra[action] = true
+				}
+			}
+		}
+	}
+
+	return sortedKeys(missing), sortedKeys(extra)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// matchesAny matches IAM actions, which are case insensitive and may contain wildcards
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if globMatcher(strings.ToLower(pattern))(strings.ToLower(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesResource(patterns []string, resource string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	if resource == "" {
+		resource = "*"
+	}
+	for _, pattern := range patterns {
+		if pattern == "*" || globMatcher(pattern)(resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintDeniedPermissions prints the result of checking denied events
+func PrintDeniedPermissions(results []DeniedPermission) error {
+	if len(results) == 0 {
+		return nil
+	}
+	fmt.Println("\nPERMISSION ANALYSIS")
+	p := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	p.AddRow([]string{"EVENT", "ACTION", "ROLE", "CREDENTIALS REQUEST", "STATUS", "POLICY DRIFT"})
+	for _, r := range results {
+		p.AddRow([]string{r.EventName, r.Action, r.Role, r.CredentialsRequest, r.Status, r.policyDrift()})
+	}
+	return p.Flush()
+}
+
+// policyDrift formats the drift of the role's policies, "-" if they weren't compared, e.g.
+// "-ec2:CreateSnapshot +ec2:*" for a missing and an extra action
+func (r DeniedPermission) policyDrift() string {
+	if !r.PolicyChecked {
+		return "-"
+	}
+	if len(r.MissingActions) == 0 && len(r.ExtraActions) == 0 {
+		return "none"
+	}
+	var drift []string
+	for _, action := range r.MissingActions {
+		drift = append(drift, "-"+action)
+	}
+	for _, action := range r.ExtraActions {
+		drift = append(drift, "+"+action)
+	}
+	return strings.Join(drift, " ")
+}
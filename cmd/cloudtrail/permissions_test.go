@@ -0,0 +1,313 @@
+package cloudtrail
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	cco "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fakeIAM serves the attached and inline policy documents of roles, pageSize policies at a
+// time if set
+type fakeIAM struct {
+	attached map[string]map[string]string
+	inline   map[string]map[string]string
+	failing  string
+	pageSize int
+	calls    int
+}
+
+// page returns the sorted keys of the page starting at marker, and the marker of the next page
+func (f *fakeIAM) page(policies map[string]string, marker *string) ([]string, *string) {
+	keys := make([]string, 0, len(policies))
+	for key := range policies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	start := 0
+	if marker != nil {
+		start, _ = strconv.Atoi(*marker)
+	}
+	if f.pageSize == 0 || start+f.pageSize >= len(keys) {
+		return keys[start:], nil
+	}
+	return keys[start : start+f.pageSize], aws.String(strconv.Itoa(start + f.pageSize))
+}
+
+func (f *fakeIAM) ListAttachedRolePolicies(ctx context.Context, params *iam.ListAttachedRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListAttachedRolePoliciesOutput, error) {
+	f.calls++
+	if *params.RoleName == f.failing {
+		return nil, fmt.Errorf("AccessDenied")
+	}
+	arns, next := f.page(f.attached[*params.RoleName], params.Marker)
+	out := &iam.ListAttachedRolePoliciesOutput{Marker: next, IsTruncated: next != nil}
+	for _, arn := range arns {
+		out.AttachedPolicies = append(out.AttachedPolicies, iamTypes.AttachedPolicy{PolicyArn: aws.String(arn)})
+	}
+	return out, nil
+}
+
+func (f *fakeIAM) GetPolicy(ctx context.Context, params *iam.GetPolicyInput, optFns ...func(*iam.Options)) (*iam.GetPolicyOutput, error) {
+	return &iam.GetPolicyOutput{Policy: &iamTypes.Policy{Arn: params.PolicyArn, DefaultVersionId: aws.String("v1")}}, nil
+}
+
+func (f *fakeIAM) GetPolicyVersion(ctx context.Context, params *iam.GetPolicyVersionInput, optFns ...func(*iam.Options)) (*iam.GetPolicyVersionOutput, error) {
+	for _, policies := range f.attached {
+		if document, ok := policies[*params.PolicyArn]; ok {
+			return &iam.GetPolicyVersionOutput{PolicyVersion: &iamTypes.PolicyVersion{Document: aws.String(url.QueryEscape(document))}}, nil
+		}
+	}
+	return nil, fmt.Errorf("no such policy %s", *params.PolicyArn)
+}
+
+func (f *fakeIAM) ListRolePolicies(ctx context.Context, params *iam.ListRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListRolePoliciesOutput, error) {
+	names, next := f.page(f.inline[*params.RoleName], params.Marker)
+	return &iam.ListRolePoliciesOutput{PolicyNames: names, Marker: next, IsTruncated: next != nil}, nil
+}
+
+func (f *fakeIAM) GetRolePolicy(ctx context.Context, params *iam.GetRolePolicyInput, optFns ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error) {
+	return &iam.GetRolePolicyOutput{PolicyDocument: aws.String(url.QueryEscape(f.inline[*params.RoleName][*params.PolicyName]))}, nil
+}
+
+func newTestCredentialsRequest(t *testing.T, namespace, name string, statements string) *cco.CredentialsRequest {
+	t.Helper()
+	return &cco.CredentialsRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: cco.CredentialsRequestSpec{
+			SecretRef: corev1.ObjectReference{Namespace: namespace, Name: name},
+			ProviderSpec: &runtime.RawExtension{Raw: []byte(fmt.Sprintf(
+				`{"apiVersion": "cloudcredential.openshift.io/v1", "kind": "AWSProviderSpec", "statementEntries": %s}`, statements))},
+		},
+	}
+}
+
+func newDeniedTestEvent(name, source, role, errorMessage string) types.Event {
+	return types.Event{
+		EventName: aws.String(name),
+		CloudTrailEvent: aws.String(fmt.Sprintf(`{"eventVersion": "1.08", "eventSource": %q, "errorCode": "AccessDenied", "errorMessage": %q,
+			"userIdentity": {"sessionContext": {"sessionIssuer": {"userName": %q}}}}`, source, errorMessage, role)),
+	}
+}
+
+func TestPermissionChecker(t *testing.T) {
+	const (
+		ebsRole   = "mycluster-a1b2-openshift-cluster-csi-drivers-ebs-cloud-credentials"
+		ingress   = "mycluster-a1b2-openshift-ingress-operator-cloud-credentials"
+		ccoRole   = "mycluster-a1b2-openshift-cloud-credential-operator-iam-ro-creds"
+		truncated = "averylongclusterprefixname-x9y8-openshift-image-registry-install"
+	)
+	require.Len(t, truncated, maxRoleNameLength)
+
+	crs := []*cco.CredentialsRequest{
+		newTestCredentialsRequest(t, "openshift-cluster-csi-drivers", "ebs-cloud-credentials",
+			`[{"effect": "Allow", "action": ["ec2:AttachVolume", "ec2:Describe*", "ec2:CreateSnapshot"], "resource": "*"}]`),
+		newTestCredentialsRequest(t, "openshift-ingress-operator", "cloud-credentials",
+			`[{"effect": "Allow", "action": ["route53:ChangeResourceRecordSets"], "resource": "*"}]`),
+		newTestCredentialsRequest(t, "openshift-image-registry", "installer-cloud-credentials",
+			`[{"effect": "Allow", "action": ["s3:GetObject"], "resource": "*"}]`),
+		newTestCredentialsRequest(t, "openshift-cloud-credential-operator", "iam-ro-creds",
+			`[{"effect": "Allow", "action": ["iam:GetUser"], "resource": "*"}]`),
+	}
+	client := &fakeIAM{
+		attached: map[string]map[string]string{
+			ebsRole: {
+				"arn:aws:iam::123456789012:policy/mycluster-ebs": `{"Version": "2012-10-17", "Statement": [
+					{"Effect": "Allow", "Action": ["ec2:AttachVolume", "ec2:Describe*"], "Resource": "*"}]}`,
+			},
+			ingress: {
+				"arn:aws:iam::aws:policy/ROSAIngressOperatorPolicy": `{"Version": "2012-10-17", "Statement":
+					{"Effect": "Allow", "Action": "route53:*", "Resource": "*"}}`,
+			},
+		},
+		inline: map[string]map[string]string{
+			truncated: {"registry": `{"Statement": [{"Effect": "Deny", "Action": "s3:*", "Resource": "*"}]}`},
+		},
+		failing: ccoRole,
+	}
+	checker, err := newPermissionChecker(crs, client)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		event    types.Event
+		expected DeniedPermission
+	}{
+		{
+			name: "action removed from the deployed policy",
+			event: newDeniedTestEvent("CreateSnapshot", "ec2.amazonaws.com", ebsRole,
+				"You are not authorized to perform this operation. User: arn:aws:sts::123456789012:assumed-role/"+ebsRole+"/x is not authorized to perform: ec2:CreateSnapshot on resource: arn:aws:ec2:us-east-1::snapshot/*"),
+			expected: DeniedPermission{
+				EventName:          "CreateSnapshot",
+				Action:             "ec2:CreateSnapshot",
+				Resource:           "arn:aws:ec2:us-east-1::snapshot/*",
+				Role:               ebsRole,
+				CredentialsRequest: "openshift-cluster-csi-drivers/ebs-cloud-credentials",
+				Status:             PermissionMissingFromPolicy,
+				PolicyChecked:      true,
+				MissingActions:     []string{"ec2:CreateSnapshot"},
+			},
+		},
+		{
+			name:  "action the operator didn't request",
+			event: newDeniedTestEvent("DeleteVolume", "ec2.amazonaws.com", ebsRole, ""),
+			expected: DeniedPermission{
+				EventName:          "DeleteVolume",
+				Action:             "ec2:DeleteVolume",
+				Role:               ebsRole,
+				CredentialsRequest: "openshift-cluster-csi-drivers/ebs-cloud-credentials",
+				Status:             PermissionNotRequested,
+			},
+		},
+		{
+			name:  "allowed by a broader managed policy",
+			event: newDeniedTestEvent("ChangeResourceRecordSets", "route53.amazonaws.com", ingress, ""),
+			expected: DeniedPermission{
+				EventName:          "ChangeResourceRecordSets",
+				Action:             "route53:ChangeResourceRecordSets",
+				Role:               ingress,
+				CredentialsRequest: "openshift-ingress-operator/cloud-credentials",
+				Status:             PermissionAllowedByPolicy,
+				PolicyChecked:      true,
+				ExtraActions:       []string{"route53:*"},
+			},
+		},
+		{
+			name:  "truncated role name denied by an inline policy",
+			event: newDeniedTestEvent("GetObject", "s3.amazonaws.com", truncated, ""),
+			expected: DeniedPermission{
+				EventName:          "GetObject",
+				Action:             "s3:GetObject",
+				Role:               truncated,
+				CredentialsRequest: "openshift-image-registry/installer-cloud-credentials",
+				Status:             PermissionMissingFromPolicy,
+				PolicyChecked:      true,
+				MissingActions:     []string{"s3:GetObject"},
+			},
+		},
+		{
+			name:  "policies that can't be read",
+			event: newDeniedTestEvent("GetUser", "iam.amazonaws.com", ccoRole, ""),
+			expected: DeniedPermission{
+				EventName:          "GetUser",
+				Action:             "iam:GetUser",
+				Role:               ccoRole,
+				CredentialsRequest: "openshift-cloud-credential-operator/iam-ro-creds",
+				Status:             PermissionPolicyUnchecked,
+			},
+		},
+		{
+			name:  "unknown role",
+			event: newDeniedTestEvent("PutMetricData", "monitoring.amazonaws.com", "ManagedOpenShift-Support-Role", ""),
+			expected: DeniedPermission{
+				EventName: "PutMetricData",
+				Action:    "cloudwatch:PutMetricData",
+				Role:      "ManagedOpenShift-Support-Role",
+				Status:    PermissionUnknownRole,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, checker.Check(tt.event))
+		})
+	}
+
+	// The policies of a role are only read once
+	checker.Check(tests[0].event)
+	assert.Equal(t, 4, client.calls)
+}
+
+func TestRolePoliciesPagination(t *testing.T) {
+	const role = "mycluster-a1b2-openshift-machine-api-aws-cloud-credentials"
+	client := &fakeIAM{
+		attached: map[string]map[string]string{role: {}},
+		inline:   map[string]map[string]string{role: {}},
+		pageSize: 2,
+	}
+	for i := 0; i < 5; i++ {
+		client.attached[role][fmt.Sprintf("arn:aws:iam::123456789012:policy/p%d", i)] = fmt.Sprintf(`{"Statement": {"Effect": "Allow", "Action": "ec2:Action%d", "Resource": "*"}}`, i)
+		client.inline[role][fmt.Sprintf("inline%d", i)] = fmt.Sprintf(`{"Statement": {"Effect": "Allow", "Action": "s3:Action%d", "Resource": "*"}}`, i)
+	}
+	checker, err := newPermissionChecker(nil, client)
+	require.NoError(t, err)
+
+	policies, err := checker.rolePolicies(role)
+	require.NoError(t, err)
+	assert.Len(t, policies.statements, 10)
+	assert.Equal(t, 3, client.calls)
+}
+
+func TestParsePolicyDocument(t *testing.T) {
+	statements, err := parsePolicyDocument(url.QueryEscape(`{"Statement": {"Effect": "Allow", "Action": "s3:Get*", "Resource": ["arn:aws:s3:::bucket/*"]}}`))
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+
+	assert.True(t, policyAllows(statements, "S3:GetObject", "arn:aws:s3:::bucket/key"))
+	assert.False(t, policyAllows(statements, "s3:GetObject", "arn:aws:s3:::other/key"))
+	assert.False(t, policyAllows(statements, "s3:PutObject", "arn:aws:s3:::bucket/key"))
+
+	_, err = parsePolicyDocument(strings.Repeat("{", 3))
+	assert.Error(t, err)
+}
+
+func TestPolicyDrift(t *testing.T) {
+	requested := []cco.StatementEntry{
+		{Effect: "Allow", Action: []string{"ec2:AttachVolume", "ec2:Describe*"}, Resource: "*"},
+		{Effect: "Allow", Action: []string{"s3:GetObject"}, Resource: "*"},
+	}
+
+	tests := []struct {
+		name            string
+		deployed        string
+		expectedMissing []string
+		expectedExtra   []string
+		expectedDrift   string
+	}{
+		{
+			name:          "same as the request",
+			deployed:      `{"Statement": [{"Effect": "Allow", "Action": ["ec2:AttachVolume", "ec2:Describe*", "s3:GetObject"], "Resource": "*"}]}`,
+			expectedDrift: "none",
+		},
+		{
+			name:            "narrowed resource and broader action",
+			deployed:        `{"Statement": [{"Effect": "Allow", "Action": ["ec2:*"], "Resource": "*"}, {"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::bucket/*"}]}`,
+			expectedMissing: []string{"s3:GetObject"},
+			expectedExtra:   []string{"ec2:*"},
+			expectedDrift:   "-s3:GetObject +ec2:*",
+		},
+		{
+			name:            "deny statements only remove actions",
+			deployed:        `{"Statement": [{"Effect": "Allow", "Action": ["ec2:AttachVolume", "ec2:Describe*", "s3:GetObject"], "Resource": "*"}, {"Effect": "Deny", "Action": "ec2:AttachVolume", "Resource": "*"}]}`,
+			expectedMissing: []string{"ec2:AttachVolume"},
+			expectedDrift:   "-ec2:AttachVolume",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployed, err := parsePolicyDocument(tt.deployed)
+			require.NoError(t, err)
+			missing, extra := policyDrift(requested, deployed)
+			assert.Equal(t, tt.expectedMissing, missing)
+			assert.Equal(t, tt.expectedExtra, extra)
+
+			result := DeniedPermission{PolicyChecked: true, MissingActions: missing, ExtraActions: extra}
+			assert.Equal(t, tt.expectedDrift, result.policyDrift())
+		})
+	}
+
+	assert.Equal(t, "-", DeniedPermission{}.policyDrift())
+}
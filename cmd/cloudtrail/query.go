@@ -0,0 +1,467 @@
+package cloudtrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+)
+
+// QueryHelp documents the filter expression language accepted by CompileQuery
+const QueryHelp = `Filter expressions combine comparisons with && (and), || (or), ! (not) and parentheses.
+
+Comparisons:
+  field=value      value matches; '*' and '?' act as wildcards (e.g. username=system:*)
+  field!=value     value does not match
+  field~regex      value matches the regular expression (e.g. event~^Delete)
+  field!~regex     value does not match the regular expression
+  field in a,b     value matches any of the comma separated values or CIDR ranges
+                   (e.g. sourceIP in 10.0.0.0/8,192.168.0.0/16)
+  field exists     field is present and not empty
+
+Fields:
+  username, event, resource-name, resource-type, arn (the session issuer user name),
+  sourceIP (alias of sourceIPAddress), region (alias of awsRegion), or any
+  field of the raw CloudTrail event addressed with dots, e.g. errorCode, readOnly,
+  userIdentity.sessionContext.sessionIssuer.arn or requestParameters.bucketName.
+
+Values containing spaces or operator characters can be quoted with "" or ''.`
+
+// fieldAliases maps short field names to paths in the raw CloudTrail event
+var fieldAliases = map[string]string{
+	"sourceIP": "sourceIPAddress",
+	"region":   "awsRegion",
+}
+
+// queryEvent wraps an event with its lazily decoded raw CloudTrailEvent JSON, so that
+// expressions only referencing the typed fields don't pay for decoding it
+type queryEvent struct {
+	event   types.Event
+	raw     map[string]any
+	decoded bool
+}
+
+func (e *queryEvent) rawEvent() map[string]any {
+	if !e.decoded {
+		e.decoded = true
+		if e.event.CloudTrailEvent != nil {
+			// Undecodable events are treated as having no raw fields
+			_ = json.Unmarshal([]byte(*e.event.CloudTrailEvent), &e.raw)
+		}
+	}
+	return e.raw
+}
+
+// values returns every value of a field. Fields can be multi valued (e.g. resource names);
+// comparisons match if any of the values match.
+func (e *queryEvent) values(field string) []string {
+	switch field {
+	case "username":
+		if e.event.Username != nil {
+			return []string{*e.event.Username}
+		}
+		return nil
+	case "event":
+		if e.event.EventName != nil {
+			return []string{*e.event.EventName}
+		}
+		return nil
+	case "resource-name":
+		var values []string
+		for _, r := range e.event.Resources {
+			if r.ResourceName != nil {
+				values = append(values, *r.ResourceName)
+			}
+		}
+		return values
+	case "resource-type":
+		var values []string
+		for _, r := range e.event.Resources {
+			if r.ResourceType != nil {
+				values = append(values, *r.ResourceType)
+			}
+		}
+		return values
+	case "arn":
+		field = "userIdentity.sessionContext.sessionIssuer.userName"
+	}
+	if alias, ok := fieldAliases[field]; ok {
+		field = alias
+	}
+
+	var current any = e.rawEvent()
+	for _, key := range strings.Split(field, ".") {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current, ok = obj[key]
+		if !ok {
+			return nil
+		}
+	}
+	return flattenValue(current)
+}
+
+func flattenValue(v any) []string {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{val}
+	case bool:
+		return []string{strconv.FormatBool(val)}
+	case float64:
+		return []string{strconv.FormatFloat(val, 'f', -1, 64)}
+	case []any:
+		var values []string
+		for _, item := range val {
+			values = append(values, flattenValue(item)...)
+		}
+		return values
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil
+		}
+		return []string{string(data)}
+	}
+}
+
+// queryNode is a compiled filter expression
+type queryNode interface {
+	eval(e *queryEvent) bool
+}
+
+type andNode struct{ left, right queryNode }
+
+func (n andNode) eval(e *queryEvent) bool { return n.left.eval(e) && n.right.eval(e) }
+
+type orNode struct{ left, right queryNode }
+
+func (n orNode) eval(e *queryEvent) bool { return n.left.eval(e) || n.right.eval(e) }
+
+type notNode struct{ node queryNode }
+
+func (n notNode) eval(e *queryEvent) bool { return !n.node.eval(e) }
+
+// matchNode matches if any value of the field satisfies match
+type matchNode struct {
+	field string
+	match func(string) bool
+}
+
+func (n matchNode) eval(e *queryEvent) bool {
+	for _, v := range e.values(n.field) {
+		if n.match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+type existsNode struct{ field string }
+
+func (n existsNode) eval(e *queryEvent) bool {
+	for _, v := range e.values(n.field) {
+		if v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// CompileQuery compiles a filter expression (see QueryHelp) into a Filter
+func CompileQuery(query string) (Filter, error) {
+	node, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return nodeFilter(node), nil
+}
+
+func nodeFilter(node queryNode) Filter {
+	return func(event types.Event) (bool, error) {
+		return node.eval(&queryEvent{event: event}), nil
+	}
+}
+
+func parseQuery(query string) (queryNode, error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty filter expression")
+	}
+	p := &queryParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression %q: %w", query, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid filter expression %q: unexpected %q", query, p.tokens[p.pos].text)
+	}
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOp
+)
+
+type queryToken struct {
+	kind tokenKind
+	text string
+}
+
+// queryOperators are matched longest first
+var queryOperators = []string{"&&", "||", "!=", "!~", "(", ")", "!", "=", "~"}
+
+func tokenizeQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			var sb strings.Builder
+			for ; end < len(runes) && runes[end] != r; end++ {
+				if runes[end] == '\\' && end+1 < len(runes) {
+					end++
+				}
+				sb.WriteRune(runes[end])
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated quoted string in %q", query)
+			}
+			tokens = append(tokens, queryToken{kind: tokenString, text: sb.String()})
+			i = end + 1
+		default:
+			if op := matchOperator(runes[i:]); op != "" {
+				tokens = append(tokens, queryToken{kind: tokenOp, text: op})
+				i += len([]rune(op))
+				continue
+			}
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && matchOperator(runes[i:]) == "" && runes[i] != '"' && runes[i] != '\'' {
+				i++
+			}
+			tokens = append(tokens, queryToken{kind: tokenWord, text: string(runes[start:i])})
+		}
+	}
+	return tokens, nil
+}
+
+func matchOperator(runes []rune) string {
+	for _, op := range queryOperators {
+		if strings.HasPrefix(string(runes[:min(len(runes), 2)]), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() *queryToken {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+func (p *queryParser) peekOp(op string) bool {
+	t := p.peek()
+	return t != nil && t.kind == tokenOp && t.text == op
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("||") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("&&") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	if p.peekOp("!") {
+		p.pos++
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	}
+	if p.peekOp("(") {
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekOp(")") {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseComparison() (queryNode, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, fmt.Errorf("unexpected end of expression, expected a field name")
+	}
+	if t.kind != tokenWord {
+		return nil, fmt.Errorf("expected a field name, got %q", t.text)
+	}
+	field := t.text
+	p.pos++
+
+	op := p.peek()
+	if op == nil {
+		return nil, fmt.Errorf("expected an operator after %q", field)
+	}
+	p.pos++
+
+	if op.kind == tokenWord {
+		switch op.text {
+		case "exists":
+			return existsNode{field: field}, nil
+		case "in":
+			value, err := p.parseValue(field)
+			if err != nil {
+				return nil, err
+			}
+			match, err := inMatcher(value)
+			if err != nil {
+				return nil, err
+			}
+			return matchNode{field: field, match: match}, nil
+		}
+		return nil, fmt.Errorf("unknown operator %q after %q", op.text, field)
+	}
+
+	value, err := p.parseValue(field)
+	if err != nil {
+		return nil, err
+	}
+	switch op.text {
+	case "=":
+		return matchNode{field: field, match: globMatcher(value)}, nil
+	case "!=":
+		return notNode{matchNode{field: field, match: globMatcher(value)}}, nil
+	case "~", "!~":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression for %q: %w", field, err)
+		}
+		var node queryNode = matchNode{field: field, match: re.MatchString}
+		if op.text == "!~" {
+			node = notNode{node}
+		}
+		return node, nil
+	}
+	return nil, fmt.Errorf("unexpected %q after %q", op.text, field)
+}
+
+func (p *queryParser) parseValue(field string) (string, error) {
+	t := p.peek()
+	if t == nil || t.kind == tokenOp {
+		return "", fmt.Errorf("missing value for %q", field)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// globMatcher matches values exactly, or as a shell pattern if the value contains wildcards
+func globMatcher(pattern string) func(string) bool {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return func(s string) bool { return s == pattern }
+	}
+	return func(s string) bool {
+		// '/' isn't special in CloudTrail values (e.g. ARNs), so a '*' must match it too
+		matched, err := path.Match(strings.ReplaceAll(pattern, "/", "\x00"), strings.ReplaceAll(s, "/", "\x00"))
+		return err == nil && matched
+	}
+}
+
+// inMatcher matches any of a comma separated list of glob patterns or CIDR ranges
+func inMatcher(list string) (func(string) bool, error) {
+	var (
+		networks []*net.IPNet
+		globs    []func(string) bool
+	)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.Contains(item, "/") {
+			if _, network, err := net.ParseCIDR(item); err == nil {
+				networks = append(networks, network)
+				continue
+			}
+		}
+		globs = append(globs, globMatcher(item))
+	}
+	if len(networks) == 0 && len(globs) == 0 {
+		return nil, fmt.Errorf("empty value list for 'in'")
+	}
+
+	return func(s string) bool {
+		if ip := net.ParseIP(s); ip != nil {
+			for _, network := range networks {
+				if network.Contains(ip) {
+					return true
+				}
+			}
+		}
+		for _, glob := range globs {
+			if glob(s) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
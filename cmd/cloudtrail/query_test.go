@@ -0,0 +1,188 @@
+package cloudtrail
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newQueryTestEvent(name, username, raw string, resources ...string) types.Event {
+	event := types.Event{
+		EventName:       aws.String(name),
+		Username:        aws.String(username),
+		CloudTrailEvent: aws.String(raw),
+	}
+	for _, r := range resources {
+		event.Resources = append(event.Resources, types.Resource{ResourceName: aws.String(r), ResourceType: aws.String("AWS::EC2::Instance")})
+	}
+	return event
+}
+
+var (
+	deleteBucketEvent = newQueryTestEvent("DeleteBucket", "john.doe", `{
+		"eventVersion": "1.8",
+		"userIdentity": {"sessionContext": {"sessionIssuer": {"userName": "ManagedOpenShift-Support", "arn": "arn:aws:iam::123456789012:role/ManagedOpenShift-Support"}}},
+		"sourceIPAddress": "203.0.113.10",
+		"awsRegion": "us-east-1",
+		"readOnly": false,
+		"errorCode": "AccessDenied",
+		"requestParameters": {"bucketName": "my-bucket"}
+	}`)
+	terminateEvent = newQueryTestEvent("TerminateInstances", "system:serviceaccount:openshift-machine-api", `{
+		"eventVersion": "1.8",
+		"userIdentity": {"sessionContext": {"sessionIssuer": {"userName": "cluster-machine-api", "arn": "arn:aws:iam::123456789012:role/cluster-machine-api"}}},
+		"sourceIPAddress": "10.0.12.4",
+		"awsRegion": "us-east-1",
+		"readOnly": false
+	}`, "i-0123", "i-4567")
+	describeEvent = newQueryTestEvent("DescribeInstances", "jane.doe", `{
+		"eventVersion": "1.8",
+		"sourceIPAddress": "ec2.amazonaws.com",
+		"awsRegion": "eu-west-1",
+		"readOnly": true
+	}`)
+	queryTestEvents = []types.Event{deleteBucketEvent, terminateEvent, describeEvent}
+)
+
+func eventNames(events []types.Event) []string {
+	var names []string
+	for _, e := range events {
+		names = append(names, *e.EventName)
+	}
+	return names
+}
+
+func TestCompileQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "event=DeleteBucket", want: []string{"DeleteBucket"}},
+		{query: "event!=DeleteBucket", want: []string{"TerminateInstances", "DescribeInstances"}},
+		{query: `event~"^(Delete|Terminate)"`, want: []string{"DeleteBucket", "TerminateInstances"}},
+		{query: "event!~^Describe", want: []string{"DeleteBucket", "TerminateInstances"}},
+		{query: "event~^Delete && !username=system:*", want: []string{"DeleteBucket"}},
+		{query: "username=system:*", want: []string{"TerminateInstances"}},
+		{query: "sourceIP in 10.0.0.0/8", want: []string{"TerminateInstances"}},
+		{query: "sourceIP in 10.0.0.0/8,203.0.113.0/24", want: []string{"DeleteBucket", "TerminateInstances"}},
+		{query: "sourceIP in *.amazonaws.com", want: []string{"DescribeInstances"}},
+		{query: "errorCode exists", want: []string{"DeleteBucket"}},
+		{query: "!errorCode exists", want: []string{"TerminateInstances", "DescribeInstances"}},
+		{query: "readOnly=false", want: []string{"DeleteBucket", "TerminateInstances"}},
+		{query: "region=eu-west-1 || requestParameters.bucketName=my-bucket", want: []string{"DeleteBucket", "DescribeInstances"}},
+		{query: "resource-name=i-4567", want: []string{"TerminateInstances"}},
+		{query: "resource-type=AWS::EC2::*", want: []string{"TerminateInstances"}},
+		{query: "arn=ManagedOpenShift-*", want: []string{"DeleteBucket"}},
+		{query: "userIdentity.sessionContext.sessionIssuer.arn=arn:aws:iam::*:role/cluster-*", want: []string{"TerminateInstances"}},
+		{query: "readOnly=false && (event=DeleteBucket || username='jane.doe')", want: []string{"DeleteBucket"}},
+		{query: "!(readOnly=false) || event=TerminateInstances && readOnly=false", want: []string{"TerminateInstances", "DescribeInstances"}},
+		{query: "doesNotExist=*", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			filter, err := CompileQuery(tt.query)
+			require.NoError(t, err)
+			filtered, err := ApplyFilters(queryTestEvents, filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, eventNames(filtered))
+		})
+	}
+}
+
+func TestCompileQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"event",
+		"event=",
+		"event = DeleteBucket &&",
+		"(event=DeleteBucket",
+		"event=DeleteBucket)",
+		"event~[",
+		"event~^(Delete|Terminate)",
+		"event like Delete",
+		`event="DeleteBucket`,
+		"&& event=DeleteBucket",
+	} {
+		t.Run(query, func(t *testing.T) {
+			_, err := CompileQuery(query)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWriteEventFiltersCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters WriteEventFilters
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "no filters",
+			want: []string{"DeleteBucket", "TerminateInstances", "DescribeInstances"},
+		},
+		{
+			name:    "include values of the same key are or-ed",
+			filters: WriteEventFilters{Include: []string{"event=DeleteBucket", "event=DescribeInstances"}},
+			want:    []string{"DeleteBucket", "DescribeInstances"},
+		},
+		{
+			name:    "include keys are and-ed",
+			filters: WriteEventFilters{Include: []string{"event=DeleteBucket", "username=jane.doe"}},
+			want:    nil,
+		},
+		{
+			name:    "exclude any key",
+			filters: WriteEventFilters{Exclude: []string{"event=DeleteBucket", "username=jane.doe"}},
+			want:    []string{"TerminateInstances"},
+		},
+		{
+			name:    "include values match exactly",
+			filters: WriteEventFilters{Include: []string{"username=system:*", "username=jane.*"}},
+			want:    nil,
+		},
+		{
+			name:    "exclude values match exactly",
+			filters: WriteEventFilters{Exclude: []string{"event=Delete*", "event=DescribeInstances"}},
+			want:    []string{"DeleteBucket", "TerminateInstances"},
+		},
+		{
+			name:    "include, exclude and query",
+			filters: WriteEventFilters{Include: []string{"resource-type=AWS::EC2::Instance"}, Exclude: []string{"username=jane.doe"}, Query: "sourceIP in 10.0.0.0/8 && readOnly=false"},
+			want:    []string{"TerminateInstances"},
+		},
+		{
+			name:    "raw fields are only supported in queries",
+			filters: WriteEventFilters{Include: []string{"readOnly=false"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid include",
+			filters: WriteEventFilters{Include: []string{"event"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid query",
+			filters: WriteEventFilters{Query: "event=("},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := tt.filters.Compile()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			filtered, err := ApplyFilters(queryTestEvents, filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, eventNames(filtered))
+		})
+	}
+}
@@ -0,0 +1,581 @@
+package cloudtrail
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	envConfig "github.com/openshift/osdctl/pkg/envConfig"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/output"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// Actor classifies who performed an event
+type Actor string
+
+const (
+	// ActorRedHat are principals matching the filter_regex_patterns of the osdctl config,
+	// i.e. Red Hat SREs, the installer and the cluster's own operators
+	ActorRedHat Actor = "red-hat"
+	// ActorCustomer are all other principals
+	ActorCustomer Actor = "customer"
+)
+
+// destructiveEventRegexp matches the calls that are highlighted when they come in bursts
+var destructiveEventRegexp = regexp.MustCompile(`^(Delete|Terminate|Detach)|^PutBucketPolicy$`)
+
+// ActivitySummary groups the write events of a period
+type ActivitySummary struct {
+	StartTime   time.Time          `json:"startTime"`
+	EndTime     time.Time          `json:"endTime"`
+	TotalEvents int                `json:"totalEvents"`
+	Destructive int                `json:"destructiveEvents"`
+	Actors      map[Actor]int      `json:"actors"`
+	Principals  []PrincipalSummary `json:"principals"`
+	EventNames  []CountEntry       `json:"eventNames"`
+	Resources   []CountEntry       `json:"resources"`
+	Hours       []HourSummary      `json:"hours"`
+	Bursts      []Burst            `json:"bursts,omitempty"`
+}
+
+// PrincipalSummary describes the activity of a single principal
+type PrincipalSummary struct {
+	Principal   string    `json:"principal"`
+	ARN         string    `json:"arn,omitempty"`
+	Actor       Actor     `json:"actor"`
+	Events      int       `json:"events"`
+	Destructive int       `json:"destructiveEvents"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+	// New is set for principals that didn't make any write call during the baseline period
+	New bool `json:"new"`
+}
+
+// CountEntry is a name and how often it occurred
+type CountEntry struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// HourSummary counts the events of an hour
+type HourSummary struct {
+	Hour        time.Time `json:"hour"`
+	Events      int       `json:"events"`
+	Destructive int       `json:"destructiveEvents"`
+}
+
+// Burst is a series of destructive calls of a principal within a short time
+type Burst struct {
+	Principal  string    `json:"principal"`
+	Actor      Actor     `json:"actor"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Events     int       `json:"events"`
+	EventNames []string  `json:"eventNames"`
+}
+
+// summaryOptions holds the options of the summary command
+type summaryOptions struct {
+	ClusterID      string
+	StartTime      string
+	EndTime        string
+	Duration       string
+	Baseline       time.Duration
+	BurstThreshold int
+	BurstWindow    time.Duration
+	Top            int
+
+	output output.Options
+}
+
+// summaryConfig holds the parameters of the analysis
+type summaryConfig struct {
+	ignoreRegex    *regexp.Regexp
+	burstThreshold int
+	burstWindow    time.Duration
+}
+
+const cloudtrailSummaryDescription = `Summarize the CloudTrail write events of a cluster.
+
+Instead of listing every event like write-events, the events are grouped by
+principal, event name, resource and hour. The report highlights:
+
+  - principals that made write calls in the period but not in the baseline
+    period right before it (--baseline)
+  - bursts of destructive calls (Delete*, Terminate*, Detach*, PutBucketPolicy)
+    made by the same principal within --burst-window
+  - whether each principal is Red Hat (SREs, installer, cluster operators) or
+    the customer, using the filter_regex_patterns of the cloudtrail_cmd_lists
+    section of the osdctl config file`
+
+func newCmdSummary() *cobra.Command {
+	opts := &summaryOptions{}
+
+	summaryCmd := &cobra.Command{
+		Use:   "summary",
+		Short: "Summarize cloudtrail write events by principal, event, resource and hour",
+		Long:  cloudtrailSummaryDescription,
+		Example: `  # Summarize the last 24 hours
+  osdctl cloudtrail summary -C ${CLUSTER_ID} --since 24h
+
+  # Summarize an incident window as Markdown to paste into a ticket
+  osdctl cloudtrail summary -C ${CLUSTER_ID} --after 2025-07-15,09:00:00 --until 2025-07-15,17:00:00 -o markdown
+
+  # Flag 3 or more destructive calls within 5 minutes, as JSON
+  osdctl cloudtrail summary -C ${CLUSTER_ID} --burst-threshold 3 --burst-window 5m -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run()
+		},
+	}
+
+	summaryCmd.Flags().StringVarP(&opts.ClusterID, "cluster-id", "C", "", "Cluster ID")
+	summaryCmd.Flags().StringVar(&opts.StartTime, "after", "", "Specifies all events that occur after the specified time. Format \"YY-MM-DD,hh:mm:ss\".")
+	summaryCmd.Flags().StringVar(&opts.EndTime, "until", "", "Specifies all events that occur before the specified time. Format \"YY-MM-DD,hh:mm:ss\".")
+	summaryCmd.Flags().StringVar(&opts.Duration, "since", "24h", "Time window to summarize (e.g. 6h, 24h). Valid time units are \"ns\", \"us\" (or \"µs\"), \"ms\", \"s\", \"m\", \"h\".")
+	summaryCmd.Flags().DurationVar(&opts.Baseline, "baseline", 72*time.Hour, "Period before the summarized window in which principals are considered known (0 disables first-seen detection)")
+	summaryCmd.Flags().IntVar(&opts.BurstThreshold, "burst-threshold", 5, "Minimum number of destructive calls of a principal within --burst-window reported as a burst")
+	summaryCmd.Flags().DurationVar(&opts.BurstWindow, "burst-window", 10*time.Minute, "Time window for burst detection")
+	summaryCmd.Flags().IntVar(&opts.Top, "top", 10, "Number of event names and resources shown in table and Markdown output (0 shows all)")
+	opts.output.AddFormatFlag(summaryCmd)
+	_ = summaryCmd.MarkFlagRequired("cluster-id")
+
+	return summaryCmd
+}
+
+func (o *summaryOptions) run() error {
+	if err := o.output.Validate(); err != nil {
+		return err
+	}
+	if o.BurstThreshold < 1 {
+		return fmt.Errorf("--burst-threshold must be at least 1")
+	}
+	if err := utils.IsValidClusterKey(o.ClusterID); err != nil {
+		return err
+	}
+
+	startTime, endTime, err := ParseStartEndTime(o.StartTime, o.EndTime, o.Duration)
+	if err != nil {
+		return err
+	}
+
+	patterns, err := envConfig.LoadCloudTrailConfig()
+	if err != nil {
+		return err
+	}
+	if len(patterns) == 0 {
+		fmt.Fprintln(os.Stderr, "[WARN] No filter_regex_patterns in the osdctl config, all principals are classified as customer")
+	}
+	cfg, err := newSummaryConfig(patterns, o.BurstThreshold, o.BurstWindow)
+	if err != nil {
+		return err
+	}
+
+	connection, err := utils.CreateConnection()
+	if err != nil {
+		return fmt.Errorf("unable to create connection to ocm: %w", err)
+	}
+	defer connection.Close()
+
+	cluster, err := utils.GetClusterAnyStatus(connection, o.ClusterID)
+	if err != nil {
+		return err
+	}
+	if strings.ToUpper(cluster.CloudProvider().ID()) != "AWS" {
+		return fmt.Errorf("this command is only available for AWS clusters")
+	}
+
+	awsCfg, err := osdCloud.CreateAWSV2Config(connection, cluster)
+	if err != nil {
+		return err
+	}
+	arn, accountID, err := Whoami(*sts.NewFromConfig(awsCfg))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "[INFO] Summarizing write events from %v until %v for AWS Account %v as %v\n", startTime, endTime, accountID, arn)
+
+	period := Period{StartTime: startTime, EndTime: endTime}
+	events, err := fetchWriteEvents(awsCfg, o.ClusterID, period)
+	if err != nil {
+		return err
+	}
+
+	var baseline []types.Event
+	if o.Baseline > 0 {
+		fmt.Fprintf(os.Stderr, "[INFO] Fetching %v of baseline events\n", o.Baseline)
+		baseline, err = fetchWriteEvents(awsCfg, o.ClusterID, Period{StartTime: startTime.Add(-o.Baseline), EndTime: startTime.Add(-time.Second)})
+		if err != nil {
+			return err
+		}
+	}
+
+	summary := summarizeEvents(events, baseline, o.Baseline > 0, period, cfg)
+	result, err := summaryResult(summary, o.Top)
+	if err != nil {
+		return err
+	}
+	return o.output.Print(os.Stdout, result)
+}
+
+// summaryResult returns the summary with its table and Markdown reports
+func summaryResult(summary ActivitySummary, top int) (*output.Result, error) {
+	var text, md strings.Builder
+	if err := printSummaryTable(&text, summary, top); err != nil {
+		return nil, err
+	}
+	printSummaryMarkdown(&md, summary, top)
+
+	result := output.NewObject(summary, strings.TrimRight(text.String(), "\n"))
+	result.Markdown = strings.TrimRight(md.String(), "\n")
+	return result, nil
+}
+
+// fetchWriteEvents returns the write events of the cluster region and of the global region
+func fetchWriteEvents(cfg aws.Config, clusterID string, period Period) ([]types.Event, error) {
+	regions := []string{cfg.Region}
+	if cfg.Region != DEFAULT_REGION {
+		regions = append(regions, DEFAULT_REGION)
+	}
+
+	var events []types.Event
+	for _, region := range regions {
+		for page := range NewEventAPI(cfg, true, region).GetEvents(clusterID, period) {
+			if page.errors != nil {
+				return nil, page.errors
+			}
+			events = append(events, page.AWSEvent...)
+		}
+	}
+	return events, nil
+}
+
+func newSummaryConfig(ignorePatterns []string, burstThreshold int, burstWindow time.Duration) (summaryConfig, error) {
+	cfg := summaryConfig{burstThreshold: burstThreshold, burstWindow: burstWindow}
+	if len(ignorePatterns) > 0 {
+		re, err := regexp.Compile(strings.Join(ignorePatterns, "|"))
+		if err != nil {
+			return cfg, fmt.Errorf("invalid filter_regex_patterns in the osdctl config: %w", err)
+		}
+		cfg.ignoreRegex = re
+	}
+	return cfg, nil
+}
+
+// principal returns the name of the principal of an event and its session issuer ARN
+func principal(event types.Event) (string, string) {
+	var name, arn string
+	if raw, err := ExtractUserDetails(event.CloudTrailEvent); err == nil {
+		arn = raw.UserIdentity.SessionContext.SessionIssuer.Arn
+	}
+	if event.Username != nil {
+		name = *event.Username
+	}
+	if name == "" {
+		name = arn
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	return name, arn
+}
+
+// classify uses the same matching as the write-events ignore list: principals whose
+// user name or session issuer ARN match it are Red Hat
+func (c summaryConfig) classify(name, arn string) Actor {
+	if c.ignoreRegex == nil {
+		return ActorCustomer
+	}
+	if c.ignoreRegex.MatchString(name) || arn != "" && c.ignoreRegex.MatchString(arn) {
+		return ActorRedHat
+	}
+	return ActorCustomer
+}
+
+func isDestructiveEvent(event types.Event) bool {
+	return event.EventName != nil && destructiveEventRegexp.MatchString(*event.EventName)
+}
+
+// summarizeEvents groups the events of the period. Principals without events in the baseline
+// are marked as new if hasBaseline is set.
+func summarizeEvents(events, baseline []types.Event, hasBaseline bool, period Period, cfg summaryConfig) ActivitySummary {
+	summary := ActivitySummary{
+		StartTime: period.StartTime,
+		EndTime:   period.EndTime,
+		Actors:    map[Actor]int{},
+	}
+
+	known := map[string]bool{}
+	for _, event := range baseline {
+		name, _ := principal(event)
+		known[name] = true
+	}
+
+	// Oldest first, for first/last seen and burst detection
+	sorted := make([]types.Event, 0, len(events))
+	for _, event := range events {
+		if event.EventTime != nil {
+			sorted = append(sorted, event)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].EventTime.Before(*sorted[j].EventTime) })
+
+	principals := map[string]*PrincipalSummary{}
+	eventNames := map[string]int{}
+	resources := map[string]int{}
+	hours := map[time.Time]*HourSummary{}
+	destructive := map[string][]types.Event{}
+
+	for _, event := range sorted {
+		name, arn := principal(event)
+		p, ok := principals[name]
+		if !ok {
+			p = &PrincipalSummary{
+				Principal: name,
+				ARN:       arn,
+				Actor:     cfg.classify(name, arn),
+				FirstSeen: *event.EventTime,
+				New:       hasBaseline && !known[name],
+			}
+			principals[name] = p
+		}
+		p.Events++
+		p.LastSeen = *event.EventTime
+
+		summary.TotalEvents++
+		summary.Actors[p.Actor]++
+		if event.EventName != nil {
+			eventNames[*event.EventName]++
+		}
+		for _, r := range event.Resources {
+			if r.ResourceName != nil {
+				resources[*r.ResourceName]++
+			}
+		}
+
+		hour := event.EventTime.UTC().Truncate(time.Hour)
+		h, ok := hours[hour]
+		if !ok {
+			h = &HourSummary{Hour: hour}
+			hours[hour] = h
+		}
+		h.Events++
+
+		if isDestructiveEvent(event) {
+			p.Destructive++
+			h.Destructive++
+			summary.Destructive++
+			destructive[name] = append(destructive[name], event)
+		}
+	}
+
+	for _, p := range principals {
+		summary.Principals = append(summary.Principals, *p)
+	}
+	sort.Slice(summary.Principals, func(i, j int) bool {
+		if summary.Principals[i].Events != summary.Principals[j].Events {
+			return summary.Principals[i].Events > summary.Principals[j].Events
+		}
+		return summary.Principals[i].Principal < summary.Principals[j].Principal
+	})
+
+	for _, h := range hours {
+		summary.Hours = append(summary.Hours, *h)
+	}
+	sort.Slice(summary.Hours, func(i, j int) bool { return summary.Hours[i].Hour.Before(summary.Hours[j].Hour) })
+
+	summary.EventNames = sortedCounts(eventNames)
+	summary.Resources = sortedCounts(resources)
+
+	for name, events := range destructive {
+		summary.Bursts = append(summary.Bursts, findBursts(name, principals[name].Actor, events, cfg)...)
+	}
+	sort.Slice(summary.Bursts, func(i, j int) bool {
+		if !summary.Bursts[i].Start.Equal(summary.Bursts[j].Start) {
+			return summary.Bursts[i].Start.Before(summary.Bursts[j].Start)
+		}
+		return summary.Bursts[i].Principal < summary.Bursts[j].Principal
+	})
+
+	return summary
+}
+
+// findBursts returns the series of at least burstThreshold destructive events, sorted oldest
+// first, that happened within burstWindow of the first event of the series
+func findBursts(name string, actor Actor, events []types.Event, cfg summaryConfig) []Burst {
+	var bursts []Burst
+	for i := 0; i < len(events); {
+		j := i
+		for j+1 < len(events) && events[j+1].EventTime.Sub(*events[i].EventTime) <= cfg.burstWindow {
+			j++
+		}
+		if j-i+1 < cfg.burstThreshold {
+			i++
+			continue
+		}
+
+		burst := Burst{
+			Principal: name,
+			Actor:     actor,
+			Start:     *events[i].EventTime,
+			End:       *events[j].EventTime,
+			Events:    j - i + 1,
+		}
+		seen := map[string]bool{}
+		for _, event := range events[i : j+1] {
+			if !seen[*event.EventName] {
+				seen[*event.EventName] = true
+				burst.EventNames = append(burst.EventNames, *event.EventName)
+			}
+		}
+		bursts = append(bursts, burst)
+		i = j + 1
+	}
+	return bursts
+}
+
+func sortedCounts(counts map[string]int) []CountEntry {
+	entries := make([]CountEntry, 0, len(counts))
+	for name, count := range counts {
+		entries = append(entries, CountEntry{Name: name, Count: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+func topCounts(entries []CountEntry, top int) []CountEntry {
+	if top > 0 && len(entries) > top {
+		return entries[:top]
+	}
+	return entries
+}
+
+func printSummaryTable(w io.Writer, summary ActivitySummary, top int) error {
+	fmt.Fprintf(w, "Write events from %s until %s: %d (%d destructive, %d customer, %d Red Hat)\n\n",
+		summary.StartTime.Format(time.RFC3339), summary.EndTime.Format(time.RFC3339), summary.TotalEvents,
+		summary.Destructive, summary.Actors[ActorCustomer], summary.Actors[ActorRedHat])
+
+	if len(summary.Bursts) > 0 {
+		fmt.Fprintln(w, "DESTRUCTIVE BURSTS")
+		p := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+		p.AddRow([]string{"PRINCIPAL", "ACTOR", "START", "END", "EVENTS", "CALLS"})
+		for _, b := range summary.Bursts {
+			p.AddRow([]string{b.Principal, string(b.Actor), b.Start.Format(time.RFC3339), b.End.Format(time.RFC3339), strconv.Itoa(b.Events), strings.Join(b.EventNames, ",")})
+		}
+		if err := p.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "PRINCIPALS")
+	p := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+	p.AddRow([]string{"PRINCIPAL", "ACTOR", "EVENTS", "DESTRUCTIVE", "FIRST SEEN", "LAST SEEN", "NEW"})
+	for _, pr := range summary.Principals {
+		isNew := ""
+		if pr.New {
+			isNew = "yes"
+		}
+		p.AddRow([]string{pr.Principal, string(pr.Actor), strconv.Itoa(pr.Events), strconv.Itoa(pr.Destructive), pr.FirstSeen.Format(time.RFC3339), pr.LastSeen.Format(time.RFC3339), isNew})
+	}
+	if err := p.Flush(); err != nil {
+		return err
+	}
+
+	for _, section := range []struct {
+		title   string
+		entries []CountEntry
+	}{
+		{"EVENTS", summary.EventNames},
+		{"RESOURCES", summary.Resources},
+	} {
+		fmt.Fprintf(w, "\n%s\n", section.title)
+		p := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+		for _, e := range topCounts(section.entries, top) {
+			p.AddRow([]string{e.Name, strconv.Itoa(e.Count)})
+		}
+		if err := p.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(w, "\nHOURS")
+	p = printer.NewTablePrinter(w, 20, 1, 3, ' ')
+	p.AddRow([]string{"HOUR", "EVENTS", "DESTRUCTIVE"})
+	for _, h := range summary.Hours {
+		p.AddRow([]string{h.Hour.Format(time.RFC3339), strconv.Itoa(h.Events), strconv.Itoa(h.Destructive)})
+	}
+	return p.Flush()
+}
+
+func printSummaryMarkdown(w io.Writer, summary ActivitySummary, top int) {
+	fmt.Fprintf(w, "## CloudTrail write events %s – %s\n\n", summary.StartTime.Format(time.RFC3339), summary.EndTime.Format(time.RFC3339))
+	fmt.Fprintf(w, "- Events: %d\n- Destructive events: %d\n- Customer events: %d\n- Red Hat events: %d\n\n",
+		summary.TotalEvents, summary.Destructive, summary.Actors[ActorCustomer], summary.Actors[ActorRedHat])
+
+	if len(summary.Bursts) > 0 {
+		fmt.Fprintln(w, "### Destructive bursts")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| Principal | Actor | Start | End | Events | Calls |")
+		fmt.Fprintln(w, "|---|---|---|---|---|---|")
+		for _, b := range summary.Bursts {
+			fmt.Fprintf(w, "| %s | %s | %s | %s | %d | %s |\n", markdownCell(b.Principal), b.Actor,
+				b.Start.Format(time.RFC3339), b.End.Format(time.RFC3339), b.Events, markdownCell(strings.Join(b.EventNames, ", ")))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "### Principals")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Principal | Actor | Events | Destructive | First seen | Last seen | New |")
+	fmt.Fprintln(w, "|---|---|---|---|---|---|---|")
+	for _, p := range summary.Principals {
+		isNew := ""
+		if p.New {
+			isNew = "**yes**"
+		}
+		fmt.Fprintf(w, "| %s | %s | %d | %d | %s | %s | %s |\n", markdownCell(p.Principal), p.Actor, p.Events, p.Destructive,
+			p.FirstSeen.Format(time.RFC3339), p.LastSeen.Format(time.RFC3339), isNew)
+	}
+
+	for _, section := range []struct {
+		title, column string
+		entries       []CountEntry
+	}{
+		{"Events", "Event", summary.EventNames},
+		{"Resources", "Resource", summary.Resources},
+	} {
+		fmt.Fprintf(w, "\n### %s\n\n| %s | Count |\n|---|---|\n", section.title, section.column)
+		for _, e := range topCounts(section.entries, top) {
+			fmt.Fprintf(w, "| %s | %d |\n", markdownCell(e.Name), e.Count)
+		}
+	}
+
+	fmt.Fprintln(w, "\n### Hours")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Hour | Events | Destructive |")
+	fmt.Fprintln(w, "|---|---|---|")
+	for _, h := range summary.Hours {
+		fmt.Fprintf(w, "| %s | %d | %d |\n", h.Hour.Format(time.RFC3339), h.Events, h.Destructive)
+	}
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,124 @@
+package cloudtrail
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/openshift/osdctl/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var summaryTestStart = time.Date(2025, 7, 15, 9, 0, 0, 0, time.UTC)
+
+func newSummaryTestEvent(name, username, issuerArn string, offset time.Duration, resources ...string) types.Event {
+	event := types.Event{
+		EventName: aws.String(name),
+		Username:  aws.String(username),
+		EventTime: aws.Time(summaryTestStart.Add(offset)),
+		CloudTrailEvent: aws.String(fmt.Sprintf(`{"eventVersion": "1.08", "userIdentity": {"sessionContext": {"sessionIssuer": {"arn": %q}}}}`,
+			issuerArn)),
+	}
+	for _, r := range resources {
+		event.Resources = append(event.Resources, types.Resource{ResourceName: aws.String(r)})
+	}
+	return event
+}
+
+func TestSummarizeEvents(t *testing.T) {
+	const (
+		sreRole      = "arn:aws:iam::123456789012:role/RH-SRE-Support"
+		customerRole = "arn:aws:iam::123456789012:role/admin"
+	)
+	events := []types.Event{
+		newSummaryTestEvent("RunInstances", "sre-session", sreRole, 5*time.Minute, "i-1"),
+		newSummaryTestEvent("TerminateInstances", "jdoe", customerRole, 70*time.Minute, "i-1"),
+		newSummaryTestEvent("DeleteVolume", "jdoe", customerRole, 72*time.Minute, "vol-1"),
+		newSummaryTestEvent("DetachVolume", "jdoe", customerRole, 71*time.Minute, "vol-1"),
+		newSummaryTestEvent("PutBucketPolicy", "jdoe", customerRole, 90*time.Minute),
+		newSummaryTestEvent("CreateTags", "jdoe", customerRole, 91*time.Minute, "i-1"),
+		newSummaryTestEvent("DeleteSecurityGroup", "sre-session", sreRole, 95*time.Minute),
+	}
+	baseline := []types.Event{
+		newSummaryTestEvent("RunInstances", "sre-session", sreRole, -time.Hour),
+	}
+
+	cfg, err := newSummaryConfig([]string{"^RH-SRE-", "RH-SRE-"}, 3, 10*time.Minute)
+	require.NoError(t, err)
+	period := Period{StartTime: summaryTestStart, EndTime: summaryTestStart.Add(2 * time.Hour)}
+	summary := summarizeEvents(events, baseline, true, period, cfg)
+
+	assert.Equal(t, 7, summary.TotalEvents)
+	assert.Equal(t, 5, summary.Destructive)
+	assert.Equal(t, map[Actor]int{ActorCustomer: 5, ActorRedHat: 2}, summary.Actors)
+
+	require.Len(t, summary.Principals, 2)
+	jdoe := summary.Principals[0]
+	assert.Equal(t, "jdoe", jdoe.Principal)
+	assert.Equal(t, ActorCustomer, jdoe.Actor)
+	assert.True(t, jdoe.New)
+	assert.Equal(t, 4, jdoe.Destructive)
+	assert.Equal(t, summaryTestStart.Add(70*time.Minute), jdoe.FirstSeen)
+	assert.Equal(t, summaryTestStart.Add(91*time.Minute), jdoe.LastSeen)
+	sre := summary.Principals[1]
+	assert.Equal(t, ActorRedHat, sre.Actor)
+	assert.False(t, sre.New)
+
+	assert.Equal(t, []CountEntry{{Name: "i-1", Count: 3}, {Name: "vol-1", Count: 2}}, summary.Resources)
+	assert.Equal(t, CountEntry{Name: "CreateTags", Count: 1}, summary.EventNames[0])
+
+	assert.Equal(t, []HourSummary{
+		{Hour: summaryTestStart, Events: 1},
+		{Hour: summaryTestStart.Add(time.Hour), Events: 6, Destructive: 5},
+	}, summary.Hours)
+
+	// Only the three calls within 10 minutes form a burst, PutBucketPolicy is 18 minutes later
+	require.Len(t, summary.Bursts, 1)
+	assert.Equal(t, Burst{
+		Principal:  "jdoe",
+		Actor:      ActorCustomer,
+		Start:      summaryTestStart.Add(70 * time.Minute),
+		End:        summaryTestStart.Add(72 * time.Minute),
+		Events:     3,
+		EventNames: []string{"TerminateInstances", "DetachVolume", "DeleteVolume"},
+	}, summary.Bursts[0])
+
+	var md bytes.Buffer
+	printSummaryMarkdown(&md, summary, 1)
+	assert.Contains(t, md.String(), "| jdoe | customer | 5 | 4 |")
+	assert.Contains(t, md.String(), "| i-1 | 3 |")
+	assert.NotContains(t, md.String(), "| vol-1 | 2 |")
+
+	result, err := summaryResult(summary, 1)
+	require.NoError(t, err)
+	for format, want := range map[string]string{
+		"table":                   "PRINCIPALS",
+		"markdown":                "### Principals",
+		"json":                    `"totalEvents": 7`,
+		"jsonpath={.totalEvents}": "7",
+	} {
+		var out bytes.Buffer
+		require.NoError(t, (&output.Options{Format: format}).Print(&out, result), format)
+		assert.Contains(t, out.String(), want, format)
+	}
+}
+
+func TestSummarizeEventsWithoutBaseline(t *testing.T) {
+	cfg, err := newSummaryConfig(nil, 1, time.Minute)
+	require.NoError(t, err)
+	events := []types.Event{newSummaryTestEvent("DeleteBucket", "RH-SRE-jdoe", "", 0)}
+
+	summary := summarizeEvents(events, nil, false, Period{}, cfg)
+	require.Len(t, summary.Principals, 1)
+	assert.False(t, summary.Principals[0].New)
+	// Without patterns everybody is a customer
+	assert.Equal(t, ActorCustomer, summary.Principals[0].Actor)
+	assert.Len(t, summary.Bursts, 1)
+
+	_, err = newSummaryConfig([]string{"("}, 1, time.Minute)
+	assert.Error(t, err)
+}
@@ -31,6 +31,7 @@ type writeEventsOptions struct {
 
 	awsAPI   *EventAPI
 	printer  *Printer
+	filter   Filter
 	log      *logrus.Logger
 	logLevel string
 
@@ -47,7 +48,11 @@ const (
     $ osdctl cloudtrail write-events -C cluster-id --after 2025-07-15,15:00:00 --since 2h --url
 
     # Get all events until the specified time since the last 2 hours; print raw-event
-    $ osdctl cloudtrail write-events -C cluster-id --after 2025-07-15,15:00:00 --since 2h --raw-event`
+    $ osdctl cloudtrail write-events -C cluster-id --after 2025-07-15,15:00:00 --since 2h --raw-event
+
+    # Filter with an expression: failed delete calls not made by system users from outside the VPC
+    $ osdctl cloudtrail write-events -C cluster-id --since 6h \
+      -q 'event~^Delete && !username=system:* && errorCode exists && !(sourceIP in 10.0.0.0/8)'`
 
 	cloudtrailWriteEventsDescription = `
 	Lists AWS CloudTrail write events for a specific OpenShift/ROSA cluster with advanced 
@@ -57,7 +62,12 @@ const (
 	the appropriate AWS role for the target cluster to access CloudTrail logs.
 
 	By default, the command filters out system and service account events using patterns 
-	from the osdctl configuration file. `
+	from the osdctl configuration file.
+
+	Events can be filtered with -I/-E key=value pairs, which match the value exactly,
+	or with a filter expression passed to --query.
+
+` + QueryHelp
 )
 
 func newCmdWriteEvents() *cobra.Command {
@@ -71,7 +81,7 @@ func newCmdWriteEvents() *cobra.Command {
 		Args:    cobra.NoArgs,
 		PreRunE: func(cmd *cobra.Command, args []string) error { return ops.preRun(*fil) },
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return ops.run(*fil)
+			return ops.run()
 		},
 	}
 	listEventsCmd.Flags().StringVarP(&ops.ClusterID, "cluster-id", "C", "", "Cluster ID")
@@ -87,12 +97,23 @@ func newCmdWriteEvents() *cobra.Command {
 
 	listEventsCmd.Flags().StringSliceVarP(&fil.Include, "include", "I", nil, "Filter events by inclusion. (i.e. \"-I username=, -I event=, -I resource-name=, -I resource-type=, -I arn=\")")
 	listEventsCmd.Flags().StringSliceVarP(&fil.Exclude, "exclude", "E", nil, "Filter events by exclusion. (i.e. \"-E username=, -E event=, -E resource-name=, -E resource-type=, -E arn=\")")
+	listEventsCmd.Flags().StringVarP(&fil.Query, "query", "q", "", "Filter events with an expression, e.g. 'event~^Delete && !username=system:*' (see --help)")
 	listEventsCmd.MarkFlagRequired("cluster-id")
 	return listEventsCmd
 }
 
-func (o *writeEventsOptions) getPages(filters WriteEventFilters, region string, requestedPeriod Period) error {
-	cache, err := NewCache(o.log, o.ClusterID)
+// filterEvents applies the compiled write-events filters
+func (o *writeEventsOptions) filterEvents(events []types.Event) []types.Event {
+	filtered, err := ApplyFilters(events, o.filter)
+	if err != nil {
+		o.log.Errorf("failed to filter events: %v", err)
+		return nil
+	}
+	return filtered
+}
+
+func (o *writeEventsOptions) getPages(region string, requestedPeriod Period) error {
+	cache, err := NewCache(o.log, o.ClusterID, region)
 	if err != nil {
 		return err
 	}
@@ -106,14 +127,11 @@ func (o *writeEventsOptions) getPages(filters WriteEventFilters, region string,
 		return err
 	}
 
+	// The cache tracks the fetched periods of every region on its own
 	var fullCacheOverlap bool
+	o.missingPeriod, fullCacheOverlap = requestedPeriod.DiffMultiple(cache.Period)
 
-	if len(o.missingPeriod) == 0 {
-		o.missingPeriod, fullCacheOverlap = requestedPeriod.DiffMultiple(cache.Period)
-	}
-
-	cacheEvents := cache.FilterByPeriod(requestedPeriod)
-	cacheEvents = FilterByRegion(region, cacheEvents)
+	cacheEvents := cache.Lookup(requestedPeriod, region)
 
 	sort.Sort(sort.Reverse(Periods(o.missingPeriod)))
 
@@ -130,7 +148,7 @@ func (o *writeEventsOptions) getPages(filters WriteEventFilters, region string,
 			events := FilterEventsBefore(FilterEventsAfter(
 				cacheEvents, requestedPeriod.StartTime),
 				requestedPeriod.EndTime)
-			o.printer.PrintEvents(Filters(filters, events), o.PrintFields)
+			o.printer.PrintEvents(o.filterEvents(events), o.PrintFields)
 			o.missingPeriod = []Period{}
 			break
 		}
@@ -141,7 +159,7 @@ func (o *writeEventsOptions) getPages(filters WriteEventFilters, region string,
 				FilterEventsAfter(cacheEvents, currentPeriod.EndTime),
 				requestedPeriod.EndTime,
 			)
-			o.printer.PrintEvents(Filters(filters, cachedBefore), o.PrintFields)
+			o.printer.PrintEvents(o.filterEvents(cachedBefore), o.PrintFields)
 		}
 
 		var missingEvents []types.Event
@@ -155,7 +173,7 @@ func (o *writeEventsOptions) getPages(filters WriteEventFilters, region string,
 			missingEvents = append(missingEvents, page.AWSEvent...)
 		}
 
-		fetchedEvents := Filters(filters, missingEvents)
+		fetchedEvents := o.filterEvents(missingEvents)
 		o.printer.PrintEvents(fetchedEvents, o.PrintFields)
 
 		// if startTimePeriod is out of range, create a period starting
@@ -174,7 +192,7 @@ func (o *writeEventsOptions) getPages(filters WriteEventFilters, region string,
 			cacheEvents, startTimePeriod),
 			currentPeriod.StartTime,
 		)
-		o.printer.PrintEvents(Filters(filters, cachedBetween), o.PrintFields)
+		o.printer.PrintEvents(o.filterEvents(cachedBetween), o.PrintFields)
 
 		newCacheData.Period = append(newCacheData.Period, currentPeriod)
 		newCacheData.Event = append(newCacheData.Event, missingEvents...)
@@ -193,10 +211,13 @@ func (o *writeEventsOptions) preRun(filters WriteEventFilters) error {
 	if err != nil {
 		return err
 	}
-	if err := ValidateFilters(filters.Include); err != nil {
-		return err
-	}
-	if err := ValidateFilters(filters.Exclude); err != nil {
+	return o.setup(filters)
+}
+
+// setup compiles the filters, validates the print fields and configures logging
+func (o *writeEventsOptions) setup(filters WriteEventFilters) error {
+	var err error
+	if o.filter, err = filters.Compile(); err != nil {
 		return err
 	}
 	if err := ValidateFormat(o.PrintFields); err != nil {
@@ -221,7 +242,7 @@ func (o *writeEventsOptions) preRun(filters WriteEventFilters) error {
 
 }
 
-func (o *writeEventsOptions) run(filters WriteEventFilters) error {
+func (o *writeEventsOptions) run() error {
 	connection, err := utils.CreateConnection()
 	if err != nil {
 		o.log.Error("unable to create connection to ocm: %w", err)
@@ -259,7 +280,7 @@ func (o *writeEventsOptions) run(filters WriteEventFilters) error {
 
 	requestedPeriod := Period{StartTime: startTime, EndTime: endTime}
 
-	err = o.getPages(filters, cfg.Region, requestedPeriod)
+	err = o.getPages(cfg.Region, requestedPeriod)
 	if err != nil {
 		return err
 	}
@@ -271,7 +292,7 @@ func (o *writeEventsOptions) run(filters WriteEventFilters) error {
 		defaultAwsAPI := NewEventAPI(cfg, true, DEFAULT_REGION)
 		o.awsAPI = defaultAwsAPI
 
-		err = o.getPages(filters, DEFAULT_REGION, requestedPeriod)
+		err = o.getPages(DEFAULT_REGION, requestedPeriod)
 		if err != nil {
 			return err
 		}
@@ -16,6 +16,7 @@ import (
 	"github.com/openshift/osdctl/cmd/servicelog"
 	infraPkg "github.com/openshift/osdctl/pkg/infra"
 	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/operation"
 	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
@@ -47,6 +48,8 @@ type changeVolumeTypeOptions struct {
 
 	hiveClient      client.Client
 	hiveAdminClient client.Client
+
+	operation operation.Options
 }
 
 func newCmdChangeVolumeType() *cobra.Command {
@@ -61,7 +64,8 @@ For control plane nodes, it patches the ControlPlaneMachineSet (CPMS) which auto
 rolls nodes one at a time. For infra nodes, it uses the Hive MachinePool dance to safely
 replace all infra nodes with new ones using the target volume type.
 
-Pre-flight checks are performed automatically before making changes.`,
+Pre-flight checks are performed automatically before making changes. The progress is saved
+after every step, an interrupted change is continued with --resume.`,
 		Example: `  # Change both control plane and infra volumes to gp3
   osdctl cluster change-ebs-volume-type -C ${CLUSTER_ID} --type gp3 --reason "${REASON}"
 
@@ -69,7 +73,10 @@ Pre-flight checks are performed automatically before making changes.`,
   osdctl cluster change-ebs-volume-type -C ${CLUSTER_ID} --type gp3 --role control-plane --reason "${REASON}"
 
   # Change only infra volumes to gp3
-  osdctl cluster change-ebs-volume-type -C ${CLUSTER_ID} --type gp3 --role infra --reason "${REASON}"`,
+  osdctl cluster change-ebs-volume-type -C ${CLUSTER_ID} --type gp3 --role infra --reason "${REASON}"
+
+  # Continue an interrupted change, retrying failed steps twice before prompting
+  osdctl cluster change-ebs-volume-type -C ${CLUSTER_ID} --type gp3 --reason "${REASON}" --resume --retries 2`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -81,6 +88,7 @@ Pre-flight checks are performed automatically before making changes.`,
 	cmd.Flags().StringVar(&ops.targetType, "type", "", "Target EBS volume type (gp3)")
 	cmd.Flags().StringVar(&ops.role, "role", "", "Node role to change: control-plane, infra (default: both)")
 	cmd.Flags().StringVar(&ops.reason, "reason", "", "Reason for elevation (OHSS/PD/JIRA ticket)")
+	ops.operation.AddFlags(cmd.Flags())
 
 	_ = cmd.MarkFlagRequired("cluster-id")
 	_ = cmd.MarkFlagRequired("type")
@@ -198,6 +206,9 @@ func (o *changeVolumeTypeOptions) run(ctx context.Context) error {
 	if err := o.validate(); err != nil {
 		return err
 	}
+	if err := o.operation.Validate(); err != nil {
+		return err
+	}
 
 	if err := o.init(); err != nil {
 		return err
@@ -208,30 +219,62 @@ func (o *changeVolumeTypeOptions) run(ctx context.Context) error {
 	fmt.Printf("Role: %s\n", roleDisplay(o.role))
 	fmt.Printf("Reason: %s\n\n", o.reason)
 
-	// Pre-flight checks
-	if err := o.preFlightChecks(ctx); err != nil {
-		return fmt.Errorf("pre-flight checks failed: %v", err)
+	store, err := operation.NewStore()
+	if err != nil {
+		return err
+	}
+	state, err := o.operation.Start(store, "change-ebs-volume-type", o.clusterID, map[string]string{"type": o.targetType, "role": o.role})
+	if err != nil {
+		return err
+	}
+	steps, err := o.steps(ctx, state)
+	if err != nil {
+		return err
 	}
 
-	doControlPlane := o.role == "" || o.role == "control-plane"
-	doInfra := o.role == "" || o.role == "infra"
+	if err := o.operation.Execute(ctx, o.operation.Runner(store), state, steps); err != nil || o.operation.Plan {
+		return err
+	}
 
-	// Control plane
-	if doControlPlane {
-		if err := o.changeControlPlaneVolumeType(ctx); err != nil {
-			return fmt.Errorf("control plane volume type change failed: %v", err)
-		}
+	printer.PrintlnGreen("\nVolume type change completed successfully!")
+	return nil
+}
+
+// steps returns the steps changing the volume type of the nodes of the role. The infra machinepools
+// are recorded in the state, the original one being deleted during the change.
+func (o *changeVolumeTypeOptions) steps(ctx context.Context, state *operation.State) ([]operation.Step, error) {
+	steps := []operation.Step{
+		{
+			Name:        "preflight-checks",
+			Description: "Check the health of the control plane, infra nodes and etcd",
+			Run:         o.preFlightChecks,
+		},
+	}
+
+	if o.role == "" || o.role == "control-plane" {
+		steps = append(steps,
+			operation.Step{
+				Name:        "patch-controlplanemachineset",
+				Description: fmt.Sprintf("Patch the control plane machine set to %s volumes", o.targetType),
+				Run:         o.changeControlPlaneVolumeType,
+			},
+			operation.Step{
+				Name:        "wait-controlplane-rollout",
+				Description: "Wait for the 3 control plane nodes to be replaced one at a time (~35-45 min)",
+				Run:         o.monitorCPMSRollout,
+			},
+		)
 	}
 
-	// Infra
-	if doInfra {
-		if err := o.changeInfraVolumeType(ctx); err != nil {
-			return fmt.Errorf("infra volume type change failed: %v", err)
+	if o.role == "" || o.role == "infra" {
+		infraSteps, err := o.infraSteps(ctx, state)
+		if err != nil {
+			return nil, err
 		}
+		steps = append(steps, infraSteps...)
 	}
 
-	printer.PrintlnGreen("\nVolume type change completed successfully!")
-	return nil
+	return steps, nil
 }
 
 // preFlightChecks verifies cluster health before making changes.
@@ -306,8 +349,6 @@ func (o *changeVolumeTypeOptions) preFlightChecks(ctx context.Context) error {
 
 // changeControlPlaneVolumeType patches the CPMS to trigger a rolling replacement.
 func (o *changeVolumeTypeOptions) changeControlPlaneVolumeType(ctx context.Context) error {
-	printer.PrintlnGreen("=== Changing control plane volume type ===")
-
 	cpms := &machinev1.ControlPlaneMachineSet{}
 	if err := o.client.Get(ctx, client.ObjectKey{Namespace: changeVolumeTypeCPMSNamespace, Name: changeVolumeTypeCPMSName}, cpms); err != nil {
 		return fmt.Errorf("failed to get CPMS: %v", err)
@@ -341,12 +382,6 @@ func (o *changeVolumeTypeOptions) changeControlPlaneVolumeType(ctx context.Conte
 	awsSpec.BlockDevices[0].EBS.VolumeType = &targetType
 	awsSpec.BlockDevices[0].EBS.Iops = nil
 
-	// Confirm
-	fmt.Printf("\nThis will replace all 3 control plane nodes one at a time (~35-45 min).\n")
-	if !utils.ConfirmPrompt() {
-		return errors.New("aborted by user")
-	}
-
 	// Marshal and patch
 	rawBytes, err := json.Marshal(awsSpec)
 	if err != nil {
@@ -361,14 +396,6 @@ func (o *changeVolumeTypeOptions) changeControlPlaneVolumeType(ctx context.Conte
 	}
 
 	printer.PrintlnGreen("CPMS patched successfully. Rolling replacement in progress...")
-	fmt.Println("Monitoring rollout (this will take ~35-45 minutes)...")
-
-	// Monitor the rollout
-	if err := o.monitorCPMSRollout(ctx); err != nil {
-		return err
-	}
-
-	printer.PrintlnGreen("Control plane volume type change complete!")
 	return nil
 }
 
@@ -399,36 +426,41 @@ const (
 	volumeTypeChangedServiceLogTemplate = "https://raw.githubusercontent.com/openshift/managed-notifications/master/osd/infranode_volume_type_changed.json"
 )
 
-// changeInfraVolumeType uses the Hive MachinePool dance from pkg/infra
-// to replace infra nodes with new ones using the target volume type.
-func (o *changeVolumeTypeOptions) changeInfraVolumeType(ctx context.Context) error {
-	printer.PrintlnGreen("\n=== Changing infra node volume type ===")
-
-	targetType := o.targetType
-	previousType := ""
-
-	originalMp, err := infraPkg.GetInfraMachinePool(ctx, o.hiveClient, o.clusterID)
+// infraSteps returns the steps of the Hive MachinePool dance from pkg/infra replacing infra nodes
+// with new ones using the target volume type, none if they already use it.
+func (o *changeVolumeTypeOptions) infraSteps(ctx context.Context, state *operation.State) ([]operation.Step, error) {
+	originalMp, newMp, err := infraPkg.LoadDanceMachinePools(state)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	if originalMp == nil {
+		originalMp, err = infraPkg.GetInfraMachinePool(ctx, o.hiveClient, o.clusterID)
+		if err != nil {
+			return nil, err
+		}
+		if originalMp.Spec.Platform.AWS == nil {
+			return nil, fmt.Errorf("infra MachinePool has no AWS platform configuration")
+		}
+		if originalMp.Spec.Platform.AWS.Type == o.targetType {
+			fmt.Printf("Infra volumes are already %s - skipping\n", o.targetType)
+			return nil, nil
+		}
 
-	newMp, err := infraPkg.CloneMachinePool(originalMp, func(mp *hivev1.MachinePool) error {
-		if mp.Spec.Platform.AWS == nil {
-			return fmt.Errorf("infra MachinePool has no AWS platform configuration")
+		newMp, err = infraPkg.CloneMachinePool(originalMp, func(mp *hivev1.MachinePool) error {
+			mp.Spec.Platform.AWS.Type = o.targetType
+			mp.Spec.Platform.AWS.IOPS = 0
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
-		previousType = mp.Spec.Platform.AWS.Type
-		if previousType == targetType {
-			return fmt.Errorf("infra volumes are already %s", targetType)
+		if err := infraPkg.SaveDanceMachinePools(state, originalMp, newMp); err != nil {
+			return nil, err
 		}
-		fmt.Printf("Current infra volume type: %s\n", previousType)
-		fmt.Printf("Target volume type: %s\n", targetType)
-		mp.Spec.Platform.AWS.Type = targetType
-		mp.Spec.Platform.AWS.IOPS = 0
-		return nil
-	})
-	if err != nil {
-		return err
 	}
+	previousType := originalMp.Spec.Platform.AWS.Type
+	fmt.Printf("Current infra volume type: %s\n", previousType)
+	fmt.Printf("Target volume type: %s\n", o.targetType)
 
 	clients := infraPkg.DanceClients{
 		ClusterClient: o.client,
@@ -436,28 +468,30 @@ func (o *changeVolumeTypeOptions) changeInfraVolumeType(ctx context.Context) err
 		HiveAdmin:     o.hiveAdminClient,
 	}
 
-	if err := infraPkg.RunMachinePoolDance(ctx, clients, originalMp, newMp, nil); err != nil {
-		return err
-	}
-
-	// Post service log
-	postCmd := servicelog.PostCmdOptions{
-		Template:  volumeTypeChangedServiceLogTemplate,
-		ClusterId: o.clusterID,
-		TemplateParams: []string{
-			fmt.Sprintf("PREVIOUS_VOLUME_TYPE=%s", previousType),
-			fmt.Sprintf("NEW_VOLUME_TYPE=%s", targetType),
-			fmt.Sprintf("REASON=%s", o.reason),
+	steps := infraPkg.MachinePoolDanceSteps(clients, originalMp, newMp, nil)
+	return append(steps, operation.Step{
+		Name:        "send-service-log",
+		Description: "Send a service log about the changed infra volume type",
+		Run: func(ctx context.Context) error {
+			postCmd := servicelog.PostCmdOptions{
+				Template:  volumeTypeChangedServiceLogTemplate,
+				ClusterId: o.clusterID,
+				TemplateParams: []string{
+					fmt.Sprintf("PREVIOUS_VOLUME_TYPE=%s", previousType),
+					fmt.Sprintf("NEW_VOLUME_TYPE=%s", o.targetType),
+					fmt.Sprintf("REASON=%s", o.reason),
+				},
+			}
+			if err := postCmd.Run(); err != nil {
+				fmt.Println("Failed to post service log. Please manually send a service log with:")
+				fmt.Printf("osdctl servicelog post %s -t %s -p %s\n",
+					o.clusterID, volumeTypeChangedServiceLogTemplate, strings.Join(postCmd.TemplateParams, " -p "))
+				return err
+			}
+			return nil
 		},
-	}
-	if err := postCmd.Run(); err != nil {
-		fmt.Println("Failed to post service log. Please manually send a service log with:")
-		fmt.Printf("osdctl servicelog post %s -t %s -p %s\n",
-			o.clusterID, volumeTypeChangedServiceLogTemplate, strings.Join(postCmd.TemplateParams, " -p "))
-	}
-
-	printer.PrintlnGreen("Infra volume type change complete!")
-	return nil
+		Skippable: true,
+	}), nil
 }
 
 func countReadyNodes(nodes *corev1.NodeList) int {
@@ -1,10 +1,24 @@
 package cluster
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
 
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	awshivev1 "github.com/openshift/hive/apis/hive/v1/aws"
+	"github.com/openshift/osdctl/pkg/operation"
+	"github.com/openshift/osdctl/pkg/operation/operationtest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func TestChangeVolumeType_ValidateTargetType(t *testing.T) {
@@ -118,3 +132,110 @@ func TestChangeVolumeType_CountReadyNodes(t *testing.T) {
 	nodes := &corev1.NodeList{}
 	assert.Equal(t, 0, countReadyNodes(nodes))
 }
+
+// healthyControlPlane returns the objects of a cluster passing the pre-flight checks of the control
+// plane, its CPMS using volumes of the given type
+func healthyControlPlane(t *testing.T, volumeType string) []client.Object {
+	raw, err := json.Marshal(&machinev1beta1.AWSMachineProviderConfig{
+		BlockDevices: []machinev1beta1.BlockDeviceMappingSpec{{
+			EBS: &machinev1beta1.EBSBlockDeviceSpec{VolumeType: ptr.To(volumeType), VolumeSize: ptr.To[int64](100)},
+		}},
+	})
+	require.NoError(t, err)
+	cpms := &machinev1.ControlPlaneMachineSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: changeVolumeTypeCPMSNamespace, Name: changeVolumeTypeCPMSName},
+		Spec: machinev1.ControlPlaneMachineSetSpec{
+			State: machinev1.ControlPlaneMachineSetStateActive,
+			Template: machinev1.ControlPlaneMachineSetTemplate{
+				OpenShiftMachineV1Beta1Machine: &machinev1.OpenShiftMachineV1Beta1MachineTemplate{},
+			},
+		},
+		Status: machinev1.ControlPlaneMachineSetStatus{ReadyReplicas: 3, UpdatedReplicas: 3},
+	}
+	cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: raw}
+
+	objs := []client.Object{cpms}
+	for i := 0; i < 3; i++ {
+		objs = append(objs,
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("master-%d", i), Labels: map[string]string{"node-role.kubernetes.io/master": ""}},
+				Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-etcd", Name: fmt.Sprintf("etcd-master-%d", i), Labels: map[string]string{"app": "etcd"}},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+			},
+		)
+	}
+	return objs
+}
+
+func cpmsVolumeType(t *testing.T, c client.Client) string {
+	cpms := &machinev1.ControlPlaneMachineSet{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: changeVolumeTypeCPMSNamespace, Name: changeVolumeTypeCPMSName}, cpms))
+	spec := &machinev1beta1.AWSMachineProviderConfig{}
+	require.NoError(t, json.Unmarshal(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value.Raw, spec))
+	return *spec.BlockDevices[0].EBS.VolumeType
+}
+
+func TestChangeVolumeType_ControlPlaneSteps(t *testing.T) {
+	ctx := context.Background()
+	c := operationtest.NewFakeClient(t, healthyControlPlane(t, "gp2")...)
+	o := &changeVolumeTypeOptions{clusterID: "cluster-1", targetType: "gp3", role: "control-plane", client: c, clientAdmin: c}
+	h := operationtest.New(t)
+	state := operation.NewState("change-ebs-volume-type", o.clusterID, nil)
+
+	steps, err := o.steps(ctx, state)
+	require.NoError(t, err)
+	require.NoError(t, h.RunUntil(ctx, state, steps, "patch-controlplanemachineset"))
+	assert.Equal(t, "gp3", cpmsVolumeType(t, c))
+	assert.Equal(t, "wait-controlplane-rollout", h.State(o.clusterID, "change-ebs-volume-type").Current().Name)
+
+	// The resumed run doesn't check the health of the cluster again while its nodes are replaced
+	require.NoError(t, c.Delete(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "master-0"}}))
+	require.NoError(t, h.Run(ctx, state, steps))
+	assert.True(t, h.State(o.clusterID, "change-ebs-volume-type").Finished())
+	assert.Equal(t, operation.StatusDone, h.State(o.clusterID, "change-ebs-volume-type").Step("preflight-checks").Status)
+}
+
+func TestChangeVolumeType_InfraSteps(t *testing.T) {
+	ctx := context.Background()
+	mp := &hivev1.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "uhc-production-cluster-1", Name: "cluster-infra"},
+		Spec: hivev1.MachinePoolSpec{
+			Name:     "infra",
+			Replicas: ptr.To[int64](2),
+			Labels:   map[string]string{"node-role.kubernetes.io/infra": ""},
+			Platform: hivev1.MachinePoolPlatform{AWS: &awshivev1.MachinePoolPlatform{EC2RootVolume: awshivev1.EC2RootVolume{Type: "gp2", IOPS: 3000}}},
+		},
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "uhc-production-cluster-1", Labels: map[string]string{"api.openshift.com/id": "cluster-1"}}}
+	o := &changeVolumeTypeOptions{clusterID: "cluster-1", targetType: "gp3", role: "infra", hiveClient: operationtest.NewFakeClient(t, namespace, mp)}
+	state := operation.NewState("change-ebs-volume-type", o.clusterID, nil)
+
+	steps, err := o.steps(ctx, state)
+	require.NoError(t, err)
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	assert.Equal(t, []string{
+		"preflight-checks",
+		"create-temporary-machinepool",
+		"wait-temporary-nodes",
+		"delete-original-machinepool",
+		"wait-original-nodes-removed",
+		"create-machinepool",
+		"wait-new-nodes",
+		"delete-temporary-machinepool",
+		"wait-temporary-nodes-removed",
+		"send-service-log",
+	}, names)
+
+	// Infra nodes already using the target type aren't replaced
+	mp.Spec.Platform.AWS.Type = "gp3"
+	o.hiveClient = operationtest.NewFakeClient(t, namespace, mp)
+	steps, err = o.steps(ctx, operation.NewState("change-ebs-volume-type", o.clusterID, nil))
+	require.NoError(t, err)
+	assert.Len(t, steps, 1)
+}
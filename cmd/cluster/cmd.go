@@ -40,6 +40,7 @@ func NewCmdCluster(streams genericclioptions.IOStreams, client *k8s.LazyClient,
 	clusterCmd.AddCommand(newCmdEtcdMemberReplacement())
 	clusterCmd.AddCommand(newCmdFromInfraId(globalOpts))
 	clusterCmd.AddCommand(NewCmdHypershiftInfo(streams))
+	clusterCmd.AddCommand(newCmdTopology())
 	clusterCmd.AddCommand(newCmdOrgId())
 	clusterCmd.AddCommand(newCmdDetachStuckVolume())
 	clusterCmd.AddCommand(newCmdChangeVolumeType())
@@ -51,6 +52,7 @@ func NewCmdCluster(streams genericclioptions.IOStreams, client *k8s.LazyClient,
 	clusterCmd.AddCommand(cad.NewCmdCad())
 	clusterCmd.AddCommand(newCmdSnapshot())
 	clusterCmd.AddCommand(newCmdDiff())
+	clusterCmd.AddCommand(newCmdTimeline())
 	clusterCmd.AddCommand(newCmdIMDSv2())
 	return clusterCmd
 }
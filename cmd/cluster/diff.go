@@ -1,81 +1,85 @@
 package cluster
 
 import (
-	"encoding/json"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/osdctl/pkg/output"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
 // DiffResult represents the comparison between two snapshots
 type DiffResult struct {
-	BeforeSnapshot   string                    `yaml:"beforeSnapshot" json:"beforeSnapshot"`
-	AfterSnapshot    string                    `yaml:"afterSnapshot" json:"afterSnapshot"`
-	Summary          DiffSummary               `yaml:"summary" json:"summary"`
-	NodeChanges      []NodeDiff                `yaml:"nodeChanges,omitempty" json:"nodeChanges,omitempty"`
-	OperatorChanges  []OperatorDiff            `yaml:"operatorChanges,omitempty" json:"operatorChanges,omitempty"`
-	NamespaceChanges []NamespaceDiff           `yaml:"namespaceChanges,omitempty" json:"namespaceChanges,omitempty"`
-	ResourceChanges  map[string][]ResourceDiff `yaml:"resourceChanges,omitempty" json:"resourceChanges,omitempty"`
+	BeforeSnapshot   string                  `yaml:"beforeSnapshot" json:"beforeSnapshot"`
+	AfterSnapshot    string                  `yaml:"afterSnapshot" json:"afterSnapshot"`
+	Summary          DiffSummary             `yaml:"summary" json:"summary"`
+	NodeChanges      []ObjectDiff            `yaml:"nodeChanges,omitempty" json:"nodeChanges,omitempty"`
+	OperatorChanges  []ObjectDiff            `yaml:"operatorChanges,omitempty" json:"operatorChanges,omitempty"`
+	NamespaceChanges []ObjectDiff            `yaml:"namespaceChanges,omitempty" json:"namespaceChanges,omitempty"`
+	ResourceChanges  map[string][]ObjectDiff `yaml:"resourceChanges,omitempty" json:"resourceChanges,omitempty"`
 }
 
 // DiffSummary provides high-level change counts
 type DiffSummary struct {
-	TotalChanges      int `yaml:"totalChanges" json:"totalChanges"`
-	NodesChanged      int `yaml:"nodesChanged" json:"nodesChanged"`
-	OperatorsChanged  int `yaml:"operatorsChanged" json:"operatorsChanged"`
-	NamespacesChanged int `yaml:"namespacesChanged" json:"namespacesChanged"`
-	ResourcesChanged  int `yaml:"resourcesChanged" json:"resourcesChanged"`
+	TotalChanges      int      `yaml:"totalChanges" json:"totalChanges"`
+	NodesChanged      int      `yaml:"nodesChanged" json:"nodesChanged"`
+	OperatorsChanged  int      `yaml:"operatorsChanged" json:"operatorsChanged"`
+	NamespacesChanged int      `yaml:"namespacesChanged" json:"namespacesChanged"`
+	ResourcesChanged  int      `yaml:"resourcesChanged" json:"resourcesChanged"`
+	Critical          int      `yaml:"critical" json:"critical"`
+	Warning           int      `yaml:"warning" json:"warning"`
+	Info              int      `yaml:"info" json:"info"`
+	HighestSeverity   Severity `yaml:"highestSeverity,omitempty" json:"highestSeverity,omitempty"`
 }
 
-// NodeDiff represents changes to a node
-type NodeDiff struct {
-	Name       string `yaml:"name" json:"name"`
-	ChangeType string `yaml:"changeType" json:"changeType"` // added, removed, modified
-	Before     string `yaml:"before,omitempty" json:"before,omitempty"`
-	After      string `yaml:"after,omitempty" json:"after,omitempty"`
+// ObjectDiff represents changes to a single node, operator, namespace or resource
+type ObjectDiff struct {
+	Name       string        `yaml:"name" json:"name"`
+	Namespace  string        `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	ChangeType string        `yaml:"changeType" json:"changeType"` // added, removed, modified
+	Severity   Severity      `yaml:"severity" json:"severity"`
+	Before     string        `yaml:"before,omitempty" json:"before,omitempty"`
+	After      string        `yaml:"after,omitempty" json:"after,omitempty"`
+	Fields     []FieldChange `yaml:"fields,omitempty" json:"fields,omitempty"`
 }
 
-// OperatorDiff represents changes to a ClusterOperator
-type OperatorDiff struct {
-	Name       string `yaml:"name" json:"name"`
-	ChangeType string `yaml:"changeType" json:"changeType"`
-	Field      string `yaml:"field,omitempty" json:"field,omitempty"`
-	Before     string `yaml:"before,omitempty" json:"before,omitempty"`
-	After      string `yaml:"after,omitempty" json:"after,omitempty"`
+// FieldChange represents a single field which differs between two snapshots of the same object.
+// Fields are addressed by path, e.g. "status", "version", "labels.<key>" or "conditions.<type>".
+type FieldChange struct {
+	Field    string   `yaml:"field" json:"field"`
+	Before   string   `yaml:"before,omitempty" json:"before,omitempty"`
+	After    string   `yaml:"after,omitempty" json:"after,omitempty"`
+	Severity Severity `yaml:"severity" json:"severity"`
 }
 
-// NamespaceDiff represents changes to a namespace
-type NamespaceDiff struct {
-	Name       string `yaml:"name" json:"name"`
-	ChangeType string `yaml:"changeType" json:"changeType"`
-	Before     string `yaml:"before,omitempty" json:"before,omitempty"`
-	After      string `yaml:"after,omitempty" json:"after,omitempty"`
-}
-
-// ResourceDiff represents changes to a resource
-type ResourceDiff struct {
-	Name       string `yaml:"name" json:"name"`
-	Namespace  string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
-	ChangeType string `yaml:"changeType" json:"changeType"`
-	Before     string `yaml:"before,omitempty" json:"before,omitempty"`
-	After      string `yaml:"after,omitempty" json:"after,omitempty"`
-}
+// snapshotFormats are the output formats of the diff and timeline commands
+var snapshotFormats = []string{output.Table, output.JSON, output.YAML, output.JSONPath, output.GoTemplate}
 
 // diffOptions holds the options for the diff command
 type diffOptions struct {
 	BeforeFile string
 	AfterFile  string
+	Output     output.Options
 	OutputJSON bool
+	RulesFile  string
+	FailOn     string
+	ClusterID  string
 }
 
 func newCmdDiff() *cobra.Command {
 	opts := &diffOptions{}
 
 	diffCmd := &cobra.Command{
-		Use:   "diff <before.yaml> <after.yaml>",
+		Use:   "diff <before> <after>",
 		Short: "Compare two cluster snapshots to identify changes",
 		Long: `Compare two cluster snapshots to identify changes.
 
@@ -86,26 +90,93 @@ in a cluster during feature testing or validation.
 Changes are categorized as:
 - added: Resource exists in after but not in before
 - removed: Resource exists in before but not in after  
-- modified: Resource exists in both but with different values`,
+- modified: Resource exists in both but with different values
+
+Modified resources are compared field by field (status, version, roles,
+labels.<key>, conditions.<type>, ...). Each change is assigned a severity
+(info, warning or critical) by a set of rules; a rules file can be provided
+with --rules to ignore noisy fields or to change severities:
+
+  ignore:
+    - kind: operator
+      field: conditions.Upgradeable
+    - kind: node
+      field: "labels.feature.node.kubernetes.io/*"
+  severity:
+    - kind: operator
+      field: degraded
+      after: "true"
+      severity: critical
+
+The command exits with a non-zero status when a change at or above the
+--fail-on severity is found, so it can be used to gate test pipelines.
+
+With --cluster-id, <before> and <after> reference snapshots in the local
+snapshot store instead of files: a snapshot ID, 'latest' or 'latest~N'.`,
 		Example: `  # Compare two snapshots
   osdctl cluster diff before.yaml after.yaml
 
   # Compare snapshots with JSON output
-  osdctl cluster diff before.yaml after.yaml --json`,
+  osdctl cluster diff before.yaml after.yaml -o json
+
+  # Compare the two most recent snapshots in the local snapshot store
+  osdctl cluster diff -C ${CLUSTER_ID} latest~1 latest
+
+  # Use custom rules and fail on warnings as well as critical changes
+  osdctl cluster diff before.yaml after.yaml --rules diff-rules.yaml --fail-on warning`,
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.BeforeFile = args[0]
 			opts.AfterFile = args[1]
-			return opts.run()
+			err := opts.run()
+			if errors.Is(err, errSeverityThreshold) {
+				cmd.SilenceUsage = true
+			}
+			return err
 		},
 	}
 
+	opts.Output.AddFormatFlag(diffCmd, snapshotFormats...)
 	diffCmd.Flags().BoolVar(&opts.OutputJSON, "json", false, "Output diff in JSON format")
+	_ = diffCmd.Flags().MarkDeprecated("json", "use --output json instead")
+	diffCmd.MarkFlagsMutuallyExclusive("output", "json")
+	diffCmd.Flags().StringVarP(&opts.ClusterID, "cluster-id", "C", "", "Resolve <before> and <after> as references to stored snapshots of this cluster")
+	diffCmd.Flags().StringVar(&opts.RulesFile, "rules", "", "YAML file with ignore and severity rules (default: built-in rules)")
+	diffCmd.Flags().StringVar(&opts.FailOn, "fail-on", string(SeverityCritical), "Exit with a non-zero status if any change has at least this severity (info, warning, critical, none)")
 
 	return diffCmd
 }
 
+// errSeverityThreshold is returned when changes at or above the --fail-on severity are found
+var errSeverityThreshold = errors.New("changes found at or above the failure severity")
+
 func (o *diffOptions) run() error {
+	if o.OutputJSON {
+		o.Output.Format = output.JSON
+	}
+	if err := o.Output.Validate(); err != nil {
+		return err
+	}
+
+	failOn, err := ParseSeverity(o.FailOn)
+	if err != nil {
+		return err
+	}
+
+	rules := &DefaultDiffRules
+	if o.RulesFile != "" {
+		rules, err = LoadDiffRules(o.RulesFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.ClusterID != "" {
+		if err := o.resolveFromStore(); err != nil {
+			return err
+		}
+	}
+
 	// Load before snapshot
 	beforeSnapshot, err := loadSnapshot(o.BeforeFile)
 	if err != nil {
@@ -125,11 +196,43 @@ func (o *diffOptions) run() error {
 			afterSnapshot.Metadata.ClusterName, afterSnapshot.Metadata.ClusterID)
 	}
 
+	// Warn about capture errors that could cause false diffs
+	warnCaptureErrors(beforeSnapshot, "before", o.BeforeFile)
+	warnCaptureErrors(afterSnapshot, "after", o.AfterFile)
+
 	// Compare snapshots
-	result := compareSnapshots(beforeSnapshot, afterSnapshot, o.BeforeFile, o.AfterFile)
+	result := compareSnapshots(beforeSnapshot, afterSnapshot, o.BeforeFile, o.AfterFile, rules)
 
 	// Print results
-	return o.printDiff(result)
+	var text bytes.Buffer
+	printDiff(&text, result)
+	if err := o.Output.Print(os.Stdout, output.NewObject(result, strings.TrimSuffix(text.String(), "\n"))); err != nil {
+		return err
+	}
+
+	if result.Summary.HighestSeverity.AtLeast(failOn) {
+		return fmt.Errorf("%w: highest severity is %s (--fail-on %s)", errSeverityThreshold, result.Summary.HighestSeverity, failOn)
+	}
+	return nil
+}
+
+// resolveFromStore replaces the before and after snapshot references with stored snapshot paths
+func (o *diffOptions) resolveFromStore() error {
+	store, err := NewSnapshotStore()
+	if err != nil {
+		return err
+	}
+	before, err := resolveStoredSnapshot(store, o.ClusterID, o.BeforeFile)
+	if err != nil {
+		return err
+	}
+	after, err := resolveStoredSnapshot(store, o.ClusterID, o.AfterFile)
+	if err != nil {
+		return err
+	}
+	o.BeforeFile = before.Path
+	o.AfterFile = after.Path
+	return nil
 }
 
 func loadSnapshot(filename string) (*ClusterSnapshot, error) {
@@ -146,32 +249,23 @@ func loadSnapshot(filename string) (*ClusterSnapshot, error) {
 	return &snapshot, nil
 }
 
-func compareSnapshots(before, after *ClusterSnapshot, beforeFile, afterFile string) *DiffResult {
+func compareSnapshots(before, after *ClusterSnapshot, beforeFile, afterFile string, rules *DiffRules) *DiffResult {
 	result := &DiffResult{
 		BeforeSnapshot:  beforeFile,
 		AfterSnapshot:   afterFile,
-		ResourceChanges: make(map[string][]ResourceDiff),
+		ResourceChanges: make(map[string][]ObjectDiff),
 	}
 
-	// Warn about capture errors that could cause false diffs
-	warnCaptureErrors(before, "before", beforeFile)
-	warnCaptureErrors(after, "after", afterFile)
-
 	// Compare nodes
-	result.NodeChanges = compareNodes(before.Nodes, after.Nodes)
+	result.NodeChanges = compareObjects("node", nodeObjects(before.Nodes), nodeObjects(after.Nodes), rules)
 	result.Summary.NodesChanged = len(result.NodeChanges)
 
 	// Compare operators
-	result.OperatorChanges = compareOperators(before.Operators, after.Operators)
-	// Count unique operators changed (not individual field changes)
-	changedOperators := map[string]struct{}{}
-	for _, diff := range result.OperatorChanges {
-		changedOperators[diff.Name] = struct{}{}
-	}
-	result.Summary.OperatorsChanged = len(changedOperators)
+	result.OperatorChanges = compareObjects("operator", operatorObjects(before.Operators), operatorObjects(after.Operators), rules)
+	result.Summary.OperatorsChanged = len(result.OperatorChanges)
 
 	// Compare namespaces
-	result.NamespaceChanges = compareNamespaces(before.Namespaces, after.Namespaces)
+	result.NamespaceChanges = compareObjects("namespace", namespaceObjects(before.Namespaces), namespaceObjects(after.Namespaces), rules)
 	result.Summary.NamespacesChanged = len(result.NamespaceChanges)
 
 	// Compare resources
@@ -184,9 +278,7 @@ func compareSnapshots(before, after *ClusterSnapshot, beforeFile, afterFile stri
 	}
 
 	for resourceType := range allResourceTypes {
-		beforeResources := before.Resources[resourceType]
-		afterResources := after.Resources[resourceType]
-		diffs := compareResources(beforeResources, afterResources)
+		diffs := compareObjects(resourceType, resourceObjects(before.Resources[resourceType]), resourceObjects(after.Resources[resourceType]), rules)
 		if len(diffs) > 0 {
 			result.ResourceChanges[resourceType] = diffs
 			result.Summary.ResourcesChanged += len(diffs)
@@ -198,345 +290,258 @@ func compareSnapshots(before, after *ClusterSnapshot, beforeFile, afterFile stri
 		result.Summary.NamespacesChanged +
 		result.Summary.ResourcesChanged
 
+	countSeverity := func(diffs []ObjectDiff) {
+		for _, d := range diffs {
+			switch d.Severity {
+			case SeverityCritical:
+				result.Summary.Critical++
+			case SeverityWarning:
+				result.Summary.Warning++
+			default:
+				result.Summary.Info++
+			}
+			result.Summary.HighestSeverity = maxSeverity(result.Summary.HighestSeverity, d.Severity)
+		}
+	}
+	countSeverity(result.NodeChanges)
+	countSeverity(result.OperatorChanges)
+	countSeverity(result.NamespaceChanges)
+	for _, diffs := range result.ResourceChanges {
+		countSeverity(diffs)
+	}
+
 	return result
 }
 
-func compareNodes(before, after []NodeSnapshot) []NodeDiff {
-	var diffs []NodeDiff
-
-	beforeMap := make(map[string]NodeSnapshot)
-	for _, n := range before {
-		beforeMap[n.Name] = n
-	}
-
-	afterMap := make(map[string]NodeSnapshot)
-	for _, n := range after {
-		afterMap[n.Name] = n
-	}
+// diffObject is the flattened, comparable form of a snapshot entry
+type diffObject struct {
+	name      string
+	namespace string
+	// summary is a short description used when the object is added or removed
+	summary string
+	fields  map[string]string
+}
 
-	// Find added and modified nodes
-	for name, afterNode := range afterMap {
-		if beforeNode, exists := beforeMap[name]; !exists {
-			diffs = append(diffs, NodeDiff{
-				Name:       name,
-				ChangeType: "added",
-				After:      fmt.Sprintf("Status: %s, Roles: %v, Version: %s", afterNode.Status, afterNode.Roles, afterNode.Version),
-			})
-		} else {
-			// Check for any changes
-			var changes []string
-			if beforeNode.Status != afterNode.Status {
-				changes = append(changes, fmt.Sprintf("Status: %s -> %s", beforeNode.Status, afterNode.Status))
-			}
-			if beforeNode.Version != afterNode.Version {
-				changes = append(changes, fmt.Sprintf("Version: %s -> %s", beforeNode.Version, afterNode.Version))
-			}
-			if fmt.Sprintf("%v", beforeNode.Roles) != fmt.Sprintf("%v", afterNode.Roles) {
-				changes = append(changes, fmt.Sprintf("Roles: %v -> %v", beforeNode.Roles, afterNode.Roles))
-			}
-			if len(changes) > 0 {
-				diffs = append(diffs, NodeDiff{
-					Name:       name,
-					ChangeType: "modified",
-					Before:     fmt.Sprintf("Status: %s, Roles: %v, Version: %s", beforeNode.Status, beforeNode.Roles, beforeNode.Version),
-					After:      fmt.Sprintf("Status: %s, Roles: %v, Version: %s", afterNode.Status, afterNode.Roles, afterNode.Version),
-				})
-			}
+func nodeObjects(nodes []NodeSnapshot) map[string]diffObject {
+	objects := make(map[string]diffObject, len(nodes))
+	for _, n := range nodes {
+		fields := map[string]string{
+			"status":  n.Status,
+			"version": n.Version,
+			"roles":   strings.Join(sortedCopy(n.Roles), ","),
 		}
-	}
-
-	// Find removed nodes
-	for name, beforeNode := range beforeMap {
-		if _, exists := afterMap[name]; !exists {
-			diffs = append(diffs, NodeDiff{
-				Name:       name,
-				ChangeType: "removed",
-				Before:     fmt.Sprintf("Status: %s, Roles: %v", beforeNode.Status, beforeNode.Roles),
-			})
+		addPrefixed(fields, "labels.", n.Labels)
+		addConditions(fields, n.Conditions)
+		objects[n.Name] = diffObject{
+			name:    n.Name,
+			summary: fmt.Sprintf("Status: %s, Roles: %v, Version: %s", n.Status, n.Roles, n.Version),
+			fields:  fields,
 		}
 	}
-
-	return diffs
+	return objects
 }
 
-func compareOperators(before, after []OperatorSnapshot) []OperatorDiff {
-	var diffs []OperatorDiff
-
-	beforeMap := make(map[string]OperatorSnapshot)
-	for _, o := range before {
-		beforeMap[o.Name] = o
-	}
-
-	afterMap := make(map[string]OperatorSnapshot)
-	for _, o := range after {
-		afterMap[o.Name] = o
-	}
-
-	// Find added and modified operators
-	for name, afterOp := range afterMap {
-		if beforeOp, exists := beforeMap[name]; !exists {
-			diffs = append(diffs, OperatorDiff{
-				Name:       name,
-				ChangeType: "added",
-				After:      formatOperatorStatus(afterOp),
-			})
-		} else {
-			// Check for status changes
-			if beforeOp.Available != afterOp.Available {
-				diffs = append(diffs, OperatorDiff{
-					Name:       name,
-					ChangeType: "modified",
-					Field:      "Available",
-					Before:     fmt.Sprintf("%v", beforeOp.Available),
-					After:      fmt.Sprintf("%v", afterOp.Available),
-				})
-			}
-			if beforeOp.Degraded != afterOp.Degraded {
-				diffs = append(diffs, OperatorDiff{
-					Name:       name,
-					ChangeType: "modified",
-					Field:      "Degraded",
-					Before:     fmt.Sprintf("%v", beforeOp.Degraded),
-					After:      fmt.Sprintf("%v", afterOp.Degraded),
-				})
-			}
-			if beforeOp.Progressing != afterOp.Progressing {
-				diffs = append(diffs, OperatorDiff{
-					Name:       name,
-					ChangeType: "modified",
-					Field:      "Progressing",
-					Before:     fmt.Sprintf("%v", beforeOp.Progressing),
-					After:      fmt.Sprintf("%v", afterOp.Progressing),
-				})
-			}
-			if beforeOp.Version != afterOp.Version {
-				diffs = append(diffs, OperatorDiff{
-					Name:       name,
-					ChangeType: "modified",
-					Field:      "Version",
-					Before:     beforeOp.Version,
-					After:      afterOp.Version,
-				})
-			}
-			// Check for condition changes
-			if !slicesEqual(beforeOp.Conditions, afterOp.Conditions) {
-				diffs = append(diffs, OperatorDiff{
-					Name:       name,
-					ChangeType: "modified",
-					Field:      "Conditions",
-					Before:     strings.Join(beforeOp.Conditions, ", "),
-					After:      strings.Join(afterOp.Conditions, ", "),
-				})
+func operatorObjects(operators []OperatorSnapshot) map[string]diffObject {
+	objects := make(map[string]diffObject, len(operators))
+	for _, op := range operators {
+		fields := map[string]string{
+			"available":   strconv.FormatBool(op.Available),
+			"progressing": strconv.FormatBool(op.Progressing),
+			"degraded":    strconv.FormatBool(op.Degraded),
+			"version":     op.Version,
+		}
+		// Available, Progressing and Degraded are already compared above, they would be reported
+		// twice as conditions
+		var conditions []string
+		for _, cond := range op.Conditions {
+			condType, _, _ := strings.Cut(cond, "=")
+			if condType != string(configv1.OperatorAvailable) && condType != string(configv1.OperatorProgressing) && condType != string(configv1.OperatorDegraded) {
+				conditions = append(conditions, cond)
 			}
 		}
-	}
-
-	// Find removed operators
-	for name, beforeOp := range beforeMap {
-		if _, exists := afterMap[name]; !exists {
-			diffs = append(diffs, OperatorDiff{
-				Name:       name,
-				ChangeType: "removed",
-				Before:     formatOperatorStatus(beforeOp),
-			})
+		addConditions(fields, conditions)
+		objects[op.Name] = diffObject{
+			name:    op.Name,
+			summary: formatOperatorStatus(op),
+			fields:  fields,
 		}
 	}
-
-	return diffs
+	return objects
 }
 
-func formatOperatorStatus(op OperatorSnapshot) string {
-	return fmt.Sprintf("Available=%v, Degraded=%v, Progressing=%v, Version=%s",
-		op.Available, op.Degraded, op.Progressing, op.Version)
+func namespaceObjects(namespaces []NamespaceSnapshot) map[string]diffObject {
+	objects := make(map[string]diffObject, len(namespaces))
+	for _, ns := range namespaces {
+		fields := map[string]string{"status": ns.Status}
+		addPrefixed(fields, "labels.", ns.Labels)
+		objects[ns.Name] = diffObject{
+			name:    ns.Name,
+			summary: fmt.Sprintf("Status: %s", ns.Status),
+			fields:  fields,
+		}
+	}
+	return objects
 }
 
-func compareNamespaces(before, after []NamespaceSnapshot) []NamespaceDiff {
-	var diffs []NamespaceDiff
-
-	beforeMap := make(map[string]NamespaceSnapshot)
-	for _, n := range before {
-		beforeMap[n.Name] = n
-	}
-
-	afterMap := make(map[string]NamespaceSnapshot)
-	for _, n := range after {
-		afterMap[n.Name] = n
-	}
-
-	// Find added and modified namespaces
-	for name, afterNs := range afterMap {
-		if beforeNs, exists := beforeMap[name]; !exists {
-			diffs = append(diffs, NamespaceDiff{
-				Name:       name,
-				ChangeType: "added",
-				After:      fmt.Sprintf("Status: %s", afterNs.Status),
-			})
-		} else {
-			// Check for status or label changes
-			statusChanged := beforeNs.Status != afterNs.Status
-			labelsChanged := fmt.Sprintf("%v", beforeNs.Labels) != fmt.Sprintf("%v", afterNs.Labels)
-			if statusChanged || labelsChanged {
-				diffs = append(diffs, NamespaceDiff{
-					Name:       name,
-					ChangeType: "modified",
-					Before:     fmt.Sprintf("Status: %s, Labels: %v", beforeNs.Status, beforeNs.Labels),
-					After:      fmt.Sprintf("Status: %s, Labels: %v", afterNs.Status, afterNs.Labels),
-				})
-			}
+func resourceObjects(resources []ResourceInfo) map[string]diffObject {
+	objects := make(map[string]diffObject, len(resources))
+	for _, r := range resources {
+		key := fmt.Sprintf("%s/%s/%s", r.Namespace, r.Kind, r.Name)
+		objects[key] = diffObject{
+			name:      r.Name,
+			namespace: r.Namespace,
+			summary:   fmt.Sprintf("Status: %s", r.Status),
+			fields:    map[string]string{"status": r.Status},
 		}
 	}
+	return objects
+}
 
-	// Find removed namespaces
-	for name, beforeNs := range beforeMap {
-		if _, exists := afterMap[name]; !exists {
-			diffs = append(diffs, NamespaceDiff{
-				Name:       name,
-				ChangeType: "removed",
-				Before:     fmt.Sprintf("Status: %s", beforeNs.Status),
-			})
-		}
+// addConditions adds "Type=Status" condition strings as "conditions.<Type>" fields
+func addConditions(fields map[string]string, conditions []string) {
+	for _, cond := range conditions {
+		condType, status, _ := strings.Cut(cond, "=")
+		fields["conditions."+condType] = status
 	}
+}
 
-	return diffs
+func addPrefixed(fields map[string]string, prefix string, values map[string]string) {
+	for k, v := range values {
+		fields[prefix+k] = v
+	}
 }
 
-func compareResources(before, after []ResourceInfo) []ResourceDiff {
-	var diffs []ResourceDiff
+// compareObjects diffs two sets of objects keyed by identity and applies the rules to every change.
+// Results are sorted by key so that output is stable between runs.
+func compareObjects(kind string, before, after map[string]diffObject, rules *DiffRules) []ObjectDiff {
+	var diffs []ObjectDiff
 
-	beforeMap := make(map[string]ResourceInfo)
-	for _, r := range before {
-		key := fmt.Sprintf("%s/%s/%s", r.Namespace, r.Kind, r.Name)
-		beforeMap[key] = r
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
 	}
-
-	afterMap := make(map[string]ResourceInfo)
-	for _, r := range after {
-		key := fmt.Sprintf("%s/%s/%s", r.Namespace, r.Kind, r.Name)
-		afterMap[key] = r
-	}
-
-	// Find added and modified resources
-	for key, afterRes := range afterMap {
-		if beforeRes, exists := beforeMap[key]; !exists {
-			diffs = append(diffs, ResourceDiff{
-				Name:       afterRes.Name,
-				Namespace:  afterRes.Namespace,
-				ChangeType: "added",
-				After:      fmt.Sprintf("Status: %s", afterRes.Status),
-			})
-		} else if beforeRes.Status != afterRes.Status {
-			diffs = append(diffs, ResourceDiff{
-				Name:       afterRes.Name,
-				Namespace:  afterRes.Namespace,
-				ChangeType: "modified",
-				Before:     fmt.Sprintf("Status: %s", beforeRes.Status),
-				After:      fmt.Sprintf("Status: %s", afterRes.Status),
-			})
+	for key := range after {
+		if _, exists := before[key]; !exists {
+			keys = append(keys, key)
 		}
 	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		beforeObj, inBefore := before[key]
+		afterObj, inAfter := after[key]
 
-	// Find removed resources
-	for key, beforeRes := range beforeMap {
-		if _, exists := afterMap[key]; !exists {
-			diffs = append(diffs, ResourceDiff{
-				Name:       beforeRes.Name,
-				Namespace:  beforeRes.Namespace,
-				ChangeType: "removed",
-				Before:     fmt.Sprintf("Status: %s", beforeRes.Status),
-			})
+		switch {
+		case !inBefore:
+			diff := ObjectDiff{Name: afterObj.name, Namespace: afterObj.namespace, ChangeType: "added", After: afterObj.summary}
+			if rules.ignored(kind, diff.Name, diff.ChangeType, FieldChange{}) {
+				continue
+			}
+			diff.Severity = rules.severityFor(kind, diff.Name, diff.ChangeType, FieldChange{})
+			diffs = append(diffs, diff)
+		case !inAfter:
+			diff := ObjectDiff{Name: beforeObj.name, Namespace: beforeObj.namespace, ChangeType: "removed", Before: beforeObj.summary}
+			if rules.ignored(kind, diff.Name, diff.ChangeType, FieldChange{}) {
+				continue
+			}
+			diff.Severity = rules.severityFor(kind, diff.Name, diff.ChangeType, FieldChange{})
+			diffs = append(diffs, diff)
+		default:
+			diff := ObjectDiff{Name: afterObj.name, Namespace: afterObj.namespace, ChangeType: "modified"}
+			for _, change := range diffFields(beforeObj.fields, afterObj.fields) {
+				if rules.ignored(kind, diff.Name, diff.ChangeType, change) {
+					continue
+				}
+				change.Severity = rules.severityFor(kind, diff.Name, diff.ChangeType, change)
+				diff.Severity = maxSeverity(diff.Severity, change.Severity)
+				diff.Fields = append(diff.Fields, change)
+			}
+			if len(diff.Fields) > 0 {
+				diff.Before = beforeObj.summary
+				diff.After = afterObj.summary
+				diffs = append(diffs, diff)
+			}
 		}
 	}
 
 	return diffs
 }
 
-func (o *diffOptions) printDiff(result *DiffResult) error {
-	if o.OutputJSON {
-		output, err := json.MarshalIndent(result, "", "  ")
-		if err != nil {
-			return err
+// diffFields returns the fields whose values differ, sorted by field path
+func diffFields(before, after map[string]string) []FieldChange {
+	var changes []FieldChange
+	for field, beforeValue := range before {
+		afterValue, exists := after[field]
+		if !exists || beforeValue != afterValue {
+			changes = append(changes, FieldChange{Field: field, Before: beforeValue, After: afterValue})
+		}
+	}
+	for field, afterValue := range after {
+		if _, exists := before[field]; !exists {
+			changes = append(changes, FieldChange{Field: field, After: afterValue})
 		}
-		fmt.Println(string(output))
-		return nil
 	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
 
+func formatOperatorStatus(op OperatorSnapshot) string {
+	return fmt.Sprintf("Available=%v, Degraded=%v, Progressing=%v, Version=%s",
+		op.Available, op.Degraded, op.Progressing, op.Version)
+}
+
+func printDiff(w io.Writer, result *DiffResult) {
 	// Print human-readable diff
-	fmt.Printf("\n╔══════════════════════════════════════════════════════════════╗\n")
-	fmt.Printf("║                    CLUSTER SNAPSHOT DIFF                      ║\n")
-	fmt.Printf("╠══════════════════════════════════════════════════════════════╣\n")
-	fmt.Printf("║ Before: %-54s ║\n", result.BeforeSnapshot)
-	fmt.Printf("║ After:  %-54s ║\n", result.AfterSnapshot)
-	fmt.Printf("╚══════════════════════════════════════════════════════════════╝\n\n")
-
-	fmt.Printf("SUMMARY\n")
-	fmt.Printf("───────\n")
-	fmt.Printf("Total Changes:     %d\n", result.Summary.TotalChanges)
-	fmt.Printf("Nodes Changed:     %d\n", result.Summary.NodesChanged)
-	fmt.Printf("Operators Changed: %d\n", result.Summary.OperatorsChanged)
-	fmt.Printf("Namespaces Changed: %d\n", result.Summary.NamespacesChanged)
-	fmt.Printf("Resources Changed: %d\n\n", result.Summary.ResourcesChanged)
+	fmt.Fprintf(w, "\n╔══════════════════════════════════════════════════════════════╗\n")
+	fmt.Fprintf(w, "║                    CLUSTER SNAPSHOT DIFF                      ║\n")
+	fmt.Fprintf(w, "╠══════════════════════════════════════════════════════════════╣\n")
+	fmt.Fprintf(w, "║ Before: %-54s ║\n", result.BeforeSnapshot)
+	fmt.Fprintf(w, "║ After:  %-54s ║\n", result.AfterSnapshot)
+	fmt.Fprintf(w, "╚══════════════════════════════════════════════════════════════╝\n\n")
+
+	fmt.Fprintf(w, "SUMMARY\n")
+	fmt.Fprintf(w, "───────\n")
+	fmt.Fprintf(w, "Total Changes:     %d\n", result.Summary.TotalChanges)
+	fmt.Fprintf(w, "Nodes Changed:     %d\n", result.Summary.NodesChanged)
+	fmt.Fprintf(w, "Operators Changed: %d\n", result.Summary.OperatorsChanged)
+	fmt.Fprintf(w, "Namespaces Changed: %d\n", result.Summary.NamespacesChanged)
+	fmt.Fprintf(w, "Resources Changed: %d\n", result.Summary.ResourcesChanged)
+	fmt.Fprintf(w, "Severity:          %d critical, %d warning, %d info\n\n", result.Summary.Critical, result.Summary.Warning, result.Summary.Info)
 
 	if result.Summary.TotalChanges == 0 {
-		fmt.Println("✓ No changes detected between snapshots.")
-		return nil
+		fmt.Fprintln(w, "✓ No changes detected between snapshots.")
+		return
 	}
 
-	// Print node changes
-	if len(result.NodeChanges) > 0 {
-		fmt.Println("NODE CHANGES")
-		fmt.Println("────────────")
-		for _, d := range result.NodeChanges {
-			printChange(d.Name, d.ChangeType, d.Before, d.After)
+	printSection := func(title string, diffs []ObjectDiff) {
+		if len(diffs) == 0 {
+			return
 		}
-		fmt.Println()
-	}
-
-	// Print operator changes
-	if len(result.OperatorChanges) > 0 {
-		fmt.Println("OPERATOR CHANGES")
-		fmt.Println("────────────────")
-		for _, d := range result.OperatorChanges {
-			name := d.Name
-			if d.Field != "" {
-				name = fmt.Sprintf("%s [%s]", d.Name, d.Field)
-			}
-			printChange(name, d.ChangeType, d.Before, d.After)
+		fmt.Fprintln(w, title)
+		fmt.Fprintln(w, strings.Repeat("─", len([]rune(title))))
+		for _, d := range diffs {
+			printChange(w, d)
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
 
-	// Print namespace changes
-	if len(result.NamespaceChanges) > 0 {
-		fmt.Println("NAMESPACE CHANGES")
-		fmt.Println("─────────────────")
-		for _, d := range result.NamespaceChanges {
-			printChange(d.Name, d.ChangeType, d.Before, d.After)
-		}
-		fmt.Println()
-	}
-
-	// Print resource changes
-	for resourceType, changes := range result.ResourceChanges {
-		if len(changes) > 0 {
-			fmt.Printf("%s CHANGES\n", strings.ToUpper(resourceType))
-			fmt.Println(strings.Repeat("─", len(resourceType)+8))
-			for _, d := range changes {
-				name := d.Name
-				if d.Namespace != "" {
-					name = fmt.Sprintf("%s/%s", d.Namespace, d.Name)
-				}
-				printChange(name, d.ChangeType, d.Before, d.After)
-			}
-			fmt.Println()
-		}
-	}
+	printSection("NODE CHANGES", result.NodeChanges)
+	printSection("OPERATOR CHANGES", result.OperatorChanges)
+	printSection("NAMESPACE CHANGES", result.NamespaceChanges)
 
-	return nil
+	resourceTypes := make([]string, 0, len(result.ResourceChanges))
+	for resourceType := range result.ResourceChanges {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	sort.Strings(resourceTypes)
+	for _, resourceType := range resourceTypes {
+		printSection(fmt.Sprintf("%s CHANGES", strings.ToUpper(resourceType)), result.ResourceChanges[resourceType])
+	}
 }
 
-func printChange(name, changeType, before, after string) {
+func printChange(w io.Writer, d ObjectDiff) {
 	var symbol string
-	switch changeType {
+	switch d.ChangeType {
 	case "added":
 		symbol = "+"
 	case "removed":
@@ -545,26 +550,43 @@ func printChange(name, changeType, before, after string) {
 		symbol = "~"
 	}
 
-	fmt.Printf("  %s %s\n", symbol, name)
-	if before != "" {
-		fmt.Printf("      Before: %s\n", before)
-	}
-	if after != "" {
-		fmt.Printf("      After:  %s\n", after)
+	name := d.Name
+	if d.Namespace != "" {
+		name = fmt.Sprintf("%s/%s", d.Namespace, d.Name)
 	}
-}
 
-// slicesEqual compares two string slices for equality
-func slicesEqual(a, b []string) bool {
-	if len(a) != len(b) {
-		return false
+	fmt.Fprintf(w, "  %s %s [%s]\n", symbol, name, d.Severity)
+	if d.ChangeType != "modified" {
+		if d.Before != "" {
+			fmt.Fprintf(w, "      Before: %s\n", d.Before)
+		}
+		if d.After != "" {
+			fmt.Fprintf(w, "      After:  %s\n", d.After)
+		}
+		return
 	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
+
+	for _, f := range d.Fields {
+		fmt.Fprintf(w, "      %s: %s -> %s", f.Field, displayValue(f.Before), displayValue(f.After))
+		if f.Severity != SeverityInfo {
+			fmt.Fprintf(w, " [%s]", f.Severity)
 		}
+		fmt.Fprintln(w)
 	}
-	return true
+}
+
+func displayValue(v string) string {
+	if v == "" {
+		return "<none>"
+	}
+	return v
+}
+
+// sortedCopy returns a sorted copy of a string slice
+func sortedCopy(s []string) []string {
+	c := slices.Clone(s)
+	sort.Strings(c)
+	return c
 }
 
 // warnCaptureErrors prints warnings about capture errors that could cause false diffs
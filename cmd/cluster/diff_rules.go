@@ -0,0 +1,198 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity classifies how significant a change between two snapshots is
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// severityRank orders severities so the most significant one can be picked
+var severityRank = map[Severity]int{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityCritical: 3,
+}
+
+// ParseSeverity validates a severity string, accepting "none" as the lowest possible threshold
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(strings.ToLower(s))
+	if sev == "none" || sev == "" {
+		return "", nil
+	}
+	if _, ok := severityRank[sev]; !ok {
+		return "", fmt.Errorf("invalid severity %q, must be one of: info, warning, critical, none", s)
+	}
+	return sev, nil
+}
+
+// AtLeast reports whether s is as significant as threshold. An empty threshold never matches.
+func (s Severity) AtLeast(threshold Severity) bool {
+	if threshold == "" {
+		return false
+	}
+	return severityRank[s] >= severityRank[threshold]
+}
+
+func maxSeverity(a, b Severity) Severity {
+	if severityRank[b] > severityRank[a] {
+		return b
+	}
+	return a
+}
+
+// DiffRules controls which changes are reported by 'osdctl cluster diff' and how severe they are.
+//
+// Example rules file:
+//
+//	ignore:
+//	  - kind: operator
+//	    field: conditions.Upgradeable
+//	  - kind: node
+//	    field: "labels.feature.node.kubernetes.io/*"
+//	severity:
+//	  - kind: operator
+//	    field: degraded
+//	    after: "true"
+//	    severity: critical
+//	  - kind: node
+//	    changeType: removed
+//	    severity: warning
+type DiffRules struct {
+	Ignore   []DiffRule `yaml:"ignore,omitempty"`
+	Severity []DiffRule `yaml:"severity,omitempty"`
+	// Default is the severity assigned to changes which don't match any severity rule
+	Default Severity `yaml:"default,omitempty"`
+}
+
+// DiffRule matches changes between snapshots. Every field is optional; empty fields match
+// anything. Name, Field, Before and After accept '*' wildcards.
+type DiffRule struct {
+	// Kind is one of node, operator, namespace or a resource type passed to --resources
+	Kind       string   `yaml:"kind,omitempty"`
+	Name       string   `yaml:"name,omitempty"`
+	Field      string   `yaml:"field,omitempty"`
+	ChangeType string   `yaml:"changeType,omitempty"`
+	Before     string   `yaml:"before,omitempty"`
+	After      string   `yaml:"after,omitempty"`
+	Severity   Severity `yaml:"severity,omitempty"`
+}
+
+// DefaultDiffRules are applied when no rules file is provided
+var DefaultDiffRules = DiffRules{
+	Default: SeverityInfo,
+	Severity: []DiffRule{
+		{Kind: "node", Field: "status", After: "NotReady", Severity: SeverityCritical},
+		{Kind: "node", ChangeType: "removed", Severity: SeverityWarning},
+		{Kind: "node", Field: "conditions.*Pressure", After: "True", Severity: SeverityWarning},
+		{Kind: "operator", Field: "degraded", After: "true", Severity: SeverityCritical},
+		{Kind: "operator", Field: "available", After: "false", Severity: SeverityCritical},
+		{Kind: "operator", ChangeType: "removed", Severity: SeverityCritical},
+		{Kind: "operator", Field: "version", Severity: SeverityWarning},
+		{Kind: "namespace", ChangeType: "removed", Severity: SeverityWarning},
+		{Kind: "namespace", Field: "status", After: "Terminating", Severity: SeverityWarning},
+	},
+}
+
+// LoadDiffRules reads a rules file. Rules without a severity default to warning.
+func LoadDiffRules(filename string) (*DiffRules, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &DiffRules{}
+	if err := yaml.Unmarshal(data, rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file %s: %w", filename, err)
+	}
+
+	if rules.Default == "" {
+		rules.Default = SeverityInfo
+	}
+	if _, ok := severityRank[rules.Default]; !ok {
+		return nil, fmt.Errorf("invalid default severity %q in %s", rules.Default, filename)
+	}
+	for i, rule := range rules.Severity {
+		if rule.Severity == "" {
+			rules.Severity[i].Severity = SeverityWarning
+			continue
+		}
+		if _, ok := severityRank[rule.Severity]; !ok {
+			return nil, fmt.Errorf("invalid severity %q for rule %d in %s", rule.Severity, i, filename)
+		}
+	}
+
+	return rules, nil
+}
+
+// ignored reports whether a change matches any of the ignore rules
+func (r *DiffRules) ignored(kind, name, changeType string, change FieldChange) bool {
+	for _, rule := range r.Ignore {
+		if rule.matches(kind, name, changeType, change) {
+			return true
+		}
+	}
+	return false
+}
+
+// severityFor returns the highest severity of all matching rules, or the default severity
+func (r *DiffRules) severityFor(kind, name, changeType string, change FieldChange) Severity {
+	var sev Severity
+	for _, rule := range r.Severity {
+		if rule.matches(kind, name, changeType, change) {
+			sev = maxSeverity(sev, rule.Severity)
+		}
+	}
+	if sev == "" {
+		sev = r.Default
+	}
+	if sev == "" {
+		sev = SeverityInfo
+	}
+	return sev
+}
+
+func (rule DiffRule) matches(kind, name, changeType string, change FieldChange) bool {
+	if rule.Kind != "" && !strings.EqualFold(rule.Kind, kind) {
+		return false
+	}
+	if rule.ChangeType != "" && !strings.EqualFold(rule.ChangeType, changeType) {
+		return false
+	}
+	if rule.Name != "" && !globMatch(rule.Name, name) {
+		return false
+	}
+	// Field-less changes (objects being added or removed) only match rules without a field
+	if rule.Field != "" && (change.Field == "" || !globMatch(rule.Field, change.Field)) {
+		return false
+	}
+	if rule.Before != "" && !globMatch(rule.Before, change.Before) {
+		return false
+	}
+	if rule.After != "" && !globMatch(rule.After, change.After) {
+		return false
+	}
+	return true
+}
+
+// globMatch matches s against a pattern where '*' matches any sequence of characters, including '/'
+// and '.', which are common in label keys and field paths
+func globMatch(pattern, s string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == s
+	}
+	re := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	matched, err := regexp.MatchString(re, s)
+	return err == nil && matched
+}
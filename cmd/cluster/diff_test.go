@@ -0,0 +1,188 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/osdctl/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDiffTestSnapshots() (*ClusterSnapshot, *ClusterSnapshot) {
+	before := &ClusterSnapshot{
+		Metadata: SnapshotMetadata{ClusterID: "abc123"},
+		Nodes: []NodeSnapshot{
+			{Name: "worker-0", Status: "Ready", Version: "v1.31.0", Roles: []string{"worker"}, Labels: map[string]string{"zone": "a"}, Conditions: []string{"Ready=True"}},
+			{Name: "worker-1", Status: "Ready", Version: "v1.31.0", Roles: []string{"worker"}, Conditions: []string{"Ready=True"}},
+			{Name: "worker-2", Status: "Ready", Version: "v1.31.0", Roles: []string{"worker"}},
+		},
+		Operators: []OperatorSnapshot{
+			{Name: "monitoring", Available: true, Version: "4.18.1", Conditions: []string{"Available=True", "Degraded=False"}},
+			{Name: "dns", Available: true, Version: "4.18.1"},
+		},
+		Namespaces: []NamespaceSnapshot{
+			{Name: "openshift-monitoring", Status: "Active"},
+		},
+	}
+	after := &ClusterSnapshot{
+		Metadata: SnapshotMetadata{ClusterID: "abc123"},
+		Nodes: []NodeSnapshot{
+			// Label change only
+			{Name: "worker-0", Status: "Ready", Version: "v1.31.0", Roles: []string{"worker"}, Labels: map[string]string{"zone": "b"}, Conditions: []string{"Ready=True"}},
+			// NotReady transition
+			{Name: "worker-1", Status: "NotReady", Version: "v1.31.0", Roles: []string{"worker"}, Conditions: []string{"Ready=False"}},
+			{Name: "worker-3", Status: "Ready", Version: "v1.31.0", Roles: []string{"worker"}},
+		},
+		Operators: []OperatorSnapshot{
+			{Name: "monitoring", Available: true, Degraded: true, Version: "4.18.1", Conditions: []string{"Available=True", "Degraded=True"}},
+			{Name: "dns", Available: true, Version: "4.18.1"},
+		},
+		Namespaces: []NamespaceSnapshot{
+			{Name: "openshift-monitoring", Status: "Active"},
+		},
+	}
+	return before, after
+}
+
+func findDiff(diffs []ObjectDiff, name string) *ObjectDiff {
+	for i := range diffs {
+		if diffs[i].Name == name {
+			return &diffs[i]
+		}
+	}
+	return nil
+}
+
+func TestCompareSnapshotsDefaultRules(t *testing.T) {
+	before, after := newDiffTestSnapshots()
+	result := compareSnapshots(before, after, "before.yaml", "after.yaml", &DefaultDiffRules)
+
+	require.Len(t, result.NodeChanges, 4)
+	// Results are sorted by name
+	assert.Equal(t, []string{"worker-0", "worker-1", "worker-2", "worker-3"}, []string{
+		result.NodeChanges[0].Name, result.NodeChanges[1].Name, result.NodeChanges[2].Name, result.NodeChanges[3].Name,
+	})
+
+	labelChange := findDiff(result.NodeChanges, "worker-0")
+	assert.Equal(t, "modified", labelChange.ChangeType)
+	assert.Equal(t, SeverityInfo, labelChange.Severity)
+	assert.Equal(t, []FieldChange{{Field: "labels.zone", Before: "a", After: "b", Severity: SeverityInfo}}, labelChange.Fields)
+
+	notReady := findDiff(result.NodeChanges, "worker-1")
+	assert.Equal(t, SeverityCritical, notReady.Severity)
+	assert.Equal(t, []FieldChange{
+		{Field: "conditions.Ready", Before: "True", After: "False", Severity: SeverityInfo},
+		{Field: "status", Before: "Ready", After: "NotReady", Severity: SeverityCritical},
+	}, notReady.Fields)
+
+	assert.Equal(t, SeverityWarning, findDiff(result.NodeChanges, "worker-2").Severity)
+	assert.Equal(t, "removed", findDiff(result.NodeChanges, "worker-2").ChangeType)
+	assert.Equal(t, SeverityInfo, findDiff(result.NodeChanges, "worker-3").Severity)
+	assert.Equal(t, "added", findDiff(result.NodeChanges, "worker-3").ChangeType)
+
+	require.Len(t, result.OperatorChanges, 1)
+	assert.Equal(t, SeverityCritical, result.OperatorChanges[0].Severity)
+	assert.Equal(t, []FieldChange{
+		{Field: "degraded", Before: "false", After: "true", Severity: SeverityCritical},
+	}, result.OperatorChanges[0].Fields, "the Degraded condition is reported once")
+	assert.Empty(t, result.NamespaceChanges)
+
+	assert.Equal(t, 5, result.Summary.TotalChanges)
+	assert.Equal(t, 2, result.Summary.Critical)
+	assert.Equal(t, 1, result.Summary.Warning)
+	assert.Equal(t, 2, result.Summary.Info)
+	assert.Equal(t, SeverityCritical, result.Summary.HighestSeverity)
+}
+
+func TestCompareSnapshotsCustomRules(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`
+ignore:
+  - kind: node
+    field: "labels.*"
+  - kind: node
+    changeType: added
+severity:
+  - kind: operator
+    field: degraded
+    after: "true"
+  - kind: node
+    name: "worker-*"
+    field: status
+    severity: info
+`), 0600))
+
+	rules, err := LoadDiffRules(rulesFile)
+	require.NoError(t, err)
+	assert.Equal(t, SeverityWarning, rules.Severity[0].Severity, "rules default to warning")
+
+	before, after := newDiffTestSnapshots()
+	result := compareSnapshots(before, after, "before.yaml", "after.yaml", rules)
+
+	assert.Nil(t, findDiff(result.NodeChanges, "worker-0"), "label changes are ignored")
+	assert.Nil(t, findDiff(result.NodeChanges, "worker-3"), "added nodes are ignored")
+	assert.Equal(t, SeverityInfo, findDiff(result.NodeChanges, "worker-1").Severity)
+	assert.Equal(t, SeverityWarning, findDiff(result.OperatorChanges, "monitoring").Severity)
+	assert.Equal(t, SeverityWarning, result.Summary.HighestSeverity)
+}
+
+func TestLoadDiffRulesInvalidSeverity(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(rulesFile, []byte("severity:\n  - kind: node\n    severity: urgent\n"), 0600))
+
+	_, err := LoadDiffRules(rulesFile)
+	assert.Error(t, err)
+}
+
+func TestSeverityThreshold(t *testing.T) {
+	tests := []struct {
+		failOn   string
+		severity Severity
+		want     bool
+	}{
+		{failOn: "critical", severity: SeverityCritical, want: true},
+		{failOn: "critical", severity: SeverityWarning, want: false},
+		{failOn: "warning", severity: SeverityCritical, want: true},
+		{failOn: "info", severity: SeverityInfo, want: true},
+		{failOn: "none", severity: SeverityCritical, want: false},
+		{failOn: "critical", severity: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.failOn+"/"+string(tt.severity), func(t *testing.T) {
+			threshold, err := ParseSeverity(tt.failOn)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tt.severity.AtLeast(threshold))
+		})
+	}
+
+	_, err := ParseSeverity("urgent")
+	assert.Error(t, err)
+}
+
+func TestGlobMatch(t *testing.T) {
+	assert.True(t, globMatch("labels.*", "labels.node-role.kubernetes.io/worker"))
+	assert.True(t, globMatch("labels.feature.node.kubernetes.io/*", "labels.feature.node.kubernetes.io/cpu-cpuid.AVX"))
+	assert.True(t, globMatch("conditions.*Pressure", "conditions.MemoryPressure"))
+	assert.False(t, globMatch("conditions.*Pressure", "conditions.Ready"))
+	assert.True(t, globMatch("status", "status"))
+	assert.False(t, globMatch("status", "statuses"))
+}
+
+func TestDiffRunFailOn(t *testing.T) {
+	dir := t.TempDir()
+	before, after := newDiffTestSnapshots()
+
+	beforeFile := filepath.Join(dir, "before.yaml")
+	afterFile := filepath.Join(dir, "after.yaml")
+	require.NoError(t, (&snapshotOptions{OutputFile: beforeFile}).writeSnapshot(before))
+	require.NoError(t, (&snapshotOptions{OutputFile: afterFile}).writeSnapshot(after))
+
+	err := (&diffOptions{BeforeFile: beforeFile, AfterFile: afterFile, Output: output.Options{Format: output.JSON}, FailOn: "critical"}).run()
+	assert.ErrorIs(t, err, errSeverityThreshold)
+
+	err = (&diffOptions{BeforeFile: beforeFile, AfterFile: afterFile, Output: output.Options{Format: output.JSON}, FailOn: "none"}).run()
+	assert.NoError(t, err)
+}
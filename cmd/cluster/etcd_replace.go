@@ -8,8 +8,10 @@ import (
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/operation"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
@@ -22,6 +24,8 @@ type etcdOptions struct {
 	nodeId    string
 	reason    string
 	clusterID string
+
+	operation operation.Options
 }
 
 // Secrets List
@@ -43,7 +47,9 @@ func newCmdEtcdMemberReplacement() *cobra.Command {
 	replaceCmd := &cobra.Command{
 		Use:   "etcd-member-replace --cluster-id <cluster-identifier>",
 		Short: "Replaces an unhealthy etcd node",
-		Long:  `Replaces an unhealthy etcd node using the member id provided`,
+		Long: `Replaces an unhealthy etcd node using the member id provided
+
+The progress is saved after every step, an interrupted replacement is continued with --resume.`,
 		Example: `  # Replace an unhealthy etcd member
   osdctl cluster etcd-member-replace --cluster-id ${CLUSTER_ID} --node ${NODE_NAME} --reason "${REASON}"`,
 		Args:              cobra.NoArgs,
@@ -55,6 +61,7 @@ func newCmdEtcdMemberReplacement() *cobra.Command {
 	replaceCmd.Flags().StringVarP(&opts.clusterID, "cluster-id", "C", "", "Provide internal Cluster ID")
 	replaceCmd.Flags().StringVar(&opts.nodeId, "node", "", "Node ID (required)")
 	replaceCmd.Flags().StringVar(&opts.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usually an OHSS or PD ticket)")
+	opts.operation.AddFlags(replaceCmd.Flags())
 	_ = replaceCmd.MarkFlagRequired("cluster-id")
 	_ = replaceCmd.MarkFlagRequired("node")
 	_ = replaceCmd.MarkFlagRequired("reason")
@@ -62,81 +69,51 @@ func newCmdEtcdMemberReplacement() *cobra.Command {
 }
 
 func (opts *etcdOptions) EtcdReplaceMember() error {
-	kubeCli, kconfig, clientset, err := common.GetKubeConfigAndClient(opts.clusterID, opts.reason, fmt.Sprintf("Replacing unhealthy etcd node %s using osdctl", opts.nodeId))
-	if err != nil {
+	if err := opts.operation.Validate(); err != nil {
 		return err
 	}
 
-	listOptions := metav1.ListOptions{
-		LabelSelector: EtcdLabelSelector,
-	}
-
-	if opts.nodeId == "" {
-		return fmt.Errorf("node name cannot be blank. Please provide node using --node flag")
-	}
-
-	// Get the etcd pods
-	pods, err := clientset.CoreV1().Pods(EtcdNamespaceName).List(context.TODO(), listOptions)
+	connection, err := utils.CreateConnection()
 	if err != nil {
 		return err
 	}
-	for _, pod := range pods.Items {
-		for _, c := range pod.Status.ContainerStatuses {
-			if c.State.Waiting != nil && (c.State.Waiting.Reason == "CrashLoopBackOff" || c.State.Waiting.Reason == "Error") {
-				podName := "etcd-" + opts.nodeId
-				if podName != pod.ObjectMeta.Name {
-					return fmt.Errorf("the etcd member seems to be healthy or is not present. Please run health-check again")
-				}
-				err := opts.ReplaceEtcdMember(kubeCli, kconfig, clientset, pod.ObjectMeta.Name)
-				if err != nil {
-					return err
-				}
-				return nil
-			}
-		}
-	}
-	return fmt.Errorf("none of the etcd members seems to be unhealthy. Please verify once again")
-}
+	defer connection.Close()
 
-func (opts *etcdOptions) ReplaceEtcdMember(kubeCli client.Client, kconfig *rest.Config, clientset *kubernetes.Clientset, pod string) error {
-	// Remove the etcd member
-	fmt.Printf("[INFO] Starting etcd member replacement for pod %s", pod)
-	err := opts.removeEtcdMember(kconfig, clientset, pod)
+	// The progress is saved under the internal ID, whichever cluster key was given
+	cluster, err := utils.GetCluster(connection, opts.clusterID)
 	if err != nil {
 		return err
 	}
+	opts.clusterID = cluster.ID()
 
-	// Turn off the quorum guard
-	fmt.Println("[INFO] Turning the quorum guard off")
-	err = patchEtcd(kubeCli, EtcdQuorumTurnOffPatch)
+	kubeCli, kconfig, clientset, err := common.GetKubeConfigAndClientWithConn(opts.clusterID, connection, opts.reason, fmt.Sprintf("Replacing unhealthy etcd node %s using osdctl", opts.nodeId))
 	if err != nil {
-		fmt.Println("[ERROR] Could not Turn the quorum guard off. Refer error below")
 		return err
 	}
 
-	// Delete the secrets for the unhealthy etcd member
-	fmt.Println("[INFO] Deleting the secrets for the unhealthy etcd member")
-	err = opts.removeEtcdSecrets(clientset)
+	if opts.nodeId == "" {
+		return fmt.Errorf("node name cannot be blank. Please provide node using --node flag")
+	}
+
+	store, err := operation.NewStore()
 	if err != nil {
-		fmt.Println("[ERROR] Could not delete the secrets for the unhealthy etcd member. Refer error below")
 		return err
 	}
-
-	// Force etcd redeployment.
-	fmt.Println("[INFO] Forcing etcd redeployment")
-	timeStamp := time.Now().Format(time.RFC3339Nano)
-	patch := fmt.Sprintf(EtcdForceRedeployPatch, timeStamp)
-	err = patchEtcd(kubeCli, patch)
+	state, err := opts.operation.Start(store, "etcd-member-replace", opts.clusterID, map[string]string{"node": opts.nodeId})
 	if err != nil {
-		fmt.Println("[ERROR] Could not force etcd redeployment. Refer error below")
 		return err
 	}
 
-	// Turn the quorum guard back
-	fmt.Println("[INFO] Turning the quorum guard back on")
-	err = patchEtcd(kubeCli, EtcdQuorumTurnOnPatch)
-	if err != nil {
-		fmt.Println("[ERROR]  Could not Turn the quorum guard back on. Refer error below")
+	// The member is no longer crashlooping once it is removed, so it is only looked for when the
+	// replacement starts
+	pod := "etcd-" + opts.nodeId
+	if !opts.operation.Resume {
+		if pod, err = findUnhealthyEtcdMember(clientset, opts.nodeId); err != nil {
+			return err
+		}
+	}
+
+	if err := opts.operation.Execute(context.TODO(), opts.operation.Runner(store), state, opts.steps(kubeCli, kconfig, clientset, pod)); err != nil || opts.operation.Plan {
 		return err
 	}
 
@@ -144,6 +121,73 @@ func (opts *etcdOptions) ReplaceEtcdMember(kubeCli client.Client, kconfig *rest.
 	return nil
 }
 
+// findUnhealthyEtcdMember returns the etcd pod of the node, failing if it isn't the crashlooping one
+func findUnhealthyEtcdMember(clientset kubernetes.Interface, nodeId string) (string, error) {
+	listOptions := metav1.ListOptions{
+		LabelSelector: EtcdLabelSelector,
+	}
+
+	// Get the etcd pods
+	pods, err := clientset.CoreV1().Pods(EtcdNamespaceName).List(context.TODO(), listOptions)
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		for _, c := range pod.Status.ContainerStatuses {
+			if c.State.Waiting != nil && (c.State.Waiting.Reason == "CrashLoopBackOff" || c.State.Waiting.Reason == "Error") {
+				podName := "etcd-" + nodeId
+				if podName != pod.ObjectMeta.Name {
+					return "", fmt.Errorf("the etcd member seems to be healthy or is not present. Please run health-check again")
+				}
+				return pod.ObjectMeta.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("none of the etcd members seems to be unhealthy. Please verify once again")
+}
+
+// steps returns the steps replacing the etcd member of the pod
+func (opts *etcdOptions) steps(kubeCli client.Client, kconfig *rest.Config, clientset *kubernetes.Clientset, pod string) []operation.Step {
+	return []operation.Step{
+		{
+			Name:        "remove-member",
+			Description: fmt.Sprintf("Remove the etcd member of pod %s", pod),
+			Run: func(ctx context.Context) error {
+				return opts.removeEtcdMember(kconfig, clientset, pod)
+			},
+		},
+		{
+			Name:        "disable-quorum-guard",
+			Description: "Turn the quorum guard off",
+			Run: func(ctx context.Context) error {
+				return patchEtcd(kubeCli, EtcdQuorumTurnOffPatch)
+			},
+		},
+		{
+			Name:        "delete-secrets",
+			Description: "Delete the secrets of the unhealthy etcd member",
+			Run: func(ctx context.Context) error {
+				return opts.removeEtcdSecrets(ctx, clientset)
+			},
+		},
+		{
+			Name:        "force-redeployment",
+			Description: "Force the etcd redeployment",
+			Run: func(ctx context.Context) error {
+				timeStamp := time.Now().Format(time.RFC3339Nano)
+				return patchEtcd(kubeCli, fmt.Sprintf(EtcdForceRedeployPatch, timeStamp))
+			},
+		},
+		{
+			Name:        "enable-quorum-guard",
+			Description: "Turn the quorum guard back on",
+			Run: func(ctx context.Context) error {
+				return patchEtcd(kubeCli, EtcdQuorumTurnOnPatch)
+			},
+		},
+	}
+}
+
 func (opts *etcdOptions) removeEtcdMember(kconfig *rest.Config, clientset *kubernetes.Clientset, pod string) error {
 	fmt.Println()
 	cmd := "etcdctl member list -w table | grep " + opts.nodeId + " | awk '{ print $2 }'"
@@ -152,12 +196,12 @@ func (opts *etcdOptions) removeEtcdMember(kconfig *rest.Config, clientset *kuber
 		return err
 	}
 	memberId = strings.TrimSpace(memberId)
-	fmt.Printf("[INFO] Replacing pod %s having member id %s.\n", pod, memberId)
-
-	prompt := utils.ConfirmPrompt()
-	if !prompt {
-		return fmt.Errorf("operation cancelled by user")
+	if memberId == "" {
+		// Removed by an interrupted run
+		fmt.Printf("[INFO] Pod %s has no etcd member anymore.\n", pod)
+		return nil
 	}
+	fmt.Printf("[INFO] Replacing pod %s having member id %s.\n", pod, memberId)
 
 	removeCmd := "etcdctl member remove " + memberId
 	output, err := Etcdctlhealth(kconfig, clientset, removeCmd, pod)
@@ -182,11 +226,11 @@ func patchEtcd(kubeCli client.Client, patch string) error {
 	return nil
 }
 
-func (opts *etcdOptions) removeEtcdSecrets(clientset *kubernetes.Clientset) error {
+func (opts *etcdOptions) removeEtcdSecrets(ctx context.Context, clientset kubernetes.Interface) error {
 	for _, secret := range secrets {
 		name := secret + opts.nodeId
-		err := clientset.CoreV1().Secrets("openshift-etcd").Delete(context.TODO(), name, metav1.DeleteOptions{})
-		if err != nil {
+		err := clientset.CoreV1().Secrets("openshift-etcd").Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
 			return err
 		}
 	}
@@ -7,6 +7,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -77,3 +81,72 @@ func TestPatchEtcd(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveEtcdSecrets(t *testing.T) {
+	// The peer secret was deleted by an interrupted run
+	clientset := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-etcd", Name: "etcd-serving-master-0"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-etcd", Name: "etcd-serving-metrics-master-0"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-etcd", Name: "etcd-peer-master-1"}},
+	)
+	opts := &etcdOptions{nodeId: "master-0"}
+
+	require.NoError(t, opts.removeEtcdSecrets(context.Background(), clientset))
+
+	remaining, err := clientset.CoreV1().Secrets("openshift-etcd").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, remaining.Items, 1)
+	assert.Equal(t, "etcd-peer-master-1", remaining.Items[0].Name)
+}
+
+func TestFindUnhealthyEtcdMember(t *testing.T) {
+	etcdPod := func(name, reason string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: EtcdNamespaceName, Name: name, Labels: map[string]string{"k8s-app": "etcd"}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}},
+			}}},
+		}
+	}
+
+	tests := []struct {
+		title   string
+		pods    []*corev1.Pod
+		want    string
+		wantErr string
+	}{
+		{
+			title: "the node's member is crashlooping",
+			pods:  []*corev1.Pod{etcdPod("etcd-master-0", "CrashLoopBackOff")},
+			want:  "etcd-master-0",
+		},
+		{
+			title:   "another member is crashlooping",
+			pods:    []*corev1.Pod{etcdPod("etcd-master-1", "CrashLoopBackOff")},
+			wantErr: "the etcd member seems to be healthy or is not present",
+		},
+		{
+			title:   "no member is crashlooping",
+			pods:    []*corev1.Pod{etcdPod("etcd-master-0", "ContainerCreating")},
+			wantErr: "none of the etcd members seems to be unhealthy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			for _, pod := range tt.pods {
+				_, err := clientset.CoreV1().Pods(EtcdNamespaceName).Create(context.Background(), pod, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			pod, err := findUnhealthyEtcdMember(clientset, "master-0")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, pod)
+		})
+	}
+}
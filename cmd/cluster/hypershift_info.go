@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,7 +20,7 @@ import (
 	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
 	"github.com/olekukonko/tablewriter"
 	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
-	"github.com/openshift/osdctl/pkg/graphviz"
+	"github.com/openshift/osdctl/pkg/graph"
 	"github.com/openshift/osdctl/pkg/osdCloud"
 	"github.com/openshift/osdctl/pkg/osdctlConfig"
 	"github.com/openshift/osdctl/pkg/provider/aws"
@@ -40,10 +41,14 @@ func NewCmdHypershiftInfo(streams genericclioptions.IOStreams) *cobra.Command {
 		Use:   "hypershift-info",
 		Short: "Pull information about AWS objects from the cluster, the management cluster and the privatelink cluster",
 		Long: `This command aggregates AWS objects from the cluster, management cluster and privatelink for hypershift cluster.
-It attempts to render the relationships as graphviz if that output format is chosen or will simply print the output as tables.`,
+It renders the relationships as a graph, along with the network of the cluster (its subnets, route tables and gateways),
+as Graphviz DOT, Mermaid, JSON, or an SVG image rendered without Graphviz, or simply prints the resources as tables.`,
 		Example: `  # Show hypershift cluster info as graphviz
   osdctl cluster hypershift-info --cluster-id ${CLUSTER_ID}
 
+  # Show hypershift cluster info as an SVG image
+  osdctl cluster hypershift-info --cluster-id ${CLUSTER_ID} -o svg > hypershift-info.svg
+
   # Show hypershift cluster info as table
   osdctl cluster hypershift-info --cluster-id ${CLUSTER_ID} --output table`,
 		DisableAutoGenTag: true,
@@ -56,7 +61,7 @@ It attempts to render the relationships as graphviz if that output format is cho
 	infoCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS Profile")
 	infoCmd.Flags().StringVarP(&ops.awsRegion, "region", "r", "", "AWS Region")
 	infoCmd.Flags().StringVarP(&ops.privatelinkAccountId, "privatelinkaccount", "l", "", "Privatelink account ID")
-	infoCmd.Flags().StringVarP(&ops.output, "output", "o", "graphviz", "output format ['table', 'graphviz']")
+	infoCmd.Flags().StringVarP(&ops.output, "output", "o", "graphviz", fmt.Sprintf("output format %v, graphviz being an alias of dot", append([]string{"table", "graphviz"}, graph.Formats...)))
 	infoCmd.Flags().BoolVarP(&ops.verbose, "verbose", "", false, "Verbose output")
 
 	// Mark cluster-id as required
@@ -146,8 +151,8 @@ func (i *infoOptions) complete(cmd *cobra.Command) error {
 		errMsg += "missing argument -l."
 	}
 	if i.output != "" {
-		if i.output != "graphviz" && i.output != "table" {
-			errMsg += "output must be 'graphviz' or 'table'"
+		if i.output != "graphviz" && i.output != "table" && !slices.Contains(graph.Formats, i.output) {
+			errMsg += fmt.Sprintf("output must be one of: table, graphviz, %s", strings.Join(graph.Formats, ", "))
 		}
 	}
 	if errMsg != "" {
@@ -217,33 +222,36 @@ func (i *infoOptions) run() error {
 			break
 		}
 	}
-	switch i.output {
-	case "table":
+	if i.output == "table" {
 		render(&ai)
-	case "graphviz":
-		connections := createGraphViz(&ai)
+		return nil
+	}
+	format := i.output
+	if format == "graphviz" {
+		format = graph.DOT
+	}
+	if format == graph.DOT {
 		verboseLog("Generating GraphViz Input - please run this: 'echo <output> | dot -Tpng -o/tmp/example.png'")
-		graphviz.RenderGraphViz(connections)
-	default:
-		fmt.Println("No valid output format selected")
 	}
-	return nil
+	return graph.Render(i.Out, createGraph(&ai), format)
 }
 
-func createGraphViz(ai *aggregateClusterInfo) map[graphviz.Node][]graphviz.Node {
-	connections := make(map[graphviz.Node][]graphviz.Node)
+// createGraph links the privatelink hosted zones and endpoint to the endpoint service of the
+// management cluster, its connections to the load balancers of the management cluster and the
+// endpoints of the customer cluster, and adds the network of the customer cluster
+func createGraph(ai *aggregateClusterInfo) *graph.Graph {
+	g := graph.New()
+	g.AddSubgraph("customer", "Customer Cluster")
+	g.AddSubgraph("management", "Management Cluster")
+	g.AddSubgraph("privatelink", "Privatelink Account")
+
 	for _, hz := range ai.privatelinkInfo.HostedZones {
-		hzn := graphviz.Node{
-			Id:                    *hz.Id,
-			AdditionalInformation: fmt.Sprintf("Hosted Zone (P)\\n%s", *hz.Name),
-			Subgraph:              "privatelink",
-		}
-		connections[hzn] = make([]graphviz.Node, 0)
+		g.AddNode(graph.Node{ID: safeDeref(hz.Id), Kind: "Hosted Zone", Name: safeDeref(hz.Name), Subgraph: "privatelink"})
 	}
 	var privatelinkVpce string
 	for _, rrs := range ai.privatelinkInfo.ResourceRecords {
 		for _, rr := range rrs.ResourceRecords {
-			if strings.Contains(*rr.Value, "vpce") {
+			if strings.Contains(safeDeref(rr.Value), "vpce") {
 				privatelinkVpce = *rr.Value
 			}
 		}
@@ -252,77 +260,59 @@ func createGraphViz(ai *aggregateClusterInfo) map[graphviz.Node][]graphviz.Node
 	for _, svcs := range ai.managementClusterInfo.EndpointServices {
 		for _, dns := range svcs.BaseEndpointDnsNames {
 			if strings.Contains(privatelinkVpce, dns) {
-				mgmntService = *svcs.ServiceId
+				mgmntService = safeDeref(svcs.ServiceId)
 			}
 		}
 	}
-	plvpce := graphviz.Node{
-		Id:                    privatelinkVpce,
-		AdditionalInformation: "VPC Endpoint (P)",
-		Subgraph:              "privatelink",
-	}
-	mgmntsvc := graphviz.Node{
-		Id:                    mgmntService,
-		AdditionalInformation: "Endpoint Service (M)",
-		Subgraph:              "management",
+	g.AddNode(graph.Node{ID: privatelinkVpce, Kind: "VPC Endpoint", Subgraph: "privatelink"})
+	g.AddNode(graph.Node{ID: mgmntService, Kind: "Endpoint Service", Subgraph: "management"})
+	g.AddEdge(privatelinkVpce, mgmntService, "")
+
+	for _, lb := range ai.managementClusterInfo.LoadBalancers {
+		g.AddNode(graph.Node{ID: safeDeref(lb.LoadBalancerArn), Kind: "Load Balancer", Name: safeDeref(lb.LoadBalancerName), Subgraph: "management"})
 	}
-	connections[plvpce] = append(connections[plvpce], mgmntsvc)
 	for _, conn := range ai.managementClusterInfo.EndpointConnections {
-		node := graphviz.Node{
-			Id:                    *conn.VpcEndpointConnectionId,
-			AdditionalInformation: "Endpoint Connection (M)",
-			Subgraph:              "management",
-		}
-		connections[mgmntsvc] = append(connections[mgmntsvc], node)
+		connID := safeDeref(conn.VpcEndpointConnectionId)
+		g.AddNode(graph.Node{ID: connID, Kind: "Endpoint Connection", Subgraph: "management"})
+		g.AddEdge(mgmntService, connID, "")
 		for _, lb := range conn.NetworkLoadBalancerArns {
-			lb := graphviz.Node{
-				Id:                    lb,
-				AdditionalInformation: "Load Balancer (M)",
-				Subgraph:              "management",
-			}
-			connections[node] = append(connections[node], lb)
+			g.AddNode(graph.Node{ID: lb, Kind: "Load Balancer", Subgraph: "management"})
+			g.AddEdge(connID, lb, "")
 		}
 		for _, ceps := range ai.clusterInfo.Endpoints {
-			customerEndpointNode := graphviz.Node{
-				Id:                    *ceps.VpcEndpointId,
-				AdditionalInformation: "VPC Endpoint (C)",
-				Subgraph:              "customer",
-			}
-			if *ceps.VpcEndpointId == *conn.VpcEndpointId {
-				connections[node] = append(connections[node], customerEndpointNode)
+			if safeDeref(ceps.VpcEndpointId) == safeDeref(conn.VpcEndpointId) {
+				g.AddNode(graph.Node{ID: *ceps.VpcEndpointId, Kind: "VPC Endpoint", Subgraph: "customer"})
+				g.AddEdge(connID, *ceps.VpcEndpointId, "")
 			}
 		}
 	}
-	for _, hz := range ai.clusterInfo.HostedZones {
-		hzn := graphviz.Node{
-			Id:                    *hz.Id,
-			AdditionalInformation: fmt.Sprintf("Hosted Zone (C)\\n%s", *hz.Name),
-			Subgraph:              "customer",
+
+	addNetwork(g, "customer", &networkInfo{
+		Subnets:     ai.clusterInfo.Subnets,
+		RouteTables: ai.clusterInfo.SubnetRouteTables,
+		HostedZones: ai.clusterInfo.HostedZones,
+	})
+	for _, ceps := range ai.clusterInfo.Endpoints {
+		endpointID := safeDeref(ceps.VpcEndpointId)
+		g.AddNode(graph.Node{ID: endpointID, Kind: "VPC Endpoint", Name: safeDeref(ceps.ServiceName), Subgraph: "customer"})
+		for _, subnet := range ceps.SubnetIds {
+			if g.HasNode(subnet) {
+				g.AddEdge(subnet, endpointID, "")
+			}
 		}
-		connections[hzn] = make([]graphviz.Node, 0)
 	}
+
 	for _, rrs := range ai.clusterInfo.ResourceRecords {
 		for _, hz := range ai.privatelinkInfo.HostedZones {
-			var hzNode graphviz.Node
-			for connection := range connections {
-				if connection.Id == *hz.Id {
-					hzNode = connection
-				}
-			}
 			for _, rr := range rrs.ResourceRecords {
-				if *rr.Value+"." == *hz.Name {
-					node := graphviz.Node{
-						Id:                    *rr.Value,
-						AdditionalInformation: "Resource Record (C)",
-						Subgraph:              "customer",
-					}
-					connections[node] = make([]graphviz.Node, 0)
-					connections[hzNode] = append(connections[hzNode], node)
+				if safeDeref(rr.Value)+"." == safeDeref(hz.Name) {
+					g.AddNode(graph.Node{ID: *rr.Value, Kind: "Resource Record", Subgraph: "customer"})
+					g.AddEdge(safeDeref(hz.Id), *rr.Value, "")
 				}
 			}
 		}
 	}
-	return connections
+	return g
 }
 
 func (i *infoOptions) getClusters() (*infoClusters, error) {
@@ -3,7 +3,6 @@ package cluster
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -14,6 +13,7 @@ import (
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	awshivev1 "github.com/openshift/hive/apis/hive/v1/aws"
 	"github.com/openshift/osdctl/pkg/infra"
+	"github.com/openshift/osdctl/pkg/operation"
 	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
@@ -52,6 +52,8 @@ type imdsv2Options struct {
 	clientAdmin     client.Client
 	hiveClient      client.Client
 	hiveAdminClient client.Client
+
+	operation operation.Options
 }
 
 func newCmdIMDSv2() *cobra.Command {
@@ -67,7 +69,8 @@ This automates the SOP for migrating machines to IMDSv2 by:
 - Updating ControlPlaneMachineSet for automatic master node rollout
 - Validating all nodes/machines are using IMDSv2
 
-Pre-flight checks verify cluster health before making changes.`,
+Pre-flight checks verify cluster health before making changes. The progress is saved after
+every step, an interrupted migration is continued with --resume.`,
 		Example: `  # Migrate all nodes (infra + masters)
   osdctl cluster imdsv2 -C ${CLUSTER_ID} --reason "JIRA-12345"
 
@@ -75,7 +78,10 @@ Pre-flight checks verify cluster health before making changes.`,
   osdctl cluster imdsv2 -C ${CLUSTER_ID} --reason "CASE-67890" --nodes infra
 
   # Migrate only master nodes
-  osdctl cluster imdsv2 -C ${CLUSTER_ID} --reason "JIRA-12345" --nodes master`,
+  osdctl cluster imdsv2 -C ${CLUSTER_ID} --reason "JIRA-12345" --nodes master
+
+  # Show the steps of the migration and their progress without making changes
+  osdctl cluster imdsv2 -C ${CLUSTER_ID} --reason "JIRA-12345" --plan`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		SilenceUsage:      true, // Don't show usage on errors
@@ -87,6 +93,7 @@ Pre-flight checks verify cluster health before making changes.`,
 	cmd.Flags().StringVarP(&ops.clusterID, "cluster-id", "C", "", "The internal/external ID of the cluster")
 	cmd.Flags().StringVar(&ops.reason, "reason", "", "Reason for elevation (OHSS/PD/JIRA ticket)")
 	cmd.Flags().StringVar(&ops.nodeRoles, "nodes", "all", "Node roles to migrate: all, master, infra, workers")
+	ops.operation.AddFlags(cmd.Flags())
 
 	_ = cmd.MarkFlagRequired("cluster-id")
 	_ = cmd.MarkFlagRequired("reason")
@@ -152,6 +159,9 @@ func (o *imdsv2Options) run(ctx context.Context) error {
 	if err := o.validate(); err != nil {
 		return err
 	}
+	if err := o.operation.Validate(); err != nil {
+		return err
+	}
 
 	// Initialize OCM connection and Kubernetes clients
 	if err := o.init(); err != nil {
@@ -160,69 +170,81 @@ func (o *imdsv2Options) run(ctx context.Context) error {
 
 	fmt.Printf("Node Roles: %s\n\n", o.nodeRoles)
 
-	// Verify cluster health before making changes
-	if err := o.preFlightChecks(ctx); err != nil {
-		return fmt.Errorf("pre-flight checks failed: %v", err)
+	store, err := operation.NewStore()
+	if err != nil {
+		return err
 	}
-
-	// Determine which node types to migrate based on --nodes flag
-	doInfra := o.nodeRoles == "all" || o.nodeRoles == "infra"
-	doMasters := o.nodeRoles == "all" || o.nodeRoles == "master"
-	doWorkers := o.nodeRoles == "all" || o.nodeRoles == "workers"
-
-	// Track what actually changed
-	var infraChanged bool
-	var cpmsChanged bool
-	var workersChanged bool
-
-	// Step 1: Replace infra machines using MachinePool dance
-	if doInfra {
-		changed, err := o.migrateInfraToIMDSv2(ctx)
-		if err != nil {
-			return fmt.Errorf("infra migration failed: %v", err)
-		}
-		infraChanged = changed
+	state, err := o.operation.Start(store, "imdsv2", o.clusterID, map[string]string{"nodes": o.nodeRoles})
+	if err != nil {
+		return err
 	}
-
-	// Step 2: Update ControlPlaneMachineSet to trigger master node rollout
-	if doMasters {
-		changed, err := o.updateCPMSForIMDSv2(ctx)
-		if err != nil {
-			return fmt.Errorf("CPMS update failed: %v", err)
-		}
-		cpmsChanged = changed
+	steps, err := o.steps(ctx, state)
+	if err != nil {
+		return err
 	}
 
-	// Step 3: List and patch worker MachinePools (if requested)
-	if doWorkers {
-		changed, err := o.migrateWorkersToIMDSv2(ctx)
-		if err != nil {
-			return fmt.Errorf("worker migration failed: %v", err)
-		}
-		workersChanged = changed
+	if err := o.operation.Execute(ctx, o.operation.Runner(store), state, steps); err != nil || o.operation.Plan {
+		return err
 	}
 
-	// Step 4: Verify all nodes and machines are configured correctly
-	if err := o.validateIMDSv2(ctx); err != nil {
-		return fmt.Errorf("validation failed: %v", err)
+	printer.PrintlnGreen("\n✓ IMDSv2 migration completed successfully!")
+	return nil
+}
+
+// steps returns the steps migrating the node roles of the --nodes flag
+func (o *imdsv2Options) steps(ctx context.Context, state *operation.State) ([]operation.Step, error) {
+	// Verify cluster health before making changes
+	steps := []operation.Step{
+		{
+			Name:        "preflight-checks",
+			Description: "Check the health of the cluster operators and nodes",
+			Run:         o.preFlightChecks,
+		},
 	}
 
-	// Only show success if we actually made changes
-	if infraChanged || cpmsChanged || workersChanged {
-		printer.PrintlnGreen("\n✓ IMDSv2 migration completed successfully!")
-		if infraChanged {
-			fmt.Println("  - Infra nodes migrated to IMDSv2")
-		}
-		if cpmsChanged {
-			fmt.Println("  - Master nodes migrated to IMDSv2")
-		}
-		if workersChanged {
-			fmt.Println("  - Worker nodes migrated to IMDSv2")
+	// Replace infra machines using MachinePool dance
+	if o.nodeRoles == "all" || o.nodeRoles == "infra" {
+		infraSteps, err := o.infraSteps(ctx, state)
+		if err != nil {
+			return nil, fmt.Errorf("infra migration failed: %v", err)
 		}
-	} else {
-		printer.PrintlnGreen("\n✓ All components already configured for IMDSv2!")
+		steps = append(steps, infraSteps...)
 	}
-	return nil
+
+	// Update ControlPlaneMachineSet to trigger master node rollout
+	if o.nodeRoles == "all" || o.nodeRoles == "master" {
+		steps = append(steps,
+			operation.Step{
+				Name:        "patch-controlplanemachineset",
+				Description: "Patch the control plane machine set to require IMDSv2",
+				Run:         o.updateCPMSForIMDSv2,
+			},
+			operation.Step{
+				Name:        "wait-controlplane-rollout",
+				Description: "Wait for the 3 control plane nodes to be replaced one at a time (~60-120 min)",
+				Run: func(ctx context.Context) error {
+					return MonitorCPMSRollout(ctx, o.client, cpmsNamespace, cpmsName, imdsv2RolloutPollTimeout)
+				},
+			},
+		)
+	}
+
+	// Patch worker MachinePools (if requested), their nodes are replaced by the customer
+	if o.nodeRoles == "all" || o.nodeRoles == "workers" {
+		steps = append(steps, operation.Step{
+			Name:        "patch-worker-machinepools",
+			Description: "Patch the worker MachinePools to require IMDSv2",
+			Run:         o.migrateWorkersToIMDSv2,
+			Skippable:   true,
+		})
+	}
+
+	// Verify all nodes and machines are configured correctly
+	return append(steps, operation.Step{
+		Name:        "validate",
+		Description: "Validate the nodes and machines are configured for IMDSv2",
+		Run:         o.validateIMDSv2,
+	}), nil
 }
 
 // preFlightChecks verifies cluster health before making changes.
@@ -280,66 +302,65 @@ func (o *imdsv2Options) preFlightChecks(ctx context.Context) error {
 	return nil
 }
 
-// migrateInfraToIMDSv2 migrates infra nodes to IMDSv2 using the MachinePool dance.
-// Returns true if changes were made, false if already configured.
-func (o *imdsv2Options) migrateInfraToIMDSv2(ctx context.Context) (bool, error) {
-	printer.PrintlnGreen("\n=== Migrating Infra Nodes to IMDSv2 ===")
-
-	// Get the infra MachinePool from Hive
-	infraMp, err := infra.GetInfraMachinePool(ctx, o.hiveClient, o.clusterID)
+// infraSteps returns the steps migrating infra nodes to IMDSv2 using the MachinePool dance, none
+// if they are already configured. The MachinePools are recorded in the state to resume the dance.
+func (o *imdsv2Options) infraSteps(ctx context.Context, state *operation.State) ([]operation.Step, error) {
+	infraMp, newMp, err := infra.LoadDanceMachinePools(state)
 	if err != nil {
-		return false, fmt.Errorf("failed to get infra MachinePool: %w", err)
+		return nil, err
 	}
+	if infraMp == nil {
+		// Get the infra MachinePool from Hive
+		infraMp, err = infra.GetInfraMachinePool(ctx, o.hiveClient, o.clusterID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get infra MachinePool: %w", err)
+		}
 
-	// Validate MachinePool name (Comment #5: MachinePool matching safety)
-	validMpNames := map[string]bool{"infra": true}
-	if !validMpNames[infraMp.Spec.Name] {
-		return false, fmt.Errorf("unexpected infra MachinePool configuration (expected name: infra)")
-	}
+		// Validate MachinePool name (Comment #5: MachinePool matching safety)
+		validMpNames := map[string]bool{"infra": true}
+		if !validMpNames[infraMp.Spec.Name] {
+			return nil, fmt.Errorf("unexpected infra MachinePool configuration (expected name: infra)")
+		}
 
-	// Check if already configured for IMDSv2
-	currentAuth := "Not configured"
-	if infraMp.Spec.Platform.AWS != nil && infraMp.Spec.Platform.AWS.EC2Metadata != nil {
-		currentAuth = infraMp.Spec.Platform.AWS.EC2Metadata.Authentication
-	}
+		// Check if already configured for IMDSv2
+		currentAuth := "Not configured"
+		if infraMp.Spec.Platform.AWS != nil && infraMp.Spec.Platform.AWS.EC2Metadata != nil {
+			currentAuth = infraMp.Spec.Platform.AWS.EC2Metadata.Authentication
+		}
 
-	if currentAuth == imdsv2Required {
-		fmt.Println("Infra nodes already configured for IMDSv2 - skipping")
-		return false, nil
+		if currentAuth == imdsv2Required {
+			fmt.Println("Infra nodes already configured for IMDSv2 - skipping")
+			return nil, nil
+		}
+		fmt.Printf("Current IMDS authentication: %s\n", currentAuth)
+
+		// Clone the MachinePool and configure it for IMDSv2
+		// NOTE: NO override annotation needed - the dance creates a new MP atomically
+		newMp, err = infra.CloneMachinePool(infraMp, func(mp *hivev1.MachinePool) error {
+			if mp.Spec.Platform.AWS == nil {
+				mp.Spec.Platform.AWS = &awshivev1.MachinePoolPlatform{}
+			}
+			if mp.Spec.Platform.AWS.EC2Metadata == nil {
+				mp.Spec.Platform.AWS.EC2Metadata = &awshivev1.EC2Metadata{}
+			}
+			mp.Spec.Platform.AWS.EC2Metadata.Authentication = imdsv2Required
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to clone MachinePool: %w", err)
+		}
+		if err := infra.SaveDanceMachinePools(state, infraMp, newMp); err != nil {
+			return nil, err
+		}
 	}
 
-	// Display current state
+	// Display the replacement
 	replicas := int64(2) // default
 	if infraMp.Spec.Replicas != nil {
 		replicas = *infraMp.Spec.Replicas
 	}
-	fmt.Printf("Current IMDS authentication: %s\n", currentAuth)
-	fmt.Printf("Infra node count: %d\n", replicas)
-
-	// Comment #3: Add confirmation prompt
-	fmt.Printf("\nThis will replace all %d infra nodes using the MachinePool dance.\n", replicas)
-	fmt.Println("During the process, there will temporarily be 2x infra nodes for high availability.")
-	estimatedMinutes := int(replicas) * 10 // rough estimate
-	fmt.Printf("Estimated time: ~%d minutes\n", estimatedMinutes)
-	if !utils.ConfirmPrompt() {
-		return false, errors.New("aborted by user")
-	}
-
-	// Clone the MachinePool and configure it for IMDSv2
-	// NOTE: NO override annotation needed - the dance creates a new MP atomically
-	newMp, err := infra.CloneMachinePool(infraMp, func(mp *hivev1.MachinePool) error {
-		if mp.Spec.Platform.AWS == nil {
-			mp.Spec.Platform.AWS = &awshivev1.MachinePoolPlatform{}
-		}
-		if mp.Spec.Platform.AWS.EC2Metadata == nil {
-			mp.Spec.Platform.AWS.EC2Metadata = &awshivev1.EC2Metadata{}
-		}
-		mp.Spec.Platform.AWS.EC2Metadata.Authentication = imdsv2Required
-		return nil
-	})
-	if err != nil {
-		return false, fmt.Errorf("failed to clone MachinePool: %w", err)
-	}
+	fmt.Printf("All %d infra nodes are replaced using the MachinePool dance, ", replicas)
+	fmt.Printf("there will temporarily be 2x infra nodes for high availability (~%d minutes).\n", int(replicas)*10)
 
 	// Set up clients for the machinepool dance
 	danceClients := infra.DanceClients{
@@ -348,38 +369,33 @@ func (o *imdsv2Options) migrateInfraToIMDSv2(ctx context.Context) (bool, error)
 		HiveAdmin:     o.hiveAdminClient,
 	}
 
-	// Comment #4 FIX: RunMachinePoolDance handles everything atomically
 	// No annotations on the original MP, no cleanup needed
-	fmt.Println("\nStarting MachinePool dance to replace infra nodes...")
-	if err := infra.RunMachinePoolDance(ctx, danceClients, infraMp, newMp, nil); err != nil {
-		return false, fmt.Errorf("MachinePool dance failed: %w", err)
-	}
+	steps := infra.MachinePoolDanceSteps(danceClients, infraMp, newMp, nil)
 
 	// Wait for cluster operators to stabilize after replacement
-	fmt.Println("\nWaiting for cluster operators to stabilize...")
-	if err := WaitForClusterOperatorsHealthy(ctx, o.client, imdsv2COWaitTimeout); err != nil {
-		return false, err
-	}
-
-	printer.PrintlnGreen("Infra nodes migrated to IMDSv2!")
-	return true, nil
+	return append(steps, operation.Step{
+		Name:        "wait-cluster-operators",
+		Description: "Wait for the cluster operators to stabilize after the infra node replacement",
+		Run: func(ctx context.Context) error {
+			return WaitForClusterOperatorsHealthy(ctx, o.client, imdsv2COWaitTimeout)
+		},
+	}), nil
 }
 
 // migrateWorkersToIMDSv2 lists worker MachinePools that need IMDSv2 and asks user which to patch.
-// Returns true if any changes were made, false if already configured or user skipped.
-func (o *imdsv2Options) migrateWorkersToIMDSv2(ctx context.Context) (bool, error) {
+func (o *imdsv2Options) migrateWorkersToIMDSv2(ctx context.Context) error {
 	printer.PrintlnGreen("\n=== Worker Node MachinePools ===")
 
 	// Get the Hive namespace for this cluster
 	hiveNamespace, err := GetHiveNamespace(o.clusterID)
 	if err != nil {
-		return false, err
+		return err
 	}
 
 	// Retrieve all MachinePools for this cluster
 	mpList := &hivev1.MachinePoolList{}
 	if err := o.hiveClient.List(ctx, mpList, &client.ListOptions{Namespace: hiveNamespace}); err != nil {
-		return false, fmt.Errorf("failed to list MachinePools: %w", err)
+		return fmt.Errorf("failed to list MachinePools: %w", err)
 	}
 
 	// Find worker MachinePools that need IMDSv2
@@ -426,7 +442,7 @@ func (o *imdsv2Options) migrateWorkersToIMDSv2(ctx context.Context) (bool, error
 
 	if len(workersNeedingUpdate) == 0 {
 		fmt.Println("All worker MachinePools already configured for IMDSv2")
-		return false, nil
+		return nil
 	}
 
 	// Display worker MachinePools that need IMDSv2
@@ -440,7 +456,7 @@ func (o *imdsv2Options) migrateWorkersToIMDSv2(ctx context.Context) (bool, error
 	fmt.Printf("\nPatch %d worker MachinePool(s) to require IMDSv2?\n", len(workersNeedingUpdate))
 	if !utils.ConfirmPrompt() {
 		fmt.Println("Skipped - worker MachinePools not patched")
-		return false, nil
+		return nil
 	}
 
 	// Patch each worker MachinePool
@@ -453,7 +469,7 @@ func (o *imdsv2Options) migrateWorkersToIMDSv2(ctx context.Context) (bool, error
 		// Get current MachinePool
 		mp := &hivev1.MachinePool{}
 		if err := o.hiveClient.Get(ctx, client.ObjectKey{Namespace: hiveNamespace, Name: mpInfo.name}, mp); err != nil {
-			return false, fmt.Errorf("failed to get worker MachinePool %d of %d: %w", patchedCount, len(workersNeedingUpdate), err)
+			return fmt.Errorf("failed to get worker MachinePool %d of %d: %w", patchedCount, len(workersNeedingUpdate), err)
 		}
 
 		patch := client.MergeFrom(mp.DeepCopy())
@@ -475,7 +491,7 @@ func (o *imdsv2Options) migrateWorkersToIMDSv2(ctx context.Context) (bool, error
 		mp.Spec.Platform.AWS.EC2Metadata.Authentication = imdsv2Required
 
 		if err := o.hiveAdminClient.Patch(ctx, mp, patch); err != nil {
-			return false, fmt.Errorf("failed to patch worker MachinePool %d of %d: %w", patchedCount, len(workersNeedingUpdate), err)
+			return fmt.Errorf("failed to patch worker MachinePool %d of %d: %w", patchedCount, len(workersNeedingUpdate), err)
 		}
 
 		fmt.Printf("  ✓ MachinePool patched successfully\n")
@@ -483,14 +499,14 @@ func (o *imdsv2Options) migrateWorkersToIMDSv2(ctx context.Context) (bool, error
 		// Remove the override annotation now that the patch is applied
 		// Re-fetch to get latest version before removing annotation
 		if err := o.hiveClient.Get(ctx, client.ObjectKey{Namespace: hiveNamespace, Name: mpInfo.name}, mp); err != nil {
-			return false, fmt.Errorf("failed to re-fetch worker MachinePool %d of %d: %w", patchedCount, len(workersNeedingUpdate), err)
+			return fmt.Errorf("failed to re-fetch worker MachinePool %d of %d: %w", patchedCount, len(workersNeedingUpdate), err)
 		}
 
 		patch = client.MergeFrom(mp.DeepCopy())
 		delete(mp.Annotations, hiveOverrideAnnotation)
 
 		if err := o.hiveAdminClient.Patch(ctx, mp, patch); err != nil {
-			return false, fmt.Errorf("failed to remove override annotation from worker MachinePool %d of %d: %w", patchedCount, len(workersNeedingUpdate), err)
+			return fmt.Errorf("failed to remove override annotation from worker MachinePool %d of %d: %w", patchedCount, len(workersNeedingUpdate), err)
 		}
 
 		fmt.Printf("  ✓ Override annotation removed\n")
@@ -506,52 +522,44 @@ func (o *imdsv2Options) migrateWorkersToIMDSv2(ctx context.Context) (bool, error
 		fmt.Println("  3. Use the MachinePool dance pattern (similar to infra node replacement)")
 	}
 
-	return anyPatched, nil
+	return nil
 }
 
-// updateCPMSForIMDSv2 patches the ControlPlaneMachineSet to trigger a rolling replacement.
-// Returns true if changes were made, false if already configured.
-func (o *imdsv2Options) updateCPMSForIMDSv2(ctx context.Context) (bool, error) {
-	printer.PrintlnGreen("\n=== Updating ControlPlaneMachineSet for IMDSv2 ===")
-
+// updateCPMSForIMDSv2 patches the ControlPlaneMachineSet to trigger a rolling replacement,
+// unless it is already configured.
+func (o *imdsv2Options) updateCPMSForIMDSv2(ctx context.Context) error {
 	// Retrieve the ControlPlaneMachineSet
 	cpms := &machinev1.ControlPlaneMachineSet{}
 	if err := o.client.Get(ctx, client.ObjectKey{Namespace: cpmsNamespace, Name: cpmsName}, cpms); err != nil {
-		return false, fmt.Errorf("failed to get CPMS: %w", err)
+		return fmt.Errorf("failed to get CPMS: %w", err)
 	}
 
 	// Parse the AWS provider spec from CPMS template
 	if cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value == nil {
-		return false, fmt.Errorf("CPMS ProviderSpec.Value is nil")
+		return fmt.Errorf("CPMS ProviderSpec.Value is nil")
 	}
 
 	awsSpec := &machinev1beta1.AWSMachineProviderConfig{}
 	if err := json.Unmarshal(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value.Raw, awsSpec); err != nil {
-		return false, fmt.Errorf("failed to unmarshal CPMS provider spec: %w", err)
+		return fmt.Errorf("failed to unmarshal CPMS provider spec: %w", err)
 	}
 
 	// Skip if already configured for IMDSv2
 	if awsSpec.MetadataServiceOptions.Authentication == imdsv2Required {
 		fmt.Println("Control plane already configured for IMDSv2 - skipping")
-		return false, nil
+		return nil
 	}
 
 	fmt.Printf("Current IMDS authentication: %s\n", awsSpec.MetadataServiceOptions.Authentication)
 	fmt.Println("Patching CPMS to enforce IMDSv2...")
 
-	// Confirm action with user (destructive operation)
-	fmt.Printf("\nThis will replace all 3 control plane nodes one at a time (~35-45 min).\n")
-	if !utils.ConfirmPrompt() {
-		return false, errors.New("aborted by user")
-	}
-
 	// Update AWS spec to require IMDSv2
 	awsSpec.MetadataServiceOptions.Authentication = imdsv2Required
 
 	// Serialize and apply the updated spec
 	rawBytes, err := json.Marshal(awsSpec)
 	if err != nil {
-		return false, fmt.Errorf("failed to marshal updated provider spec: %w", err)
+		return fmt.Errorf("failed to marshal updated provider spec: %w", err)
 	}
 
 	patch := client.MergeFrom(cpms.DeepCopy())
@@ -559,19 +567,11 @@ func (o *imdsv2Options) updateCPMSForIMDSv2(ctx context.Context) (bool, error) {
 
 	// Apply the patch
 	if err := o.clientAdmin.Patch(ctx, cpms, patch); err != nil {
-		return false, fmt.Errorf("failed to patch CPMS: %w", err)
+		return fmt.Errorf("failed to patch CPMS: %w", err)
 	}
 
 	printer.PrintlnGreen("CPMS patched successfully. Rolling replacement in progress...")
-	fmt.Println("Monitoring rollout (this may take 60-120 minutes)...")
-
-	// Wait for all master nodes to be replaced
-	if err := MonitorCPMSRollout(ctx, o.client, cpmsNamespace, cpmsName, imdsv2RolloutPollTimeout); err != nil {
-		return false, err
-	}
-
-	printer.PrintlnGreen("Control plane IMDSv2 migration complete!")
-	return true, nil
+	return nil
 }
 
 // validateIMDSv2 verifies the migration was successful.
@@ -2,6 +2,7 @@ package cluster
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -10,11 +11,15 @@ import (
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	awshivev1 "github.com/openshift/hive/apis/hive/v1/aws"
+	"github.com/openshift/osdctl/pkg/operation"
+	"github.com/openshift/osdctl/pkg/operation/operationtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -340,7 +345,7 @@ func TestMachinePoolNameValidation(t *testing.T) {
 }
 
 // TestCPMSIMDSv2Configuration tests the skip logic in updateCPMSForIMDSv2.
-// When already configured, the function should return (false, nil).
+// When already configured, the CPMS is not patched.
 func TestCPMSIMDSv2Configuration(t *testing.T) {
 	scheme := runtime.NewScheme()
 	require.NoError(t, machinev1.AddToScheme(scheme))
@@ -575,3 +580,99 @@ func TestValidateAWSClassicCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateCPMSForIMDSv2(t *testing.T) {
+	raw, err := json.Marshal(&machinev1beta1.AWSMachineProviderConfig{
+		MetadataServiceOptions: machinev1beta1.MetadataServiceOptions{Authentication: imdsv2Optional},
+	})
+	require.NoError(t, err)
+	cpms := &machinev1.ControlPlaneMachineSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: cpmsNamespace, Name: cpmsName},
+		Spec: machinev1.ControlPlaneMachineSetSpec{
+			State: machinev1.ControlPlaneMachineSetStateActive,
+			Template: machinev1.ControlPlaneMachineSetTemplate{
+				OpenShiftMachineV1Beta1Machine: &machinev1.OpenShiftMachineV1Beta1MachineTemplate{},
+			},
+		},
+	}
+	cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: raw}
+	c := operationtest.NewFakeClient(t, cpms)
+	ops := &imdsv2Options{client: c, clientAdmin: c}
+
+	require.NoError(t, ops.updateCPMSForIMDSv2(context.Background()))
+
+	patched := &machinev1.ControlPlaneMachineSet{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: cpmsNamespace, Name: cpmsName}, patched))
+	spec := &machinev1beta1.AWSMachineProviderConfig{}
+	require.NoError(t, json.Unmarshal(patched.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value.Raw, spec))
+	assert.EqualValues(t, imdsv2Required, spec.MetadataServiceOptions.Authentication)
+
+	// A resumed run patching the CPMS again leaves it unchanged
+	require.NoError(t, ops.updateCPMSForIMDSv2(context.Background()))
+}
+
+func TestIMDSv2Steps(t *testing.T) {
+	infraMp := &hivev1.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "uhc-production-cluster-1", Name: "cluster-infra"},
+		Spec: hivev1.MachinePoolSpec{
+			Name:     "infra",
+			Replicas: ptr.To[int64](3),
+			Labels:   map[string]string{"node-role.kubernetes.io/infra": ""},
+			Platform: hivev1.MachinePoolPlatform{AWS: &awshivev1.MachinePoolPlatform{InstanceType: "r5.xlarge"}},
+		},
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "uhc-production-cluster-1", Labels: map[string]string{"api.openshift.com/id": "cluster-1"}}}
+
+	tests := []struct {
+		name      string
+		nodeRoles string
+		want      []string
+	}{
+		{
+			name:      "master",
+			nodeRoles: "master",
+			want:      []string{"preflight-checks", "patch-controlplanemachineset", "wait-controlplane-rollout", "validate"},
+		},
+		{
+			name:      "workers",
+			nodeRoles: "workers",
+			want:      []string{"preflight-checks", "patch-worker-machinepools", "validate"},
+		},
+		{
+			name:      "infra",
+			nodeRoles: "infra",
+			want: []string{
+				"preflight-checks",
+				"create-temporary-machinepool",
+				"wait-temporary-nodes",
+				"delete-original-machinepool",
+				"wait-original-nodes-removed",
+				"create-machinepool",
+				"wait-new-nodes",
+				"delete-temporary-machinepool",
+				"wait-temporary-nodes-removed",
+				"wait-cluster-operators",
+				"validate",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := &imdsv2Options{
+				clusterID:  "cluster-1",
+				nodeRoles:  tt.nodeRoles,
+				hiveClient: operationtest.NewFakeClient(t, namespace, infraMp),
+			}
+			state := operation.NewState("imdsv2", "cluster-1", nil)
+
+			steps, err := ops.steps(context.Background(), state)
+			require.NoError(t, err)
+			var names []string
+			for _, step := range steps {
+				names = append(names, step.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
@@ -13,10 +13,9 @@ import (
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
-	bpelevate "github.com/openshift/backplane-cli/pkg/elevate"
 	"github.com/openshift/osdctl/cmd/servicelog"
 	"github.com/openshift/osdctl/pkg/k8s"
-	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/operation"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -43,6 +42,8 @@ type controlPlane struct {
 
 	// reason to provide for elevation (eg: OHSS/PG ticket)
 	reason string
+
+	operation operation.Options
 }
 
 // This command requires to previously be logged in via `ocm login`
@@ -55,7 +56,8 @@ func newCmdResizeControlPlane() *cobra.Command {
 
   Requires previous login to the api server via "ocm backplane login".
   The user will be prompted to send a service log after initiating the resize. The resize process runs asynchronously,
-  and this command exits immediately after sending the service log. Any issues with the resize will be reported via PagerDuty.`,
+  and this command exits immediately after sending the service log. Any issues with the resize will be reported via PagerDuty.
+  The progress of the command is saved after every step, an interrupted run is continued with --resume.`,
 		Example: `  # Resize all control plane instances to m5.4xlarge using control plane machine sets
   osdctl cluster resize control-plane --cluster-id "${CLUSTER_ID}" --machine-type m5.4xlarge --reason "${REASON}"`,
 		Args:              cobra.NoArgs,
@@ -70,6 +72,7 @@ func newCmdResizeControlPlane() *cobra.Command {
 	resizeControlPlaneNodeCmd.Flags().StringVarP(&ops.clusterID, "cluster-id", "C", "", "The internal ID of the cluster to perform actions on")
 	resizeControlPlaneNodeCmd.Flags().StringVar(&ops.newMachineType, "machine-type", "", "The target AWS machine type to resize to (e.g. m5.2xlarge)")
 	resizeControlPlaneNodeCmd.Flags().StringVar(&ops.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usually an OHSS or PD ticket)")
+	ops.operation.AddFlags(resizeControlPlaneNodeCmd.Flags())
 	_ = resizeControlPlaneNodeCmd.MarkFlagRequired("cluster-id")
 	_ = resizeControlPlaneNodeCmd.MarkFlagRequired("machine-type")
 	_ = resizeControlPlaneNodeCmd.MarkFlagRequired("reason")
@@ -78,6 +81,10 @@ func newCmdResizeControlPlane() *cobra.Command {
 }
 
 func (o *controlPlane) New() error {
+	if err := o.operation.Validate(); err != nil {
+		return err
+	}
+
 	if err := validateInstanceSize(o.newMachineType, "controlplane"); err != nil {
 		return err
 	}
@@ -131,8 +138,6 @@ func (o *controlPlane) New() error {
 	return nil
 }
 
-func (o *controlPlane) embiggenMachineType() {}
-
 // extractInstanceClass extracts the instance class from an instance type string.
 // For example: "m5.4xlarge" -> "m5", "m6i.8xlarge" -> "m6i"
 func extractInstanceClass(instanceType string) (string, error) {
@@ -146,163 +151,77 @@ func extractInstanceClass(instanceType string) (string, error) {
 	return "", fmt.Errorf("instance type %s is not a valid instance type", instanceType)
 }
 
-type optionsDialogResponse int64
-
-const (
-	Undefined optionsDialogResponse = 0
-	Retry                           = 1
-	Skip                            = 2
-	Force                           = 3
-	Cancel                          = 4
-)
-
-func retrySkipCancelDialog(procedure string) (optionsDialogResponse, error) {
-	fmt.Printf("Do you want to retry %[1]s, skip %[1]s or cancel this command? (retry/skip/cancel):\n", procedure)
-
-	reader := bufio.NewReader(os.Stdin)
-
-	responseBytes, _, err := reader.ReadLine()
+// run performs a control plane resize leveraging control plane machine sets
+// https://docs.openshift.com/container-platform/latest/machine_management/control_plane_machine_management/cpmso-about.html
+func (o *controlPlane) run(ctx context.Context) error {
+	store, err := operation.NewStore()
 	if err != nil {
-		return Undefined, fmt.Errorf("reader.ReadLine() resulted in an error: %s", err)
-	}
-
-	response := strings.ToUpper(string(responseBytes))
-
-	switch response {
-	case "RETRY":
-		return Retry, nil
-	case "SKIP":
-		return Skip, nil
-	case "CANCEL":
-		return Cancel, nil
-	default:
-		fmt.Println("Invalid response, expected 'retry', 'skip' or 'cancel' (case-insensitive).")
-		return retrySkipCancelDialog(procedure)
-	}
-}
-
-func withRetrySkipCancelOption(fn func() error, procedure string) (err error) {
-	err = fn()
-	if err == nil {
-		return nil
+		return err
 	}
-	fmt.Println(err)
-	dialogResponse, err := retrySkipCancelDialog(procedure)
+	state, err := o.operation.Start(store, "resize-control-plane", o.clusterID, map[string]string{"machine-type": o.newMachineType})
 	if err != nil {
 		return err
 	}
 
-	switch dialogResponse {
-	case Retry:
-		return withRetrySkipCancelOption(fn, procedure)
-	case Skip:
-		fmt.Printf("Skipping %s...\n", procedure)
-	case Cancel:
-		return errors.New("exiting")
-	default:
-		return errors.New("unhandled enumerator in withRetrySkipCancelOption")
-	}
-	return nil
-}
-
-func retrySkipForceCancelDialog(procedure string) (optionsDialogResponse, error) {
-	fmt.Printf("Do you want to retry %s, skip %s, force %s or cancel this command? (retry/skip/force/cancel):\n", procedure, procedure, procedure)
-
-	reader := bufio.NewReader(os.Stdin)
-
-	responseBytes, _, err := reader.ReadLine()
-	if err != nil {
-		return Undefined, fmt.Errorf("reader.ReadLine() resulted in an error: %s", err)
+	// Check the resize can be done before planning it
+	if _, _, err := o.resizedControlPlaneMachineSet(ctx); err != nil {
+		return err
 	}
 
-	response := strings.ToUpper(string(responseBytes))
-
-	switch response {
-	case "RETRY":
-		return Retry, nil
-	case "SKIP":
-		return Skip, nil
-	case "FORCE":
-		return Force, nil
-	case "CANCEL":
-		return Cancel, nil
-	default:
-		fmt.Println("Invalid response, expected 'retry', 'skip', 'force' or 'cancel' (case-insensitive).")
-		return retrySkipForceCancelDialog(procedure)
-	}
+	log.Printf("Initiating control plane node resize for cluster %s/%s to %s using control plane machine sets. This process runs asynchronously.", o.cluster.Name(), o.cluster.ID(), o.newMachineType)
+	return o.operation.Execute(ctx, o.operation.Runner(store), state, o.steps())
 }
 
-func (o *controlPlane) forceDrainNode(nodeID string, reason string) error {
-	printer.PrintlnGreen("Force draining node... This might take a minute or two...")
-	err := bpelevate.RunElevate([]string{
-		fmt.Sprintf("%s - Elevate required to force drain node for resizecontroleplanenode", reason),
-		"adm drain --ignore-daemonsets --delete-emptydir-data --force", nodeID,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to force drain:\n%s", err)
+func (o *controlPlane) steps() []operation.Step {
+	return []operation.Step{
+		{
+			Name:        "patch-controlplanemachineset",
+			Description: fmt.Sprintf("Patch the control plane machine set to the %s machine type", o.newMachineType),
+			Run:         o.patchControlPlaneMachineSet,
+		},
+		{
+			Name:        "send-service-log",
+			Description: "Send a service log about the resize",
+			Run: func(ctx context.Context) error {
+				return promptGenerateResizeSL(o.clusterID, o.newMachineType)
+			},
+			Skippable: true,
+		},
 	}
-	return nil
 }
 
-func (o *controlPlane) drainNode(nodeID string, reason string) error {
-	printer.PrintlnGreen("Draining node", nodeID)
-
-	err := bpelevate.RunElevate([]string{
-		fmt.Sprintf("%s - Elevate required to drain node for resizecontroleplanenode", reason),
-		"adm drain --ignore-daemonsets --delete-emptydir-data", nodeID,
-	})
+// patchControlPlaneMachineSet patches the control plane machine set to the new machine type, which
+// makes the control plane machine set operator roll out new control plane nodes
+func (o *controlPlane) patchControlPlaneMachineSet(ctx context.Context) error {
+	cpms, patch, err := o.resizedControlPlaneMachineSet(ctx)
 	if err != nil {
-		fmt.Println("Failed to drain node:")
-		fmt.Println(err)
-
-		dialogResponse, err := retrySkipForceCancelDialog("draining node")
-		if err != nil {
-			return err
-		}
-
-		switch dialogResponse {
-		case Retry:
-			return o.drainNode(nodeID, reason)
-		case Skip:
-			fmt.Println("Skipping node drain")
-		case Force:
-			err = withRetrySkipCancelOption(func() error { return o.forceDrainNode(nodeID, reason) }, "force draining")
-			if err != nil {
-				return err
-			}
-		case Cancel:
-			return errors.New("exiting")
-		}
+		return err
+	}
+	if patch == nil {
+		log.Printf("Control plane machine set already uses the %s machine type", o.newMachineType)
+		return nil
 	}
-	return nil
-}
 
-func (o *controlPlane) patchMachineType(machine string, machineType string, reason string) error {
-	printer.PrintlnGreen("Patching machine type of machine", machine, "to", machineType)
-	err := bpelevate.RunElevate([]string{
-		fmt.Sprintf("%s - Elevate required to patch machine type of machine %s to %s", reason, machine, machineType),
-		`-n openshift-machine-api patch machine`, machine, `--patch "{\"spec\":{\"providerSpec\":{\"value\":{\"instanceType\":\"` + machineType + `\"}}}}" --type merge`,
-	})
-	if err != nil {
-		return fmt.Errorf("could not patch machine type:\n%s", err)
+	if err := o.clientAdmin.Patch(ctx, cpms, patch); err != nil {
+		return fmt.Errorf("failed patching control plane machine set: %v", err)
 	}
+
+	log.Println("Control plane machine set patched successfully. The resize is now in progress and will complete asynchronously. This command will exit after sending a service log, and any issues will be reported via PagerDuty.")
 	return nil
 }
 
-// run performs a control plane resize leveraging control plane machine sets
-// https://docs.openshift.com/container-platform/latest/machine_management/control_plane_machine_management/cpmso-about.html
-func (o *controlPlane) run(ctx context.Context) error {
+// resizedControlPlaneMachineSet returns the control plane machine set of the cluster with the new
+// machine type and the patch to apply, a nil patch if it already uses the new machine type
+func (o *controlPlane) resizedControlPlaneMachineSet(ctx context.Context) (*machinev1.ControlPlaneMachineSet, client.Patch, error) {
 	cpms := &machinev1.ControlPlaneMachineSet{}
 	if err := o.client.Get(ctx, client.ObjectKey{Namespace: cpmsNamespace, Name: cpmsName}, cpms); err != nil {
-		return fmt.Errorf("error retrieving control plane machine set: %v", err)
+		return nil, nil, fmt.Errorf("error retrieving control plane machine set: %v", err)
 	}
 
 	if cpms.Spec.State != machinev1.ControlPlaneMachineSetStateActive {
-		return fmt.Errorf("control plane machine set is unexpectedly in %s state, must be %s - check for service logs, support exceptions, ask for a second opinion", cpms.Spec.State, machinev1.ControlPlaneMachineSetStateActive)
+		return nil, nil, fmt.Errorf("control plane machine set is unexpectedly in %s state, must be %s - check for service logs, support exceptions, ask for a second opinion", cpms.Spec.State, machinev1.ControlPlaneMachineSetStateActive)
 	}
 
-	patch := client.MergeFrom(cpms.DeepCopy())
-
 	var (
 		rawBytes            []byte
 		currentInstanceType string
@@ -312,58 +231,52 @@ func (o *controlPlane) run(ctx context.Context) error {
 	case "aws":
 		awsSpec := &machinev1beta1.AWSMachineProviderConfig{}
 		if err := json.Unmarshal(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value.Raw, &awsSpec); err != nil {
-			return fmt.Errorf("error unmarshalling providerSpec: %v", err)
+			return nil, nil, fmt.Errorf("error unmarshalling providerSpec: %v", err)
 		}
 		currentInstanceType = awsSpec.InstanceType
 
 		// Validate that instance class is not being changed
 		currentClass, err := extractInstanceClass(currentInstanceType)
 		if err != nil {
-			return fmt.Errorf("error extracting current instance class: %v", err)
+			return nil, nil, fmt.Errorf("error extracting current instance class: %v", err)
 		}
 		newClass, err := extractInstanceClass(o.newMachineType)
 		if err != nil {
-			return fmt.Errorf("error extracting new instance class: %v", err)
+			return nil, nil, fmt.Errorf("error extracting new instance class: %v", err)
 		}
 		if currentClass != newClass {
-			return fmt.Errorf("cannot change instance class from %s to %s (current: %s, requested: %s). You can only resize within the same instance class", currentClass, newClass, currentInstanceType, o.newMachineType)
+			return nil, nil, fmt.Errorf("cannot change instance class from %s to %s (current: %s, requested: %s). You can only resize within the same instance class", currentClass, newClass, currentInstanceType, o.newMachineType)
 		}
 
 		awsSpec.InstanceType = o.newMachineType
 
 		rawBytes, err = json.Marshal(awsSpec)
 		if err != nil {
-			return fmt.Errorf("error marshalling AWS spec: %v", err)
+			return nil, nil, fmt.Errorf("error marshalling AWS spec: %v", err)
 		}
 	case "gcp":
 		gcpSpec := &machinev1beta1.GCPMachineProviderSpec{}
 		if err := json.Unmarshal(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value.Raw, gcpSpec); err != nil {
-			return fmt.Errorf("error unmarshalling providerSpec: %v", err)
+			return nil, nil, fmt.Errorf("error unmarshalling providerSpec: %v", err)
 		}
+		currentInstanceType = gcpSpec.MachineType
 
 		gcpSpec.MachineType = o.newMachineType
 		rawBytes, err = json.Marshal(gcpSpec)
 		if err != nil {
-			return fmt.Errorf("error marshalling GCP spec: %v", err)
+			return nil, nil, fmt.Errorf("error marshalling GCP spec: %v", err)
 		}
 	default:
-		return fmt.Errorf("cloud provider not supported: %s, only AWS and GCP are supported", o.cluster.CloudProvider().ID())
+		return nil, nil, fmt.Errorf("cloud provider not supported: %s, only AWS and GCP are supported", o.cluster.CloudProvider().ID())
 	}
 
-	log.Printf("Initiating control plane node resize for cluster %s/%s to %s using control plane machine sets. This process runs asynchronously.", o.cluster.Name(), o.cluster.ID(), o.newMachineType)
-	if !utils.ConfirmPrompt() {
-		return errors.New("aborting control plane resize")
+	if currentInstanceType == o.newMachineType {
+		return cpms, nil, nil
 	}
 
-	// Patch the ControlPlaneMachineSet
+	patch := client.MergeFrom(cpms.DeepCopy())
 	cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: rawBytes}
-	if err := o.clientAdmin.Patch(ctx, cpms, patch); err != nil {
-		return fmt.Errorf("failed patching control plane machine set: %v", err)
-	}
-
-	log.Println("Control plane machine set patched successfully. The resize is now in progress and will complete asynchronously. This command will exit after sending a service log, and any issues will be reported via PagerDuty.")
-
-	return promptGenerateResizeSL(o.clusterID, o.newMachineType)
+	return cpms, patch, nil
 }
 
 func promptGenerateResizeSL(clusterID string, newMachineType string) error {
@@ -1,7 +1,18 @@
 package resize
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/osdctl/pkg/operation"
+	"github.com/openshift/osdctl/pkg/operation/operationtest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func TestExtractInstanceClass_AWS(t *testing.T) {
@@ -92,3 +103,72 @@ func TestInstanceClassValidation_AWS(t *testing.T) {
 		})
 	}
 }
+
+func newTestControlPlaneMachineSet(t *testing.T, instanceType string) *machinev1.ControlPlaneMachineSet {
+	raw, err := json.Marshal(&machinev1beta1.AWSMachineProviderConfig{InstanceType: instanceType})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpms := &machinev1.ControlPlaneMachineSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: cpmsNamespace, Name: cpmsName},
+		Spec:       machinev1.ControlPlaneMachineSetSpec{State: machinev1.ControlPlaneMachineSetStateActive},
+	}
+	cpms.Spec.Template.OpenShiftMachineV1Beta1Machine = &machinev1.OpenShiftMachineV1Beta1MachineTemplate{}
+	cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: raw}
+	return cpms
+}
+
+func TestControlPlanePatchStep(t *testing.T) {
+	ctx := context.Background()
+	c := operationtest.NewFakeClient(t, newTestControlPlaneMachineSet(t, "m5.2xlarge"))
+	o := &controlPlane{
+		clusterID:      "cluster-1",
+		newMachineType: "m5.4xlarge",
+		cluster:        newTestCluster(t, cmv1.NewCluster().CloudProvider(cmv1.NewCloudProvider().ID("aws"))),
+		client:         c,
+		clientAdmin:    c,
+	}
+	h := operationtest.New(t)
+	state := operation.NewState("resize-control-plane", o.clusterID, nil)
+
+	// The service log step is left pending, as if the resize was interrupted once the patch is applied
+	if err := h.RunUntil(ctx, state, o.steps(), "patch-controlplanemachineset"); err != nil {
+		t.Fatalf("expected no err, got %v", err)
+	}
+	if got := h.State(o.clusterID, "resize-control-plane").Current().Name; got != "send-service-log" {
+		t.Errorf("expected the resize to stop before send-service-log, stopped before %s", got)
+	}
+
+	cpms := &machinev1.ControlPlaneMachineSet{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: cpmsNamespace, Name: cpmsName}, cpms); err != nil {
+		t.Fatal(err)
+	}
+	spec := &machinev1beta1.AWSMachineProviderConfig{}
+	if err := json.Unmarshal(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value.Raw, spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.InstanceType != "m5.4xlarge" {
+		t.Errorf("expected instance type m5.4xlarge, got %s", spec.InstanceType)
+	}
+
+	// Patching again is a no-op, so that the step can be run again when resuming
+	if err := o.patchControlPlaneMachineSet(ctx); err != nil {
+		t.Errorf("expected no err patching a resized control plane machine set, got %v", err)
+	}
+}
+
+func TestControlPlanePatchStepRejectsInactiveControlPlaneMachineSet(t *testing.T) {
+	cpms := newTestControlPlaneMachineSet(t, "m5.2xlarge")
+	cpms.Spec.State = machinev1.ControlPlaneMachineSetStateInactive
+	c := operationtest.NewFakeClient(t, cpms)
+	o := &controlPlane{
+		newMachineType: "m5.4xlarge",
+		cluster:        newTestCluster(t, cmv1.NewCluster().CloudProvider(cmv1.NewCloudProvider().ID("aws"))),
+		client:         c,
+		clientAdmin:    c,
+	}
+
+	if err := o.patchControlPlaneMachineSet(context.Background()); err == nil {
+		t.Error("expected err, got nil")
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/openshift/osdctl/cmd/servicelog"
 	infraPkg "github.com/openshift/osdctl/pkg/infra"
 	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/operation"
 	"github.com/openshift/osdctl/pkg/osdCloud"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
@@ -54,6 +55,8 @@ type Infra struct {
 
 	// hiveOcmUrl is the OCM environment URL for Hive operations
 	hiveOcmUrl string
+
+	operation operation.Options
 }
 
 func newCmdResizeInfra() *cobra.Command {
@@ -70,12 +73,18 @@ func newCmdResizeInfra() *cobra.Command {
   Remember to follow the SOP for preparation and follow up steps:
 
     https://github.com/openshift/ops-sop/blob/master/v4/howto/resize-infras-workers.md
+
+  The progress of the resize is saved after every step, an interrupted resize is continued with --resume.
 `,
 		Example: `  # Automatically vertically scale infra nodes to the next size
   osdctl cluster resize infra --cluster-id ${CLUSTER_ID} --reason "${REASON}" --justification "${JUSTIFICATION}" --ohss "${OHSS}"
 
   # Resize infra nodes to a specific instance type
-  osdctl cluster resize infra --cluster-id ${CLUSTER_ID} --instance-type "r5.xlarge" --reason "${REASON}" --justification "${JUSTIFICATION}" --ohss "${OHSS}"`,
+  osdctl cluster resize infra --cluster-id ${CLUSTER_ID} --instance-type "r5.xlarge" --reason "${REASON}" --justification "${JUSTIFICATION}" --ohss "${OHSS}"
+
+  # Show the progress of an interrupted resize, then continue it
+  osdctl cluster resize infra --cluster-id ${CLUSTER_ID} --reason "${REASON}" --justification "${JUSTIFICATION}" --ohss "${OHSS}" --resume --plan
+  osdctl cluster resize infra --cluster-id ${CLUSTER_ID} --reason "${REASON}" --justification "${JUSTIFICATION}" --ohss "${OHSS}" --resume`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return r.RunInfra(context.Background())
 		},
@@ -88,6 +97,8 @@ func newCmdResizeInfra() *cobra.Command {
 	infraResizeCmd.Flags().StringVar(&r.ohss, "ohss", "", "OHSS ticket tracking this infra node resize")
 	infraResizeCmd.Flags().StringVar(&r.hiveOcmUrl, "hive-ocm-url", "", "(optional) OCM environment URL for hive operations. Aliases: 'production', 'staging', 'integration'. If not specified, uses the same OCM environment as the target cluster.")
 
+	r.operation.AddFlags(infraResizeCmd.Flags())
+
 	_ = infraResizeCmd.MarkFlagRequired("cluster-id")
 	_ = infraResizeCmd.MarkFlagRequired("justification")
 	_ = infraResizeCmd.MarkFlagRequired("reason")
@@ -218,53 +229,84 @@ func (r *Infra) New() error {
 }
 
 func (r *Infra) RunInfra(ctx context.Context) error {
+	if err := r.operation.Validate(); err != nil {
+		return err
+	}
 	if err := r.New(); err != nil {
 		return fmt.Errorf("failed to initialize command: %v", err)
 	}
 
-	log.Printf("resizing infra nodes for %s - %s", r.cluster.Name(), r.clusterId)
-	originalMp, err := infraPkg.GetInfraMachinePool(ctx, r.hive, r.clusterId)
+	store, err := operation.NewStore()
 	if err != nil {
 		return err
 	}
-	originalInstanceType, err := getInstanceType(originalMp)
+	state, err := r.operation.Start(store, "resize-infra", r.clusterId, map[string]string{"instance-type": r.instanceType})
 	if err != nil {
-		return fmt.Errorf("failed to parse instance type from machinepool: %v", err)
+		return err
 	}
 
-	newMp, err := r.embiggenMachinePool(originalMp)
+	log.Printf("resizing infra nodes for %s - %s", r.cluster.Name(), r.clusterId)
+	steps, err := r.steps(ctx, state)
 	if err != nil {
 		return err
 	}
-	instanceType, err := getInstanceType(newMp)
+
+	return r.operation.Execute(ctx, r.operation.Runner(store), state, steps)
+}
+
+// steps returns the steps of the resize. The machinepools of the dance are computed from Hive on
+// a new run and read from the state when resuming, Hive no longer having the original machinepool
+// once it is deleted.
+func (r *Infra) steps(ctx context.Context, state *operation.State) ([]operation.Step, error) {
+	originalMp, newMp, err := infraPkg.LoadDanceMachinePools(state)
 	if err != nil {
-		return fmt.Errorf("failed to parse instance type from machinepool: %v", err)
+		return nil, err
+	}
+	if originalMp == nil {
+		originalMp, err = infraPkg.GetInfraMachinePool(ctx, r.hive, r.clusterId)
+		if err != nil {
+			return nil, err
+		}
+		newMp, err = r.embiggenMachinePool(originalMp)
+		if err != nil {
+			return nil, err
+		}
+		if err := infraPkg.SaveDanceMachinePools(state, originalMp, newMp); err != nil {
+			return nil, err
+		}
 	}
 
-	log.Printf("planning to resize to instance type from %s to %s", originalInstanceType, instanceType)
-	if !utils.ConfirmPrompt() {
-		log.Printf("exiting")
-		return nil
+	originalInstanceType, err := getInstanceType(originalMp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse instance type from machinepool: %v", err)
 	}
+	instanceType, err := getInstanceType(newMp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse instance type from machinepool: %v", err)
+	}
+	log.Printf("planning to resize to instance type from %s to %s", originalInstanceType, instanceType)
 
 	clients := infraPkg.DanceClients{
 		ClusterClient: r.client,
 		HiveClient:    r.hive,
 		HiveAdmin:     r.hiveAdmin,
 	}
-
-	if err := infraPkg.RunMachinePoolDance(ctx, clients, originalMp, newMp, r.terminateCloudInstances); err != nil {
-		return err
-	}
-
-	postCmd := generateServiceLog(newMp, r.instanceType, r.justification, r.clusterId, r.ohss)
-	if err := postCmd.Run(); err != nil {
-		fmt.Println("Failed to generate service log. Please manually send a service log to the customer for the blocked egresses with:")
-		fmt.Printf("osdctl servicelog post %v -t %v -p %v\n",
-			r.clusterId, resizedInfraNodeServiceLogTemplate, strings.Join(postCmd.TemplateParams, " -p "))
-	}
-
-	return nil
+	steps := infraPkg.MachinePoolDanceSteps(clients, originalMp, newMp, r.terminateCloudInstances)
+	return append(steps, operation.Step{
+		Name:        "send-service-log",
+		Description: "Send a service log about the resized infra nodes",
+		Run: func(ctx context.Context) error {
+			postCmd := generateServiceLog(newMp, instanceType, r.justification, r.clusterId, r.ohss)
+			if err := postCmd.Run(); err != nil {
+				fmt.Println("Failed to generate service log. Please manually send a service log to the customer for the blocked egresses with:")
+				fmt.Printf("osdctl servicelog post %v -t %v -p %v\n",
+					r.clusterId, postCmd.Template, strings.Join(postCmd.TemplateParams, " -p "))
+				return err
+			}
+			return nil
+		},
+		Skippable: true,
+	}), nil
 }
 
 func (r *Infra) embiggenMachinePool(mp *hivev1.MachinePool) (*hivev1.MachinePool, error) {
@@ -1,6 +1,7 @@
 package resize
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -8,7 +9,12 @@ import (
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	hivev1aws "github.com/openshift/hive/apis/hive/v1/aws"
 	hivev1gcp "github.com/openshift/hive/apis/hive/v1/gcp"
+	infraPkg "github.com/openshift/osdctl/pkg/infra"
+	"github.com/openshift/osdctl/pkg/operation"
+	"github.com/openshift/osdctl/pkg/operation/operationtest"
 	"github.com/openshift/osdctl/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // newTestCluster assembles a *cmv1.Cluster while handling the error to help out with inline test-case generation
@@ -279,3 +285,48 @@ func TestHiveOcmUrlValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestInfraSteps(t *testing.T) {
+	replicas := int64(2)
+	hive := operationtest.NewFakeClient(t,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "uhc-production-cluster-1", Labels: map[string]string{"api.openshift.com/id": "cluster-1"}}},
+		&hivev1.MachinePool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "uhc-production-cluster-1", Name: "cluster-infra"},
+			Spec: hivev1.MachinePoolSpec{
+				Name:     "infra",
+				Replicas: &replicas,
+				Labels:   map[string]string{"node-role.kubernetes.io/infra": ""},
+				Platform: hivev1.MachinePoolPlatform{AWS: &hivev1aws.MachinePoolPlatform{InstanceType: "r5.xlarge"}},
+			},
+		},
+	)
+	r := &Infra{
+		clusterId: "cluster-1",
+		cluster:   newTestCluster(t, cmv1.NewCluster().CloudProvider(cmv1.NewCloudProvider().ID("aws"))),
+		hive:      hive,
+	}
+	state := operation.NewState("resize-infra", "cluster-1", nil)
+
+	steps, err := r.steps(context.Background(), state)
+	if err != nil {
+		t.Fatalf("expected no err, got %v", err)
+	}
+	if len(steps) != 9 || steps[len(steps)-1].Name != "send-service-log" {
+		t.Errorf("expected the machinepool dance followed by send-service-log, got %d steps", len(steps))
+	}
+
+	originalMp, newMp, err := infraPkg.LoadDanceMachinePools(state)
+	if err != nil || originalMp == nil {
+		t.Fatalf("expected the machinepools to be recorded in the state, got %v", err)
+	}
+	if newMp.Spec.Platform.AWS.InstanceType != "r5.2xlarge" {
+		t.Errorf("expected new instance type r5.2xlarge, got %s", newMp.Spec.Platform.AWS.InstanceType)
+	}
+
+	// Resuming once the original machinepool is deleted uses the recorded machinepools
+	r.hive = operationtest.NewFakeClient(t)
+	r.instanceType = ""
+	if _, err := r.steps(context.Background(), state); err != nil {
+		t.Errorf("expected resuming without the original machinepool to succeed, got %v", err)
+	}
+}
@@ -4,15 +4,29 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/osdctl/pkg/k8s"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // ClusterSnapshot represents a point-in-time capture of cluster state
@@ -77,6 +91,11 @@ type snapshotOptions struct {
 	IncludeSecrets bool
 	Namespaces     []string
 	ResourceTypes  []string
+	Reason         string
+	NoStore        bool
+
+	client client.Client
+	store  *SnapshotStore
 }
 
 func newCmdSnapshot() *cobra.Command {
@@ -93,27 +112,43 @@ This command captures the current state of key cluster resources including:
 - ClusterOperator status
 - Custom resources (optional)
 
-The snapshot can be saved to a YAML file and later compared using 
-'osdctl cluster diff' to identify changes during feature testing.`,
-		Example: `  # Capture cluster snapshot to a file
+Snapshots are kept in a local snapshot store under the user cache directory
+(see 'osdctl cluster snapshot list') and can additionally be written to a YAML
+file. Snapshots can later be compared using 'osdctl cluster diff' to identify
+changes during feature testing, or replayed with 'osdctl cluster timeline' to
+see how the cluster evolved across an incident.`,
+		Example: `  # Capture cluster snapshot into the local snapshot store
+  osdctl cluster snapshot -C ${CLUSTER_ID}
+
+  # Capture cluster snapshot to a file
   osdctl cluster snapshot -C ${CLUSTER_ID} -o before.yaml
 
   # Capture snapshot with specific namespaces
   osdctl cluster snapshot -C ${CLUSTER_ID} -o snapshot.yaml --namespaces openshift-monitoring,openshift-operators
 
   # Capture additional resource types
-  osdctl cluster snapshot -C ${CLUSTER_ID} -o snapshot.yaml --resources pods,deployments,services`,
+  osdctl cluster snapshot -C ${CLUSTER_ID} -o snapshot.yaml --resources pods,deployments,services
+
+  # Capture resources which require elevation
+  osdctl cluster snapshot -C ${CLUSTER_ID} -o snapshot.yaml --resources secrets --reason OHSS-1234`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return opts.run()
 		},
 	}
 
+	snapshotCmd.AddCommand(newCmdSnapshotList())
+	snapshotCmd.AddCommand(newCmdSnapshotShow())
+	snapshotCmd.AddCommand(newCmdSnapshotImport())
+	snapshotCmd.AddCommand(newCmdSnapshotPrune())
+
 	snapshotCmd.Flags().StringVarP(&opts.ClusterID, "cluster-id", "C", "", "Cluster ID (internal, external, or name)")
-	snapshotCmd.Flags().StringVarP(&opts.OutputFile, "output", "o", "", "Output file path (YAML format)")
+	snapshotCmd.Flags().StringVarP(&opts.OutputFile, "output", "o", "", "Output file path (YAML format), in addition to the local snapshot store")
+	snapshotCmd.Flags().BoolVar(&opts.NoStore, "no-store", false, "Don't save the snapshot in the local snapshot store")
 	snapshotCmd.Flags().StringSliceVar(&opts.Namespaces, "namespaces", []string{}, "Specific namespaces to include (default: all openshift-* namespaces)")
 	snapshotCmd.Flags().StringSliceVar(&opts.ResourceTypes, "resources", []string{}, "Additional resource types to capture (e.g., pods,deployments)")
+	snapshotCmd.Flags().StringVar(&opts.Reason, "reason", "", "Reason for elevation (OHSS/PD/JIRA ticket); when set, resources are read as backplane-cluster-admin")
 	cmdutil.CheckErr(snapshotCmd.MarkFlagRequired("cluster-id"))
-	cmdutil.CheckErr(snapshotCmd.MarkFlagRequired("output"))
 
 	return snapshotCmd
 }
@@ -123,6 +158,10 @@ func (o *snapshotOptions) run() error {
 		return err
 	}
 
+	if o.NoStore && o.OutputFile == "" {
+		return fmt.Errorf("--no-store requires --output, the snapshot would otherwise be discarded")
+	}
+
 	connection, err := utils.CreateConnection()
 	if err != nil {
 		return fmt.Errorf("unable to create connection to OCM: %w", err)
@@ -141,8 +180,6 @@ func (o *snapshotOptions) run() error {
 	}
 	fmt.Printf("[INFO] Creating snapshot for cluster: %s (%s) - %s\n", cluster.Name(), cluster.ID(), clusterType)
 
-	captureErrors := make(map[string]string)
-
 	snapshot := &ClusterSnapshot{
 		Metadata: SnapshotMetadata{
 			ClusterID:   cluster.ID(),
@@ -159,48 +196,15 @@ func (o *snapshotOptions) run() error {
 		fmt.Println("[INFO] HCP cluster detected - note that only worker nodes will be visible")
 	}
 
-	// Capture nodes
-	fmt.Println("[INFO] Capturing node states...")
-	nodes, err := o.captureNodes()
-	if err != nil {
-		fmt.Printf("[WARN] Failed to capture nodes: %v\n", err)
-		captureErrors["nodes"] = err.Error()
-	} else {
-		snapshot.Nodes = nodes
-	}
-
-	// Capture namespaces
-	fmt.Println("[INFO] Capturing namespace states...")
-	namespaces, err := o.captureNamespaces()
-	if err != nil {
-		fmt.Printf("[WARN] Failed to capture namespaces: %v\n", err)
-		captureErrors["namespaces"] = err.Error()
-	} else {
-		snapshot.Namespaces = namespaces
-	}
-
-	// Capture cluster operators
-	fmt.Println("[INFO] Capturing ClusterOperator states...")
-	operators, err := o.captureClusterOperators()
-	if err != nil {
-		fmt.Printf("[WARN] Failed to capture cluster operators: %v\n", err)
-		captureErrors["operators"] = err.Error()
-	} else {
-		snapshot.Operators = operators
-	}
-
-	// Capture additional resources if specified
-	for _, resourceType := range o.ResourceTypes {
-		fmt.Printf("[INFO] Capturing %s...\n", resourceType)
-		resources, err := o.captureResources(resourceType)
+	if o.client == nil {
+		o.client, err = o.newClient(cluster.ID())
 		if err != nil {
-			fmt.Printf("[WARN] Failed to capture %s: %v\n", resourceType, err)
-			captureErrors[resourceType] = err.Error()
-			continue
+			return fmt.Errorf("unable to create client for cluster %s: %w", cluster.ID(), err)
 		}
-		snapshot.Resources[resourceType] = resources
 	}
 
+	captureErrors := o.capture(context.Background(), snapshot)
+
 	// Store capture errors in metadata
 	if len(captureErrors) > 0 {
 		snapshot.Metadata.CaptureErrors = captureErrors
@@ -213,63 +217,174 @@ func (o *snapshotOptions) run() error {
 		}
 	}
 
+	// Save snapshot in the local store
+	if !o.NoStore {
+		if o.store == nil {
+			if o.store, err = NewSnapshotStore(); err != nil {
+				return fmt.Errorf("failed to open snapshot store: %w", err)
+			}
+		}
+		stored, err := o.store.Save(snapshot)
+		if err != nil {
+			return fmt.Errorf("failed to store snapshot: %w", err)
+		}
+		fmt.Printf("[INFO] Snapshot %s stored in: %s\n", stored.ID, stored.Path)
+	}
+
 	// Write snapshot to file
-	if err := o.writeSnapshot(snapshot); err != nil {
-		return fmt.Errorf("failed to write snapshot: %w", err)
+	if o.OutputFile != "" {
+		if err := o.writeSnapshot(snapshot); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		fmt.Printf("[INFO] Snapshot saved to: %s\n", o.OutputFile)
 	}
 
-	fmt.Printf("[INFO] Snapshot saved to: %s\n", o.OutputFile)
 	return nil
 }
 
-func (o *snapshotOptions) captureNodes() ([]NodeSnapshot, error) {
-	output, err := exec.CommandContext(context.TODO(), "oc", "get", "nodes", "-o", "json").CombinedOutput()
+// newClient builds a backplane client for the cluster, elevated to backplane-cluster-admin when a
+// reason is provided so that resource types the default SRE role can't list are still captured.
+func (o *snapshotOptions) newClient(clusterID string) (client.Client, error) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := configv1.Install(scheme); err != nil {
+		return nil, err
+	}
+
+	var (
+		cfg *rest.Config
+		err error
+	)
+	if o.Reason == "" {
+		cfg, err = k8s.NewRestConfig(clusterID)
+	} else {
+		cfg, err = k8s.NewRestConfigAsBackplaneClusterAdmin(clusterID, []string{
+			o.Reason,
+			fmt.Sprintf("Capturing cluster snapshot for cluster %s", clusterID),
+		}...)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("oc get nodes failed: %w: %s", err, strings.TrimSpace(string(output)))
-	}
-
-	var result struct {
-		Items []struct {
-			Metadata struct {
-				Name   string            `json:"name"`
-				Labels map[string]string `json:"labels"`
-			} `json:"metadata"`
-			Status struct {
-				Conditions []struct {
-					Type   string `json:"type"`
-					Status string `json:"status"`
-				} `json:"conditions"`
-				NodeInfo struct {
-					KubeletVersion string `json:"kubeletVersion"`
-				} `json:"nodeInfo"`
-			} `json:"status"`
-		} `json:"items"`
-	}
-
-	if err := yaml.Unmarshal(output, &result); err != nil {
 		return nil, err
 	}
 
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfg, client.Options{Scheme: scheme, Mapper: newSnapshotRESTMapper(dc)})
+}
+
+// newSnapshotRESTMapper returns a RESTMapper discovering the resource types of the cluster, which
+// also expands their short names (e.g. "po", "deploy") like 'oc get' does
+func newSnapshotRESTMapper(dc discovery.DiscoveryInterface) meta.RESTMapper {
+	cached := memory.NewMemCacheClient(dc)
+	return restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(cached), cached, nil)
+}
+
+// capture collects every section of the snapshot concurrently, one goroutine per resource type.
+// Failures are not fatal: they are returned keyed by section so they can be recorded in the
+// snapshot metadata and surfaced by 'osdctl cluster diff'.
+func (o *snapshotOptions) capture(ctx context.Context, snapshot *ClusterSnapshot) map[string]string {
+	var (
+		wg            sync.WaitGroup
+		mu            sync.Mutex
+		captureErrors = make(map[string]string)
+	)
+
+	collect := func(section string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fmt.Printf("[INFO] Capturing %s...\n", section)
+			if err := fn(); err != nil {
+				fmt.Printf("[WARN] Failed to capture %s: %v\n", section, err)
+				mu.Lock()
+				captureErrors[section] = err.Error()
+				mu.Unlock()
+			}
+		}()
+	}
+
+	collect("nodes", func() error {
+		nodes, err := o.captureNodes(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		snapshot.Nodes = nodes
+		mu.Unlock()
+		return nil
+	})
+
+	collect("namespaces", func() error {
+		namespaces, err := o.captureNamespaces(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		snapshot.Namespaces = namespaces
+		mu.Unlock()
+		return nil
+	})
+
+	collect("operators", func() error {
+		operators, err := o.captureClusterOperators(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		snapshot.Operators = operators
+		mu.Unlock()
+		return nil
+	})
+
+	for _, resourceType := range o.ResourceTypes {
+		collect(resourceType, func() error {
+			resources, err := o.captureResources(ctx, resourceType)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			snapshot.Resources[resourceType] = resources
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	wg.Wait()
+	return captureErrors
+}
+
+func (o *snapshotOptions) captureNodes(ctx context.Context) ([]NodeSnapshot, error) {
+	nodeList := &corev1.NodeList{}
+	if err := o.client.List(ctx, nodeList); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
 	var nodes []NodeSnapshot
-	for _, item := range result.Items {
+	for _, item := range nodeList.Items {
 		node := NodeSnapshot{
-			Name:    item.Metadata.Name,
+			Name:    item.Name,
 			Version: item.Status.NodeInfo.KubeletVersion,
-			Labels:  item.Metadata.Labels,
+			Labels:  item.Labels,
 		}
 
 		// Extract roles from labels
-		for label := range item.Metadata.Labels {
+		for label := range item.Labels {
 			if strings.HasPrefix(label, "node-role.kubernetes.io/") {
 				role := strings.TrimPrefix(label, "node-role.kubernetes.io/")
 				node.Roles = append(node.Roles, role)
 			}
 		}
+		// Label iteration order is random, keep roles stable between snapshots
+		sort.Strings(node.Roles)
 
 		// Check node conditions
 		for _, cond := range item.Status.Conditions {
-			if cond.Type == "Ready" {
-				if cond.Status == "True" {
+			if cond.Type == corev1.NodeReady {
+				if cond.Status == corev1.ConditionTrue {
 					node.Status = "Ready"
 				} else {
 					node.Status = "NotReady"
@@ -284,103 +399,54 @@ func (o *snapshotOptions) captureNodes() ([]NodeSnapshot, error) {
 	return nodes, nil
 }
 
-func (o *snapshotOptions) captureNamespaces() ([]NamespaceSnapshot, error) {
-	args := []string{"get", "namespaces", "-o", "json"}
-
-	output, err := exec.CommandContext(context.TODO(), "oc", args...).CombinedOutput() //#nosec G204 -- args are constructed from trusted input
-	if err != nil {
-		return nil, fmt.Errorf("oc get namespaces failed: %w: %s", err, strings.TrimSpace(string(output)))
-	}
-
-	var result struct {
-		Items []struct {
-			Metadata struct {
-				Name   string            `json:"name"`
-				Labels map[string]string `json:"labels"`
-			} `json:"metadata"`
-			Status struct {
-				Phase string `json:"phase"`
-			} `json:"status"`
-		} `json:"items"`
-	}
-
-	if err := yaml.Unmarshal(output, &result); err != nil {
-		return nil, err
+func (o *snapshotOptions) captureNamespaces(ctx context.Context) ([]NamespaceSnapshot, error) {
+	nsList := &corev1.NamespaceList{}
+	if err := o.client.List(ctx, nsList); err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
 	}
 
 	var namespaces []NamespaceSnapshot
-	for _, item := range result.Items {
+	for _, item := range nsList.Items {
 		// Filter to openshift-* namespaces if no specific namespaces provided
 		if len(o.Namespaces) == 0 {
-			if !strings.HasPrefix(item.Metadata.Name, "openshift-") {
-				continue
-			}
-		} else {
-			found := false
-			for _, ns := range o.Namespaces {
-				if item.Metadata.Name == ns {
-					found = true
-					break
-				}
-			}
-			if !found {
+			if !strings.HasPrefix(item.Name, "openshift-") {
 				continue
 			}
+		} else if !slices.Contains(o.Namespaces, item.Name) {
+			continue
 		}
 
 		namespaces = append(namespaces, NamespaceSnapshot{
-			Name:   item.Metadata.Name,
-			Status: item.Status.Phase,
-			Labels: item.Metadata.Labels,
+			Name:   item.Name,
+			Status: string(item.Status.Phase),
+			Labels: item.Labels,
 		})
 	}
 
 	return namespaces, nil
 }
 
-func (o *snapshotOptions) captureClusterOperators() ([]OperatorSnapshot, error) {
-	output, err := exec.CommandContext(context.TODO(), "oc", "get", "clusteroperators", "-o", "json").CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("oc get clusteroperators failed: %w: %s", err, strings.TrimSpace(string(output)))
-	}
-
-	var result struct {
-		Items []struct {
-			Metadata struct {
-				Name string `json:"name"`
-			} `json:"metadata"`
-			Status struct {
-				Conditions []struct {
-					Type   string `json:"type"`
-					Status string `json:"status"`
-				} `json:"conditions"`
-				Versions []struct {
-					Name    string `json:"name"`
-					Version string `json:"version"`
-				} `json:"versions"`
-			} `json:"status"`
-		} `json:"items"`
-	}
-
-	if err := yaml.Unmarshal(output, &result); err != nil {
-		return nil, err
+func (o *snapshotOptions) captureClusterOperators(ctx context.Context) ([]OperatorSnapshot, error) {
+	coList := &configv1.ClusterOperatorList{}
+	if err := o.client.List(ctx, coList); err != nil {
+		return nil, fmt.Errorf("failed to list clusteroperators: %w", err)
 	}
 
 	var operators []OperatorSnapshot
-	for _, item := range result.Items {
+	for _, item := range coList.Items {
 		operator := OperatorSnapshot{
-			Name: item.Metadata.Name,
+			Name: item.Name,
 		}
 
 		for _, cond := range item.Status.Conditions {
 			operator.Conditions = append(operator.Conditions, fmt.Sprintf("%s=%s", cond.Type, cond.Status))
 			switch cond.Type {
-			case "Available":
-				operator.Available = cond.Status == "True"
-			case "Progressing":
-				operator.Progressing = cond.Status == "True"
-			case "Degraded":
-				operator.Degraded = cond.Status == "True"
+			case configv1.OperatorAvailable:
+				operator.Available = cond.Status == configv1.ConditionTrue
+			case configv1.OperatorProgressing:
+				operator.Progressing = cond.Status == configv1.ConditionTrue
+			case configv1.OperatorDegraded:
+				operator.Degraded = cond.Status == configv1.ConditionTrue
 			}
 		}
 
@@ -397,35 +463,29 @@ func (o *snapshotOptions) captureClusterOperators() ([]OperatorSnapshot, error)
 	return operators, nil
 }
 
-func (o *snapshotOptions) captureResources(resourceType string) ([]ResourceInfo, error) {
-	output, err := exec.CommandContext(context.TODO(), "oc", "get", resourceType, "--all-namespaces", "-o", "json").CombinedOutput() //#nosec G204 -- resourceType is user-provided but filtered
+// captureResources lists an arbitrary resource type across all namespaces. The resource type is
+// resolved through the client's RESTMapper, so it accepts the same forms as 'oc get'
+// (e.g. "pods", "po", "deployments.apps").
+func (o *snapshotOptions) captureResources(ctx context.Context, resourceType string) ([]ResourceInfo, error) {
+	gvk, err := o.client.RESTMapper().KindFor(schema.ParseGroupResource(resourceType).WithVersion(""))
 	if err != nil {
-		return nil, fmt.Errorf("oc get %s failed: %w: %s", resourceType, err, strings.TrimSpace(string(output)))
+		return nil, fmt.Errorf("unable to resolve resource type %q: %w", resourceType, err)
 	}
 
-	var result struct {
-		Items []struct {
-			Metadata struct {
-				Name      string `json:"name"`
-				Namespace string `json:"namespace"`
-			} `json:"metadata"`
-			Status struct {
-				Phase string `json:"phase"`
-			} `json:"status"`
-		} `json:"items"`
-	}
-
-	if err := yaml.Unmarshal(output, &result); err != nil {
-		return nil, err
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := o.client.List(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", resourceType, err)
 	}
 
 	var resources []ResourceInfo
-	for _, item := range result.Items {
+	for _, item := range list.Items {
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
 		resources = append(resources, ResourceInfo{
-			Name:      item.Metadata.Name,
-			Namespace: item.Metadata.Namespace,
+			Name:      item.GetName(),
+			Namespace: item.GetNamespace(),
 			Kind:      resourceType,
-			Status:    item.Status.Phase,
+			Status:    phase,
 		})
 	}
 
@@ -0,0 +1,243 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func newCmdSnapshotList() *cobra.Command {
+	var clusterKey string
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List snapshots in the local snapshot store",
+		Example: `  # List snapshots of all clusters
+  osdctl cluster snapshot list
+
+  # List snapshots of a single cluster
+  osdctl cluster snapshot list -C ${CLUSTER_ID}`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := NewSnapshotStore()
+			if err != nil {
+				return err
+			}
+			return listSnapshots(store, clusterKey)
+		},
+	}
+
+	listCmd.Flags().StringVarP(&clusterKey, "cluster-id", "C", "", "Only list snapshots of this cluster (internal ID or name)")
+
+	return listCmd
+}
+
+func listSnapshots(store *SnapshotStore, clusterKey string) error {
+	clusters, err := store.Clusters()
+	if err != nil {
+		return err
+	}
+	if clusterKey != "" {
+		clusterID, err := store.ResolveCluster(clusterKey)
+		if err != nil {
+			return err
+		}
+		clusters = []string{clusterID}
+	}
+
+	p := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	p.AddRow([]string{"CLUSTER ID", "NAME", "SNAPSHOT", "TIMESTAMP", "VERSION", "NODES", "OPERATORS", "ERRORS"})
+	for _, clusterID := range clusters {
+		snapshots, err := store.List(clusterID)
+		if err != nil {
+			return err
+		}
+		for _, stored := range snapshots {
+			snapshot, err := store.Load(stored)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[WARN] Failed to load snapshot %s: %v\n", stored.Path, err)
+				continue
+			}
+			readyNodes := 0
+			for _, node := range snapshot.Nodes {
+				if node.Status == "Ready" {
+					readyNodes++
+				}
+			}
+			degradedOperators := 0
+			for _, op := range snapshot.Operators {
+				if op.Degraded || !op.Available {
+					degradedOperators++
+				}
+			}
+			p.AddRow([]string{
+				clusterID,
+				snapshot.Metadata.ClusterName,
+				stored.ID,
+				stored.Timestamp.Format(time.RFC3339),
+				snapshot.Metadata.Version,
+				fmt.Sprintf("%d/%d ready", readyNodes, len(snapshot.Nodes)),
+				fmt.Sprintf("%d/%d unhealthy", degradedOperators, len(snapshot.Operators)),
+				strconv.Itoa(len(snapshot.Metadata.CaptureErrors)),
+			})
+		}
+	}
+
+	return p.Flush()
+}
+
+func newCmdSnapshotShow() *cobra.Command {
+	var clusterKey string
+
+	showCmd := &cobra.Command{
+		Use:   "show [snapshot]",
+		Short: "Print a stored snapshot as YAML",
+		Long: `Print a stored snapshot as YAML.
+
+The snapshot is referenced by its ID as shown by 'osdctl cluster snapshot list'
+(any unique prefix is accepted), 'latest' (the default) or 'latest~N' for the
+Nth snapshot before the latest one. The output is a regular snapshot file which
+can be shared and passed to 'osdctl cluster diff' or 'osdctl cluster snapshot import'.`,
+		Example: `  # Show the latest snapshot of a cluster
+  osdctl cluster snapshot show -C ${CLUSTER_ID}
+
+  # Export a snapshot to share it
+  osdctl cluster snapshot show -C ${CLUSTER_ID} 20250101T120000Z > snapshot.yaml`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := NewSnapshotStore()
+			if err != nil {
+				return err
+			}
+			ref := "latest"
+			if len(args) == 1 {
+				ref = args[0]
+			}
+			stored, err := resolveStoredSnapshot(store, clusterKey, ref)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(stored.Path)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+
+	showCmd.Flags().StringVarP(&clusterKey, "cluster-id", "C", "", "Cluster ID (internal ID or name)")
+	cmdutil.CheckErr(showCmd.MarkFlagRequired("cluster-id"))
+
+	return showCmd
+}
+
+func newCmdSnapshotImport() *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import <snapshot.yaml>...",
+		Short: "Add snapshot files to the local snapshot store",
+		Example: `  # Import a snapshot shared by a colleague
+  osdctl cluster snapshot import before.yaml`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := NewSnapshotStore()
+			if err != nil {
+				return err
+			}
+			for _, filename := range args {
+				stored, err := store.Import(filename)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("[INFO] Imported %s as snapshot %s of cluster %s\n", filename, stored.ID, stored.ClusterID)
+			}
+			return nil
+		},
+	}
+
+	return importCmd
+}
+
+func newCmdSnapshotPrune() *cobra.Command {
+	var (
+		clusterKey string
+		all        bool
+		keep       int
+		olderThan  time.Duration
+	)
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove old snapshots from the local snapshot store",
+		Example: `  # Keep only the 10 most recent snapshots of a cluster
+  osdctl cluster snapshot prune -C ${CLUSTER_ID} --keep 10
+
+  # Remove snapshots older than 30 days for every cluster
+  osdctl cluster snapshot prune --all --older-than 720h`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (clusterKey == "") == !all {
+				return fmt.Errorf("exactly one of --cluster-id or --all must be specified")
+			}
+			if keep <= 0 && olderThan <= 0 {
+				return fmt.Errorf("at least one of --keep or --older-than must be specified")
+			}
+
+			store, err := NewSnapshotStore()
+			if err != nil {
+				return err
+			}
+			return pruneSnapshots(store, clusterKey, keep, olderThan, time.Now().UTC())
+		},
+	}
+
+	pruneCmd.Flags().StringVarP(&clusterKey, "cluster-id", "C", "", "Cluster ID (internal ID or name)")
+	pruneCmd.Flags().BoolVar(&all, "all", false, "Prune snapshots of every cluster in the store")
+	pruneCmd.Flags().IntVar(&keep, "keep", 0, "Number of most recent snapshots to keep per cluster")
+	pruneCmd.Flags().DurationVar(&olderThan, "older-than", 0, "Only remove snapshots older than this duration (e.g. 168h)")
+
+	return pruneCmd
+}
+
+func pruneSnapshots(store *SnapshotStore, clusterKey string, keep int, olderThan time.Duration, now time.Time) error {
+	clusters, err := store.Clusters()
+	if err != nil {
+		return err
+	}
+	if clusterKey != "" {
+		clusterID, err := store.ResolveCluster(clusterKey)
+		if err != nil {
+			return err
+		}
+		clusters = []string{clusterID}
+	}
+
+	total := 0
+	for _, clusterID := range clusters {
+		pruned, err := store.Prune(clusterID, keep, olderThan, now)
+		for _, stored := range pruned {
+			fmt.Printf("[INFO] Removed snapshot %s of cluster %s\n", stored.ID, clusterID)
+		}
+		total += len(pruned)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("[INFO] Removed %d snapshot(s)\n", total)
+
+	return nil
+}
+
+// resolveStoredSnapshot resolves a cluster key and a snapshot reference against the store
+func resolveStoredSnapshot(store *SnapshotStore, clusterKey, ref string) (*StoredSnapshot, error) {
+	clusterID, err := store.ResolveCluster(clusterKey)
+	if err != nil {
+		return nil, err
+	}
+	return store.Resolve(clusterID, ref)
+}
@@ -0,0 +1,342 @@
+package cluster
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// snapshotIDFormat is the timestamp layout used to name stored snapshots. It sorts lexically in
+// chronological order and is safe to use as a file name on every platform. Different snapshots
+// taken within the same second get a "-N" suffix, see SnapshotStore.Save.
+const snapshotIDFormat = "20060102T150405Z"
+
+// ErrSnapshotNotFound is returned when a snapshot reference can't be resolved in the store
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// SnapshotStore persists cluster snapshots in the user cache directory, next to the cloudtrail
+// cache, keyed by cluster ID and capture timestamp:
+//
+//	<cache dir>/osdctl/cluster/snapshots/<cluster id>/<timestamp>.yaml
+//
+// Stored snapshots are plain 'osdctl cluster snapshot' YAML files, so they can be shared with
+// 'snapshot show' and added to another store with 'snapshot import'.
+type SnapshotStore struct {
+	dir string
+}
+
+// StoredSnapshot describes a snapshot held in the store
+type StoredSnapshot struct {
+	ClusterID string    `yaml:"clusterId" json:"clusterId"`
+	ID        string    `yaml:"id" json:"id"`
+	Timestamp time.Time `yaml:"timestamp" json:"timestamp"`
+	Path      string    `yaml:"path" json:"path"`
+	Size      int64     `yaml:"size" json:"size"`
+}
+
+// NewSnapshotStore returns the store located in the user cache directory
+func NewSnapshotStore() (*SnapshotStore, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return newSnapshotStoreAt(filepath.Join(cacheDir, "osdctl", "cluster", "snapshots")), nil
+}
+
+func newSnapshotStoreAt(dir string) *SnapshotStore {
+	return &SnapshotStore{dir: dir}
+}
+
+// Save writes a snapshot to the store. Saving a snapshot identical to one already stored with the
+// same cluster ID and timestamp returns the stored one, which makes importing the same file twice
+// idempotent. A different snapshot with the same timestamp second is stored with a "-N" suffix.
+func (s *SnapshotStore) Save(snapshot *ClusterSnapshot) (*StoredSnapshot, error) {
+	clusterID := snapshot.Metadata.ClusterID
+	if clusterID == "" {
+		return nil, fmt.Errorf("snapshot has no cluster ID")
+	}
+	// The cluster ID names a directory of the store and comes from imported files, make sure it
+	// can't point outside of the store
+	if err := utils.IsValidClusterKey(clusterID); err != nil || filepath.Base(clusterID) != clusterID {
+		return nil, fmt.Errorf("snapshot has an invalid cluster ID %q", clusterID)
+	}
+	if snapshot.Metadata.Timestamp.IsZero() {
+		return nil, fmt.Errorf("snapshot has no timestamp")
+	}
+
+	clusterDir := filepath.Join(s.dir, clusterID)
+	if err := os.MkdirAll(clusterDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot store directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	timestamp := snapshot.Metadata.Timestamp.UTC().Truncate(time.Second)
+	baseID := timestamp.Format(snapshotIDFormat)
+	for seq := 0; ; seq++ {
+		id := baseID
+		if seq > 0 {
+			id = fmt.Sprintf("%s-%d", baseID, seq)
+		}
+		path := filepath.Join(clusterDir, id+".yaml")
+		stored := &StoredSnapshot{
+			ClusterID: clusterID,
+			ID:        id,
+			Timestamp: timestamp,
+			Path:      path,
+			Size:      int64(len(data)),
+		}
+
+		existing, err := os.ReadFile(path)
+		if err == nil {
+			if bytes.Equal(existing, data) {
+				return stored, nil
+			}
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read snapshot %s: %w", id, err)
+		}
+
+		// O_EXCL so a concurrent capture of the same second can't be overwritten
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+			return nil, fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		return stored, nil
+	}
+}
+
+// Import adds a snapshot file, e.g. one shared by another engineer, to the store
+func (s *SnapshotStore) Import(filename string) (*StoredSnapshot, error) {
+	snapshot, err := loadSnapshot(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot %s: %w", filename, err)
+	}
+	return s.Save(snapshot)
+}
+
+// Clusters returns the IDs of all clusters with stored snapshots
+func (s *SnapshotStore) Clusters() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			clusters = append(clusters, entry.Name())
+		}
+	}
+	sort.Strings(clusters)
+	return clusters, nil
+}
+
+// List returns the snapshots stored for a cluster, oldest first
+func (s *SnapshotStore) List(clusterID string) ([]StoredSnapshot, error) {
+	clusterDir := filepath.Join(s.dir, clusterID)
+	entries, err := os.ReadDir(clusterDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []StoredSnapshot
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if entry.IsDir() || !ok {
+			continue
+		}
+		timestamp, _, err := parseSnapshotID(id)
+		if err != nil {
+			// Not written by the store, leave it alone
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, StoredSnapshot{
+			ClusterID: clusterID,
+			ID:        id,
+			Timestamp: timestamp,
+			Path:      filepath.Join(clusterDir, entry.Name()),
+			Size:      info.Size(),
+		})
+	}
+
+	// Snapshots of the same second are ordered by their suffix, the order they were saved in
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].Timestamp.Equal(snapshots[j].Timestamp) {
+			return snapshots[i].Timestamp.Before(snapshots[j].Timestamp)
+		}
+		_, seqI, _ := parseSnapshotID(snapshots[i].ID)
+		_, seqJ, _ := parseSnapshotID(snapshots[j].ID)
+		return seqI < seqJ
+	})
+	return snapshots, nil
+}
+
+// parseSnapshotID returns the timestamp and the collision suffix (0 when absent) of a snapshot ID
+func parseSnapshotID(id string) (time.Time, int, error) {
+	base, suffix, hasSuffix := strings.Cut(id, "-")
+	timestamp, err := time.Parse(snapshotIDFormat, base)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	if !hasSuffix {
+		return timestamp, 0, nil
+	}
+	seq, err := strconv.Atoi(suffix)
+	if err != nil || seq <= 0 {
+		return time.Time{}, 0, fmt.Errorf("invalid snapshot ID %q", id)
+	}
+	return timestamp, seq, nil
+}
+
+// ResolveCluster maps a cluster key to the cluster ID used in the store. Besides the internal
+// cluster ID, the cluster name recorded in the most recent snapshot is accepted so the store can
+// be used without an OCM connection.
+func (s *SnapshotStore) ResolveCluster(key string) (string, error) {
+	clusters, err := s.Clusters()
+	if err != nil {
+		return "", err
+	}
+
+	for _, clusterID := range clusters {
+		if clusterID == key {
+			return clusterID, nil
+		}
+	}
+
+	for _, clusterID := range clusters {
+		snapshots, err := s.List(clusterID)
+		if err != nil || len(snapshots) == 0 {
+			continue
+		}
+		latest, err := loadSnapshot(snapshots[len(snapshots)-1].Path)
+		if err != nil {
+			continue
+		}
+		if latest.Metadata.ClusterName == key {
+			return clusterID, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: no snapshots stored for cluster %q", ErrSnapshotNotFound, key)
+}
+
+// Resolve finds a stored snapshot of a cluster by reference. A reference is either a snapshot ID
+// (as shown by 'snapshot list', any unique prefix is accepted), "latest", or "latest~N" for the
+// Nth snapshot before the latest one.
+func (s *SnapshotStore) Resolve(clusterID, ref string) (*StoredSnapshot, error) {
+	snapshots, err := s.List(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("%w: no snapshots stored for cluster %s", ErrSnapshotNotFound, clusterID)
+	}
+
+	if ref == "" || ref == "latest" || strings.HasPrefix(ref, "latest~") {
+		offset := 0
+		if n, ok := strings.CutPrefix(ref, "latest~"); ok {
+			offset, err = strconv.Atoi(n)
+			if err != nil || offset < 0 {
+				return nil, fmt.Errorf("invalid snapshot reference %q", ref)
+			}
+		}
+		idx := len(snapshots) - 1 - offset
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: %s, only %d snapshots stored for cluster %s", ErrSnapshotNotFound, ref, len(snapshots), clusterID)
+		}
+		return &snapshots[idx], nil
+	}
+
+	var matches []StoredSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.ID == ref {
+			return &snapshot, nil
+		}
+		if strings.HasPrefix(snapshot.ID, ref) {
+			matches = append(matches, snapshot)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s for cluster %s", ErrSnapshotNotFound, ref, clusterID)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("snapshot reference %q is ambiguous, it matches %d snapshots", ref, len(matches))
+	}
+}
+
+// Load reads a stored snapshot
+func (s *SnapshotStore) Load(stored StoredSnapshot) (*ClusterSnapshot, error) {
+	return loadSnapshot(stored.Path)
+}
+
+// Prune removes snapshots of a cluster which are older than olderThan, while always keeping the
+// newest keep snapshots. A zero olderThan only applies the keep limit. The removed snapshots are
+// returned.
+func (s *SnapshotStore) Prune(clusterID string, keep int, olderThan time.Duration, now time.Time) ([]StoredSnapshot, error) {
+	snapshots, err := s.List(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []StoredSnapshot
+	for i, snapshot := range snapshots {
+		newerCount := len(snapshots) - 1 - i
+		if keep > 0 && newerCount < keep {
+			break
+		}
+		if olderThan > 0 && now.Sub(snapshot.Timestamp) < olderThan {
+			continue
+		}
+		if keep <= 0 && olderThan <= 0 {
+			break
+		}
+		if err := os.Remove(snapshot.Path); err != nil {
+			return pruned, fmt.Errorf("failed to remove snapshot %s: %w", snapshot.ID, err)
+		}
+		pruned = append(pruned, snapshot)
+	}
+
+	// Don't leave empty cluster directories behind
+	if remaining, err := s.List(clusterID); err == nil && len(remaining) == 0 {
+		_ = os.Remove(filepath.Join(s.dir, clusterID))
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,206 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStoreTestSnapshot(clusterID string, ts time.Time, nodeStatus string) *ClusterSnapshot {
+	return &ClusterSnapshot{
+		Metadata: SnapshotMetadata{ClusterID: clusterID, ClusterName: clusterID + "-name", Timestamp: ts, Version: "4.18.1"},
+		Nodes: []NodeSnapshot{
+			{Name: "worker-0", Status: nodeStatus, Version: "v1.31.0"},
+		},
+		Operators: []OperatorSnapshot{
+			{Name: "dns", Available: true, Version: "4.18.1"},
+		},
+	}
+}
+
+func populateStore(t *testing.T, store *SnapshotStore, clusterID string, base time.Time, statuses ...string) []StoredSnapshot {
+	var stored []StoredSnapshot
+	for i, status := range statuses {
+		s, err := store.Save(newStoreTestSnapshot(clusterID, base.Add(time.Duration(i)*time.Hour), status))
+		require.NoError(t, err)
+		stored = append(stored, *s)
+	}
+	return stored
+}
+
+func TestSnapshotStoreSaveAndList(t *testing.T) {
+	store := newSnapshotStoreAt(t.TempDir())
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Saved out of order, listed oldest first
+	_, err := store.Save(newStoreTestSnapshot("abc", base.Add(time.Hour), "Ready"))
+	require.NoError(t, err)
+	first, err := store.Save(newStoreTestSnapshot("abc", base, "Ready"))
+	require.NoError(t, err)
+	assert.Equal(t, "20250101T120000Z", first.ID)
+	_, err = store.Save(newStoreTestSnapshot("def", base, "Ready"))
+	require.NoError(t, err)
+
+	clusters, err := store.Clusters()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc", "def"}, clusters)
+
+	snapshots, err := store.List("abc")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "20250101T120000Z", snapshots[0].ID)
+	assert.Equal(t, "20250101T130000Z", snapshots[1].ID)
+
+	loaded, err := store.Load(snapshots[0])
+	require.NoError(t, err)
+	assert.Equal(t, "abc", loaded.Metadata.ClusterID)
+
+	_, err = store.Save(&ClusterSnapshot{})
+	assert.Error(t, err)
+}
+
+func TestSnapshotStoreSaveInvalidClusterID(t *testing.T) {
+	dir := t.TempDir()
+	store := newSnapshotStoreAt(filepath.Join(dir, "store"))
+	ts := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, clusterID := range []string{"..", "../outside", "abc/def", "/tmp/abc", `abc\def`, "abc def"} {
+		t.Run(clusterID, func(t *testing.T) {
+			_, err := store.Save(newStoreTestSnapshot(clusterID, ts, "Ready"))
+			assert.Error(t, err)
+		})
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 0, "nothing is written for an invalid cluster ID")
+}
+
+func TestSnapshotStoreSaveSameSecond(t *testing.T) {
+	store := newSnapshotStoreAt(t.TempDir())
+	ts := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	first, err := store.Save(newStoreTestSnapshot("abc", ts, "Ready"))
+	require.NoError(t, err)
+	second, err := store.Save(newStoreTestSnapshot("abc", ts.Add(200*time.Millisecond), "NotReady"))
+	require.NoError(t, err)
+	third, err := store.Save(newStoreTestSnapshot("abc", ts.Add(400*time.Millisecond), "Ready"))
+	require.NoError(t, err)
+	assert.Equal(t, "20250101T120000Z", first.ID)
+	assert.Equal(t, "20250101T120000Z-1", second.ID)
+	assert.Equal(t, "20250101T120000Z-2", third.ID)
+
+	// Saving an identical snapshot again doesn't add a copy
+	again, err := store.Save(newStoreTestSnapshot("abc", ts.Add(200*time.Millisecond), "NotReady"))
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, again.ID)
+
+	snapshots, err := store.List("abc")
+	require.NoError(t, err)
+	assert.Equal(t, []StoredSnapshot{*first, *second, *third}, snapshots)
+
+	latest, err := store.Resolve("abc", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, third.ID, latest.ID)
+	exact, err := store.Resolve("abc", "20250101T120000Z")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, exact.ID)
+}
+
+func TestSnapshotStoreResolve(t *testing.T) {
+	store := newSnapshotStoreAt(t.TempDir())
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	populateStore(t, store, "abc", base, "Ready", "Ready", "NotReady")
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "latest", want: "20250101T140000Z"},
+		{ref: "", want: "20250101T140000Z"},
+		{ref: "latest~2", want: "20250101T120000Z"},
+		{ref: "latest~3", wantErr: true},
+		{ref: "latest~x", wantErr: true},
+		{ref: "20250101T130000Z", want: "20250101T130000Z"},
+		{ref: "20250101T13", want: "20250101T130000Z"},
+		{ref: "20250101T1", wantErr: true},
+		{ref: "20240101", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			stored, err := store.Resolve("abc", tt.ref)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stored.ID)
+		})
+	}
+
+	clusterID, err := store.ResolveCluster("abc-name")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", clusterID)
+
+	_, err = store.ResolveCluster("unknown")
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+}
+
+func TestSnapshotStoreImport(t *testing.T) {
+	dir := t.TempDir()
+	store := newSnapshotStoreAt(filepath.Join(dir, "store"))
+
+	file := filepath.Join(dir, "shared.yaml")
+	snapshot := newStoreTestSnapshot("abc", time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), "Ready")
+	require.NoError(t, (&snapshotOptions{OutputFile: file}).writeSnapshot(snapshot))
+
+	// Importing twice is idempotent
+	for i := 0; i < 2; i++ {
+		stored, err := store.Import(file)
+		require.NoError(t, err)
+		assert.Equal(t, "20250101T120000Z", stored.ID)
+	}
+
+	snapshots, err := store.List("abc")
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+}
+
+func TestSnapshotStorePrune(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := base.Add(10 * time.Hour)
+
+	tests := []struct {
+		name       string
+		keep       int
+		olderThan  time.Duration
+		wantPruned int
+	}{
+		{name: "keep newest", keep: 2, wantPruned: 3},
+		{name: "older than", olderThan: 8*time.Hour + 30*time.Minute, wantPruned: 2},
+		{name: "keep wins over age", keep: 4, olderThan: time.Hour, wantPruned: 1},
+		{name: "nothing to do", wantPruned: 0},
+		{name: "keep everything", keep: 10, wantPruned: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newSnapshotStoreAt(t.TempDir())
+			all := populateStore(t, store, "abc", base, "Ready", "Ready", "Ready", "Ready", "Ready")
+
+			pruned, err := store.Prune("abc", tt.keep, tt.olderThan, now)
+			require.NoError(t, err)
+			assert.Len(t, pruned, tt.wantPruned)
+
+			remaining, err := store.List("abc")
+			require.NoError(t, err)
+			assert.Equal(t, all[tt.wantPruned:], remaining)
+		})
+	}
+}
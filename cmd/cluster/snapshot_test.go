@@ -0,0 +1,240 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func newSnapshotTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, configv1.Install(scheme))
+	return scheme
+}
+
+// newSnapshotTestRESTMapper returns a RESTMapper discovering the resource types of the test objects
+func newSnapshotTestRESTMapper() meta.RESTMapper {
+	dc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "pods", SingularName: "pod", Namespaced: true, Kind: "Pod", ShortNames: []string{"po"}, Verbs: metav1.Verbs{"list"}},
+			{Name: "nodes", SingularName: "node", Kind: "Node", ShortNames: []string{"no"}, Verbs: metav1.Verbs{"list"}},
+			{Name: "namespaces", SingularName: "namespace", Kind: "Namespace", ShortNames: []string{"ns"}, Verbs: metav1.Verbs{"list"}},
+		},
+	}}}}
+	return newSnapshotRESTMapper(dc)
+}
+
+func newSnapshotTestObjects() []client.Object {
+	return []client.Object{
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "worker-0",
+				Labels: map[string]string{
+					"node-role.kubernetes.io/worker": "",
+					"node-role.kubernetes.io/infra":  "",
+				},
+			},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+					{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
+				},
+				NodeInfo: corev1.NodeSystemInfo{KubeletVersion: "v1.31.0"},
+			},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionUnknown},
+				},
+			},
+		},
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "openshift-monitoring"},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "customer-app"},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+		&configv1.ClusterOperator{
+			ObjectMeta: metav1.ObjectMeta{Name: "monitoring"},
+			Status: configv1.ClusterOperatorStatus{
+				Conditions: []configv1.ClusterOperatorStatusCondition{
+					{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+					{Type: configv1.OperatorProgressing, Status: configv1.ConditionFalse},
+					{Type: configv1.OperatorDegraded, Status: configv1.ConditionTrue},
+				},
+				Versions: []configv1.OperandVersion{
+					{Name: "prometheus", Version: "2.55.0"},
+					{Name: "operator", Version: "4.18.1"},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "prometheus-k8s-0", Namespace: "openshift-monitoring"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	}
+}
+
+func TestSnapshotCaptureNodes(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(newSnapshotTestScheme(t)).WithObjects(newSnapshotTestObjects()...).Build()
+	o := &snapshotOptions{client: c}
+
+	nodes, err := o.captureNodes(context.Background())
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+
+	assert.Equal(t, "worker-0", nodes[0].Name)
+	assert.Equal(t, "Ready", nodes[0].Status)
+	assert.Equal(t, "v1.31.0", nodes[0].Version)
+	assert.Equal(t, []string{"infra", "worker"}, nodes[0].Roles)
+	assert.Equal(t, []string{"Ready=True", "MemoryPressure=False"}, nodes[0].Conditions)
+
+	assert.Equal(t, "worker-1", nodes[1].Name)
+	assert.Equal(t, "NotReady", nodes[1].Status)
+	assert.Empty(t, nodes[1].Roles)
+}
+
+func TestSnapshotCaptureNamespaces(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []string
+		want       []string
+	}{
+		{
+			name: "defaults to openshift namespaces",
+			want: []string{"openshift-monitoring"},
+		},
+		{
+			name:       "explicit namespaces",
+			namespaces: []string{"customer-app"},
+			want:       []string{"customer-app"},
+		},
+		{
+			name:       "unknown namespace",
+			namespaces: []string{"does-not-exist"},
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(newSnapshotTestScheme(t)).WithObjects(newSnapshotTestObjects()...).Build()
+			o := &snapshotOptions{client: c, Namespaces: tt.namespaces}
+
+			namespaces, err := o.captureNamespaces(context.Background())
+			require.NoError(t, err)
+
+			var got []string
+			for _, ns := range namespaces {
+				assert.Equal(t, "Active", ns.Status)
+				got = append(got, ns.Name)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSnapshotCaptureClusterOperators(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(newSnapshotTestScheme(t)).WithObjects(newSnapshotTestObjects()...).Build()
+	o := &snapshotOptions{client: c}
+
+	operators, err := o.captureClusterOperators(context.Background())
+	require.NoError(t, err)
+	require.Len(t, operators, 1)
+
+	assert.Equal(t, OperatorSnapshot{
+		Name:        "monitoring",
+		Available:   true,
+		Progressing: false,
+		Degraded:    true,
+		Version:     "4.18.1",
+		Conditions:  []string{"Available=True", "Progressing=False", "Degraded=True"},
+	}, operators[0])
+}
+
+func TestSnapshotCaptureResources(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(newSnapshotTestScheme(t)).WithRESTMapper(newSnapshotTestRESTMapper()).WithObjects(newSnapshotTestObjects()...).Build()
+	o := &snapshotOptions{client: c}
+
+	for _, resourceType := range []string{"pods", "pod", "po", "Pod"} {
+		resources, err := o.captureResources(context.Background(), resourceType)
+		require.NoError(t, err, resourceType)
+		assert.Equal(t, []ResourceInfo{{
+			Name:      "prometheus-k8s-0",
+			Namespace: "openshift-monitoring",
+			Kind:      resourceType,
+			Status:    "Running",
+		}}, resources)
+	}
+
+	_, err := o.captureResources(context.Background(), "widgets.example.com")
+	assert.Error(t, err)
+}
+
+func TestSnapshotCapture(t *testing.T) {
+	c := fake.NewClientBuilder().
+		WithScheme(newSnapshotTestScheme(t)).
+		WithRESTMapper(newSnapshotTestRESTMapper()).
+		WithObjects(newSnapshotTestObjects()...).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				if _, ok := list.(*corev1.NamespaceList); ok {
+					return fmt.Errorf("forbidden")
+				}
+				return c.List(ctx, list, opts...)
+			},
+		}).
+		Build()
+	o := &snapshotOptions{client: c, ResourceTypes: []string{"pods", "widgets.example.com"}}
+
+	snapshot := &ClusterSnapshot{Resources: make(map[string][]ResourceInfo)}
+	captureErrors := o.capture(context.Background(), snapshot)
+
+	assert.Len(t, snapshot.Nodes, 2)
+	assert.Len(t, snapshot.Operators, 1)
+	assert.Empty(t, snapshot.Namespaces)
+	assert.Len(t, snapshot.Resources["pods"], 1)
+
+	assert.Contains(t, captureErrors, "namespaces")
+	assert.Contains(t, captureErrors, "widgets.example.com")
+	assert.NotContains(t, captureErrors, "nodes")
+	assert.NotContains(t, captureErrors, "pods")
+}
+
+func TestSnapshotWriteAndLoad(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(newSnapshotTestScheme(t)).WithObjects(newSnapshotTestObjects()...).Build()
+	o := &snapshotOptions{client: c, OutputFile: filepath.Join(t.TempDir(), "nested", "snapshot.yaml")}
+
+	snapshot := &ClusterSnapshot{
+		Metadata:  SnapshotMetadata{ClusterID: "abc123"},
+		Resources: make(map[string][]ResourceInfo),
+	}
+	assert.Empty(t, o.capture(context.Background(), snapshot))
+	require.NoError(t, o.writeSnapshot(snapshot))
+
+	loaded, err := loadSnapshot(o.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", loaded.Metadata.ClusterID)
+	assert.Equal(t, snapshot.Nodes, loaded.Nodes)
+	assert.Equal(t, snapshot.Operators, loaded.Operators)
+}
@@ -0,0 +1,206 @@
+package support
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
+	"github.com/openshift/osdctl/pkg/output"
+	ctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// limitedSupportQuery selects the clusters having at least one limited support reason
+const limitedSupportQuery = "status.limited_support_reason_count > 0"
+
+// auditedReason is a limited support reason set on an audited cluster
+type auditedReason struct {
+	ID        string    `json:"id"`
+	Summary   string    `json:"summary"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+	Evidence  string    `json:"evidence,omitempty"`
+}
+
+// auditedCluster is a cluster which has been in limited support longer than the threshold
+type auditedCluster struct {
+	ClusterID   string          `json:"cluster_id"`
+	ClusterName string          `json:"cluster_name"`
+	Since       time.Time       `json:"limited_support_since"`
+	Days        int             `json:"days"`
+	Reasons     []auditedReason `json:"reasons"`
+}
+
+type auditOptions struct {
+	olderThan    time.Duration
+	orgID        string
+	filterParams []string
+	output       output.Options
+}
+
+func newCmdAudit() *cobra.Command {
+	opts := &auditOptions{}
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Lists the clusters which have been in limited support for longer than a threshold",
+		Long: `Lists the clusters which have been in limited support for longer than a threshold, with
+their limited support reasons and the evidence posted with them.
+
+  A cluster is in limited support since its oldest limited support reason has been created.`,
+		Example: `  # List the clusters in limited support for more than 30 days
+  osdctl cluster support audit
+
+  # List the clusters of an organization in limited support for more than a week
+  osdctl cluster support audit --org-id ${ORG_ID} --older-than 168h
+
+  # List the AWS clusters in limited support for more than 30 days, with the evidence
+  osdctl cluster support audit -q "cloud_provider.id = 'aws'" -o wide`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run()
+		},
+	}
+
+	auditCmd.Flags().DurationVar(&opts.olderThan, "older-than", 30*24*time.Hour, "Only list clusters in limited support for longer than this duration")
+	auditCmd.Flags().StringVar(&opts.orgID, "org-id", "", "Only list the clusters of this organization")
+	auditCmd.Flags().StringArrayVarP(&opts.filterParams, "query", "q", []string{}, "Specify a search query (eg. -q \"name like foo\") to only list matching clusters")
+	opts.output.AddFlags(auditCmd)
+
+	return auditCmd
+}
+
+func (o *auditOptions) run() error {
+	if o.olderThan < 0 {
+		return fmt.Errorf("--older-than must be positive, got %s", o.olderThan)
+	}
+	if err := o.output.Validate(); err != nil {
+		return err
+	}
+
+	connection, err := ctlutil.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := connection.Close(); err != nil {
+			fmt.Printf("Cannot close the connection: %q\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	clusters, err := o.limitedSupportClusters(connection)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	audited := []auditedCluster{}
+	for _, cluster := range clusters {
+		reasons, err := ctlutil.GetClusterLimitedSupportReasons(connection, cluster.ID())
+		if err != nil {
+			return fmt.Errorf("can't retrieve the limited support reasons of cluster %s: %w", cluster.ID(), err)
+		}
+		// Only fetch the evidence of the clusters which are listed
+		if _, ok := auditCluster(cluster, reasons, nil, now, o.olderThan); !ok {
+			continue
+		}
+		evidenceLogs, err := getEvidenceServiceLogs(connection, cluster)
+		if err != nil {
+			return err
+		}
+		entry, _ := auditCluster(cluster, reasons, evidenceLogs, now, o.olderThan)
+		audited = append(audited, entry)
+	}
+
+	sort.SliceStable(audited, func(i, j int) bool {
+		return audited[i].Since.Before(audited[j].Since)
+	})
+	return o.output.Print(os.Stdout, output.NewTable(audited, auditColumns, auditRow))
+}
+
+// limitedSupportClusters returns the clusters in limited support matching the flags
+func (o *auditOptions) limitedSupportClusters(connection *sdk.Connection) ([]*cmv1.Cluster, error) {
+	filters := append([]string{limitedSupportQuery}, o.filterParams...)
+	if o.orgID != "" {
+		return getOrganizationClusters(connection, o.orgID, filters...)
+	}
+	clusters, err := ctlutil.ApplyFilters(connection, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for clusters in limited support: %w", err)
+	}
+	return clusters, nil
+}
+
+// auditCluster returns the audit entry of a cluster, and whether the cluster has been in limited
+// support for longer than olderThan
+func auditCluster(cluster *cmv1.Cluster, reasons []*cmv1.LimitedSupportReason, evidenceLogs []*slv1.LogEntry, now time.Time, olderThan time.Duration) (auditedCluster, bool) {
+	entry := auditedCluster{ClusterID: cluster.ID(), ClusterName: cluster.Name(), Reasons: []auditedReason{}}
+	if len(reasons) == 0 {
+		return entry, false
+	}
+
+	evidence := map[string][]string{}
+	for _, log := range evidenceLogs {
+		if reasonID, text, ok := parseEvidence(log.Description()); ok {
+			evidence[reasonID] = append(evidence[reasonID], text)
+		}
+	}
+
+	for _, reason := range reasons {
+		created := reason.CreationTimestamp()
+		if entry.Since.IsZero() || created.Before(entry.Since) {
+			entry.Since = created
+		}
+		entry.Reasons = append(entry.Reasons, auditedReason{
+			ID:        reason.ID(),
+			Summary:   reason.Summary(),
+			Details:   reason.Details(),
+			CreatedAt: created,
+			Evidence:  strings.Join(evidence[reason.ID()], "; "),
+		})
+	}
+	sort.SliceStable(entry.Reasons, func(i, j int) bool {
+		return entry.Reasons[i].CreatedAt.Before(entry.Reasons[j].CreatedAt)
+	})
+
+	age := now.Sub(entry.Since)
+	entry.Days = int(age / (24 * time.Hour))
+	return entry, age > olderThan
+}
+
+var auditColumns = []output.Column{
+	{Name: "CLUSTER ID"},
+	{Name: "CLUSTER NAME"},
+	{Name: "LS SINCE"},
+	{Name: "DAYS"},
+	{Name: "REASONS"},
+	{Name: "SUMMARY"},
+	{Name: "EVIDENCE", Wide: true},
+}
+
+func auditRow(c auditedCluster) []string {
+	var summaries, evidence []string
+	for _, reason := range c.Reasons {
+		summaries = append(summaries, reason.Summary)
+		if reason.Evidence != "" {
+			evidence = append(evidence, fmt.Sprintf("%s: %s", reason.ID, reason.Evidence))
+		}
+	}
+	return []string{
+		c.ClusterID,
+		c.ClusterName,
+		c.Since.UTC().Format(time.RFC3339),
+		strconv.Itoa(c.Days),
+		strconv.Itoa(len(c.Reasons)),
+		strings.Join(summaries, "; "),
+		strings.Join(evidence, "; "),
+	}
+}
@@ -0,0 +1,43 @@
+package support
+
+import (
+	"testing"
+	"time"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditCluster(t *testing.T) {
+	cluster, err := cmv1.NewCluster().ID("cluster-id").Name("my-cluster").Build()
+	require.NoError(t, err)
+
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	reasons := []*cmv1.LimitedSupportReason{
+		newLimitedSupportReason(t, "recent", "Cluster misconfiguration", now.Add(-24*time.Hour)),
+		newLimitedSupportReason(t, "old", "Cloud misconfiguration", now.Add(-40*24*time.Hour)),
+	}
+	evidenceLogs := []*slv1.LogEntry{
+		newEvidenceLog(t, now.Add(-40*24*time.Hour), "old - See OHSS-1"),
+	}
+
+	entry, ok := auditCluster(cluster, reasons, evidenceLogs, now, 30*24*time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-40*24*time.Hour), entry.Since)
+	assert.Equal(t, 40, entry.Days)
+	require.Len(t, entry.Reasons, 2)
+	assert.Equal(t, "old", entry.Reasons[0].ID)
+	assert.Equal(t, "See OHSS-1", entry.Reasons[0].Evidence)
+	assert.Equal(t, []string{
+		"cluster-id", "my-cluster", "2024-01-21T00:00:00Z", "40", "2",
+		"Cloud misconfiguration; Cluster misconfiguration", "old: See OHSS-1",
+	}, auditRow(entry))
+
+	_, ok = auditCluster(cluster, reasons, nil, now, 60*24*time.Hour)
+	assert.False(t, ok)
+
+	_, ok = auditCluster(cluster, nil, nil, now, 0)
+	assert.False(t, ok)
+}
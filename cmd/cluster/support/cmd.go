@@ -12,6 +12,8 @@ import (
 // osdctl cluster support status
 // osdctl cluster support create --summary="" --reason=""
 // osdctl cluster support delete --reason=""
+// osdctl cluster support history --cluster-id=""
+// osdctl cluster support audit --older-than=""
 func NewCmdSupport(streams genericclioptions.IOStreams, client client.Client, globalOpts *globalflags.GlobalOptions) *cobra.Command {
 	supportCmd := &cobra.Command{
 		Use:               "support",
@@ -24,6 +26,8 @@ func NewCmdSupport(streams genericclioptions.IOStreams, client client.Client, gl
 	supportCmd.AddCommand(newCmdstatus(streams, globalOpts))
 	supportCmd.AddCommand(newCmdPost())
 	supportCmd.AddCommand(newCmddelete(streams, globalOpts))
+	supportCmd.AddCommand(newCmdHistory())
+	supportCmd.AddCommand(newCmdAudit())
 
 	return supportCmd
 }
@@ -4,11 +4,18 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
+	sdk "github.com/openshift-online/ocm-sdk-go"
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
 	ctlutil "github.com/openshift/osdctl/pkg/utils"
 )
 
+// listPageSize is the page size used when listing service logs, subscriptions and clusters
+const listPageSize = 100
+
 func getLimitedSupportReasons(clusterId string) ([]*cmv1.LimitedSupportReason, error) {
 	// Check that the cluster key (name, identifier or external identifier) given by the user
 	// is reasonably safe so that there is no risk of SQL injection
@@ -43,3 +50,89 @@ func getLimitedSupportReasons(clusterId string) ([]*cmv1.LimitedSupportReason, e
 
 	return clusterLimitedSupportReasons, nil
 }
+
+// getEvidenceServiceLogs returns the internal service logs holding the evidence of the limited
+// support reasons posted to a cluster, oldest first
+func getEvidenceServiceLogs(connection *sdk.Connection, cluster *cmv1.Cluster) ([]*slv1.LogEntry, error) {
+	request := connection.ServiceLogs().V1().Clusters().ClusterLogs().List().
+		ClusterID(cluster.ID()).
+		ClusterUUID(cluster.ExternalID()).
+		Search(fmt.Sprintf("summary = '%s'", InternalServiceLogSummary)).
+		Parameter("orderBy", "timestamp asc").
+		Size(listPageSize)
+
+	var logs []*slv1.LogEntry
+	for page := 1; ; page++ {
+		response, err := request.Page(page).Send()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the service logs of cluster %s: %w", cluster.ID(), err)
+		}
+		items := response.Items().Slice()
+		logs = append(logs, items...)
+		if len(items) < listPageSize || page*listPageSize >= response.Total() {
+			break
+		}
+	}
+
+	// The API matches the summary loosely, keep the exact ones
+	evidenceLogs := []*slv1.LogEntry{}
+	for _, log := range logs {
+		if log.Summary() == InternalServiceLogSummary {
+			evidenceLogs = append(evidenceLogs, log)
+		}
+	}
+	sort.SliceStable(evidenceLogs, func(i, j int) bool {
+		return evidenceLogs[i].Timestamp().Before(evidenceLogs[j].Timestamp())
+	})
+	return evidenceLogs, nil
+}
+
+// parseEvidence returns the limited support reason ID and the evidence held by the description
+// of an internal service log built by buildInternalServiceLog
+func parseEvidence(description string) (reasonID string, evidence string, ok bool) {
+	reasonID, evidence, ok = strings.Cut(description, " - ")
+	if !ok || reasonID == "" || strings.ContainsAny(reasonID, " \n") {
+		return "", "", false
+	}
+	return reasonID, evidence, true
+}
+
+// getOrganizationClusters returns the active managed clusters of an organization matching the
+// cluster search filters
+func getOrganizationClusters(connection *sdk.Connection, orgID string, filters ...string) ([]*cmv1.Cluster, error) {
+	if err := ctlutil.IsValidClusterKey(orgID); err != nil {
+		return nil, fmt.Errorf("invalid organization ID: %w", err)
+	}
+
+	request := connection.AccountsMgmt().V1().Subscriptions().List().
+		Search(fmt.Sprintf("organization_id='%s' and status='Active' and managed=true", orgID)).
+		Size(listPageSize)
+
+	var clusterIDs []string
+	for page := 1; ; page++ {
+		response, err := request.Page(page).Send()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the subscriptions of organization %s: %w", orgID, err)
+		}
+		for _, subscription := range response.Items().Slice() {
+			if subscription.ClusterID() != "" {
+				clusterIDs = append(clusterIDs, subscription.ClusterID())
+			}
+		}
+		if response.Size() < listPageSize || page*listPageSize >= response.Total() {
+			break
+		}
+	}
+
+	// Keep the cluster searches reasonably short for large organizations
+	clusters := []*cmv1.Cluster{}
+	for start := 0; start < len(clusterIDs); start += listPageSize {
+		end := min(start+listPageSize, len(clusterIDs))
+		found, err := ctlutil.ApplyFilters(connection, append([]string{ctlutil.GenerateIDQuery(clusterIDs[start:end])}, filters...))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the clusters of organization %s: %w", orgID, err)
+		}
+		clusters = append(clusters, found...)
+	}
+	return clusters, nil
+}
@@ -0,0 +1,216 @@
+package support
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
+	"github.com/openshift/osdctl/pkg/output"
+	ctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// Actions of a limited support history event
+const (
+	limitedSupportAdded   = "added"
+	limitedSupportRemoved = "removed"
+)
+
+// limitedSupportEvent is a limited support reason being added to or removed from a cluster
+type limitedSupportEvent struct {
+	// Time is unset when unknown: removals are not recorded, only the absence of a reason is
+	Time        *time.Time `json:"time,omitempty"`
+	ClusterID   string     `json:"cluster_id"`
+	ClusterName string     `json:"cluster_name"`
+	ReasonID    string     `json:"reason_id"`
+	Action      string     `json:"action"`
+	Summary     string     `json:"summary,omitempty"`
+	Evidence    string     `json:"evidence,omitempty"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+}
+
+type historyOptions struct {
+	clusterID string
+	orgID     string
+	output    output.Options
+}
+
+func newCmdHistory() *cobra.Command {
+	opts := &historyOptions{}
+	historyCmd := &cobra.Command{
+		Use:   "history (--cluster-id <cluster-identifier> | --org-id <org-id>)",
+		Short: "Shows the limited support reasons added to and removed from a cluster or the clusters of an organization",
+		Long: `Shows the limited support reasons added to and removed from a cluster or the clusters of an organization.
+
+  The history is rebuilt from the internal 'LimitedSupportEvidence' service logs sent by
+  'osdctl cluster support post --evidence' and from the current limited support reasons.
+  A reason which has evidence but is no longer set on the cluster has been removed at an
+  unknown time.`,
+		Example: `  # Show the limited support history of a cluster
+  osdctl cluster support history --cluster-id ${CLUSTER_ID}
+
+  # Show the limited support history of the clusters of an organization as JSON
+  osdctl cluster support history --org-id ${ORG_ID} -o json`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run()
+		},
+	}
+
+	historyCmd.Flags().StringVarP(&opts.clusterID, "cluster-id", "C", "", "Cluster ID to show the limited support history of")
+	historyCmd.Flags().StringVar(&opts.orgID, "org-id", "", "Organization ID to show the limited support history of all active clusters of")
+	historyCmd.MarkFlagsMutuallyExclusive("cluster-id", "org-id")
+	historyCmd.MarkFlagsOneRequired("cluster-id", "org-id")
+	opts.output.AddFlags(historyCmd)
+
+	return historyCmd
+}
+
+func (o *historyOptions) run() error {
+	if err := o.output.Validate(); err != nil {
+		return err
+	}
+
+	connection, err := ctlutil.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := connection.Close(); err != nil {
+			fmt.Printf("Cannot close the connection: %q\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	clusters, err := historyClusters(connection, o.clusterID, o.orgID)
+	if err != nil {
+		return err
+	}
+
+	events := []limitedSupportEvent{}
+	for _, cluster := range clusters {
+		evidenceLogs, err := getEvidenceServiceLogs(connection, cluster)
+		if err != nil {
+			return err
+		}
+		reasons, err := ctlutil.GetClusterLimitedSupportReasons(connection, cluster.ID())
+		if err != nil {
+			return fmt.Errorf("can't retrieve the limited support reasons of cluster %s: %w", cluster.ID(), err)
+		}
+		events = append(events, buildLimitedSupportHistory(cluster, evidenceLogs, reasons)...)
+	}
+
+	return o.output.Print(os.Stdout, output.NewTable(events, historyColumns, historyRow))
+}
+
+// historyClusters returns the cluster given with --cluster-id, or the clusters of the organization
+func historyClusters(connection *sdk.Connection, clusterID string, orgID string) ([]*cmv1.Cluster, error) {
+	if orgID != "" {
+		return getOrganizationClusters(connection, orgID)
+	}
+
+	// Check that the cluster key (name, identifier or external identifier) given by the user
+	// is reasonably safe so that there is no risk of SQL injection
+	if err := ctlutil.IsValidClusterKey(clusterID); err != nil {
+		return nil, err
+	}
+	cluster, err := ctlutil.GetCluster(connection, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve cluster: %w", err)
+	}
+	return []*cmv1.Cluster{cluster}, nil
+}
+
+// buildLimitedSupportHistory returns the limited support events of a cluster, oldest first:
+//   - every evidence service log is a reason being added
+//   - a reason with evidence which is no longer set has been removed, at an unknown time
+//   - a reason set without evidence has been added when it was created
+func buildLimitedSupportHistory(cluster *cmv1.Cluster, evidenceLogs []*slv1.LogEntry, reasons []*cmv1.LimitedSupportReason) []limitedSupportEvent {
+	active := map[string]*cmv1.LimitedSupportReason{}
+	for _, reason := range reasons {
+		active[reason.ID()] = reason
+	}
+
+	var events, removed []limitedSupportEvent
+	withEvidence := map[string]bool{}
+	for _, log := range evidenceLogs {
+		reasonID, evidence, ok := parseEvidence(log.Description())
+		if !ok {
+			continue
+		}
+		timestamp := log.Timestamp()
+		event := limitedSupportEvent{
+			Time:        &timestamp,
+			ClusterID:   cluster.ID(),
+			ClusterName: cluster.Name(),
+			ReasonID:    reasonID,
+			Action:      limitedSupportAdded,
+			Evidence:    evidence,
+			CreatedBy:   log.Username(),
+		}
+		if reason, ok := active[reasonID]; ok {
+			event.Summary = reason.Summary()
+		}
+		events = append(events, event)
+
+		if _, ok := active[reasonID]; !ok && !withEvidence[reasonID] {
+			removed = append(removed, limitedSupportEvent{
+				ClusterID:   cluster.ID(),
+				ClusterName: cluster.Name(),
+				ReasonID:    reasonID,
+				Action:      limitedSupportRemoved,
+			})
+		}
+		withEvidence[reasonID] = true
+	}
+
+	for _, reason := range reasons {
+		if withEvidence[reason.ID()] {
+			continue
+		}
+		event := limitedSupportEvent{
+			ClusterID:   cluster.ID(),
+			ClusterName: cluster.Name(),
+			ReasonID:    reason.ID(),
+			Action:      limitedSupportAdded,
+			Summary:     reason.Summary(),
+		}
+		if created, ok := reason.GetCreationTimestamp(); ok {
+			event.Time = &created
+		}
+		events = append(events, event)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Time == nil || events[j].Time == nil {
+			return events[j].Time == nil && events[i].Time != nil
+		}
+		return events[i].Time.Before(*events[j].Time)
+	})
+	return append(events, removed...)
+}
+
+var historyColumns = []output.Column{
+	{Name: "TIME"},
+	{Name: "CLUSTER ID"},
+	{Name: "CLUSTER NAME", Wide: true},
+	{Name: "REASON ID"},
+	{Name: "ACTION"},
+	{Name: "SUMMARY"},
+	{Name: "EVIDENCE"},
+	{Name: "CREATED BY", Wide: true},
+}
+
+func historyRow(e limitedSupportEvent) []string {
+	timestamp := "unknown"
+	if e.Time != nil {
+		timestamp = e.Time.UTC().Format(time.RFC3339)
+	}
+	return []string{timestamp, e.ClusterID, e.ClusterName, e.ReasonID, e.Action, e.Summary, e.Evidence, e.CreatedBy}
+}
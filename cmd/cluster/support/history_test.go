@@ -0,0 +1,69 @@
+package support
+
+import (
+	"testing"
+	"time"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEvidenceLog(t *testing.T, timestamp time.Time, description string) *slv1.LogEntry {
+	log, err := slv1.NewLogEntry().
+		Summary(InternalServiceLogSummary).
+		Description(description).
+		Timestamp(timestamp).
+		Username("sre-user").
+		Build()
+	require.NoError(t, err)
+	return log
+}
+
+func newLimitedSupportReason(t *testing.T, id string, summary string, created time.Time) *cmv1.LimitedSupportReason {
+	reason, err := cmv1.NewLimitedSupportReason().ID(id).Summary(summary).CreationTimestamp(created).Build()
+	require.NoError(t, err)
+	return reason
+}
+
+func TestParseEvidence(t *testing.T) {
+	reasonID, evidence, ok := parseEvidence("2abc - See OHSS-1 - follow up")
+	assert.True(t, ok)
+	assert.Equal(t, "2abc", reasonID)
+	assert.Equal(t, "See OHSS-1 - follow up", evidence)
+
+	_, _, ok = parseEvidence("free text without a reason")
+	assert.False(t, ok)
+}
+
+func TestBuildLimitedSupportHistory(t *testing.T) {
+	cluster, err := cmv1.NewCluster().ID("cluster-id").Name("my-cluster").Build()
+	require.NoError(t, err)
+
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	evidenceLogs := []*slv1.LogEntry{
+		newEvidenceLog(t, day(1), "removed-reason - See OHSS-1"),
+		newEvidenceLog(t, day(3), "active-reason - See OHSS-2"),
+		newEvidenceLog(t, day(4), "not an evidence log"),
+	}
+	reasons := []*cmv1.LimitedSupportReason{
+		newLimitedSupportReason(t, "active-reason", "Cloud misconfiguration", day(3)),
+		newLimitedSupportReason(t, "no-evidence", "Cluster misconfiguration", day(2)),
+	}
+
+	events := buildLimitedSupportHistory(cluster, evidenceLogs, reasons)
+
+	var got []string
+	for _, e := range events {
+		got = append(got, historyRow(e)[0]+" "+e.ReasonID+" "+e.Action+" "+e.Summary+" "+e.Evidence)
+	}
+	assert.Equal(t, []string{
+		"2024-01-01T00:00:00Z removed-reason added  See OHSS-1",
+		"2024-01-02T00:00:00Z no-evidence added Cluster misconfiguration ",
+		"2024-01-03T00:00:00Z active-reason added Cloud misconfiguration See OHSS-2",
+		"unknown removed-reason removed  ",
+	}, got)
+	assert.Equal(t, "sre-user", events[0].CreatedBy)
+	assert.Equal(t, "my-cluster", events[0].ClusterName)
+}
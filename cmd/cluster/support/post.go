@@ -17,6 +17,7 @@ import (
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
+	"github.com/openshift/osdctl/internal/io"
 	"github.com/openshift/osdctl/internal/utils"
 	ctlutil "github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
@@ -46,6 +47,8 @@ type Post struct {
 	Evidence         string
 	cluster          *cmv1.Cluster
 	ClusterID        string
+	clustersFile     string
+	filterParams     []string
 }
 
 type TemplateFile struct {
@@ -72,7 +75,14 @@ The caller will be prompted to continue before sending the limited support reaso
   osdctl cluster support post --cluster-id ${CLUSTER_ID} --misconfiguration=cluster \
     --problem="The cluster has a second failing ingress controller" \
     --resolution="Remove the additional ingress controller" \
-    --evidence="See ${REASON}"`,
+    --evidence="See ${REASON}"
+
+  # Post a limited support reason from a template to a list of clusters
+  osdctl cluster support post --clusters-file clusters.json -t template.json -p REASON="..."
+
+  # Post a limited support reason to the clusters matching an OCM query
+  osdctl cluster support post -q "name like 'test-%'" --misconfiguration=cloud \
+    --problem="..." --resolution="..."`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -81,7 +91,9 @@ The caller will be prompted to continue before sending the limited support reaso
 	}
 
 	// Define required flags
-	postCmd.Flags().StringVarP(&p.ClusterID, "cluster-id", "C", "", "Internal Cluster ID (required)")
+	postCmd.Flags().StringVarP(&p.ClusterID, "cluster-id", "C", "", "Internal Cluster ID, required unless --clusters-file or --query is used")
+	postCmd.Flags().StringVarP(&p.clustersFile, "clusters-file", "c", "", `Read a list of clusters to post the limited support reason to. the format of the file is: {"clusters":["$CLUSTERID"]}`)
+	postCmd.Flags().StringArrayVarP(&p.filterParams, "query", "q", []string{}, "Specify a search query (eg. -q \"name like foo\") for a bulk-post to matching clusters.")
 	postCmd.Flags().StringVarP(&p.Template, "template", "t", "", "Message template file or URL")
 	postCmd.Flags().StringArrayVarP(&p.TemplateParams, "param", "p", p.TemplateParams, "Specify a key-value pair (eg. -p FOO=BAR) to set/override a parameter value in the template.")
 	postCmd.Flags().Var(&p.Misconfiguration, MisconfigurationFlag, "The type of misconfiguration responsible for the cluster being placed into limited support. Valid values are `cloud` or `cluster`.")
@@ -89,8 +101,6 @@ The caller will be prompted to continue before sending the limited support reaso
 	postCmd.Flags().StringVar(&p.Resolution, ResolutionFlag, "", "Complete sentence(s) describing the steps for the customer to take to resolve the issue and move out of limited support. Will form the limited support message with the contents of --problem prepended")
 	postCmd.Flags().StringVar(&p.Evidence, EvidenceFlag, "", "(optional) The reasoning that led to the decision to place the cluster in limited support. Can also be a link to a Jira case. Used for internal service log only.")
 
-	_ = postCmd.MarkFlagRequired("cluster-id")
-
 	return postCmd
 }
 
@@ -135,11 +145,8 @@ func (p *Post) Run(clusterID string) error {
 	if err := p.check(); err != nil {
 		return err
 	}
-
-	// Check that the cluster key (name, identifier or external identifier) given by the user
-	// is reasonably safe so that there is no risk of SQL injection
-	if err := ctlutil.IsValidClusterKey(clusterID); err != nil {
-		return err
+	if clusterID == "" && p.clustersFile == "" && len(p.filterParams) == 0 {
+		return fmt.Errorf("no cluster identifier has been found, please specify --cluster-id, --clusters-file or --query")
 	}
 
 	connection, err := ctlutil.CreateConnection()
@@ -153,11 +160,146 @@ func (p *Post) Run(clusterID string) error {
 		}
 	}()
 
-	p.cluster, err = ctlutil.GetCluster(connection, clusterID)
+	clusters, err := p.targetClusters(connection, clusterID)
 	if err != nil {
-		return fmt.Errorf("can't retrieve cluster: %w", err)
+		return err
+	}
+
+	var limitedSupport *cmv1.LimitedSupportReason
+	if p.Template != "" {
+		limitedSupport, err = p.buildLimitedSupportTemplate()
+		if err != nil {
+			return err
+		}
+	} else {
+		limitedSupport, err = p.buildLimitedSupport()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(clusters) == 1 {
+		p.cluster = clusters[0]
+		confirmed, err := p.confirmCriticalCustomer(connection)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return nil
+		}
+		fmt.Printf("The following limited support reason will be sent to %s:\n", p.cluster.ID())
+	} else {
+		fmt.Printf("The following limited support reason will be sent to %d clusters:\n", len(clusters))
+		for _, cluster := range clusters {
+			fmt.Printf("  %s (%s)\n", cluster.ID(), cluster.Name())
+		}
+	}
+	if err = printLimitedSupportReason(limitedSupport); err != nil {
+		return fmt.Errorf("failed to print limited support reason template: %w", err)
+	}
+
+	if !ctlutil.ConfirmPrompt() {
+		return nil
+	}
+
+	if len(clusters) == 1 {
+		return p.postToCluster(connection, limitedSupport)
+	}
+
+	// Post to every cluster, asking again for clusters of critical customers
+	failed := map[string]error{}
+	skipped := 0
+	for _, cluster := range clusters {
+		p.cluster = cluster
+		fmt.Printf("\nPosting to %s (%s)\n", cluster.ID(), cluster.Name())
+		confirmed, err := p.confirmCriticalCustomer(connection)
+		if err != nil {
+			fmt.Printf("Failed to post to cluster %s: %v\n", cluster.ID(), err)
+			failed[cluster.ID()] = err
+			continue
+		}
+		if !confirmed {
+			fmt.Printf("Skipping cluster %s\n", cluster.ID())
+			skipped++
+			continue
+		}
+		if err := p.postToCluster(connection, limitedSupport); err != nil {
+			fmt.Printf("Failed to post to cluster %s: %v\n", cluster.ID(), err)
+			failed[cluster.ID()] = err
+		}
+	}
+
+	fmt.Printf("\nSuccess: %d, Skipped: %d, Failed: %d\n", len(clusters)-len(failed)-skipped, skipped, len(failed))
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to post the limited support reason to %d of %d clusters", len(failed), len(clusters))
 	}
+	return nil
+}
 
+// targetClusters returns the cluster given with --cluster-id, or the clusters of the clusters
+// file and search queries, the same way 'servicelog post' selects them
+func (p *Post) targetClusters(connection *sdk.Connection, clusterID string) ([]*cmv1.Cluster, error) {
+	if p.clustersFile == "" && len(p.filterParams) == 0 {
+		// Check that the cluster key (name, identifier or external identifier) given by the user
+		// is reasonably safe so that there is no risk of SQL injection
+		if err := ctlutil.IsValidClusterKey(clusterID); err != nil {
+			return nil, err
+		}
+		cluster, err := ctlutil.GetCluster(connection, clusterID)
+		if err != nil {
+			return nil, fmt.Errorf("can't retrieve cluster: %w", err)
+		}
+		return []*cmv1.Cluster{cluster}, nil
+	}
+
+	filters, err := p.clusterFilters(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := ctlutil.ApplyFilters(connection, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for clusters with provided filters (%v): %w", filters, err)
+	}
+	if len(clusters) < 1 {
+		return nil, fmt.Errorf("no clusters match the given filters (%v)", filters)
+	}
+	return clusters, nil
+}
+
+// clusterFilters combines the search queries with the clusters of the clusters file and
+// --cluster-id
+func (p *Post) clusterFilters(clusterID string) ([]string, error) {
+	// Check that the cluster key given by the user is reasonably safe to be used in a search
+	// query, so that there is no risk of SQL injection
+	if clusterID != "" {
+		if err := ctlutil.IsValidClusterKey(clusterID); err != nil {
+			return nil, err
+		}
+	}
+	filters := append([]string{}, p.filterParams...)
+
+	var queries []string
+	if p.clustersFile != "" {
+		clusterIDs, err := io.ParseAndValidateClustersFile(p.clustersFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse clusters file %s: %w", p.clustersFile, err)
+		}
+		for _, id := range clusterIDs {
+			queries = append(queries, ctlutil.GenerateQuery(id))
+		}
+	}
+	if clusterID != "" {
+		queries = append(queries, ctlutil.GenerateQuery(clusterID))
+	}
+	if len(queries) > 0 {
+		filters = append(filters, strings.Join(queries, " or "))
+	}
+	return filters, nil
+}
+
+// confirmCriticalCustomer asks for confirmation when the cluster is owned by a critical
+// customer, and returns whether to post to the cluster
+func (p *Post) confirmCriticalCustomer(connection *sdk.Connection) (bool, error) {
 	subscriptionResponse, err := connection.
 		AccountsMgmt().
 		V1().
@@ -165,7 +307,7 @@ func (p *Post) Run(clusterID string) error {
 		Subscription(p.cluster.Subscription().ID()).
 		Get().Send()
 	if err != nil {
-		return fmt.Errorf("failed to retrieve cluster subscription: %w", err)
+		return false, fmt.Errorf("failed to retrieve the subscription of cluster %s: %w", p.cluster.ID(), err)
 	}
 
 	labelResponse, err := connection.
@@ -179,39 +321,20 @@ func (p *Post) Run(clusterID string) error {
 	if err != nil {
 		// if the label is missing, there's no need to show an error to the user
 		if labelResponse.Error().Status() != http.StatusNotFound {
-			return fmt.Errorf("failed to retrieve cluster labels: %w", err)
+			return false, fmt.Errorf("failed to retrieve the labels of cluster %s: %w", p.cluster.ID(), err)
 		}
 	} else if labelResponse.Body().Value() == "true" {
 		fmt.Println(`WARNING: This cluster is owned by a critical customer. Make sure that an SL has been sent and proactive case opened with the customer. Only continue if there has been no customer response for 24 hours.
 
 See: https://source.redhat.com/groups/public/sre/wiki/defining_limited_support_process_for_osdrosa_for_critical_customers`)
-		if !ctlutil.ConfirmPrompt() {
-			return nil
-		}
-	}
-
-	var limitedSupport *cmv1.LimitedSupportReason
-	if p.Template != "" {
-		limitedSupport, err = p.buildLimitedSupportTemplate()
-		if err != nil {
-			return err
-		}
-	} else {
-		limitedSupport, err = p.buildLimitedSupport()
-		if err != nil {
-			return err
-		}
-	}
-
-	fmt.Printf("The following limited support reason will be sent to %s:\n", clusterID)
-	if err = printLimitedSupportReason(limitedSupport); err != nil {
-		return fmt.Errorf("failed to print limited support reason template: %w", err)
-	}
-
-	if !ctlutil.ConfirmPrompt() {
-		return nil
+		return ctlutil.ConfirmPrompt(), nil
 	}
+	return true, nil
+}
 
+// postToCluster posts the limited support reason to the cluster, followed by the internal
+// service log holding the evidence
+func (p *Post) postToCluster(connection *sdk.Connection, limitedSupport *cmv1.LimitedSupportReason) error {
 	postLimitedSupportResponse, err := sendLimitedSupportPostRequest(connection, p.cluster.ID(), limitedSupport)
 	if err != nil {
 		return fmt.Errorf("failed to post limited support reason: %w", err)
@@ -228,7 +351,7 @@ See: https://source.redhat.com/groups/public/sre/wiki/defining_limited_support_p
 			return err
 		}
 
-		fmt.Printf("Sending the following internal service log to %s:\n", clusterID)
+		fmt.Printf("Sending the following internal service log to %s:\n", p.cluster.ID())
 		if err = printInternalServiceLog(internalServiceLog); err != nil {
 			return fmt.Errorf("failed to print internal service log template: %w", err)
 		}
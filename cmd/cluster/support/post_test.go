@@ -565,3 +565,55 @@ func TestPrintInternalServiceLog(t *testing.T) {
 		})
 	}
 }
+
+func TestPostClusterFilters(t *testing.T) {
+	clustersFile := t.TempDir() + "/clusters.json"
+	if err := os.WriteFile(clustersFile, []byte(`{"clusters": ["cluster-1", "cluster-2"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		post      *Post
+		clusterID string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name: "query only",
+			post: &Post{filterParams: []string{"name like 'test-%'"}},
+			want: []string{"name like 'test-%'"},
+		},
+		{
+			name:      "clusters file and cluster ID",
+			post:      &Post{clustersFile: clustersFile},
+			clusterID: "cluster-3",
+			want: []string{
+				"(display_name like 'cluster-1') or (display_name like 'cluster-2') or (display_name like 'cluster-3')",
+			},
+		},
+		{
+			name:      "invalid cluster ID with a query",
+			post:      &Post{filterParams: []string{"name like 'test-%'"}},
+			clusterID: "x') or (id like '%",
+			wantErr:   true,
+		},
+		{
+			name:    "missing clusters file",
+			post:    &Post{clustersFile: clustersFile + ".missing"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := tt.post.clusterFilters(tt.clusterID)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, filters)
+		})
+	}
+}
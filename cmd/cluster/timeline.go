@@ -0,0 +1,250 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/output"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// TimelineEntry describes the state of a cluster at the time of a stored snapshot and what
+// changed since the previous snapshot
+type TimelineEntry struct {
+	SnapshotID         string       `json:"snapshotId"`
+	Timestamp          time.Time    `json:"timestamp"`
+	Version            string       `json:"version"`
+	Nodes              int          `json:"nodes"`
+	ReadyNodes         int          `json:"readyNodes"`
+	Operators          int          `json:"operators"`
+	UnhealthyOperators int          `json:"unhealthyOperators"`
+	Severity           Severity     `json:"severity,omitempty"`
+	NodeChanges        []ObjectDiff `json:"nodeChanges,omitempty"`
+	OperatorChanges    []ObjectDiff `json:"operatorChanges,omitempty"`
+}
+
+// timelineOptions holds the options for the timeline command
+type timelineOptions struct {
+	ClusterID string
+	Since     time.Duration
+	From      string
+	To        string
+	RulesFile string
+	Output    output.Options
+
+	store *SnapshotStore
+}
+
+func newCmdTimeline() *cobra.Command {
+	opts := &timelineOptions{}
+
+	timelineCmd := &cobra.Command{
+		Use:   "timeline",
+		Short: "Show how a cluster evolved across stored snapshots",
+		Long: `Show how a cluster evolved across the snapshots in the local snapshot store.
+
+Consecutive snapshots taken with 'osdctl cluster snapshot' are compared using
+the same rules as 'osdctl cluster diff', and the node and ClusterOperator
+changes between each pair are printed in chronological order. This is useful
+to reconstruct how an incident unfolded when snapshots were taken periodically.`,
+		Example: `  # Show the full timeline of a cluster
+  osdctl cluster timeline -C ${CLUSTER_ID}
+
+  # Only show snapshots from the last 6 hours
+  osdctl cluster timeline -C ${CLUSTER_ID} --since 6h
+
+  # Show the timeline between two snapshots as JSON
+  osdctl cluster timeline -C ${CLUSTER_ID} --from 20250101T120000Z --to latest -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run()
+		},
+	}
+
+	timelineCmd.Flags().StringVarP(&opts.ClusterID, "cluster-id", "C", "", "Cluster ID (internal ID or name)")
+	timelineCmd.Flags().DurationVar(&opts.Since, "since", 0, "Only include snapshots taken within this duration (e.g. 6h)")
+	timelineCmd.Flags().StringVar(&opts.From, "from", "", "First snapshot to include (snapshot ID, 'latest' or 'latest~N')")
+	timelineCmd.Flags().StringVar(&opts.To, "to", "", "Last snapshot to include (snapshot ID, 'latest' or 'latest~N')")
+	timelineCmd.Flags().StringVar(&opts.RulesFile, "rules", "", "YAML file with ignore and severity rules (default: built-in rules)")
+	opts.Output.AddFormatFlag(timelineCmd, snapshotFormats...)
+	cmdutil.CheckErr(timelineCmd.MarkFlagRequired("cluster-id"))
+
+	return timelineCmd
+}
+
+func (o *timelineOptions) run() error {
+	if o.Since > 0 && o.From != "" {
+		return fmt.Errorf("--since and --from cannot be used together")
+	}
+	if err := o.Output.Validate(); err != nil {
+		return err
+	}
+
+	rules := &DefaultDiffRules
+	if o.RulesFile != "" {
+		var err error
+		rules, err = LoadDiffRules(o.RulesFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.store == nil {
+		store, err := NewSnapshotStore()
+		if err != nil {
+			return err
+		}
+		o.store = store
+	}
+
+	snapshots, err := o.selectSnapshots(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		return fmt.Errorf("no stored snapshots of cluster %s match the selected range", o.ClusterID)
+	}
+
+	timeline, err := buildTimeline(o.store, snapshots, rules)
+	if err != nil {
+		return err
+	}
+
+	var text bytes.Buffer
+	printTimeline(&text, timeline)
+	return o.Output.Print(os.Stdout, output.NewObject(timeline, strings.TrimSuffix(text.String(), "\n")))
+}
+
+// selectSnapshots returns the stored snapshots within the requested range, oldest first
+func (o *timelineOptions) selectSnapshots(now time.Time) ([]StoredSnapshot, error) {
+	clusterID, err := o.store.ResolveCluster(o.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := o.store.List(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to time.Time
+	if o.From != "" {
+		stored, err := o.store.Resolve(clusterID, o.From)
+		if err != nil {
+			return nil, err
+		}
+		from = stored.Timestamp
+	}
+	if o.Since > 0 {
+		from = now.Add(-o.Since)
+	}
+	if o.To != "" {
+		stored, err := o.store.Resolve(clusterID, o.To)
+		if err != nil {
+			return nil, err
+		}
+		to = stored.Timestamp
+	}
+
+	var selected []StoredSnapshot
+	for _, stored := range snapshots {
+		if !from.IsZero() && stored.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && stored.Timestamp.After(to) {
+			continue
+		}
+		selected = append(selected, stored)
+	}
+	return selected, nil
+}
+
+// buildTimeline loads the snapshots in order and diffs each one against its predecessor
+func buildTimeline(store *SnapshotStore, snapshots []StoredSnapshot, rules *DiffRules) ([]TimelineEntry, error) {
+	var (
+		timeline []TimelineEntry
+		previous *ClusterSnapshot
+	)
+
+	for _, stored := range snapshots {
+		snapshot, err := store.Load(stored)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load snapshot %s: %w", stored.ID, err)
+		}
+
+		entry := TimelineEntry{
+			SnapshotID: stored.ID,
+			Timestamp:  stored.Timestamp,
+			Version:    snapshot.Metadata.Version,
+			Nodes:      len(snapshot.Nodes),
+			Operators:  len(snapshot.Operators),
+		}
+		for _, node := range snapshot.Nodes {
+			if node.Status == "Ready" {
+				entry.ReadyNodes++
+			}
+		}
+		for _, op := range snapshot.Operators {
+			if op.Degraded || !op.Available {
+				entry.UnhealthyOperators++
+			}
+		}
+
+		if previous != nil {
+			diff := compareSnapshots(previous, snapshot, "", "", rules)
+			entry.NodeChanges = diff.NodeChanges
+			entry.OperatorChanges = diff.OperatorChanges
+			for _, d := range entry.NodeChanges {
+				entry.Severity = maxSeverity(entry.Severity, d.Severity)
+			}
+			for _, d := range entry.OperatorChanges {
+				entry.Severity = maxSeverity(entry.Severity, d.Severity)
+			}
+		}
+
+		timeline = append(timeline, entry)
+		previous = snapshot
+	}
+
+	return timeline, nil
+}
+
+func printTimeline(w io.Writer, timeline []TimelineEntry) {
+	for i, entry := range timeline {
+		fmt.Fprintf(w, "%s  %s  version=%s  nodes=%d/%d ready  operators=%d unhealthy/%d",
+			entry.Timestamp.Format(time.RFC3339), entry.SnapshotID, entry.Version,
+			entry.ReadyNodes, entry.Nodes, entry.UnhealthyOperators, entry.Operators)
+		if entry.Severity != "" {
+			fmt.Fprintf(w, "  [%s]", entry.Severity)
+		}
+		fmt.Fprintln(w)
+
+		if i > 0 && len(entry.NodeChanges) == 0 && len(entry.OperatorChanges) == 0 {
+			fmt.Fprintln(w, "    no node or operator changes")
+		}
+		for _, d := range entry.NodeChanges {
+			printTimelineChange(w, "node", d)
+		}
+		for _, d := range entry.OperatorChanges {
+			printTimelineChange(w, "operator", d)
+		}
+	}
+}
+
+func printTimelineChange(w io.Writer, kind string, d ObjectDiff) {
+	switch d.ChangeType {
+	case "added":
+		fmt.Fprintf(w, "    + %s %s [%s] %s\n", kind, d.Name, d.Severity, d.After)
+	case "removed":
+		fmt.Fprintf(w, "    - %s %s [%s] %s\n", kind, d.Name, d.Severity, d.Before)
+	default:
+		fmt.Fprintf(w, "    ~ %s %s [%s]\n", kind, d.Name, d.Severity)
+		for _, f := range d.Fields {
+			fmt.Fprintf(w, "        %s: %s -> %s\n", f.Field, displayValue(f.Before), displayValue(f.After))
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	store := newSnapshotStoreAt(t.TempDir())
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	stored := populateStore(t, store, "abc", base, "Ready", "Ready", "NotReady", "Ready")
+
+	timeline, err := buildTimeline(store, stored, &DefaultDiffRules)
+	require.NoError(t, err)
+	require.Len(t, timeline, 4)
+
+	assert.Equal(t, 1, timeline[0].ReadyNodes)
+	assert.Empty(t, timeline[0].NodeChanges)
+	assert.Empty(t, timeline[1].NodeChanges)
+
+	assert.Equal(t, 0, timeline[2].ReadyNodes)
+	assert.Equal(t, SeverityCritical, timeline[2].Severity)
+	require.Len(t, timeline[2].NodeChanges, 1)
+	assert.Equal(t, "worker-0", timeline[2].NodeChanges[0].Name)
+
+	assert.Equal(t, SeverityInfo, timeline[3].Severity)
+}
+
+func TestTimelineSelectSnapshots(t *testing.T) {
+	store := newSnapshotStoreAt(t.TempDir())
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	all := populateStore(t, store, "abc", base, "Ready", "Ready", "Ready", "Ready")
+
+	tests := []struct {
+		name string
+		opts timelineOptions
+		want []StoredSnapshot
+	}{
+		{name: "all", want: all},
+		{name: "since", opts: timelineOptions{Since: 90 * time.Minute}, want: all[2:]},
+		{name: "from and to", opts: timelineOptions{From: "20250101T13", To: "latest~1"}, want: all[1:3]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.opts
+			o.ClusterID = "abc"
+			o.store = store
+			got, err := o.selectSnapshots(base.Add(3*time.Hour + 30*time.Minute))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTimelineRun(t *testing.T) {
+	store := newSnapshotStoreAt(t.TempDir())
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	populateStore(t, store, "abc", base, "Ready", "Ready")
+
+	tests := []struct {
+		name    string
+		opts    timelineOptions
+		wantErr string
+	}{
+		{name: "since and from", opts: timelineOptions{ClusterID: "abc", Since: time.Hour, From: "latest"}, wantErr: "cannot be used together"},
+		{name: "unknown cluster", opts: timelineOptions{ClusterID: "unknown"}, wantErr: "no snapshots stored"},
+		{name: "empty range", opts: timelineOptions{ClusterID: "abc", Since: time.Minute}, wantErr: "match the selected range"},
+		{name: "missing rules file", opts: timelineOptions{ClusterID: "abc", RulesFile: "does-not-exist.yaml"}, wantErr: "does-not-exist.yaml"},
+		{name: "unsupported output format", opts: timelineOptions{ClusterID: "abc", Output: output.Options{Format: "xml"}}, wantErr: "unsupported output format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.opts
+			o.store = store
+			err := o.run()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
+	elbtypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/graph"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// topologyOptions defines the struct for running the topology command
+type topologyOptions struct {
+	clusterID  string
+	awsProfile string
+	output     string
+}
+
+func newCmdTopology() *cobra.Command {
+	ops := &topologyOptions{}
+	topologyCmd := &cobra.Command{
+		Use:   "topology",
+		Short: "Render the AWS network topology of a classic cluster as a graph",
+		Long: `Render the AWS network topology of a classic cluster as a graph.
+
+The graph holds the VPC of the cluster, its subnets and their route tables, the
+gateways the routes lead to (internet, NAT and transit gateways, peering
+connections), the load balancers of the VPC and the Route53 zones of the cluster,
+linked to the load balancers their records alias.
+
+The graph is written as Graphviz DOT, Mermaid, JSON, or as an SVG image rendered
+without Graphviz. Use 'osdctl cluster hypershift-info' for hosted control plane clusters.`,
+		Example: `  # Render the network topology of a cluster as an SVG image
+  osdctl cluster topology --cluster-id ${CLUSTER_ID} -o svg > topology.svg
+
+  # Render the network topology of a cluster with Graphviz
+  osdctl cluster topology --cluster-id ${CLUSTER_ID} | dot -Tpng -o topology.png`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(ops.complete())
+			cmdutil.CheckErr(ops.run())
+		},
+	}
+	topologyCmd.Flags().StringVarP(&ops.clusterID, "cluster-id", "C", "", "Provide internal ID of the cluster")
+	topologyCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS Profile")
+	topologyCmd.Flags().StringVarP(&ops.output, "output", "o", graph.DOT, fmt.Sprintf("output format %v", graph.Formats))
+	_ = topologyCmd.MarkFlagRequired("cluster-id")
+
+	return topologyCmd
+}
+
+func (o *topologyOptions) complete() error {
+	if !slices.Contains(graph.Formats, o.output) {
+		return fmt.Errorf("output must be one of: %s", strings.Join(graph.Formats, ", "))
+	}
+	return nil
+}
+
+func (o *topologyOptions) run() error {
+	ocmClient, err := utils.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetClusterAnyStatus(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.Hypershift().Enabled() {
+		return fmt.Errorf("cluster %s has a hosted control plane, use 'osdctl cluster hypershift-info' instead", cluster.ID())
+	}
+	if cluster.CloudProvider().ID() != "aws" {
+		return fmt.Errorf("cluster %s is not an AWS cluster", cluster.ID())
+	}
+
+	awsClient, err := osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+	if err != nil {
+		return err
+	}
+	info, err := gatherNetworkInfo(awsClient, cluster)
+	if err != nil {
+		return err
+	}
+
+	g := graph.New()
+	g.AddSubgraph("cluster", fmt.Sprintf("Cluster %s", cluster.Name()))
+	addNetwork(g, "cluster", info)
+	return graph.Render(os.Stdout, g, o.output)
+}
+
+// networkInfo holds the network resources of a cluster in its AWS account
+type networkInfo struct {
+	Vpcs                 []ec2types.Vpc
+	Subnets              []ec2types.Subnet
+	RouteTables          []ec2types.RouteTable
+	LoadBalancers        []elbv2types.LoadBalancer
+	ClassicLoadBalancers []elbtypes.LoadBalancerDescription
+	HostedZones          []route53types.HostedZone
+	// ResourceRecords are the records of each hosted zone, by zone ID
+	ResourceRecords map[string][]route53types.ResourceRecordSet
+}
+
+// gatherNetworkInfo retrieves the network resources of a classic cluster: the subnets of the
+// cluster, their VPCs and the route tables, load balancers of the VPCs, and the hosted zones of
+// the cluster domain
+func gatherNetworkInfo(client aws.Client, cluster *v1.Cluster) (*networkInfo, error) {
+	info := &networkInfo{ResourceRecords: map[string][]route53types.ResourceRecordSet{}}
+
+	subnetsInput := &ec2.DescribeSubnetsInput{SubnetIds: cluster.AWS().SubnetIDs()}
+	if len(subnetsInput.SubnetIds) == 0 {
+		// The subnets of clusters installed in their own VPC are tagged with the infra ID
+		subnetsInput.Filters = []ec2types.Filter{{
+			Name:   awsSdk.String("tag-key"),
+			Values: []string{fmt.Sprintf("kubernetes.io/cluster/%s", cluster.InfraID())},
+		}}
+	}
+	subnets, err := client.DescribeSubnets(subnetsInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the subnets of the cluster: %w", err)
+	}
+	info.Subnets = subnets.Subnets
+
+	var vpcIDs []string
+	for _, subnet := range info.Subnets {
+		if subnet.VpcId != nil && !slices.Contains(vpcIDs, *subnet.VpcId) {
+			vpcIDs = append(vpcIDs, *subnet.VpcId)
+		}
+	}
+	if len(vpcIDs) == 0 {
+		return nil, errors.New("no subnets found for the cluster")
+	}
+
+	vpcs, err := client.DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: vpcIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the VPC of the cluster: %w", err)
+	}
+	info.Vpcs = vpcs.Vpcs
+
+	routeTables, err := client.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
+		Filters: []ec2types.Filter{{Name: awsSdk.String("vpc-id"), Values: vpcIDs}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the route tables of the VPC: %w", err)
+	}
+	info.RouteTables = routeTables.RouteTables
+
+	v2Input := &elasticloadbalancingv2.DescribeLoadBalancersInput{}
+	for {
+		loadBalancers, err := client.DescribeV2LoadBalancers(v2Input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe the load balancers: %w", err)
+		}
+		for _, lb := range loadBalancers.LoadBalancers {
+			if lb.VpcId != nil && slices.Contains(vpcIDs, *lb.VpcId) {
+				info.LoadBalancers = append(info.LoadBalancers, lb)
+			}
+		}
+		if loadBalancers.NextMarker == nil {
+			break
+		}
+		v2Input.Marker = loadBalancers.NextMarker
+	}
+
+	classicInput := &elasticloadbalancing.DescribeLoadBalancersInput{}
+	for {
+		loadBalancers, err := client.DescribeLoadBalancers(classicInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe the classic load balancers: %w", err)
+		}
+		for _, lb := range loadBalancers.LoadBalancerDescriptions {
+			if lb.VPCId != nil && slices.Contains(vpcIDs, *lb.VPCId) {
+				info.ClassicLoadBalancers = append(info.ClassicLoadBalancers, lb)
+			}
+		}
+		if loadBalancers.NextMarker == nil {
+			break
+		}
+		classicInput.Marker = loadBalancers.NextMarker
+	}
+
+	info.HostedZones, err = getHostedZones(client, fmt.Sprintf("%s.%s", cluster.Name(), cluster.DNS().BaseDomain()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the hosted zones of the cluster: %w", err)
+	}
+	for _, zone := range info.HostedZones {
+		input := &route53.ListResourceRecordSetsInput{HostedZoneId: zone.Id}
+		for {
+			records, err := client.ListResourceRecordSets(input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list the records of hosted zone %s: %w", safeDeref(zone.Id), err)
+			}
+			info.ResourceRecords[safeDeref(zone.Id)] = append(info.ResourceRecords[safeDeref(zone.Id)], records.ResourceRecordSets...)
+			if !records.IsTruncated {
+				break
+			}
+			input.StartRecordName = records.NextRecordName
+			input.StartRecordType = records.NextRecordType
+			input.StartRecordIdentifier = records.NextRecordIdentifier
+		}
+	}
+
+	return info, nil
+}
+
+// addNetwork adds the network resources to a subgraph: the VPCs and their subnets, the route
+// tables of the subnets and the gateways of their routes, the load balancers in the subnets, and
+// the hosted zones whose records alias the load balancers
+func addNetwork(g *graph.Graph, subgraph string, info *networkInfo) {
+	for _, vpc := range info.Vpcs {
+		g.AddNode(graph.Node{ID: safeDeref(vpc.VpcId), Kind: "VPC", Name: withNameTag(safeDeref(vpc.CidrBlock), vpc.Tags), Subgraph: subgraph})
+	}
+
+	explicitlyAssociated := map[string]bool{}
+	for _, subnet := range info.Subnets {
+		g.AddNode(graph.Node{ID: safeDeref(subnet.VpcId), Kind: "VPC", Subgraph: subgraph})
+		g.AddNode(graph.Node{
+			ID:       safeDeref(subnet.SubnetId),
+			Kind:     "Subnet",
+			Name:     withNameTag(strings.TrimSpace(safeDeref(subnet.AvailabilityZone)+" "+safeDeref(subnet.CidrBlock)), subnet.Tags),
+			Subgraph: subgraph,
+		})
+		g.AddEdge(safeDeref(subnet.VpcId), safeDeref(subnet.SubnetId), "")
+	}
+	for _, rtb := range info.RouteTables {
+		for _, association := range rtb.Associations {
+			if association.SubnetId != nil {
+				explicitlyAssociated[*association.SubnetId] = true
+			}
+		}
+	}
+
+	for _, rtb := range info.RouteTables {
+		rtbID := safeDeref(rtb.RouteTableId)
+		var subnets []string
+		for _, association := range rtb.Associations {
+			if association.SubnetId != nil {
+				subnets = append(subnets, *association.SubnetId)
+			}
+			if association.Main != nil && *association.Main {
+				// The main route table is used by the subnets without a route table of their own
+				for _, subnet := range info.Subnets {
+					if safeDeref(subnet.VpcId) == safeDeref(rtb.VpcId) && !explicitlyAssociated[safeDeref(subnet.SubnetId)] {
+						subnets = append(subnets, safeDeref(subnet.SubnetId))
+					}
+				}
+			}
+		}
+		if len(subnets) == 0 {
+			continue
+		}
+
+		g.AddNode(graph.Node{ID: rtbID, Kind: "Route Table", Name: withNameTag("", rtb.Tags), Subgraph: subgraph})
+		for _, subnet := range subnets {
+			if g.HasNode(subnet) {
+				g.AddEdge(subnet, rtbID, "")
+			}
+		}
+		for _, route := range rtb.Routes {
+			target, kind := routeTarget(route)
+			if target == "" {
+				continue
+			}
+			g.AddNode(graph.Node{ID: target, Kind: kind, Subgraph: subgraph})
+			g.AddEdge(rtbID, target, routeDestination(route))
+		}
+	}
+
+	lbByDNSName := map[string]string{}
+	for _, lb := range info.LoadBalancers {
+		id := safeDeref(lb.LoadBalancerArn)
+		g.AddNode(graph.Node{
+			ID:       id,
+			Kind:     "Load Balancer",
+			Name:     strings.Join([]string{string(lb.Type), string(lb.Scheme), safeDeref(lb.LoadBalancerName)}, " "),
+			Subgraph: subgraph,
+		})
+		for _, az := range lb.AvailabilityZones {
+			if g.HasNode(safeDeref(az.SubnetId)) {
+				g.AddEdge(safeDeref(az.SubnetId), id, "")
+			}
+		}
+		lbByDNSName[normalizeDNSName(safeDeref(lb.DNSName))] = id
+	}
+	for _, lb := range info.ClassicLoadBalancers {
+		id := safeDeref(lb.LoadBalancerName)
+		g.AddNode(graph.Node{ID: id, Kind: "Classic Load Balancer", Name: safeDeref(lb.Scheme), Subgraph: subgraph})
+		for _, subnet := range lb.Subnets {
+			if g.HasNode(subnet) {
+				g.AddEdge(subnet, id, "")
+			}
+		}
+		lbByDNSName[normalizeDNSName(safeDeref(lb.DNSName))] = id
+	}
+
+	for _, zone := range info.HostedZones {
+		zoneID := safeDeref(zone.Id)
+		visibility := "public"
+		if zone.Config != nil && zone.Config.PrivateZone {
+			visibility = "private"
+		}
+		g.AddNode(graph.Node{ID: zoneID, Kind: "Hosted Zone", Name: fmt.Sprintf("%s (%s)", strings.TrimSuffix(safeDeref(zone.Name), "."), visibility), Subgraph: subgraph})
+		for _, record := range info.ResourceRecords[zoneID] {
+			if record.AliasTarget == nil {
+				continue
+			}
+			if lb, ok := lbByDNSName[normalizeDNSName(safeDeref(record.AliasTarget.DNSName))]; ok {
+				g.AddEdge(zoneID, lb, strings.TrimSuffix(safeDeref(record.Name), "."))
+			}
+		}
+	}
+}
+
+// routeTarget returns the gateway or connection a route leads to and its kind, or nothing for
+// the routes local to the VPC
+func routeTarget(route ec2types.Route) (string, string) {
+	switch {
+	case route.NatGatewayId != nil:
+		return *route.NatGatewayId, "NAT Gateway"
+	case route.TransitGatewayId != nil:
+		return *route.TransitGatewayId, "Transit Gateway"
+	case route.VpcPeeringConnectionId != nil:
+		return *route.VpcPeeringConnectionId, "Peering Connection"
+	case route.GatewayId != nil && strings.HasPrefix(*route.GatewayId, "igw-"):
+		return *route.GatewayId, "Internet Gateway"
+	case route.GatewayId != nil && strings.HasPrefix(*route.GatewayId, "vgw-"):
+		return *route.GatewayId, "VPN Gateway"
+	case route.GatewayId != nil && strings.HasPrefix(*route.GatewayId, "vpce-"):
+		return *route.GatewayId, "VPC Endpoint"
+	case route.LocalGatewayId != nil:
+		return *route.LocalGatewayId, "Local Gateway"
+	case route.NetworkInterfaceId != nil:
+		return *route.NetworkInterfaceId, "Network Interface"
+	default:
+		return "", ""
+	}
+}
+
+func routeDestination(route ec2types.Route) string {
+	switch {
+	case route.DestinationCidrBlock != nil:
+		return *route.DestinationCidrBlock
+	case route.DestinationIpv6CidrBlock != nil:
+		return *route.DestinationIpv6CidrBlock
+	default:
+		return safeDeref(route.DestinationPrefixListId)
+	}
+}
+
+// withNameTag appends the Name tag of a resource to its description
+func withNameTag(description string, tags []ec2types.Tag) string {
+	for _, tag := range tags {
+		if safeDeref(tag.Key) == "Name" && safeDeref(tag.Value) != "" {
+			return strings.TrimSpace(description + " " + *tag.Value)
+		}
+	}
+	return description
+}
+
+// normalizeDNSName returns a DNS name as found both in load balancers and in the alias records
+// targeting them
+func normalizeDNSName(name string) string {
+	return strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(name), "."), "dualstack.")
+}
@@ -0,0 +1,210 @@
+package cluster
+
+import (
+	"bytes"
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/graph"
+	mock_aws "github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func testNetworkInfo() *networkInfo {
+	return &networkInfo{
+		Vpcs: []ec2types.Vpc{
+			{VpcId: awsSdk.String("vpc-1"), CidrBlock: awsSdk.String("10.0.0.0/16"), Tags: []ec2types.Tag{{Key: awsSdk.String("Name"), Value: awsSdk.String("test-abcd-vpc")}}},
+		},
+		Subnets: []ec2types.Subnet{
+			{SubnetId: awsSdk.String("subnet-public"), VpcId: awsSdk.String("vpc-1"), AvailabilityZone: awsSdk.String("us-east-1a"), CidrBlock: awsSdk.String("10.0.0.0/20")},
+			{SubnetId: awsSdk.String("subnet-private"), VpcId: awsSdk.String("vpc-1"), AvailabilityZone: awsSdk.String("us-east-1a"), CidrBlock: awsSdk.String("10.0.128.0/20")},
+		},
+		RouteTables: []ec2types.RouteTable{
+			{
+				RouteTableId: awsSdk.String("rtb-main"),
+				VpcId:        awsSdk.String("vpc-1"),
+				Associations: []ec2types.RouteTableAssociation{{Main: awsSdk.Bool(true)}},
+				Routes: []ec2types.Route{
+					{DestinationCidrBlock: awsSdk.String("10.0.0.0/16"), GatewayId: awsSdk.String("local")},
+					{DestinationCidrBlock: awsSdk.String("0.0.0.0/0"), GatewayId: awsSdk.String("igw-1")},
+				},
+			},
+			{
+				RouteTableId: awsSdk.String("rtb-private"),
+				VpcId:        awsSdk.String("vpc-1"),
+				Associations: []ec2types.RouteTableAssociation{{SubnetId: awsSdk.String("subnet-private")}},
+				Routes: []ec2types.Route{
+					{DestinationCidrBlock: awsSdk.String("0.0.0.0/0"), NatGatewayId: awsSdk.String("nat-1")},
+					{DestinationPrefixListId: awsSdk.String("pl-s3"), GatewayId: awsSdk.String("vpce-s3")},
+				},
+			},
+		},
+		LoadBalancers: []elbv2types.LoadBalancer{
+			{
+				LoadBalancerArn:   awsSdk.String("arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/net/test-abcd-ext/1"),
+				LoadBalancerName:  awsSdk.String("test-abcd-ext"),
+				Type:              elbv2types.LoadBalancerTypeEnumNetwork,
+				Scheme:            elbv2types.LoadBalancerSchemeEnumInternetFacing,
+				DNSName:           awsSdk.String("test-abcd-ext-1.elb.us-east-1.amazonaws.com"),
+				AvailabilityZones: []elbv2types.AvailabilityZone{{SubnetId: awsSdk.String("subnet-public")}},
+			},
+		},
+		HostedZones: []route53types.HostedZone{
+			{Id: awsSdk.String("/hostedzone/Z1"), Name: awsSdk.String("test.abcd.p1.openshiftapps.com."), Config: &route53types.HostedZoneConfig{PrivateZone: false}},
+		},
+		ResourceRecords: map[string][]route53types.ResourceRecordSet{
+			"/hostedzone/Z1": {
+				{Name: awsSdk.String("api.test.abcd.p1.openshiftapps.com."), AliasTarget: &route53types.AliasTarget{DNSName: awsSdk.String("dualstack.test-abcd-ext-1.elb.us-east-1.amazonaws.com.")}},
+				{Name: awsSdk.String("test.abcd.p1.openshiftapps.com."), Type: route53types.RRTypeNs},
+			},
+		},
+	}
+}
+
+func TestAddNetwork(t *testing.T) {
+	g := graph.New()
+	g.AddSubgraph("cluster", "Cluster test")
+	addNetwork(g, "cluster", testNetworkInfo())
+
+	var out bytes.Buffer
+	require.NoError(t, g.WriteDOT(&out))
+	assert.Equal(t, `strict graph {
+  node [shape=box]
+  subgraph "cluster_cluster" {
+    label="Cluster test"
+    "/hostedzone/Z1" [label="Hosted Zone\ntest.abcd.p1.openshiftapps.com (public)\n/hostedzone/Z1"]
+    "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/net/test-abcd-ext/1" [label="Load Balancer\nnetwork internet-facing test-abcd-ext\narn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/net/test-abcd-ext/1"]
+    "igw-1" [label="Internet Gateway\nigw-1"]
+    "nat-1" [label="NAT Gateway\nnat-1"]
+    "rtb-main" [label="Route Table\nrtb-main"]
+    "rtb-private" [label="Route Table\nrtb-private"]
+    "subnet-private" [label="Subnet\nus-east-1a 10.0.128.0/20\nsubnet-private"]
+    "subnet-public" [label="Subnet\nus-east-1a 10.0.0.0/20\nsubnet-public"]
+    "vpc-1" [label="VPC\n10.0.0.0/16 test-abcd-vpc\nvpc-1"]
+    "vpce-s3" [label="VPC Endpoint\nvpce-s3"]
+  }
+  "/hostedzone/Z1" -- "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/net/test-abcd-ext/1" [label="api.test.abcd.p1.openshiftapps.com"]
+  "rtb-main" -- "igw-1" [label="0.0.0.0/0"]
+  "rtb-private" -- "nat-1" [label="0.0.0.0/0"]
+  "rtb-private" -- "vpce-s3" [label="pl-s3"]
+  "subnet-private" -- "rtb-private"
+  "subnet-public" -- "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/net/test-abcd-ext/1"
+  "subnet-public" -- "rtb-main"
+  "vpc-1" -- "subnet-private"
+  "vpc-1" -- "subnet-public"
+}
+`, out.String())
+}
+
+func TestGatherNetworkInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mock_aws.NewMockClient(ctrl)
+
+	cluster, err := v1.NewCluster().Name("test").InfraID("test-abcd").DNS(v1.NewDNS().BaseDomain("abcd.p1.openshiftapps.com")).Build()
+	require.NoError(t, err)
+	info := testNetworkInfo()
+	for i := range info.LoadBalancers {
+		info.LoadBalancers[i].VpcId = awsSdk.String("vpc-1")
+	}
+
+	mockClient.EXPECT().DescribeSubnets(&ec2.DescribeSubnetsInput{
+		Filters: []ec2types.Filter{{Name: awsSdk.String("tag-key"), Values: []string{"kubernetes.io/cluster/test-abcd"}}},
+	}).Return(&ec2.DescribeSubnetsOutput{Subnets: info.Subnets}, nil)
+	mockClient.EXPECT().DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: []string{"vpc-1"}}).Return(&ec2.DescribeVpcsOutput{Vpcs: info.Vpcs}, nil)
+	mockClient.EXPECT().DescribeRouteTables(gomock.Any()).Return(&ec2.DescribeRouteTablesOutput{RouteTables: info.RouteTables}, nil)
+	mockClient.EXPECT().DescribeV2LoadBalancers(gomock.Any()).Return(&elasticloadbalancingv2.DescribeLoadBalancersOutput{
+		LoadBalancers: append(info.LoadBalancers, elbv2types.LoadBalancer{LoadBalancerArn: awsSdk.String("other-vpc"), VpcId: awsSdk.String("vpc-2")}),
+		NextMarker:    awsSdk.String("page-2"),
+	}, nil)
+	mockClient.EXPECT().DescribeV2LoadBalancers(&elasticloadbalancingv2.DescribeLoadBalancersInput{Marker: awsSdk.String("page-2")}).Return(&elasticloadbalancingv2.DescribeLoadBalancersOutput{}, nil)
+	mockClient.EXPECT().DescribeLoadBalancers(gomock.Any()).Return(&elasticloadbalancing.DescribeLoadBalancersOutput{}, nil)
+	mockClient.EXPECT().ListHostedZones(gomock.Any()).Return(&route53.ListHostedZonesOutput{
+		HostedZones: append(info.HostedZones, route53types.HostedZone{Id: awsSdk.String("/hostedzone/Z2"), Name: awsSdk.String("other.abcd.p1.openshiftapps.com.")}),
+	}, nil)
+	mockClient.EXPECT().ListResourceRecordSets(&route53.ListResourceRecordSetsInput{HostedZoneId: awsSdk.String("/hostedzone/Z1")}).Return(&route53.ListResourceRecordSetsOutput{
+		ResourceRecordSets: info.ResourceRecords["/hostedzone/Z1"],
+	}, nil)
+
+	gathered, err := gatherNetworkInfo(mockClient, cluster)
+	require.NoError(t, err)
+	assert.Equal(t, info.Subnets, gathered.Subnets)
+	assert.Equal(t, info.Vpcs, gathered.Vpcs)
+	assert.Equal(t, info.LoadBalancers, gathered.LoadBalancers, "only the load balancers of the VPC are kept")
+	assert.Equal(t, info.HostedZones, gathered.HostedZones, "only the hosted zones of the cluster are kept")
+	assert.Equal(t, info.ResourceRecords, gathered.ResourceRecords)
+}
+
+func TestCreateGraph(t *testing.T) {
+	info := testNetworkInfo()
+	ai := &aggregateClusterInfo{
+		privatelinkInfo: &privatelinkInfo{
+			HostedZones: []route53types.HostedZone{{Id: awsSdk.String("/hostedzone/ZPL"), Name: awsSdk.String("hcp.example.com.")}},
+			ResourceRecords: []route53types.ResourceRecordSet{
+				{ResourceRecords: []route53types.ResourceRecord{{Value: awsSdk.String("vpce-pl.vpce-svc-1.us-east-1.vpce.amazonaws.com")}}},
+			},
+		},
+		managementClusterInfo: &managementClusterInfo{
+			EndpointServices: []ec2types.ServiceDetail{
+				{ServiceId: awsSdk.String("vpce-svc-1"), BaseEndpointDnsNames: []string{"vpce-svc-1.us-east-1.vpce.amazonaws.com"}},
+			},
+			EndpointConnections: []ec2types.VpcEndpointConnection{
+				{VpcEndpointConnectionId: awsSdk.String("vpce-con-1"), VpcEndpointId: awsSdk.String("vpce-customer"), NetworkLoadBalancerArns: []string{"mgmt-nlb"}},
+			},
+		},
+		clusterInfo: &clusterInfo{
+			Endpoints: []ec2types.VpcEndpoint{
+				{VpcEndpointId: awsSdk.String("vpce-customer"), ServiceName: awsSdk.String("com.amazonaws.vpce.us-east-1.vpce-svc-1"), SubnetIds: []string{"subnet-private"}},
+			},
+			Subnets:           info.Subnets,
+			SubnetRouteTables: info.RouteTables[1:],
+		},
+	}
+
+	g := createGraph(ai)
+	var out bytes.Buffer
+	require.NoError(t, g.WriteJSON(&out))
+	assert.JSONEq(t, `{
+		"subgraphs": [
+			{"id": "customer", "label": "Customer Cluster"},
+			{"id": "management", "label": "Management Cluster"},
+			{"id": "privatelink", "label": "Privatelink Account"}
+		],
+		"nodes": [
+			{"id": "nat-1", "kind": "NAT Gateway", "subgraph": "customer"},
+			{"id": "rtb-private", "kind": "Route Table", "subgraph": "customer"},
+			{"id": "subnet-private", "kind": "Subnet", "name": "us-east-1a 10.0.128.0/20", "subgraph": "customer"},
+			{"id": "subnet-public", "kind": "Subnet", "name": "us-east-1a 10.0.0.0/20", "subgraph": "customer"},
+			{"id": "vpc-1", "kind": "VPC", "subgraph": "customer"},
+			{"id": "vpce-customer", "kind": "VPC Endpoint", "name": "com.amazonaws.vpce.us-east-1.vpce-svc-1", "subgraph": "customer"},
+			{"id": "vpce-s3", "kind": "VPC Endpoint", "subgraph": "customer"},
+			{"id": "mgmt-nlb", "kind": "Load Balancer", "subgraph": "management"},
+			{"id": "vpce-con-1", "kind": "Endpoint Connection", "subgraph": "management"},
+			{"id": "vpce-svc-1", "kind": "Endpoint Service", "subgraph": "management"},
+			{"id": "/hostedzone/ZPL", "kind": "Hosted Zone", "name": "hcp.example.com.", "subgraph": "privatelink"},
+			{"id": "vpce-pl.vpce-svc-1.us-east-1.vpce.amazonaws.com", "kind": "VPC Endpoint", "subgraph": "privatelink"}
+		],
+		"edges": [
+			{"from": "rtb-private", "to": "nat-1", "label": "0.0.0.0/0"},
+			{"from": "rtb-private", "to": "vpce-s3", "label": "pl-s3"},
+			{"from": "subnet-private", "to": "rtb-private"},
+			{"from": "subnet-private", "to": "vpce-customer"},
+			{"from": "vpc-1", "to": "subnet-private"},
+			{"from": "vpc-1", "to": "subnet-public"},
+			{"from": "vpce-con-1", "to": "mgmt-nlb"},
+			{"from": "vpce-con-1", "to": "vpce-customer"},
+			{"from": "vpce-pl.vpce-svc-1.us-east-1.vpce.amazonaws.com", "to": "vpce-svc-1"},
+			{"from": "vpce-svc-1", "to": "vpce-con-1"}
+		]
+	}`, out.String())
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/openshift/osdctl/cmd/jira"
 	"github.com/openshift/osdctl/cmd/jumphost"
 	"github.com/openshift/osdctl/cmd/mc"
+	"github.com/openshift/osdctl/cmd/mcp"
 	"github.com/openshift/osdctl/cmd/network"
 	"github.com/openshift/osdctl/cmd/org"
 	"github.com/openshift/osdctl/cmd/promote"
@@ -119,6 +120,7 @@ func NewCmdRoot(streams genericclioptions.IOStreams) *cobra.Command {
 	addToRootCmdWithOtherGlobalOpts(iampermissions.NewCmdIamPermissions())
 	rootCmd.AddCommand(dynatrace.NewCmdDynatrace())
 	rootCmd.AddCommand(rhobs.NewCmdRhobs())
+	rootCmd.AddCommand(mcp.NewCmdMcp())
 
 	// Add cost command to use AWS Cost Manager
 	addToRootCmdWithOtherGlobalOpts(cost.NewCmdCost(streams, globalOpts))
@@ -0,0 +1,293 @@
+package dynatrace
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Data sources of the DQL query builder
+const (
+	DQLSourceLogs      = "logs"
+	DQLSourceEvents    = "events"
+	DQLSourceSpans     = "spans"
+	DQLSourceBizEvents = "bizevents"
+	DQLSourceMetrics   = "timeseries"
+)
+
+// DQLSources lists the data sources accepted by DQLQuery
+var DQLSources = []string{DQLSourceLogs, DQLSourceEvents, DQLSourceSpans, DQLSourceBizEvents, DQLSourceMetrics}
+
+// Operators of a DQLFilter
+const (
+	DQLFilterEqual    = "="
+	DQLFilterNotEqual = "!="
+	DQLFilterContains = "~"
+)
+
+// DQLAggregations lists the aggregations of a timeseries query
+var DQLAggregations = []string{"avg", "sum", "min", "max", "count"}
+
+// dqlFieldRegex matches the field and metric names which can be written without backticks
+var dqlFieldRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// DQLFilter restricts the records of a query to those whose field:
+//   - matches one of the values (=)
+//   - matches none of the values (!=)
+//   - contains the value, ignoring case (~)
+type DQLFilter struct {
+	Field    string
+	Operator string
+	Values   []string
+}
+
+// ParseDQLFilter parses a filter of the form field=value1,value2, field!=value1,value2 or
+// field~phrase
+func ParseDQLFilter(filter string) (DQLFilter, error) {
+	i := strings.IndexAny(filter, "!=~")
+	if i <= 0 {
+		return DQLFilter{}, fmt.Errorf("invalid filter %q, expecting field=value, field!=value or field~phrase", filter)
+	}
+
+	f := DQLFilter{Field: strings.TrimSpace(filter[:i])}
+	value := filter[i+1:]
+	switch {
+	case filter[i] == '!' && strings.HasPrefix(value, "="):
+		f.Operator = DQLFilterNotEqual
+		value = value[1:]
+	case filter[i] == '=':
+		f.Operator = DQLFilterEqual
+	case filter[i] == '~':
+		f.Operator = DQLFilterContains
+	default:
+		return DQLFilter{}, fmt.Errorf("invalid filter %q, expecting field=value, field!=value or field~phrase", filter)
+	}
+
+	// A phrase may contain commas
+	if f.Operator == DQLFilterContains {
+		f.Values = []string{value}
+	} else {
+		f.Values = strings.Split(value, ",")
+	}
+	return f, f.validate()
+}
+
+func (f DQLFilter) validate() error {
+	if !dqlFieldRegex.MatchString(f.Field) {
+		return fmt.Errorf("invalid field name %q", f.Field)
+	}
+	if f.Operator != DQLFilterEqual && f.Operator != DQLFilterNotEqual && f.Operator != DQLFilterContains {
+		return fmt.Errorf("invalid operator %q for field %s", f.Operator, f.Field)
+	}
+	if len(f.Values) == 0 {
+		return fmt.Errorf("no value to filter field %s on", f.Field)
+	}
+	if f.Operator == DQLFilterContains && len(f.Values) != 1 {
+		return fmt.Errorf("field %s can only be filtered on one phrase", f.Field)
+	}
+	return nil
+}
+
+// String returns the DQL condition of the filter
+func (f DQLFilter) String() string {
+	if f.Operator == DQLFilterContains {
+		return fmt.Sprintf("contains(%s, %s, caseSensitive:false)", f.Field, dqlString(f.Values[0]))
+	}
+
+	conditions := make([]string, len(f.Values))
+	for i, value := range f.Values {
+		conditions[i] = fmt.Sprintf("matchesValue(%s, %s)", f.Field, dqlString(value))
+	}
+	condition := strings.Join(conditions, " or ")
+	if len(conditions) > 1 {
+		condition = "(" + condition + ")"
+	}
+	if f.Operator == DQLFilterNotEqual {
+		if len(conditions) == 1 {
+			condition = "(" + condition + ")"
+		}
+		condition = "not " + condition
+	}
+	return condition
+}
+
+// DQLQuery describes a query of one data source. Unlike DTQuery, the values of the query are
+// escaped and the field names validated when it is built.
+type DQLQuery struct {
+	Source string
+	// Since selects the records of the last Since, unless From and To are set
+	Since time.Duration
+	From  time.Time
+	To    time.Time
+	// Filters are all met by the records
+	Filters []DQLFilter
+	// Fields are the fields kept in the records, all of them when empty
+	Fields []string
+	// SortOrder sorts the records by timestamp, either "asc" or "desc"
+	SortOrder string
+	// Limit is the maximum number of records, unlimited when 0
+	Limit int
+
+	// Metric is aggregated with Aggregation over Interval by the timeseries data source, split
+	// by the By dimensions
+	Metric      string
+	Aggregation string
+	By          []string
+	Interval    time.Duration
+}
+
+// Build validates the query and returns its DQL
+func (q DQLQuery) Build() (string, error) {
+	timeframe, err := q.timeframe()
+	if err != nil {
+		return "", err
+	}
+	for _, filter := range q.Filters {
+		if err := filter.validate(); err != nil {
+			return "", err
+		}
+	}
+	if q.Limit < 0 {
+		return "", fmt.Errorf("invalid limit %d", q.Limit)
+	}
+
+	var query string
+	switch q.Source {
+	case DQLSourceLogs, DQLSourceEvents, DQLSourceSpans, DQLSourceBizEvents:
+		query, err = q.buildFetch(timeframe)
+	case DQLSourceMetrics:
+		query, err = q.buildTimeseries(timeframe)
+	default:
+		return "", fmt.Errorf("invalid data source %q, valid sources are: %s", q.Source, strings.Join(DQLSources, ", "))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if q.Limit > 0 {
+		query += fmt.Sprintf("\n| limit %d", q.Limit)
+	}
+	return query, nil
+}
+
+// buildFetch returns the query of a data source holding records, e.g. logs or spans
+func (q DQLQuery) buildFetch(timeframe string) (string, error) {
+	if q.Metric != "" || len(q.By) > 0 || q.Interval > 0 {
+		return "", fmt.Errorf("metric, dimensions and interval can only be set for the %s data source", DQLSourceMetrics)
+	}
+
+	query := fmt.Sprintf("fetch %s, %s", q.Source, timeframe)
+
+	if len(q.Filters) > 0 {
+		query += "\n| filter " + q.conditions()
+	}
+
+	if len(q.Fields) > 0 {
+		for _, field := range q.Fields {
+			if !dqlFieldRegex.MatchString(field) {
+				return "", fmt.Errorf("invalid field name %q", field)
+			}
+		}
+		query += "\n| fields " + strings.Join(q.Fields, ", ")
+	}
+
+	switch q.SortOrder {
+	case "":
+	case "asc", "desc":
+		query += "\n| sort timestamp " + q.SortOrder
+	default:
+		return "", fmt.Errorf("invalid sort order %q, expecting 'asc' or 'desc'", q.SortOrder)
+	}
+	return query, nil
+}
+
+// buildTimeseries returns the query of a metric, whose values are named "value"
+func (q DQLQuery) buildTimeseries(timeframe string) (string, error) {
+	if !dqlFieldRegex.MatchString(q.Metric) {
+		return "", fmt.Errorf("invalid metric name %q", q.Metric)
+	}
+	aggregation := q.Aggregation
+	if aggregation == "" {
+		aggregation = "avg"
+	}
+	if !slices.Contains(DQLAggregations, aggregation) {
+		return "", fmt.Errorf("invalid aggregation %q, valid aggregations are: %s", aggregation, strings.Join(DQLAggregations, ", "))
+	}
+	if len(q.Fields) > 0 || q.SortOrder != "" {
+		return "", fmt.Errorf("fields and sort order can't be set for the %s data source", DQLSourceMetrics)
+	}
+
+	query := fmt.Sprintf("timeseries value=%s(%s)", aggregation, q.Metric)
+	if len(q.By) > 0 {
+		for _, dimension := range q.By {
+			if !dqlFieldRegex.MatchString(dimension) {
+				return "", fmt.Errorf("invalid dimension name %q", dimension)
+			}
+		}
+		query += ", by:{" + strings.Join(q.By, ", ") + "}"
+	}
+	if q.Interval > 0 {
+		interval, err := dqlDuration(q.Interval)
+		if err != nil {
+			return "", err
+		}
+		query += ", interval:" + interval
+	}
+	query += ", " + timeframe
+	if len(q.Filters) > 0 {
+		query += ", filter:{" + q.conditions() + "}"
+	}
+	return query, nil
+}
+
+// conditions returns the conditions of all filters
+func (q DQLQuery) conditions() string {
+	conditions := make([]string, len(q.Filters))
+	for i, filter := range q.Filters {
+		conditions[i] = filter.String()
+	}
+	return strings.Join(conditions, " and ")
+}
+
+// timeframe returns the from and to parameters of the query
+func (q DQLQuery) timeframe() (string, error) {
+	if !q.From.IsZero() || !q.To.IsZero() {
+		if q.From.IsZero() || q.To.IsZero() {
+			return "", fmt.Errorf("both the start and end of the time range must be set")
+		}
+		if q.To.Before(q.From) {
+			return "", fmt.Errorf("the end of the time range is before its start")
+		}
+		return fmt.Sprintf("from:%s, to:%s", dqlString(q.From.UTC().Format(timeFormat)), dqlString(q.To.UTC().Format(timeFormat))), nil
+	}
+
+	since, err := dqlDuration(q.Since)
+	if err != nil {
+		return "", err
+	}
+	return "from:now()-" + since, nil
+}
+
+// dqlDuration formats a duration in the largest DQL unit dividing it, e.g. 2d or 90m
+func dqlDuration(d time.Duration) (string, error) {
+	if d < time.Second {
+		return "", fmt.Errorf("invalid duration %s, durations must be at least 1s", d)
+	}
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour)), nil
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour), nil
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute), nil
+	default:
+		return fmt.Sprintf("%ds", d/time.Second), nil
+	}
+}
+
+// dqlString returns s as a DQL string literal
+func dqlString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
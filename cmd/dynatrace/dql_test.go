@@ -0,0 +1,102 @@
+package dynatrace
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDQLFilter(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  string
+	}{
+		{input: "k8s.namespace.name=openshift-monitoring", expected: `matchesValue(k8s.namespace.name, "openshift-monitoring")`},
+		{input: "status=ERROR,WARN", expected: `(matchesValue(status, "ERROR") or matchesValue(status, "WARN"))`},
+		{input: "status!=INFO", expected: `not (matchesValue(status, "INFO"))`},
+		{input: "status!=INFO,DEBUG", expected: `not (matchesValue(status, "INFO") or matchesValue(status, "DEBUG"))`},
+		{input: `content~failed, "retrying"`, expected: `contains(content, "failed, \"retrying\"", caseSensitive:false)`},
+		{input: "=value", wantErr: "invalid filter"},
+		{input: "status!value", wantErr: "invalid filter"},
+		{input: "no operator", wantErr: "invalid filter"},
+		{input: `content") or true or ("=x`, wantErr: "invalid field name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			filter, err := ParseDQLFilter(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if filter.String() != tt.expected {
+				t.Errorf("expected: %s\ngot: %s", tt.expected, filter.String())
+			}
+		})
+	}
+}
+
+func TestDQLQuery_Build(t *testing.T) {
+	namespace := DQLFilter{Field: "k8s.namespace.name", Operator: DQLFilterEqual, Values: []string{"openshift-monitoring"}}
+	from := time.Date(2025, 6, 15, 4, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    DQLQuery
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "logs",
+			query:    DQLQuery{Source: DQLSourceLogs, Since: 2 * time.Hour, Filters: []DQLFilter{namespace}, Fields: []string{"timestamp", "content"}, SortOrder: "desc", Limit: 10},
+			expected: "fetch logs, from:now()-2h\n| filter matchesValue(k8s.namespace.name, \"openshift-monitoring\")\n| fields timestamp, content\n| sort timestamp desc\n| limit 10",
+		},
+		{
+			name:     "spans in a time range",
+			query:    DQLQuery{Source: DQLSourceSpans, From: from, To: from.Add(90 * time.Minute)},
+			expected: `fetch spans, from:"2025-06-15T04:00:00Z", to:"2025-06-15T05:30:00Z"`,
+		},
+		{
+			name:     "bizevents",
+			query:    DQLQuery{Source: DQLSourceBizEvents, Since: 90 * time.Minute},
+			expected: "fetch bizevents, from:now()-90m",
+		},
+		{
+			name:     "timeseries",
+			query:    DQLQuery{Source: DQLSourceMetrics, Since: 24 * time.Hour, Metric: "dt.kubernetes.container.cpu_usage", Aggregation: "max", By: []string{"k8s.pod.name"}, Interval: time.Hour, Filters: []DQLFilter{namespace}},
+			expected: `timeseries value=max(dt.kubernetes.container.cpu_usage), by:{k8s.pod.name}, interval:1h, from:now()-1d, filter:{matchesValue(k8s.namespace.name, "openshift-monitoring")}`,
+		},
+		{name: "unknown source", query: DQLQuery{Source: "traces", Since: time.Hour}, wantErr: "invalid data source"},
+		{name: "no timeframe", query: DQLQuery{Source: DQLSourceLogs}, wantErr: "invalid duration"},
+		{name: "reversed time range", query: DQLQuery{Source: DQLSourceLogs, From: from, To: from.Add(-time.Hour)}, wantErr: "before its start"},
+		{name: "invalid field", query: DQLQuery{Source: DQLSourceLogs, Since: time.Hour, Fields: []string{"content | limit 1"}}, wantErr: "invalid field name"},
+		{name: "invalid sort", query: DQLQuery{Source: DQLSourceLogs, Since: time.Hour, SortOrder: "up"}, wantErr: "invalid sort order"},
+		{name: "metric of logs", query: DQLQuery{Source: DQLSourceLogs, Since: time.Hour, Metric: "dt.host.cpu.usage"}, wantErr: "only be set for the timeseries"},
+		{name: "missing metric", query: DQLQuery{Source: DQLSourceMetrics, Since: time.Hour}, wantErr: "invalid metric name"},
+		{name: "invalid aggregation", query: DQLQuery{Source: DQLSourceMetrics, Since: time.Hour, Metric: "dt.host.cpu.usage", Aggregation: "median"}, wantErr: "invalid aggregation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := tt.query.Build()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if query != tt.expected {
+				t.Errorf("expected: %s\ngot: %s", tt.expected, query)
+			}
+		})
+	}
+}
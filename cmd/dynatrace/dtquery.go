@@ -40,6 +40,17 @@ func (q *DTQuery) InitEvents(hours int) *DTQuery {
 	return q
 }
 
+func (q *DTQuery) InitEventsWithTimeRange(from time.Time, to time.Time) *DTQuery {
+	q.fragments = []string{}
+
+	fromStr := from.Format(timeFormat)
+	toStr := to.Format(timeFormat)
+
+	q.fragments = append(q.fragments, fmt.Sprintf("fetch events, from:\"%s\", to:\"%s\" \n| filter ", fromStr, toStr))
+
+	return q
+}
+
 func (q *DTQuery) Cluster(mgmtClusterName string) *DTQuery {
 	q.fragments = append(q.fragments, fmt.Sprintf("matchesPhrase(dt.kubernetes.cluster.name, \"%s\")", mgmtClusterName))
 
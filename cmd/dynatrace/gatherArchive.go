@@ -0,0 +1,188 @@
+package dynatrace
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const gatherIndexFile = "index.html"
+
+var gatherIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Logs of cluster {{ .ClusterID }}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.failed { color: #c00; }
+</style>
+</head>
+<body>
+<h1>Logs of cluster {{ .ClusterID }}</h1>
+<p>Logs and events from {{ .From }} to {{ .To }}, gathered from Dynatrace on {{ .GeneratedAt }}.</p>
+<p>{{ .Fetched }} files fetched, {{ .Failed }} failed.</p>
+<table>
+<tr><th>Namespace</th><th>Kind</th><th>Name</th><th>Records</th><th>State</th></tr>
+{{- range .Results }}
+<tr>
+<td>{{ .Namespace }}</td>
+<td>{{ .Kind }}</td>
+<td>{{ if eq .State "fetched" }}<a href="{{ .Path }}">{{ or .Name .Path }}</a>{{ else }}{{ or .Name .Path }}{{ end }}</td>
+<td>{{ .Records }}</td>
+<td{{ if eq .State "failed" }} class="failed" title="{{ .Error }}"{{ end }}>{{ .State }}</td>
+</tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
+
+// writeGatherIndex writes the index.html summary of the gather directory
+func writeGatherIndex(dir string, manifest *gatherManifest) error {
+	results := manifest.SortedResults()
+	data := struct {
+		ClusterID   string
+		From        string
+		To          string
+		GeneratedAt string
+		Fetched     int
+		Failed      int
+		Results     []gatherResult
+	}{
+		ClusterID:   manifest.ClusterID,
+		From:        manifest.Window.From.Format(time.RFC3339),
+		To:          manifest.Window.To.Format(time.RFC3339),
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Results:     results,
+	}
+	for i, result := range results {
+		if result.State == gatherStateFetched {
+			data.Fetched++
+		} else {
+			data.Failed++
+		}
+		// Links are relative to the index, whatever the OS
+		results[i].Path = filepath.ToSlash(result.Path)
+	}
+
+	f, err := os.Create(filepath.Join(dir, gatherIndexFile)) //#nosec G304 -- Potential file inclusion via variable
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	if err := gatherIndexTemplate.Execute(f, data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return f.Close()
+}
+
+// archiveGatherDir writes the gather directory to an archive next to it and returns its path.
+// The files of the archive are in a directory named after the gather directory.
+func archiveGatherDir(dir string, format string) (string, error) {
+	dir = filepath.Clean(dir)
+	archivePath := dir + "." + format
+	f, err := os.Create(archivePath) //#nosec G304 -- Potential file inclusion via variable
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	switch format {
+	case gatherArchiveTarGz:
+		err = writeTarGz(f, dir)
+	case gatherArchiveZip:
+		err = writeZip(f, dir)
+	default:
+		err = fmt.Errorf("invalid archive format %q", format)
+	}
+	closeErr := f.Close()
+	if err != nil {
+		os.Remove(archivePath)
+		return "", fmt.Errorf("failed to write archive: %w", err)
+	}
+	return archivePath, closeErr
+}
+
+// walkGatherDir calls fn with the regular files of the gather directory and their name in the
+// archive, leaving out the files being written
+func walkGatherDir(dir string, fn func(path string, name string, info os.FileInfo) error) error {
+	base := filepath.Base(dir)
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || strings.HasSuffix(path, ".part") || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return fn(path, filepath.ToSlash(filepath.Join(base, rel)), info)
+	})
+}
+
+func writeTarGz(w io.Writer, dir string) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err := walkGatherDir(dir, func(path string, name string, info os.FileInfo) error {
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		return copyFile(tarWriter, path)
+	})
+	if err != nil {
+		return err
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+func writeZip(w io.Writer, dir string) error {
+	zipWriter := zip.NewWriter(w)
+
+	err := walkGatherDir(dir, func(path string, name string, info os.FileInfo) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		header.Method = zip.Deflate
+		fw, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		return copyFile(fw, path)
+	})
+	if err != nil {
+		return err
+	}
+	return zipWriter.Close()
+}
+
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path) //#nosec G304 -- Potential file inclusion via variable
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,192 @@
+package dynatrace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const gatherManifestFile = "manifest.json"
+
+// Kinds of gather tasks
+const (
+	gatherKindPodLogs       = "pod-logs"
+	gatherKindEvents        = "events"
+	gatherKindRestartedPods = "restarted-pods"
+)
+
+// States of the gather tasks recorded in the manifest
+const (
+	gatherStateFetched = "fetched"
+	gatherStateFailed  = "failed"
+)
+
+// gatherWindow is the time range of the gathered logs and events
+type gatherWindow struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// windowMatch is how the requested time window must match the window of a resumed gather
+type windowMatch int
+
+const (
+	// windowKept resumes the gather in its own window, the window wasn't requested explicitly
+	windowKept windowMatch = iota
+	// windowExact requires the same start and end, set with --start-time or --end-time
+	windowExact
+	// windowDuration requires the same duration, set with --since
+	windowDuration
+)
+
+// matches reports whether the window of a previous gather is the requested one
+func (m windowMatch) matches(requested, previous gatherWindow) bool {
+	switch m {
+	case windowExact:
+		return requested.From.Equal(previous.From) && requested.To.Equal(previous.To)
+	case windowDuration:
+		return requested.To.Sub(requested.From) == previous.To.Sub(previous.From)
+	}
+	return true
+}
+
+// gatherTask is a query whose records are written to a file of the gather directory
+type gatherTask struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name,omitempty"`
+	// Path is the file holding the records, relative to the gather directory
+	Path string `json:"path"`
+
+	query string
+}
+
+// gatherResult is the outcome of a gather task
+type gatherResult struct {
+	gatherTask
+	State     string    `json:"state"`
+	Records   int       `json:"records"`
+	Error     string    `json:"error,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// gatherManifest records the outcome of the tasks of a gather, so that running an unfinished
+// gather again only fetches what is missing, for the same time window
+type gatherManifest struct {
+	ClusterID string                   `json:"cluster_id"`
+	Window    gatherWindow             `json:"window"`
+	StartedAt time.Time                `json:"started_at"`
+	Completed bool                     `json:"completed"`
+	Results   map[string]*gatherResult `json:"results"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// openGatherManifest returns the manifest of the gather directory, resuming the previous gather
+// unless it completed or restart is set. A resumed gather keeps its time window, a window
+// requested explicitly must match it.
+func openGatherManifest(dir string, clusterID string, window gatherWindow, match windowMatch, restart bool) (*gatherManifest, error) {
+	path := filepath.Join(dir, gatherManifestFile)
+	manifest := &gatherManifest{
+		ClusterID: clusterID,
+		Window:    window,
+		StartedAt: time.Now().UTC(),
+		Results:   map[string]*gatherResult{},
+		path:      path,
+	}
+
+	data, err := os.ReadFile(path) //#nosec G304 -- Potential file inclusion via variable
+	if errors.Is(err, os.ErrNotExist) || (err == nil && restart) {
+		return manifest, manifest.save()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gather manifest: %w", err)
+	}
+
+	previous := &gatherManifest{path: path}
+	if err := json.Unmarshal(data, previous); err != nil {
+		return nil, fmt.Errorf("invalid gather manifest %s, use --restart to gather everything again: %w", path, err)
+	}
+	if previous.ClusterID != clusterID {
+		return nil, fmt.Errorf("%s holds the logs of cluster %s, use another --dest-dir", dir, previous.ClusterID)
+	}
+	if previous.Completed {
+		fmt.Printf("The gather started at %s completed, gathering again\n", previous.StartedAt.Format(time.RFC3339))
+		return manifest, manifest.save()
+	}
+	if !match.matches(window, previous.Window) {
+		return nil, fmt.Errorf("%s holds the logs from %s to %s, use --restart to gather another time window", dir, previous.Window.From.Format(time.RFC3339), previous.Window.To.Format(time.RFC3339))
+	}
+	if previous.Results == nil {
+		previous.Results = map[string]*gatherResult{}
+	}
+
+	fmt.Printf("Resuming the gather started at %s\n", previous.StartedAt.Format(time.RFC3339))
+	return previous, nil
+}
+
+// Fetched reports whether the records of a task have been fetched
+func (m *gatherManifest) Fetched(task gatherTask) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result, ok := m.Results[task.Path]
+	return ok && result.State == gatherStateFetched
+}
+
+// Record saves the outcome of a task
+func (m *gatherManifest) Record(result gatherResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Results[result.Path] = &result
+	return m.save()
+}
+
+// Complete records that every task has been fetched, so that the gather isn't resumed
+func (m *gatherManifest) Complete() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Completed = true
+	return m.save()
+}
+
+// SortedResults returns the outcomes of the tasks by namespace, kind and name
+func (m *gatherManifest) SortedResults() []gatherResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	results := make([]gatherResult, 0, len(m.Results))
+	for _, result := range m.Results {
+		results = append(results, *result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Namespace != results[j].Namespace {
+			return results[i].Namespace < results[j].Namespace
+		}
+		if results[i].Kind != results[j].Kind {
+			return results[i].Kind < results[j].Kind
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+// save replaces the manifest file, so that it is never left half written
+func (m *gatherManifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write gather manifest: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return fmt.Errorf("failed to write gather manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package dynatrace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/utils"
+	"golang.org/x/time/rate"
+)
+
+// gatherMaxRetries is the number of times a rate limited query is retried
+const gatherMaxRetries = 5
+
+// gatherRetryBackoff is the delay before the first retry of a rate limited query, doubled on
+// every retry, unless Dynatrace tells how long to wait
+var gatherRetryBackoff = 2 * time.Second
+
+// gatherSummary counts the outcomes of the tasks of a gather
+type gatherSummary struct {
+	fetched int
+	skipped int
+	failed  int
+}
+
+// logGatherer fetches the records of gather tasks with a pool of workers, starting at most rate
+// queries per second. The outcome of every task is recorded in the manifest.
+type logGatherer struct {
+	dtURL         string
+	tokenProvider utils.AccessTokenProvider
+	dir           string
+	workers       int
+	rate          float64
+	manifest      *gatherManifest
+	out           io.Writer
+}
+
+// Run fetches the tasks which haven't been fetched yet, until all are handled or the context is
+// cancelled, in which case the tasks left are fetched when resuming
+func (g *logGatherer) Run(ctx context.Context, tasks []gatherTask) gatherSummary {
+	limit := rate.Inf
+	if g.rate > 0 {
+		limit = rate.Limit(g.rate)
+	}
+	limiter := rate.NewLimiter(limit, 1)
+
+	workers := g.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var summary gatherSummary
+	var pending []gatherTask
+	for _, task := range tasks {
+		if g.manifest.Fetched(task) {
+			summary.skipped++
+			continue
+		}
+		pending = append(pending, task)
+	}
+
+	queue := make(chan gatherTask)
+	var (
+		mu   sync.Mutex
+		done int
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				if err := limiter.Wait(ctx); err != nil {
+					continue
+				}
+				records, err := g.fetch(ctx, task)
+				if ctx.Err() != nil {
+					continue
+				}
+
+				result := gatherResult{gatherTask: task, State: gatherStateFetched, Records: records, FetchedAt: time.Now().UTC()}
+				if err != nil {
+					result.State = gatherStateFailed
+					result.Error = err.Error()
+				}
+
+				mu.Lock()
+				done++
+				if err != nil {
+					summary.failed++
+					fmt.Fprintf(g.out, "[%d/%d] %s: failed: %v\n", done, len(pending), task.Path, err)
+				} else {
+					summary.fetched++
+					fmt.Fprintf(g.out, "[%d/%d] %s: %d records\n", done, len(pending), task.Path, records)
+				}
+				if err := g.manifest.Record(result); err != nil {
+					fmt.Fprintf(g.out, "failed to record %s in the manifest: %v\n", task.Path, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for _, task := range pending {
+		select {
+		case queue <- task:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	return summary
+}
+
+// fetch runs the query of a task and writes its records, retrying when Dynatrace rate limits
+// the queries
+func (g *logGatherer) fetch(ctx context.Context, task gatherTask) (int, error) {
+	for attempt := 0; ; attempt++ {
+		records, err := g.fetchOnce(ctx, task)
+		delay, retry := gatherRetryDelay(err, attempt)
+		if !retry {
+			return records, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// fetchOnce writes the records of a task to a temporary file, renamed once all records are
+// written so that the file of a task is either complete or missing
+func (g *logGatherer) fetchOnce(ctx context.Context, task gatherTask) (int, error) {
+	accessToken, err := g.tokenProvider.Token()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get access token: %v", err)
+	}
+
+	requestToken, err := getDTQueryExecution(ctx, g.dtURL, accessToken, task.query)
+	if err != nil {
+		return 0, err
+	}
+
+	path := filepath.Join(g.dir, task.Path)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return 0, fmt.Errorf("failed to setup directory %v", err)
+	}
+	tmp := path + ".part"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) //#nosec G304 -- Potential file inclusion via variable
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+
+	var records int
+	if task.Kind == gatherKindEvents {
+		records, err = fetchAndWriteEvents(ctx, g.dtURL, accessToken, requestToken, f)
+	} else {
+		records, err = fetchAndWriteLogs(ctx, g.dtURL, accessToken, requestToken, f)
+	}
+	closeErr := f.Close()
+	if err != nil {
+		return 0, err
+	}
+	if closeErr != nil {
+		return 0, closeErr
+	}
+	return records, os.Rename(tmp, path)
+}
+
+// gatherRetryDelay returns how long to wait before retrying a query which failed with err,
+// and whether to retry it. Queries are retried when Dynatrace is rate limiting or unavailable.
+func gatherRetryDelay(err error, attempt int) (time.Duration, bool) {
+	var reqErr *utils.RequestError
+	if !errors.As(err, &reqErr) || attempt >= gatherMaxRetries {
+		return 0, false
+	}
+	if reqErr.StatusCode != http.StatusTooManyRequests && reqErr.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	if reqErr.RetryAfter > 0 {
+		return reqErr.RetryAfter, true
+	}
+	return gatherRetryBackoff << attempt, true
+}
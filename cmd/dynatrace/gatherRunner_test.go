@@ -0,0 +1,252 @@
+package dynatrace
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type staticTokenProvider string
+
+func (p staticTokenProvider) Token() (string, error) {
+	return string(p), nil
+}
+
+func newTestGatherer(t *testing.T, dir string, dtURL string, manifest *gatherManifest) *logGatherer {
+	backoff := gatherRetryBackoff
+	gatherRetryBackoff = time.Millisecond
+	t.Cleanup(func() { gatherRetryBackoff = backoff })
+
+	return &logGatherer{
+		dtURL:         dtURL,
+		tokenProvider: staticTokenProvider("token"),
+		dir:           dir,
+		workers:       2,
+		manifest:      manifest,
+		out:           io.Discard,
+	}
+}
+
+func TestLogGatherer(t *testing.T) {
+	dir := t.TempDir()
+	fake, dtURL := newFakeDynatrace(t, []map[string]interface{}{{"content": "line 1"}, {"content": "line 2"}}, 1)
+	fake.rateLimited = 1
+	fake.invalid = "broken"
+
+	tasks := []gatherTask{
+		{Kind: gatherKindPodLogs, Namespace: "ns", Name: "pod-1", Path: filepath.Join("ns", "pods", "pod-1", "pod.log"), query: "fetch logs pod-1"},
+		{Kind: gatherKindEvents, Namespace: "ns", Name: "deploy-1", Path: filepath.Join("ns", "events", "deploy-1", "events.log"), query: "fetch events deploy-1"},
+		{Kind: gatherKindRestartedPods, Namespace: "ns", Path: filepath.Join("ns", "restarted-pods", "pods.log"), query: "fetch logs broken"},
+	}
+
+	manifest, err := openGatherManifest(dir, "cluster-1", testGatherWindow, windowExact, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	summary := newTestGatherer(t, dir, dtURL, manifest).Run(context.Background(), tasks)
+	if summary != (gatherSummary{fetched: 2, failed: 1}) {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	logs, err := os.ReadFile(filepath.Join(dir, tasks[0].Path))
+	if err != nil || string(logs) != "line 1\nline 2\n" {
+		t.Errorf("unexpected pod logs %q: %v", logs, err)
+	}
+	events, err := os.ReadFile(filepath.Join(dir, tasks[1].Path))
+	if err != nil || string(events) != "{\"content\":\"line 1\"}\n{\"content\":\"line 2\"}\n" {
+		t.Errorf("unexpected events %q: %v", events, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, tasks[2].Path)); !os.IsNotExist(err) {
+		t.Errorf("expected no file for the failed query, got: %v", err)
+	}
+
+	// Resuming only runs the failed query, in the time window of the first run
+	fake.invalid = ""
+	manifest, err = openGatherManifest(dir, "cluster-1", gatherWindow{From: time.Now().Add(-time.Hour), To: time.Now()}, windowKept, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if manifest.Window != testGatherWindow {
+		t.Errorf("expected the window of the first run to be kept, got: %v", manifest.Window)
+	}
+	summary = newTestGatherer(t, dir, dtURL, manifest).Run(context.Background(), tasks)
+	if summary != (gatherSummary{fetched: 1, skipped: 2}) {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if len(fake.queries) != 3 {
+		t.Errorf("expected 3 queries to run, got: %v", fake.queries)
+	}
+	for _, result := range manifest.SortedResults() {
+		if result.State != gatherStateFetched || result.Records != 2 {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	}
+
+	// The window of a gather can't be changed when resuming it
+	_, err = openGatherManifest(dir, "cluster-1", gatherWindow{From: testGatherWindow.From, To: time.Now()}, windowExact, false)
+	if err == nil || !strings.Contains(err.Error(), "--restart") {
+		t.Errorf("expected an error about the time window, got: %v", err)
+	}
+	manifest, err = openGatherManifest(dir, "cluster-1", gatherWindow{From: testGatherWindow.From, To: time.Now()}, windowExact, true)
+	if err != nil || len(manifest.Results) != 0 {
+		t.Errorf("expected a new manifest, got: %v", err)
+	}
+}
+
+func TestGatherManifestResume(t *testing.T) {
+	dir := t.TempDir()
+	task := gatherTask{Kind: gatherKindPodLogs, Namespace: "ns", Name: "pod-1", Path: filepath.Join("ns", "pods", "pod-1", "pod.log")}
+	manifest, err := openGatherManifest(dir, "cluster-1", testGatherWindow, windowDuration, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manifest.Record(gatherResult{gatherTask: task, State: gatherStateFetched}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// --since resumes an unfinished gather of the same duration, and fails on another duration
+	later := gatherWindow{From: testGatherWindow.From.Add(time.Hour), To: testGatherWindow.To.Add(time.Hour)}
+	manifest, err = openGatherManifest(dir, "cluster-1", later, windowDuration, false)
+	if err != nil || manifest.Window != testGatherWindow || !manifest.Fetched(task) {
+		t.Errorf("expected the gather to be resumed, got: %+v, %v", manifest, err)
+	}
+	longer := gatherWindow{From: testGatherWindow.From.Add(-time.Hour), To: testGatherWindow.To}
+	_, err = openGatherManifest(dir, "cluster-1", longer, windowDuration, false)
+	if err == nil || !strings.Contains(err.Error(), "--restart") {
+		t.Errorf("expected an error about the time window, got: %v", err)
+	}
+
+	// A completed gather is never resumed
+	if err := manifest.Complete(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	manifest, err = openGatherManifest(dir, "cluster-1", later, windowKept, false)
+	if err != nil || manifest.Window != later || manifest.Fetched(task) || manifest.Completed {
+		t.Errorf("expected a new gather, got: %+v, %v", manifest, err)
+	}
+}
+
+func TestGatherRetryDelay(t *testing.T) {
+	fake, dtURL := newFakeDynatrace(t, nil, 0)
+	fake.rateLimited = gatherMaxRetries + 1
+
+	manifest, err := openGatherManifest(t.TempDir(), "cluster-1", testGatherWindow, windowKept, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gatherer := newTestGatherer(t, t.TempDir(), dtURL, manifest)
+	_, err = gatherer.fetch(context.Background(), gatherTask{Kind: gatherKindPodLogs, Path: "pod.log", query: "fetch logs"})
+	if err == nil || !strings.Contains(err.Error(), "429 Too Many Requests") {
+		t.Errorf("expected the query to fail once the retries are exhausted, got: %v", err)
+	}
+	if fake.rateLimited != 0 {
+		t.Errorf("expected %d attempts, %d left", gatherMaxRetries+1, fake.rateLimited)
+	}
+}
+
+func TestArchiveGatherDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hcp-logs-dump-ns")
+	manifest, err := openGatherManifest(mkdir(t, dir), "cluster-1", testGatherWindow, windowKept, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	task := gatherTask{Kind: gatherKindPodLogs, Namespace: "ns", Name: "pod-1", Path: filepath.Join("ns", "pods", "pod-1", "pod.log")}
+	writeFile(t, filepath.Join(dir, task.Path), "line 1\n")
+	writeFile(t, filepath.Join(dir, "ns", "restarted-pods", "pods.log.part"), "partial")
+	if err := manifest.Record(gatherResult{gatherTask: task, State: gatherStateFetched, Records: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manifest.Record(gatherResult{gatherTask: gatherTask{Kind: gatherKindEvents, Namespace: "ns", Name: "deploy-1"}, State: gatherStateFailed, Error: "query failed"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := writeGatherIndex(dir, manifest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	index, err := os.ReadFile(filepath.Join(dir, gatherIndexFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"Logs of cluster cluster-1", `<a href="ns/pods/pod-1/pod.log">pod-1</a>`, `class="failed" title="query failed"`, "1 files fetched, 1 failed"} {
+		if !strings.Contains(string(index), expected) {
+			t.Errorf("expected the index to contain %q:\n%s", expected, index)
+		}
+	}
+
+	expected := []string{"hcp-logs-dump-ns/index.html", "hcp-logs-dump-ns/manifest.json", "hcp-logs-dump-ns/ns/pods/pod-1/pod.log"}
+	for _, format := range []string{gatherArchiveTarGz, gatherArchiveZip} {
+		t.Run(format, func(t *testing.T) {
+			archivePath, err := archiveGatherDir(dir, format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if archivePath != dir+"."+format {
+				t.Errorf("unexpected archive path %s", archivePath)
+			}
+			names := archiveNames(t, archivePath, format)
+			if strings.Join(names, ",") != strings.Join(expected, ",") {
+				t.Errorf("expected: %v\ngot: %v", expected, names)
+			}
+		})
+	}
+}
+
+// archiveNames returns the sorted names of the files of an archive
+func archiveNames(t *testing.T, path string, format string) []string {
+	var names []string
+	if format == gatherArchiveZip {
+		reader, err := zip.OpenReader(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer reader.Close()
+		for _, f := range reader.File {
+			names = append(names, f.Name)
+		}
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer f.Close()
+		gzipReader, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tarReader := tar.NewReader(gzipReader)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			names = append(names, header.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func mkdir(t *testing.T, dir string) string {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	mkdir(t, filepath.Dir(path))
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
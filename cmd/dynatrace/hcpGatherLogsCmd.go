@@ -3,13 +3,16 @@ package dynatrace
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/openshift/osdctl/cmd/common"
-	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/prometheus/common/model"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 	appsv1 "k8s.io/api/apps/v1"
@@ -20,13 +23,35 @@ import (
 )
 
 type GatherLogsOpts struct {
-	Since     int
+	// Since is the duration before EndTime to gather, e.g. 10h, or a number of hours
+	Since     string
+	StartTime time.Time
+	EndTime   time.Time
 	Tail      int
 	SortOrder string
 	DestDir   string
 	ClusterID string
+	// Workers is the number of queries run in parallel, defaultGatherWorkers when 0
+	Workers int
+	// Rate is the maximum number of queries started per second, unlimited when 0
+	Rate float64
+	// Archive is the format of the archive of the gathered logs, none when empty
+	Archive string
+	// Restart gathers everything again instead of resuming an interrupted gather
+	Restart bool
 }
 
+const (
+	defaultGatherSince   = "10h"
+	defaultGatherWorkers = 4
+)
+
+// Formats of the archive of the gathered logs
+const (
+	gatherArchiveTarGz = "tar.gz"
+	gatherArchiveZip   = "zip"
+)
+
 func NewCmdHCPMustGather() *cobra.Command {
 	g := &GatherLogsOpts{}
 
@@ -37,11 +62,21 @@ func NewCmdHCPMustGather() *cobra.Command {
 		Long: `Gathers pods logs and evnets of a given HCP from Dynatrace.
 
   This command fetches the logs from the HCP namespace, the hypershift namespace and cert-manager related namespaces.
-  Logs will be dumped to a directory with prefix hcp-must-gather.
+  Logs will be dumped to a directory with prefix hcp-logs-dump, along with an index.html summary.
+
+  Logs are fetched in parallel. What has been fetched is recorded in the manifest.json file of the directory, running
+  the command again after an interruption or failures only fetches what is missing, for the same time window.
+  Running it again after a complete gather gathers everything again.
 		`,
 		Example: `
   # Gather logs for a HCP cluster with cluster id hcp-cluster-id-123
-  osdctl dt gather-logs --cluster-id hcp-cluster-id-123`,
+  osdctl dt gather-logs --cluster-id hcp-cluster-id-123
+
+  # Gather the logs of the last 2 days into a zip archive
+  osdctl dt gather-logs --cluster-id hcp-cluster-id-123 --since 48h --archive zip
+
+  # Gather the logs of an incident
+  osdctl dt gather-logs --cluster-id hcp-cluster-id-123 --start-time 2025-06-15T04:00:00Z --end-time 2025-06-15T06:00:00Z`,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
 
@@ -52,18 +87,103 @@ func NewCmdHCPMustGather() *cobra.Command {
 		},
 	}
 
-	hcpMgCmd.Flags().IntVar(&g.Since, "since", 10, "Number of hours (integer) since which to pull logs and events")
+	hcpMgCmd.Flags().StringVar(&g.Since, "since", "", "Only gather logs and events newer than a relative duration (e.g. 30m, 10h, 2d), "+defaultGatherSince+" by default - exclusive with --start-time & --end-time")
+	hcpMgCmd.Flags().TimeVar(&g.StartTime, "start-time", time.Time{}, []string{time.RFC3339}, "Start time of the logs and events to gather (default to "+defaultGatherSince+" before --end-time)")
+	hcpMgCmd.Flags().TimeVar(&g.EndTime, "end-time", time.Time{}, []string{time.RFC3339}, "End time of the logs and events to gather (default to now)")
+	hcpMgCmd.MarkFlagsMutuallyExclusive("start-time", "since")
+	hcpMgCmd.MarkFlagsMutuallyExclusive("end-time", "since")
 	hcpMgCmd.Flags().IntVar(&g.Tail, "tail", 0, "Last 'n' logs and events to fetch. By default it will pull everything")
 	hcpMgCmd.Flags().StringVar(&g.SortOrder, "sort", "asc", "Sort the results by timestamp in either ascending or descending order. Accepted values are 'asc' and 'desc'")
 	hcpMgCmd.Flags().StringVar(&g.DestDir, "dest-dir", "", "Destination directory for the logs dump, defaults to the local directory.")
 	hcpMgCmd.Flags().StringVarP(&g.ClusterID, "cluster-id", "C", "", "Internal ID of the HCP cluster to gather logs from (required)")
+	hcpMgCmd.Flags().IntVar(&g.Workers, "workers", defaultGatherWorkers, "Number of Dynatrace queries run in parallel")
+	hcpMgCmd.Flags().Float64Var(&g.Rate, "rate", 2, "Maximum number of Dynatrace queries started per second, 0 for no limit")
+	hcpMgCmd.Flags().StringVar(&g.Archive, "archive", "", "Also write the logs dump to an archive next to its directory. One of: "+gatherArchiveTarGz+", "+gatherArchiveZip)
+	hcpMgCmd.Flags().BoolVar(&g.Restart, "restart", false, "Gather everything again instead of resuming a previous gather into the same directory")
 
 	_ = hcpMgCmd.MarkFlagRequired("cluster-id")
 
 	return hcpMgCmd
 }
 
+func (g *GatherLogsOpts) validate() error {
+	if g.SortOrder != "asc" && g.SortOrder != "desc" {
+		return fmt.Errorf("invalid sort order, expecting 'asc' or 'desc'")
+	}
+	if g.Tail < 0 {
+		return fmt.Errorf("--tail can't be negative")
+	}
+	if g.Workers < 0 {
+		return fmt.Errorf("--workers can't be negative")
+	}
+	if g.Rate < 0 {
+		return fmt.Errorf("--rate can't be negative")
+	}
+	if g.Archive != "" && g.Archive != gatherArchiveTarGz && g.Archive != gatherArchiveZip {
+		return fmt.Errorf("invalid archive format %q, expecting '%s' or '%s'", g.Archive, gatherArchiveTarGz, gatherArchiveZip)
+	}
+	return nil
+}
+
+// window returns the time range to gather: from --start-time, or --since before the end, to
+// --end-time or now
+func (g *GatherLogsOpts) window(now time.Time) (gatherWindow, error) {
+	w := gatherWindow{From: g.StartTime.UTC(), To: now.UTC()}
+	if !g.EndTime.IsZero() {
+		w.To = g.EndTime.UTC()
+	}
+
+	if g.StartTime.IsZero() {
+		since := g.Since
+		if since == "" {
+			since = defaultGatherSince
+		}
+		duration, err := parseGatherSince(since)
+		if err != nil {
+			return w, err
+		}
+		w.From = w.To.Add(-duration)
+	}
+
+	if !w.From.Before(w.To) {
+		return w, fmt.Errorf("the start time of the logs must be before their end time")
+	}
+	return w, nil
+}
+
+// windowMatch returns how the window of a resumed gather must match the requested one
+func (g *GatherLogsOpts) windowMatch() windowMatch {
+	switch {
+	case !g.StartTime.IsZero() || !g.EndTime.IsZero():
+		return windowExact
+	case g.Since != "":
+		return windowDuration
+	}
+	return windowKept
+}
+
+// parseGatherSince parses a duration, a bare number being a number of hours as in previous
+// versions of the command
+func parseGatherSince(since string) (time.Duration, error) {
+	if hours, err := strconv.Atoi(since); err == nil {
+		since = fmt.Sprintf("%dh", hours)
+	}
+	duration, err := model.ParseDuration(since)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid duration %q for --since, expecting e.g. 30m, 10h or 2d", since)
+	}
+	return time.Duration(duration), nil
+}
+
 func (g *GatherLogsOpts) GatherLogs(clusterID string, elevationReasons ...string) (error error) {
+	if err := g.validate(); err != nil {
+		return err
+	}
+	window, err := g.window(time.Now())
+	if err != nil {
+		return err
+	}
+
 	tokenProvider, err := getStorageTokenProvider()
 	if err != nil {
 		return fmt.Errorf("failed to setup Dynatrace access token provider (is the vault CLI installed and configured?): %v", err)
@@ -93,199 +213,138 @@ func (g *GatherLogsOpts) GatherLogs(clusterID string, elevationReasons ...string
 		return err
 	}
 
-	for _, gatherNS := range gatherNamespaces {
-		fmt.Printf("Gathering for %s\n", gatherNS)
-
-		pods, err := getPodsForNamespace(clientset, gatherNS)
-		if err != nil {
-			return err
-		}
-
-		nsDir, err := addDir([]string{gatherDir, gatherNS}, []string{})
-		if err != nil {
-			return err
-		}
-
-		err = g.dumpPodLogs(pods, nsDir, gatherNS, hcpCluster.managementClusterName, hcpCluster.DynatraceURL, tokenProvider, g.Since, g.Tail, g.SortOrder)
-		if err != nil {
-			return err
-		}
-
-		deployments, err := getDeploymentsForNamespace(clientset, gatherNS)
-		if err != nil {
-			return err
-		}
-
-		err = g.dumpEvents(deployments, nsDir, gatherNS, hcpCluster.managementClusterName, hcpCluster.DynatraceURL, tokenProvider, g.Since, g.Tail, g.SortOrder)
-		if err != nil {
-			return err
-		}
+	manifest, err := openGatherManifest(gatherDir, hcpCluster.internalID, window, g.windowMatch(), g.Restart)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Gathering logs and events from %s to %s\n", manifest.Window.From.Format(time.RFC3339), manifest.Window.To.Format(time.RFC3339))
 
-		err = g.dumpRestartedPodLogs(pods, nsDir, gatherNS, hcpCluster.managementClusterName, hcpCluster.DynatraceURL, tokenProvider)
+	var tasks []gatherTask
+	for _, gatherNS := range gatherNamespaces {
+		fmt.Printf("Listing pods and deployments of %s\n", gatherNS)
+		nsTasks, err := g.planNamespace(clientset, gatherDir, gatherNS, hcpCluster.managementClusterName, manifest.Window)
 		if err != nil {
 			return err
 		}
-
+		tasks = append(tasks, nsTasks...)
 	}
 
-	return nil
-}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-func (g *GatherLogsOpts) dumpEvents(deploys *appsv1.DeploymentList, parentDir string, targetNS string, managementClusterName string, DTURL string, tokenProvider utils.AccessTokenProvider, since int, tail int, sortOrder string) error {
-	totalDeployments := len(deploys.Items)
-	for k, d := range deploys.Items {
-		fmt.Printf("[%d/%d] Deployment events for %s\n", k+1, totalDeployments, d.Name)
-
-		eventQuery, err := getEventQuery(d.Name, targetNS, g.Since, g.Tail, g.SortOrder, managementClusterName)
-		if err != nil {
-			return err
-		}
-		eventQuery.Build()
+	gatherer := &logGatherer{
+		dtURL:         hcpCluster.DynatraceURL,
+		tokenProvider: tokenProvider,
+		dir:           gatherDir,
+		workers:       g.Workers,
+		rate:          g.Rate,
+		manifest:      manifest,
+		out:           os.Stdout,
+	}
+	if gatherer.workers == 0 {
+		gatherer.workers = defaultGatherWorkers
+	}
+	summary := gatherer.Run(ctx, tasks)
+	fmt.Printf("Fetched %d, skipped %d already fetched, %d failed\n", summary.fetched, summary.skipped, summary.failed)
 
-		deploymentYamlFileName := "deployment.yaml"
-		eventsFileName := "events.log"
-		eventsDirPath, err := addDir([]string{parentDir, "events", d.Name}, []string{deploymentYamlFileName, eventsFileName})
-		if err != nil {
-			return err
-		}
+	if err := writeGatherIndex(gatherDir, manifest); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("gathering interrupted, run the command again to resume it")
+	}
 
-		deploymentYamlPath := filepath.Join(eventsDirPath, deploymentYamlFileName)
-		deploymentYaml, err := yaml.Marshal(d)
-		if err != nil {
-			return fmt.Errorf("failed to marshal YAML: %v", err)
-		}
-		f, err := os.OpenFile(deploymentYamlPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if g.Archive != "" {
+		archivePath, err := archiveGatherDir(gatherDir, g.Archive)
 		if err != nil {
 			return err
 		}
-		_, writeErr := f.Write(deploymentYaml)
-		closeErr := f.Close()
-		if writeErr != nil {
-			return writeErr
-		}
-		if closeErr != nil {
-			return closeErr
-		}
-
-		eventsFilePath := filepath.Join(eventsDirPath, eventsFileName)
-
-		accessToken, err := tokenProvider.Token()
-		if err != nil {
-			return fmt.Errorf("failed to get access token: %v", err)
-		}
-
-		eventsRequestToken, err := getDTQueryExecution(DTURL, accessToken, eventQuery.finalQuery)
-		if err != nil {
-			log.Printf("failed to get request token: %v", err)
-			continue
-		}
-
-		err = fetchAndWriteEvents(DTURL, accessToken, eventsRequestToken, eventsFilePath)
-		if err != nil {
-			log.Printf("failed to get logs, continuing: %v. Query: %v", err, eventQuery.finalQuery)
-			continue
-		}
+		fmt.Printf("Logs archived to %s\n", archivePath)
+	}
 
+	if summary.failed > 0 {
+		return fmt.Errorf("%d queries failed, run the command again to retry them", summary.failed)
 	}
-	return nil
+	return manifest.Complete()
 }
 
-func (g *GatherLogsOpts) dumpPodLogs(pods *corev1.PodList, parentDir string, targetNS string, managementClusterName string, DTURL string, tokenProvider utils.AccessTokenProvider, since int, tail int, sortOrder string) error {
-	totalPods := len(pods.Items)
-	for k, p := range pods.Items {
-		fmt.Printf("[%d/%d] Pod logs for %s\n", k+1, totalPods, p.Name)
-
-		podLogsQuery, err := getPodQuery(p.Name, targetNS, g.Since, g.Tail, g.SortOrder, managementClusterName)
-		if err != nil {
-			return err
-		}
-		podLogsQuery.Build()
-
-		podYamlFileName := "pod.yaml"
-		podLogFileName := "pod.log"
-		podDirPath, err := addDir([]string{parentDir, "pods", p.Name}, []string{podLogFileName, podYamlFileName})
-		if err != nil {
-			return err
-		}
+// planNamespace writes the manifests of the pods and deployments of a namespace and returns the
+// queries of their logs and events
+func (g *GatherLogsOpts) planNamespace(clientset kubernetes.Interface, gatherDir string, namespace string, managementClusterName string, window gatherWindow) ([]gatherTask, error) {
+	pods, err := getPodsForNamespace(clientset, namespace)
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := getDeploymentsForNamespace(clientset, namespace)
+	if err != nil {
+		return nil, err
+	}
 
-		podYamlFilePath := filepath.Join(podDirPath, podYamlFileName)
-		podYaml, err := yaml.Marshal(p)
-		if err != nil {
-			return fmt.Errorf("failed to marshal YAML: %v", err)
-		}
-		f, err := os.OpenFile(podYamlFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-		if err != nil {
-			return err
-		}
-		_, writeErr := f.Write(podYaml)
-		closeErr := f.Close()
-		if writeErr != nil {
-			return writeErr
-		}
-		if closeErr != nil {
-			return closeErr
+	var tasks []gatherTask
+	var podNames []string
+	for _, p := range pods.Items {
+		podNames = append(podNames, p.Name)
+		podDir := filepath.Join(namespace, "pods", p.Name)
+		if err := writeYaml(filepath.Join(gatherDir, podDir, "pod.yaml"), p); err != nil {
+			return nil, err
 		}
 
-		podLogsFilePath := filepath.Join(podDirPath, podLogFileName)
-
-		accessToken, err := tokenProvider.Token()
+		podLogsQuery, err := getPodQuery(p.Name, namespace, window, g.Tail, g.SortOrder, managementClusterName)
 		if err != nil {
-			return fmt.Errorf("failed to get access token: %v", err)
+			return nil, err
 		}
-
-		podLogsRequestToken, err := getDTQueryExecution(DTURL, accessToken, podLogsQuery.finalQuery)
-		if err != nil {
-			log.Printf("failed to get request token: %v", err)
-			continue
+		tasks = append(tasks, gatherTask{
+			Kind:      gatherKindPodLogs,
+			Namespace: namespace,
+			Name:      p.Name,
+			Path:      filepath.Join(podDir, "pod.log"),
+			query:     podLogsQuery.Build(),
+		})
+	}
+
+	for _, d := range deployments.Items {
+		eventsDir := filepath.Join(namespace, "events", d.Name)
+		if err := writeYaml(filepath.Join(gatherDir, eventsDir, "deployment.yaml"), d); err != nil {
+			return nil, err
 		}
 
-		err = fetchAndWriteLogs(DTURL, accessToken, podLogsRequestToken, podLogsFilePath)
+		eventQuery, err := getEventQuery(d.Name, namespace, window, g.Tail, g.SortOrder, managementClusterName)
 		if err != nil {
-			log.Printf("failed to get logs, continuing: %v. Query: %v", err, podLogsQuery.finalQuery)
-			continue
+			return nil, err
 		}
+		tasks = append(tasks, gatherTask{
+			Kind:      gatherKindEvents,
+			Namespace: namespace,
+			Name:      d.Name,
+			Path:      filepath.Join(eventsDir, "events.log"),
+			query:     eventQuery.Build(),
+		})
 	}
 
-	return nil
-}
-
-func (g *GatherLogsOpts) dumpRestartedPodLogs(pods *corev1.PodList, parentDir string, targetNS string, managementClusterName string, DTURL string, tokenProvider utils.AccessTokenProvider) error {
-	var podList []string
-	for _, p := range pods.Items {
-		podList = append(podList, p.Name)
-	}
-	fmt.Printf("Collecting Restarted Pod logs for %s\n", targetNS)
-
-	restartedPodLogsQuery, err := getRestartedPodQuery(podList, targetNS, g.Since, g.Tail, g.SortOrder, managementClusterName)
+	restartedPodLogsQuery, err := getRestartedPodQuery(podNames, namespace, window, g.Tail, g.SortOrder, managementClusterName)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	restartedPodLogsQuery.Build()
-
-	restartedPodLogFileName := "pods.log"
-	podDirPath, err := addDir([]string{parentDir, "restarted-pods"}, []string{restartedPodLogFileName})
-	if err != nil {
-		return err
-	}
-
-	restartedPodLogsFilePath := filepath.Join(podDirPath, restartedPodLogFileName)
+	tasks = append(tasks, gatherTask{
+		Kind:      gatherKindRestartedPods,
+		Namespace: namespace,
+		Path:      filepath.Join(namespace, "restarted-pods", "pods.log"),
+		query:     restartedPodLogsQuery.Build(),
+	})
 
-	accessToken, err := tokenProvider.Token()
-	if err != nil {
-		return fmt.Errorf("failed to get access token: %v", err)
-	}
+	return tasks, nil
+}
 
-	podLogsRequestToken, err := getDTQueryExecution(DTURL, accessToken, restartedPodLogsQuery.finalQuery)
+// writeYaml writes an object to a YAML file, creating its directory
+func writeYaml(path string, object interface{}) error {
+	data, err := yaml.Marshal(object)
 	if err != nil {
-		log.Printf("failed to get request token: %v", err)
-		return nil
+		return fmt.Errorf("failed to marshal YAML: %v", err)
 	}
-	err = fetchAndWriteLogs(DTURL, accessToken, podLogsRequestToken, restartedPodLogsFilePath)
-	if err != nil {
-		log.Printf("failed to get restarted pod logs: %v. Query: %v", err, restartedPodLogsQuery.finalQuery)
+	if _, err := addDir([]string{filepath.Dir(path)}, []string{}); err != nil {
+		return err
 	}
-
-	return nil
+	return os.WriteFile(path, data, 0600)
 }
 
 func setupGatherDir(destBaseDir string, dirName string) (logsDir string, error error) {
@@ -318,9 +377,9 @@ func addDir(dirs []string, filePaths []string) (path string, error error) {
 	return dirPath, nil
 }
 
-func getPodQuery(pod string, namespace string, since int, tail int, sortOrder string, srcCluster string) (query DTQuery, error error) {
+func getPodQuery(pod string, namespace string, window gatherWindow, tail int, sortOrder string, srcCluster string) (query DTQuery, error error) {
 	q := DTQuery{}
-	q.InitLogs(since).Cluster(srcCluster)
+	q.InitLogsWithTimeRange(window.From, window.To).Cluster(srcCluster)
 
 	if namespace != "" {
 		q.Namespaces([]string{namespace})
@@ -344,9 +403,9 @@ func getPodQuery(pod string, namespace string, since int, tail int, sortOrder st
 	return q, nil
 }
 
-func getRestartedPodQuery(pods []string, namespace string, since int, tail int, sortOrder string, srcCluster string) (query DTQuery, error error) {
+func getRestartedPodQuery(pods []string, namespace string, window gatherWindow, tail int, sortOrder string, srcCluster string) (query DTQuery, error error) {
 	q := DTQuery{}
-	q.InitLogs(since).Cluster(srcCluster)
+	q.InitLogsWithTimeRange(window.From, window.To).Cluster(srcCluster)
 
 	if namespace != "" {
 		q.Namespaces([]string{namespace})
@@ -376,9 +435,9 @@ func getRestartedPodQuery(pods []string, namespace string, since int, tail int,
 	return q, nil
 }
 
-func getEventQuery(deploy string, namespace string, since int, tail int, sortOrder string, srcCluster string) (query DTQuery, error error) {
+func getEventQuery(deploy string, namespace string, window gatherWindow, tail int, sortOrder string, srcCluster string) (query DTQuery, error error) {
 	q := DTQuery{}
-	q.InitEvents(since).Cluster(srcCluster)
+	q.InitEventsWithTimeRange(window.From, window.To).Cluster(srcCluster)
 
 	if namespace != "" {
 		q.Namespaces([]string{namespace})
@@ -402,7 +461,7 @@ func getEventQuery(deploy string, namespace string, since int, tail int, sortOrd
 	return q, nil
 }
 
-func getPodsForNamespace(clientset *kubernetes.Clientset, namespace string) (pl *corev1.PodList, error error) {
+func getPodsForNamespace(clientset kubernetes.Interface, namespace string) (pl *corev1.PodList, error error) {
 	// Getting pod objects for non-running state pod
 	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), v1.ListOptions{})
 	if err != nil {
@@ -412,11 +471,11 @@ func getPodsForNamespace(clientset *kubernetes.Clientset, namespace string) (pl
 	return pods, nil
 }
 
-func getDeploymentsForNamespace(clientset *kubernetes.Clientset, namespace string) (pl *appsv1.DeploymentList, error error) {
+func getDeploymentsForNamespace(clientset kubernetes.Interface, namespace string) (pl *appsv1.DeploymentList, error error) {
 	// Getting pod objects for non-running state pod
 	deploys, err := clientset.AppsV1().Deployments(namespace).List(context.TODO(), v1.ListOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list pods in namespace '%s'", namespace)
+		return nil, fmt.Errorf("failed to list deployments in namespace '%s'", namespace)
 	}
 
 	return deploys, nil
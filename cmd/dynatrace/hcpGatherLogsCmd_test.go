@@ -6,8 +6,14 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
+var testGatherWindow = gatherWindow{
+	From: time.Date(2025, 6, 15, 4, 0, 0, 0, time.UTC),
+	To:   time.Date(2025, 6, 15, 6, 0, 0, 0, time.UTC),
+}
+
 func TestSetupGatherDir(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -124,7 +130,7 @@ func TestGetPodQuery(t *testing.T) {
 	tests := []struct {
 		pod         string
 		namespace   string
-		since       int
+		window      gatherWindow
 		tail        int
 		sortOrder   string
 		srcCluster  string
@@ -133,7 +139,7 @@ func TestGetPodQuery(t *testing.T) {
 		{
 			pod:         "test-pod",
 			namespace:   "test-namespace",
-			since:       24,
+			window:      testGatherWindow,
 			tail:        100,
 			sortOrder:   "asc",
 			srcCluster:  "cluster1",
@@ -142,7 +148,7 @@ func TestGetPodQuery(t *testing.T) {
 		{
 			pod:         "",
 			namespace:   "test-namespace",
-			since:       24,
+			window:      testGatherWindow,
 			tail:        100,
 			sortOrder:   "asc",
 			srcCluster:  "cluster1",
@@ -151,7 +157,7 @@ func TestGetPodQuery(t *testing.T) {
 		{
 			pod:         "test-pod",
 			namespace:   "",
-			since:       24,
+			window:      testGatherWindow,
 			tail:        100,
 			sortOrder:   "asc",
 			srcCluster:  "cluster1",
@@ -160,7 +166,7 @@ func TestGetPodQuery(t *testing.T) {
 		{
 			pod:         "test-pod",
 			namespace:   "test-namespace",
-			since:       24,
+			window:      testGatherWindow,
 			tail:        100,
 			sortOrder:   "invalid",
 			srcCluster:  "cluster1",
@@ -170,7 +176,7 @@ func TestGetPodQuery(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%s-%s", tt.pod, tt.namespace), func(t *testing.T) {
-			query, err := getPodQuery(tt.pod, tt.namespace, tt.since, tt.tail, tt.sortOrder, tt.srcCluster)
+			query, err := getPodQuery(tt.pod, tt.namespace, tt.window, tt.tail, tt.sortOrder, tt.srcCluster)
 
 			if tt.expectError && err == nil {
 				t.Errorf("expected error but got none for test: %s-%s", tt.pod, tt.namespace)
@@ -203,7 +209,7 @@ func TestGetEventQuery(t *testing.T) {
 	tests := []struct {
 		event       string
 		namespace   string
-		since       int
+		window      gatherWindow
 		tail        int
 		sortOrder   string
 		srcCluster  string
@@ -212,7 +218,7 @@ func TestGetEventQuery(t *testing.T) {
 		{
 			event:       "test-event",
 			namespace:   "test-namespace",
-			since:       24,
+			window:      testGatherWindow,
 			tail:        100,
 			sortOrder:   "asc",
 			srcCluster:  "cluster1",
@@ -221,7 +227,7 @@ func TestGetEventQuery(t *testing.T) {
 		{
 			event:       "",
 			namespace:   "test-namespace",
-			since:       24,
+			window:      testGatherWindow,
 			tail:        100,
 			sortOrder:   "asc",
 			srcCluster:  "cluster1",
@@ -230,7 +236,7 @@ func TestGetEventQuery(t *testing.T) {
 		{
 			event:       "test-event",
 			namespace:   "",
-			since:       24,
+			window:      testGatherWindow,
 			tail:        100,
 			sortOrder:   "asc",
 			srcCluster:  "cluster1",
@@ -239,7 +245,7 @@ func TestGetEventQuery(t *testing.T) {
 		{
 			event:       "test-event",
 			namespace:   "test-namespace",
-			since:       24,
+			window:      testGatherWindow,
 			tail:        100,
 			sortOrder:   "invalid",
 			srcCluster:  "cluster1",
@@ -249,7 +255,7 @@ func TestGetEventQuery(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%s-%s", tt.event, tt.namespace), func(t *testing.T) {
-			query, err := getEventQuery(tt.event, tt.namespace, tt.since, tt.tail, tt.sortOrder, tt.srcCluster)
+			query, err := getEventQuery(tt.event, tt.namespace, tt.window, tt.tail, tt.sortOrder, tt.srcCluster)
 
 			if tt.expectError && err == nil {
 				t.Errorf("expected error but got none for test: %s-%s", tt.event, tt.namespace)
@@ -277,3 +283,40 @@ func TestGetEventQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestGatherLogsWindow(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		opts     GatherLogsOpts
+		expected gatherWindow
+		wantErr  string
+	}{
+		{name: "default", opts: GatherLogsOpts{}, expected: gatherWindow{From: now.Add(-10 * time.Hour), To: now}},
+		{name: "since duration", opts: GatherLogsOpts{Since: "2d"}, expected: gatherWindow{From: now.Add(-48 * time.Hour), To: now}},
+		{name: "since hours", opts: GatherLogsOpts{Since: "72"}, expected: gatherWindow{From: now.Add(-72 * time.Hour), To: now}},
+		{name: "start and end", opts: GatherLogsOpts{StartTime: testGatherWindow.From, EndTime: testGatherWindow.To}, expected: testGatherWindow},
+		{name: "start only", opts: GatherLogsOpts{StartTime: testGatherWindow.From}, expected: gatherWindow{From: testGatherWindow.From, To: now}},
+		{name: "end only", opts: GatherLogsOpts{EndTime: testGatherWindow.To}, expected: gatherWindow{From: testGatherWindow.To.Add(-10 * time.Hour), To: testGatherWindow.To}},
+		{name: "reversed", opts: GatherLogsOpts{StartTime: testGatherWindow.To, EndTime: testGatherWindow.From}, wantErr: "must be before"},
+		{name: "invalid since", opts: GatherLogsOpts{Since: "yesterday"}, wantErr: "invalid duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := tt.opts.window(now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if window != tt.expected {
+				t.Errorf("expected: %v\ngot: %v", tt.expected, window)
+			}
+		})
+	}
+}
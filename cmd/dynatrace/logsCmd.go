@@ -1,9 +1,11 @@
 package dynatrace
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"os"
 	"time"
 
 	k8s "github.com/openshift/osdctl/pkg/k8s"
@@ -191,11 +193,11 @@ func main(clusterID string) error {
 		return fmt.Errorf("failed to acquire access token %v", err)
 	}
 
-	requestToken, err := getDTQueryExecution(hcpCluster.DynatraceURL, accessToken, query.finalQuery)
+	requestToken, err := getDTQueryExecution(context.Background(), hcpCluster.DynatraceURL, accessToken, query.finalQuery)
 	if err != nil {
 		return fmt.Errorf("failed to get  vault token %v", err)
 	}
-	err = fetchAndWriteLogs(hcpCluster.DynatraceURL, accessToken, requestToken, "")
+	_, err = fetchAndWriteLogs(context.Background(), hcpCluster.DynatraceURL, accessToken, requestToken, os.Stdout)
 	if err != nil {
 		return fmt.Errorf("failed to get logs %v", err)
 	}
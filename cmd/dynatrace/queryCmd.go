@@ -0,0 +1,304 @@
+package dynatrace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/output"
+	"github.com/prometheus/common/model"
+	"github.com/spf13/cobra"
+)
+
+const (
+	queryCmdDescription = `
+  Run a DQL query against the Dynatrace tenant of a cluster and print its records.
+
+  The query is either raw DQL, or built from flags for the logs, events, spans, bizevents and
+  timeseries data sources. Built queries are restricted to the cluster given with --cluster-id,
+  and to its hosted control plane namespace for HCP clusters.
+
+  The query is cancelled in Dynatrace on Ctrl-C.
+`
+
+	queryCmdExample = `
+  # Run a raw DQL query in the tenant of a cluster
+  $ osdctl dt query --cluster-id <cluster-id> 'fetch logs | filter k8s.namespace.name == "openshift-monitoring" | limit 10'
+
+  # Run a raw DQL query in a given tenant
+  $ osdctl dt query --dynatrace-url https://<tenant>.apps.dynatrace.com/ --query 'fetch bizevents | limit 10'
+
+  # Get the error logs of the last 2 hours of a namespace, as CSV
+  $ osdctl dt query --cluster-id <cluster-id> --since 2h --filter k8s.namespace.name=openshift-monitoring --filter status=ERROR --fields timestamp,k8s.pod.name,content -o csv
+
+  # Get the spans containing a phrase between two dates
+  $ osdctl dt query --cluster-id <cluster-id> --source spans --from "2025-06-15 04:00" --to "2025-06-15 05:00" --filter span.name~reconcile
+
+  # Get the CPU usage of the pods of a namespace over the last day, in 1h intervals
+  $ osdctl dt query --cluster-id <cluster-id> --source timeseries --metric dt.kubernetes.container.cpu_usage --by k8s.pod.name --interval 1h --since 1d --filter k8s.namespace.name=openshift-monitoring -o json
+
+  # Only print the DQL built from the flags
+  $ osdctl dt query --cluster-id <cluster-id> --source events --since 30m --dry-run
+`
+)
+
+// queryBuilderFlags are the flags building the query, which can't be used with raw DQL
+var queryBuilderFlags = []string{"source", "since", "from", "to", "filter", "fields", "sort", "limit", "metric", "aggregation", "by", "interval"}
+
+type queryOptions struct {
+	clusterID    string
+	dynatraceURL string
+	query        string
+
+	source      string
+	since       string
+	from        time.Time
+	to          time.Time
+	filters     []string
+	fields      []string
+	sortOrder   string
+	limit       int
+	metric      string
+	aggregation string
+	by          []string
+	interval    string
+
+	dryRun bool
+	output output.Options
+}
+
+func newCmdQuery() *cobra.Command {
+	opts := &queryOptions{}
+	queryCmd := &cobra.Command{
+		Use:               "query [--cluster-id <cluster-identifier> | --dynatrace-url <url>] [DQL]",
+		Short:             "Run a DQL query against Dynatrace",
+		Long:              queryCmdDescription,
+		Example:           queryCmdExample,
+		Args:              cobra.MaximumNArgs(1),
+		DisableAutoGenTag: true,
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				if opts.query != "" {
+					return fmt.Errorf("the query can't be given both as an argument and with --query")
+				}
+				opts.query = args[0]
+			}
+			if opts.query != "" {
+				for _, flag := range queryBuilderFlags {
+					if cmd.Flags().Changed(flag) {
+						return fmt.Errorf("--%s can't be used with a raw DQL query", flag)
+					}
+				}
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return opts.run(ctx, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	queryCmd.Flags().StringVarP(&opts.clusterID, "cluster-id", "C", "", "Name or Internal ID of the cluster whose Dynatrace tenant to query")
+	queryCmd.Flags().StringVar(&opts.dynatraceURL, "dynatrace-url", "", "URL of the Dynatrace tenant to query, instead of the tenant of a cluster")
+	queryCmd.Flags().StringVarP(&opts.query, "query", "q", "", "Raw DQL query to run")
+	queryCmd.Flags().StringVar(&opts.source, "source", DQLSourceLogs, "Data source of the built query. One of: "+strings.Join(DQLSources, ", "))
+	queryCmd.Flags().StringVar(&opts.since, "since", "1h", "Query the records of the last duration, e.g. 30m, 2h or 1d")
+	queryCmd.Flags().TimeVar(&opts.from, "from", time.Time{}, []string{time.RFC3339, "2006-01-02 15:04"}, "Datetime from which to query records, in the format \"YYYY-MM-DD HH:MM\"")
+	queryCmd.Flags().TimeVar(&opts.to, "to", time.Time{}, []string{time.RFC3339, "2006-01-02 15:04"}, "Datetime until which to query records, in the format \"YYYY-MM-DD HH:MM\"")
+	queryCmd.Flags().StringArrayVar(&opts.filters, "filter", []string{}, "Filter the records on a field: field=value1,value2, field!=value1,value2 or field~phrase (can be repeated)")
+	queryCmd.Flags().StringSliceVar(&opts.fields, "fields", []string{}, "Fields of the records to keep (comma-separated)")
+	queryCmd.Flags().StringVar(&opts.sortOrder, "sort", "", "Sort the records by timestamp in either ascending or descending order. Accepted values are 'asc' and 'desc'.")
+	queryCmd.Flags().IntVar(&opts.limit, "limit", 1000, "Maximum number of records to fetch, 0 for no limit")
+	queryCmd.Flags().StringVar(&opts.metric, "metric", "", "Metric of the timeseries data source, e.g. dt.kubernetes.container.cpu_usage")
+	queryCmd.Flags().StringVar(&opts.aggregation, "aggregation", "avg", "Aggregation of the metric. One of: "+strings.Join(DQLAggregations, ", "))
+	queryCmd.Flags().StringSliceVar(&opts.by, "by", []string{}, "Dimensions to split the metric by (comma-separated)")
+	queryCmd.Flags().StringVar(&opts.interval, "interval", "", "Interval of the metric values, e.g. 5m or 1h")
+	queryCmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Only print the query without running it")
+	opts.output.AddFlags(queryCmd)
+
+	queryCmd.MarkFlagsMutuallyExclusive("cluster-id", "dynatrace-url")
+	queryCmd.MarkFlagsOneRequired("cluster-id", "dynatrace-url")
+	queryCmd.MarkFlagsRequiredTogether("from", "to")
+	queryCmd.MarkFlagsMutuallyExclusive("since", "from")
+	queryCmd.MarkFlagsMutuallyExclusive("since", "to")
+
+	return queryCmd
+}
+
+func (o *queryOptions) run(ctx context.Context, out io.Writer, errOut io.Writer) error {
+	if err := o.output.Validate(); err != nil {
+		return err
+	}
+
+	var cluster HCPCluster
+	dtURL := o.dynatraceURL
+	if o.clusterID != "" {
+		var err error
+		cluster, err = FetchClusterDetails(o.clusterID)
+		if err != nil {
+			return fmt.Errorf("failed to acquire cluster details %v", err)
+		}
+		dtURL = cluster.DynatraceURL
+	}
+	if !strings.HasSuffix(dtURL, "/") {
+		dtURL += "/"
+	}
+
+	query := o.query
+	if query == "" {
+		dqlQuery, err := o.dqlQuery(cluster)
+		if err != nil {
+			return err
+		}
+		if query, err = dqlQuery.Build(); err != nil {
+			return fmt.Errorf("failed to build query for Dynatrace: %w", err)
+		}
+	}
+
+	if o.dryRun {
+		_, err := fmt.Fprintln(out, query)
+		return err
+	}
+	fmt.Fprintf(errOut, "%s\n\n", query)
+
+	accessToken, err := getQueryAccessToken()
+	if err != nil {
+		return fmt.Errorf("failed to acquire access token %v", err)
+	}
+
+	return o.runQuery(ctx, dtURL, accessToken, query, out, errOut)
+}
+
+// runQuery runs the query and prints its records, reporting the progress of the query to errOut
+func (o *queryOptions) runQuery(ctx context.Context, dtURL string, accessToken string, query string, out io.Writer, errOut io.Writer) error {
+	lastProgress := -1
+	progress := func(p int) {
+		if p != lastProgress && p < 100 {
+			fmt.Fprintf(errOut, "Query running: %d%%\n", p)
+		}
+		lastProgress = p
+	}
+
+	records, err := fetchDTRecords(ctx, dtURL, accessToken, query, progress)
+	if err != nil {
+		return err
+	}
+
+	columns := recordColumns(records, o.fields)
+	result := output.NewTable(records, columns, func(record map[string]interface{}) []string {
+		return recordRow(record, columns)
+	})
+	return o.output.Print(out, result)
+}
+
+// dqlQuery builds the query from the flags, restricting it to the cluster when one is given
+func (o *queryOptions) dqlQuery(cluster HCPCluster) (DQLQuery, error) {
+	q := DQLQuery{
+		Source:      o.source,
+		From:        o.from,
+		To:          o.to,
+		Fields:      o.fields,
+		SortOrder:   o.sortOrder,
+		Limit:       o.limit,
+		Metric:      o.metric,
+		Aggregation: o.aggregation,
+		By:          o.by,
+	}
+
+	if o.from.IsZero() {
+		since, err := model.ParseDuration(o.since)
+		if err != nil {
+			return q, fmt.Errorf("invalid duration %q for --since: %w", o.since, err)
+		}
+		q.Since = time.Duration(since)
+	}
+	if o.interval != "" {
+		interval, err := model.ParseDuration(o.interval)
+		if err != nil {
+			return q, fmt.Errorf("invalid duration %q for --interval: %w", o.interval, err)
+		}
+		q.Interval = time.Duration(interval)
+	}
+
+	if cluster.managementClusterName != "" {
+		q.Filters = append(q.Filters, DQLFilter{Field: clusterNameField(o.source), Operator: DQLFilterEqual, Values: []string{cluster.managementClusterName}})
+	}
+	if cluster.hcpNamespace != "" {
+		q.Filters = append(q.Filters, DQLFilter{Field: "k8s.namespace.name", Operator: DQLFilterEqual, Values: []string{cluster.hcpNamespace}})
+	}
+	for _, f := range o.filters {
+		filter, err := ParseDQLFilter(f)
+		if err != nil {
+			return q, err
+		}
+		q.Filters = append(q.Filters, filter)
+	}
+	return q, nil
+}
+
+// clusterNameField is the field holding the name of the cluster of the records of a data source
+func clusterNameField(source string) string {
+	if source == DQLSourceMetrics {
+		return "k8s.cluster.name"
+	}
+	return "dt.kubernetes.cluster.name"
+}
+
+// recordColumns returns the fields when set, otherwise the fields of all records sorted by
+// name, timestamp first
+func recordColumns(records []map[string]interface{}, fields []string) []output.Column {
+	names := fields
+	if len(names) == 0 {
+		seen := map[string]bool{}
+		for _, record := range records {
+			for name := range record {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if names[i] == "timestamp" || names[j] == "timestamp" {
+				return names[i] == "timestamp"
+			}
+			return names[i] < names[j]
+		})
+	}
+
+	columns := make([]output.Column, len(names))
+	for i, name := range names {
+		columns[i] = output.Column{Name: name}
+	}
+	return columns
+}
+
+// recordRow returns the values of the columns of a record. Values other than strings and
+// numbers, e.g. the values of a timeseries, are printed as JSON.
+func recordRow(record map[string]interface{}, columns []output.Column) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		switch value := record[column.Name].(type) {
+		case nil:
+		case string:
+			row[i] = value
+		case json.Number:
+			row[i] = value.String()
+		default:
+			data, err := json.Marshal(value)
+			if err != nil {
+				row[i] = fmt.Sprint(value)
+				continue
+			}
+			row[i] = string(data)
+		}
+	}
+	return row
+}
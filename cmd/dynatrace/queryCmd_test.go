@@ -0,0 +1,220 @@
+package dynatrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/output"
+)
+
+// fakeDynatrace serves the query endpoints of the Dynatrace storage API. A query runs for
+// polls polls before returning records, or until it is cancelled when polls is negative.
+type fakeDynatrace struct {
+	mu        sync.Mutex
+	records   []map[string]interface{}
+	polls     int
+	queries   []string
+	cancelled bool
+	// rateLimited is the number of queries to refuse with 429 Too Many Requests
+	rateLimited int
+	// invalid is a phrase making the queries containing it invalid
+	invalid string
+}
+
+func newFakeDynatrace(t *testing.T, records []map[string]interface{}, polls int) (*fakeDynatrace, string) {
+	fake := &fakeDynatrace{records: records, polls: polls}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	interval := dtPollInterval
+	dtPollInterval = time.Millisecond
+	t.Cleanup(func() { dtPollInterval = interval })
+
+	return fake, server.URL + "/"
+}
+
+func (f *fakeDynatrace) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer token" {
+		f.reply(w, http.StatusUnauthorized, map[string]interface{}{"error": map[string]string{"message": "invalid token"}})
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/platform/storage/query/v1/query:execute":
+		var payload DTQueryPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			f.reply(w, http.StatusBadRequest, map[string]interface{}{"error": map[string]string{"message": err.Error()}})
+			return
+		}
+		if f.rateLimited > 0 {
+			f.rateLimited--
+			f.reply(w, http.StatusTooManyRequests, map[string]interface{}{"error": map[string]string{"message": "too many requests"}})
+			return
+		}
+		if f.invalid != "" && strings.Contains(payload.Query, f.invalid) {
+			f.reply(w, http.StatusBadRequest, map[string]interface{}{"error": map[string]string{"message": "invalid query"}})
+			return
+		}
+		f.queries = append(f.queries, payload.Query)
+		f.reply(w, http.StatusAccepted, map[string]interface{}{"state": "RUNNING", "requestToken": "request-1", "ttlSeconds": 60})
+	case r.Method == http.MethodGet && r.URL.Path == "/platform/storage/query/v1/query:poll" && r.URL.Query().Get("request-token") == "request-1":
+		if f.polls != 0 {
+			if f.polls > 0 {
+				f.polls--
+			}
+			f.reply(w, http.StatusOK, map[string]interface{}{"state": "RUNNING", "progress": 50})
+			return
+		}
+		f.reply(w, http.StatusOK, map[string]interface{}{"state": "SUCCEEDED", "progress": 100, "result": map[string]interface{}{"records": f.records}})
+	case r.Method == http.MethodPost && r.URL.Path == "/platform/storage/query/v1/query:cancel":
+		f.cancelled = true
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		f.reply(w, http.StatusNotFound, map[string]interface{}{"error": map[string]string{"message": "not found"}})
+	}
+}
+
+func (f *fakeDynatrace) reply(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestRunQuery(t *testing.T) {
+	records := []map[string]interface{}{
+		{"timestamp": "2025-06-15T04:00:00Z", "k8s.pod.name": "alertmanager-main-0", "bytes": 12345678901234567},
+		{"timestamp": "2025-06-15T04:01:00Z", "k8s.pod.name": "alertmanager-main-1", "value": []float64{1.5, 2}},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		fields   []string
+		expected string
+	}{
+		{
+			name:   "table",
+			format: output.Table,
+			expected: `timestamp              bytes               k8s.pod.name          value
+2025-06-15T04:00:00Z   12345678901234567   alertmanager-main-0
+2025-06-15T04:01:00Z                       alertmanager-main-1   [1.5,2]
+`,
+		},
+		{
+			name:   "csv with fields",
+			format: output.CSV,
+			fields: []string{"k8s.pod.name", "timestamp"},
+			expected: `k8s.pod.name,timestamp
+alertmanager-main-0,2025-06-15T04:00:00Z
+alertmanager-main-1,2025-06-15T04:01:00Z
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, dtURL := newFakeDynatrace(t, records, 2)
+			opts := &queryOptions{fields: tt.fields, output: output.Options{Format: tt.format}}
+
+			var out, errOut bytes.Buffer
+			if err := opts.runQuery(context.Background(), dtURL, "token", "fetch logs", &out, &errOut); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.Join(strings.Fields(out.String()), " "); got != strings.Join(strings.Fields(tt.expected), " ") {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, out.String())
+			}
+			if errOut.String() != "Query running: 50%\n" {
+				t.Errorf("expected the progress to be reported once, got: %q", errOut.String())
+			}
+			if len(fake.queries) != 1 || fake.queries[0] != "fetch logs" {
+				t.Errorf("expected the query to be executed once, got: %v", fake.queries)
+			}
+		})
+	}
+}
+
+func TestRunQueryJSON(t *testing.T) {
+	_, dtURL := newFakeDynatrace(t, []map[string]interface{}{{"event.id": 9007199254740993}}, 0)
+	opts := &queryOptions{output: output.Options{Format: output.JSON}}
+
+	var out bytes.Buffer
+	if err := opts.runQuery(context.Background(), dtURL, "token", "fetch events", &out, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Numbers are printed as returned by Dynatrace, even those a float64 can't hold
+	expected := "[\n  {\n    \"event.id\": 9007199254740993\n  }\n]\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestRunQueryErrors(t *testing.T) {
+	_, dtURL := newFakeDynatrace(t, nil, 0)
+	opts := &queryOptions{output: output.Options{Format: output.Table}}
+
+	err := opts.runQuery(context.Background(), dtURL, "expired", "fetch logs", &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("expected an unauthorized error, got: %v", err)
+	}
+}
+
+func TestRunQueryCancel(t *testing.T) {
+	fake, dtURL := newFakeDynatrace(t, nil, -1)
+	opts := &queryOptions{output: output.Options{Format: output.Table}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := opts.runQuery(ctx, dtURL, "token", "fetch logs", &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "query cancelled") {
+		t.Errorf("expected the query to be cancelled, got: %v", err)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !fake.cancelled {
+		t.Errorf("expected the query to be cancelled in Dynatrace")
+	}
+}
+
+func TestQueryDQL(t *testing.T) {
+	opts := &queryOptions{
+		source:      DQLSourceLogs,
+		since:       "1d",
+		filters:     []string{"status=ERROR"},
+		sortOrder:   "asc",
+		limit:       100,
+		aggregation: "avg",
+	}
+	cluster := HCPCluster{managementClusterName: "hs-mc-1", hcpNamespace: "ocm-production-1234-hcp"}
+
+	q, err := opts.dqlQuery(cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query, err := q.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `fetch logs, from:now()-1d
+| filter matchesValue(dt.kubernetes.cluster.name, "hs-mc-1") and matchesValue(k8s.namespace.name, "ocm-production-1234-hcp") and matchesValue(status, "ERROR")
+| sort timestamp asc
+| limit 100`
+	if query != expected {
+		t.Errorf("expected: %s\ngot: %s", expected, query)
+	}
+
+	opts.since = "yesterday"
+	if _, err := opts.dqlQuery(cluster); err == nil || !strings.Contains(err.Error(), "--since") {
+		t.Errorf("expected an invalid --since error, got: %v", err)
+	}
+}
@@ -1,12 +1,14 @@
 package dynatrace
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
+	"time"
 
 	"github.com/openshift/osdctl/pkg/utils"
 )
@@ -18,6 +20,9 @@ const (
 	DTStorageVaultPathKey string = "dt_vault_path"
 	DTStorageScopes       string = "storage:logs:read storage:events:read storage:buckets:read"
 
+	// Queries of any data source
+	DTQueryScopes string = DTStorageScopes + " storage:spans:read storage:bizevents:read storage:metrics:read"
+
 	// Dashboards
 	DTDocumentVaultPathKey string = "dt_document_vault_path"
 	DTDocumentScopes       string = "document:documents:read"
@@ -32,6 +37,10 @@ func getStorageAccessToken() (string, error) {
 	return utils.GetScopedAccessToken(authURL, DTStorageVaultPathKey, DTStorageScopes)
 }
 
+func getQueryAccessToken() (string, error) {
+	return utils.GetScopedAccessToken(authURL, DTStorageVaultPathKey, DTQueryScopes)
+}
+
 func getStorageTokenProvider() (utils.AccessTokenProvider, error) {
 	return utils.GetScopedTokenProvider(authURL, DTStorageVaultPathKey, DTStorageScopes)
 }
@@ -69,6 +78,17 @@ type DTEventResult struct {
 	Records []json.RawMessage `json:"records"`
 }
 
+// DTRecordsPollResult is the result of a query of any data source, e.g. spans or timeseries
+type DTRecordsPollResult struct {
+	State    string          `json:"state"`
+	Progress int             `json:"progress"`
+	Result   DTRecordsResult `json:"result"`
+}
+
+type DTRecordsResult struct {
+	Records []map[string]interface{} `json:"records"`
+}
+
 type DTExecuteState struct {
 	State      string `json:"state"`
 	TTLSeconds int    `json:"ttlSeconds"`
@@ -92,7 +112,13 @@ type DTDocument struct {
 	Type string `json:"type"`
 }
 
-func getDTQueryExecution(dtURL string, accessToken string, query string) (reqToken string, error error) {
+// dtPollInterval is the delay between two polls of a running query
+var dtPollInterval = time.Second
+
+// dtProgressFunc is called with the progress percentage of a running query
+type dtProgressFunc func(progress int)
+
+func getDTQueryExecution(ctx context.Context, dtURL string, accessToken string, query string) (reqToken string, error error) {
 	// Note: Currently we are setting a limit of 20,000 lines to pull from Dynatrace
 	// due to a limitation in dynatrace to pull all logs. This limitation can be revoked
 	// once https://community.dynatrace.com/t5/Product-ideas/Pagination-in-DQL-results/idi-p/248282#M45818
@@ -116,6 +142,7 @@ func getDTQueryExecution(dtURL string, accessToken string, query string) (reqTok
 			"Authorization": "Bearer " + accessToken,
 		},
 		SuccessCode: http.StatusAccepted,
+		Context:     ctx,
 	}
 
 	var resp string
@@ -157,7 +184,9 @@ func getDTQueryExecution(dtURL string, accessToken string, query string) (reqTok
 	return token.RequestToken, err
 }
 
-func getDTPollResults(dtURL string, requestToken string, accessToken string) (respBody string, error error) {
+// getDTPollResults polls a query until it completes, reporting its progress to progress when
+// not nil. The query is cancelled when ctx is done.
+func getDTPollResults(ctx context.Context, dtURL string, requestToken string, accessToken string, progress dtProgressFunc) (respBody string, error error) {
 	var dtPollRes DTLogsPollResult
 	reqData := url.Values{
 		"request-token": {requestToken},
@@ -171,11 +200,16 @@ func getDTPollResults(dtURL string, requestToken string, accessToken string) (re
 			"Authorization": "Bearer " + accessToken,
 		},
 		SuccessCode: http.StatusOK,
+		Context:     ctx,
 	}
 
 	for {
 		resp, err := requester.Send()
 		if err != nil {
+			if ctx.Err() != nil {
+				cancelDTQuery(dtURL, requestToken, accessToken)
+				return "", fmt.Errorf("query cancelled: %w", ctx.Err())
+			}
 			return "", err
 		}
 
@@ -184,18 +218,66 @@ func getDTPollResults(dtURL string, requestToken string, accessToken string) (re
 			return "", err
 		}
 
-		if dtPollRes.State == "RUNNING" {
-			continue
+		if progress != nil {
+			progress(dtPollRes.Progress)
 		}
 
 		if dtPollRes.State == "SUCCEEDED" {
 			return resp, nil
 		}
 
-		if dtPollRes.State != "RUNNING" && dtPollRes.State != "SUCCEEDED" {
+		if dtPollRes.State != "RUNNING" {
 			return "", fmt.Errorf("query failed")
 		}
+
+		select {
+		case <-ctx.Done():
+			cancelDTQuery(dtURL, requestToken, accessToken)
+			return "", fmt.Errorf("query cancelled: %w", ctx.Err())
+		case <-time.After(dtPollInterval):
+		}
+	}
+}
+
+// cancelDTQuery stops a running query. Cancelling is best effort, the query ends by itself
+// when it times out.
+func cancelDTQuery(dtURL string, requestToken string, accessToken string) {
+	reqData := url.Values{
+		"request-token": {requestToken},
+	}.Encode()
+
+	requester := utils.Requester{
+		Method: http.MethodPost,
+		Url:    dtURL + "platform/storage/query/v1/query:cancel?" + reqData,
+		Headers: map[string]string{
+			"Content-Type":  "application/json",
+			"Authorization": "Bearer " + accessToken,
+		},
+		SuccessCode: http.StatusAccepted,
 	}
+	_, _ = requester.Send()
+}
+
+// fetchDTRecords runs a query and returns its records, whatever their data source
+func fetchDTRecords(ctx context.Context, dtURL string, accessToken string, query string, progress dtProgressFunc) ([]map[string]interface{}, error) {
+	requestToken, err := getDTQueryExecution(ctx, dtURL, accessToken, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	resp, err := getDTPollResults(ctx, dtURL, requestToken, accessToken, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	// Numbers are kept as they are returned, as 64-bit IDs and counters can't be held by a float64
+	var dtPollRes DTRecordsPollResult
+	decoder := json.NewDecoder(bytes.NewReader([]byte(resp)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&dtPollRes); err != nil {
+		return nil, fmt.Errorf("failed to parse query results: %w", err)
+	}
+	return dtPollRes.Result.Records, nil
 }
 
 // getDocumentIDByNameAndType searches using the dynatrace document API using a filter that
@@ -241,64 +323,48 @@ func getDocumentIDByNameAndType(dtURL string, accessToken string, docName string
 	return dtDashboard.Id, nil
 }
 
-func fetchAndWriteLogs(dtURL string, accessToken string, requestToken string, filePath string) error {
-	resp, err := getDTPollResults(dtURL, requestToken, accessToken)
+// fetchAndWriteLogs writes the content of the logs returned by a query, one log per line, and
+// returns the number of logs
+func fetchAndWriteLogs(ctx context.Context, dtURL string, accessToken string, requestToken string, w io.Writer) (int, error) {
+	resp, err := getDTPollResults(ctx, dtURL, requestToken, accessToken, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	var dtPollRes DTLogsPollResult
 	err = json.Unmarshal([]byte(resp), &dtPollRes)
 	if err != nil {
-		return err
-	}
-
-	var w io.Writer = os.Stdout
-	if filePath != "" {
-		f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		w = f
+		return 0, err
 	}
 
 	for _, result := range dtPollRes.Result.Records {
 		if _, err := fmt.Fprintf(w, "%s\n", result.Content); err != nil {
-			return err
+			return 0, err
 		}
 	}
 
-	return nil
+	return len(dtPollRes.Result.Records), nil
 }
 
-func fetchAndWriteEvents(dtURL string, accessToken string, requestToken string, filePath string) error {
-	resp, err := getDTPollResults(dtURL, requestToken, accessToken)
+// fetchAndWriteEvents writes the events returned by a query, one JSON event per line, and
+// returns the number of events
+func fetchAndWriteEvents(ctx context.Context, dtURL string, accessToken string, requestToken string, w io.Writer) (int, error) {
+	resp, err := getDTPollResults(ctx, dtURL, requestToken, accessToken, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	var dtPollRes DTEventsPollResult
 	err = json.Unmarshal([]byte(resp), &dtPollRes)
 	if err != nil {
-		return err
-	}
-
-	var w io.Writer = os.Stdout
-	if filePath != "" {
-		f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		w = f
+		return 0, err
 	}
 
 	for _, result := range dtPollRes.Result.Records {
 		if _, err := fmt.Fprintf(w, "%s\n", result); err != nil {
-			return err
+			return 0, err
 		}
 	}
 
-	return nil
+	return len(dtPollRes.Result.Records), nil
 }
@@ -1,37 +1,21 @@
 package getoutput
 
 import (
-	"encoding/json"
-	"fmt"
+	"os"
 
-	"gopkg.in/yaml.v2"
+	"github.com/openshift/osdctl/pkg/output"
 )
 
 type CmdResponse interface {
 	String() string
 }
 
-func PrintResponse(output string, resp CmdResponse) error {
-	if output == "json" {
-
-		accountsToJson, err := json.MarshalIndent(resp, "", "    ")
-		if err != nil {
-			return err
-		}
-
-		fmt.Println(string(accountsToJson))
-
-	} else if output == "yaml" {
-
-		accountIdToYaml, err := yaml.Marshal(resp)
-		if err != nil {
-			return err
-		}
-
-		fmt.Println(string(accountIdToYaml))
-
-	} else {
-		fmt.Println(resp)
+// PrintResponse prints resp in the given output format. Formats the output package doesn't
+// support print the String() form, as they always did.
+func PrintResponse(format string, resp CmdResponse) error {
+	opts := output.Options{Format: format}
+	if opts.Validate() != nil {
+		opts.Format = output.Table
 	}
-	return nil
+	return opts.Print(os.Stdout, output.NewObject(resp, resp.String()))
 }
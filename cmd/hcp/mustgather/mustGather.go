@@ -174,7 +174,7 @@ func (mg *mustGather) Run() error {
 				destDir := outputDir + "/hcp"
 
 				// 1. Gather logs from DT
-				gatherOptions := &dynatrace.GatherLogsOpts{Since: 72, SortOrder: "asc", DestDir: destDir}
+				gatherOptions := &dynatrace.GatherLogsOpts{Since: "72h", SortOrder: "asc", DestDir: destDir}
 				if err := gatherOptions.GatherLogs(mg.clusterId); err != nil {
 					fmt.Printf("failed to gather HCP dynatrace logs: %v\n", err)
 				}
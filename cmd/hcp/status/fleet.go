@@ -0,0 +1,63 @@
+package status
+
+import (
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/output"
+)
+
+// fleetRow is the summary of the status of a cluster of a fleet
+type fleetRow struct {
+	ClusterKey   string `json:"cluster_key"`
+	ClusterID    string `json:"cluster_id,omitempty"`
+	ClusterName  string `json:"cluster_name,omitempty"`
+	ClusterState string `json:"cluster_state,omitempty"`
+	Version      string `json:"version,omitempty"`
+	Health       Health `json:"health"`
+	Error        string `json:"error,omitempty"`
+}
+
+// fleetStatus summarizes the status of every cluster, a cluster whose status can't be fetched
+// being reported with an Unknown health and the error
+func fleetStatus(clusterKeys []string, getStatus statusFunc, thresholds HealthThresholds, now time.Time) []fleetRow {
+	rows := make([]fleetRow, 0, len(clusterKeys))
+	for _, key := range clusterKeys {
+		row := fleetRow{ClusterKey: key}
+		status, err := getStatus(key)
+		if err != nil {
+			row.Health = Health{Verdict: HealthUnknown}
+			row.Error = err.Error()
+			rows = append(rows, row)
+			continue
+		}
+
+		row.ClusterID = status.ClusterID
+		row.ClusterName = status.ClusterName
+		row.ClusterState = status.ClusterState
+		row.Version = status.Version.Current
+		row.Health = evaluateHealth(status, thresholds, now)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// fleetTable prints one row per cluster, with the reasons it isn't healthy
+func fleetTable(rows []fleetRow) *output.Result {
+	columns := []output.Column{
+		{Name: "CLUSTER"},
+		{Name: "NAME"},
+		{Name: "STATE"},
+		{Name: "VERSION"},
+		{Name: "HEALTH"},
+		{Name: "REASONS"},
+		{Name: "ID", Wide: true},
+	}
+	return output.NewTable(rows, columns, func(r fleetRow) []string {
+		reasons := strings.Join(r.Health.Reasons, "; ")
+		if r.Error != "" {
+			reasons = r.Error
+		}
+		return []string{r.ClusterKey, r.ClusterName, r.ClusterState, r.Version, r.Health.Verdict, reasons, r.ClusterID}
+	})
+}
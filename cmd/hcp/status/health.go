@@ -0,0 +1,208 @@
+package status
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Health verdicts, from the best to the worst
+const (
+	HealthHealthy   = "Healthy"
+	HealthUnknown   = "Unknown"
+	HealthDegraded  = "Degraded"
+	HealthUnhealthy = "Unhealthy"
+)
+
+// Health is the overall health of an HCP cluster, with the reasons it isn't healthy.
+type Health struct {
+	Verdict string   `json:"verdict"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// HealthThresholds holds the limits beyond which a cluster is reported as degraded.
+type HealthThresholds struct {
+	// CertExpiryDays flags certificates expiring within this many days
+	CertExpiryDays int
+	// StaleAfter flags ManifestWorks whose conditions haven't changed for longer than this
+	// duration, zero disables the check
+	StaleAfter time.Duration
+}
+
+// DefaultHealthThresholds are the thresholds used unless set otherwise.
+var DefaultHealthThresholds = HealthThresholds{CertExpiryDays: 14}
+
+// criticalConditions are the conditions which make the cluster unhealthy when they don't have
+// the expected status, while the other expectedConditions only make it degraded.
+var (
+	criticalConditions = map[string]string{
+		"Available": "True",
+		"Ready":     "True",
+		"Degraded":  "False",
+	}
+	expectedConditions = map[string]string{
+		"ClusterVersionSucceeding":             "True",
+		"ReconciliationSucceeded":              "True",
+		"ValidConfiguration":                   "True",
+		"ValidHostedControlPlaneConfiguration": "True",
+		"ValidReleaseImage":                    "True",
+		"ValidReleaseInfo":                     "True",
+		"AllMachinesReady":                     "True",
+		"AllNodesHealthy":                      "True",
+	}
+)
+
+// evaluateHealth computes the health verdict of a cluster at the given time. Failing critical
+// conditions, ManifestWorks which aren't applied or available, and expired or not ready
+// certificates make the cluster unhealthy. Other failing conditions, certificates close to their
+// expiry and stale ManifestWorks make it degraded.
+func evaluateHealth(s *HCPStatus, thresholds HealthThresholds, now time.Time) Health {
+	var problems, warnings []string
+
+	if s.ClusterState != "" && s.ClusterState != "ready" {
+		warnings = append(warnings, fmt.Sprintf("cluster state is %s", s.ClusterState))
+	}
+
+	checkConditions := func(owner string, conditions []Condition) {
+		for _, c := range conditions {
+			if expected, ok := criticalConditions[c.Type]; ok && c.Status != expected {
+				problems = append(problems, conditionReason(owner, c))
+			} else if expected, ok := expectedConditions[c.Type]; ok && c.Status != expected {
+				warnings = append(warnings, conditionReason(owner, c))
+			}
+		}
+	}
+	checkConditions("HostedCluster", s.HostedClusterConditions)
+	for _, np := range s.NodePools {
+		checkConditions("NodePool "+np.Name, np.Conditions)
+	}
+
+	for _, mw := range s.ManifestWorks {
+		switch {
+		case !mw.Applied:
+			problems = append(problems, fmt.Sprintf("ManifestWork %s is not applied", mw.Name))
+		case !mw.Available:
+			problems = append(problems, fmt.Sprintf("ManifestWork %s is not available", mw.Name))
+		case thresholds.StaleAfter > 0 && !mw.LastSyncTime.IsZero() && now.Sub(mw.LastSyncTime) > thresholds.StaleAfter:
+			warnings = append(warnings, fmt.Sprintf("ManifestWork %s last synced %s ago", mw.Name, now.Sub(mw.LastSyncTime).Truncate(time.Minute)))
+		}
+	}
+
+	if c := s.IngressCertificate; c != nil {
+		daysRemaining := int(math.Ceil(c.NotAfter.Sub(now).Hours() / 24))
+		switch {
+		case c.Ready != nil && !*c.Ready:
+			problems = append(problems, "ingress certificate is not ready")
+		case !c.NotAfter.IsZero() && !c.NotAfter.After(now):
+			problems = append(problems, fmt.Sprintf("ingress certificate expired on %s", c.NotAfter.Format("2006-01-02")))
+		case !c.NotAfter.IsZero() && daysRemaining <= thresholds.CertExpiryDays:
+			warnings = append(warnings, fmt.Sprintf("ingress certificate expires in %dd", daysRemaining))
+		}
+	}
+
+	health := Health{Verdict: HealthHealthy, Reasons: append(problems, warnings...)}
+	switch {
+	case len(problems) > 0:
+		health.Verdict = HealthUnhealthy
+	case len(warnings) > 0:
+		health.Verdict = HealthDegraded
+	case len(s.ManifestWorks) == 0 && len(s.HostedClusterConditions) == 0:
+		health.Verdict = HealthUnknown
+		health.Reasons = []string{"no ManifestWork or HostedCluster conditions found"}
+	}
+	return health
+}
+
+// conditionReason describes a condition which doesn't have the expected status
+func conditionReason(owner string, c Condition) string {
+	reason := fmt.Sprintf("%s condition %s is %s", owner, c.Type, c.Status)
+	if c.Reason != "" {
+		reason += " (" + c.Reason + ")"
+	}
+	return reason
+}
+
+// statusTransitions lists the changes between two polls of the status of a cluster
+func statusTransitions(previous, current *Report) []string {
+	var transitions []string
+	changed := func(what string, before, after string) {
+		if before != after {
+			transitions = append(transitions, fmt.Sprintf("%s: %s -> %s", what, orNone(before), orNone(after)))
+		}
+	}
+
+	changed("Health", previous.Health.Verdict, current.Health.Verdict)
+	changed("Cluster state", previous.ClusterState, current.ClusterState)
+	changed("Control plane version", previous.Version.Current, current.Version.Current)
+	changed("Control plane version status", previous.Version.Status, current.Version.Status)
+	conditionTransitions("HostedCluster", previous.HostedClusterConditions, current.HostedClusterConditions, changed)
+
+	previousMWs := map[string]ManifestWorkSync{}
+	for _, mw := range previous.ManifestWorks {
+		previousMWs[mw.Name] = mw
+	}
+	for _, mw := range current.ManifestWorks {
+		before, ok := previousMWs[mw.Name]
+		delete(previousMWs, mw.Name)
+		if !ok {
+			transitions = append(transitions, fmt.Sprintf("ManifestWork %s added", mw.Name))
+			continue
+		}
+		changed("ManifestWork "+mw.Name+" applied", boolStatus(before.Applied), boolStatus(mw.Applied))
+		changed("ManifestWork "+mw.Name+" available", boolStatus(before.Available), boolStatus(mw.Available))
+	}
+	for _, mw := range previous.ManifestWorks {
+		if _, ok := previousMWs[mw.Name]; ok {
+			transitions = append(transitions, fmt.Sprintf("ManifestWork %s removed", mw.Name))
+		}
+	}
+
+	previousNPs := map[string]NodePoolStatus{}
+	for _, np := range previous.NodePools {
+		previousNPs[np.Name] = np
+	}
+	for _, np := range current.NodePools {
+		before, ok := previousNPs[np.Name]
+		delete(previousNPs, np.Name)
+		if !ok {
+			transitions = append(transitions, fmt.Sprintf("NodePool %s added", np.Name))
+			continue
+		}
+		changed("NodePool "+np.Name+" replicas", fmt.Sprint(before.Replicas), fmt.Sprint(np.Replicas))
+		changed("NodePool "+np.Name+" version", before.Version, np.Version)
+		conditionTransitions("NodePool "+np.Name, before.Conditions, np.Conditions, changed)
+	}
+	for _, np := range previous.NodePools {
+		if _, ok := previousNPs[np.Name]; ok {
+			transitions = append(transitions, fmt.Sprintf("NodePool %s removed", np.Name))
+		}
+	}
+
+	return transitions
+}
+
+// conditionTransitions reports the conditions whose status changed, appeared or disappeared
+func conditionTransitions(owner string, previous, current []Condition, changed func(what string, before, after string)) {
+	before := map[string]string{}
+	for _, c := range previous {
+		before[c.Type] = c.Status
+	}
+	seen := map[string]bool{}
+	for _, c := range current {
+		seen[c.Type] = true
+		changed(owner+" condition "+c.Type, before[c.Type], c.Status)
+	}
+	for _, c := range previous {
+		if !seen[c.Type] {
+			changed(owner+" condition "+c.Type, c.Status, "")
+		}
+	}
+}
+
+func orNone(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "(none)"
+	}
+	return s
+}
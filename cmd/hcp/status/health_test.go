@@ -0,0 +1,170 @@
+package status
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func healthyStatus() *HCPStatus {
+	ready := true
+	return &HCPStatus{
+		ClusterID:    "cluster-1",
+		ClusterName:  "my-cluster",
+		ClusterState: "ready",
+		Version:      VersionInfo{Current: "4.21.0", Status: "Completed"},
+		ManifestWorks: []ManifestWorkSync{
+			{Name: "abc-123", Applied: true, Available: true, LastSyncTime: testNow.Add(-time.Hour)},
+		},
+		HostedClusterConditions: []Condition{
+			{Type: "Available", Status: "True"},
+			{Type: "Degraded", Status: "False"},
+			{Type: "Progressing", Status: "True"},
+		},
+		IngressCertificate: &CertificateStatus{Ready: &ready, NotAfter: testNow.Add(60 * 24 * time.Hour)},
+		NodePools: []NodePoolStatus{
+			{Name: "workers", Replicas: 2, Conditions: []Condition{{Type: "Ready", Status: "True"}, {Type: "AllNodesHealthy", Status: "True"}}},
+		},
+	}
+}
+
+func TestEvaluateHealth(t *testing.T) {
+	thresholds := HealthThresholds{CertExpiryDays: 14, StaleAfter: 24 * time.Hour}
+	notReady := false
+
+	tests := []struct {
+		name            string
+		mutate          func(s *HCPStatus)
+		expectedVerdict string
+		expectedReasons []string
+	}{
+		{
+			name:            "healthy",
+			mutate:          func(s *HCPStatus) {},
+			expectedVerdict: HealthHealthy,
+		},
+		{
+			name: "degraded hosted cluster",
+			mutate: func(s *HCPStatus) {
+				s.HostedClusterConditions[1] = Condition{Type: "Degraded", Status: "True", Reason: "EtcdUnavailable"}
+			},
+			expectedVerdict: HealthUnhealthy,
+			expectedReasons: []string{"HostedCluster condition Degraded is True (EtcdUnavailable)"},
+		},
+		{
+			name: "unhealthy nodes",
+			mutate: func(s *HCPStatus) {
+				s.NodePools[0].Conditions[1].Status = "False"
+			},
+			expectedVerdict: HealthDegraded,
+			expectedReasons: []string{"NodePool workers condition AllNodesHealthy is False"},
+		},
+		{
+			name: "certificate expiring soon",
+			mutate: func(s *HCPStatus) {
+				s.IngressCertificate.NotAfter = testNow.Add(10 * 24 * time.Hour)
+			},
+			expectedVerdict: HealthDegraded,
+			expectedReasons: []string{"ingress certificate expires in 10d"},
+		},
+		{
+			name: "expired certificate",
+			mutate: func(s *HCPStatus) {
+				s.IngressCertificate.NotAfter = testNow.Add(-time.Hour)
+			},
+			expectedVerdict: HealthUnhealthy,
+			expectedReasons: []string{"ingress certificate expired on 2026-03-01"},
+		},
+		{
+			name: "certificate not ready",
+			mutate: func(s *HCPStatus) {
+				s.IngressCertificate.Ready = &notReady
+			},
+			expectedVerdict: HealthUnhealthy,
+			expectedReasons: []string{"ingress certificate is not ready"},
+		},
+		{
+			name: "stale manifest work",
+			mutate: func(s *HCPStatus) {
+				s.ManifestWorks[0].LastSyncTime = testNow.Add(-48 * time.Hour)
+			},
+			expectedVerdict: HealthDegraded,
+			expectedReasons: []string{"ManifestWork abc-123 last synced 48h0m0s ago"},
+		},
+		{
+			name: "manifest work not applied and cluster updating",
+			mutate: func(s *HCPStatus) {
+				s.ClusterState = "updating"
+				s.ManifestWorks[0].Applied = false
+			},
+			expectedVerdict: HealthUnhealthy,
+			expectedReasons: []string{"ManifestWork abc-123 is not applied", "cluster state is updating"},
+		},
+		{
+			name: "no live data",
+			mutate: func(s *HCPStatus) {
+				s.ManifestWorks = nil
+				s.HostedClusterConditions = nil
+			},
+			expectedVerdict: HealthUnknown,
+			expectedReasons: []string{"no ManifestWork or HostedCluster conditions found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := healthyStatus()
+			tt.mutate(s)
+			health := evaluateHealth(s, thresholds, testNow)
+			if health.Verdict != tt.expectedVerdict {
+				t.Errorf("expected verdict %s, got %s (%v)", tt.expectedVerdict, health.Verdict, health.Reasons)
+			}
+			if strings.Join(health.Reasons, "|") != strings.Join(tt.expectedReasons, "|") {
+				t.Errorf("expected reasons %v, got %v", tt.expectedReasons, health.Reasons)
+			}
+		})
+	}
+}
+
+func TestEvaluateHealthStaleCheckDisabled(t *testing.T) {
+	s := healthyStatus()
+	s.ManifestWorks[0].LastSyncTime = testNow.Add(-30 * 24 * time.Hour)
+
+	health := evaluateHealth(s, HealthThresholds{CertExpiryDays: 14}, testNow)
+	if health.Verdict != HealthHealthy {
+		t.Errorf("expected a healthy cluster when the stale check is disabled, got %s (%v)", health.Verdict, health.Reasons)
+	}
+}
+
+func TestStatusTransitions(t *testing.T) {
+	thresholds := HealthThresholds{CertExpiryDays: 14}
+	previous := newStatusReport(healthyStatus(), thresholds, testNow)
+
+	if transitions := statusTransitions(previous, newStatusReport(healthyStatus(), thresholds, testNow)); len(transitions) != 0 {
+		t.Errorf("expected no transitions, got %v", transitions)
+	}
+
+	current := healthyStatus()
+	current.HostedClusterConditions[0].Status = "False"
+	current.HostedClusterConditions = current.HostedClusterConditions[:2]
+	current.ManifestWorks[0].Available = false
+	current.ManifestWorks = append(current.ManifestWorks, ManifestWorkSync{Name: "abc-123-nodepools", Applied: true, Available: true})
+	current.NodePools[0].Replicas = 3
+	current.NodePools = append(current.NodePools, NodePoolStatus{Name: "infra"})
+
+	expected := []string{
+		"Health: Healthy -> Unhealthy",
+		"HostedCluster condition Available: True -> False",
+		"HostedCluster condition Progressing: True -> (none)",
+		"ManifestWork abc-123 available: True -> False",
+		"ManifestWork abc-123-nodepools added",
+		"NodePool workers replicas: 2 -> 3",
+		"NodePool infra added",
+	}
+	transitions := statusTransitions(previous, newStatusReport(current, thresholds, testNow))
+	if strings.Join(transitions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(transitions, "\n"))
+	}
+}
@@ -1,14 +1,29 @@
 package status
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	osdctlio "github.com/openshift/osdctl/internal/io"
+	"github.com/openshift/osdctl/pkg/output"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
 type statusOptions struct {
-	clusterID string
+	clusterID    string
+	clustersFile string
+	watch        bool
+	interval     time.Duration
+	thresholds   HealthThresholds
+	output       output.Options
 }
 
 // NewCmdStatus creates and returns the status command.
@@ -20,9 +35,28 @@ func NewCmdStatus() *cobra.Command {
 		Short: "Show HCP cluster health status from OCM live resources",
 		Long: `Display a comprehensive health overview of a ROSA HCP cluster using
 data from the OCM live resources endpoint. Shows ManifestWork sync status,
-HostedCluster conditions, certificate status, and NodePool health.`,
+HostedCluster conditions, certificate status, and NodePool health.
+
+The overall health verdict is Unhealthy when a critical condition (Available,
+Ready, Degraded) fails, a ManifestWork isn't applied or available, or the
+ingress certificate is expired or not ready. It is Degraded when another
+condition fails, the ingress certificate expires within --cert-expiry-days, or
+a ManifestWork hasn't synced for longer than --stale-after.
+
+With --watch, the status is polled every --interval and the transitions between
+polls are highlighted. With --clusters-file, one summary row is printed per
+cluster.`,
 		Example: `  # Show HCP cluster status
-  osdctl hcp status --cluster-id ${CLUSTER_ID}`,
+  osdctl hcp status --cluster-id ${CLUSTER_ID}
+
+  # Show HCP cluster status as JSON
+  osdctl hcp status --cluster-id ${CLUSTER_ID} -o json
+
+  # Watch the status of a cluster and print its transitions
+  osdctl hcp status --cluster-id ${CLUSTER_ID} --watch --interval 1m
+
+  # Show the health of a fleet of clusters
+  osdctl hcp status --clusters-file clusters.json`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -31,47 +65,139 @@ HostedCluster conditions, certificate status, and NodePool health.`,
 	}
 
 	cmd.Flags().StringVarP(&opts.clusterID, "cluster-id", "C", "", "Cluster name, ID, or external ID")
-	_ = cmd.MarkFlagRequired("cluster-id")
+	cmd.Flags().StringVarP(&opts.clustersFile, "clusters-file", "c", "", "JSON file containing cluster IDs (format: {\"clusters\":[\"$CLUSTERID1\", \"$CLUSTERID2\"]})")
+	cmd.Flags().BoolVarP(&opts.watch, "watch", "w", false, "Poll the status of the cluster and print its transitions until interrupted")
+	cmd.Flags().DurationVar(&opts.interval, "interval", 30*time.Second, "Polling interval of --watch")
+	cmd.Flags().IntVar(&opts.thresholds.CertExpiryDays, "cert-expiry-days", DefaultHealthThresholds.CertExpiryDays, "Report the cluster as degraded when the ingress certificate expires within this many days")
+	cmd.Flags().DurationVar(&opts.thresholds.StaleAfter, "stale-after", 0, "Report the cluster as degraded when a ManifestWork hasn't synced for longer than this duration, e.g. 24h (disabled by default)")
+	opts.output.AddFlags(cmd)
+	cmd.MarkFlagsMutuallyExclusive("cluster-id", "clusters-file")
+	cmd.MarkFlagsOneRequired("cluster-id", "clusters-file")
+	cmd.MarkFlagsMutuallyExclusive("watch", "clusters-file")
 
 	return cmd
 }
 
+func (o *statusOptions) validate() error {
+	if o.watch && o.interval < time.Second {
+		return fmt.Errorf("--interval must be at least 1s")
+	}
+	if o.thresholds.CertExpiryDays < 0 {
+		return fmt.Errorf("--cert-expiry-days can't be negative")
+	}
+	if o.thresholds.StaleAfter < 0 {
+		return fmt.Errorf("--stale-after can't be negative")
+	}
+	return o.output.Validate()
+}
+
 func (o *statusOptions) run() error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	var clusterIDs []string
+	if o.clustersFile != "" {
+		var err error
+		clusterIDs, err = osdctlio.ParseAndValidateClustersFile(o.clustersFile)
+		if err != nil {
+			return err
+		}
+		if len(clusterIDs) == 0 {
+			return fmt.Errorf("clusters file contains no cluster IDs - the 'clusters' array is empty")
+		}
+	}
+
 	conn, err := utils.CreateConnection()
 	if err != nil {
 		return fmt.Errorf("failed to create OCM connection: %w", err)
 	}
 	defer conn.Close()
 
-	cluster, err := utils.GetCluster(conn, o.clusterID)
+	var getStatus statusFunc = func(clusterKey string) (*HCPStatus, error) {
+		return GetStatus(conn, clusterKey)
+	}
+
+	if o.clustersFile != "" {
+		return o.output.Print(os.Stdout, fleetTable(fleetStatus(clusterIDs, getStatus, o.thresholds, time.Now())))
+	}
+
+	if o.watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		w := &statusWatcher{
+			clusterKey: o.clusterID,
+			interval:   o.interval,
+			thresholds: o.thresholds,
+			output:     o.output,
+			getStatus:  getStatus,
+			out:        os.Stdout,
+			errOut:     os.Stderr,
+		}
+		return w.Run(ctx)
+	}
+
+	status, err := getStatus(o.clusterID)
 	if err != nil {
-		return fmt.Errorf("failed to find cluster: %w", err)
+		return err
+	}
+	return o.output.Print(os.Stdout, newStatusResult(newStatusReport(status, o.thresholds, time.Now())))
+}
+
+// GetStatusReport fetches the status of an HCP cluster and evaluates its health.
+func GetStatusReport(conn *sdk.Connection, clusterKey string, thresholds HealthThresholds) (*Report, error) {
+	status, err := GetStatus(conn, clusterKey)
+	if err != nil {
+		return nil, err
+	}
+	return newStatusReport(status, thresholds, time.Now()), nil
+}
+
+// newStatusReport evaluates the health of a cluster from its status
+func newStatusReport(status *HCPStatus, thresholds HealthThresholds, now time.Time) *Report {
+	return &Report{HCPStatus: status, Health: evaluateHealth(status, thresholds, now)}
+}
+
+// newStatusResult returns the report as an output result, printed as text by the table formats
+func newStatusResult(report *Report) *output.Result {
+	var text bytes.Buffer
+	printStatus(&text, report.HCPStatus, report.Health)
+	// The output ends the text with a newline
+	return output.NewObject(report, strings.TrimRight(text.String(), "\n"))
+}
+
+// GetStatus fetches the live resources of an HCP cluster from OCM and parses them.
+func GetStatus(conn *sdk.Connection, clusterKey string) (*HCPStatus, error) {
+	cluster, err := utils.GetCluster(conn, clusterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find cluster: %w", err)
 	}
 
 	if !cluster.Hypershift().Enabled() {
-		return fmt.Errorf("cluster %q is not an HCP cluster", o.clusterID)
+		return nil, fmt.Errorf("cluster %q is not an HCP cluster", clusterKey)
 	}
 
 	liveResponse, err := conn.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).Resources().Live().Get().Send()
 	if err != nil {
-		return fmt.Errorf("failed to get live resources: %w", err)
+		return nil, fmt.Errorf("failed to get live resources: %w", err)
 	}
 
 	resources := liveResponse.Body().Resources()
 	if len(resources) == 0 {
-		return fmt.Errorf("no live resources found for cluster %s", cluster.ID())
+		return nil, fmt.Errorf("no live resources found for cluster %s", cluster.ID())
 	}
 
 	status, err := parseLiveResources(resources, cluster.ID())
 	if err != nil {
-		return fmt.Errorf("failed to parse live resources: %w", err)
+		return nil, fmt.Errorf("failed to parse live resources: %w", err)
 	}
 
 	status.ClusterID = cluster.ExternalID()
 	status.ClusterName = cluster.Name()
 	status.ClusterState = string(cluster.State())
 
-	printStatus(status)
-
-	return nil
+	return status, nil
 }
+
+// statusFunc fetches the status of a cluster
+type statusFunc func(clusterKey string) (*HCPStatus, error)
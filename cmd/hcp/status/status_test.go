@@ -0,0 +1,122 @@
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/openshift/osdctl/pkg/output"
+)
+
+func TestStatusResult(t *testing.T) {
+	report := newStatusReport(healthyStatus(), HealthThresholds{CertExpiryDays: 14}, testNow)
+
+	var out bytes.Buffer
+	opts := output.Options{Format: output.JSON}
+	if err := opts.Print(&out, newStatusResult(report)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded["cluster_id"] != "cluster-1" {
+		t.Errorf("expected the status fields at the top level, got: %s", out.String())
+	}
+	if health, ok := decoded["health"].(map[string]interface{}); !ok || health["verdict"] != HealthHealthy {
+		t.Errorf("expected a healthy verdict, got: %s", out.String())
+	}
+
+	out.Reset()
+	opts = output.Options{Format: output.Table}
+	if err := opts.Print(&out, newStatusResult(report)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "HCP Cluster Status: my-cluster (cluster-1)\nCluster State: ready\nHealth: Healthy\n") {
+		t.Errorf("unexpected text output:\n%s", out.String())
+	}
+}
+
+func TestStatusWatcherPoll(t *testing.T) {
+	var (
+		status *HCPStatus
+		err    error
+	)
+	var out, errOut bytes.Buffer
+	w := &statusWatcher{
+		clusterKey: "cluster-1",
+		thresholds: HealthThresholds{CertExpiryDays: 14},
+		output:     output.Options{Format: output.Table},
+		getStatus:  func(string) (*HCPStatus, error) { return status, err },
+		out:        &out,
+		errOut:     &errOut,
+	}
+
+	// The first poll prints the whole status and fails on errors
+	err = errors.New("cluster not found")
+	if _, pollErr := w.poll(nil, testNow); pollErr == nil {
+		t.Fatalf("expected the first poll to fail")
+	}
+	status, err = healthyStatus(), nil
+	previous, pollErr := w.poll(nil, testNow)
+	if pollErr != nil {
+		t.Fatalf("unexpected error: %v", pollErr)
+	}
+	if !strings.Contains(out.String(), "HCP Cluster Status: my-cluster") {
+		t.Errorf("expected the whole status to be printed, got:\n%s", out.String())
+	}
+
+	// Unchanged status and errors of later polls print nothing
+	out.Reset()
+	status = healthyStatus()
+	previous, _ = w.poll(previous, testNow)
+	err = errors.New("connection reset")
+	previous, pollErr = w.poll(previous, testNow)
+	if pollErr != nil || out.Len() != 0 {
+		t.Errorf("expected nothing to be printed, got %v:\n%s", pollErr, out.String())
+	}
+	if !strings.Contains(errOut.String(), "connection reset") {
+		t.Errorf("expected the poll error to be reported, got: %s", errOut.String())
+	}
+
+	// Transitions are printed with the new health
+	status, err = healthyStatus(), nil
+	status.NodePools[0].Conditions[1].Status = "False"
+	if _, pollErr = w.poll(previous, testNow); pollErr != nil {
+		t.Fatalf("unexpected error: %v", pollErr)
+	}
+	expected := "[2026-03-01T12:00:00Z] my-cluster changed\n" +
+		"  Health: Healthy -> Degraded\n" +
+		"  NodePool workers condition AllNodesHealthy: True -> False\n" +
+		"Health: Degraded\n" +
+		"  - NodePool workers condition AllNodesHealthy is False\n\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestFleetStatus(t *testing.T) {
+	getStatus := func(key string) (*HCPStatus, error) {
+		if key == "missing" {
+			return nil, errors.New("failed to find cluster")
+		}
+		s := healthyStatus()
+		s.ClusterID = key
+		return s, nil
+	}
+
+	rows := fleetStatus([]string{"cluster-1", "missing"}, getStatus, HealthThresholds{}, testNow)
+	var out bytes.Buffer
+	opts := output.Options{Format: output.CSV}
+	if err := opts.Print(&out, fleetTable(rows)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "CLUSTER,NAME,STATE,VERSION,HEALTH,REASONS,ID\n" +
+		"cluster-1,my-cluster,ready,4.21.0,Healthy,,cluster-1\n" +
+		"missing,,,,Unknown,failed to find cluster,\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
@@ -4,58 +4,58 @@ import "time"
 
 // HCPStatus holds the parsed status of an HCP cluster from the live endpoint.
 type HCPStatus struct {
-	ClusterID               string
-	ClusterName             string
-	ClusterState            string
-	ManagementCluster       string
-	Version                 VersionInfo
-	APIServerCertificate    *CertificateStatus
-	IngressCertificate      *CertificateStatus
-	ManifestWorks           []ManifestWorkSync
-	HostedClusterConditions []Condition
-	NodePools               []NodePoolStatus
+	ClusterID               string             `json:"cluster_id"`
+	ClusterName             string             `json:"cluster_name"`
+	ClusterState            string             `json:"cluster_state"`
+	ManagementCluster       string             `json:"management_cluster"`
+	Version                 VersionInfo        `json:"version"`
+	APIServerCertificate    *CertificateStatus `json:"api_server_certificate,omitempty"`
+	IngressCertificate      *CertificateStatus `json:"ingress_certificate,omitempty"`
+	ManifestWorks           []ManifestWorkSync `json:"manifest_works,omitempty"`
+	HostedClusterConditions []Condition        `json:"hosted_cluster_conditions,omitempty"`
+	NodePools               []NodePoolStatus   `json:"node_pools,omitempty"`
 }
 
 // ManifestWorkSync represents the sync status of a single ManifestWork.
 type ManifestWorkSync struct {
-	Name         string
-	Applied      bool
-	Available    bool
-	LastSyncTime time.Time
+	Name         string    `json:"name"`
+	Applied      bool      `json:"applied"`
+	Available    bool      `json:"available"`
+	LastSyncTime time.Time `json:"last_sync_time"`
 }
 
 // VersionInfo holds cluster version details.
 type VersionInfo struct {
-	Current          string
-	Desired          string
-	Status           string
-	Image            string
-	AvailableUpdates []string
+	Current          string   `json:"current"`
+	Desired          string   `json:"desired"`
+	Status           string   `json:"status"`
+	Image            string   `json:"image"`
+	AvailableUpdates []string `json:"available_updates,omitempty"`
 }
 
 // CertificateStatus holds the certificate details.
 type CertificateStatus struct {
-	Ready       *bool // nil = unknown, true/false = known status
-	NotAfter    time.Time
-	RenewalTime time.Time
-	DNSNames    []string
+	Ready       *bool     `json:"ready"` // nil = unknown, true/false = known status
+	NotAfter    time.Time `json:"not_after"`
+	RenewalTime time.Time `json:"renewal_time"`
+	DNSNames    []string  `json:"dns_names,omitempty"`
 }
 
 // Condition represents a single condition from a HostedCluster or NodePool.
 type Condition struct {
-	Type               string
-	Status             string
-	Reason             string
-	Message            string
-	LastTransitionTime string
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+	LastTransitionTime string `json:"last_transition_time"`
 }
 
 // NodePoolStatus holds the status of a single NodePool.
 type NodePoolStatus struct {
-	Name       string
-	Replicas   int
-	Version    string
-	Conditions []Condition
+	Name       string      `json:"name"`
+	Replicas   int         `json:"replicas"`
+	Version    string      `json:"version"`
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // mainMWResult holds the parsed output from the main ManifestWork.
@@ -78,3 +78,9 @@ type FieldValue struct {
 	String  string `json:"string"`
 	Integer int    `json:"integer"`
 }
+
+// Report is the status of a cluster with its health, as printed by the status command.
+type Report struct {
+	*HCPStatus
+	Health Health `json:"health"`
+}
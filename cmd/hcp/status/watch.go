@@ -0,0 +1,82 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/openshift/osdctl/pkg/output"
+)
+
+// statusWatcher polls the status of a cluster and prints its transitions
+type statusWatcher struct {
+	clusterKey string
+	interval   time.Duration
+	thresholds HealthThresholds
+	output     output.Options
+	getStatus  statusFunc
+	out        io.Writer
+	errOut     io.Writer
+}
+
+// Run prints the status of the cluster, then the transitions of every poll which changed it,
+// until the context is cancelled. Structured formats print the whole status when it changes.
+func (w *statusWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	var previous *Report
+	for {
+		report, err := w.poll(previous, time.Now())
+		if err != nil {
+			return err
+		}
+		previous = report
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the status of the cluster and prints what changed since the previous poll. Only
+// the first poll fails on errors, later ones are reported and retried on the next poll.
+func (w *statusWatcher) poll(previous *Report, now time.Time) (*Report, error) {
+	status, err := w.getStatus(w.clusterKey)
+	if err != nil {
+		if previous == nil {
+			return nil, err
+		}
+		fmt.Fprintf(w.errOut, "[%s] failed to poll the status: %v\n", now.Format(time.RFC3339), err)
+		return previous, nil
+	}
+
+	report := newStatusReport(status, w.thresholds, now)
+	if previous == nil {
+		return report, w.output.Print(w.out, newStatusResult(report))
+	}
+
+	transitions := statusTransitions(previous, report)
+	if len(transitions) == 0 {
+		return report, nil
+	}
+	if w.output.IsStructured() {
+		return report, w.output.Print(w.out, newStatusResult(report))
+	}
+
+	highlight := color.New(color.FgYellow, color.Bold).SprintFunc()
+	fmt.Fprintf(w.out, "[%s] %s changed\n", now.Format(time.RFC3339), report.ClusterName)
+	for _, transition := range transitions {
+		fmt.Fprintf(w.out, "  %s\n", highlight(transition))
+	}
+	if report.Health.Verdict != previous.Health.Verdict || !slices.Equal(report.Health.Reasons, previous.Health.Reasons) {
+		printHealth(w.out, report.Health)
+	}
+	fmt.Fprintln(w.out)
+	return report, nil
+}
@@ -2,10 +2,7 @@ package hive
 
 import (
 	"context"
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
-	"os"
 	"regexp"
 	"sort"
 	"strconv"
@@ -14,9 +11,8 @@ import (
 
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	hiveapiv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
-	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/output"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -42,8 +38,7 @@ type clusterSyncFailuresOptions struct {
 	includeLimitedSupport  bool
 	includeHibernating     bool
 	includeFailingSyncSets bool
-	noHeaders              bool
-	output                 string
+	output                 output.Options
 	sortField              string
 	sortOrder              string
 
@@ -58,7 +53,7 @@ const (
   This command by default will list ClusterSyncs that are in a failure state
   for clusters that are not in limited support or hibernating.
 
-  Error messages are include in all output format except the text format.
+  Error messages are included in all output formats except the table format.
 `
 	clusterSyncFailuresExample = `
   # List clustersync failures using the short version of the command
@@ -68,6 +63,12 @@ const (
   # by timestamp in a descending order
   $ osdctl hive csf --syncsets=false --output=yaml --sort-by=timestamp --order=desc
 
+  # Show all columns, including the error messages
+  $ osdctl hive csf -o wide
+
+  # Print the names of the failing clusters
+  $ osdctl hive csf -o jsonpath='{.[*].Name}'
+
   # Include limited support and hibernating clusters
   $ osdctl hive csf --limited-support -hibernating
 
@@ -98,8 +99,8 @@ func NewCmdClusterSyncFailures(streams genericclioptions.IOStreams, client clien
 	clusterSyncCmd.Flags().BoolVarP(&opts.includeLimitedSupport, "limited-support", "l", false, "Include clusters in limited support.")
 	clusterSyncCmd.Flags().BoolVarP(&opts.includeHibernating, "hibernating", "i", false, "Include hibernating clusters.")
 	clusterSyncCmd.Flags().BoolVarP(&opts.includeFailingSyncSets, "syncsets", "", true, "Include failing syncsets.")
-	clusterSyncCmd.Flags().BoolVar(&opts.noHeaders, "no-headers", false, "Don't print headers when output format is set to text.")
-	clusterSyncCmd.Flags().StringVarP(&opts.output, "output", "o", "text", "Set the output format. Options: yaml, json, csv, text.")
+	opts.output.AddFormatFlag(clusterSyncCmd)
+	opts.output.AddNoHeadersFlag(clusterSyncCmd)
 	clusterSyncCmd.Flags().StringVar(&opts.sortField, "sort-by", "timestamp", "Sort the output by a specified field. Options: name, timestamp, failingsyncsets.")
 	clusterSyncCmd.Flags().StringVar(&opts.sortOrder, "order", "asc", "Set the sorting order. Options: asc, desc.")
 	clusterSyncCmd.Flags().StringVarP(&opts.clusterID, "cluster-id", "C", "", "Internal ID to list failing syncsets and relative errors for a specific cluster.")
@@ -116,8 +117,8 @@ func (o *clusterSyncFailuresOptions) complete(cmd *cobra.Command, args []string)
 		return cmdutil.UsageErrorf(cmd, "sort order must be 'asc' or 'desc'")
 	}
 
-	if o.output != "yaml" && o.output != "json" && o.output != "csv" && o.output != "text" {
-		return cmdutil.UsageErrorf(cmd, "invalid output field")
+	if err := o.output.Validate(); err != nil {
+		return cmdutil.UsageErrorf(cmd, "%v", err)
 	}
 
 	if _, err := config.GetConfig(); err != nil {
@@ -141,26 +142,7 @@ func (o *clusterSyncFailuresOptions) run() error {
 		return err
 	}
 
-	switch o.output {
-	case "json":
-		if err = o.printJson(csList); err != nil {
-			return err
-		}
-	case "yaml":
-		if err = o.printYaml(csList); err != nil {
-			return err
-		}
-	case "csv":
-		if err = o.printCsv(csList); err != nil {
-			return err
-		}
-	default:
-		if err = o.printText(csList); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return o.output.Print(o.IOStreams.Out, o.result(csList))
 }
 
 // printFailingCluster print sync failures relative to a specified cluster
@@ -346,8 +328,9 @@ func (o *clusterSyncFailuresOptions) sortBy(failingClusterSyncList []failingClus
 	return nil
 }
 
-// printJson prints the ClusterSync failures list in json format
-func (o *clusterSyncFailuresOptions) printJson(failingClusterSyncList []failingClusterSync) error {
+// result returns the ClusterSync failures to print. Limited support and hibernating clusters
+// are filtered out unless included, in which case the table also shows these columns.
+func (o *clusterSyncFailuresOptions) result(failingClusterSyncList []failingClusterSync) *output.Result {
 	filteredFailingClusterSyncList := []failingClusterSync{}
 	for _, cs := range failingClusterSyncList {
 		if !o.includeLimitedSupport && cs.LimitedSupport {
@@ -359,116 +342,24 @@ func (o *clusterSyncFailuresOptions) printJson(failingClusterSyncList []failingC
 		filteredFailingClusterSyncList = append(filteredFailingClusterSyncList, cs)
 	}
 
-	if err := json.NewEncoder(os.Stdout).Encode(filteredFailingClusterSyncList); err != nil {
-		return err
-	}
-	return nil
-}
-
-// printYaml prints the ClusterSync failures list in yaml format
-func (o *clusterSyncFailuresOptions) printYaml(failingClusterSyncList []failingClusterSync) error {
-	filteredFailingClusterSyncList := []failingClusterSync{}
-	for _, cs := range failingClusterSyncList {
-		if !o.includeLimitedSupport && cs.LimitedSupport {
-			continue
-		}
-		if !o.includeHibernating && cs.Hibernating {
-			continue
-		}
-		filteredFailingClusterSyncList = append(filteredFailingClusterSyncList, cs)
-	}
-
-	if err := yaml.NewEncoder(os.Stdout).Encode(failingClusterSyncList); err != nil {
-		return err
-	}
-	return nil
-}
-
-// printCsv prints the ClusterSync failures list in csv format
-func (o *clusterSyncFailuresOptions) printCsv(failingClusterSyncList []failingClusterSync) error {
-	writer := csv.NewWriter(os.Stdout)
-
-	headers := []string{"NAME", "NAMESPACE", "TIMESTAMP", "LIMITED SUPPORT", "HIBERNATING", "FAILING SYNCSETS", "ERROR MESSAGE"}
-	if err := writer.Write(headers); err != nil {
-		return err
-	}
-
-	for _, f := range failingClusterSyncList {
-		if !o.includeLimitedSupport && f.LimitedSupport {
-			continue
-		}
-
-		if !o.includeHibernating && f.Hibernating {
-			continue
+	columns := []output.Column{
+		{Name: "NAMESPACE"},
+		{Name: "NAME"},
+		{Name: "TIMESTAMP"},
+		{Name: "LIMITED SUPPORT", Wide: !o.includeLimitedSupport},
+		{Name: "HIBERNATING", Wide: !o.includeHibernating},
+		{Name: "FAILING SYNCSETS", Wide: !o.includeFailingSyncSets},
+		{Name: "ERROR MESSAGE", Wide: true},
+	}
+	return output.NewTable(filteredFailingClusterSyncList, columns, func(cs failingClusterSync) []string {
+		return []string{
+			cs.Namespace,
+			cs.Name,
+			cs.Timestamp,
+			strconv.FormatBool(cs.LimitedSupport),
+			strconv.FormatBool(cs.Hibernating),
+			cs.FailingSyncSets,
+			cs.ErrorMessage,
 		}
-
-		row := []string{
-			f.Name,
-			f.Namespace,
-			f.Timestamp,
-			strconv.FormatBool(f.LimitedSupport),
-			strconv.FormatBool(f.Hibernating),
-			f.FailingSyncSets,
-			f.ErrorMessage,
-		}
-		if err := writer.Write(row); err != nil {
-			return err
-		}
-	}
-
-	writer.Flush()
-
-	return nil
-}
-
-// printText prints the ClusterSync failures list in text format
-func (o *clusterSyncFailuresOptions) printText(failingClusterSyncList []failingClusterSync) error {
-	p := printer.NewTablePrinter(o.IOStreams.Out, 20, 1, 3, ' ')
-
-	if !o.noHeaders {
-		headers := []string{"NAMESPACE", "NAME", "TIMESTAMP"}
-		if o.includeLimitedSupport {
-			headers = append(headers, "LS")
-		}
-		if o.includeHibernating {
-			headers = append(headers, "HIBERNATING")
-		}
-
-		if o.includeFailingSyncSets {
-			headers = append(headers, "SYNCSETS")
-		}
-		p.AddRow(headers)
-	}
-
-	for _, cs := range failingClusterSyncList {
-		if !o.includeLimitedSupport && cs.LimitedSupport {
-			continue
-		}
-
-		if !o.includeHibernating && cs.Hibernating {
-			continue
-		}
-
-		row := []string{cs.Namespace, cs.Name, cs.Timestamp}
-
-		if o.includeLimitedSupport {
-			row = append(row, strconv.FormatBool(cs.LimitedSupport))
-		}
-
-		if o.includeHibernating {
-			row = append(row, strconv.FormatBool(cs.Hibernating))
-		}
-
-		if o.includeFailingSyncSets {
-			row = append(row, cs.FailingSyncSets)
-		}
-
-		p.AddRow(row)
-	}
-
-	if err := p.Flush(); err != nil {
-		return err
-	}
-
-	return nil
+	})
 }
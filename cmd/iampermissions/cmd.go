@@ -11,6 +11,9 @@ const (
 	// cloudFlagName is declared as const so we can keep naming of this flag
 	// consistent between different subcommands
 	cloudFlagName = "cloud"
+
+	releaseMirrorFlagName = "release-mirror"
+	noCacheFlagName       = "no-cache"
 )
 
 func NewCmdIamPermissions() *cobra.Command {
@@ -27,10 +30,21 @@ func NewCmdIamPermissions() *cobra.Command {
 		},
 	}
 	iamPermissionsCommand.PersistentFlags().VarP(&cloudValue, "cloud", "c", "cloud for which the policies should be retrieved. supported values: [aws, sts, gcp, wif]")
+	iamPermissionsCommand.PersistentFlags().String(releaseMirrorFlagName, "", "repository mirroring the OCP release images, e.g. mirror.example.com/ocp4/openshift-release-dev")
+	iamPermissionsCommand.PersistentFlags().Bool(noCacheFlagName, false, "extract the CredentialsRequests again instead of using the ones cached for the release")
 
 	iamPermissionsCommand.AddCommand(newCmdGet())
 	iamPermissionsCommand.AddCommand(newCmdDiff())
 	iamPermissionsCommand.AddCommand(newCmdSave())
+	iamPermissionsCommand.AddCommand(newCmdVerify())
 
 	return iamPermissionsCommand
 }
+
+// downloadFunc returns the function extracting the CredentialsRequests of a release, configured
+// by the persistent flags of the command
+func downloadFunc(cmd *cobra.Command) func(string, policies.CloudSpec) (string, error) {
+	mirror := cmd.Flag(releaseMirrorFlagName).Value.String()
+	noCache := cmd.Flag(noCacheFlagName).Value.String() == "true"
+	return policies.NewCredentialsRequestSource(mirror, !noCache).Download
+}
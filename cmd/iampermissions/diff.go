@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
+	"strings"
 
+	cco "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	"github.com/openshift/osdctl/pkg/output"
 	"github.com/openshift/osdctl/pkg/policies"
 	"github.com/spf13/cobra"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -15,8 +17,10 @@ type diffOptions struct {
 	BaseVersion   string
 	TargetVersion string
 	Cloud         policies.CloudSpec
+	FailOnAdded   bool
+	output        output.Options
 	downloadFunc  func(string, policies.CloudSpec) (string, error)
-	execFunc      func(string, ...string) *exec.Cmd
+	parseFunc     func(string) ([]*cco.CredentialsRequest, error)
 	outputWriter  io.Writer
 }
 
@@ -28,25 +32,45 @@ const (
 func newCmdDiff() *cobra.Command {
 	ops := &diffOptions{
 		downloadFunc: policies.DownloadCredentialRequests,
-		execFunc:     exec.Command,
+		parseFunc:    policies.ParseCredentialsRequestsInDir,
 		outputWriter: os.Stdout,
 	}
 
 	policyCmd := &cobra.Command{
 		Use:   "diff",
 		Short: "Diff IAM permissions for cluster operators between two versions",
+		Long: `Diff IAM permissions for cluster operators between two versions.
+
+Reports, per CredentialsRequest, the AWS actions added or removed and the
+actions whose effect, resources or conditions changed, or the GCP roles and
+permissions added or removed. The table, csv and markdown formats print one row
+per change, the json and yaml formats one report per CredentialsRequest.
+
+With --fail-on-added, the command fails when the target version grants new
+actions, roles or permissions, allows an action on resources or under conditions
+the base version didn't, or removes a Deny. Narrowing the resources of an action,
+adding a condition or a Deny doesn't fail.`,
 		Example: `  # Diff IAM permissions between two OCP versions
-  osdctl iampermissions diff --base-version 4.14.0 --target-version 4.15.0`,
+  osdctl iampermissions diff --base-version 4.14.0 --target-version 4.15.0
+
+  # Diff IAM permissions as JSON, pulling the release images from a mirror
+  osdctl iampermissions diff -b 4.14.0 -t 4.15.0 --release-mirror mirror.example.com/ocp4/openshift-release-dev -o json
+
+  # Write the GCP permission changes as markdown, failing when permissions were added
+  osdctl iampermissions diff -c wif -b 4.14.0 -t 4.15.0 -o markdown --fail-on-added`,
 		Args:              cobra.ExactArgs(0),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			ops.Cloud = *cmd.Flag(cloudFlagName).Value.(*policies.CloudSpec)
+			ops.downloadFunc = downloadFunc(cmd)
 			cmdutil.CheckErr(ops.run())
 		},
 	}
 
-	policyCmd.Flags().StringVarP(&ops.BaseVersion, baseVersionFlagName, "b", "", "")
-	policyCmd.Flags().StringVarP(&ops.TargetVersion, targetVersionFlagName, "t", "", "")
+	policyCmd.Flags().StringVarP(&ops.BaseVersion, baseVersionFlagName, "b", "", "OCP version, release image pull spec, or path of a release payload on disk to compare from")
+	policyCmd.Flags().StringVarP(&ops.TargetVersion, targetVersionFlagName, "t", "", "OCP version, release image pull spec, or path of a release payload on disk to compare to")
+	policyCmd.Flags().BoolVar(&ops.FailOnAdded, "fail-on-added", false, "Exit with an error when the target version grants new permissions, e.g. to gate releases on reviewed permissions")
+	ops.output.AddFlags(policyCmd)
 	_ = policyCmd.MarkFlagRequired(baseVersionFlagName)
 	_ = policyCmd.MarkFlagRequired(targetVersionFlagName)
 
@@ -54,21 +78,96 @@ func newCmdDiff() *cobra.Command {
 }
 
 func (o *diffOptions) run() error {
+	if err := o.output.Validate(); err != nil {
+		return err
+	}
+
 	fmt.Fprintf(os.Stderr, "Downloading Credential Requests for %s\n", o.BaseVersion)
-	baseDir, err := o.downloadFunc(o.BaseVersion, o.Cloud)
+	base, err := o.credentialsRequests(o.BaseVersion)
 	if err != nil {
 		return err
 	}
 
 	fmt.Fprintf(os.Stderr, "Downloading Credential Requests for %s\n", o.TargetVersion)
-	targetDir, err := o.downloadFunc(o.TargetVersion, o.Cloud)
+	target, err := o.credentialsRequests(o.TargetVersion)
 	if err != nil {
 		return err
 	}
 
-	cmd := o.execFunc("diff", baseDir, targetDir)
-	output, _ := cmd.CombinedOutput() // #nosec G204
-	fmt.Fprintln(o.outputWriter, string(output))
+	diffs, err := policies.DiffCredentialsRequests(base, target)
+	if err != nil {
+		return err
+	}
+	if diffs == nil {
+		diffs = []policies.CredentialsRequestDiff{}
+	}
 
+	if len(diffs) == 0 && !o.output.IsStructured() {
+		fmt.Fprintf(o.outputWriter, "No %s permission changes between %s and %s\n", o.Cloud.String(), o.BaseVersion, o.TargetVersion)
+	} else if err := o.output.Print(o.outputWriter, diffResult(diffs)); err != nil {
+		return err
+	}
+
+	if o.FailOnAdded {
+		var grown []string
+		for _, diff := range diffs {
+			if diff.AddsPermissions() {
+				grown = append(grown, diff.Name)
+			}
+		}
+		if len(grown) > 0 {
+			return fmt.Errorf("%s grants new permissions to %d CredentialsRequests: %s", o.TargetVersion, len(grown), strings.Join(grown, ", "))
+		}
+	}
 	return nil
 }
+
+func (o *diffOptions) credentialsRequests(version string) ([]*cco.CredentialsRequest, error) {
+	dir, err := o.downloadFunc(version, o.Cloud)
+	if err != nil {
+		return nil, err
+	}
+	return o.parseFunc(dir)
+}
+
+// diffResult prints the diffs as one row per permission change, and as a report per
+// CredentialsRequest in the structured formats
+func diffResult(diffs []policies.CredentialsRequestDiff) *output.Result {
+	columns := []output.Column{
+		{Name: "CREDENTIALS REQUEST"},
+		{Name: "CHANGE"},
+		{Name: "KIND"},
+		{Name: "PERMISSION"},
+		{Name: "DETAILS"},
+	}
+
+	var rows [][]string
+	for _, diff := range diffs {
+		row := func(kind string, permission string, details string) {
+			rows = append(rows, []string{diff.Name, diff.Change, kind, permission, details})
+		}
+		for _, action := range diff.AddedActions {
+			row("action added", action, "")
+		}
+		for _, action := range diff.RemovedActions {
+			row("action removed", action, "")
+		}
+		for _, change := range diff.ChangedActions {
+			row("action changed", change.Action, strings.Join(change.Before, ", ")+" -> "+strings.Join(change.After, ", "))
+		}
+		for _, role := range diff.AddedRoles {
+			row("role added", role, "")
+		}
+		for _, role := range diff.RemovedRoles {
+			row("role removed", role, "")
+		}
+		for _, permission := range diff.AddedPermissions {
+			row("permission added", permission, "")
+		}
+		for _, permission := range diff.RemovedPermissions {
+			row("permission removed", permission, "")
+		}
+	}
+
+	return &output.Result{Object: diffs, Columns: columns, Rows: rows}
+}
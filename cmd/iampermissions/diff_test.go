@@ -2,33 +2,143 @@ package iampermissions
 
 import (
 	"bytes"
-	"os/exec"
+	"errors"
 	"testing"
 
+	cco "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	"github.com/openshift/osdctl/pkg/output"
 	"github.com/openshift/osdctl/pkg/policies"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
-func TestRunSuccess(t *testing.T) {
-	mockDownload := func(version string, cloud policies.CloudSpec) (string, error) {
-		return "/mock/path/" + version, nil
+func awsCredentialsRequest(name string, resource string, actions ...string) *cco.CredentialsRequest {
+	spec := `{"apiVersion":"cloudcredential.openshift.io/v1","kind":"AWSProviderSpec","statementEntries":[{"effect":"Allow","resource":"` + resource + `","action":[`
+	for i, action := range actions {
+		if i > 0 {
+			spec += ","
+		}
+		spec += `"` + action + `"`
 	}
-
-	mockExec := func(command string, args ...string) *exec.Cmd {
-		return exec.Command("echo", "mock diff output")
+	spec += `]}]}`
+	return &cco.CredentialsRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       cco.CredentialsRequestSpec{ProviderSpec: &runtime.RawExtension{Raw: []byte(spec)}},
 	}
+}
 
-	var outputBuffer bytes.Buffer
+func newTestDiffOptions(format string, out *bytes.Buffer) *diffOptions {
+	credReqs := map[string][]*cco.CredentialsRequest{
+		"/mock/path/v1": {
+			awsCredentialsRequest("openshift-machine-api-aws", "*", "ec2:CreateTags", "ec2:DescribeImages"),
+			awsCredentialsRequest("openshift-image-registry", "*", "s3:CreateBucket"),
+			awsCredentialsRequest("openshift-ingress", "*", "elasticloadbalancing:DescribeLoadBalancers"),
+		},
+		"/mock/path/v2": {
+			awsCredentialsRequest("openshift-machine-api-aws", "*", "ec2:CreateTags", "ec2:DescribeInstances"),
+			awsCredentialsRequest("openshift-cloud-network-config-controller-aws", "*", "ec2:AssignPrivateIpAddresses"),
+			awsCredentialsRequest("openshift-ingress", "arn:aws:elasticloadbalancing:*:*:loadbalancer/*", "elasticloadbalancing:DescribeLoadBalancers"),
+		},
+	}
 
-	o := &diffOptions{
+	return &diffOptions{
 		BaseVersion:   "v1",
 		TargetVersion: "v2",
 		Cloud:         policies.AWS,
-		downloadFunc:  mockDownload,
-		execFunc:      mockExec,
-		outputWriter:  &outputBuffer,
+		output:        output.Options{Format: format},
+		downloadFunc: func(version string, cloud policies.CloudSpec) (string, error) {
+			return "/mock/path/" + version, nil
+		},
+		parseFunc: func(dir string) ([]*cco.CredentialsRequest, error) {
+			return credReqs[dir], nil
+		},
+		outputWriter: out,
 	}
+}
+
+func TestRunSuccess(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	o := newTestDiffOptions(output.CSV, &outputBuffer)
+
+	err := o.run()
+	assert.NoError(t, err)
+	assert.Equal(t, `CREDENTIALS REQUEST,CHANGE,KIND,PERMISSION,DETAILS
+openshift-cloud-network-config-controller-aws,added,action added,ec2:AssignPrivateIpAddresses,
+openshift-image-registry,removed,action removed,s3:CreateBucket,
+openshift-ingress,changed,action changed,elasticloadbalancing:DescribeLoadBalancers,Allow * -> Allow arn:aws:elasticloadbalancing:*:*:loadbalancer/*
+openshift-machine-api-aws,changed,action added,ec2:DescribeInstances,
+openshift-machine-api-aws,changed,action removed,ec2:DescribeImages,
+`, outputBuffer.String())
+}
+
+func TestRunMarkdown(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	o := newTestDiffOptions(output.Markdown, &outputBuffer)
+	o.output.SortBy = "kind"
+
+	err := o.run()
+	assert.NoError(t, err)
+	assert.Equal(t, `| CREDENTIALS REQUEST | CHANGE | KIND | PERMISSION | DETAILS |
+| --- | --- | --- | --- | --- |
+| openshift-cloud-network-config-controller-aws | added | action added | ec2:AssignPrivateIpAddresses |  |
+| openshift-machine-api-aws | changed | action added | ec2:DescribeInstances |  |
+| openshift-ingress | changed | action changed | elasticloadbalancing:DescribeLoadBalancers | Allow * -> Allow arn:aws:elasticloadbalancing:*:*:loadbalancer/* |
+| openshift-image-registry | removed | action removed | s3:CreateBucket |  |
+| openshift-machine-api-aws | changed | action removed | ec2:DescribeImages |  |
+`, outputBuffer.String())
+}
+
+func TestRunJSON(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	o := newTestDiffOptions(output.JSON, &outputBuffer)
+
+	err := o.run()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"name": "openshift-cloud-network-config-controller-aws", "change": "added", "added_actions": ["ec2:AssignPrivateIpAddresses"]},
+		{"name": "openshift-image-registry", "change": "removed", "removed_actions": ["s3:CreateBucket"]},
+		{"name": "openshift-ingress", "change": "changed", "changed_actions": [
+			{"action": "elasticloadbalancing:DescribeLoadBalancers", "before": ["Allow *"], "after": ["Allow arn:aws:elasticloadbalancing:*:*:loadbalancer/*"]}
+		]},
+		{"name": "openshift-machine-api-aws", "change": "changed", "added_actions": ["ec2:DescribeInstances"], "removed_actions": ["ec2:DescribeImages"]}
+	]`, outputBuffer.String())
+}
+
+func TestRunFailOnAdded(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	o := newTestDiffOptions(output.JSON, &outputBuffer)
+	o.FailOnAdded = true
+
+	err := o.run()
+	// Narrowing the resources of openshift-ingress grants nothing new
+	assert.EqualError(t, err, "v2 grants new permissions to 2 CredentialsRequests: openshift-cloud-network-config-controller-aws, openshift-machine-api-aws")
+	assert.Contains(t, outputBuffer.String(), "openshift-image-registry", "the report is printed before failing")
+
+	o.BaseVersion, o.TargetVersion = "v2", "v1"
+	err = o.run()
+	assert.EqualError(t, err, "v1 grants new permissions to 3 CredentialsRequests: openshift-image-registry, openshift-ingress, openshift-machine-api-aws")
+
+	o.TargetVersion = "v2"
+	assert.NoError(t, o.run())
+}
+
+func TestRunNoChanges(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	o := newTestDiffOptions(output.Table, &outputBuffer)
+	o.TargetVersion = "v1"
+
 	err := o.run()
 	assert.NoError(t, err)
-	assert.Contains(t, outputBuffer.String(), "mock diff output")
+	assert.Equal(t, "No aws permission changes between v1 and v1\n", outputBuffer.String())
+}
+
+func TestRunDownloadFailure(t *testing.T) {
+	o := newTestDiffOptions(output.Table, &bytes.Buffer{})
+	o.downloadFunc = func(version string, cloud policies.CloudSpec) (string, error) {
+		return "", errors.New("download failed")
+	}
+
+	err := o.run()
+	assert.EqualError(t, err, "download failed")
 }
@@ -29,16 +29,20 @@ func newCmdGet() *cobra.Command {
 		Use:   "get",
 		Short: "Get OCP CredentialsRequests",
 		Example: `  # Get IAM permissions for a specific OCP version
-  osdctl iampermissions get --release-version 4.15.0`,
+  osdctl iampermissions get --release-version 4.15.0
+
+  # Get IAM permissions from a release payload extracted on disk
+  osdctl iampermissions get --release-version ./release-4.15.0`,
 		Args:              cobra.ExactArgs(0),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			ops.Cloud = *cmd.Flag(cloudFlagName).Value.(*policies.CloudSpec)
+			ops.downloadFunc = downloadFunc(cmd)
 			cmdutil.CheckErr(ops.run())
 		},
 	}
 
-	policyCmd.Flags().StringVarP(&ops.ReleaseVersion, "release-version", "r", "", "")
+	policyCmd.Flags().StringVarP(&ops.ReleaseVersion, "release-version", "r", "", "OCP version, release image pull spec, or path of a release payload on disk")
 	_ = policyCmd.MarkFlagRequired("release-version")
 
 	return policyCmd
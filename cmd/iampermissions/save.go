@@ -52,12 +52,13 @@ func newCmdSave() *cobra.Command {
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, _ []string) {
 			op.Cloud = *cmd.Flag(cloudFlagName).Value.(*policies.CloudSpec)
+			op.DownloadCRs = downloadFunc(cmd)
 			cmdutil.CheckErr(op.run())
 		},
 	}
 
 	saveCmd.Flags().StringVarP(&op.OutFolder, "dir", "d", "", "Folder where the policy files should be written")
-	saveCmd.Flags().StringVarP(&op.ReleaseVersion, "release-version", "r", "", "ocp version for which the policies should be downloaded")
+	saveCmd.Flags().StringVarP(&op.ReleaseVersion, "release-version", "r", "", "OCP version, release image pull spec, or path of a release payload on disk for which the policies should be saved")
 	saveCmd.Flags().BoolVarP(&op.Force, "force", "f", false, "Overwrite existing files")
 
 	_ = saveCmd.MarkFlagRequired("dir")
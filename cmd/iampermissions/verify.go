@@ -0,0 +1,444 @@
+package iampermissions
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	cco "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	"github.com/openshift/osdctl/pkg/controller"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/output"
+	"github.com/openshift/osdctl/pkg/policies"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// Verification statuses of the CredentialsRequest of a release
+const (
+	verifyStatusOK                 = "ok"
+	verifyStatusMissingPermissions = "missing permissions"
+	verifyStatusMissingIdentity    = "missing identity"
+	verifyStatusNotChecked         = "not checked"
+)
+
+const (
+	// featureSetAnnotation lists the feature sets a CredentialsRequest is installed with, all of
+	// them when it is absent
+	featureSetAnnotation = "release.openshift.io/feature-set"
+	// defaultFeatureSet is the feature set of managed clusters
+	defaultFeatureSet = "Default"
+	// deleteAnnotation marks the CredentialsRequests removed by a release
+	deleteAnnotation = "release.openshift.io/delete"
+)
+
+type verifyOptions struct {
+	ClusterID     string
+	TargetVersion string
+	AWSProfile    string
+	output        output.Options
+
+	// Injected for testability
+	downloadFunc func(string, policies.CloudSpec) (string, error)
+	parseFunc    func(string) ([]*cco.CredentialsRequest, error)
+	outputWriter io.Writer
+}
+
+// operatorIdentity is the cloud identity of an operator of a cluster, the AWS role or GCP
+// service account used with the secret of its CredentialsRequest
+type operatorIdentity struct {
+	Namespace string
+	Secret    string
+	// ID is the ARN of the AWS role, or the ID of the GCP service account
+	ID string
+	// Roles are the predefined roles, and Permissions the permissions of the custom roles,
+	// granted to the GCP service account by its WIF config
+	Roles       []string
+	Permissions []string
+}
+
+// operatorVerification is the result of the verification of the identity of an operator against
+// its CredentialsRequest in a release
+type operatorVerification struct {
+	Version            string   `json:"version"`
+	CredentialsRequest string   `json:"credentials_request"`
+	Namespace          string   `json:"namespace"`
+	Secret             string   `json:"secret"`
+	Identity           string   `json:"identity,omitempty"`
+	Status             string   `json:"status"`
+	MissingActions     []string `json:"missing_actions,omitempty"`
+	MissingRoles       []string `json:"missing_roles,omitempty"`
+	MissingPermissions []string `json:"missing_permissions,omitempty"`
+}
+
+// failed reports whether the operator would lack permissions running the release
+func (v operatorVerification) failed() bool {
+	return v.Status == verifyStatusMissingPermissions || v.Status == verifyStatusMissingIdentity
+}
+
+// permissionCheck fills the permissions of a CredentialsRequest its operator's identity lacks
+type permissionCheck func(identity operatorIdentity, credReq *cco.CredentialsRequest, result *operatorVerification) error
+
+func newCmdVerify() *cobra.Command {
+	ops := &verifyOptions{
+		downloadFunc: policies.DownloadCredentialRequests,
+		parseFunc:    policies.ParseCredentialsRequestsInDir,
+		outputWriter: os.Stdout,
+	}
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the operator roles of a cluster against the CredentialsRequests of its releases",
+		Long: `Verify the operator roles of a cluster against the CredentialsRequests of its releases.
+
+Compares the permissions of the STS operator roles of an AWS cluster, or of the WIF
+service accounts of a GCP cluster, with the CredentialsRequests of the current
+version of the cluster and of its target upgrade version: the --target-version,
+or else the version of its scheduled upgrade. The cloud is the one of the cluster.
+
+On AWS, the actions of every CredentialsRequest are simulated against the
+policies attached to its operator role with SimulatePrincipalPolicy. On GCP, the
+roles and permissions of every CredentialsRequest are compared with the roles
+granted to its service account by the WIF config of the cluster.
+
+A CredentialsRequest without an operator role or service account is only
+reported as missing one when it's new in the target version, otherwise it's not
+checked. The command fails when an operator lacks permissions for either version.`,
+		Example: `  # Verify the operator roles of a cluster for its current version and scheduled upgrade
+  osdctl iampermissions verify -C ${CLUSTER_ID}
+
+  # Verify the operator roles of a cluster before upgrading it to 4.16.10
+  osdctl iampermissions verify -C ${CLUSTER_ID} --target-version 4.16.10 -o json`,
+		Args:              cobra.ExactArgs(0),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.downloadFunc = downloadFunc(cmd)
+			cmdutil.CheckErr(ops.run())
+		},
+	}
+
+	verifyCmd.Flags().StringVarP(&ops.ClusterID, "cluster-id", "C", "", "Name, ID or external ID of the cluster")
+	verifyCmd.Flags().StringVarP(&ops.TargetVersion, targetVersionFlagName, "t", "", "OCP version, release image pull spec, or path of a release payload on disk the cluster will be upgraded to, defaults to the version of its scheduled upgrade")
+	verifyCmd.Flags().StringVarP(&ops.AWSProfile, "profile", "p", "", "AWS profile used to access the account of the cluster")
+	ops.output.AddFlags(verifyCmd)
+	_ = verifyCmd.MarkFlagRequired("cluster-id")
+
+	return verifyCmd
+}
+
+func (o *verifyOptions) run() error {
+	if err := o.output.Validate(); err != nil {
+		return err
+	}
+
+	conn, err := utils.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	cluster, err := utils.GetClusterAnyStatus(conn, o.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	versions := []string{cluster.Version().RawID()}
+	target := o.TargetVersion
+	if target == "" {
+		if target, err = scheduledUpgradeVersion(conn, cluster); err != nil {
+			return err
+		}
+	}
+	if target != "" && target != versions[0] {
+		versions = append(versions, target)
+	}
+
+	var cloud policies.CloudSpec
+	var identities []operatorIdentity
+	var check permissionCheck
+	switch {
+	case cluster.AWS().STS().Enabled():
+		cloud = policies.AWS
+		identities = stsOperatorRoles(cluster)
+		awsClient, err := osdCloud.GenerateAWSClientForCluster(o.AWSProfile, cluster.ID())
+		if err != nil {
+			return err
+		}
+		check = newRoleSimulator(func(roleARN string, actions []string) ([]controller.PermissionResult, error) {
+			return controller.SimulatePermissions(awsClient, roleARN, actions)
+		}).check
+	case cluster.GCP().Authentication().Id() != "":
+		cloud = policies.GCP
+		response, err := conn.ClustersMgmt().V1().GCP().WifConfigs().WifConfig(cluster.GCP().Authentication().Id()).Get().Send()
+		if err != nil {
+			return fmt.Errorf("failed to get the WIF config of cluster %s: %w", cluster.ID(), err)
+		}
+		identities = wifServiceAccounts(response.Body())
+		check = checkServiceAccount
+	default:
+		return fmt.Errorf("cluster %s uses neither STS nor WIF, its operators have no identity of their own", cluster.ID())
+	}
+
+	results, err := o.verify(cloud, versions, identities, check)
+	if err != nil {
+		return err
+	}
+	if err := o.output.Print(o.outputWriter, verifyResult(results)); err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, result := range results {
+		if result.failed() {
+			failed = append(failed, fmt.Sprintf("%s (%s)", result.CredentialsRequest, result.Version))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("the operators of cluster %s lack permissions for %d CredentialsRequests: %s", cluster.ID(), len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// verify checks the identities of the operators against the CredentialsRequests of each version,
+// the first version being the current one of the cluster
+func (o *verifyOptions) verify(cloud policies.CloudSpec, versions []string, identities []operatorIdentity, check permissionCheck) ([]operatorVerification, error) {
+	bySecret := map[string]operatorIdentity{}
+	for _, identity := range identities {
+		bySecret[identity.Namespace+"/"+identity.Secret] = identity
+	}
+
+	results := []operatorVerification{}
+	var current map[string]bool
+	for i, version := range versions {
+		fmt.Fprintf(os.Stderr, "Downloading Credential Requests for %s\n", version)
+		dir, err := o.downloadFunc(version, cloud)
+		if err != nil {
+			return nil, err
+		}
+		credReqs, err := o.parseFunc(dir)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(credReqs, func(i, j int) bool { return credReqs[i].Name < credReqs[j].Name })
+
+		names := map[string]bool{}
+		for _, credReq := range credReqs {
+			if !installedOnManagedClusters(credReq) {
+				continue
+			}
+			names[credReq.Name] = true
+			result := operatorVerification{
+				Version:            version,
+				CredentialsRequest: credReq.Name,
+				Namespace:          credReq.Spec.SecretRef.Namespace,
+				Secret:             credReq.Spec.SecretRef.Name,
+				Status:             verifyStatusNotChecked,
+			}
+
+			identity, ok := bySecret[result.Namespace+"/"+result.Secret]
+			switch {
+			case !ok && i > 0 && !current[credReq.Name]:
+				result.Status = verifyStatusMissingIdentity
+			case ok:
+				result.Identity = identity.ID
+				if err := check(identity, credReq, &result); err != nil {
+					return nil, fmt.Errorf("error checking the permissions of %s for CredentialsRequest '%s': %w", identity.ID, credReq.Name, err)
+				}
+				result.Status = verifyStatusOK
+				if len(result.MissingActions) > 0 || len(result.MissingRoles) > 0 || len(result.MissingPermissions) > 0 {
+					result.Status = verifyStatusMissingPermissions
+				}
+			}
+			results = append(results, result)
+		}
+		if i == 0 {
+			current = names
+		}
+	}
+	return results, nil
+}
+
+// installedOnManagedClusters leaves out the CredentialsRequests which aren't installed with the
+// Default feature set and the ones a release deletes, as 'oc adm release extract
+// --credentials-requests' does
+func installedOnManagedClusters(credReq *cco.CredentialsRequest) bool {
+	if featureSets, ok := credReq.Annotations[featureSetAnnotation]; ok {
+		installed := false
+		for _, featureSet := range strings.Split(featureSets, ",") {
+			if strings.TrimSpace(featureSet) == defaultFeatureSet {
+				installed = true
+			}
+		}
+		if !installed {
+			return false
+		}
+	}
+	return credReq.Annotations[deleteAnnotation] != "true"
+}
+
+// scheduledUpgradeVersion returns the version of the scheduled upgrade of a cluster, if any
+func scheduledUpgradeVersion(conn *sdk.Connection, cluster *cmv1.Cluster) (string, error) {
+	clusterClient := conn.ClustersMgmt().V1().Clusters().Cluster(cluster.ID())
+	if cluster.Hypershift().Enabled() {
+		response, err := clusterClient.ControlPlane().UpgradePolicies().List().Send()
+		if err != nil {
+			return "", fmt.Errorf("failed to list upgrade policies: %w", err)
+		}
+		for _, policy := range response.Items().Slice() {
+			if policy.Version() != "" {
+				return policy.Version(), nil
+			}
+		}
+		return "", nil
+	}
+
+	response, err := clusterClient.UpgradePolicies().List().Send()
+	if err != nil {
+		return "", fmt.Errorf("failed to list upgrade policies: %w", err)
+	}
+	for _, policy := range response.Items().Slice() {
+		if policy.Version() != "" {
+			return policy.Version(), nil
+		}
+	}
+	return "", nil
+}
+
+// stsOperatorRoles returns the operator roles of an STS cluster
+func stsOperatorRoles(cluster *cmv1.Cluster) []operatorIdentity {
+	var identities []operatorIdentity
+	for _, role := range cluster.AWS().STS().OperatorIAMRoles() {
+		identities = append(identities, operatorIdentity{Namespace: role.Namespace(), Secret: role.Name(), ID: role.RoleARN()})
+	}
+	return identities
+}
+
+// wifServiceAccounts returns the operator service accounts of a WIF config, with the roles and
+// permissions it grants them
+func wifServiceAccounts(wifConfig *cmv1.WifConfig) []operatorIdentity {
+	var identities []operatorIdentity
+	for _, sa := range wifConfig.Gcp().ServiceAccounts() {
+		secretRef := sa.CredentialRequest().SecretRef()
+		if secretRef.Name() == "" {
+			continue
+		}
+		identity := operatorIdentity{Namespace: secretRef.Namespace(), Secret: secretRef.Name(), ID: sa.ServiceAccountId()}
+		for _, role := range sa.Roles() {
+			if role.Predefined() {
+				identity.Roles = append(identity.Roles, policies.GCPRoleIDPrefix+strings.TrimPrefix(role.RoleId(), policies.GCPRoleIDPrefix))
+			} else {
+				identity.Permissions = append(identity.Permissions, role.Permissions()...)
+			}
+		}
+		identities = append(identities, identity)
+	}
+	return identities
+}
+
+// roleSimulator checks the actions of AWS CredentialsRequests with SimulatePrincipalPolicy
+type roleSimulator struct {
+	simulate func(roleARN string, actions []string) ([]controller.PermissionResult, error)
+	// allowed caches the simulated actions per role, the versions mostly requesting the same ones
+	allowed map[string]map[string]bool
+}
+
+func newRoleSimulator(simulate func(roleARN string, actions []string) ([]controller.PermissionResult, error)) *roleSimulator {
+	return &roleSimulator{simulate: simulate, allowed: map[string]map[string]bool{}}
+}
+
+func (s *roleSimulator) check(identity operatorIdentity, credReq *cco.CredentialsRequest, result *operatorVerification) error {
+	spec, err := policies.GetAWSProviderSpec(credReq)
+	if err != nil {
+		return err
+	}
+
+	var actions []string
+	for _, statement := range spec.StatementEntries {
+		if statement.Effect != "" && statement.Effect != "Allow" {
+			continue
+		}
+		for _, action := range statement.Action {
+			if !slices.Contains(actions, action) {
+				actions = append(actions, action)
+			}
+		}
+	}
+	sort.Strings(actions)
+
+	allowed := s.allowed[identity.ID]
+	if allowed == nil {
+		allowed = map[string]bool{}
+		s.allowed[identity.ID] = allowed
+	}
+	var unknown []string
+	for _, action := range actions {
+		if _, ok := allowed[action]; !ok {
+			unknown = append(unknown, action)
+		}
+	}
+	if len(unknown) > 0 {
+		permissions, err := s.simulate(identity.ID, unknown)
+		if err != nil {
+			return err
+		}
+		for _, permission := range permissions {
+			allowed[permission.Action] = permission.Allowed
+		}
+	}
+
+	for _, action := range actions {
+		if !allowed[action] {
+			result.MissingActions = append(result.MissingActions, action)
+		}
+	}
+	return nil
+}
+
+// checkServiceAccount compares the roles and permissions of a GCP CredentialsRequest with the ones
+// the WIF config grants to its service account
+func checkServiceAccount(identity operatorIdentity, credReq *cco.CredentialsRequest, result *operatorVerification) error {
+	sa, err := policies.CredentialsRequestToWifServiceAccount(credReq)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range sa.Roles {
+		if role.Predefined {
+			if roleID := policies.GCPRoleIDPrefix + role.Id; !slices.Contains(identity.Roles, roleID) {
+				result.MissingRoles = append(result.MissingRoles, roleID)
+			}
+			continue
+		}
+		for _, permission := range role.Permissions {
+			if !slices.Contains(identity.Permissions, permission) {
+				result.MissingPermissions = append(result.MissingPermissions, permission)
+			}
+		}
+	}
+	sort.Strings(result.MissingRoles)
+	sort.Strings(result.MissingPermissions)
+	return nil
+}
+
+// verifyResult prints one row per CredentialsRequest and version, with the permissions its
+// operator lacks
+func verifyResult(results []operatorVerification) *output.Result {
+	columns := []output.Column{
+		{Name: "VERSION"},
+		{Name: "CREDENTIALS REQUEST"},
+		{Name: "STATUS"},
+		{Name: "MISSING"},
+		{Name: "SECRET", Wide: true},
+		{Name: "IDENTITY", Wide: true},
+	}
+	return output.NewTable(results, columns, func(r operatorVerification) []string {
+		missing := slices.Concat(r.MissingActions, r.MissingRoles, r.MissingPermissions)
+		return []string{r.Version, r.CredentialsRequest, r.Status, strings.Join(missing, ", "), r.Namespace + "/" + r.Secret, r.Identity}
+	})
+}
@@ -0,0 +1,171 @@
+package iampermissions
+
+import (
+	"bytes"
+	"testing"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	cco "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	"github.com/openshift/osdctl/pkg/controller"
+	"github.com/openshift/osdctl/pkg/output"
+	"github.com/openshift/osdctl/pkg/policies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func withSecret(credReq *cco.CredentialsRequest, namespace, name string) *cco.CredentialsRequest {
+	credReq.Spec.SecretRef.Namespace = namespace
+	credReq.Spec.SecretRef.Name = name
+	return credReq
+}
+
+func gcpCredentialsRequest(name string, role string, permission string) *cco.CredentialsRequest {
+	spec := `{"apiVersion":"cloudcredential.openshift.io/v1","kind":"GCPProviderSpec","predefinedRoles":["` + role + `"],"permissions":["` + permission + `"]}`
+	return &cco.CredentialsRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       cco.CredentialsRequestSpec{ProviderSpec: &runtime.RawExtension{Raw: []byte(spec)}},
+	}
+}
+
+func newTestVerifyOptions(credReqs map[string][]*cco.CredentialsRequest) *verifyOptions {
+	return &verifyOptions{
+		downloadFunc: func(version string, cloud policies.CloudSpec) (string, error) {
+			return version, nil
+		},
+		parseFunc: func(dir string) ([]*cco.CredentialsRequest, error) {
+			return credReqs[dir], nil
+		},
+	}
+}
+
+func TestVerifyOperatorRoles(t *testing.T) {
+	techPreview := withSecret(awsCredentialsRequest("openshift-tech-preview", "*", "ec2:RunInstances"), "openshift-tech-preview", "cloud-credentials")
+	techPreview.Annotations = map[string]string{featureSetAnnotation: "TechPreviewNoUpgrade"}
+	o := newTestVerifyOptions(map[string][]*cco.CredentialsRequest{
+		"4.15.0": {
+			withSecret(awsCredentialsRequest("openshift-ingress", "*", "route53:ChangeResourceRecordSets", "tag:GetResources"), "openshift-ingress-operator", "cloud-credentials"),
+			withSecret(awsCredentialsRequest("openshift-cloud-credential-operator-iam-ro", "*", "iam:GetUser"), "openshift-cloud-credential-operator", "cloud-credential-operator-iam-ro-creds"),
+		},
+		"4.16.0": {
+			withSecret(awsCredentialsRequest("openshift-ingress", "*", "route53:ChangeResourceRecordSets", "tag:GetResources", "elasticloadbalancing:DescribeLoadBalancers"), "openshift-ingress-operator", "cloud-credentials"),
+			withSecret(awsCredentialsRequest("openshift-cloud-credential-operator-iam-ro", "*", "iam:GetUser"), "openshift-cloud-credential-operator", "cloud-credential-operator-iam-ro-creds"),
+			withSecret(awsCredentialsRequest("openshift-aws-efs-csi-driver", "*", "elasticfilesystem:DescribeFileSystems"), "openshift-cluster-csi-drivers", "aws-efs-cloud-credentials"),
+			techPreview,
+		},
+	})
+
+	ingressRole := "arn:aws:iam::123456789012:role/test-openshift-ingress-operator-cloud-credentials"
+	var simulated [][]string
+	simulator := newRoleSimulator(func(roleARN string, actions []string) ([]controller.PermissionResult, error) {
+		assert.Equal(t, ingressRole, roleARN)
+		simulated = append(simulated, actions)
+		var results []controller.PermissionResult
+		for _, action := range actions {
+			results = append(results, controller.PermissionResult{Action: action, Allowed: action != "elasticloadbalancing:DescribeLoadBalancers"})
+		}
+		return results, nil
+	})
+	identities := []operatorIdentity{
+		{Namespace: "openshift-ingress-operator", Secret: "cloud-credentials", ID: ingressRole},
+	}
+
+	results, err := o.verify(policies.AWS, []string{"4.15.0", "4.16.0"}, identities, simulator.check)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"route53:ChangeResourceRecordSets", "tag:GetResources"},
+		{"elasticloadbalancing:DescribeLoadBalancers"},
+	}, simulated, "actions are only simulated once per role")
+
+	var out bytes.Buffer
+	require.NoError(t, (&output.Options{Format: output.CSV}).Print(&out, verifyResult(results)))
+	assert.Equal(t, `VERSION,CREDENTIALS REQUEST,STATUS,MISSING,SECRET,IDENTITY
+4.15.0,openshift-cloud-credential-operator-iam-ro,not checked,,openshift-cloud-credential-operator/cloud-credential-operator-iam-ro-creds,
+4.15.0,openshift-ingress,ok,,openshift-ingress-operator/cloud-credentials,`+ingressRole+`
+4.16.0,openshift-aws-efs-csi-driver,missing identity,,openshift-cluster-csi-drivers/aws-efs-cloud-credentials,
+4.16.0,openshift-cloud-credential-operator-iam-ro,not checked,,openshift-cloud-credential-operator/cloud-credential-operator-iam-ro-creds,
+4.16.0,openshift-ingress,missing permissions,elasticloadbalancing:DescribeLoadBalancers,openshift-ingress-operator/cloud-credentials,`+ingressRole+`
+`, out.String())
+
+	var failed []string
+	for _, result := range results {
+		if result.failed() {
+			failed = append(failed, result.CredentialsRequest)
+		}
+	}
+	assert.Equal(t, []string{"openshift-aws-efs-csi-driver", "openshift-ingress"}, failed)
+}
+
+func TestInstalledOnManagedClusters(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		installed   bool
+	}{
+		{annotations: nil, installed: true},
+		{annotations: map[string]string{featureSetAnnotation: "Default"}, installed: true},
+		{annotations: map[string]string{featureSetAnnotation: "CustomNoUpgrade,Default,TechPreviewNoUpgrade"}, installed: true},
+		{annotations: map[string]string{featureSetAnnotation: "TechPreviewNoUpgrade"}, installed: false},
+		{annotations: map[string]string{featureSetAnnotation: "CustomNoUpgrade,TechPreviewNoUpgrade"}, installed: false},
+		{annotations: map[string]string{featureSetAnnotation: "Default", deleteAnnotation: "true"}, installed: false},
+	}
+	for _, tt := range tests {
+		credReq := awsCredentialsRequest("openshift-ingress", "*", "route53:ChangeResourceRecordSets")
+		credReq.Annotations = tt.annotations
+		assert.Equal(t, tt.installed, installedOnManagedClusters(credReq), tt.annotations)
+	}
+}
+
+func TestVerifyWifServiceAccounts(t *testing.T) {
+	wifConfig, err := cmv1.NewWifConfig().Gcp(cmv1.NewWifGcp().ServiceAccounts(
+		cmv1.NewWifServiceAccount().
+			ServiceAccountId("openshift-gcp-ccm").
+			CredentialRequest(cmv1.NewWifCredentialRequest().SecretRef(cmv1.NewWifSecretRef().Namespace("openshift-cloud-controller-manager").Name("gcp-ccm-cloud-credentials"))).
+			Roles(
+				cmv1.NewWifRole().RoleId("compute.loadBalancerAdmin").Predefined(true),
+				cmv1.NewWifRole().RoleId("openshift_gcp_ccm").Permissions("compute.instances.get"),
+			),
+		cmv1.NewWifServiceAccount().ServiceAccountId("osd-deployer"),
+	)).Build()
+	require.NoError(t, err)
+
+	identities := wifServiceAccounts(wifConfig)
+	assert.Equal(t, []operatorIdentity{{
+		Namespace:   "openshift-cloud-controller-manager",
+		Secret:      "gcp-ccm-cloud-credentials",
+		ID:          "openshift-gcp-ccm",
+		Roles:       []string{"roles/compute.loadBalancerAdmin"},
+		Permissions: []string{"compute.instances.get"},
+	}}, identities, "service accounts without a CredentialsRequest are left out")
+
+	o := newTestVerifyOptions(map[string][]*cco.CredentialsRequest{
+		"4.16.0": {
+			withSecret(gcpCredentialsRequest("openshift-gcp-ccm", "roles/compute.securityAdmin", "compute.instances.list"), "openshift-cloud-controller-manager", "gcp-ccm-cloud-credentials"),
+		},
+	})
+	results, err := o.verify(policies.GCP, []string{"4.16.0"}, identities, checkServiceAccount)
+	require.NoError(t, err)
+	assert.Equal(t, []operatorVerification{{
+		Version:            "4.16.0",
+		CredentialsRequest: "openshift-gcp-ccm",
+		Namespace:          "openshift-cloud-controller-manager",
+		Secret:             "gcp-ccm-cloud-credentials",
+		Identity:           "openshift-gcp-ccm",
+		Status:             verifyStatusMissingPermissions,
+		MissingRoles:       []string{"roles/compute.securityAdmin"},
+		MissingPermissions: []string{"compute.instances.list"},
+	}}, results)
+}
+
+func TestStsOperatorRoles(t *testing.T) {
+	cluster, err := cmv1.NewCluster().AWS(cmv1.NewAWS().STS(cmv1.NewSTS().Enabled(true).OperatorIAMRoles(
+		cmv1.NewOperatorIAMRole().Namespace("openshift-image-registry").Name("installer-cloud-credentials").RoleARN("arn:aws:iam::123456789012:role/test-openshift-image-registry-installer-cloud-credentials"),
+	))).Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, []operatorIdentity{{
+		Namespace: "openshift-image-registry",
+		Secret:    "installer-cloud-credentials",
+		ID:        "arn:aws:iam::123456789012:role/test-openshift-image-registry-installer-cloud-credentials",
+	}}, stsOperatorRoles(cluster))
+}
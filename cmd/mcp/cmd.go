@@ -0,0 +1,209 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/openshift/osdctl/pkg/output"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// AllowedCommandsKey is the osdctl config key listing the commands exposed by the MCP server
+const AllowedCommandsKey = "mcp_allowed_commands"
+
+type mcpOptions struct {
+	allow   []string
+	timeout time.Duration
+}
+
+// NewCmdMcp returns the mcp command. The commands of the tree it is added to are exposed as tools.
+func NewCmdMcp() *cobra.Command {
+	opts := &mcpOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "MCP server exposing read-only osdctl commands to AI agents",
+		Long: fmt.Sprintf(`MCP (Model Context Protocol) server that exposes read-only osdctl commands
+as tools for AI agents. The tool arguments are derived from the command flags
+and commands supporting JSON output return structured content.
+
+By default the following commands are exposed:
+  %s
+
+The list can be replaced with the '%s' key of the osdctl config file
+and extended with --allow. Commands that may change clusters or accounts
+(e.g. post, delete, resize, break-glass) or that require a --reason for
+elevation are never exposed.
+
+Subcommands:
+  server    Start the stdio MCP server
+  config    Print MCP client configuration JSON
+  tools     List the commands exposed as tools`, strings.Join(defaultAllowedCommands, "\n  "), AllowedCommandsKey),
+		Args: cobra.NoArgs,
+	}
+
+	cmd.PersistentFlags().StringSliceVar(&opts.allow, "allow", nil, `Additional commands to expose, e.g. --allow "org get,cluster health"`)
+	cmd.PersistentFlags().DurationVar(&opts.timeout, "timeout", 5*time.Minute, "Maximum duration of a tool call")
+
+	cmd.AddCommand(newCmdMcpServer(opts))
+	cmd.AddCommand(newCmdMcpConfig(opts))
+	cmd.AddCommand(newCmdMcpTools(opts))
+
+	return cmd
+}
+
+// allowedCommands returns the configured commands to expose
+func (o *mcpOptions) allowedCommands() []string {
+	allowed := defaultAllowedCommands
+	if viper.IsSet(AllowedCommandsKey) {
+		allowed = viper.GetStringSlice(AllowedCommandsKey)
+	}
+	return append(append([]string{}, allowed...), o.allow...)
+}
+
+func newCmdMcpServer(opts *mcpOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:          "server",
+		Short:        "Start the osdctl MCP server",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.SetOutput(io.Discard)
+
+			execPath, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to determine osdctl binary path: %v", err)
+			}
+
+			tools, rejected := discoverTools(cmd.Root(), opts.allowedCommands())
+			for path, reason := range rejected {
+				fmt.Fprintf(os.Stderr, "WARNING: not exposing '%s': %s\n", path, reason)
+			}
+
+			server := mcp.NewServer(&mcp.Implementation{
+				Name:    "osdctl",
+				Version: "1.0.0",
+			}, nil)
+
+			run := execRunner(execPath, opts.timeout)
+			for _, tool := range tools {
+				tool.register(server, run)
+			}
+
+			return server.Run(cmd.Context(), &mcp.StdioTransport{})
+		},
+	}
+}
+
+func newCmdMcpConfig(opts *mcpOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "config",
+		Short: "Print MCP client configuration JSON",
+		Long: `Print MCP client configuration JSON for use with AI agents.
+
+Usage with Claude Code:
+  claude --mcp-config "$(osdctl mcp config)"`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			execPath, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to determine osdctl binary path: %v", err)
+			}
+
+			serverArgs := []string{"mcp", "server"}
+			if len(opts.allow) > 0 {
+				serverArgs = append(serverArgs, "--allow", strings.Join(opts.allow, ","))
+			}
+			if cmd.Flags().Changed("timeout") {
+				serverArgs = append(serverArgs, "--timeout", opts.timeout.String())
+			}
+
+			config := map[string]interface{}{
+				"mcpServers": map[string]interface{}{
+					"osdctl": map[string]interface{}{
+						"command": execPath,
+						"args":    serverArgs,
+					},
+				},
+			}
+
+			output, err := json.MarshalIndent(config, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal config: %v", err)
+			}
+
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+}
+
+// exposedTool is a command exposed by the MCP server, as listed by the tools command
+type exposedTool struct {
+	Tool       string `json:"tool"`
+	Command    string `json:"command"`
+	JSONOutput bool   `json:"jsonOutput"`
+}
+
+func newCmdMcpTools(opts *mcpOptions) *cobra.Command {
+	var out output.Options
+
+	cmd := &cobra.Command{
+		Use:          "tools",
+		Short:        "List the osdctl commands exposed by the MCP server",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := out.Validate(); err != nil {
+				return err
+			}
+			tools, rejected := discoverTools(cmd.Root(), opts.allowedCommands())
+
+			exposed := make([]exposedTool, 0, len(tools))
+			for _, tool := range tools {
+				exposed = append(exposed, exposedTool{
+					Tool:       tool.name,
+					Command:    "osdctl " + strings.Join(tool.path, " "),
+					JSONOutput: tool.jsonFlag != "",
+				})
+			}
+			columns := []output.Column{{Name: "TOOL"}, {Name: "COMMAND"}, {Name: "JSON OUTPUT"}}
+			result := output.NewTable(exposed, columns, func(t exposedTool) []string {
+				jsonOutput := "no"
+				if t.JSONOutput {
+					jsonOutput = "yes"
+				}
+				return []string{t.Tool, t.Command, jsonOutput}
+			})
+			if err := out.Print(cmd.OutOrStdout(), result); err != nil {
+				return err
+			}
+
+			if len(rejected) > 0 {
+				paths := make([]string, 0, len(rejected))
+				for path := range rejected {
+					paths = append(paths, path)
+				}
+				sort.Strings(paths)
+
+				fmt.Fprintln(os.Stderr, "\nNot exposed:")
+				for _, path := range paths {
+					fmt.Fprintf(os.Stderr, "  %s: %s\n", path, rejected[path])
+				}
+			}
+			return nil
+		},
+	}
+	out.AddFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,388 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// defaultAllowedCommands are the read-only commands exposed unless configured otherwise
+var defaultAllowedCommands = []string{
+	"cluster context",
+	"cluster support status",
+	"servicelog list",
+	"hive clustersync-failures",
+	"org describe",
+}
+
+// deniedWords are command name words of commands changing clusters or accounts. A command whose
+// path contains any of them is never exposed, even if it is explicitly allowed.
+var deniedWords = map[string]bool{
+	"add": true, "apply": true, "backup": true, "break": true, "change": true, "cleanup": true,
+	"clear": true, "cordon": true, "create": true, "delete": true, "detach": true, "drain": true,
+	"exec": true, "expire": true, "force": true, "install": true, "login": true, "mcp": true,
+	"patch": true, "post": true, "promote": true, "remove": true, "replace": true, "reset": true,
+	"resize": true, "resync": true, "rm": true, "rotate": true, "scale": true, "set": true,
+	"ssh": true, "transfer": true, "update": true, "upgrade": true,
+}
+
+// serverControlledFlags are set by the server and not exposed in the tool schemas
+var serverControlledFlags = map[string]bool{
+	"help":               true,
+	"skip-version-check": true,
+	"output":             true,
+	"json":               true,
+}
+
+// maxOutputSize is the maximum size of the command output returned to the client
+const maxOutputSize = 1024 * 1024
+
+// commandTool is an osdctl command exposed as an MCP tool
+type commandTool struct {
+	name       string
+	path       []string
+	cmd        *cobra.Command
+	positional bool
+	jsonFlag   string
+}
+
+// commandRunner runs osdctl with the given arguments and returns its stdout and stderr
+type commandRunner func(ctx context.Context, args []string) ([]byte, []byte, error)
+
+// execRunner runs the osdctl binary at path in a subprocess, so commands writing to stdout
+// or exiting the process don't break the stdio transport
+func execRunner(path string, timeout time.Duration) commandRunner {
+	return func(ctx context.Context, args []string) ([]byte, []byte, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, path, args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("command timed out after %s", timeout)
+		}
+		return stdout.Bytes(), stderr.Bytes(), err
+	}
+}
+
+// commandPath returns the path of a command without the root command
+func commandPath(cmd *cobra.Command) []string {
+	var path []string
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		path = append([]string{c.Name()}, path...)
+	}
+	return path
+}
+
+// denyReason returns why a command must never be exposed, or an empty string
+func denyReason(cmd *cobra.Command) string {
+	for _, segment := range commandPath(cmd) {
+		for _, word := range strings.Split(segment, "-") {
+			if deniedWords[word] {
+				return fmt.Sprintf("'%s' may change clusters or accounts", segment)
+			}
+		}
+	}
+	if cmd.Flags().Lookup("reason") != nil {
+		return "requires a --reason for elevation"
+	}
+	if !cmd.Runnable() {
+		return "not runnable"
+	}
+	return ""
+}
+
+// discoverTools walks the command tree and returns the allowed commands as tools, sorted by
+// name, and the allowed commands that were rejected with the reason why
+func discoverTools(root *cobra.Command, allowed []string) ([]*commandTool, map[string]string) {
+	allowedPaths := map[string]bool{}
+	for _, path := range allowed {
+		allowedPaths[strings.Join(strings.Fields(path), " ")] = true
+	}
+
+	var tools []*commandTool
+	rejected := map[string]string{}
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+		path := commandPath(cmd)
+		key := strings.Join(path, " ")
+		if !allowedPaths[key] {
+			return
+		}
+		delete(allowedPaths, key)
+		if reason := denyReason(cmd); reason != "" {
+			rejected[key] = reason
+			return
+		}
+		tools = append(tools, newCommandTool(cmd, path))
+	}
+	walk(root)
+
+	for path := range allowedPaths {
+		rejected[path] = "no such command"
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].name < tools[j].name })
+	return tools, rejected
+}
+
+func newCommandTool(cmd *cobra.Command, path []string) *commandTool {
+	tool := &commandTool{
+		name:       strings.ReplaceAll(strings.Join(path, "_"), "-", "_"),
+		path:       path,
+		cmd:        cmd,
+		positional: acceptsPositionalArgs(cmd.Use),
+	}
+	if f := cmd.Flag("output"); f != nil && strings.Contains(f.Usage, "json") {
+		tool.jsonFlag = "--output=json"
+	} else if f := cmd.Flag("json"); f != nil && f.Value.Type() == "bool" {
+		tool.jsonFlag = "--json"
+	}
+	return tool
+}
+
+// acceptsPositionalArgs guesses from the usage line whether a command takes arguments,
+// e.g. "describe [org-id]" but not "context --cluster-id <cluster-identifier> [flags]"
+func acceptsPositionalArgs(use string) bool {
+	fields := strings.Fields(use)
+	for i := 1; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case strings.HasPrefix(field, "-"):
+			// Skip the value of the flag
+			if !strings.Contains(field, "=") && i+1 < len(fields) && !strings.HasPrefix(fields[i+1], "[") {
+				i++
+			}
+		case field == "[flags]" || field == "[options]":
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// flags returns the flags of the command exposed in the tool schema. Only the flags of the
+// command itself are exposed: the inherited flags include the kube and AWS credentials,
+// impersonation and API server flags, which would let a client leave the allowed scope.
+func (t *commandTool) flags() []*pflag.Flag {
+	var flags []*pflag.Flag
+	t.cmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || serverControlledFlags[f.Name] {
+			return
+		}
+		flags = append(flags, f)
+	})
+	return flags
+}
+
+// inputSchema derives the JSON schema of the tool arguments from the command flags
+func (t *commandTool) inputSchema() map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for _, f := range t.flags() {
+		property := map[string]interface{}{"description": f.Usage}
+		switch f.Value.Type() {
+		case "bool":
+			property["type"] = "boolean"
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+			property["type"] = "integer"
+		case "float32", "float64":
+			property["type"] = "number"
+		case "stringSlice", "stringArray", "intSlice", "uintSlice", "durationSlice", "boolSlice":
+			property["type"] = "array"
+			property["items"] = map[string]interface{}{"type": "string"}
+		default:
+			property["type"] = "string"
+		}
+		if f.DefValue != "" && f.DefValue != "[]" {
+			property["default"] = f.DefValue
+		}
+		properties[f.Name] = property
+
+		if ann, ok := f.Annotations[cobra.BashCompOneRequiredFlag]; ok && len(ann) > 0 && ann[0] == "true" {
+			required = append(required, f.Name)
+		}
+	}
+	if t.positional {
+		properties["args"] = map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Positional arguments: " + t.cmd.Use,
+		}
+	}
+
+	sort.Strings(required)
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func (t *commandTool) description() string {
+	description := t.cmd.Short
+	if t.cmd.Long != "" {
+		description = t.cmd.Long
+	}
+	if t.cmd.Example != "" {
+		description += "\n\nCLI examples:\n" + t.cmd.Example
+	}
+	return "osdctl " + strings.Join(t.path, " ") + ": " + description
+}
+
+// commandArgs converts the tool arguments into the osdctl command line
+func (t *commandTool) commandArgs(args map[string]interface{}) ([]string, error) {
+	known := map[string]*pflag.Flag{}
+	for _, f := range t.flags() {
+		known[f.Name] = f
+	}
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cmdArgs := append([]string{}, t.path...)
+	cmdArgs = append(cmdArgs, "--skip-version-check")
+	if t.jsonFlag != "" {
+		cmdArgs = append(cmdArgs, t.jsonFlag)
+	}
+
+	var positional []string
+	for _, name := range names {
+		value := args[name]
+		if name == "args" && t.positional {
+			values, err := stringValues(value)
+			if err != nil {
+				return nil, fmt.Errorf("args: %v", err)
+			}
+			positional = values
+			continue
+		}
+		f, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown argument: %s", name)
+		}
+		values, err := stringValues(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		for _, v := range values {
+			cmdArgs = append(cmdArgs, fmt.Sprintf("--%s=%s", f.Name, v))
+		}
+	}
+
+	if len(positional) > 0 {
+		cmdArgs = append(cmdArgs, "--")
+		cmdArgs = append(cmdArgs, positional...)
+	}
+	return cmdArgs, nil
+}
+
+// stringValues converts a JSON argument into flag values
+func stringValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{fmt.Sprint(v)}, nil
+	case float64:
+		return []string{fmt.Sprint(v)}, nil
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			itemValues, err := stringValues(item)
+			if err != nil {
+				return nil, err
+			}
+			if len(itemValues) != 1 {
+				return nil, fmt.Errorf("nested arrays are not supported")
+			}
+			values = append(values, itemValues...)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v", value)
+}
+
+// register adds the tool to the server, running it with the runner
+func (t *commandTool) register(s *mcp.Server, run commandRunner) {
+	schema, _ := json.Marshal(t.inputSchema())
+	s.AddTool(&mcp.Tool{
+		Name:        t.name,
+		Description: t.description(),
+		InputSchema: json.RawMessage(schema),
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := map[string]interface{}{}
+		if len(req.Params.Arguments) > 0 {
+			if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+				return toolError("invalid arguments: %v", err), nil
+			}
+		}
+		cmdArgs, err := t.commandArgs(args)
+		if err != nil {
+			return toolError("%v", err), nil
+		}
+
+		stdout, stderr, err := run(ctx, cmdArgs)
+		if err != nil {
+			return toolError("osdctl %s failed: %v\n%s", strings.Join(t.path, " "), err, truncate(stderr)), nil
+		}
+		return commandResult(strings.Join(t.path, " "), stdout), nil
+	})
+}
+
+// commandResult returns the output as structured content if it is JSON, otherwise as text
+func commandResult(command string, stdout []byte) *mcp.CallToolResult {
+	text := truncate(stdout)
+	result := &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}
+	var parsed interface{}
+	if len(stdout) <= maxOutputSize && json.Unmarshal(stdout, &parsed) == nil {
+		result.StructuredContent = map[string]interface{}{
+			"command": command,
+			"result":  parsed,
+		}
+	}
+	return result
+}
+
+func truncate(output []byte) string {
+	if len(output) > maxOutputSize {
+		return string(output[:maxOutputSize]) + "\n... output truncated"
+	}
+	return string(output)
+}
+
+func toolError(format string, args ...interface{}) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf(format, args...)}},
+		IsError: true,
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,188 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTree() *cobra.Command {
+	noop := func(cmd *cobra.Command, args []string) error { return nil }
+
+	root := &cobra.Command{Use: "osdctl"}
+	root.PersistentFlags().BoolP("skip-version-check", "S", false, "skip checking to see if this is the most recent release")
+	root.PersistentFlags().String("as", "", "Username to impersonate for the operation")
+	root.PersistentFlags().String("token", "", "Bearer token for authentication to the API server")
+	root.PersistentFlags().String("server", "", "The address and port of the Kubernetes API server")
+
+	cluster := &cobra.Command{Use: "cluster"}
+	cluster.PersistentFlags().StringP("output", "o", "", "Valid formats are ['', 'json', 'yaml', 'env']")
+	cluster.PersistentFlags().String("kubeconfig", "", "Path to the kubeconfig file")
+	root.AddCommand(cluster)
+
+	contextCmd := &cobra.Command{Use: "context --cluster-id <cluster-identifier>", Short: "Shows the context of a specified cluster", RunE: noop}
+	contextCmd.Flags().StringP("cluster-id", "C", "", "Provide internal ID of the cluster")
+	_ = contextCmd.MarkFlagRequired("cluster-id")
+	contextCmd.Flags().Int("days", 30, "Days of service logs")
+	contextCmd.Flags().Bool("full", false, "Run full suite of checks.")
+	contextCmd.Flags().StringArray("team-ids", []string{}, "PD team IDs")
+	contextCmd.Flags().String("secret", "", "Hidden flag")
+	_ = contextCmd.Flags().MarkHidden("secret")
+	cluster.AddCommand(contextCmd)
+
+	cluster.AddCommand(&cobra.Command{Use: "delete-snapshot", RunE: noop})
+
+	mustGather := &cobra.Command{Use: "must-gather", RunE: noop}
+	mustGather.Flags().String("reason", "", "The reason for this command, which requires elevation")
+	cluster.AddCommand(mustGather)
+
+	org := &cobra.Command{Use: "org"}
+	root.AddCommand(org)
+	describe := &cobra.Command{Use: "describe [org-id]", RunE: noop}
+	describe.Flags().Bool("json", false, "Output as JSON")
+	org.AddCommand(describe)
+
+	return root
+}
+
+func TestDiscoverTools(t *testing.T) {
+	tools, rejected := discoverTools(newTestTree(), []string{
+		"cluster context", "cluster  delete-snapshot", "cluster must-gather", "org describe", "org missing", "org",
+	})
+
+	require.Len(t, tools, 2)
+	assert.Equal(t, "cluster_context", tools[0].name)
+	assert.Equal(t, []string{"cluster", "context"}, tools[0].path)
+	assert.Equal(t, "--output=json", tools[0].jsonFlag)
+	assert.False(t, tools[0].positional)
+	assert.Equal(t, "org_describe", tools[1].name)
+	assert.Equal(t, "--json", tools[1].jsonFlag)
+	assert.True(t, tools[1].positional)
+
+	assert.Equal(t, map[string]string{
+		"cluster delete-snapshot": "'delete-snapshot' may change clusters or accounts",
+		"cluster must-gather":     "requires a --reason for elevation",
+		"org missing":             "no such command",
+		"org":                     "not runnable",
+	}, rejected)
+}
+
+func TestInputSchema(t *testing.T) {
+	tools, _ := discoverTools(newTestTree(), []string{"cluster context", "org describe"})
+	require.Len(t, tools, 2)
+
+	schema := tools[0].inputSchema()
+	properties := schema["properties"].(map[string]interface{})
+	assert.ElementsMatch(t, []string{"cluster-id", "days", "full", "team-ids"}, keys(properties))
+	for _, flag := range []string{"kubeconfig", "as", "token", "server"} {
+		assert.NotContains(t, properties, flag)
+	}
+	assert.Equal(t, []string{"cluster-id"}, schema["required"])
+	assert.Equal(t, "integer", properties["days"].(map[string]interface{})["type"])
+	assert.Equal(t, "30", properties["days"].(map[string]interface{})["default"])
+	assert.Equal(t, "boolean", properties["full"].(map[string]interface{})["type"])
+	assert.Equal(t, "array", properties["team-ids"].(map[string]interface{})["type"])
+
+	properties = tools[1].inputSchema()["properties"].(map[string]interface{})
+	assert.ElementsMatch(t, []string{"args"}, keys(properties))
+}
+
+func keys(m map[string]interface{}) []string {
+	var result []string
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
+
+func TestCommandArgs(t *testing.T) {
+	tools, _ := discoverTools(newTestTree(), []string{"cluster context", "org describe"})
+	require.Len(t, tools, 2)
+
+	args, err := tools[0].commandArgs(map[string]interface{}{
+		"cluster-id": "abc",
+		"days":       float64(7),
+		"full":       true,
+		"team-ids":   []interface{}{"T1", "T2"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cluster", "context", "--skip-version-check", "--output=json",
+		"--cluster-id=abc", "--days=7", "--full=true", "--team-ids=T1", "--team-ids=T2"}, args)
+
+	_, err = tools[0].commandArgs(map[string]interface{}{"secret": "x"})
+	assert.EqualError(t, err, "unknown argument: secret")
+	_, err = tools[0].commandArgs(map[string]interface{}{"output": "yaml"})
+	assert.EqualError(t, err, "unknown argument: output")
+	_, err = tools[0].commandArgs(map[string]interface{}{"kubeconfig": "/tmp/other"})
+	assert.EqualError(t, err, "unknown argument: kubeconfig")
+
+	args, err = tools[1].commandArgs(map[string]interface{}{"args": []interface{}{"--delete"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"org", "describe", "--skip-version-check", "--json", "--", "--delete"}, args)
+}
+
+func TestAcceptsPositionalArgs(t *testing.T) {
+	assert.False(t, acceptsPositionalArgs("list --cluster-id <cluster-identifier> [flags] [options]"))
+	assert.False(t, acceptsPositionalArgs("clustersync-failures [flags]"))
+	assert.False(t, acceptsPositionalArgs("describe"))
+	assert.True(t, acceptsPositionalArgs("hcp-dashboard [dashboard-name]"))
+	assert.True(t, acceptsPositionalArgs("get --output json <org-id>"))
+}
+
+func TestToolCall(t *testing.T) {
+	tools, _ := discoverTools(newTestTree(), []string{"cluster context"})
+	require.Len(t, tools, 1)
+
+	var calls [][]string
+	run := func(ctx context.Context, args []string) ([]byte, []byte, error) {
+		calls = append(calls, args)
+		if args[len(args)-1] == "--cluster-id=broken" {
+			return nil, []byte("cluster not found"), errors.New("exit status 1")
+		}
+		if args[len(args)-1] == "--cluster-id=text" {
+			return []byte("plain output"), nil, nil
+		}
+		return []byte(`{"id": "abc"}`), nil, nil
+	}
+
+	s := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0.0"}, nil)
+	tools[0].register(s, run)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx, serverTransport) }()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	listed, err := session.ListTools(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, listed.Tools, 1)
+	assert.True(t, listed.Tools[0].Annotations.ReadOnlyHint)
+
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "cluster_context", Arguments: map[string]interface{}{"cluster-id": "abc"}})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, map[string]interface{}{"command": "cluster context", "result": map[string]interface{}{"id": "abc"}}, result.StructuredContent)
+
+	result, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "cluster_context", Arguments: map[string]interface{}{"cluster-id": "text"}})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Nil(t, result.StructuredContent)
+	assert.Equal(t, "plain output", result.Content[0].(*mcp.TextContent).Text)
+
+	result, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "cluster_context", Arguments: map[string]interface{}{"cluster-id": "broken"}})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "cluster not found")
+
+	assert.Len(t, calls, 3)
+}
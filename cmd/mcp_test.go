@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var (
+	testRootOnce sync.Once
+	testRootCmd  *cobra.Command
+)
+
+// testRoot returns the root command shared by the tests: it can only be built once, as some
+// subcommands are package variables which would get their global flags added twice
+func testRoot() *cobra.Command {
+	testRootOnce.Do(func() {
+		streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+		testRootCmd = NewCmdRoot(streams)
+	})
+	return testRootCmd
+}
+
+func TestMcpToolsUsesBuiltRoot(t *testing.T) {
+	root := testRoot()
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"mcp", "tools", "-o", "json"})
+	defer func() {
+		root.SetOut(nil)
+		root.SetArgs(nil)
+	}()
+	if err := root.Execute(); err != nil {
+		t.Fatalf("mcp tools failed: %v", err)
+	}
+
+	var tools []struct {
+		Tool    string `json:"tool"`
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &tools); err != nil {
+		t.Fatalf("invalid output %q: %v", out.String(), err)
+	}
+	if len(tools) == 0 {
+		t.Fatal("no commands of the osdctl tree are exposed")
+	}
+	for _, tool := range tools {
+		if tool.Command == "osdctl servicelog list" {
+			return
+		}
+	}
+	t.Errorf("servicelog list is not exposed: %+v", tools)
+}
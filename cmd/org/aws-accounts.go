@@ -6,7 +6,6 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/service/organizations"
 	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
-	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/spf13/cobra"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
@@ -47,7 +46,7 @@ func init() {
 		"specify organization unit id",
 	)
 
-	AddOutputFlag(flags)
+	AddOutputFlag(awsAccountsCmd)
 }
 
 func searchChildAwsAccounts(cmd *cobra.Command) error {
@@ -62,32 +61,13 @@ func searchChildAwsAccounts(cmd *cobra.Command) error {
 	if err != nil {
 		return fmt.Errorf("cannot get organization children: %q", err)
 	}
-	printAccounts(children)
-
-	return nil
+	return printAccounts(children)
 }
 
-func printAccounts(children *organizations.ListChildrenOutput) {
-	if IsJsonOutput() {
-		items := AWSAccountItems{
-			Accounts: children.Children,
-		}
-		PrintJson(items)
-	} else {
-		table := printer.NewTablePrinter(os.Stdout, 20, 1, 2, ' ')
-		table.AddRow([]string{"ID", "Type"})
-		for _, item := range children.Children {
-			table.AddRow([]string{
-				*item.Id,
-				string(item.Type),
-			})
-		}
-
-		table.AddRow([]string{})
-		err := table.Flush()
-		if err != nil {
-			panic(err)
-		}
-	}
-
+func printAccounts(children *organizations.ListChildrenOutput) error {
+	return printItems(os.Stdout, AWSAccountItems{Accounts: children.Children}, children.Children,
+		[]string{"ID", "Type"},
+		func(child types.Child) []string {
+			return []string{*child.Id, string(child.Type)}
+		})
 }
@@ -2,14 +2,12 @@ package org
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 
 	"github.com/aws/aws-sdk-go-v2/service/organizations"
 	accountsv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
-	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/spf13/cobra"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
@@ -84,7 +82,7 @@ func init() {
 		"specify AWS Account Id",
 	)
 
-	AddOutputFlag(flags)
+	AddOutputFlag(clustersCmd)
 }
 
 func SearchSubscriptions(orgId string, status string) ([]*accountsv1.Subscription, error) {
@@ -134,31 +132,26 @@ func getOrganizationIdFromAWSProfile() (*string, error) {
 }
 
 func formatClustersOutput(items []*accountsv1.Subscription) ([]byte, error) {
-	if IsJsonOutput() {
-		subs := make([]map[string]string, 0, len(items))
-		for _, item := range items {
-			subs = append(subs, map[string]string{
-				"cluster_id":   item.ClusterID(),
-				"external_id":  item.ExternalClusterID(),
-				"display_name": item.DisplayName(),
-				"status":       item.Status(),
-			})
-		}
-		return json.MarshalIndent(subs, "", "  ")
-	} else {
-		var buf bytes.Buffer
-		table := printer.NewTablePrinter(&buf, 20, 1, 3, ' ')
-		table.AddRow([]string{"DISPLAY NAME", "INTERNAL CLUSTER ID", "EXTERNAL CLUSTER ID", "STATUS"})
-		for _, s := range items {
-			table.AddRow([]string{s.DisplayName(), s.ClusterID(), s.ExternalClusterID(), s.Status()})
-		}
-		table.AddRow([]string{})
-		err := table.Flush()
-		if err != nil {
-			return nil, err
-		}
-		return buf.Bytes(), nil
+	subs := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		subs = append(subs, map[string]string{
+			"cluster_id":   item.ClusterID(),
+			"external_id":  item.ExternalClusterID(),
+			"display_name": item.DisplayName(),
+			"status":       item.Status(),
+		})
+	}
+
+	var buf bytes.Buffer
+	err := printItems(&buf, subs, items,
+		[]string{"DISPLAY NAME", "INTERNAL CLUSTER ID", "EXTERNAL CLUSTER ID", "STATUS"},
+		func(s *accountsv1.Subscription) []string {
+			return []string{s.DisplayName(), s.ClusterID(), s.ExternalClusterID(), s.Status()}
+		})
+	if err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
 }
 
 // isAWSProfileSearch indicates if AWS profile flags are set.
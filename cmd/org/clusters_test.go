@@ -11,8 +11,8 @@ import (
 )
 
 func TestFormatClustersOutput_JSON(t *testing.T) {
-	output = "json"
-	defer func() { output = "" }()
+	outputOptions.Format = "json"
+	defer func() { outputOptions.Format = "" }()
 
 	sub1, _ := accountsv1.NewSubscription().
 		ClusterID("cid-1").
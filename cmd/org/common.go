@@ -1,18 +1,20 @@
 package org
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
-	"github.com/openshift-online/ocm-cli/pkg/dump"
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	accountsv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
 	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/output"
 	"github.com/openshift/osdctl/pkg/printer"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/openshift/osdctl/pkg/utils"
-	"github.com/spf13/pflag"
+	"github.com/spf13/cobra"
 )
 
 const (
@@ -23,8 +25,8 @@ const (
 )
 
 var (
-	awsProfile string = ""
-	output     string = ""
+	awsProfile    string = ""
+	outputOptions output.Options
 )
 
 type Organization struct {
@@ -59,42 +61,39 @@ func initAWSClient(awsProfile string) (awsprovider.Client, error) {
 	return awsprovider.NewAwsClient(awsProfile, common.DefaultRegion, "")
 }
 
-func printOrg(org Organization) {
+func printOrg(org Organization) error {
 	// Print org details
-	if IsJsonOutput() {
-		PrintJson(org)
-	} else {
-		table := printer.NewTablePrinter(os.Stdout, 20, 1, 2, ' ')
-		table.AddRow([]string{"ID:", org.ID})
-		table.AddRow([]string{"Name:", org.Name})
-		table.AddRow([]string{"External ID:", org.ExternalID})
-		table.AddRow([]string{"EBS ID:", org.EBSAccoundID})
-		table.AddRow([]string{"Created:", org.Created})
-		table.AddRow([]string{"Updated:", org.Updated})
-
-		table.AddRow([]string{})
-		_ = table.Flush()
+	var buf bytes.Buffer
+	table := printer.NewTablePrinter(&buf, 20, 1, 2, ' ')
+	table.AddRow([]string{"ID:", org.ID})
+	table.AddRow([]string{"Name:", org.Name})
+	table.AddRow([]string{"External ID:", org.ExternalID})
+	table.AddRow([]string{"EBS ID:", org.EBSAccoundID})
+	table.AddRow([]string{"Created:", org.Created})
+	table.AddRow([]string{"Updated:", org.Updated})
+	if err := table.Flush(); err != nil {
+		return err
 	}
-}
-
-func AddOutputFlag(flags *pflag.FlagSet) {
 
-	flags.StringVarP(
-		&output,
-		"output",
-		"o",
-		"",
-		"valid output formats are ['', 'json']",
-	)
+	return outputOptions.Print(os.Stdout, output.NewObject(org, strings.TrimSuffix(buf.String(), "\n")))
 }
 
-func IsJsonOutput() bool {
-	return output == "json"
+// AddOutputFlag adds the --output flag shared by the org commands
+func AddOutputFlag(cmd *cobra.Command) {
+	outputOptions.AddFormatFlag(cmd)
 }
 
-func PrintJson(data interface{}) {
-	marshalledStruct, _ := json.MarshalIndent(data, "", "  ")
-	dump.Pretty(os.Stdout, marshalledStruct)
+// printItems prints a row per item in the table formats, and object, which holds the items,
+// in the structured formats
+func printItems[T any](w io.Writer, object interface{}, items []T, columns []string, row func(T) []string) error {
+	result := &output.Result{Object: object}
+	for _, name := range columns {
+		result.Columns = append(result.Columns, output.Column{Name: name})
+	}
+	for _, item := range items {
+		result.Rows = append(result.Rows, row(item))
+	}
+	return outputOptions.Print(w, result)
 }
 
 func SearchAllSubscriptionsByOrg(orgID string, status string, managedOnly bool) ([]*accountsv1.Subscription, error) {
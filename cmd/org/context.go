@@ -2,8 +2,6 @@ package org
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -18,7 +16,7 @@ import (
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	v1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
 	"github.com/openshift/osdctl/cmd/servicelog"
-	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/output"
 	pdProvider "github.com/openshift/osdctl/pkg/provider/pagerduty"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
@@ -94,6 +92,7 @@ func NewDefaultContextFetcher() *DefaultContextFetcher {
 }
 
 func ContextCmd(fetcher ContextFetcher) *cobra.Command {
+	opts := output.Options{}
 	cmd := &cobra.Command{
 		Use:   "context orgId",
 		Short: "fetches information about the given organization",
@@ -102,18 +101,17 @@ func ContextCmd(fetcher ContextFetcher) *cobra.Command {
 osdctl org context 1a2B3c4DefghIjkLMNOpQrSTUV5
 
 # Get context data in JSON format
-osdctl org context 1a2B3c4DefghIjkLMNOpQrSTUV5 -o json`,
+osdctl org context 1a2B3c4DefghIjkLMNOpQrSTUV5 -o json
+
+# List the clusters of the organization sorted by the number of recent service logs
+osdctl org context 1a2B3c4DefghIjkLMNOpQrSTUV5 --sort-by recent-sls`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			outputFormat, err := cmd.Flags().GetString("output")
-			if err != nil {
-				return fmt.Errorf("error reading flag 'output': %w", err)
-			}
-			if outputFormat != "" && outputFormat != "json" {
-				return errors.New("unsupported output format, only 'json' is accepted")
+			if err := opts.Validate(); err != nil {
+				return err
 			}
 
-			// Progress goes to stderr, JSON to stdout
+			// Progress goes to stderr, results to stdout
 			progressWriter := os.Stderr
 
 			clusterInfos, err := fetcher.FetchContext(args[0], progressWriter)
@@ -125,13 +123,10 @@ osdctl org context 1a2B3c4DefghIjkLMNOpQrSTUV5 -o json`,
 				return nil
 			}
 
-			if outputFormat == "json" {
-				return printContextJson(os.Stdout, clusterInfos)
-			}
-			return printContext(clusterInfos)
+			return opts.Print(os.Stdout, contextResult(clusterInfos))
 		},
 	}
-	cmd.Flags().StringP("output", "o", "", "output format for the results. only supported value currently is 'json'")
+	opts.AddFlags(cmd)
 	return cmd
 }
 
@@ -242,62 +237,42 @@ func (f *DefaultContextFetcher) FetchContext(orgID string, output io.Writer) ([]
 	return results, nil
 }
 
-func printContextJson(w io.Writer, clusterInfos []ClusterInfo) error {
+// contextResult returns a row per cluster, and the clusters as clusterInfoView in the
+// structured formats
+func contextResult(clusterInfos []ClusterInfo) *output.Result {
 	views := make([]clusterInfoView, 0, len(clusterInfos))
 	for _, ci := range clusterInfos {
-		plan := ci.Plan
-		if plan == "MOA" {
-			plan = "ROSA"
-		}
-		if plan == "MOA-HostedControlPlane" {
-			plan = "HCP"
-		}
 		views = append(views, clusterInfoView{
 			DisplayName: ci.Name,
 			ClusterId:   ci.ID,
 			Version:     ci.Version,
 			Status:      getSupportStatusDisplayText(ci.LimitedSupportReasons),
 			Provider:    ci.CloudProvider,
-			Plan:        plan,
+			Plan:        getPlanDisplayText(ci.Plan),
 			NodeCount:   ci.NodeCount,
 			RecentSLs:   len(ci.ServiceLogs),
 			ActivePDs:   len(ci.PdAlerts),
 			OHSS:        len(ci.JiraIssues),
 		})
 	}
-	bytes, err := json.MarshalIndent(views, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal json response: %w", err)
+	columns := []output.Column{
+		{Name: "DISPLAY NAME"}, {Name: "CLUSTER ID"}, {Name: "VERSION"}, {Name: "STATUS"}, {Name: "PROVIDER"},
+		{Name: "PLAN"}, {Name: "NODE COUNT"}, {Name: "RECENT SLs"}, {Name: "ACTIVE PDs"}, {Name: "OHSS TICKETS"},
 	}
-	_, err = fmt.Fprintln(w, string(bytes))
-	return err
-}
-
-func printContext(clusterInfos []ClusterInfo) error {
-	table := printer.NewTablePrinter(os.Stdout, 0, 1, 3, ' ')
-	table.AddRow([]string{"DISPLAY NAME", "CLUSTER ID", "VERSION", "STATUS", "PROVIDER", "PLAN", "NODE COUNT", "RECENT SLs", "ACTIVE PDs", "OHSS TICKETS"})
-	for _, ci := range clusterInfos {
-		recentSLs := len(ci.ServiceLogs)
-		activePDs := len(ci.PdAlerts)
-		ohss := len(ci.JiraIssues)
-		table.AddRow([]string{
-			ci.Name,
-			ci.ID,
-			ci.Version,
-			getSupportStatusDisplayText(ci.LimitedSupportReasons),
-			ci.CloudProvider,
-			getPlanDisplayText(ci.Plan),
-			fmt.Sprintf("%v", ci.NodeCount),
-			strconv.Itoa(recentSLs),
-			strconv.Itoa(activePDs),
-			strconv.Itoa(ohss),
-		})
-	}
-	table.AddRow([]string{})
-	if err := table.Flush(); err != nil {
-		return fmt.Errorf("error writing data to console: %w", err)
-	}
-	return nil
+	return output.NewTable(views, columns, func(v clusterInfoView) []string {
+		return []string{
+			v.DisplayName,
+			v.ClusterId,
+			v.Version,
+			v.Status,
+			v.Provider,
+			v.Plan,
+			fmt.Sprintf("%v", v.NodeCount),
+			strconv.Itoa(v.RecentSLs),
+			strconv.Itoa(v.ActivePDs),
+			strconv.Itoa(v.OHSS),
+		}
+	})
 }
 
 func getSupportStatusDisplayText(reasons []*cmv1.LimitedSupportReason) string {
@@ -12,6 +12,7 @@ import (
 	accountsv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	v1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
+	"github.com/openshift/osdctl/pkg/output"
 )
 
 type fakePDClient struct {
@@ -53,7 +54,7 @@ func TestGetSupportStatusDisplayText(t *testing.T) {
 	}
 }
 
-func TestPrintContextJson(t *testing.T) {
+func TestContextResultJSON(t *testing.T) {
 	infos := []ClusterInfo{
 		{
 			Name:                  "cluster1",
@@ -69,8 +70,9 @@ func TestPrintContextJson(t *testing.T) {
 		},
 	}
 	buf := &bytes.Buffer{}
-	if err := printContextJson(buf, infos); err != nil {
-		t.Fatalf("printContextJson returned error: %v", err)
+	opts := output.Options{Format: output.JSON}
+	if err := opts.Print(buf, contextResult(infos)); err != nil {
+		t.Fatalf("printing the context returned error: %v", err)
 	}
 	out := buf.String()
 	if !strings.Contains(out, `"displayName": "cluster1"`) {
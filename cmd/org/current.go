@@ -38,7 +38,7 @@ var (
 			if err != nil {
 				cmdutil.CheckErr(err)
 			}
-			printOrg(*orgs)
+			cmdutil.CheckErr(printOrg(*orgs))
 		},
 	}
 )
@@ -48,8 +48,7 @@ type account struct {
 }
 
 func init() {
-	flags := currentCmd.Flags()
-	AddOutputFlag(flags)
+	AddOutputFlag(currentCmd)
 }
 
 func getCurrentOrg(data []byte) (*Organization, error) {
@@ -6,7 +6,6 @@ import (
 
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
-	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -32,7 +31,7 @@ var (
 			if err != nil {
 				cmdutil.CheckErr(err)
 			}
-			printCustomers(customers)
+			cmdutil.CheckErr(printCustomers(customers))
 		},
 	}
 	paying   bool   = true
@@ -61,7 +60,7 @@ func init() {
 		"get organization based on paying status",
 	)
 
-	AddOutputFlag(flags)
+	AddOutputFlag(customersCmd)
 }
 
 func getCustomers(ocmClient *sdk.Connection) ([]Customer, error) {
@@ -104,25 +103,10 @@ func getCustomers(ocmClient *sdk.Connection) ([]Customer, error) {
 	return customerList, nil
 }
 
-func printCustomers(items []Customer) {
-	if IsJsonOutput() {
-		customers := CustomerItems{
-			Customers: items,
-		}
-		PrintJson(customers)
-	} else {
-		table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
-		table.AddRow([]string{"ID", "OrganizationID", "SKU"})
-
-		for _, customer := range items {
-			table.AddRow([]string{
-				customer.ID,
-				customer.OrganizationID,
-				customer.SKU,
-			})
-		}
-
-		table.AddRow([]string{})
-		table.Flush()
-	}
+func printCustomers(items []Customer) error {
+	return printItems(os.Stdout, CustomerItems{Customers: items}, items,
+		[]string{"ID", "OrganizationID", "SKU"},
+		func(customer Customer) []string {
+			return []string{customer.ID, customer.OrganizationID, customer.SKU}
+		})
 }
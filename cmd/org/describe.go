@@ -34,9 +34,7 @@ var (
 )
 
 func init() {
-	flags := describeCmd.Flags()
-
-	AddOutputFlag(flags)
+	AddOutputFlag(describeCmd)
 }
 
 func describeOrg(cmd *cobra.Command, orgID string, ocmClient *sdk.Connection) error {
@@ -51,9 +49,7 @@ func describeOrg(cmd *cobra.Command, orgID string, ocmClient *sdk.Connection) er
 		return fmt.Errorf("failed to parse organization data: %v", err)
 	}
 
-	printOrg(org)
-
-	return nil
+	return printOrg(org)
 }
 
 func sendDescribeOrgRequest(orgID string) (*sdk.Response, error) {
@@ -8,7 +8,6 @@ import (
 
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
 	sdk "github.com/openshift-online/ocm-sdk-go"
-	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -81,7 +80,7 @@ func init() {
 		false,
 		"Part matching user name",
 	)
-	AddOutputFlag(flags)
+	AddOutputFlag(getCmd)
 
 	getCmd.MarkFlagsMutuallyExclusive("user", "ebs-id")
 
@@ -114,9 +113,7 @@ func searchOrgs(cmd *cobra.Command, ocmClient *sdk.Connection) error {
 		orgList = items.Orgs
 	}
 
-	printOrgList(orgList)
-
-	return nil
+	return printOrgList(orgList)
 }
 
 func getOrgs(ocmClient *sdk.Connection) (*sdk.Response, error) {
@@ -162,30 +159,12 @@ func getSearchQuery() string {
 	return searchQuery
 }
 
-func printOrgList(orgs []Organization) {
-
-	if IsJsonOutput() {
-		items := OrgItems{
-			Orgs: orgs,
-		}
-		PrintJson(items)
-	} else {
-		table := printer.NewTablePrinter(os.Stdout, 20, 1, 6, ' ')
-		table.AddRow([]string{"ID", "Name", "External ID", "EBS ID"})
-
-		for _, org := range orgs {
-			table.AddRow([]string{
-				org.ID,
-				org.Name,
-				org.ExternalID,
-				org.EBSAccoundID,
-			})
-		}
-
-		table.AddRow([]string{})
-		table.Flush()
-	}
-
+func printOrgList(orgs []Organization) error {
+	return printItems(os.Stdout, OrgItems{Orgs: orgs}, orgs,
+		[]string{"ID", "Name", "External ID", "EBS ID"},
+		func(org Organization) []string {
+			return []string{org.ID, org.Name, org.ExternalID, org.EBSAccoundID}
+		})
 }
 
 func getSearchType() int {
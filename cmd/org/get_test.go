@@ -228,7 +228,7 @@ func TestPrintOrgList(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		output = ""
+		outputOptions.Format = ""
 		printOrgList(testOrgs)
 
 		// Capture output and restore stdout
@@ -252,7 +252,7 @@ func TestPrintOrgList(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		output = "json"
+		outputOptions.Format = "json"
 		printOrgList(testOrgs)
 
 		// Capture output and restore stdout
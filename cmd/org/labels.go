@@ -8,7 +8,6 @@ import (
 
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
 	sdk "github.com/openshift-online/ocm-sdk-go"
-	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -46,9 +45,7 @@ type Label struct {
 }
 
 func init() {
-	flags := labelsCmd.Flags()
-
-	AddOutputFlag(flags)
+	AddOutputFlag(labelsCmd)
 }
 
 func searchLabelsByOrg(cmd *cobra.Command, orgID string, ocmClient *sdk.Connection) error {
@@ -61,9 +58,7 @@ func searchLabelsByOrg(cmd *cobra.Command, orgID string, ocmClient *sdk.Connecti
 	items := LabelItems{}
 	json.Unmarshal(response.Bytes(), &items)
 
-	printLabels(items.Labels)
-
-	return nil
+	return printLabels(items.Labels)
 }
 
 func getLabels(orgID string) (*sdk.Response, error) {
@@ -97,26 +92,10 @@ func createGetLabelsRequest(ocmClient *sdk.Connection, orgID string) *sdk.Reques
 	return request
 }
 
-func printLabels(items []Label) {
-	if IsJsonOutput() {
-		lables := LabelItems{
-			Labels: items,
-		}
-		PrintJson(lables)
-	} else {
-		table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
-		table.AddRow([]string{"ID", "KEY", "VALUE"})
-
-		for _, label := range items {
-			table.AddRow([]string{
-				label.ID,
-				label.Key,
-				label.Value,
-			})
-		}
-
-		table.AddRow([]string{})
-		table.Flush()
-	}
-
+func printLabels(items []Label) error {
+	return printItems(os.Stdout, LabelItems{Labels: items}, items,
+		[]string{"ID", "KEY", "VALUE"},
+		func(label Label) []string {
+			return []string{label.ID, label.Key, label.Value}
+		})
 }
@@ -137,7 +137,7 @@ func TestPrintLabels(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		output = ""
+		outputOptions.Format = ""
 		printLabels(testLabels)
 
 		// Capture output and restore stdout
@@ -161,7 +161,7 @@ func TestPrintLabels(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		output = "json"
+		outputOptions.Format = "json"
 		printLabels(testLabels)
 
 		// Capture output and restore stdout
@@ -7,7 +7,6 @@ import (
 
 	acc_util "github.com/openshift-online/ocm-cli/pkg/account"
 	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
-	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -43,9 +42,7 @@ var args struct {
 }
 
 func init() {
-	flags := usersCmd.Flags()
-
-	AddOutputFlag(flags)
+	AddOutputFlag(usersCmd)
 }
 
 func checkRoles(roles, roleArgs []string) bool {
@@ -129,30 +126,13 @@ func getUsers(orgID string) error {
 		pageIndex++
 	}
 
-	printUsers(userList)
-	return nil
+	return printUsers(userList)
 }
 
-func printUsers(userList []*userModel) {
-	if IsJsonOutput() {
-		users := UserItems{
-			Users: userList,
-		}
-		PrintJson(users)
-	} else {
-		table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
-		table.AddRow([]string{"USER", "USER ID", "ROLES"})
-
-		for _, user := range userList {
-			table.AddRow([]string{
-				user.UserName,
-				user.UserID,
-				printArray(user.Roles),
-			})
-		}
-
-		table.AddRow([]string{})
-		table.Flush()
-	}
-
+func printUsers(userList []*userModel) error {
+	return printItems(os.Stdout, UserItems{Users: userList}, userList,
+		[]string{"USER", "USER ID", "ROLES"},
+		func(user *userModel) []string {
+			return []string{user.UserName, user.UserID, printArray(user.Roles)}
+		})
 }
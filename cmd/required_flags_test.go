@@ -2,18 +2,15 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
 func TestRequiredFlagsDocumentedInExamples(t *testing.T) {
-	streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
-	root := NewCmdRoot(streams)
+	root := testRoot()
 
 	var walk func(cmd *cobra.Command)
 	walk = func(cmd *cobra.Command) {
@@ -17,6 +17,7 @@ import (
 	"github.com/google/uuid"
 	rhobsclient "github.com/observatorium/api/client"
 	rhobsmodels "github.com/observatorium/api/client/models"
+	"github.com/openshift/osdctl/pkg/output"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -36,7 +37,7 @@ func newCmdAlerts() *cobra.Command {
 }
 
 func newCmdAlertsGet() *cobra.Command {
-	var outputFormatStr string
+	var outputOptions output.Options
 	var isPrintingClusterResultsOnly bool
 
 	cmd := &cobra.Command{
@@ -46,8 +47,7 @@ func newCmdAlertsGet() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = false
 
-			outputFormat, err := GetAlertsFormatFromString(outputFormatStr)
-			if err != nil {
+			if err := outputOptions.Validate(); err != nil {
 				return err
 			}
 
@@ -58,7 +58,7 @@ func newCmdAlertsGet() *cobra.Command {
 				return err
 			}
 
-			err = rhobsFetcher.PrintAlerts(cmd.Context(), outputFormat, isPrintingClusterResultsOnly)
+			err = rhobsFetcher.PrintAlerts(cmd.Context(), &outputOptions, isPrintingClusterResultsOnly)
 			if err != nil {
 				return fmt.Errorf("failed to print alerts: %v", err)
 			}
@@ -67,7 +67,7 @@ func newCmdAlertsGet() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputFormatStr, "output", "o", string(AlertsFormatText), `Format of the output - allowed values: "text", "csv" or "json"`)
+	outputOptions.AddFormatFlag(cmd, rawDataFormats...)
 	cmd.Flags().BoolVarP(&isPrintingClusterResultsOnly, "filter", "f", false, "Only keep the results matching the given cluster - "+
 		"only effective if some of those results have a _id, _mc_id or mc_name label")
 
@@ -217,50 +217,13 @@ func newCmdSilencesCreate() *cobra.Command {
 				return fmt.Errorf("value passed to --start-time must be before the value passed to --end-time")
 			}
 
-			selectors := []*alertsSelector{}
-			specialChars := `=!~,\`
-			esc := func(s string) string {
-				for _, c := range specialChars {
-					s = strings.ReplaceAll(s, `\`+string(c), `\%`+fmt.Sprintf("%x", int(c)))
-				}
-				return s
-			}
-			unesc := func(s string) string {
-				for _, c := range specialChars {
-					s = strings.ReplaceAll(s, `\%`+fmt.Sprintf("%x", int(c)), string(c))
-				}
-				return s
-			}
-
-			for _, arg := range args {
-				for _, selectorStr := range strings.Split(esc(arg), ",") {
-					var selector *alertsSelector
-					for _, op := range allAlertsSelectorOps {
-						idx := strings.Index(selectorStr, op.symbol)
-						if idx != -1 {
-							selector = &alertsSelector{
-								labelName:  unesc(selectorStr[:idx]),
-								op:         &op,
-								labelValue: unesc(selectorStr[idx+len(op.symbol):]),
-							}
-							break
-						}
-					}
-					if selector == nil {
-						return fmt.Errorf("invalid argument / not a valid alert selector: %s", arg)
-					}
-					selectors = append(selectors, selector)
-				}
+			selectors, err := parseAlertsSelectors(args)
+			if err != nil {
+				return err
 			}
 
 			if author == "" {
-				user, err := user.Current()
-				if err == nil {
-					author = user.Name
-				} else {
-					log.Warnln("Failed to determine the current OS user:", err)
-					author = "osdctl"
-				}
+				author = defaultSilenceAuthor()
 			}
 
 			if comment == "" {
@@ -274,10 +237,11 @@ func newCmdSilencesCreate() *cobra.Command {
 				return err
 			}
 
-			err = rhobsFetcher.createSilence(cmd.Context(), &selectors, startTime, endTime, author, comment)
+			body, err := rhobsFetcher.createSilence(cmd.Context(), &selectors, startTime, endTime, author, comment)
 			if err != nil {
 				return fmt.Errorf("failed to create silence: %v", err)
 			}
+			fmt.Println("DONE", string(body))
 
 			return nil
 		},
@@ -295,6 +259,56 @@ func newCmdSilencesCreate() *cobra.Command {
 	return cmd
 }
 
+// parseAlertsSelectors parses selectors like key1==value1,key2!=value2 - see the help of the silences create command
+func parseAlertsSelectors(args []string) ([]*alertsSelector, error) {
+	selectors := []*alertsSelector{}
+	specialChars := `=!~,\`
+	esc := func(s string) string {
+		for _, c := range specialChars {
+			s = strings.ReplaceAll(s, `\`+string(c), `\%`+fmt.Sprintf("%x", int(c)))
+		}
+		return s
+	}
+	unesc := func(s string) string {
+		for _, c := range specialChars {
+			s = strings.ReplaceAll(s, `\%`+fmt.Sprintf("%x", int(c)), string(c))
+		}
+		return s
+	}
+
+	for _, arg := range args {
+		for _, selectorStr := range strings.Split(esc(arg), ",") {
+			var selector *alertsSelector
+			for _, op := range allAlertsSelectorOps {
+				idx := strings.Index(selectorStr, op.symbol)
+				if idx != -1 {
+					selector = &alertsSelector{
+						labelName:  unesc(selectorStr[:idx]),
+						op:         &op,
+						labelValue: unesc(selectorStr[idx+len(op.symbol):]),
+					}
+					break
+				}
+			}
+			if selector == nil {
+				return nil, fmt.Errorf("invalid argument / not a valid alert selector: %s", arg)
+			}
+			selectors = append(selectors, selector)
+		}
+	}
+
+	return selectors, nil
+}
+
+func defaultSilenceAuthor() string {
+	user, err := user.Current()
+	if err != nil {
+		log.Warnln("Failed to determine the current OS user:", err)
+		return "osdctl"
+	}
+	return user.Name
+}
+
 func newCmdSilencesDelete() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "delete [silence-id]",
@@ -315,10 +329,11 @@ func newCmdSilencesDelete() *cobra.Command {
 				return err
 			}
 
-			err = rhobsFetcher.DeleteSilence(cmd.Context(), silenceId)
+			body, err := rhobsFetcher.DeleteSilence(cmd.Context(), silenceId)
 			if err != nil {
 				return fmt.Errorf("failed to delete silence: %v", err)
 			}
+			fmt.Println("DONE", string(body))
 
 			return nil
 		},
@@ -327,27 +342,6 @@ func newCmdSilencesDelete() *cobra.Command {
 	return cmd
 }
 
-type AlertsFormat string
-
-const (
-	AlertsFormatText AlertsFormat = "text"
-	AlertsFormatCsv  AlertsFormat = "csv"
-	AlertsFormatJson AlertsFormat = "json"
-)
-
-func GetAlertsFormatFromString(formatStr string) (AlertsFormat, error) {
-	switch formatStr {
-	case string(AlertsFormatText):
-		return AlertsFormatText, nil
-	case string(AlertsFormatCsv):
-		return AlertsFormatCsv, nil
-	case string(AlertsFormatJson):
-		return AlertsFormatJson, nil
-	default:
-		return AlertsFormatText, fmt.Errorf("invalid output format: %s", formatStr)
-	}
-}
-
 type getAlertsResponse = []*jsonInterceptor[alertResult]
 
 type alertResult rhobsmodels.GettableAlert
@@ -469,17 +463,6 @@ func printAlertsAsCsv(alerts *[]*jsonInterceptor[alertResult]) {
 	writer.Flush()
 }
 
-func createAlertsPrinter(format AlertsFormat) alertsPrinter {
-	switch format {
-	case AlertsFormatJson:
-		return printResultsAsJson[alertResult]
-	case AlertsFormatCsv:
-		return printAlertsAsCsv
-	default:
-		return printAlertsAsText
-	}
-}
-
 func (f *RhobsFetcher) queryAlerts(ctx context.Context) (*[]*jsonInterceptor[alertResult], error) {
 	client, err := f.getClient()
 	if err != nil {
@@ -505,16 +488,15 @@ func (f *RhobsFetcher) queryAlerts(ctx context.Context) (*[]*jsonInterceptor[ale
 	return &formattedResponse, nil
 }
 
-func (f *RhobsFetcher) PrintAlerts(ctx context.Context, format AlertsFormat, isPrintingClusterResultsOnly bool) error {
+func (f *RhobsFetcher) PrintAlerts(ctx context.Context, opts *output.Options, isPrintingClusterResultsOnly bool) error {
 	alerts, err := f.queryAlerts(ctx)
 	if err != nil {
 		return err
 	}
 
 	alerts = filterMetricsResults(f, alerts, isPrintingClusterResultsOnly)
-	createAlertsPrinter(format)(alerts)
 
-	return nil
+	return printResults(opts, alerts, printAlertsAsText, printAlertsAsCsv)
 }
 
 func (f *RhobsFetcher) QueryRules(ctx context.Context, ruleType string) (json.RawMessage, error) {
@@ -561,10 +543,10 @@ func (f *RhobsFetcher) QuerySilences(ctx context.Context) (json.RawMessage, erro
 	return response.Body, nil
 }
 
-func (f *RhobsFetcher) createSilence(ctx context.Context, selectors *[]*alertsSelector, startTime, endTime time.Time, author, comment string) error {
+func (f *RhobsFetcher) createSilence(ctx context.Context, selectors *[]*alertsSelector, startTime, endTime time.Time, author, comment string) (json.RawMessage, error) {
 	client, err := f.getClient()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	log.Infoln("RHOBS cell:", f.RhobsCell)
@@ -588,21 +570,19 @@ func (f *RhobsFetcher) createSilence(ctx context.Context, selectors *[]*alertsSe
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to send request to RHOBS: %v", err)
+		return nil, fmt.Errorf("failed to send request to RHOBS: %v", err)
 	}
 	if response.HTTPResponse.StatusCode != http.StatusOK {
-		return fmt.Errorf("RHOBS query failed with status code: %d - body: %s", response.HTTPResponse.StatusCode, string(response.Body))
+		return nil, fmt.Errorf("RHOBS query failed with status code: %d - body: %s", response.HTTPResponse.StatusCode, string(response.Body))
 	}
 
-	fmt.Println("DONE", string(response.Body))
-
-	return nil
+	return response.Body, nil
 }
 
-func (f *RhobsFetcher) DeleteSilence(ctx context.Context, silenceId uuid.UUID) error {
+func (f *RhobsFetcher) DeleteSilence(ctx context.Context, silenceId uuid.UUID) (json.RawMessage, error) {
 	client, err := f.getClient()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	log.Infoln("RHOBS cell:", f.RhobsCell)
@@ -610,13 +590,11 @@ func (f *RhobsFetcher) DeleteSilence(ctx context.Context, silenceId uuid.UUID) e
 	response, err := client.DeleteSilenceWithResponse(ctx, "hcp", silenceId)
 
 	if err != nil {
-		return fmt.Errorf("failed to send request to RHOBS: %v", err)
+		return nil, fmt.Errorf("failed to send request to RHOBS: %v", err)
 	}
 	if response.HTTPResponse.StatusCode != http.StatusOK {
-		return fmt.Errorf("RHOBS query failed with status code: %d - body: %s", response.HTTPResponse.StatusCode, string(response.Body))
+		return nil, fmt.Errorf("RHOBS query failed with status code: %d - body: %s", response.HTTPResponse.StatusCode, string(response.Body))
 	}
 
-	fmt.Println("DONE", string(response.Body))
-
-	return nil
+	return response.Body, nil
 }
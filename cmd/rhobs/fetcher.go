@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 	"regexp"
 	"strings"
 
 	"github.com/openshift/osdctl/pkg/k8s"
 	"github.com/openshift/osdctl/pkg/osdctlConfig"
+	"github.com/openshift/osdctl/pkg/output"
 	ocmutils "github.com/openshift/osdctl/pkg/utils"
 
 	rhobsclient "github.com/observatorium/api/client"
@@ -378,6 +380,18 @@ func printAsJson(data interface{}) {
 	}
 }
 
-func printResultsAsJson[result any](results *[]*jsonInterceptor[result]) {
-	printAsJson(results)
+// rawDataFormats are the output formats of the rhobs commands, which print the raw RHOBS data in
+// the structured formats
+var rawDataFormats = []string{output.Table, output.CSV, output.JSON, output.YAML, output.JSONPath, output.GoTemplate}
+
+func printResults[result any](opts *output.Options, results *[]*jsonInterceptor[result], printTable, printCsv func(*[]*jsonInterceptor[result])) error {
+	switch {
+	case opts.IsStructured():
+		return opts.Print(os.Stdout, output.NewObject(results, ""))
+	case opts.FormatName() == output.CSV:
+		printCsv(results)
+	default:
+		printTable(results)
+	}
+	return nil
 }
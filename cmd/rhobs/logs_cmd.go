@@ -23,6 +23,7 @@ import (
 	"github.com/gorilla/websocket"
 	rhobsclient "github.com/observatorium/api/client"
 	rhobsparameters "github.com/observatorium/api/client/parameters"
+	"github.com/openshift/osdctl/pkg/output"
 	"github.com/pkg/browser"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -52,7 +53,7 @@ func newCmdLogs() *cobra.Command {
 	var isFollowing bool
 	var logsCount int
 	var isNotLimitingLogsCount bool
-	var outputFormatStr string
+	var outputOptions output.Options
 	var isPrintingTimestamp bool
 	var printedFields []string
 
@@ -217,12 +218,11 @@ func newCmdLogs() *cobra.Command {
 				}
 			}
 
-			outputFormat, err := GetLogsFormatFromString(outputFormatStr)
-			if err != nil {
+			if err := outputOptions.Validate(); err != nil {
 				return err
 			}
 
-			if outputFormat == LogsFormatJson {
+			if outputOptions.FormatName() == output.JSON {
 				if cmd.Flags().Changed("ts") {
 					return fmt.Errorf("--ts flag cannot be used with json output format")
 				}
@@ -257,9 +257,9 @@ func newCmdLogs() *cobra.Command {
 				}
 			} else {
 				if isFollowing {
-					err = rhobsFetcher.StreamLogs(lokiExpr, outputFormat, isPrintingTimestamp, printedFields)
+					err = rhobsFetcher.StreamLogs(lokiExpr, &outputOptions, isPrintingTimestamp, printedFields)
 				} else {
-					err = rhobsFetcher.PrintLogs(cmd.Context(), lokiExpr, startTime, endTime, logsCount, isGoingForward, outputFormat, isPrintingTimestamp, printedFields)
+					err = rhobsFetcher.PrintLogs(cmd.Context(), lokiExpr, startTime, endTime, logsCount, isGoingForward, &outputOptions, isPrintingTimestamp, printedFields)
 				}
 				if err != nil {
 					return fmt.Errorf("failed to print logs: %v", err)
@@ -317,7 +317,9 @@ func newCmdLogs() *cobra.Command {
 	cmd.Flags().BoolVar(&isNotLimitingLogsCount, "no-limit", false, "Do not limit the number of logs to return - exclusive with --limit, --url & --follow flags")
 	cmd.MarkFlagsMutuallyExclusive("limit", "no-limit", "url", "follow")
 
-	cmd.Flags().StringVarP(&outputFormatStr, "output", "o", string(LogsFormatText), `Format of the output - allowed values: "text", "csv" or "json" - exclusive with --url`)
+	// Logs are printed as they are received, which the output package can't do
+	outputOptions.AddFormatFlag(cmd, output.Table, output.CSV, output.JSON)
+	cmd.Flags().Lookup("output").Usage += " - exclusive with --url"
 	cmd.MarkFlagsMutuallyExclusive("output", "url")
 	cmd.Flags().BoolVar(&isPrintingTimestamp, "ts", false, `Print metadata timestamps - to be used when log messages do not have a timestamp - not possible with the "json" output format - exclusive with --url`)
 	cmd.MarkFlagsMutuallyExclusive("ts", "url")
@@ -329,27 +331,6 @@ func newCmdLogs() *cobra.Command {
 	return cmd
 }
 
-type LogsFormat string
-
-const (
-	LogsFormatText LogsFormat = "text"
-	LogsFormatCsv  LogsFormat = "csv"
-	LogsFormatJson LogsFormat = "json"
-)
-
-func GetLogsFormatFromString(formatStr string) (LogsFormat, error) {
-	switch formatStr {
-	case string(LogsFormatText):
-		return LogsFormatText, nil
-	case string(LogsFormatCsv):
-		return LogsFormatCsv, nil
-	case string(LogsFormatJson):
-		return LogsFormatJson, nil
-	default:
-		return LogsFormatText, fmt.Errorf("invalid output format: %s", formatStr)
-	}
-}
-
 func (f *RhobsFetcher) getLogsGrafanaDataSource() (string, error) {
 	baseDataSource, err := f.getBaseGrafanaDataSource()
 	if err != nil {
@@ -571,11 +552,11 @@ func (p *jsonLogsPrinter) PrintTrailer() {
 	fmt.Println("]")
 }
 
-func createLogsPrinter(format LogsFormat, isPrintingTimeValue bool, fieldNames []string) logsPrinter {
-	switch format {
-	case LogsFormatCsv:
+func createLogsPrinter(opts *output.Options, isPrintingTimeValue bool, fieldNames []string) logsPrinter {
+	switch opts.FormatName() {
+	case output.CSV:
 		return &csvLogsPrinter{writer: csv.NewWriter(os.Stdout), isPrintingTimeValue: isPrintingTimeValue, fieldNames: fieldNames}
-	case LogsFormatJson:
+	case output.JSON:
 		return &jsonLogsPrinter{}
 	default:
 		return &textLogsPrinter{isPrintingTimeValue: isPrintingTimeValue, fieldNames: fieldNames}
@@ -707,8 +688,8 @@ func (f *RhobsFetcher) queryLogs(ctx context.Context, lokiExpr string, startTime
 	return nil
 }
 
-func (f *RhobsFetcher) PrintLogs(ctx context.Context, lokiExpr string, startTime, endTime time.Time, logsCount int, isGoingForward bool, format LogsFormat, isPrintingTimeValue bool, fieldNames []string) error {
-	logsPrinter := createLogsPrinter(format, isPrintingTimeValue, fieldNames)
+func (f *RhobsFetcher) PrintLogs(ctx context.Context, lokiExpr string, startTime, endTime time.Time, logsCount int, isGoingForward bool, opts *output.Options, isPrintingTimeValue bool, fieldNames []string) error {
+	logsPrinter := createLogsPrinter(opts, isPrintingTimeValue, fieldNames)
 	logsPrinter.PrintHeader()
 	defer logsPrinter.PrintTrailer()
 
@@ -727,7 +708,7 @@ type streamLogsContext struct {
 	webSocket *websocket.Conn
 }
 
-func (f *RhobsFetcher) StreamLogs(lokiExpr string, format LogsFormat, isPrintingTimeValue bool, fieldNames []string) error {
+func (f *RhobsFetcher) StreamLogs(lokiExpr string, opts *output.Options, isPrintingTimeValue bool, fieldNames []string) error {
 	startTime := time.Now().Add(-5 * time.Minute)
 	tokenProvider, err := f.getTokenProvider()
 	if err != nil {
@@ -737,7 +718,7 @@ func (f *RhobsFetcher) StreamLogs(lokiExpr string, format LogsFormat, isPrinting
 	log.Infoln("RHOBS cell:", f.RhobsCell)
 	log.Infoln("Loki query:", lokiExpr)
 
-	logsPrinter := createLogsPrinter(format, isPrintingTimeValue, fieldNames)
+	logsPrinter := createLogsPrinter(opts, isPrintingTimeValue, fieldNames)
 	logsPrinter.PrintHeader()
 	defer logsPrinter.PrintTrailer()
 
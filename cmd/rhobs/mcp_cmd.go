@@ -40,7 +40,11 @@ func newCmdMcp() *cobra.Command {
 		Use:   "mcp",
 		Short: "RHOBS MCP server for AI agent integration",
 		Long: `MCP (Model Context Protocol) server that exposes RHOBS metrics, logs,
-and alerts querying as tools for AI agents.
+alerts, rules and silences querying, RHOBS cell and Grafana dashboard lookup
+and HCP cluster status as tools for AI agents.
+
+Tools creating or expiring silences are only available when the server is
+started with --allow-write.
 
 Compatible with any MCP client (Claude Code, Cursor, Windsurf, custom agents).
 
@@ -67,7 +71,9 @@ Prerequisites:
 }
 
 func newCmdMcpServer() *cobra.Command {
-	return &cobra.Command{
+	var allowWrite bool
+
+	cmd := &cobra.Command{
 		Use:          "server",
 		Short:        "Start the RHOBS MCP server",
 		Args:         cobra.NoArgs,
@@ -88,14 +94,23 @@ func newCmdMcpServer() *cobra.Command {
 			}, nil)
 
 			registerMcpTools(server)
+			if allowWrite {
+				registerMcpWriteTools(server)
+			}
 
 			return server.Run(cmd.Context(), &mcp.StdioTransport{})
 		},
 	}
+
+	cmd.Flags().BoolVar(&allowWrite, "allow-write", false, "Also expose the tools creating and expiring RHOBS silences")
+
+	return cmd
 }
 
 func newCmdMcpConfig() *cobra.Command {
-	return &cobra.Command{
+	var allowWrite bool
+
+	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "Print MCP client configuration JSON",
 		Long: `Print MCP client configuration JSON for use with AI agents.
@@ -112,11 +127,16 @@ Or add to ~/.claude/mcp_settings.json manually.`,
 				return fmt.Errorf("failed to determine osdctl binary path: %v", err)
 			}
 
+			serverArgs := []string{"rhobs", "mcp", "server"}
+			if allowWrite {
+				serverArgs = append(serverArgs, "--allow-write")
+			}
+
 			config := map[string]interface{}{
 				"mcpServers": map[string]interface{}{
 					"osdctl-rhobs": map[string]interface{}{
 						"command": execPath,
-						"args":    []string{"rhobs", "mcp", "server"},
+						"args":    serverArgs,
 					},
 				},
 			}
@@ -130,4 +150,8 @@ Or add to ~/.claude/mcp_settings.json manually.`,
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&allowWrite, "allow-write", false, "Start the server with --allow-write")
+
+	return cmd
 }
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	hcpstatus "github.com/openshift/osdctl/cmd/hcp/status"
 )
 
 func makeRequest(tool string, args map[string]interface{}) *mcp.CallToolRequest {
@@ -692,6 +693,129 @@ func TestHandleAlerts_MissingCluster(t *testing.T) {
 	}
 }
 
+// --- Triage tools validation tests ---
+
+func TestTriageTools_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     func(context.Context, *mcp.CallToolRequest) (*mcp.CallToolResult, error)
+		args        map[string]interface{}
+		expectedMsg string
+	}{
+		{"rules no cluster", handleRules, map[string]interface{}{}, "cluster_id is required"},
+		{"rules invalid type", handleRules, map[string]interface{}{"cluster_id": "test", "type": "alerts"}, "type must be either alert or record"},
+		{"silences no cluster", handleSilences, map[string]interface{}{}, "cluster_id is required"},
+		{"cell no cluster", handleCell, map[string]interface{}{}, "cluster_id is required"},
+		{"dashboard no cluster", handleHcpDashboard, map[string]interface{}{}, "cluster_id is required"},
+		{"dashboard invalid name", handleHcpDashboard, map[string]interface{}{"cluster_id": "test", "dashboard": "nope"}, "invalid dashboard name: nope"},
+		{"hcp status no cluster", handleHcpStatus, map[string]interface{}{}, "cluster_id is required"},
+		{
+			"create silence no comment", handleCreateSilence,
+			map[string]interface{}{"cluster_id": "test", "matchers": []interface{}{"alertname==Foo"}, "duration": "1h"},
+			"cluster_id, matchers and comment are required",
+		},
+		{
+			"create silence no expiry", handleCreateSilence,
+			map[string]interface{}{"cluster_id": "test", "matchers": []interface{}{"alertname==Foo"}, "comment": "OHSS-1"},
+			"exactly one of duration or end_time is required",
+		},
+		{
+			"create silence both expiries", handleCreateSilence,
+			map[string]interface{}{"cluster_id": "test", "matchers": []interface{}{"alertname==Foo"}, "comment": "OHSS-1", "duration": "1h", "end_time": "2030-01-01T00:00:00Z"},
+			"exactly one of duration or end_time is required",
+		},
+		{
+			"create silence negative duration", handleCreateSilence,
+			map[string]interface{}{"cluster_id": "test", "matchers": []interface{}{"alertname==Foo"}, "comment": "OHSS-1", "duration": "-1h"},
+			"'duration' must be greater than 0",
+		},
+		{
+			"create silence past end time", handleCreateSilence,
+			map[string]interface{}{"cluster_id": "test", "matchers": []interface{}{"alertname==Foo"}, "comment": "OHSS-1", "end_time": "2020-01-01T00:00:00Z"},
+			"'end_time' must be in the future",
+		},
+		{
+			"create silence invalid matcher", handleCreateSilence,
+			map[string]interface{}{"cluster_id": "test", "matchers": []interface{}{"alertname"}, "comment": "OHSS-1", "duration": "1h"},
+			"Invalid matchers: invalid argument / not a valid alert selector: alertname",
+		},
+		{"expire silence no id", handleExpireSilence, map[string]interface{}{"cluster_id": "test"}, "cluster_id and silence_id are required"},
+		{"expire silence invalid id", handleExpireSilence, map[string]interface{}{"cluster_id": "test", "silence_id": "abc"}, "silence_id is not a UUID: abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.handler(context.Background(), makeRequest("test", tt.args))
+			if err != nil {
+				t.Fatalf("handler returned Go error: %v", err)
+			}
+			if !isToolError(result) {
+				t.Error("expected tool error")
+			}
+			if got := getResultText(result); got != tt.expectedMsg {
+				t.Errorf("error = %q, want %q", got, tt.expectedMsg)
+			}
+		})
+	}
+}
+
+func TestParseAlertsSelectors(t *testing.T) {
+	selectors, err := parseAlertsSelectors([]string{`alertname==Foo,_id!=abc`, `namespace=~openshift-.*`, `msg!~a\,b`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		name, symbol, value string
+	}{
+		{"alertname", "==", "Foo"},
+		{"_id", "!=", "abc"},
+		{"namespace", "=~", "openshift-.*"},
+		{"msg", "!~", "a,b"},
+	}
+	if len(selectors) != len(expected) {
+		t.Fatalf("got %d selectors, want %d", len(selectors), len(expected))
+	}
+	for i, e := range expected {
+		if selectors[i].labelName != e.name || selectors[i].op.symbol != e.symbol || selectors[i].labelValue != e.value {
+			t.Errorf("selector %d = %s %s %s, want %s %s %s", i,
+				selectors[i].labelName, selectors[i].op.symbol, selectors[i].labelValue, e.name, e.symbol, e.value)
+		}
+	}
+
+	if _, err := parseAlertsSelectors([]string{"alertname"}); err == nil {
+		t.Error("expected error for selector without operator")
+	}
+}
+
+func TestFilterSilences(t *testing.T) {
+	body := json.RawMessage(`[
+		{"id": "1", "status": {"state": "active"}},
+		{"id": "2", "status": {"state": "expired"}},
+		{"id": "3", "status": {"state": "active"}}
+	]`)
+
+	all, err := filterSilences(body, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("got %d silences, want 3", len(all))
+	}
+
+	active, err := filterSilences(body, "active")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(active) != 2 || active[0]["id"] != "1" || active[1]["id"] != "3" {
+		t.Errorf("active silences = %v", active)
+	}
+
+	if _, err := filterSilences(json.RawMessage(`{}`), ""); err == nil {
+		t.Error("expected error for non array response")
+	}
+}
+
 // --- Tool registration test ---
 
 func TestRegisterMcpTools(t *testing.T) {
@@ -719,9 +843,14 @@ func TestRegisterMcpTools(t *testing.T) {
 	}
 
 	expectedNames := map[string]bool{
-		"rhobs_metrics": false,
-		"rhobs_logs":    false,
-		"rhobs_alerts":  false,
+		"rhobs_metrics":       false,
+		"rhobs_logs":          false,
+		"rhobs_alerts":        false,
+		"rhobs_rules":         false,
+		"rhobs_silences":      false,
+		"rhobs_cell":          false,
+		"rhobs_hcp_dashboard": false,
+		"hcp_status":          false,
 	}
 
 	if len(result.Tools) != len(expectedNames) {
@@ -772,6 +901,49 @@ func TestRegisterMcpTools(t *testing.T) {
 	}
 }
 
+func TestRegisterMcpWriteTools(t *testing.T) {
+	s := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0.0"}, nil)
+	registerMcpWriteTools(s)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() { _ = s.Run(ctx, serverTransport) }()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect failed: %v", err)
+	}
+	defer session.Close()
+
+	result, err := session.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+
+	if len(result.Tools) != 2 {
+		t.Fatalf("expected 2 tools, got %d", len(result.Tools))
+	}
+	for _, tool := range result.Tools {
+		if tool.Name != "rhobs_create_silence" && tool.Name != "rhobs_expire_silence" {
+			t.Errorf("unexpected tool: %s", tool.Name)
+		}
+		if tool.Annotations == nil {
+			t.Errorf("tool %s missing annotations", tool.Name)
+			continue
+		}
+		if tool.Annotations.ReadOnlyHint {
+			t.Errorf("tool %s should not be read-only", tool.Name)
+		}
+		if tool.Annotations.DestructiveHint == nil || !*tool.Annotations.DestructiveHint {
+			t.Errorf("tool %s should be destructive", tool.Name)
+		}
+	}
+}
+
 func TestRegisterMcpTools_SchemaValidation(t *testing.T) {
 	s := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0.0"}, nil)
 	registerMcpTools(s)
@@ -967,6 +1139,40 @@ func TestMcpConfigCommand(t *testing.T) {
 	}
 }
 
+func TestMcpConfigCommand_AllowWrite(t *testing.T) {
+	cmd := newCmdMcpConfig()
+	if err := cmd.Flags().Set("allow-write", "true"); err != nil {
+		t.Fatalf("failed to set flag: %v", err)
+	}
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := cmd.RunE(cmd, []string{})
+
+	w.Close()
+	os.Stdout = old
+
+	if err != nil {
+		t.Fatalf("config command returned error: %v", err)
+	}
+
+	var config struct {
+		McpServers map[string]struct {
+			Args []string `json:"args"`
+		} `json:"mcpServers"`
+	}
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		t.Fatalf("config output is not valid JSON: %v", err)
+	}
+
+	args := config.McpServers["osdctl-rhobs"].Args
+	if len(args) != 4 || args[3] != "--allow-write" {
+		t.Errorf("args = %v, want [rhobs mcp server --allow-write]", args)
+	}
+}
+
 // --- StructuredContent wire roundtrip test ---
 
 // TestStructuredContent_WireRoundtrip creates a minimal in-process MCP server
@@ -1045,3 +1251,43 @@ func TestToolAnnotations(t *testing.T) {
 		t.Error("expected DestructiveHint=false")
 	}
 }
+
+func TestHcpStatusOutputSchema(t *testing.T) {
+	ready := true
+	report := hcpstatus.Report{
+		HCPStatus: &hcpstatus.HCPStatus{
+			ClusterID:               "external-id",
+			APIServerCertificate:    &hcpstatus.CertificateStatus{Ready: &ready},
+			IngressCertificate:      &hcpstatus.CertificateStatus{Ready: &ready},
+			ManifestWorks:           []hcpstatus.ManifestWorkSync{{Name: "main"}},
+			HostedClusterConditions: []hcpstatus.Condition{{Type: "Available", Status: "True"}},
+			NodePools:               []hcpstatus.NodePoolStatus{{Name: "workers"}},
+		},
+		Health: hcpstatus.Health{Verdict: hcpstatus.HealthHealthy},
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var schema struct {
+		Properties map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal(hcpStatusOutputSchema, &schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	for field := range fields {
+		if _, ok := schema.Properties[field]; !ok {
+			t.Errorf("field %q of the status report is missing from the schema", field)
+		}
+	}
+	for property := range schema.Properties {
+		if _, ok := fields[property]; !ok {
+			t.Errorf("schema property %q is not a field of the status report", property)
+		}
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	hcpstatus "github.com/openshift/osdctl/cmd/hcp/status"
+	ocmutils "github.com/openshift/osdctl/pkg/utils"
 )
 
 type mcpLogEntry struct {
@@ -20,6 +23,12 @@ var readOnlyAnnotations = &mcp.ToolAnnotations{
 	DestructiveHint: boolPtr(false),
 }
 
+// Write tools change the alerting of a whole RHOBS cell; they are only registered with --allow-write
+var writeAnnotations = &mcp.ToolAnnotations{
+	ReadOnlyHint:    false,
+	DestructiveHint: boolPtr(true),
+}
+
 func registerMcpTools(s *mcp.Server) {
 	s.AddTool(&mcp.Tool{
 		Name: "rhobs_metrics",
@@ -108,6 +117,204 @@ func registerMcpTools(s *mcp.Server) {
 			}
 		}`),
 	}, handleAlerts)
+
+	s.AddTool(&mcp.Tool{
+		Name: "rhobs_rules",
+		Description: "List the Prometheus alerting and recording rules defined on the RHOBS cell of a cluster. " +
+			"Use it to find the expression, thresholds and labels behind an alert returned by rhobs_alerts.",
+		Annotations: readOnlyAnnotations,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cluster_id": {"type": "string", "description": "Cluster ID or name (HCP, MC, or SC)"},
+				"type":       {"type": "string", "enum": ["alert", "record"], "description": "Only return alerting or recording rules. Default: both"}
+			},
+			"required": ["cluster_id"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cell":        {"type": "string", "description": "RHOBS cell URL (regional Thanos/Loki endpoint)"},
+				"cluster_id":  {"type": "string", "description": "Internal cluster ID"},
+				"environment": {"type": "string", "description": "OCM environment (production, stage, integration)"},
+				"rules":       {"type": "object", "description": "Prometheus rules API response with the rule groups"}
+			}
+		}`),
+	}, handleRules)
+
+	s.AddTool(&mcp.Tool{
+		Name: "rhobs_silences",
+		Description: "List the alert silences defined on the RHOBS cell of a cluster. " +
+			"Silences apply to the whole cell, check their matchers to know which clusters they cover.",
+		Annotations: readOnlyAnnotations,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cluster_id": {"type": "string", "description": "Cluster ID or name (HCP, MC, or SC)"},
+				"state":      {"type": "string", "enum": ["active", "pending", "expired"], "description": "Only return silences in this state. Default: all"}
+			},
+			"required": ["cluster_id"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cell":        {"type": "string", "description": "RHOBS cell URL (regional Thanos/Loki endpoint)"},
+				"cluster_id":  {"type": "string", "description": "Internal cluster ID"},
+				"environment": {"type": "string", "description": "OCM environment (production, stage, integration)"},
+				"silences":    {"type": "array", "description": "Silences with ID, matchers, status, author and comment"},
+				"count":       {"type": "integer", "description": "Number of silences returned"}
+			}
+		}`),
+	}, handleSilences)
+
+	s.AddTool(&mcp.Tool{
+		Name: "rhobs_cell",
+		Description: "Resolve the RHOBS cells holding the metrics and the logs of a cluster. " +
+			"For HCP clusters the logs are stored with the parent Management Cluster.",
+		Annotations: readOnlyAnnotations,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cluster_id": {"type": "string", "description": "Cluster ID or name (HCP, MC, or SC)"}
+			},
+			"required": ["cluster_id"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cluster_id":   {"type": "string", "description": "Internal cluster ID"},
+				"environment":  {"type": "string", "description": "OCM environment (production, stage, integration)"},
+				"metrics_cell": {"type": "string", "description": "RHOBS cell URL used for metrics and alerts"},
+				"logs_cell":    {"type": "string", "description": "RHOBS cell URL used for logs"}
+			}
+		}`),
+	}, handleCell)
+
+	s.AddTool(&mcp.Tool{
+		Name: "rhobs_hcp_dashboard",
+		Description: "Get the URL of a Grafana dashboard for a cluster, to hand over to a human. " +
+			"The hosted-cluster dashboard requires an HCP cluster, the management-cluster dashboard an MC or HCP cluster.",
+		Annotations: readOnlyAnnotations,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cluster_id": {"type": "string", "description": "Cluster ID or name (HCP, MC, or SC)"},
+				"dashboard":  {"type": "string", "enum": ` + mustMarshalJSON(GetAllowedGrafanaDashboardsShortNames()) + `, "description": "Dashboard name. Default: ` + defaultGrafanaDashboardShortName + `"}
+			},
+			"required": ["cluster_id"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cluster_id": {"type": "string", "description": "Internal cluster ID"},
+				"dashboard":  {"type": "string", "description": "Dashboard name"},
+				"url":        {"type": "string", "description": "Grafana dashboard URL"}
+			}
+		}`),
+	}, handleHcpDashboard)
+
+	s.AddTool(&mcp.Tool{
+		Name: "hcp_status",
+		Description: "Get the health of a ROSA HCP cluster from the OCM live resources: ManifestWork sync status, " +
+			"HostedCluster conditions and version, API server and ingress certificates, and NodePool conditions.",
+		Annotations: readOnlyAnnotations,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cluster_id": {"type": "string", "description": "HCP cluster name, ID, or external ID"}
+			},
+			"required": ["cluster_id"]
+		}`),
+		OutputSchema: hcpStatusOutputSchema,
+	}, handleHcpStatus)
+}
+
+// hcpStatusOutputSchema is the schema of the status report of an HCP cluster
+var hcpStatusOutputSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"cluster_id":                {"type": "string", "description": "External cluster ID"},
+		"cluster_name":              {"type": "string", "description": "Cluster name"},
+		"cluster_state":             {"type": "string", "description": "OCM cluster state"},
+		"management_cluster":        {"type": "string", "description": "Name of the Management Cluster"},
+		"version":                   {"type": "object", "description": "Current and desired versions, and available updates"},
+		"api_server_certificate":    {"type": "object", "description": "API server certificate status"},
+		"ingress_certificate":       {"type": "object", "description": "Ingress certificate status"},
+		"manifest_works":            {"type": "array", "description": "ManifestWork sync status"},
+		"hosted_cluster_conditions": {"type": "array", "description": "HostedCluster conditions"},
+		"node_pools":                {"type": "array", "description": "NodePools with their conditions"},
+		"health": {
+			"type": "object",
+			"description": "Overall health of the cluster",
+			"properties": {
+				"verdict": {"type": "string", "enum": ["Healthy", "Unknown", "Degraded", "Unhealthy"]},
+				"reasons": {"type": "array", "items": {"type": "string"}, "description": "Why the cluster isn't healthy"}
+			}
+		}
+	}
+}`)
+
+// registerMcpWriteTools registers the tools changing the RHOBS cell state
+func registerMcpWriteTools(s *mcp.Server) {
+	s.AddTool(&mcp.Tool{
+		Name: "rhobs_create_silence",
+		Description: "Create an alert silence on the RHOBS cell of a cluster. " +
+			"The silence applies to every alert of the cell matching all matchers, so always include a matcher " +
+			"restricting it to the target cluster (e.g. _id==<external cluster ID>).",
+		Annotations: writeAnnotations,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cluster_id": {"type": "string", "description": "Cluster ID or name (HCP, MC, or SC)"},
+				"matchers":   {"type": "array", "items": {"type": "string"}, "description": "Label matchers using ==, !=, =~ or !~ (e.g. alertname==KubePodCrashLooping)"},
+				"duration":   {"type": "string", "description": "Duration after which the silence expires (e.g. 2h)"},
+				"end_time":   {"type": "string", "description": "Time at which the silence expires (RFC3339). Exclusive with duration."},
+				"comment":    {"type": "string", "description": "Why the silence is created, with JIRA or other references"},
+				"author":     {"type": "string", "description": "Author of the silence. Default: the OS user name"}
+			},
+			"required": ["cluster_id", "matchers", "comment"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cell":        {"type": "string", "description": "RHOBS cell URL (regional Thanos/Loki endpoint)"},
+				"cluster_id":  {"type": "string", "description": "Internal cluster ID"},
+				"environment": {"type": "string", "description": "OCM environment (production, stage, integration)"},
+				"silence":     {"type": "object", "description": "Alertmanager response with the ID of the new silence"},
+				"ends_at":     {"type": "string", "description": "Expiry time of the silence"}
+			}
+		}`),
+	}, handleCreateSilence)
+
+	s.AddTool(&mcp.Tool{
+		Name:        "rhobs_expire_silence",
+		Description: "Expire an alert silence on the RHOBS cell of a cluster.",
+		Annotations: writeAnnotations,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cluster_id": {"type": "string", "description": "Cluster ID or name (HCP, MC, or SC)"},
+				"silence_id": {"type": "string", "description": "ID (UUID) of the silence to expire"}
+			},
+			"required": ["cluster_id", "silence_id"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"cell":       {"type": "string", "description": "RHOBS cell URL (regional Thanos/Loki endpoint)"},
+				"cluster_id": {"type": "string", "description": "Internal cluster ID"},
+				"silence_id": {"type": "string", "description": "ID of the expired silence"}
+			}
+		}`),
+	}, handleExpireSilence)
+}
+
+func mustMarshalJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
 }
 
 func getArgs(req *mcp.CallToolRequest) map[string]interface{} {
@@ -132,6 +339,20 @@ func getIntArg(args map[string]interface{}, key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getStringSliceArg(args map[string]interface{}, key string) []string {
+	values, ok := args[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	result := []string{}
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
 func getBoolArg(args map[string]interface{}, key string, defaultValue bool) bool {
 	if val, ok := args[key].(bool); ok {
 		return val
@@ -288,3 +509,261 @@ func handleAlerts(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolR
 		"count":       len(*alerts),
 	})
 }
+
+func handleRules(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := getArgs(req)
+	clusterId := getStringArg(args, "cluster_id", "")
+	ruleType := getStringArg(args, "type", "")
+
+	if clusterId == "" {
+		return mcpError("cluster_id is required")
+	}
+	if ruleType != "" && ruleType != "alert" && ruleType != "record" {
+		return mcpError("type must be either alert or record")
+	}
+
+	fetcher, err := getCachedFetcher(ctx, clusterId, RhobsFetchForMetrics)
+	if err != nil {
+		return mcpError("Failed to initialize RHOBS fetcher: %v", err)
+	}
+
+	rules, err := fetcher.QueryRules(ctx, ruleType)
+	if err != nil {
+		return mcpError("Rules query failed: %v", err)
+	}
+
+	return mcpResultJSON(map[string]interface{}{
+		"cell":        fetcher.RhobsCell,
+		"cluster_id":  fetcher.clusterId,
+		"environment": fetcher.ocmEnvName,
+		"rules":       rules,
+	})
+}
+
+func handleSilences(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := getArgs(req)
+	clusterId := getStringArg(args, "cluster_id", "")
+	state := getStringArg(args, "state", "")
+
+	if clusterId == "" {
+		return mcpError("cluster_id is required")
+	}
+
+	fetcher, err := getCachedFetcher(ctx, clusterId, RhobsFetchForMetrics)
+	if err != nil {
+		return mcpError("Failed to initialize RHOBS fetcher: %v", err)
+	}
+
+	body, err := fetcher.QuerySilences(ctx)
+	if err != nil {
+		return mcpError("Silences query failed: %v", err)
+	}
+
+	silences, err := filterSilences(body, state)
+	if err != nil {
+		return mcpError("Failed to parse silences: %v", err)
+	}
+
+	return mcpResultJSON(map[string]interface{}{
+		"cell":        fetcher.RhobsCell,
+		"cluster_id":  fetcher.clusterId,
+		"environment": fetcher.ocmEnvName,
+		"silences":    silences,
+		"count":       len(silences),
+	})
+}
+
+// filterSilences decodes the silences returned by Alertmanager and keeps the ones in the given state, if any
+func filterSilences(body json.RawMessage, state string) ([]map[string]interface{}, error) {
+	var silences []map[string]interface{}
+	if err := json.Unmarshal(body, &silences); err != nil {
+		return nil, err
+	}
+
+	filtered := []map[string]interface{}{}
+	for _, silence := range silences {
+		if state != "" {
+			status, _ := silence["status"].(map[string]interface{})
+			if status["state"] != state {
+				continue
+			}
+		}
+		filtered = append(filtered, silence)
+	}
+	return filtered, nil
+}
+
+func handleCell(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := getArgs(req)
+	clusterId := getStringArg(args, "cluster_id", "")
+
+	if clusterId == "" {
+		return mcpError("cluster_id is required")
+	}
+
+	metricsFetcher, err := getCachedFetcher(ctx, clusterId, RhobsFetchForMetrics)
+	if err != nil {
+		return mcpError("Failed to initialize RHOBS metrics fetcher: %v", err)
+	}
+	logsFetcher, err := getCachedFetcher(ctx, clusterId, RhobsFetchForLogs)
+	if err != nil {
+		return mcpError("Failed to initialize RHOBS logs fetcher: %v", err)
+	}
+
+	return mcpResultJSON(map[string]interface{}{
+		"cluster_id":   metricsFetcher.clusterId,
+		"environment":  metricsFetcher.ocmEnvName,
+		"metrics_cell": metricsFetcher.RhobsCell,
+		"logs_cell":    logsFetcher.RhobsCell,
+	})
+}
+
+func handleHcpDashboard(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := getArgs(req)
+	clusterId := getStringArg(args, "cluster_id", "")
+	dashboardName := getStringArg(args, "dashboard", defaultGrafanaDashboardShortName)
+
+	if clusterId == "" {
+		return mcpError("cluster_id is required")
+	}
+	dashboard := GetGrafanaDashboardForShortName(dashboardName)
+	if dashboard == nil {
+		return mcpError("invalid dashboard name: %s", dashboardName)
+	}
+
+	metricsFetcher, err := getCachedFetcher(ctx, clusterId, RhobsFetchForMetrics)
+	if err != nil {
+		return mcpError("Failed to initialize RHOBS metrics fetcher: %v", err)
+	}
+	logsFetcher, err := getCachedFetcher(ctx, clusterId, RhobsFetchForLogs)
+	if err != nil {
+		return mcpError("Failed to initialize RHOBS logs fetcher: %v", err)
+	}
+
+	grafanaUrl, err := GetGrafanaDashboardUrl(metricsFetcher, logsFetcher, dashboard)
+	if err != nil {
+		return mcpError("Failed to compute Grafana URL: %v", err)
+	}
+
+	return mcpResultJSON(map[string]interface{}{
+		"cluster_id": metricsFetcher.clusterId,
+		"dashboard":  dashboardName,
+		"url":        grafanaUrl,
+	})
+}
+
+func handleHcpStatus(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := getArgs(req)
+	clusterId := getStringArg(args, "cluster_id", "")
+
+	if clusterId == "" {
+		return mcpError("cluster_id is required")
+	}
+
+	conn, err := ocmutils.CreateConnection()
+	if err != nil {
+		return mcpError("Failed to create OCM connection: %v", err)
+	}
+	defer conn.Close()
+
+	report, err := hcpstatus.GetStatusReport(conn, clusterId, hcpstatus.DefaultHealthThresholds)
+	if err != nil {
+		return mcpError("Failed to get HCP status: %v", err)
+	}
+
+	return mcpResultJSON(report)
+}
+
+func handleCreateSilence(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := getArgs(req)
+	clusterId := getStringArg(args, "cluster_id", "")
+	matchers := getStringSliceArg(args, "matchers")
+	durationStr := getStringArg(args, "duration", "")
+	endTimeStr := getStringArg(args, "end_time", "")
+	comment := getStringArg(args, "comment", "")
+	author := getStringArg(args, "author", "")
+
+	if clusterId == "" || len(matchers) == 0 || comment == "" {
+		return mcpError("cluster_id, matchers and comment are required")
+	}
+	if (durationStr == "") == (endTimeStr == "") {
+		return mcpError("exactly one of duration or end_time is required")
+	}
+
+	startTime := time.Now()
+	var endTime time.Time
+	if durationStr != "" {
+		duration, err := time.ParseDuration(durationStr)
+		if err != nil {
+			return mcpError("Invalid 'duration' '%s': %v", durationStr, err)
+		}
+		if duration <= 0 {
+			return mcpError("'duration' must be greater than 0")
+		}
+		endTime = startTime.Add(duration)
+	} else {
+		var err error
+		endTime, err = time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			return mcpError("Invalid 'end_time' '%s': %v", endTimeStr, err)
+		}
+		if !endTime.After(startTime) {
+			return mcpError("'end_time' must be in the future")
+		}
+	}
+
+	selectors, err := parseAlertsSelectors(matchers)
+	if err != nil {
+		return mcpError("Invalid matchers: %v", err)
+	}
+	if author == "" {
+		author = defaultSilenceAuthor()
+	}
+
+	fetcher, err := getCachedFetcher(ctx, clusterId, RhobsFetchForMetrics)
+	if err != nil {
+		return mcpError("Failed to initialize RHOBS fetcher: %v", err)
+	}
+
+	body, err := fetcher.createSilence(ctx, &selectors, startTime, endTime, author, comment)
+	if err != nil {
+		return mcpError("Failed to create silence: %v", err)
+	}
+
+	return mcpResultJSON(map[string]interface{}{
+		"cell":        fetcher.RhobsCell,
+		"cluster_id":  fetcher.clusterId,
+		"environment": fetcher.ocmEnvName,
+		"silence":     body,
+		"ends_at":     endTime.UTC().Format(time.RFC3339),
+	})
+}
+
+func handleExpireSilence(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := getArgs(req)
+	clusterId := getStringArg(args, "cluster_id", "")
+	silenceIdStr := getStringArg(args, "silence_id", "")
+
+	if clusterId == "" || silenceIdStr == "" {
+		return mcpError("cluster_id and silence_id are required")
+	}
+	silenceId, err := uuid.Parse(silenceIdStr)
+	if err != nil {
+		return mcpError("silence_id is not a UUID: %s", silenceIdStr)
+	}
+
+	fetcher, err := getCachedFetcher(ctx, clusterId, RhobsFetchForMetrics)
+	if err != nil {
+		return mcpError("Failed to initialize RHOBS fetcher: %v", err)
+	}
+
+	if _, err := fetcher.DeleteSilence(ctx, silenceId); err != nil {
+		return mcpError("Failed to expire silence: %v", err)
+	}
+
+	return mcpResultJSON(map[string]interface{}{
+		"cell":       fetcher.RhobsCell,
+		"cluster_id": fetcher.clusterId,
+		"silence_id": silenceId.String(),
+	})
+}
@@ -15,6 +15,7 @@ import (
 
 	rhobsclient "github.com/observatorium/api/client"
 	rhobsparameters "github.com/observatorium/api/client/parameters"
+	"github.com/openshift/osdctl/pkg/output"
 	"github.com/pkg/browser"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -28,7 +29,7 @@ func newCmdMetrics() *cobra.Command {
 	var endTime time.Time
 	var duration time.Duration
 	var stepDuration time.Duration
-	var outputFormatStr string
+	var outputOptions output.Options
 	var isPrintingClusterResultsOnly bool
 
 	cmd := &cobra.Command{
@@ -85,8 +86,7 @@ func newCmdMetrics() *cobra.Command {
 				}
 			}
 
-			outputFormat, err := GetMetricsFormatFromString(outputFormatStr)
-			if err != nil {
+			if err := outputOptions.Validate(); err != nil {
 				return err
 			}
 
@@ -116,9 +116,9 @@ func newCmdMetrics() *cobra.Command {
 				}
 			} else {
 				if cmd.Flags().Changed("since") || cmd.Flags().Changed("start-time") {
-					err = rhobsFetcher.PrintRangeMetrics(cmd.Context(), args[0], NewMetricsTimeRange(startTime, endTime, stepDuration), outputFormat, isPrintingClusterResultsOnly)
+					err = rhobsFetcher.PrintRangeMetrics(cmd.Context(), args[0], NewMetricsTimeRange(startTime, endTime, stepDuration), &outputOptions, isPrintingClusterResultsOnly)
 				} else {
-					err = rhobsFetcher.PrintInstantMetrics(cmd.Context(), args[0], evalTime, outputFormat, isPrintingClusterResultsOnly)
+					err = rhobsFetcher.PrintInstantMetrics(cmd.Context(), args[0], evalTime, &outputOptions, isPrintingClusterResultsOnly)
 				}
 				if err != nil {
 					return fmt.Errorf("failed to print metrics: %v", err)
@@ -141,8 +141,8 @@ func newCmdMetrics() *cobra.Command {
 	cmd.MarkFlagsMutuallyExclusive("time", "start-time", "since")
 	cmd.MarkFlagsMutuallyExclusive("time", "end-time", "since")
 
-	cmd.Flags().StringVarP(&outputFormatStr, "output", "o", string(MetricsFormatTable), `Format of the output - allowed values: "table", "csv" or "json" - `+
-		`"json" prints raw API data and as such is forward compatible - exclusive with --url`)
+	outputOptions.AddFormatFlag(cmd, rawDataFormats...)
+	cmd.Flags().Lookup("output").Usage += " - structured formats print raw API data and as such are forward compatible - exclusive with --url"
 	cmd.MarkFlagsMutuallyExclusive("output", "url")
 	cmd.Flags().BoolVarP(&isPrintingClusterResultsOnly, "filter", "f", false, "Only keep the results matching the given cluster - "+
 		"only effective if some of those results have a _id, _mc_id or mc_name label - exclusive with --url")
@@ -151,27 +151,6 @@ func newCmdMetrics() *cobra.Command {
 	return cmd
 }
 
-type MetricsFormat string
-
-const (
-	MetricsFormatTable MetricsFormat = "table"
-	MetricsFormatCsv   MetricsFormat = "csv"
-	MetricsFormatJson  MetricsFormat = "json"
-)
-
-func GetMetricsFormatFromString(formatStr string) (MetricsFormat, error) {
-	switch formatStr {
-	case string(MetricsFormatTable):
-		return MetricsFormatTable, nil
-	case string(MetricsFormatCsv):
-		return MetricsFormatCsv, nil
-	case string(MetricsFormatJson):
-		return MetricsFormatJson, nil
-	default:
-		return MetricsFormatTable, fmt.Errorf("invalid output format: %s", formatStr)
-	}
-}
-
 func (f *RhobsFetcher) getMetricsGrafanaDataSource() (string, error) {
 	baseDataSource, err := f.getBaseGrafanaDataSource()
 	if err != nil {
@@ -401,17 +380,6 @@ func printMetricsAsCsv(results *[]*jsonInterceptor[instantMetricResult]) {
 	writer.Flush()
 }
 
-func createInstantMetricsPrinter(format MetricsFormat) instantMetricsPrinter {
-	switch format {
-	case MetricsFormatCsv:
-		return printMetricsAsCsv
-	case MetricsFormatJson:
-		return printResultsAsJson[instantMetricResult]
-	default:
-		return printMetricsAsTable
-	}
-}
-
 type resultWithLabel interface {
 	instantMetricResult | rangeMetricResult | alertResult
 
@@ -518,16 +486,15 @@ func (f *RhobsFetcher) queryInstantMetrics(ctx context.Context, promExpr string,
 	return &formattedResponse.Data.Results, nil
 }
 
-func (f *RhobsFetcher) PrintInstantMetrics(ctx context.Context, promExpr string, evalTime time.Time, format MetricsFormat, isPrintingClusterResultsOnly bool) error {
+func (f *RhobsFetcher) PrintInstantMetrics(ctx context.Context, promExpr string, evalTime time.Time, opts *output.Options, isPrintingClusterResultsOnly bool) error {
 	results, err := f.queryInstantMetrics(ctx, promExpr, evalTime)
 	if err != nil {
 		return err
 	}
 
 	results = filterMetricsResults(f, results, isPrintingClusterResultsOnly)
-	createInstantMetricsPrinter(format)(results)
 
-	return nil
+	return printResults(opts, results, printMetricsAsTable, printMetricsAsCsv)
 }
 
 type MetricsTimeRange struct {
@@ -619,7 +586,7 @@ func (f *RhobsFetcher) queryRangeMetrics(ctx context.Context, promExpr string, t
 	return &formattedResponse.Data.Results, nil
 }
 
-func (f *RhobsFetcher) PrintRangeMetrics(ctx context.Context, promExpr string, timeRange MetricsTimeRange, format MetricsFormat, isPrintingClusterResultsOnly bool) error {
+func (f *RhobsFetcher) PrintRangeMetrics(ctx context.Context, promExpr string, timeRange MetricsTimeRange, opts *output.Options, isPrintingClusterResultsOnly bool) error {
 	results, err := f.queryRangeMetrics(ctx, promExpr, timeRange)
 	if err != nil {
 		return err
@@ -627,9 +594,8 @@ func (f *RhobsFetcher) PrintRangeMetrics(ctx context.Context, promExpr string, t
 
 	results = filterMetricsResults(f, results, isPrintingClusterResultsOnly)
 
-	if format == MetricsFormatJson {
-		printResultsAsJson[rangeMetricResult](results)
-		return nil
+	if opts.IsStructured() {
+		return opts.Print(os.Stdout, output.NewObject(results, ""))
 	}
 
 	instantResults := []*jsonInterceptor[instantMetricResult]{}
@@ -646,7 +612,5 @@ func (f *RhobsFetcher) PrintRangeMetrics(ctx context.Context, promExpr string, t
 		}
 	}
 
-	createInstantMetricsPrinter(format)(&instantResults)
-
-	return nil
+	return printResults(opts, &instantResults, printMetricsAsTable, printMetricsAsCsv)
 }
@@ -0,0 +1,172 @@
+package servicelog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"golang.org/x/time/rate"
+)
+
+// Outcomes of posting a service log to a cluster, as recorded in the journal
+const (
+	postStatusSent      = "sent"
+	postStatusFailed    = "failed"
+	postStatusDuplicate = "duplicate"
+)
+
+// journalEntry is the outcome of posting a service log to a cluster. The journal of a bulk post
+// holds one entry per line, written as soon as the cluster has been handled.
+type journalEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	ClusterID   string    `json:"cluster_id"`
+	ClusterUUID string    `json:"cluster_uuid"`
+	Summary     string    `json:"summary"`
+	Status      string    `json:"status"`
+	Message     string    `json:"message,omitempty"`
+}
+
+// done reports whether the cluster doesn't need the service log anymore when resuming
+func (e journalEntry) done() bool {
+	return e.Status == postStatusSent || e.Status == postStatusDuplicate
+}
+
+// postJournal appends the outcomes of a bulk post to a JSONL file
+type postJournal struct {
+	path string
+	file *os.File
+}
+
+// defaultJournalPath returns a new journal file in the user cache directory
+func defaultJournalPath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("post-%s.jsonl", time.Now().UTC().Format("20060102T150405Z"))
+	return filepath.Join(cacheDir, "osdctl", "servicelog", "journals", name), nil
+}
+
+// openPostJournal opens a journal for appending, creating it if needed
+func openPostJournal(path string) (*postJournal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) //#nosec G304 -- Potential file inclusion via variable
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	return &postJournal{path: path, file: file}, nil
+}
+
+// Record appends an entry to the journal
+func (j *postJournal) Record(entry journalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = j.file.Write(append(data, '\n'))
+	return err
+}
+
+func (j *postJournal) Close() error {
+	return j.file.Close()
+}
+
+// readPostJournal returns the latest entry of every cluster in a journal
+func readPostJournal(path string) (map[string]journalEntry, error) {
+	file, err := os.Open(path) //#nosec G304 -- Potential file inclusion via variable
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	entries := map[string]journalEntry{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// The last line may be truncated if osdctl was killed while writing it
+			return nil, fmt.Errorf("invalid journal entry on line %d of %s: %w", line, path, err)
+		}
+		entries[entry.ClusterID] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	return entries, nil
+}
+
+// bulkPoster posts a service log to clusters with a pool of workers, starting at most rate
+// posts per second
+type bulkPoster struct {
+	workers int
+	rate    float64
+	// post sends the service log to a cluster and returns the outcome
+	post func(ctx context.Context, cluster *v1.Cluster) journalEntry
+	// record is called with the outcome of every cluster, one at a time
+	record func(entry journalEntry)
+}
+
+// Run posts to the clusters until all are handled or the context is cancelled, in which case
+// clusters not yet handled are left out of the returned outcomes
+func (b *bulkPoster) Run(ctx context.Context, clusters []*v1.Cluster) []journalEntry {
+	limit := rate.Inf
+	if b.rate > 0 {
+		limit = rate.Limit(b.rate)
+	}
+	limiter := rate.NewLimiter(limit, 1)
+
+	workers := b.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	queue := make(chan *v1.Cluster)
+	var (
+		mu       sync.Mutex
+		outcomes []journalEntry
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cluster := range queue {
+				if err := limiter.Wait(ctx); err != nil {
+					continue
+				}
+				entry := b.post(ctx, cluster)
+
+				mu.Lock()
+				outcomes = append(outcomes, entry)
+				if b.record != nil {
+					b.record(entry)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for _, cluster := range clusters {
+		select {
+		case queue <- cluster:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	return outcomes
+}
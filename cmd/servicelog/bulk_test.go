@@ -0,0 +1,138 @@
+package servicelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/internal/servicelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClusters(t *testing.T, n int) []*v1.Cluster {
+	var clusters []*v1.Cluster
+	for i := 0; i < n; i++ {
+		cluster, err := v1.NewCluster().ID(fmt.Sprintf("id-%d", i)).ExternalID(fmt.Sprintf("uuid-%d", i)).Build()
+		require.NoError(t, err)
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+func TestPostJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journals", "post.jsonl")
+
+	journal, err := openPostJournal(path)
+	require.NoError(t, err)
+	require.NoError(t, journal.Record(journalEntry{ClusterID: "id-0", Status: postStatusFailed, Message: "timeout"}))
+	require.NoError(t, journal.Record(journalEntry{ClusterID: "id-1", Status: postStatusDuplicate}))
+	require.NoError(t, journal.Close())
+
+	// Resuming appends to the journal, the latest entry of a cluster wins
+	journal, err = openPostJournal(path)
+	require.NoError(t, err)
+	require.NoError(t, journal.Record(journalEntry{ClusterID: "id-0", Status: postStatusSent}))
+	require.NoError(t, journal.Close())
+
+	entries, err := readPostJournal(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.True(t, entries["id-0"].done())
+	assert.True(t, entries["id-1"].done())
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"cluster_id": "id-0", "status": "sent"}`+"\n"+`{"cluster_id": "id-1", "sta`), 0600))
+	_, err = readPostJournal(path)
+	assert.ErrorContains(t, err, "invalid journal entry on line 2")
+}
+
+func TestSkipResumedClusters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "post.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(
+		`{"cluster_id": "id-0", "status": "sent", "summary": "Hello"}`+"\n"+
+			`{"cluster_id": "id-1", "status": "failed"}`+"\n"+
+			`{"cluster_id": "id-2", "status": "duplicate"}`+"\n"), 0600))
+
+	o := &PostCmdOptions{resumeJournal: path, Message: servicelog.Message{Summary: "Hello"}}
+	remaining, err := o.skipResumedClusters(newTestClusters(t, 4))
+	require.NoError(t, err)
+
+	var ids []string
+	for _, cluster := range remaining {
+		ids = append(ids, cluster.ID())
+	}
+	assert.Equal(t, []string{"id-1", "id-3"}, ids)
+}
+
+func TestBulkPoster(t *testing.T) {
+	clusters := newTestClusters(t, 10)
+
+	var running, maxRunning int32
+	var recorded []string
+	poster := &bulkPoster{
+		workers: 3,
+		post: func(ctx context.Context, cluster *v1.Cluster) journalEntry {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return journalEntry{ClusterID: cluster.ID(), Status: postStatusSent}
+		},
+		record: func(entry journalEntry) {
+			recorded = append(recorded, entry.ClusterID)
+		},
+	}
+
+	outcomes := poster.Run(context.Background(), clusters)
+	assert.Len(t, outcomes, 10)
+	assert.LessOrEqual(t, maxRunning, int32(3))
+	assert.Greater(t, maxRunning, int32(1))
+
+	sort.Strings(recorded)
+	assert.Equal(t, []string{"id-0", "id-1", "id-2", "id-3", "id-4", "id-5", "id-6", "id-7", "id-8", "id-9"}, recorded)
+}
+
+func TestBulkPosterRateLimit(t *testing.T) {
+	poster := &bulkPoster{
+		workers: 4,
+		rate:    20,
+		post: func(ctx context.Context, cluster *v1.Cluster) journalEntry {
+			return journalEntry{ClusterID: cluster.ID(), Status: postStatusSent}
+		},
+	}
+
+	start := time.Now()
+	outcomes := poster.Run(context.Background(), newTestClusters(t, 5))
+	assert.Len(t, outcomes, 5)
+	// The first post starts immediately, the 4 others 50ms apart
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+}
+
+func TestBulkPosterInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	poster := &bulkPoster{
+		workers: 1,
+		post: func(ctx context.Context, cluster *v1.Cluster) journalEntry {
+			if cluster.ID() == "id-2" {
+				cancel()
+			}
+			return journalEntry{ClusterID: cluster.ID(), Status: postStatusSent}
+		},
+	}
+
+	outcomes := poster.Run(ctx, newTestClusters(t, 10))
+	assert.Len(t, outcomes, 3)
+}
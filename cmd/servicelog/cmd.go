@@ -20,6 +20,8 @@ func NewCmdServiceLog() *cobra.Command {
 
 	servicelogCmd.AddCommand(newListCmd())
 	servicelogCmd.AddCommand(newPostCmd())
+	servicelogCmd.AddCommand(newLintCmd())
+	servicelogCmd.AddCommand(newTemplatesCmd())
 
 	return servicelogCmd
 }
@@ -3,6 +3,8 @@ package servicelog
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	sdk "github.com/openshift-online/ocm-sdk-go"
@@ -83,6 +85,21 @@ func GetServiceLogsSince(clusterID string, timeSince time.Time, allMessages bool
 	return errorServiceLogs, nil
 }
 
+// recentServiceLogs returns the service logs of a service the cluster received since the given
+// time, newest first, reading every page. An empty service name selects the SRE service logs, and
+// a non-empty summary only keeps the service logs with that exact summary.
+func recentServiceLogs(ocmClient *sdk.Connection, cluster *cmv1.Cluster, serviceName, summary string, since time.Time) ([]*v1.LogEntry, error) {
+	filter := serviceLogFilter{since: since}
+	if serviceName != "" {
+		filter.serviceNames = []string{serviceName}
+	}
+	if summary != "" {
+		filter.summary = regexp.MustCompile("^" + regexp.QuoteMeta(summary) + "$")
+	}
+	entries, _, err := fetchFilteredServiceLogs(ocmClient, cluster, filter, 0, 0)
+	return entries, err
+}
+
 func FetchServiceLogs(clusterID string, allMessages bool, internalOnly bool) (*v1.ClustersClusterLogsListResponse, error) {
 	// Create OCM client to talk to cluster API
 	ocmClient, err := utils.CreateConnection()
@@ -111,22 +128,24 @@ func FetchServiceLogs(clusterID string, allMessages bool, internalOnly bool) (*v
 }
 
 func sendClusterLogsListRequest(ocmClient *sdk.Connection, cluster *cmv1.Cluster, allMessages bool, internalMessages bool) (*v1.ClustersClusterLogsListResponse, error) {
+	return sendFilteredClusterLogsListRequest(ocmClient, cluster, serviceLogFilter{allMessages: allMessages, internal: internalMessages}, 0, 0)
+}
+
+// sendFilteredClusterLogsListRequest fetches a page of the service logs of a cluster matching the
+// filter conditions the service log API can search on, newest first. A zero page or size uses
+// the API default.
+func sendFilteredClusterLogsListRequest(ocmClient *sdk.Connection, cluster *cmv1.Cluster, filter serviceLogFilter, page int, size int) (*v1.ClustersClusterLogsListResponse, error) {
 	request := ocmClient.ServiceLogs().V1().Clusters().ClusterLogs().List().
 		ClusterID(cluster.ID()).
 		ClusterUUID(cluster.ExternalID()).
 		Parameter("orderBy", "timestamp desc")
-
-	var searchQuery string
-	if !allMessages {
-		searchQuery = "service_name='SREManualAction'"
+	if page > 0 {
+		request.Page(page)
 	}
-	if internalMessages {
-		if searchQuery != "" {
-			searchQuery += " and "
-		}
-		searchQuery += "internal_only='true'"
+	if size > 0 {
+		request.Size(size)
 	}
-	request.Search(searchQuery)
+	request.Search(filter.searchQuery())
 
 	response, err := request.Send()
 	if err != nil {
@@ -134,3 +153,94 @@ func sendClusterLogsListRequest(ocmClient *sdk.Connection, cluster *cmv1.Cluster
 	}
 	return response, nil
 }
+
+// fetchFilteredServiceLogs fetches the service logs of a cluster matching the filter, newest
+// first, following pages until limit entries are found or all pages are read. A zero limit
+// fetches all pages. The total is the number of entries matching the API search, before the
+// summary is matched.
+func fetchFilteredServiceLogs(ocmClient *sdk.Connection, cluster *cmv1.Cluster, filter serviceLogFilter, limit int, pageSize int) (entries []*v1.LogEntry, total int, err error) {
+	for page := 1; ; page++ {
+		response, err := sendFilteredClusterLogsListRequest(ocmClient, cluster, filter, page, pageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		total = response.Total()
+
+		items := response.Items().Slice()
+		for _, entry := range items {
+			if !filter.matches(entry) {
+				continue
+			}
+			entries = append(entries, entry)
+			if limit > 0 && len(entries) >= limit {
+				return entries, total, nil
+			}
+		}
+		if len(items) == 0 || len(items) < response.Size() || page*response.Size() >= total {
+			return entries, total, nil
+		}
+	}
+}
+
+// serviceLogFilter selects service logs. Conditions the service log API can search on are sent
+// with the request, the summary is matched locally.
+type serviceLogFilter struct {
+	// allMessages includes messages of all services, not only SRE ones, when no service is set
+	allMessages  bool
+	internal     bool
+	severities   []string
+	serviceNames []string
+	createdBy    string
+	since        time.Time
+	until        time.Time
+	summary      *regexp.Regexp
+}
+
+// searchQuery returns the search query of the service log API selecting the filtered logs
+func (f serviceLogFilter) searchQuery() string {
+	var conditions []string
+	if len(f.serviceNames) > 0 {
+		conditions = append(conditions, "service_name in ("+quoteSearchValues(f.serviceNames)+")")
+	} else if !f.allMessages {
+		conditions = append(conditions, "service_name='SREManualAction'")
+	}
+	if f.internal {
+		conditions = append(conditions, "internal_only='true'")
+	}
+	if len(f.severities) > 0 {
+		conditions = append(conditions, "severity in ("+quoteSearchValues(f.severities)+")")
+	}
+	if f.createdBy != "" {
+		conditions = append(conditions, "created_by="+quoteSearchValues([]string{f.createdBy}))
+	}
+	if !f.since.IsZero() {
+		conditions = append(conditions, "created_at>="+quoteSearchValues([]string{f.since.UTC().Format(time.RFC3339)}))
+	}
+	if !f.until.IsZero() {
+		conditions = append(conditions, "created_at<"+quoteSearchValues([]string{f.until.UTC().Format(time.RFC3339)}))
+	}
+	return strings.Join(conditions, " and ")
+}
+
+// matches reports whether an entry returned by the API search matches the rest of the filter
+func (f serviceLogFilter) matches(entry *v1.LogEntry) bool {
+	if f.summary != nil && !f.summary.MatchString(entry.Summary()) {
+		return false
+	}
+	if !f.since.IsZero() && entry.CreatedAt().Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !entry.CreatedAt().Before(f.until) {
+		return false
+	}
+	return true
+}
+
+// quoteSearchValues quotes values for a search query, e.g. 'Info', 'Warning'
+func quoteSearchValues(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, "'"+strings.ReplaceAll(value, "'", "''")+"'")
+	}
+	return strings.Join(quoted, ", ")
+}
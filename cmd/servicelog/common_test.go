@@ -1,10 +1,21 @@
 package servicelog
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
 	"github.com/openshift/osdctl/internal/servicelog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestValidateGoodResponse(t *testing.T) {
@@ -224,3 +235,114 @@ func TestValidateBadResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceLogFilterSearchQuery(t *testing.T) {
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		filter   serviceLogFilter
+		expected string
+	}{
+		{
+			name:     "sre messages",
+			filter:   serviceLogFilter{},
+			expected: "service_name='SREManualAction'",
+		},
+		{
+			name:     "all internal messages",
+			filter:   serviceLogFilter{allMessages: true, internal: true},
+			expected: "internal_only='true'",
+		},
+		{
+			name: "all conditions",
+			filter: serviceLogFilter{
+				serviceNames: []string{"SREManualAction", "Cluster Lifecycle"},
+				severities:   []string{"Warning", "Error"},
+				createdBy:    "o'brien",
+				since:        since,
+				until:        since.Add(24 * time.Hour),
+			},
+			expected: "service_name in ('SREManualAction', 'Cluster Lifecycle') and severity in ('Warning', 'Error') and created_by='o''brien' and created_at>='2025-03-01T00:00:00Z' and created_at<'2025-03-02T00:00:00Z'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.searchQuery())
+		})
+	}
+}
+
+func TestServiceLogFilterMatches(t *testing.T) {
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	filter := serviceLogFilter{summary: regexp.MustCompile(`(?i)upgrade`), since: since, until: since.Add(time.Hour)}
+
+	entry := func(summary string, createdAt time.Time) *slv1.LogEntry {
+		e, err := slv1.NewLogEntry().Summary(summary).CreatedAt(createdAt).Build()
+		require.NoError(t, err)
+		return e
+	}
+
+	assert.True(t, filter.matches(entry("Cluster Upgrade scheduled", since)))
+	assert.False(t, filter.matches(entry("Incident resolved", since)))
+	assert.False(t, filter.matches(entry("Upgrade", since.Add(-time.Second))))
+	assert.False(t, filter.matches(entry("Upgrade", since.Add(time.Hour))))
+}
+
+func TestRecentServiceLogs(t *testing.T) {
+	testToken, _ := jwt.New(jwt.SigningMethodHS256).SignedString([]byte("test-secret"))
+	tokenPath := "/fake-path/token" // #nosec G101
+	since := time.Now().Add(-time.Hour).UTC()
+
+	// Two pages of service logs, newest first
+	pages := [][]map[string]interface{}{
+		{
+			{"kind": "LogEntry", "summary": "Other summary", "created_at": since.Add(30 * time.Minute).Format(time.RFC3339)},
+			{"kind": "LogEntry", "summary": "Other summary", "created_at": since.Add(20 * time.Minute).Format(time.RFC3339)},
+		},
+		{
+			{"kind": "LogEntry", "summary": "Action required", "created_at": since.Add(10 * time.Minute).Format(time.RFC3339)},
+		},
+	}
+	var searches []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == tokenPath {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": testToken, "token_type": "Bearer", "expires_in": 3600})
+			return
+		}
+		searches = append(searches, r.URL.Query().Get("search"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"kind":  "ClusterLogList",
+			"page":  page,
+			"size":  2,
+			"total": 3,
+			"items": pages[page-1],
+		})
+	}))
+	defer server.Close()
+
+	conn, err := sdk.NewConnectionBuilder().
+		URL(server.URL).
+		TokenURL(server.URL+tokenPath).
+		Client("fake-id", "fake-secret").
+		Build()
+	require.NoError(t, err)
+	cluster, err := cmv1.NewCluster().ID("cluster-id").ExternalID("cluster-uuid").Build()
+	require.NoError(t, err)
+
+	entries, err := recentServiceLogs(conn, cluster, "SREManualAction", "Action required", since)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Action required", entries[0].Summary())
+	require.Len(t, searches, 2)
+	assert.Contains(t, searches[0], "service_name in ('SREManualAction')")
+	assert.Contains(t, searches[0], "created_at>=")
+
+	searches = nil
+	entries, err = recentServiceLogs(conn, cluster, "", "", since)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Contains(t, searches[0], "service_name='SREManualAction'")
+}
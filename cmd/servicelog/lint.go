@@ -0,0 +1,379 @@
+package servicelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/openshift/osdctl/internal/servicelog"
+	"github.com/openshift/osdctl/internal/utils"
+	"github.com/openshift/osdctl/pkg/link_validator"
+	"github.com/openshift/osdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// Levels of lint findings. Errors fail the lint, warnings are only reported.
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+)
+
+var (
+	// validSeverities are the severities accepted by the service log API
+	validSeverities = []string{"Debug", "Info", "Warning", "Error", "Fatal", "Major", "Critical"}
+	// validServiceNames are the service names SRE may post service logs as
+	validServiceNames = []string{"SREManualAction"}
+	// requiredFields must be set in every template
+	requiredFields = []string{"severity", "service_name", "summary", "description"}
+
+	// placeholderRegex matches template parameters, including malformed ones such as '${}' or an
+	// unterminated '${FOO'
+	placeholderRegex = regexp.MustCompile(`\$\{[^{}\s]*\}?`)
+	// parameterNameRegex matches the name of a well-formed parameter
+	parameterNameRegex = regexp.MustCompile(`^\$\{[A-Za-z_][A-Za-z0-9_]*\}$`)
+)
+
+// lintFinding is a problem found in a template
+type lintFinding struct {
+	Template string `json:"template"`
+	Level    string `json:"level"`
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+type lintOptions struct {
+	templateParams []string
+	product        string
+	skipLinkCheck  bool
+	output         output.Options
+
+	// validateLinks checks the links of a template, it is replaced in tests
+	validateLinks func(text string) ([]link_validator.ValidationResult, error)
+}
+
+func newLintCmd() *cobra.Command {
+	opts := &lintOptions{validateLinks: link_validator.NewLinkValidator().ValidateLinks}
+	cmd := &cobra.Command{
+		Use:   "lint <template|dir>...",
+		Short: "Validate service log templates before posting them",
+		Long: `Validate service log templates before posting them.
+
+  Templates are checked for unknown or mistyped fields, missing required fields, invalid
+  severity and service_name values, malformed or unreplaced parameters, documentation links
+  for another product than the one the template targets, and dead links.
+
+  Directories, e.g. a managed-notifications checkout, are searched for JSON templates. The
+  product of a template is taken from --product, or from an 'osd', 'rosa' or 'hcp' directory
+  in its path.`,
+		Example: `
+  # Lint a template
+  osdctl servicelog lint ~/managed-notifications/osd/incident_resolved.json
+
+  # Check that no parameter is left unreplaced when posting with these parameters
+  osdctl servicelog lint osd/incident_resolved.json -p ALERT_NAME=alert
+
+  # Lint all templates of a managed-notifications checkout without checking links
+  osdctl servicelog lint ~/managed-notifications --skip-link-check -o json
+`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(args)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&opts.templateParams, "param", "p", nil, "Specify a key-value pair (eg. -p FOO=BAR) to check the template is complete with these parameters.")
+	cmd.Flags().StringVar(&opts.product, "product", "", "Product the templates are sent to (osd, rosa), instead of guessing it from their path.")
+	cmd.Flags().BoolVar(&opts.skipLinkCheck, "skip-link-check", false, "Skip validating if links in the templates are valid")
+	opts.output.AddFlags(cmd)
+
+	return cmd
+}
+
+func (o *lintOptions) run(paths []string) error {
+	if err := o.output.Validate(); err != nil {
+		return err
+	}
+	params, err := parseTemplateParams(o.templateParams)
+	if err != nil {
+		return err
+	}
+
+	templates, err := findTemplates(paths)
+	if err != nil {
+		return err
+	}
+
+	findings := []lintFinding{}
+	failed := 0
+	for _, template := range templates {
+		data, err := readLintTemplate(template)
+		if err != nil {
+			return err
+		}
+		templateFindings := o.lint(template, data, params)
+		for _, finding := range templateFindings {
+			if finding.Level == lintError {
+				failed++
+				break
+			}
+		}
+		findings = append(findings, templateFindings...)
+	}
+
+	columns := []output.Column{{Name: "TEMPLATE"}, {Name: "LEVEL"}, {Name: "FIELD"}, {Name: "MESSAGE"}}
+	result := output.NewTable(findings, columns, func(f lintFinding) []string {
+		return []string{f.Template, f.Level, f.Field, f.Message}
+	})
+	if len(findings) == 0 && !o.output.IsStructured() {
+		result = output.NewObject(findings, fmt.Sprintf("%d template(s) linted, no problems found", len(templates)))
+	}
+	if err := o.output.Print(os.Stdout, result); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d template(s) failed linting", failed, len(templates))
+	}
+	return nil
+}
+
+// lint returns the problems found in a template. When params are given, the template must use
+// all of them and have no other parameter left once they are replaced.
+func (o *lintOptions) lint(template string, data []byte, params map[string]string) []lintFinding {
+	var findings []lintFinding
+	report := func(level, field, format string, args ...interface{}) {
+		findings = append(findings, lintFinding{Template: template, Level: level, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	message, schemaErrors := checkTemplateSchema(data)
+	for _, err := range schemaErrors {
+		report(lintError, err.field, "%s", err.message)
+	}
+	if message == nil {
+		return findings
+	}
+
+	if message.Severity != "" && !slices.Contains(validSeverities, message.Severity) {
+		report(lintError, "severity", "invalid severity %q, valid values are: %s", message.Severity, strings.Join(validSeverities, ", "))
+	}
+	if message.ServiceName != "" && !slices.Contains(validServiceNames, message.ServiceName) {
+		report(lintError, "service_name", "invalid service_name %q, valid values are: %s", message.ServiceName, strings.Join(validServiceNames, ", "))
+	}
+
+	for _, field := range templateTextFields(message) {
+		for _, placeholder := range placeholderRegex.FindAllString(field.value, -1) {
+			if !parameterNameRegex.MatchString(placeholder) {
+				report(lintError, field.name, "malformed parameter %q, parameters are written as ${NAME}", placeholder)
+			}
+		}
+	}
+
+	if len(params) > 0 {
+		names := make([]string, 0, len(params))
+		for name := range params {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if !message.SearchFlag(name) {
+				report(lintWarning, "", "parameter %s is not used by the template", name)
+			}
+			message.ReplaceWithFlag(name, params[name])
+		}
+		leftovers, _ := message.FindLeftovers()
+		slices.Sort(leftovers)
+		for _, leftover := range slices.Compact(leftovers) {
+			// ${CLUSTER_UUID} is replaced for each cluster when posting
+			if leftover != "${CLUSTER_UUID}" && parameterNameRegex.MatchString(leftover) {
+				report(lintError, "", "parameter %s is not replaced, use '-p %s=\"FOOBAR\"'", leftover, strings.Trim(leftover, "${}"))
+			}
+		}
+	}
+
+	if product := o.templateProduct(template); product != "" {
+		if docProduct := getDocClusterType(message.Description); docProduct != "" && docProduct != product {
+			report(lintWarning, "description", "documentation link is for '%s' while the template is for '%s'", docProduct, product)
+		}
+		for _, reference := range message.DocReferences {
+			if docProduct := getDocClusterType(reference); docProduct != "" && docProduct != product {
+				report(lintWarning, "doc_references", "documentation link %s is for '%s' while the template is for '%s'", reference, docProduct, product)
+			}
+		}
+	}
+
+	if !o.skipLinkCheck {
+		text := strings.Join(append([]string{message.Summary, message.Description}, message.DocReferences...), " ")
+		warnings, err := o.validateLinks(text)
+		if err != nil {
+			report(lintError, "", "%v", err)
+		}
+		for _, warning := range warnings {
+			report(lintWarning, "", "link %s: %v", warning.URL, warning.Warning)
+		}
+	}
+
+	return findings
+}
+
+// templateProduct returns the product a template is sent to, either given with --product or
+// guessed from the directories of its path
+func (o *lintOptions) templateProduct(template string) string {
+	if o.product != "" {
+		return o.product
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(template)), "/") {
+		switch dir {
+		case "osd":
+			return "osd"
+		// HCP documentation is part of the ROSA documentation
+		case "rosa", "hcp":
+			return "rosa"
+		}
+	}
+	return ""
+}
+
+type schemaError struct {
+	field   string
+	message string
+}
+
+// checkTemplateSchema decodes a template into a service log message, returning the message and
+// the fields which are unknown, of the wrong type or missing. The message is nil when the
+// template can't be decoded at all.
+func checkTemplateSchema(data []byte) (*servicelog.Message, []schemaError) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, []schemaError{{message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+
+	var errs []schemaError
+	known := messageFields()
+	for field := range fields {
+		if !slices.Contains(known, field) {
+			errs = append(errs, schemaError{field: field, message: fmt.Sprintf("unknown field %q", field)})
+		}
+	}
+
+	message := &servicelog.Message{}
+	for _, field := range known {
+		value, ok := fields[field]
+		if !ok {
+			continue
+		}
+		// Decode fields one at a time to report every mistyped field, not only the first one
+		if err := json.Unmarshal(bytes.Join([][]byte{[]byte(`{"` + field + `":`), value, []byte(`}`)}, nil), message); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				errs = append(errs, schemaError{field: field, message: fmt.Sprintf("expected a %s, got a JSON %s", typeErr.Type, typeErr.Value)})
+			} else {
+				errs = append(errs, schemaError{field: field, message: err.Error()})
+			}
+		}
+	}
+
+	for _, field := range requiredFields {
+		if value, ok := fields[field]; !ok || string(value) == `""` || string(value) == "null" {
+			errs = append(errs, schemaError{field: field, message: "required field is missing or empty"})
+		}
+	}
+
+	slices.SortStableFunc(errs, func(a, b schemaError) int { return strings.Compare(a.field, b.field) })
+	return message, errs
+}
+
+// messageFields returns the JSON names of the service log message fields
+func messageFields() []string {
+	var fields []string
+	t := reflect.TypeOf(servicelog.Message{})
+	for i := 0; i < t.NumField(); i++ {
+		fields = append(fields, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	return fields
+}
+
+type textField struct {
+	name  string
+	value string
+}
+
+// templateTextFields returns the text fields of a message which may hold parameters
+func templateTextFields(m *servicelog.Message) []textField {
+	return []textField{
+		{"severity", m.Severity},
+		{"service_name", m.ServiceName},
+		{"cluster_uuid", m.ClusterUUID},
+		{"cluster_id", m.ClusterID},
+		{"summary", m.Summary},
+		{"description", m.Description},
+		{"event_stream_id", m.EventStreamID},
+		{"subscription_id", m.SubscriptionID},
+	}
+}
+
+// parseTemplateParams parses '-p FOO=BAR' flags into a map of placeholders to values
+func parseTemplateParams(templateParams []string) (map[string]string, error) {
+	params := map[string]string{}
+	for _, v := range templateParams {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("wrong syntax of '-p' flag. Please use it like this: '-p FOO=BAR'")
+		}
+		params[fmt.Sprintf("${%v}", name)] = value
+	}
+	return params, nil
+}
+
+// findTemplates expands directories into the JSON files they contain, skipping hidden
+// directories such as .git. Other paths are returned as is.
+func findTemplates(paths []string) ([]string, error) {
+	var templates []string
+	for _, path := range paths {
+		if !utils.FolderExists(path) {
+			templates = append(templates, path)
+			continue
+		}
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && p != path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && filepath.Ext(p) == ".json" {
+				templates = append(templates, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot search %s for templates: %w", path, err)
+		}
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("no templates found in %s", strings.Join(paths, ", "))
+	}
+	return templates, nil
+}
+
+// readLintTemplate reads a template from a file, a URL or the local template library
+func readLintTemplate(template string) ([]byte, error) {
+	data, err := (&PostCmdOptions{}).accessFile(template)
+	if err == nil {
+		return data, nil
+	}
+	if library, libErr := newTemplateLibrary(); libErr == nil {
+		if data, libErr := library.Read(template); libErr == nil {
+			return data, nil
+		}
+	}
+	return nil, err
+}
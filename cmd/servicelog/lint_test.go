@@ -0,0 +1,112 @@
+package servicelog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/osdctl/pkg/link_validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	noLinks := func(string) ([]link_validator.ValidationResult, error) { return nil, nil }
+
+	tests := []struct {
+		name          string
+		template      string
+		data          string
+		params        map[string]string
+		validateLinks func(string) ([]link_validator.ValidationResult, error)
+		expected      []lintFinding
+	}{
+		{
+			name:     "valid template",
+			template: "osd/valid.json",
+			data:     `{"severity": "Info", "service_name": "SREManualAction", "summary": "Hello", "description": "See https://docs.openshift.com/dedicated/welcome/index.html for ${ALERT_NAME}", "internal_only": false}`,
+		},
+		{
+			name:     "invalid JSON",
+			template: "broken.json",
+			data:     `{"severity": "Info",`,
+			expected: []lintFinding{{Template: "broken.json", Level: lintError, Message: "invalid JSON: unexpected end of JSON input"}},
+		},
+		{
+			name:     "schema and enum errors",
+			template: "bad.json",
+			data:     `{"severity": "Urgent", "service_name": "SREManualAction", "sumary": "typo", "description": "d", "internal_only": "yes"}`,
+			expected: []lintFinding{
+				{Template: "bad.json", Level: lintError, Field: "internal_only", Message: "expected a bool, got a JSON string"},
+				{Template: "bad.json", Level: lintError, Field: "sumary", Message: `unknown field "sumary"`},
+				{Template: "bad.json", Level: lintError, Field: "summary", Message: "required field is missing or empty"},
+				{Template: "bad.json", Level: lintError, Field: "severity", Message: `invalid severity "Urgent", valid values are: Debug, Info, Warning, Error, Fatal, Major, Critical`},
+			},
+		},
+		{
+			name:     "malformed and unreplaced parameters",
+			template: "params.json",
+			data:     `{"severity": "Info", "service_name": "SREManualAction", "summary": "${NAME} on ${CLUSTER_UUID}", "description": "${REASON} ${} ${REASON}"}`,
+			params:   map[string]string{"${NAME}": "foo", "${UNUSED}": "bar"},
+			expected: []lintFinding{
+				{Template: "params.json", Level: lintError, Field: "description", Message: `malformed parameter "${}", parameters are written as ${NAME}`},
+				{Template: "params.json", Level: lintWarning, Message: "parameter ${UNUSED} is not used by the template"},
+				{Template: "params.json", Level: lintError, Message: `parameter ${REASON} is not replaced, use '-p REASON="FOOBAR"'`},
+			},
+		},
+		{
+			name:     "documentation for another product",
+			template: "rosa/doc.json",
+			data:     `{"severity": "Info", "service_name": "SREManualAction", "summary": "s", "description": "See https://docs.openshift.com/dedicated/index.html", "doc_references": ["https://docs.openshift.com/rosa/index.html"]}`,
+			expected: []lintFinding{
+				{Template: "rosa/doc.json", Level: lintWarning, Field: "description", Message: "documentation link is for 'osd' while the template is for 'rosa'"},
+			},
+		},
+		{
+			name:     "dead link",
+			template: "link.json",
+			data:     `{"severity": "Info", "service_name": "SREManualAction", "summary": "s", "description": "See https://example.com/gone"}`,
+			validateLinks: func(string) ([]link_validator.ValidationResult, error) {
+				return []link_validator.ValidationResult{{URL: "https://example.com/slow", Warning: errors.New("HTTP 503")}}, errors.New("dead link: https://example.com/gone (HTTP 404)")
+			},
+			expected: []lintFinding{
+				{Template: "link.json", Level: lintError, Message: "dead link: https://example.com/gone (HTTP 404)"},
+				{Template: "link.json", Level: lintWarning, Message: "link https://example.com/slow: HTTP 503"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &lintOptions{validateLinks: noLinks}
+			if tt.validateLinks != nil {
+				opts.validateLinks = tt.validateLinks
+			}
+			assert.Equal(t, tt.expected, opts.lint(tt.template, []byte(tt.data), tt.params))
+		})
+	}
+}
+
+func TestTemplateProduct(t *testing.T) {
+	assert.Equal(t, "osd", (&lintOptions{}).templateProduct("managed-notifications/osd/a.json"))
+	assert.Equal(t, "rosa", (&lintOptions{}).templateProduct("hcp/a.json"))
+	assert.Equal(t, "", (&lintOptions{}).templateProduct("a.json"))
+	assert.Equal(t, "rosa", (&lintOptions{product: "rosa"}).templateProduct("osd/a.json"))
+}
+
+func TestFindTemplates(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"osd/a.json", "rosa/b.json", "README.md", ".git/c.json"} {
+		path := filepath.Join(dir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("{}"), 0600))
+	}
+
+	templates, err := findTemplates([]string{dir, "https://example.com/d.json"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "osd/a.json"), filepath.Join(dir, "rosa/b.json"), "https://example.com/d.json"}, templates)
+
+	_, err = findTemplates([]string{t.TempDir()})
+	assert.ErrorContains(t, err, "no templates found")
+}
@@ -1,26 +1,43 @@
 package servicelog
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"regexp"
 	"slices"
+	"strings"
 	"time"
 
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
-
-	"github.com/openshift-online/ocm-cli/pkg/dump"
+	"github.com/openshift/osdctl/pkg/output"
+	"github.com/openshift/osdctl/pkg/utils"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 type listCmdOptions struct {
-	allMessages bool
-	internal    bool
-	clusterID   string
+	allMessages  bool
+	internal     bool
+	clusterID    string
+	severities   []string
+	serviceNames []string
+	summary      string
+	createdBy    string
+	since        string
+	until        string
+	limit        int
+	pageSize     int
+	follow       bool
+	interval     time.Duration
+	output       output.Options
 }
 
 func newListCmd() *cobra.Command {
-	opts := &listCmdOptions{}
+	opts := &listCmdOptions{output: output.Options{Format: output.JSON}}
 	cmd := &cobra.Command{
 		Use:   "list --cluster-id <cluster-identifier> [flags] [options]",
 		Long:  "Get service logs for a given cluster identifier.",
@@ -32,7 +49,16 @@ func newListCmd() *cobra.Command {
   osdctl servicelog list --cluster-id ${CLUSTER_ID} --all-messages
 
   # List all service logs including internal
-  osdctl servicelog list --cluster-id ${CLUSTER_ID} --all-messages --internal`,
+  osdctl servicelog list --cluster-id ${CLUSTER_ID} --all-messages --internal
+
+  # List the warnings and errors of the last week in a table
+  osdctl servicelog list --cluster-id ${CLUSTER_ID} -A --severity Warning,Error --since 168h -o table
+
+  # List the service logs about upgrades sent in March
+  osdctl servicelog list --cluster-id ${CLUSTER_ID} -A --summary '(?i)upgrade' --since 2025-03-01 --until 2025-04-01
+
+  # Follow the service logs sent to a cluster
+  osdctl servicelog list --cluster-id ${CLUSTER_ID} -A --follow -o table`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return listServiceLogs(opts.clusterID, opts)
@@ -42,41 +68,242 @@ func newListCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(&opts.allMessages, "all-messages", "A", false, "Toggle if we should see all of the messages or only SRE-P specific ones")
 	cmd.Flags().BoolVarP(&opts.internal, "internal", "i", false, "Toggle if we should see internal messages")
 	cmd.Flags().StringVarP(&opts.clusterID, "cluster-id", "C", "", "Internal Cluster identifier (required)")
+	cmd.Flags().StringSliceVar(&opts.severities, "severity", nil, "Only list service logs of these severities, e.g. Warning,Error")
+	cmd.Flags().StringSliceVar(&opts.serviceNames, "service-name", nil, "Only list service logs of these services, instead of SRE ones or all of them with --all-messages")
+	cmd.Flags().StringVar(&opts.summary, "summary", "", "Only list service logs whose summary matches this regular expression")
+	cmd.Flags().StringVar(&opts.createdBy, "created-by", "", "Only list service logs created by this user")
+	cmd.Flags().StringVar(&opts.since, "since", "", "Only list service logs created since this time, either a duration ago (e.g. 24h) or a date (2006-01-02 or RFC3339)")
+	cmd.Flags().StringVar(&opts.until, "until", "", "Only list service logs created before this time, either a duration ago (e.g. 24h) or a date (2006-01-02 or RFC3339)")
+	cmd.Flags().IntVar(&opts.limit, "limit", 0, "Maximum number of service logs to list, the most recent ones. 0 lists all of them")
+	cmd.Flags().IntVar(&opts.pageSize, "page-size", 100, "Number of service logs fetched per request")
+	cmd.Flags().BoolVarP(&opts.follow, "follow", "f", false, "Keep polling for new service logs until interrupted")
+	cmd.Flags().DurationVar(&opts.interval, "interval", 30*time.Second, "Polling interval of --follow")
+	opts.output.AddFlags(cmd)
 	_ = cmd.MarkFlagRequired("cluster-id")
 
 	return cmd
 }
 
+// filter returns the service log filter selected by the flags
+func (o *listCmdOptions) filter(now time.Time) (serviceLogFilter, error) {
+	filter := serviceLogFilter{
+		allMessages:  o.allMessages,
+		internal:     o.internal,
+		severities:   o.severities,
+		serviceNames: o.serviceNames,
+		createdBy:    o.createdBy,
+	}
+
+	var err error
+	if o.summary != "" {
+		if filter.summary, err = regexp.Compile(o.summary); err != nil {
+			return filter, fmt.Errorf("invalid --summary regular expression: %w", err)
+		}
+	}
+	if o.since != "" {
+		if filter.since, err = parseTimeFlag(o.since, now); err != nil {
+			return filter, fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if o.until != "" {
+		if filter.until, err = parseTimeFlag(o.until, now); err != nil {
+			return filter, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+	if !filter.since.IsZero() && !filter.until.IsZero() && !filter.since.Before(filter.until) {
+		return filter, fmt.Errorf("--since must be before --until")
+	}
+	return filter, nil
+}
+
+// parseTimeFlag parses a duration before now, such as 24h, or a date in the 2006-01-02 or
+// RFC3339 formats
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		if duration < 0 {
+			return time.Time{}, fmt.Errorf("duration must be positive, got %q", value)
+		}
+		return now.Add(-duration), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a duration nor a date", value)
+}
+
+func (o *listCmdOptions) validate() error {
+	if o.limit < 0 {
+		return fmt.Errorf("--limit can't be negative")
+	}
+	if o.pageSize < 1 {
+		return fmt.Errorf("--page-size must be at least 1")
+	}
+	if o.follow && o.interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	if o.follow && o.until != "" {
+		return fmt.Errorf("--follow can't be used with --until")
+	}
+	return o.output.Validate()
+}
+
 func listServiceLogs(clusterID string, opts *listCmdOptions) error {
-	response, err := FetchServiceLogs(clusterID, opts.allMessages, opts.internal)
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	filter, err := opts.filter(time.Now())
+	if err != nil {
+		return err
+	}
+
+	ocmClient, err := utils.CreateConnection()
 	if err != nil {
-		return fmt.Errorf("failed to fetch service logs: %w", err)
+		return err
+	}
+	defer func() {
+		if err := ocmClient.Close(); err != nil {
+			fmt.Printf("Cannot close the ocmClient (possible memory leak): %q", err)
+		}
+	}()
+
+	clusters := utils.GetClusters(ocmClient, []string{clusterID})
+	if len(clusters) != 1 {
+		return fmt.Errorf("GetClusters expected to return 1 cluster, got: %d", len(clusters))
+	}
+	cluster := clusters[0]
+
+	entries, total, err := fetchFilteredServiceLogs(ocmClient, cluster, filter, opts.limit, opts.pageSize)
+	if err != nil {
+		return fmt.Errorf("failed to fetch service logs for cluster %v: %w", clusterID, err)
 	}
 
-	if err = printServiceLogResponse(response); err != nil {
+	if err = opts.printServiceLogs(entries, total); err != nil {
 		return fmt.Errorf("failed to print service logs: %w", err)
 	}
 
+	if opts.follow {
+		return opts.followServiceLogs(ocmClient, cluster, filter, entries)
+	}
 	return nil
 }
 
-func printServiceLogResponse(response *slv1.ClustersClusterLogsListResponse) error {
-	entryViews := logEntryToView(response.Items().Slice())
+// followServiceLogs polls for service logs created after the ones already listed and prints
+// them until interrupted
+func (o *listCmdOptions) followServiceLogs(ocmClient *sdk.Connection, cluster *cmv1.Cluster, filter serviceLogFilter, listed []*slv1.LogEntry) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	seen := map[string]bool{}
+	for _, entry := range listed {
+		seen[entry.ID()] = true
+		if entry.CreatedAt().After(filter.since) {
+			filter.since = entry.CreatedAt()
+		}
+	}
+	if filter.since.IsZero() {
+		filter.since = time.Now()
+	}
+
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		entries, _, err := fetchFilteredServiceLogs(ocmClient, cluster, filter, 0, o.pageSize)
+		if err != nil {
+			log.Warnf("Cannot fetch new service logs, retrying in %v: %v", o.interval, err)
+			continue
+		}
+
+		var newEntries []*slv1.LogEntry
+		for _, entry := range entries {
+			if seen[entry.ID()] {
+				continue
+			}
+			seen[entry.ID()] = true
+			newEntries = append(newEntries, entry)
+			// Entries created in the same second as the latest one are fetched again, and
+			// skipped as already seen
+			if entry.CreatedAt().After(filter.since) {
+				filter.since = entry.CreatedAt()
+			}
+		}
+		if err := o.printNewServiceLogs(newEntries); err != nil {
+			return fmt.Errorf("failed to print service logs: %w", err)
+		}
+	}
+}
+
+// printServiceLogs prints the service logs, oldest first. The json and yaml formats print the
+// LogEntryResponseView, the table formats one row per service log.
+func (o *listCmdOptions) printServiceLogs(entries []*slv1.LogEntry, total int) error {
+	entryViews := logEntryToView(entries)
 	slices.Reverse(entryViews)
 	view := LogEntryResponseView{
 		Items: entryViews,
 		Kind:  "ClusterLogList",
-		Page:  response.Page(),
-		Size:  response.Size(),
-		Total: response.Total(),
+		Page:  1,
+		Size:  len(entryViews),
+		Total: total,
 	}
 
-	viewBytes, err := json.Marshal(view)
-	if err != nil {
-		return fmt.Errorf("failed to marshal response for output: %w", err)
+	table := serviceLogTable(entryViews)
+	return o.output.Print(os.Stdout, &output.Result{Object: view, Columns: table.Columns, Rows: table.Rows})
+}
+
+// printNewServiceLogs prints service logs found while following, oldest first: as table rows
+// without headers, or one object per service log in the structured formats
+func (o *listCmdOptions) printNewServiceLogs(entries []*slv1.LogEntry) error {
+	entryViews := logEntryToView(entries)
+	slices.Reverse(entryViews)
+
+	if o.output.IsStructured() {
+		for _, entryView := range entryViews {
+			if err := o.output.Print(os.Stdout, output.NewObject(entryView, "")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(entryViews) == 0 {
+		return nil
 	}
+	rowOptions := o.output
+	rowOptions.NoHeaders = true
+	return rowOptions.Print(os.Stdout, serviceLogTable(entryViews))
+}
 
-	return dump.Pretty(os.Stdout, viewBytes)
+// serviceLogTable returns the table view of service logs
+func serviceLogTable(entryViews []*LogEntryView) *output.Result {
+	columns := []output.Column{
+		{Name: "CREATED"},
+		{Name: "SEVERITY"},
+		{Name: "SERVICE"},
+		{Name: "CREATED BY"},
+		{Name: "SUMMARY"},
+		{Name: "ID", Wide: true},
+		{Name: "INTERNAL", Wide: true},
+		{Name: "DESCRIPTION", Wide: true},
+	}
+	return output.NewTable(entryViews, columns, func(e *LogEntryView) []string {
+		return []string{
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			e.Severity,
+			e.ServiceName,
+			e.CreatedBy,
+			e.Summary,
+			e.ID,
+			fmt.Sprint(e.InternalOnly),
+			strings.ReplaceAll(e.Description, "\n", " "),
+		}
+	})
 }
 
 type LogEntryResponseView struct {
@@ -0,0 +1,52 @@
+package servicelog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Time
+		err      string
+	}{
+		{value: "24h", expected: now.Add(-24 * time.Hour)},
+		{value: "2025-03-01", expected: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-03-01T08:30:00Z", expected: time.Date(2025, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{value: "-1h", err: "duration must be positive"},
+		{value: "yesterday", err: "neither a duration nor a date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			parsed, err := parseTimeFlag(tt.value, now)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(parsed), "expected %v, got %v", tt.expected, parsed)
+		})
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	opts := &listCmdOptions{allMessages: true, severities: []string{"Error"}, summary: "^Upgrade", since: "48h", until: "24h"}
+	filter, err := opts.filter(now)
+	require.NoError(t, err)
+	assert.Equal(t, "severity in ('Error') and created_at>='2025-03-08T12:00:00Z' and created_at<'2025-03-09T12:00:00Z'", filter.searchQuery())
+	assert.True(t, filter.summary.MatchString("Upgrade scheduled"))
+
+	_, err = (&listCmdOptions{since: "24h", until: "48h"}).filter(now)
+	assert.EqualError(t, err, "--since must be before --until")
+
+	_, err = (&listCmdOptions{summary: "("}).filter(now)
+	assert.ErrorContains(t, err, "invalid --summary regular expression")
+}
@@ -1,6 +1,7 @@
 package servicelog
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -47,9 +48,17 @@ type PostCmdOptions struct {
 	ClusterId       string
 	SkipLinkCheck   bool
 
+	// Bulk posting
+	workers         int
+	rateLimit       float64
+	journalPath     string
+	resumeJournal   string
+	duplicateWindow time.Duration
+
 	// Messaged clusters
 	successfulClusters map[string]string
 	failedClusters     map[string]string
+	skippedClusters    map[string]string
 }
 
 const documentationBaseURL = "https://docs.openshift.com"
@@ -69,6 +78,9 @@ func newPostCmd() *cobra.Command {
   # Post a service log to a single cluster via a remote URL, providing a parameter
   osdctl servicelog post --cluster-id ${CLUSTER_ID} -t https://raw.githubusercontent.com/openshift/managed-notifications/master/osd/incident_resolved.json -p ALERT_NAME="alert"
 
+  # Post a template of the local template library, see 'osdctl servicelog templates'
+  osdctl servicelog post --cluster-id ${CLUSTER_ID} -t osd/incident_resolved.json -p ALERT_NAME="alert"
+
   # Post an internal-only service log message
   osdctl servicelog post --cluster-id ${CLUSTER_ID} -i -p "MESSAGE=This is an internal message"
 
@@ -78,6 +90,12 @@ func newPostCmd() *cobra.Command {
   # Post a service log to a group of clusters, determined by an OCM query
   ocm list cluster -p search="cloud_provider.id is 'gcp' and managed='true' and state is 'ready'"
   osdctl servicelog post -q "cloud_provider.id is 'gcp' and managed='true' and state is 'ready'" -t file.json
+
+  # Post to a list of clusters with 4 workers, skipping clusters which received the same summary in the last day
+  osdctl servicelog post -c clusters.json -t file.json --workers 4 --rate 2 --skip-duplicates-within 24h
+
+  # Resume an interrupted bulk post, skipping the clusters already posted to
+  osdctl servicelog post -c clusters.json -t file.json --resume ~/.cache/osdctl/servicelog/journals/post-20250101T120000Z.jsonl
 `,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -87,7 +105,7 @@ func newPostCmd() *cobra.Command {
 
 	// define flags
 	postCmd.Flags().StringVarP(&opts.ClusterId, "cluster-id", "C", "", "Internal ID of the cluster to post the service log to")
-	postCmd.Flags().StringVarP(&opts.Template, "template", "t", "", "Message template file or URL")
+	postCmd.Flags().StringVarP(&opts.Template, "template", "t", "", "Message template file, URL or path in the local template library")
 	postCmd.Flags().StringArrayVarP(&opts.TemplateParams, "param", "p", opts.TemplateParams, "Specify a key-value pair (eg. -p FOO=BAR) to set/override a parameter value in the template.")
 	postCmd.Flags().StringArrayVarP(&opts.Overrides, "override", "r", opts.Overrides, "Specify a key-value pair (eg. -r FOO=BAR) to replace a JSON key in the document, only supports string fields, specifying -r without -t or -i will use a default template with severity `Info` and internal_only=True unless these are also overridden.")
 	postCmd.Flags().BoolVarP(&opts.isDryRun, "dry-run", "d", false, "Dry-run - print the service log about to be sent but don't send it.")
@@ -97,6 +115,11 @@ func newPostCmd() *cobra.Command {
 	postCmd.Flags().StringVarP(&opts.clustersFile, "clusters-file", "c", "", `Read a list of clusters to post the servicelog to. the format of the file is: {"clusters":["$CLUSTERID"]}`)
 	postCmd.Flags().BoolVarP(&opts.InternalOnly, "internal", "i", false, "Internal only service log. Use MESSAGE for template parameter (eg. -p MESSAGE='My super secret message').")
 	postCmd.Flags().BoolVar(&opts.SkipLinkCheck, "skip-link-check", false, "Skip validating if links in Service Log are valid")
+	postCmd.Flags().IntVar(&opts.workers, "workers", 1, "Number of clusters to post to in parallel.")
+	postCmd.Flags().Float64Var(&opts.rateLimit, "rate", 5, "Maximum number of service logs posted per second, 0 for no limit.")
+	postCmd.Flags().StringVar(&opts.journalPath, "journal", "", "JSONL file recording the result of every cluster. Defaults to a new file in the osdctl cache directory.")
+	postCmd.Flags().StringVar(&opts.resumeJournal, "resume", "", "Journal of a previous post to resume: clusters which already received the service log are skipped, and results are appended to it.")
+	postCmd.Flags().DurationVar(&opts.duplicateWindow, "skip-duplicates-within", 0, "Skip clusters which received a service log with the same summary within this duration, e.g. 24h.")
 
 	return postCmd
 }
@@ -106,6 +129,7 @@ func (o *PostCmdOptions) Init() error {
 	userParameterValues = []string{}
 	o.successfulClusters = make(map[string]string)
 	o.failedClusters = make(map[string]string)
+	o.skippedClusters = make(map[string]string)
 	return nil
 }
 
@@ -113,13 +137,22 @@ func (o *PostCmdOptions) Validate() error {
 	if o.ClusterId == "" && len(o.filterParams) == 0 && o.clustersFile == "" && len(o.filterFiles) == 0 {
 		return fmt.Errorf("no cluster identifier has been found, please specify --cluster-id, -q, -c or -f")
 	}
+	if o.workers < 0 {
+		return fmt.Errorf("--workers can't be negative")
+	}
+	if o.rateLimit < 0 {
+		return fmt.Errorf("--rate can't be negative")
+	}
+	if o.journalPath != "" && o.resumeJournal != "" {
+		return fmt.Errorf("--journal and --resume can't be used together, results are appended to the resumed journal")
+	}
 	return nil
 }
 
-// CheckServiceLogsLastHour returns true if there were servicelogs sent in the past hour, otherwise false
-func CheckServiceLogsLastHour(clusterId string) bool {
-	timeStampToCompare := time.Now().Add(-time.Hour)
-	serviceLogs, err := GetServiceLogsSince(clusterId, timeStampToCompare, false, false)
+// checkServiceLogsLastHour returns true if the cluster received service logs of the service of the
+// message in the past hour, otherwise false
+func (o *PostCmdOptions) checkServiceLogsLastHour(ocmClient *sdk.Connection, cluster *v1.Cluster) bool {
+	serviceLogs, err := recentServiceLogs(ocmClient, cluster, o.Message.ServiceName, "", time.Now().Add(-time.Hour))
 	if err != nil {
 		log.Warnf("please verify that you are not sending a duplicate service log that has been recently sent - failed to fetch recent service logs: %v", err)
 		return true
@@ -220,6 +253,14 @@ func (o *PostCmdOptions) Run() error {
 		return fmt.Errorf("no clusters match the given filters (%v)", o.filterParams)
 	}
 
+	clusters, err = o.skipResumedClusters(clusters)
+	if err != nil {
+		return err
+	} else if len(clusters) < 1 {
+		log.Infoln("All clusters matching the given filters were already handled")
+		return nil
+	}
+
 	log.Infoln("The following clusters match the given parameters:")
 	if err := o.printClusters(clusters); err != nil {
 		return fmt.Errorf("could not print matching clusters: %v", err)
@@ -228,7 +269,7 @@ func (o *PostCmdOptions) Run() error {
 	// If sending a service log to one cluster, print recent service logs so that we can verify we aren't sending
 	// duplicate messages in quick succession
 	if len(clusters) == 1 {
-		if term.IsTerminal(int(os.Stdout.Fd())) && CheckServiceLogsLastHour(clusters[0].ID()) {
+		if term.IsTerminal(int(os.Stdout.Fd())) && o.checkServiceLogsLastHour(ocmClient, clusters[0]) {
 			if !ocmutils.ConfirmPrompt() {
 				return nil
 			}
@@ -265,30 +306,13 @@ func (o *PostCmdOptions) Run() error {
 		}
 	}
 
-	// Handler if the program terminates abruptly
-	go func() {
-		sigchan := make(chan os.Signal, 1)
-		signal.Notify(sigchan, os.Interrupt)
-		<-sigchan
-
-		// perform final cleanup actions
-		log.Error("program abruptly terminated, performing clean-up...")
-		o.cleanUp(clusters)
-		log.Fatal("servicelog post command terminated")
-	}()
-
 	// cluster type for which documentation link is provided in servicelog description
 	docClusterType := getDocClusterType(o.Message.Description)
 
+	// if servicelog description contains a documentation link, verify that
+	// documentation link matches the cluster product (rosa, dedicated)
+	var targets []*v1.Cluster
 	for _, cluster := range clusters {
-		request, err := o.createPostRequest(ocmClient, cluster)
-		if err != nil {
-			o.failedClusters[cluster.ExternalID()] = err.Error()
-			continue
-		}
-
-		// if servicelog description contains a documentation link, verify that
-		// documentation link matches the cluster product (rosa, dedicated)
 		if !o.skipPrompts && docClusterType != "" {
 			clusterType := cluster.Product().ID()
 
@@ -300,20 +324,140 @@ func (o *PostCmdOptions) Run() error {
 				}
 			}
 		}
+		targets = append(targets, cluster)
+	}
 
-		response, err := ocmutils.SendRequest(request)
-		if err != nil {
-			o.failedClusters[cluster.ExternalID()] = err.Error()
-			continue
-		}
+	journal, err := o.openJournal()
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+	log.Infof("Recording the result of every cluster in %s", journal.path)
+
+	// Stop posting on interrupt, the clusters already handled are in the journal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-		o.check(response, o.Message)
+	poster := &bulkPoster{
+		workers: o.workers,
+		rate:    o.rateLimit,
+		post: func(ctx context.Context, cluster *v1.Cluster) journalEntry {
+			return o.postToCluster(ocmClient, cluster)
+		},
+		record: func(entry journalEntry) {
+			o.recordOutcome(entry)
+			if err := journal.Record(entry); err != nil {
+				log.Errorf("Cannot record the result of cluster %s in the journal: %v", entry.ClusterID, err)
+			}
+		},
+	}
+	poster.Run(ctx, targets)
+
+	if ctx.Err() != nil {
+		o.cleanUp(targets)
+		return fmt.Errorf("interrupted, resume with --resume %s", journal.path)
 	}
 
 	o.printPostOutput()
+	if len(o.failedClusters) > 0 {
+		log.Infof("Retry the failed clusters with '--resume %s'", journal.path)
+	}
 	return nil
 }
 
+// openJournal opens the journal to resume, the one given with --journal or a new one
+func (o *PostCmdOptions) openJournal() (*postJournal, error) {
+	path := o.journalPath
+	if o.resumeJournal != "" {
+		path = o.resumeJournal
+	}
+	if path == "" {
+		var err error
+		if path, err = defaultJournalPath(); err != nil {
+			return nil, err
+		}
+	}
+	return openPostJournal(path)
+}
+
+// skipResumedClusters removes the clusters which already received the service log according
+// to the journal being resumed
+func (o *PostCmdOptions) skipResumedClusters(clusters []*v1.Cluster) ([]*v1.Cluster, error) {
+	if o.resumeJournal == "" {
+		return clusters, nil
+	}
+	entries, err := readPostJournal(o.resumeJournal)
+	if err != nil {
+		return nil, err
+	}
+
+	var remaining []*v1.Cluster
+	for _, cluster := range clusters {
+		entry, ok := entries[cluster.ID()]
+		if !ok || !entry.done() {
+			remaining = append(remaining, cluster)
+			continue
+		}
+		if entry.Summary != o.Message.Summary {
+			log.Warnf("Cluster %s received %q according to the journal, while the summary is now %q", cluster.ID(), entry.Summary, o.Message.Summary)
+		}
+	}
+	log.Infof("Skipping %d cluster(s) already handled according to %s", len(clusters)-len(remaining), o.resumeJournal)
+	return remaining, nil
+}
+
+// postToCluster sends the service log to a cluster, unless it received a service log with the
+// same summary within the duplicate window, and returns the outcome
+func (o *PostCmdOptions) postToCluster(ocmClient *sdk.Connection, cluster *v1.Cluster) journalEntry {
+	message := o.Message
+	entry := journalEntry{
+		ClusterID:   cluster.ID(),
+		ClusterUUID: cluster.ExternalID(),
+		Summary:     message.Summary,
+	}
+	finish := func(status, detail string) journalEntry {
+		entry.Timestamp = time.Now().UTC()
+		entry.Status = status
+		entry.Message = detail
+		return entry
+	}
+
+	if o.duplicateWindow > 0 {
+		duplicates, err := recentServiceLogs(ocmClient, cluster, message.ServiceName, message.Summary, time.Now().Add(-o.duplicateWindow))
+		if err != nil {
+			return finish(postStatusFailed, fmt.Sprintf("cannot check for duplicate service logs: %v", err))
+		}
+		if len(duplicates) > 0 {
+			return finish(postStatusDuplicate, fmt.Sprintf("a service log with the same summary was sent in the last %v", o.duplicateWindow))
+		}
+	}
+
+	request, err := o.createPostRequest(ocmClient, cluster, &message)
+	if err != nil {
+		return finish(postStatusFailed, err.Error())
+	}
+	response, err := ocmutils.SendRequest(request)
+	if err != nil {
+		return finish(postStatusFailed, err.Error())
+	}
+	if err := checkResponse(response, message); err != nil {
+		return finish(postStatusFailed, err.Error())
+	}
+	return finish(postStatusSent, fmt.Sprintf("Message has been successfully sent to %s", cluster.ExternalID()))
+}
+
+// recordOutcome adds the outcome of a cluster to the summary printed at the end
+func (o *PostCmdOptions) recordOutcome(entry journalEntry) {
+	switch entry.Status {
+	case postStatusSent:
+		o.successfulClusters[entry.ClusterUUID] = entry.Message
+	case postStatusDuplicate:
+		o.skippedClusters[entry.ClusterUUID] = entry.Message
+	default:
+		o.failedClusters[entry.ClusterUUID] = entry.Message
+	}
+}
+
 // if servicelog description contains documentation link, parse and return the cluster type from the url
 func getDocClusterType(message string) string {
 
@@ -336,23 +480,18 @@ func getDocClusterType(message string) string {
 	return ""
 }
 
-func (o *PostCmdOptions) check(response *sdk.Response, clusterMessage servicelog.Message) {
+// checkResponse returns an error if the service log wasn't posted as sent
+func checkResponse(response *sdk.Response, clusterMessage servicelog.Message) error {
 	body := response.Bytes()
 	if response.Status() < 400 {
 		_, err := validateGoodResponse(body, clusterMessage)
-		if err != nil {
-			o.failedClusters[clusterMessage.ClusterUUID] = err.Error()
-		} else {
-			o.successfulClusters[clusterMessage.ClusterUUID] = fmt.Sprintf("Message has been successfully sent to %s", clusterMessage.ClusterUUID)
-		}
-	} else {
-		badReply, err := validateBadResponse(body)
-		if err != nil {
-			o.failedClusters[clusterMessage.ClusterUUID] = err.Error()
-		} else {
-			o.failedClusters[clusterMessage.ClusterUUID] = badReply.Reason
-		}
+		return err
+	}
+	badReply, err := validateBadResponse(body)
+	if err != nil {
+		return err
 	}
+	return errors.New(badReply.Reason)
 }
 
 // parseUserParameters parse all the '-p FOO=BAR' parameters and checks for syntax errors
@@ -505,7 +644,13 @@ func (o *PostCmdOptions) readTemplate() {
 
 	file, err := o.accessFile(o.Template)
 	if err != nil { // check if this URL or file and if we can access it
-		log.Fatal(err)
+		// fall back to the local template library, e.g. when offline
+		libraryFile, libraryErr := o.readLibraryTemplate()
+		if libraryErr != nil {
+			log.Fatal(err)
+		}
+		log.Infof("Using %s from the local template library", o.Template)
+		file = libraryFile
 	}
 
 	if err = o.parseTemplate(file); err != nil {
@@ -513,6 +658,15 @@ func (o *PostCmdOptions) readTemplate() {
 	}
 }
 
+// readLibraryTemplate reads the template from the local template library
+func (o *PostCmdOptions) readLibraryTemplate() ([]byte, error) {
+	library, err := newTemplateLibrary()
+	if err != nil {
+		return nil, err
+	}
+	return library.Read(o.Template)
+}
+
 func (o *PostCmdOptions) readFilterFile() {
 	if len(o.filterFiles) < 1 {
 		// No filterFiles specified in args
@@ -601,7 +755,7 @@ func (o *PostCmdOptions) printTemplate() (err error) {
 	return dump.Pretty(os.Stdout, exampleMessage)
 }
 
-func (o *PostCmdOptions) createPostRequest(ocmClient *sdk.Connection, cluster *v1.Cluster) (request *sdk.Request, err error) {
+func (o *PostCmdOptions) createPostRequest(ocmClient *sdk.Connection, cluster *v1.Cluster, message *servicelog.Message) (request *sdk.Request, err error) {
 	// Create and populate the request:
 	request = ocmClient.Post()
 	err = arguments.ApplyPathArg(request, targetAPIPath)
@@ -609,14 +763,14 @@ func (o *PostCmdOptions) createPostRequest(ocmClient *sdk.Connection, cluster *v
 		return nil, fmt.Errorf("cannot parse API path '%s': %v", targetAPIPath, err)
 	}
 
-	o.Message.ClusterUUID = cluster.ExternalID()
-	o.Message.ClusterID = cluster.ID()
-	o.Message.InternalOnly = o.InternalOnly
+	message.ClusterUUID = cluster.ExternalID()
+	message.ClusterID = cluster.ID()
+	message.InternalOnly = o.InternalOnly
 	if subscription := cluster.Subscription(); subscription != nil {
-		o.Message.SubscriptionID = cluster.Subscription().ID()
+		message.SubscriptionID = cluster.Subscription().ID()
 	}
 
-	messageBytes, err := json.Marshal(o.Message)
+	messageBytes, err := json.Marshal(message)
 	if err != nil {
 		return nil, fmt.Errorf("cannot marshal template to json: %v", err)
 	}
@@ -642,7 +796,7 @@ func (o *PostCmdOptions) listMessagedClusters(clusters map[string]string) error
 
 // printPostOutput prints the main servicelog post output.
 func (o *PostCmdOptions) printPostOutput() {
-	output := fmt.Sprintf("Success: %d, Failed: %d\n", len(o.successfulClusters), len(o.failedClusters))
+	output := fmt.Sprintf("Success: %d, Failed: %d, Skipped: %d\n", len(o.successfulClusters), len(o.failedClusters), len(o.skippedClusters))
 	log.Infoln(output + "\n")
 
 	// Print if any service logs were successfully sent
@@ -660,15 +814,31 @@ func (o *PostCmdOptions) printPostOutput() {
 			log.Fatalf("Cannot list failed clusters: %q", err)
 		}
 	}
+
+	// Print if clusters were skipped as they already received the service log
+	if len(o.skippedClusters) > 0 {
+		log.Infoln("Skipped clusters:")
+		if err := o.listMessagedClusters(o.skippedClusters); err != nil {
+			log.Fatalf("Cannot list skipped clusters: %q", err)
+		}
+	}
 }
 
 // cleanUp performs final actions in case of program termination.
 func (o *PostCmdOptions) cleanUp(clusters []*v1.Cluster) {
 	for _, cluster := range clusters {
-		if _, ok := o.successfulClusters[cluster.ExternalID()]; !ok {
+		if !o.handled(cluster.ExternalID()) {
 			o.failedClusters[cluster.ExternalID()] = "cannot send message due to program interruption"
 		}
 	}
 
 	o.printPostOutput()
 }
+
+// handled reports whether the outcome of posting to a cluster is known
+func (o *PostCmdOptions) handled(clusterUUID string) bool {
+	_, sent := o.successfulClusters[clusterUUID]
+	_, failed := o.failedClusters[clusterUUID]
+	_, skipped := o.skippedClusters[clusterUUID]
+	return sent || failed || skipped
+}
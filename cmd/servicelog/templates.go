@@ -0,0 +1,320 @@
+package servicelog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/internal/servicelog"
+	"github.com/openshift/osdctl/internal/utils"
+	"github.com/openshift/osdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+const templateIndexFile = "index.json"
+
+// managedNotificationsURLPrefixes are the URLs templates are usually posted from. Templates
+// referenced by these URLs are read from the library when the URL can't be reached.
+var managedNotificationsURLPrefixes = []string{
+	"https://raw.githubusercontent.com/openshift/managed-notifications/master/",
+	"https://raw.githubusercontent.com/openshift/managed-notifications/main/",
+	"https://github.com/openshift/managed-notifications/blob/master/",
+	"https://github.com/openshift/managed-notifications/blob/main/",
+}
+
+// ErrTemplateNotFound is returned when a template isn't in the library
+var ErrTemplateNotFound = errors.New("template not found in the local template library")
+
+// templateLibrary is a local copy of the managed-notifications templates, synced from a
+// checkout of the repository and indexed so they can be searched and posted offline:
+//
+//	<cache dir>/osdctl/servicelog/templates/index.json
+//	<cache dir>/osdctl/servicelog/templates/<path in the repository>
+type templateLibrary struct {
+	dir string
+}
+
+// libraryTemplate describes a template held in the library
+type libraryTemplate struct {
+	Path        string   `json:"path"`
+	Severity    string   `json:"severity"`
+	ServiceName string   `json:"service_name"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description"`
+	Parameters  []string `json:"parameters,omitempty"`
+}
+
+// templateIndex lists the templates of the library
+type templateIndex struct {
+	Source    string            `json:"source"`
+	SyncedAt  time.Time         `json:"synced_at"`
+	Templates []libraryTemplate `json:"templates"`
+}
+
+// newTemplateLibrary returns the library located in the user cache directory
+func newTemplateLibrary() (*templateLibrary, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return newTemplateLibraryAt(filepath.Join(cacheDir, "osdctl", "servicelog", "templates")), nil
+}
+
+func newTemplateLibraryAt(dir string) *templateLibrary {
+	return &templateLibrary{dir: dir}
+}
+
+// Sync replaces the library with the templates found in a managed-notifications checkout.
+// JSON files which aren't service log templates are skipped.
+func (l *templateLibrary) Sync(checkout string) (*templateIndex, error) {
+	if !utils.FolderExists(checkout) {
+		return nil, fmt.Errorf("%s is not a directory", checkout)
+	}
+	source, err := filepath.Abs(checkout)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &templateIndex{Source: source, SyncedAt: time.Now().UTC().Truncate(time.Second)}
+	files := map[string][]byte{}
+	err = filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != source && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path) //#nosec G304 -- Potential file inclusion via variable
+		if err != nil {
+			return err
+		}
+		var message servicelog.Message
+		if json.Unmarshal(data, &message) != nil || message.Summary == "" || message.ServiceName == "" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		files[rel] = data
+		index.Templates = append(index.Templates, libraryTemplate{
+			Path:        rel,
+			Severity:    message.Severity,
+			ServiceName: message.ServiceName,
+			Summary:     message.Summary,
+			Description: message.Description,
+			Parameters:  templateParameters(&message),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates from %s: %w", checkout, err)
+	}
+	if len(index.Templates) == 0 {
+		return nil, fmt.Errorf("no service log templates found in %s", checkout)
+	}
+
+	// Write the new library next to the current one and swap them, so a failed sync leaves
+	// the current library untouched
+	staging := l.dir + ".sync"
+	if err := os.RemoveAll(staging); err != nil {
+		return nil, err
+	}
+	for rel, data := range files {
+		path := filepath.Join(staging, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create template library directory: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write template %s: %w", rel, err)
+		}
+	}
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(staging, templateIndexFile), indexData, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write template index: %w", err)
+	}
+	if err := os.RemoveAll(l.dir); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(staging, l.dir); err != nil {
+		return nil, fmt.Errorf("failed to replace template library: %w", err)
+	}
+	return index, nil
+}
+
+// Index returns the index of the library
+func (l *templateLibrary) Index() (*templateIndex, error) {
+	data, err := os.ReadFile(filepath.Join(l.dir, templateIndexFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("the local template library is empty, run 'osdctl servicelog templates sync <managed-notifications checkout>'")
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := &templateIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse template index: %w", err)
+	}
+	return index, nil
+}
+
+// Search returns the templates whose path, summary or description contain all keywords,
+// ignoring case
+func (l *templateLibrary) Search(keywords []string) ([]libraryTemplate, error) {
+	index, err := l.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []libraryTemplate{}
+	for _, template := range index.Templates {
+		text := strings.ToLower(template.Path + " " + template.Summary + " " + template.Description)
+		matched := true
+		for _, keyword := range keywords {
+			if !strings.Contains(text, strings.ToLower(keyword)) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, template)
+		}
+	}
+	return matches, nil
+}
+
+// Read returns a template of the library, referenced by its path in the repository or by its
+// managed-notifications URL
+func (l *templateLibrary) Read(ref string) ([]byte, error) {
+	index, err := l.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	path := ref
+	for _, prefix := range managedNotificationsURLPrefixes {
+		path = strings.TrimPrefix(path, prefix)
+	}
+	path = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+
+	if !slices.ContainsFunc(index.Templates, func(t libraryTemplate) bool { return t.Path == path }) {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, ref)
+	}
+	return os.ReadFile(filepath.Join(l.dir, filepath.FromSlash(path))) //#nosec G304 -- path is listed in the index
+}
+
+// templateParameters returns the names of the parameters used by a template
+func templateParameters(m *servicelog.Message) []string {
+	var params []string
+	for _, field := range templateTextFields(m) {
+		for _, placeholder := range placeholderRegex.FindAllString(field.value, -1) {
+			if parameterNameRegex.MatchString(placeholder) {
+				params = append(params, strings.Trim(placeholder, "${}"))
+			}
+		}
+	}
+	slices.Sort(params)
+	return slices.Compact(params)
+}
+
+func newTemplatesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Manage the local library of service log templates",
+		Long: `Manage the local library of service log templates.
+
+  The library is a copy of the managed-notifications templates, synced from a checkout of
+  https://github.com/openshift/managed-notifications. Once synced, 'servicelog post -t' and
+  'servicelog lint' accept a template path such as osd/incident_resolved.json, and fall back
+  to the library when a managed-notifications URL can't be reached.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newTemplatesSyncCmd())
+	cmd.AddCommand(newTemplatesSearchCmd())
+
+	return cmd
+}
+
+func newTemplatesSyncCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "sync <managed-notifications checkout>",
+		Short: "Replace the local template library with the templates of a managed-notifications checkout",
+		Example: `
+  # Sync the library from an up to date checkout
+  git -C ~/managed-notifications pull
+  osdctl servicelog templates sync ~/managed-notifications
+`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			library, err := newTemplateLibrary()
+			if err != nil {
+				return err
+			}
+			index, err := library.Sync(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Synced %d templates from %s\n", len(index.Templates), index.Source)
+			return nil
+		},
+	}
+}
+
+func newTemplatesSearchCmd() *cobra.Command {
+	var out output.Options
+	cmd := &cobra.Command{
+		Use:   "search <keyword>...",
+		Short: "Search the local template library",
+		Long:  "Search the local template library for templates whose path, summary or description contain all the given keywords.",
+		Example: `
+  # Find templates about expiring certificates
+  osdctl servicelog templates search certificate expir
+
+  # Post the template found
+  osdctl servicelog post --cluster-id ${CLUSTER_ID} -t osd/cert_expiring.json
+`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := out.Validate(); err != nil {
+				return err
+			}
+			library, err := newTemplateLibrary()
+			if err != nil {
+				return err
+			}
+			templates, err := library.Search(args)
+			if err != nil {
+				return err
+			}
+
+			columns := []output.Column{{Name: "PATH"}, {Name: "SEVERITY"}, {Name: "SUMMARY"}, {Name: "PARAMETERS", Wide: true}}
+			return out.Print(os.Stdout, output.NewTable(templates, columns, func(t libraryTemplate) []string {
+				return []string{t.Path, t.Severity, t.Summary, strings.Join(t.Parameters, ",")}
+			}))
+		},
+	}
+	out.AddFlags(cmd)
+
+	return cmd
+}
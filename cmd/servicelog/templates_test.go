@@ -0,0 +1,67 @@
+package servicelog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCheckout(t *testing.T) string {
+	checkout := t.TempDir()
+	files := map[string]string{
+		"osd/incident_resolved.json": `{"severity": "Info", "service_name": "SREManualAction", "summary": "Incident resolved", "description": "The alert ${ALERT_NAME} on ${CLUSTER_UUID} is resolved. ${ALERT_NAME}"}`,
+		"rosa/cert_expiring.json":    `{"severity": "Warning", "service_name": "SREManualAction", "summary": "Certificate expiring", "description": "Your certificate expires soon"}`,
+		"package.json":               `{"name": "managed-notifications"}`,
+		".github/config.json":        `{"severity": "Info", "service_name": "SREManualAction", "summary": "hidden"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(checkout, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+	return checkout
+}
+
+func TestTemplateLibrary(t *testing.T) {
+	library := newTemplateLibraryAt(filepath.Join(t.TempDir(), "templates"))
+
+	_, err := library.Search([]string{"incident"})
+	assert.ErrorContains(t, err, "template library is empty")
+
+	index, err := library.Sync(newTestCheckout(t))
+	require.NoError(t, err)
+	require.Len(t, index.Templates, 2)
+	assert.Equal(t, "osd/incident_resolved.json", index.Templates[0].Path)
+	assert.Equal(t, []string{"ALERT_NAME", "CLUSTER_UUID"}, index.Templates[0].Parameters)
+
+	matches, err := library.Search([]string{"CERTIFICATE", "soon"})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "rosa/cert_expiring.json", matches[0].Path)
+
+	matches, err = library.Search([]string{"certificate", "resolved"})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	data, err := library.Read("https://raw.githubusercontent.com/openshift/managed-notifications/master/osd/incident_resolved.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Incident resolved")
+
+	data, err = library.Read("rosa/cert_expiring.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Certificate expiring")
+
+	_, err = library.Read("../package.json")
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+
+	// A sync replaces the whole library
+	checkout := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(checkout, "new.json"), []byte(`{"severity": "Info", "service_name": "SREManualAction", "summary": "New"}`), 0600))
+	_, err = library.Sync(checkout)
+	require.NoError(t, err)
+	_, err = library.Read("rosa/cert_expiring.json")
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
@@ -33,7 +33,7 @@ func (opts *GlobalOptions) AddSkipVersionCheckFlag(cmd *cobra.Command) {
 }
 
 func (opts *GlobalOptions) AddOutputFlag(cmd *cobra.Command) {
-	cmd.PersistentFlags().StringVarP(&opts.Output, "output", "o", "", "Valid formats are ['', 'json', 'yaml', 'env']")
+	cmd.PersistentFlags().StringVarP(&opts.Output, "output", "o", "", "Valid formats are ['', 'json', 'yaml', 'jsonpath=<template>', 'go-template=<template>', 'env']")
 }
 
 func (opts *GlobalOptions) AddNoAwsProxyFlag(cmd *cobra.Command) {
@@ -606,10 +606,7 @@ func diagnosePermissions(ctx context.Context, input *AWSCredsInput, report *Diag
 
 // simulateActions runs SimulatePrincipalPolicy for a set of IAM actions and records allow/deny results in the report.
 func simulateActions(awsClient awsprovider.Client, policySourceArn string, actions []string, category string, actionToCRs map[string][]string, report *DiagnosticReport) error {
-	output, err := awsClient.SimulatePrincipalPolicy(&iam.SimulatePrincipalPolicyInput{
-		PolicySourceArn: awsSdk.String(policySourceArn),
-		ActionNames:     actions,
-	})
+	results, err := SimulatePermissions(awsClient, policySourceArn, actions)
 	if err != nil {
 		report.Findings = append(report.Findings, Finding{
 			Severity: "WARN",
@@ -620,21 +617,13 @@ func simulateActions(awsClient awsprovider.Client, policySourceArn string, actio
 		return nil
 	}
 
-	for _, result := range output.EvaluationResults {
-		if result.EvalActionName == nil {
-			continue
-		}
-		allowed := result.EvalDecision == iamTypes.PolicyEvaluationDecisionTypeAllowed
-		pr := PermissionResult{
-			Action:   *result.EvalActionName,
-			Allowed:  allowed,
-			Category: category,
-		}
+	for _, pr := range results {
+		pr.Category = category
 		if actionToCRs != nil {
-			pr.RequestedBy = actionToCRs[*result.EvalActionName]
+			pr.RequestedBy = actionToCRs[pr.Action]
 		}
 		report.Permissions = append(report.Permissions, pr)
-		if !allowed {
+		if !pr.Allowed {
 			report.AllPermissionsOK = false
 		}
 	}
@@ -642,6 +631,36 @@ func simulateActions(awsClient awsprovider.Client, policySourceArn string, actio
 	return nil
 }
 
+// SimulatePermissions runs SimulatePrincipalPolicy for a set of IAM actions against the policies
+// of a user or role, returning whether each action is allowed.
+func SimulatePermissions(awsClient awsprovider.Client, policySourceArn string, actions []string) ([]PermissionResult, error) {
+	input := &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: awsSdk.String(policySourceArn),
+		ActionNames:     actions,
+	}
+
+	var results []PermissionResult
+	for {
+		output, err := awsClient.SimulatePrincipalPolicy(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range output.EvaluationResults {
+			if result.EvalActionName == nil {
+				continue
+			}
+			results = append(results, PermissionResult{
+				Action:  *result.EvalActionName,
+				Allowed: result.EvalDecision == iamTypes.PolicyEvaluationDecisionTypeAllowed,
+			})
+		}
+		if !output.IsTruncated || output.Marker == nil {
+			return results, nil
+		}
+		input.Marker = output.Marker
+	}
+}
+
 // extractCredReqActions collects all IAM actions declared in AWSProviderSpec across
 // CredentialRequests, mapping each action to the CR names that require it.
 func extractCredReqActions(ctx context.Context, input *AWSCredsInput) (map[string][]string, error) {
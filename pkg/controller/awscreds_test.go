@@ -1072,6 +1072,42 @@ func TestDiagnoseCredentials_SimulateAPIError(t *testing.T) {
 	assert.True(t, hasWarn, "expected WARN about simulate API failure")
 }
 
+func TestSimulatePermissions_Paginated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mock_aws.NewMockClient(ctrl)
+
+	roleArn := "arn:aws:iam::123456789012:role/test-openshift-ingress-operator-cloud-credentials"
+	gomock.InOrder(
+		mockClient.EXPECT().SimulatePrincipalPolicy(&iam.SimulatePrincipalPolicyInput{
+			PolicySourceArn: awsSdk.String(roleArn),
+			ActionNames:     []string{"route53:ChangeResourceRecordSets", "tag:GetResources"},
+		}).Return(&iam.SimulatePrincipalPolicyOutput{
+			EvaluationResults: []iamTypes.EvaluationResult{
+				{EvalActionName: awsSdk.String("route53:ChangeResourceRecordSets"), EvalDecision: iamTypes.PolicyEvaluationDecisionTypeAllowed},
+			},
+			IsTruncated: true,
+			Marker:      awsSdk.String("page-2"),
+		}, nil),
+		mockClient.EXPECT().SimulatePrincipalPolicy(&iam.SimulatePrincipalPolicyInput{
+			PolicySourceArn: awsSdk.String(roleArn),
+			ActionNames:     []string{"route53:ChangeResourceRecordSets", "tag:GetResources"},
+			Marker:          awsSdk.String("page-2"),
+		}).Return(&iam.SimulatePrincipalPolicyOutput{
+			EvaluationResults: []iamTypes.EvaluationResult{
+				{EvalActionName: awsSdk.String("tag:GetResources"), EvalDecision: iamTypes.PolicyEvaluationDecisionTypeImplicitDeny},
+			},
+		}, nil),
+	)
+
+	results, err := SimulatePermissions(mockClient, roleArn, []string{"route53:ChangeResourceRecordSets", "tag:GetResources"})
+	require.NoError(t, err)
+	assert.Equal(t, []PermissionResult{
+		{Action: "route53:ChangeResourceRecordSets", Allowed: true},
+		{Action: "tag:GetResources", Allowed: false},
+	}, results)
+}
+
 func mustMarshal(t *testing.T, v any) []byte {
 	t.Helper()
 	b, err := json.Marshal(v)
@@ -0,0 +1,194 @@
+// Package graph models the relationships between resources, rendered deterministically as
+// Graphviz DOT, Mermaid, JSON or SVG
+package graph
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Output formats of a graph
+const (
+	DOT     = "dot"
+	Mermaid = "mermaid"
+	JSON    = "json"
+	SVG     = "svg"
+)
+
+// Formats are the output formats of a graph
+var Formats = []string{DOT, Mermaid, JSON, SVG}
+
+// Node is a resource, e.g. a VPC or a hosted zone
+type Node struct {
+	ID string `json:"id"`
+	// Kind is the type of resource, e.g. "VPC"
+	Kind string `json:"kind,omitempty"`
+	// Name is a description of the resource shown next to its ID, e.g. the CIDR of a VPC
+	Name     string `json:"name,omitempty"`
+	Subgraph string `json:"subgraph,omitempty"`
+}
+
+// Label returns the lines describing the node: its kind, name and ID
+func (n Node) Label() []string {
+	var lines []string
+	for _, line := range []string{n.Kind, n.Name, n.ID} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Edge connects two nodes, the label describing the relationship, e.g. the destination of a route
+type Edge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label,omitempty"`
+}
+
+// Subgraph groups the nodes of a graph, e.g. the resources of an AWS account
+type Subgraph struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+}
+
+// Graph is an undirected graph of resources, with at most one edge between two nodes
+type Graph struct {
+	nodes     map[string]Node
+	edges     map[[2]string][]string
+	subgraphs map[string]string
+}
+
+// New returns an empty graph
+func New() *Graph {
+	return &Graph{
+		nodes:     map[string]Node{},
+		edges:     map[[2]string][]string{},
+		subgraphs: map[string]string{},
+	}
+}
+
+// AddSubgraph adds a subgraph with its label, the subgraphs of the nodes being otherwise
+// labeled with their ID
+func (g *Graph) AddSubgraph(id, label string) {
+	g.subgraphs[id] = label
+}
+
+// AddNode adds a node, filling the kind, name and subgraph of a node with the same ID left empty
+func (g *Graph) AddNode(node Node) {
+	if node.ID == "" {
+		return
+	}
+	if _, ok := g.subgraphs[node.Subgraph]; !ok && node.Subgraph != "" {
+		g.subgraphs[node.Subgraph] = node.Subgraph
+	}
+	existing, ok := g.nodes[node.ID]
+	if !ok {
+		g.nodes[node.ID] = node
+		return
+	}
+	if existing.Kind == "" {
+		existing.Kind = node.Kind
+	}
+	if existing.Name == "" {
+		existing.Name = node.Name
+	}
+	if existing.Subgraph == "" {
+		existing.Subgraph = node.Subgraph
+	}
+	g.nodes[node.ID] = existing
+}
+
+// HasNode reports whether the graph has a node
+func (g *Graph) HasNode(id string) bool {
+	_, ok := g.nodes[id]
+	return ok
+}
+
+// AddEdge connects two nodes, adding the ones missing. The labels of the edges between the same
+// nodes are merged.
+func (g *Graph) AddEdge(from, to, label string) {
+	if from == "" || to == "" || from == to {
+		return
+	}
+	g.AddNode(Node{ID: from})
+	g.AddNode(Node{ID: to})
+	key := [2]string{from, to}
+	if _, ok := g.edges[key]; !ok {
+		if _, ok := g.edges[[2]string{to, from}]; ok {
+			key = [2]string{to, from}
+		}
+	}
+	labels := g.edges[key]
+	if label != "" && !slices.Contains(labels, label) {
+		labels = append(labels, label)
+	}
+	g.edges[key] = labels
+}
+
+// Nodes returns the nodes sorted by subgraph and ID
+func (g *Graph) Nodes() []Node {
+	nodes := make([]Node, 0, len(g.nodes))
+	for _, node := range g.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Subgraph != nodes[j].Subgraph {
+			return nodes[i].Subgraph < nodes[j].Subgraph
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	return nodes
+}
+
+// Edges returns the edges sorted by their nodes
+func (g *Graph) Edges() []Edge {
+	edges := make([]Edge, 0, len(g.edges))
+	for key, labels := range g.edges {
+		sorted := append([]string(nil), labels...)
+		sort.Strings(sorted)
+		edges = append(edges, Edge{From: key[0], To: key[1], Label: strings.Join(sorted, ", ")})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// Subgraphs returns the subgraphs holding nodes, sorted by ID
+func (g *Graph) Subgraphs() []Subgraph {
+	used := map[string]bool{}
+	for _, node := range g.nodes {
+		used[node.Subgraph] = true
+	}
+	var subgraphs []Subgraph
+	for id, label := range g.subgraphs {
+		if used[id] {
+			subgraphs = append(subgraphs, Subgraph{ID: id, Label: label})
+		}
+	}
+	sort.Slice(subgraphs, func(i, j int) bool { return subgraphs[i].ID < subgraphs[j].ID })
+	return subgraphs
+}
+
+// Render writes the graph in one of the Formats
+func Render(w io.Writer, g *Graph, format string) error {
+	switch format {
+	case DOT:
+		return g.WriteDOT(w)
+	case Mermaid:
+		return g.WriteMermaid(w)
+	case JSON:
+		return g.WriteJSON(w)
+	case SVG:
+		return g.WriteSVG(w)
+	default:
+		return fmt.Errorf("unsupported graph format %q, must be one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testGraph() *Graph {
+	g := New()
+	g.AddSubgraph("customer", "Customer Cluster")
+	g.AddNode(Node{ID: "vpc-1", Kind: "VPC", Name: "10.0.0.0/16", Subgraph: "customer"})
+	g.AddNode(Node{ID: "subnet-b", Kind: "Subnet", Subgraph: "customer"})
+	g.AddNode(Node{ID: "subnet-a", Kind: "Subnet", Subgraph: "customer"})
+	g.AddEdge("vpc-1", "subnet-b", "")
+	g.AddEdge("vpc-1", "subnet-a", "")
+	g.AddEdge("subnet-a", "igw-1", "0.0.0.0/0")
+	g.AddEdge("igw-1", "subnet-a", "::/0")
+	g.AddNode(Node{ID: "igw-1", Kind: "Internet Gateway"})
+	g.AddNode(Node{ID: "zone\"1", Kind: "Hosted Zone", Name: "<example.com>", Subgraph: "dns"})
+	g.AddEdge("zone\"1", "igw-1", "")
+	return g
+}
+
+func TestGraphIsDeterministic(t *testing.T) {
+	for _, format := range Formats {
+		var first bytes.Buffer
+		require.NoError(t, Render(&first, testGraph(), format))
+		for i := 0; i < 10; i++ {
+			var out bytes.Buffer
+			require.NoError(t, Render(&out, testGraph(), format))
+			assert.Equal(t, first.String(), out.String(), format)
+		}
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, testGraph().WriteDOT(&out))
+	assert.Equal(t, `strict graph {
+  node [shape=box]
+  subgraph "cluster_customer" {
+    label="Customer Cluster"
+    "subnet-a" [label="Subnet\nsubnet-a"]
+    "subnet-b" [label="Subnet\nsubnet-b"]
+    "vpc-1" [label="VPC\n10.0.0.0/16\nvpc-1"]
+  }
+  subgraph "cluster_dns" {
+    label="dns"
+    "zone\"1" [label="Hosted Zone\n<example.com>\nzone\"1"]
+  }
+  "igw-1" [label="Internet Gateway\nigw-1"]
+  "subnet-a" -- "igw-1" [label="0.0.0.0/0, ::/0"]
+  "vpc-1" -- "subnet-a"
+  "vpc-1" -- "subnet-b"
+  "zone\"1" -- "igw-1"
+}
+`, out.String())
+}
+
+func TestWriteMermaid(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, testGraph().WriteMermaid(&out))
+	assert.Equal(t, `flowchart LR
+  subgraph s0 ["Customer Cluster"]
+    n1["Subnet<br/>subnet-a"]
+    n2["Subnet<br/>subnet-b"]
+    n3["VPC<br/>10.0.0.0/16<br/>vpc-1"]
+  end
+  subgraph s1 ["dns"]
+    n4["Hosted Zone<br/>#lt;example.com#gt;<br/>zone#quot;1"]
+  end
+  n0["Internet Gateway<br/>igw-1"]
+  n1 ---|"0.0.0.0/0, ::/0"| n0
+  n3 --- n1
+  n3 --- n2
+  n4 --- n0
+`, out.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, testGraph().WriteJSON(&out))
+	assert.JSONEq(t, `{
+		"subgraphs": [{"id": "customer", "label": "Customer Cluster"}, {"id": "dns", "label": "dns"}],
+		"nodes": [
+			{"id": "igw-1", "kind": "Internet Gateway"},
+			{"id": "subnet-a", "kind": "Subnet", "subgraph": "customer"},
+			{"id": "subnet-b", "kind": "Subnet", "subgraph": "customer"},
+			{"id": "vpc-1", "kind": "VPC", "name": "10.0.0.0/16", "subgraph": "customer"},
+			{"id": "zone\"1", "kind": "Hosted Zone", "name": "<example.com>", "subgraph": "dns"}
+		],
+		"edges": [
+			{"from": "subnet-a", "to": "igw-1", "label": "0.0.0.0/0, ::/0"},
+			{"from": "vpc-1", "to": "subnet-a"},
+			{"from": "vpc-1", "to": "subnet-b"},
+			{"from": "zone\"1", "to": "igw-1"}
+		]
+	}`, out.String())
+
+	out.Reset()
+	require.NoError(t, New().WriteJSON(&out))
+	assert.JSONEq(t, `{"subgraphs": [], "nodes": [], "edges": []}`, out.String())
+}
+
+func TestWriteSVG(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, testGraph().WriteSVG(&out))
+	svg := out.String()
+
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`))
+	assert.True(t, strings.HasSuffix(svg, "</svg>\n"))
+	assert.Contains(t, svg, `font-weight="bold">Customer Cluster</text>`)
+	assert.Contains(t, svg, `>&lt;example.com&gt;</text>`, "labels are escaped")
+	assert.Contains(t, svg, `>zone&#34;1</text>`)
+	assert.Equal(t, 4, strings.Count(svg, "<line "), "one line per edge")
+	assert.Contains(t, svg, `>0.0.0.0/0, ::/0</text>`)
+}
+
+func TestRanks(t *testing.T) {
+	g := testGraph()
+	assert.Equal(t, map[string]int{"subnet-a": 1, "subnet-b": 1, "igw-1": 2}, ranks(g.Nodes(), g.Edges()), "the nodes no edge leads to are in the first row")
+
+	cyclic := New()
+	cyclic.AddEdge("a", "b", "")
+	cyclic.AddEdge("b", "c", "")
+	cyclic.AddEdge("c", "a", "")
+	for _, rank := range ranks(cyclic.Nodes(), cyclic.Edges()) {
+		assert.Less(t, rank, 3, "ranks are bounded when edges form a cycle")
+	}
+}
+
+func TestRenderUnsupportedFormat(t *testing.T) {
+	err := Render(&bytes.Buffer{}, New(), "png")
+	assert.EqualError(t, err, `unsupported graph format "png", must be one of: dot, mermaid, json, svg`)
+}
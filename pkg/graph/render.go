@@ -0,0 +1,121 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes the graph in the Graphviz DOT language, the subgraphs as clusters
+func (g *Graph) WriteDOT(w io.Writer) error {
+	sb := strings.Builder{}
+	sb.WriteString("strict graph {\n")
+	sb.WriteString("  node [shape=box]\n")
+
+	nodes := g.Nodes()
+	for _, subgraph := range g.Subgraphs() {
+		if subgraph.ID == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "  subgraph %s {\n", dotID("cluster_"+subgraph.ID))
+		fmt.Fprintf(&sb, "    label=%s\n", dotID(subgraph.Label))
+		for _, node := range nodes {
+			if node.Subgraph == subgraph.ID {
+				fmt.Fprintf(&sb, "    %s [label=%s]\n", dotID(node.ID), dotID(strings.Join(node.Label(), "\n")))
+			}
+		}
+		sb.WriteString("  }\n")
+	}
+	for _, node := range nodes {
+		if node.Subgraph == "" {
+			fmt.Fprintf(&sb, "  %s [label=%s]\n", dotID(node.ID), dotID(strings.Join(node.Label(), "\n")))
+		}
+	}
+	for _, edge := range g.Edges() {
+		fmt.Fprintf(&sb, "  %s -- %s", dotID(edge.From), dotID(edge.To))
+		if edge.Label != "" {
+			fmt.Fprintf(&sb, " [label=%s]", dotID(edge.Label))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// dotID quotes a DOT identifier
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart, the nodes being named after their
+// position in the sorted nodes since Mermaid restricts the characters of the IDs
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	sb := strings.Builder{}
+	sb.WriteString("flowchart LR\n")
+
+	nodes := g.Nodes()
+	names := make(map[string]string, len(nodes))
+	for i, node := range nodes {
+		names[node.ID] = fmt.Sprintf("n%d", i)
+	}
+	writeNode := func(indent string, node Node) {
+		lines := node.Label()
+		for i, line := range lines {
+			lines[i] = mermaidText(line)
+		}
+		fmt.Fprintf(&sb, "%s%s[\"%s\"]\n", indent, names[node.ID], strings.Join(lines, "<br/>"))
+	}
+
+	for i, subgraph := range g.Subgraphs() {
+		if subgraph.ID == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "  subgraph s%d [\"%s\"]\n", i, mermaidText(subgraph.Label))
+		for _, node := range nodes {
+			if node.Subgraph == subgraph.ID {
+				writeNode("    ", node)
+			}
+		}
+		sb.WriteString("  end\n")
+	}
+	for _, node := range nodes {
+		if node.Subgraph == "" {
+			writeNode("  ", node)
+		}
+	}
+	for _, edge := range g.Edges() {
+		if edge.Label != "" {
+			fmt.Fprintf(&sb, "  %s ---|\"%s\"| %s\n", names[edge.From], mermaidText(edge.Label), names[edge.To])
+		} else {
+			fmt.Fprintf(&sb, "  %s --- %s\n", names[edge.From], names[edge.To])
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// mermaidText escapes the characters ending a quoted Mermaid label or read as HTML
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
+
+// WriteJSON writes the subgraphs, nodes and edges of the graph as a JSON document
+func (g *Graph) WriteJSON(w io.Writer) error {
+	subgraphs := g.Subgraphs()
+	if subgraphs == nil {
+		subgraphs = []Subgraph{}
+	}
+	document := struct {
+		Subgraphs []Subgraph `json:"subgraphs"`
+		Nodes     []Node     `json:"nodes"`
+		Edges     []Edge     `json:"edges"`
+	}{subgraphs, g.Nodes(), g.Edges()}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
@@ -0,0 +1,156 @@
+package graph
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// Dimensions of the SVG rendering, in pixels, for a 12px monospace font
+const (
+	svgMargin      = 20
+	svgCharWidth   = 7
+	svgLineHeight  = 16
+	svgPadding     = 8
+	svgNodeGap     = 16
+	svgRowGap      = 48
+	svgLaneGap     = 24
+	svgLaneHeader  = 28
+	svgLanePadding = 12
+)
+
+// svgLane is a column of the layout holding the nodes of a subgraph
+type svgLane struct {
+	subgraph Subgraph
+	// cells are the nodes of the lane per rank
+	cells     map[int][]Node
+	nodeWidth int
+	x         int
+	width     int
+}
+
+type svgBox struct {
+	x, y, width, height int
+}
+
+func (b svgBox) center() (int, int) {
+	return b.x + b.width/2, b.y + b.height/2
+}
+
+// WriteSVG renders the graph as an SVG image without Graphviz. Each subgraph is a column, and
+// the nodes are laid out in rows by their distance from the first nodes of the edges leading to
+// them, so the resources read from left to right and top to bottom.
+func (g *Graph) WriteSVG(w io.Writer) error {
+	nodes := g.Nodes()
+	edges := g.Edges()
+	rows := ranks(nodes, edges)
+
+	var lanes []*svgLane
+	laneOf := map[string]*svgLane{}
+	for _, subgraph := range g.Subgraphs() {
+		lane := &svgLane{subgraph: subgraph, cells: map[int][]Node{}}
+		lanes = append(lanes, lane)
+		laneOf[subgraph.ID] = lane
+	}
+	maxRank, maxLines := 0, 1
+	for _, node := range nodes {
+		lane, ok := laneOf[node.Subgraph]
+		if !ok {
+			lane = &svgLane{subgraph: Subgraph{ID: node.Subgraph}, cells: map[int][]Node{}}
+			lanes = append(lanes, lane)
+			laneOf[node.Subgraph] = lane
+		}
+		rank := rows[node.ID]
+		lane.cells[rank] = append(lane.cells[rank], node)
+		maxRank = max(maxRank, rank)
+		for _, line := range node.Label() {
+			lane.nodeWidth = max(lane.nodeWidth, len(line)*svgCharWidth+2*svgPadding)
+		}
+		maxLines = max(maxLines, len(node.Label()))
+	}
+
+	nodeHeight := maxLines*svgLineHeight + 2*svgPadding
+	boxes := map[string]svgBox{}
+	x := svgMargin
+	for _, lane := range lanes {
+		lane.x = x
+		columns := 1
+		for _, cell := range lane.cells {
+			columns = max(columns, len(cell))
+		}
+		lane.width = max(columns*(lane.nodeWidth+svgNodeGap)-svgNodeGap, len(lane.subgraph.Label)*svgCharWidth) + 2*svgLanePadding
+		for rank, cell := range lane.cells {
+			for i, node := range cell {
+				boxes[node.ID] = svgBox{
+					x:      lane.x + svgLanePadding + i*(lane.nodeWidth+svgNodeGap),
+					y:      svgMargin + svgLaneHeader + rank*(nodeHeight+svgRowGap),
+					width:  lane.nodeWidth,
+					height: nodeHeight,
+				}
+			}
+		}
+		x += lane.width + svgLaneGap
+	}
+	width := max(x-svgLaneGap+svgMargin, 2*svgMargin)
+	laneHeight := svgLaneHeader + (maxRank+1)*(nodeHeight+svgRowGap) - svgRowGap + svgLanePadding
+	height := svgMargin + laneHeight + svgMargin
+
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"monospace\" font-size=\"12\">\n", width, height, width, height)
+	fmt.Fprintf(&sb, "  <rect width=\"%d\" height=\"%d\" fill=\"white\"/>\n", width, height)
+	for _, lane := range lanes {
+		if lane.subgraph.ID == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "  <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"6\" fill=\"#f5f7fa\" stroke=\"#9aa5b1\" stroke-dasharray=\"4 2\"/>\n", lane.x, svgMargin, lane.width, laneHeight)
+		fmt.Fprintf(&sb, "  <text x=\"%d\" y=\"%d\" font-weight=\"bold\">%s</text>\n", lane.x+svgLanePadding, svgMargin+svgLineHeight+2, html.EscapeString(lane.subgraph.Label))
+	}
+	for _, edge := range edges {
+		x1, y1 := boxes[edge.From].center()
+		x2, y2 := boxes[edge.To].center()
+		fmt.Fprintf(&sb, "  <line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"#52606d\"/>\n", x1, y1, x2, y2)
+	}
+	for _, node := range nodes {
+		box := boxes[node.ID]
+		fmt.Fprintf(&sb, "  <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"4\" fill=\"white\" stroke=\"#1f2933\"/>\n", box.x, box.y, box.width, box.height)
+		for i, line := range node.Label() {
+			weight := ""
+			if i == 0 && node.Kind != "" {
+				weight = " font-weight=\"bold\""
+			}
+			fmt.Fprintf(&sb, "  <text x=\"%d\" y=\"%d\"%s>%s</text>\n", box.x+svgPadding, box.y+svgPadding+(i+1)*svgLineHeight-4, weight, html.EscapeString(line))
+		}
+	}
+	for _, edge := range edges {
+		if edge.Label == "" {
+			continue
+		}
+		x1, y1 := boxes[edge.From].center()
+		x2, y2 := boxes[edge.To].center()
+		fmt.Fprintf(&sb, "  <text x=\"%d\" y=\"%d\" text-anchor=\"middle\" font-size=\"10\" fill=\"#52606d\">%s</text>\n", (x1+x2)/2, (y1+y2)/2-4, html.EscapeString(edge.Label))
+	}
+	sb.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// ranks returns the row of each node: the length of the longest path of edges leading to it,
+// bounded by the number of nodes when the edges form cycles
+func ranks(nodes []Node, edges []Edge) map[string]int {
+	ranks := make(map[string]int, len(nodes))
+	for i := 0; i < len(nodes); i++ {
+		changed := false
+		for _, edge := range edges {
+			if rank := ranks[edge.From] + 1; rank > ranks[edge.To] && rank < len(nodes) {
+				ranks[edge.To] = rank
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return ranks
+}
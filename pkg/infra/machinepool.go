@@ -7,11 +7,11 @@ import (
 	"time"
 
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/osdctl/pkg/operation"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -84,171 +84,215 @@ type DanceClients struct {
 	HiveAdmin     client.Client
 }
 
-// RunMachinePoolDance performs the machinepool dance to replace infra nodes.
+// Keys of the machinepools of a dance in the state of its operation
+const (
+	originalMachinePoolValue = "infra.originalMachinePool"
+	newMachinePoolValue      = "infra.newMachinePool"
+)
+
+// SaveDanceMachinePools records the machinepools of a dance in the state of its operation. They
+// can't be read from Hive anymore once the original machinepool is deleted, and are needed to
+// resume the dance.
+func SaveDanceMachinePools(state *operation.State, originalMp, newMp *hivev1.MachinePool) error {
+	if err := state.SetValue(originalMachinePoolValue, originalMp); err != nil {
+		return err
+	}
+	return state.SetValue(newMachinePoolValue, newMp)
+}
+
+// LoadDanceMachinePools returns the machinepools recorded by SaveDanceMachinePools, nil if the
+// operation has none
+func LoadDanceMachinePools(state *operation.State) (originalMp, newMp *hivev1.MachinePool, err error) {
+	originalMp, newMp = &hivev1.MachinePool{}, &hivev1.MachinePool{}
+	found, err := state.Value(originalMachinePoolValue, originalMp)
+	if err != nil || !found {
+		return nil, nil, err
+	}
+	if found, err = state.Value(newMachinePoolValue, newMp); err != nil || !found {
+		return nil, nil, err
+	}
+	return originalMp, newMp, nil
+}
+
+// MachinePoolDanceSteps returns the steps of the machinepool dance replacing infra nodes.
 // It takes the original MachinePool and an already-modified new MachinePool.
 // The dance creates a temporary pool, waits for nodes, deletes the original,
 // creates a permanent replacement, then removes the temporary pool.
 //
 // The onTimeout callback is called when nodes fail to drain within the timeout.
 // It receives the list of stuck nodes and should terminate the backing instances.
-// If onTimeout is nil, the dance will return an error on timeout.
-func RunMachinePoolDance(ctx context.Context, clients DanceClients, originalMp, newMp *hivev1.MachinePool, onTimeout func(ctx context.Context, nodes *corev1.NodeList) error) error {
+// If onTimeout is nil, the step waiting for the nodes fails on timeout.
+func MachinePoolDanceSteps(clients DanceClients, originalMp, newMp *hivev1.MachinePool, onTimeout func(ctx context.Context, nodes *corev1.NodeList) error) []operation.Step {
 	tempMp := newMp.DeepCopy()
 	tempMp.Name = fmt.Sprintf("%s2", tempMp.Name)
 	tempMp.Spec.Name = fmt.Sprintf("%s2", tempMp.Spec.Name)
 	tempMp.Spec.Labels[TemporaryInfraNodeLabel] = ""
 
-	// Create the temporary machinepool
-	log.Printf("creating temporary machinepool %s", tempMp.Name)
-	if err := clients.HiveAdmin.Create(ctx, tempMp); err != nil {
-		return err
+	expected := int(*originalMp.Spec.Replicas) * 2
+
+	return []operation.Step{
+		{
+			Name:        "create-temporary-machinepool",
+			Description: fmt.Sprintf("Create the temporary machinepool %s", tempMp.Name),
+			Run: func(ctx context.Context) error {
+				return createMachinePool(ctx, clients.HiveAdmin, tempMp)
+			},
+		},
+		{
+			Name:        "wait-temporary-nodes",
+			Description: fmt.Sprintf("Wait for %d infra nodes to be Ready", expected),
+			Run: func(ctx context.Context) error {
+				return waitForReadyInfraNodes(ctx, clients.ClusterClient, expected)
+			},
+		},
+		{
+			Name:        "delete-original-machinepool",
+			Description: fmt.Sprintf("Delete the original machinepool %s", originalMp.Name),
+			Run: func(ctx context.Context) error {
+				return deleteMachinePool(ctx, clients.HiveAdmin, originalMp)
+			},
+		},
+		{
+			Name:        "wait-original-nodes-removed",
+			Description: "Wait for the nodes of the original machinepool to be removed",
+			Run: func(ctx context.Context) error {
+				originalNodeSelector, err := labels.Parse(InfraNodeLabel + ",!" + TemporaryInfraNodeLabel)
+				if err != nil {
+					return err
+				}
+				originalNodes := &corev1.NodeList{}
+				if err := clients.ClusterClient.List(ctx, originalNodes, &client.ListOptions{LabelSelector: originalNodeSelector}); err != nil {
+					return err
+				}
+				if err := waitForMachinePoolDeletion(ctx, clients.HiveClient, originalMp); err != nil {
+					return err
+				}
+				return waitForNodesDeletion(ctx, clients.ClusterClient, originalNodeSelector, onTimeout, originalNodes)
+			},
+		},
+		{
+			Name:        "create-machinepool",
+			Description: fmt.Sprintf("Create the new permanent machinepool %s", newMp.Name),
+			Run: func(ctx context.Context) error {
+				return createMachinePool(ctx, clients.HiveAdmin, newMp)
+			},
+		},
+		{
+			Name:        "wait-new-nodes",
+			Description: fmt.Sprintf("Wait for %d infra nodes to be Ready", expected),
+			Run: func(ctx context.Context) error {
+				return waitForReadyInfraNodes(ctx, clients.ClusterClient, expected)
+			},
+		},
+		{
+			Name:        "delete-temporary-machinepool",
+			Description: fmt.Sprintf("Delete the temporary machinepool %s", tempMp.Name),
+			Run: func(ctx context.Context) error {
+				return deleteMachinePool(ctx, clients.HiveAdmin, tempMp)
+			},
+		},
+		{
+			Name:        "wait-temporary-nodes-removed",
+			Description: fmt.Sprintf("Wait for the infra node count to return to %d", int(*originalMp.Spec.Replicas)),
+			Run: func(ctx context.Context) error {
+				tempNodeSelector, err := labels.Parse(InfraNodeLabel + "," + TemporaryInfraNodeLabel)
+				if err != nil {
+					return err
+				}
+				tempNodes := &corev1.NodeList{}
+				if err := clients.ClusterClient.List(ctx, tempNodes, &client.ListOptions{LabelSelector: tempNodeSelector}); err != nil {
+					return err
+				}
+				if err := waitForMachinePoolDeletion(ctx, clients.HiveClient, tempMp); err != nil {
+					return err
+				}
+				return waitForInfraNodeCount(ctx, clients.ClusterClient, int(*originalMp.Spec.Replicas), onTimeout, tempNodes, tempNodeSelector)
+			},
+		},
 	}
+}
 
-	// Wait for 2x infra nodes to be Ready
-	selector, err := labels.Parse(InfraNodeLabel)
-	if err != nil {
+// createMachinePool creates a copy of the machinepool, which may already exist when resuming
+func createMachinePool(ctx context.Context, hiveAdmin client.Client, mp *hivev1.MachinePool) error {
+	log.Printf("creating machinepool %s", mp.Name)
+	if err := hiveAdmin.Create(ctx, mp.DeepCopy()); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			log.Printf("machinepool %s already exists", mp.Name)
+			return nil
+		}
 		return err
 	}
+	return nil
+}
 
-	pollCtx, cancel := context.WithTimeout(ctx, pollTimeout)
-	defer cancel()
-	if err := wait.PollUntilContextTimeout(pollCtx, pollInterval, pollTimeout, true, func(ctx context.Context) (bool, error) {
-		nodes := &corev1.NodeList{}
-		if err := clients.ClusterClient.List(ctx, nodes, &client.ListOptions{LabelSelector: selector}); err != nil {
-			log.Printf("error retrieving nodes list, continuing to wait: %s", err)
-			return false, nil
+// deleteMachinePool deletes the machinepool, which may already be deleted when resuming
+func deleteMachinePool(ctx context.Context, hiveAdmin client.Client, mp *hivev1.MachinePool) error {
+	log.Printf("deleting machinepool %s", mp.Name)
+	if err := hiveAdmin.Delete(ctx, mp.DeepCopy()); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Printf("machinepool %s is already deleted", mp.Name)
+			return nil
 		}
-
-		readyNodes := countReadyNodes(nodes)
-		expected := int(*originalMp.Spec.Replicas) * 2
-		log.Printf("waiting for %d infra nodes to be reporting Ready, found %d", expected, readyNodes)
-
-		return readyNodes >= expected, nil
-	}); err != nil {
 		return err
 	}
+	return nil
+}
 
-	// Build selectors for original vs temp nodes
-	requireInfra, err := labels.NewRequirement(InfraNodeLabel, selection.Exists, nil)
-	if err != nil {
-		return err
-	}
-	requireNotTempNode, err := labels.NewRequirement(TemporaryInfraNodeLabel, selection.DoesNotExist, nil)
-	if err != nil {
-		return err
-	}
-	requireTempNode, err := labels.NewRequirement(TemporaryInfraNodeLabel, selection.Exists, nil)
+func waitForReadyInfraNodes(ctx context.Context, clusterClient client.Client, expected int) error {
+	selector, err := labels.Parse(InfraNodeLabel)
 	if err != nil {
 		return err
 	}
-
-	originalNodeSelector := selector.Add(*requireInfra, *requireNotTempNode)
-	tempNodeSelector := selector.Add(*requireInfra, *requireTempNode)
-
-	originalNodes := &corev1.NodeList{}
-	if err := clients.ClusterClient.List(ctx, originalNodes, &client.ListOptions{LabelSelector: originalNodeSelector}); err != nil {
-		return err
-	}
-
-	// Delete original machinepool
-	log.Printf("deleting original machinepool %s", originalMp.Name)
-	if err := clients.HiveAdmin.Delete(ctx, originalMp); err != nil {
-		return err
-	}
-
-	// Wait for original machinepool to delete
-	if err := waitForMachinePoolDeletion(ctx, clients.HiveClient, originalMp); err != nil {
-		return err
-	}
-
-	// Wait for original nodes to delete
-	if err := waitForNodesDeletion(ctx, clients.ClusterClient, originalNodeSelector, onTimeout, originalNodes); err != nil {
-		return err
-	}
-
-	// Create new permanent machinepool
-	log.Printf("creating new permanent machinepool %s", newMp.Name)
-	if err := clients.HiveAdmin.Create(ctx, newMp); err != nil {
-		return err
-	}
-
-	// Wait for new permanent machines to become nodes
-	pollCtx2, cancel2 := context.WithTimeout(ctx, pollTimeout)
-	defer cancel2()
-	if err := wait.PollUntilContextTimeout(pollCtx2, pollInterval, pollTimeout, true, func(ctx context.Context) (bool, error) {
+	pollCtx, cancel := context.WithTimeout(ctx, pollTimeout)
+	defer cancel()
+	return wait.PollUntilContextTimeout(pollCtx, pollInterval, pollTimeout, true, func(ctx context.Context) (bool, error) {
 		nodes := &corev1.NodeList{}
-		infraSelector, err := labels.Parse("node-role.kubernetes.io/infra=")
-		if err != nil {
-			return false, err
-		}
-		if err := clients.ClusterClient.List(ctx, nodes, &client.ListOptions{LabelSelector: infraSelector}); err != nil {
+		if err := clusterClient.List(ctx, nodes, &client.ListOptions{LabelSelector: selector}); err != nil {
 			log.Printf("error retrieving nodes list, continuing to wait: %s", err)
 			return false, nil
 		}
 
 		readyNodes := countReadyNodes(nodes)
-		expected := int(*originalMp.Spec.Replicas) * 2
 		log.Printf("waiting for %d infra nodes to be reporting Ready, found %d", expected, readyNodes)
 
 		return readyNodes >= expected, nil
-	}); err != nil {
-		return err
-	}
-
-	tempNodes := &corev1.NodeList{}
-	if err := clients.ClusterClient.List(ctx, tempNodes, &client.ListOptions{LabelSelector: tempNodeSelector}); err != nil {
-		return err
-	}
-
-	// Delete temp machinepool
-	log.Printf("deleting temporary machinepool %s", tempMp.Name)
-	if err := clients.HiveAdmin.Delete(ctx, tempMp); err != nil {
-		return err
-	}
-
-	// Wait for temporary machinepool to delete
-	if err := waitForMachinePoolDeletion(ctx, clients.HiveClient, tempMp); err != nil {
-		return err
-	}
+	})
+}
 
-	// Wait for infra node count to return to normal
-	log.Printf("waiting for infra node count to return to: %d", int(*originalMp.Spec.Replicas))
-	pollCtx3, cancel3 := context.WithTimeout(ctx, pollTimeout)
-	defer cancel3()
-	if err := wait.PollUntilContextTimeout(pollCtx3, pollInterval, pollTimeout, true, func(ctx context.Context) (bool, error) {
+// waitForInfraNodeCount waits for the number of infra nodes to return to normal, terminating the
+// nodes of the temporary machinepool with onTimeout if they don't drain in time
+func waitForInfraNodeCount(ctx context.Context, clusterClient client.Client, replicas int, onTimeout func(ctx context.Context, nodes *corev1.NodeList) error, tempNodes *corev1.NodeList, tempNodeSelector labels.Selector) error {
+	log.Printf("waiting for infra node count to return to: %d", replicas)
+	pollCtx, cancel := context.WithTimeout(ctx, pollTimeout)
+	defer cancel()
+	err := wait.PollUntilContextTimeout(pollCtx, pollInterval, pollTimeout, true, func(ctx context.Context) (bool, error) {
 		nodes := &corev1.NodeList{}
 		infraSelector, err := labels.Parse("node-role.kubernetes.io/infra=")
 		if err != nil {
 			return false, err
 		}
-		if err := clients.ClusterClient.List(ctx, nodes, &client.ListOptions{LabelSelector: infraSelector}); err != nil {
+		if err := clusterClient.List(ctx, nodes, &client.ListOptions{LabelSelector: infraSelector}); err != nil {
 			log.Printf("error retrieving nodes list, continuing to wait: %s", err)
 			return false, nil
 		}
 
 		switch len(nodes.Items) {
-		case int(*originalMp.Spec.Replicas):
+		case replicas:
 			log.Printf("found %d infra nodes, replacement complete", len(nodes.Items))
 			return true, nil
 		default:
 			log.Printf("found %d infra nodes, continuing to wait", len(nodes.Items))
 			return false, nil
 		}
-	}); err != nil {
-		if wait.Interrupted(err) && onTimeout != nil {
-			log.Printf("Warning: timed out waiting for nodes to drain: %v. Terminating backing cloud instances.", err.Error())
-			if err := onTimeout(ctx, tempNodes); err != nil {
-				return err
-			}
-			if err := waitForNodesGone(ctx, clients.ClusterClient, tempNodeSelector); err != nil {
-				return err
-			}
-		} else {
+	})
+	if err != nil && wait.Interrupted(err) && onTimeout != nil && ctx.Err() == nil {
+		log.Printf("Warning: timed out waiting for nodes to drain: %v. Terminating backing cloud instances.", err.Error())
+		if err := onTimeout(ctx, tempNodes); err != nil {
 			return err
 		}
+		return waitForNodesGone(ctx, clusterClient, tempNodeSelector)
 	}
-
-	return nil
+	return err
 }
 
 func waitForMachinePoolDeletion(ctx context.Context, hiveClient client.Client, mp *hivev1.MachinePool) error {
@@ -275,7 +319,7 @@ func waitForNodesDeletion(ctx context.Context, clusterClient client.Client, sele
 	if err := wait.PollUntilContextTimeout(pollCtx, pollInterval, pollTimeout, true, func(ctx context.Context) (bool, error) {
 		return nodesMatchExpectedCount(ctx, clusterClient, selector, 0)
 	}); err != nil {
-		if wait.Interrupted(err) && onTimeout != nil {
+		if wait.Interrupted(err) && onTimeout != nil && ctx.Err() == nil {
 			log.Printf("Warning: timed out waiting for nodes to drain: %v. Terminating backing cloud instances.", err.Error())
 			if err := onTimeout(ctx, originalNodes); err != nil {
 				return err
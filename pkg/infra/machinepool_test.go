@@ -7,9 +7,13 @@ import (
 
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	hivev1aws "github.com/openshift/hive/apis/hive/v1/aws"
+	"github.com/openshift/osdctl/pkg/operation"
+	"github.com/openshift/osdctl/pkg/operation/operationtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -348,3 +352,89 @@ func TestNodesMatchExpectedCount(t *testing.T) {
 func int64Ptr(i int64) *int64 {
 	return &i
 }
+
+func infraNode(name string, temporary bool) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{InfraNodeLabel: ""}},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	if temporary {
+		node.Labels[TemporaryInfraNodeLabel] = ""
+	}
+	return node
+}
+
+func TestMachinePoolDanceStepsResume(t *testing.T) {
+	ctx := context.Background()
+	originalMp := &hivev1.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster-infra", Namespace: "uhc-production-test"},
+		Spec: hivev1.MachinePoolSpec{
+			Name:     "infra",
+			Replicas: int64Ptr(2),
+			Labels:   map[string]string{InfraNodeLabel: ""},
+			Platform: hivev1.MachinePoolPlatform{AWS: &hivev1aws.MachinePoolPlatform{InstanceType: "r5.xlarge"}},
+		},
+	}
+	newMp, err := CloneMachinePool(originalMp, func(mp *hivev1.MachinePool) error {
+		mp.Spec.Platform.AWS.InstanceType = "r5.2xlarge"
+		return nil
+	})
+	require.NoError(t, err)
+
+	hive := operationtest.NewFakeClient(t, originalMp.DeepCopy())
+	cluster := operationtest.NewFakeClient(t,
+		infraNode("infra-a", false), infraNode("infra-b", false),
+		infraNode("infra2-a", true), infraNode("infra2-b", true))
+	clients := DanceClients{ClusterClient: cluster, HiveClient: hive, HiveAdmin: hive}
+
+	h := operationtest.New(t)
+	state := operation.NewState("resize-infra", "test", nil)
+	require.NoError(t, SaveDanceMachinePools(state, originalMp, newMp))
+	steps := MachinePoolDanceSteps(clients, originalMp, newMp, nil)
+
+	// osdctl is killed once the original machinepool is deleted
+	require.NoError(t, h.RunUntil(ctx, state, steps, "delete-original-machinepool"))
+	err = hive.Get(ctx, client.ObjectKeyFromObject(originalMp), &hivev1.MachinePool{})
+	assert.True(t, apierrors.IsNotFound(err), "the original machinepool is deleted")
+	temp := &hivev1.MachinePool{}
+	require.NoError(t, hive.Get(ctx, client.ObjectKey{Namespace: originalMp.Namespace, Name: "test-cluster-infra2"}, temp))
+	assert.Equal(t, "infra2", temp.Spec.Name)
+	assert.Equal(t, "r5.2xlarge", temp.Spec.Platform.AWS.InstanceType)
+
+	// The machinepools can't be read from Hive anymore and are resumed from the state
+	saved := h.State("test", "resize-infra")
+	resumedOriginal, resumedNew, err := LoadDanceMachinePools(saved)
+	require.NoError(t, err)
+	assert.Equal(t, originalMp.Name, resumedOriginal.Name)
+	assert.Equal(t, "r5.2xlarge", resumedNew.Spec.Platform.AWS.InstanceType)
+
+	// Meanwhile the original nodes are drained
+	require.NoError(t, cluster.Delete(ctx, infraNode("infra-a", false)))
+	require.NoError(t, cluster.Delete(ctx, infraNode("infra-b", false)))
+
+	steps = MachinePoolDanceSteps(clients, resumedOriginal, resumedNew, nil)
+	require.NoError(t, h.RunUntil(ctx, saved, steps, "create-machinepool"))
+	require.NoError(t, cluster.Create(ctx, infraNode("infra-c", false)))
+	require.NoError(t, cluster.Create(ctx, infraNode("infra-d", false)))
+
+	require.NoError(t, h.RunUntil(ctx, saved, steps, "delete-temporary-machinepool"))
+	permanent := &hivev1.MachinePool{}
+	require.NoError(t, hive.Get(ctx, client.ObjectKeyFromObject(originalMp), permanent))
+	assert.Equal(t, "r5.2xlarge", permanent.Spec.Platform.AWS.InstanceType)
+	err = hive.Get(ctx, client.ObjectKeyFromObject(temp), &hivev1.MachinePool{})
+	assert.True(t, apierrors.IsNotFound(err), "the temporary machinepool is deleted")
+
+	require.NoError(t, cluster.Delete(ctx, infraNode("infra2-a", true)))
+	require.NoError(t, cluster.Delete(ctx, infraNode("infra2-b", true)))
+	require.NoError(t, h.Run(ctx, saved, steps))
+	assert.True(t, h.State("test", "resize-infra").Finished())
+
+	// Running the steps again is harmless, the machinepools being created and deleted already
+	for _, step := range steps {
+		if step.Name == "create-temporary-machinepool" || step.Name == "delete-temporary-machinepool" || step.Name == "create-machinepool" {
+			assert.NoError(t, step.Run(ctx), step.Name)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Package operation runs the steps of long node replacement workflows. The progress of every
+// cluster and operation is persisted after each step so that a run interrupted by a failure or a
+// lost terminal can be resumed where it stopped instead of leaving the cluster half-migrated.
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Status is the progress of a step in the state of an operation
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	// StatusRunning is recorded before a step starts, a step still running when resuming was
+	// interrupted and is run again
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusSkipped Status = "skipped"
+	StatusFailed  Status = "failed"
+)
+
+// Step is a unit of work of an operation. A step is run again when resuming after it failed or was
+// interrupted, so Run must succeed when what it does has already been partially or fully applied.
+type Step struct {
+	// Name identifies the step in the persisted state and must be unique within the operation
+	Name string
+	// Description is shown in the plan and when the step starts
+	Description string
+	Run         func(ctx context.Context) error
+	// Force is an optional alternative to Run offered once Run failed, e.g. a drain that ignores
+	// pod disruption budgets
+	Force func(ctx context.Context) error
+	// Skippable steps may be skipped once they failed, the following steps not depending on them
+	Skippable bool
+}
+
+// StepState is the progress of a step
+type StepState struct {
+	Name     string    `json:"name"`
+	Status   Status    `json:"status"`
+	Attempts int       `json:"attempts,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Updated  time.Time `json:"updated"`
+}
+
+// State is the persisted progress of an operation on a cluster
+type State struct {
+	Operation string `json:"operation"`
+	ClusterID string `json:"clusterId"`
+	// Params are the parameters the operation was started with, a run can only be resumed with the
+	// same parameters
+	Params  map[string]string `json:"params,omitempty"`
+	Created time.Time         `json:"created"`
+	Updated time.Time         `json:"updated"`
+	Steps   []StepState       `json:"steps"`
+	// Values are what the steps need to know about the cluster as it was before the operation
+	// started, e.g. a resource the operation deletes
+	Values map[string]json.RawMessage `json:"values,omitempty"`
+}
+
+// NewState returns the state of an operation that has not started yet
+func NewState(operation, clusterID string, params map[string]string) *State {
+	now := time.Now().UTC()
+	return &State{
+		Operation: operation,
+		ClusterID: clusterID,
+		Params:    params,
+		Created:   now,
+		Updated:   now,
+	}
+}
+
+// Step returns the progress of a step, nil if the step is not part of the state
+func (s *State) Step(name string) *StepState {
+	for i := range s.Steps {
+		if s.Steps[i].Name == name {
+			return &s.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Current returns the first step that is neither done nor skipped, nil if there is none
+func (s *State) Current() *StepState {
+	for i := range s.Steps {
+		if !s.Steps[i].Status.finished() {
+			return &s.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Finished reports whether every step of the operation is done or skipped
+func (s *State) Finished() bool {
+	return len(s.Steps) > 0 && s.Current() == nil
+}
+
+// Value decodes the value recorded for key into v and reports whether there was one
+func (s *State) Value(key string, v any) (bool, error) {
+	raw, ok := s.Values[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("failed to decode %s from the state of %s: %w", key, s.Operation, err)
+	}
+	return true, nil
+}
+
+// SetValue records v for key, it is persisted with the progress of the steps
+func (s *State) SetValue(key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	if s.Values == nil {
+		s.Values = map[string]json.RawMessage{}
+	}
+	s.Values[key] = raw
+	return nil
+}
+
+func (s Status) finished() bool {
+	return s == StatusDone || s == StatusSkipped
+}
+
+// Store persists the states of operations, one file per cluster and operation
+type Store struct {
+	dir string
+}
+
+// NewStore returns the store located in the user cache directory
+func NewStore() (*Store, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreAt(filepath.Join(cacheDir, "osdctl", "operations")), nil
+}
+
+// NewStoreAt returns a store located in dir
+func NewStoreAt(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Path returns the file holding the state of an operation on a cluster
+func (s *Store) Path(clusterID, operation string) string {
+	return filepath.Join(s.dir, clusterID, operation+".json")
+}
+
+// Load returns the state of an operation on a cluster, nil if it was never run
+func (s *Store) Load(clusterID, operation string) (*State, error) {
+	data, err := os.ReadFile(s.Path(clusterID, operation))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read the state of %s: %w", operation, err)
+	}
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid state of %s in %s: %w", operation, s.Path(clusterID, operation), err)
+	}
+	return state, nil
+}
+
+// Save writes the state of an operation. The file is replaced atomically so that the state is never
+// left truncated when osdctl is killed while writing it.
+func (s *Store) Save(state *State) error {
+	path := s.Path(state.ClusterID, state.Operation)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write the state of %s: %w", state.Operation, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write the state of %s: %w", state.Operation, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the state of %s: %w", state.Operation, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
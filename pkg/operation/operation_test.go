@@ -0,0 +1,309 @@
+package operation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder returns steps appending their name to calls, the step named failing returning the
+// errors in order until there are none left
+func recorder(calls *[]string, failing string, errs ...error) []Step {
+	var steps []Step
+	for _, name := range []string{"first", "second", "third"} {
+		steps = append(steps, Step{
+			Name:        name,
+			Description: "Run " + name,
+			Run: func(ctx context.Context) error {
+				*calls = append(*calls, name)
+				if name == failing && len(errs) > 0 {
+					err := errs[0]
+					errs = errs[1:]
+					return err
+				}
+				return nil
+			},
+		})
+	}
+	return steps
+}
+
+func testRunner(t *testing.T, policy Policy, input string) (*Runner, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return &Runner{
+		Store:  NewStoreAt(t.TempDir()),
+		Policy: policy,
+		In:     strings.NewReader(input),
+		Out:    out,
+	}, out
+}
+
+func TestRunResumesAfterFailedStep(t *testing.T) {
+	var calls []string
+	runner, _ := testRunner(t, Policy{OnFailure: Abort}, "")
+	steps := recorder(&calls, "second", errors.New("drain timed out"))
+
+	err := runner.Run(context.Background(), NewState("resize", "cluster-1", nil), steps)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "second failed: drain timed out")
+	assert.Contains(t, err.Error(), "--resume")
+	assert.Equal(t, []string{"first", "second"}, calls)
+
+	state, err := runner.Store.Load("cluster-1", "resize")
+	require.NoError(t, err)
+	assert.Equal(t, StatusDone, state.Step("first").Status)
+	assert.Equal(t, StatusFailed, state.Step("second").Status)
+	assert.Equal(t, "drain timed out", state.Step("second").Error)
+	assert.Equal(t, StatusPending, state.Step("third").Status)
+	assert.Equal(t, "second", state.Current().Name)
+
+	calls = nil
+	require.NoError(t, runner.Run(context.Background(), state, steps))
+	assert.Equal(t, []string{"second", "third"}, calls, "the steps already done are not run again")
+
+	state, err = runner.Store.Load("cluster-1", "resize")
+	require.NoError(t, err)
+	assert.True(t, state.Finished())
+	assert.Equal(t, 2, state.Step("second").Attempts)
+	assert.Empty(t, state.Step("second").Error)
+}
+
+func TestRunStopsWhenInterrupted(t *testing.T) {
+	var calls []string
+	runner, _ := testRunner(t, Policy{OnFailure: Abort}, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	steps := recorder(&calls, "")
+	steps[0].Run = func(ctx context.Context) error {
+		calls = append(calls, "first")
+		cancel()
+		return nil
+	}
+
+	err := runner.Run(ctx, NewState("resize", "cluster-1", nil), steps)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"first"}, calls)
+
+	state, err := runner.Store.Load("cluster-1", "resize")
+	require.NoError(t, err)
+	assert.Equal(t, "second", state.Current().Name)
+}
+
+func TestRunPolicies(t *testing.T) {
+	failure := errors.New("failure")
+	tests := []struct {
+		name      string
+		policy    Policy
+		input     string
+		skippable bool
+		force     bool
+		errs      []error
+		wantErr   bool
+		wantCalls []string
+		want      Status
+	}{
+		{
+			name:      "retries before the failure action",
+			policy:    Policy{Retries: 2, OnFailure: Abort},
+			errs:      []error{failure, failure},
+			wantCalls: []string{"first", "second", "second", "second", "third"},
+			want:      StatusDone,
+		},
+		{
+			name:      "aborts once the retries are exhausted",
+			policy:    Policy{Retries: 1, OnFailure: Abort},
+			errs:      []error{failure, failure},
+			wantErr:   true,
+			wantCalls: []string{"first", "second", "second"},
+			want:      StatusFailed,
+		},
+		{
+			name:      "skips skippable steps",
+			policy:    Policy{OnFailure: Skip},
+			skippable: true,
+			errs:      []error{failure},
+			wantCalls: []string{"first", "second", "third"},
+			want:      StatusSkipped,
+		},
+		{
+			name:      "aborts on steps that can't be skipped",
+			policy:    Policy{OnFailure: Skip},
+			errs:      []error{failure},
+			wantErr:   true,
+			wantCalls: []string{"first", "second"},
+			want:      StatusFailed,
+		},
+		{
+			name:      "forces steps with an alternative",
+			policy:    Policy{OnFailure: Force},
+			force:     true,
+			errs:      []error{failure},
+			wantCalls: []string{"first", "second", "force second", "third"},
+			want:      StatusDone,
+		},
+		{
+			name:      "aborts on steps without an alternative",
+			policy:    Policy{OnFailure: Force},
+			errs:      []error{failure},
+			wantErr:   true,
+			wantCalls: []string{"first", "second"},
+			want:      StatusFailed,
+		},
+		{
+			name:      "prompts until a valid answer",
+			policy:    Policy{OnFailure: Prompt},
+			input:     "skip\nRETRY\n",
+			errs:      []error{failure},
+			wantCalls: []string{"first", "second", "second", "third"},
+			want:      StatusDone,
+		},
+		{
+			name:      "prompt skips skippable steps",
+			policy:    Policy{OnFailure: Prompt},
+			input:     "skip\n",
+			skippable: true,
+			errs:      []error{failure},
+			wantCalls: []string{"first", "second", "third"},
+			want:      StatusSkipped,
+		},
+		{
+			name:      "prompt cancels",
+			policy:    Policy{OnFailure: Prompt},
+			input:     "cancel\n",
+			errs:      []error{failure},
+			wantErr:   true,
+			wantCalls: []string{"first", "second"},
+			want:      StatusFailed,
+		},
+		{
+			name:      "prompt aborts without a terminal",
+			policy:    Policy{OnFailure: Prompt},
+			errs:      []error{failure},
+			wantErr:   true,
+			wantCalls: []string{"first", "second"},
+			want:      StatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			runner, _ := testRunner(t, tt.policy, tt.input)
+			steps := recorder(&calls, "second", tt.errs...)
+			steps[1].Skippable = tt.skippable
+			if tt.force {
+				steps[1].Force = func(ctx context.Context) error {
+					calls = append(calls, "force second")
+					return nil
+				}
+			}
+
+			state := NewState("resize", "cluster-1", nil)
+			err := runner.Run(context.Background(), state, steps)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.want, state.Step("second").Status)
+		})
+	}
+}
+
+func TestRunRejectsDuplicateSteps(t *testing.T) {
+	var calls []string
+	runner, _ := testRunner(t, Policy{OnFailure: Abort}, "")
+	steps := recorder(&calls, "")
+	steps[2].Name = "first"
+
+	err := runner.Run(context.Background(), NewState("resize", "cluster-1", nil), steps)
+	assert.EqualError(t, err, "step first is defined twice in resize")
+	assert.Empty(t, calls)
+}
+
+func TestStart(t *testing.T) {
+	store := NewStoreAt(t.TempDir())
+	params := map[string]string{"instance-type": "r5.2xlarge"}
+
+	_, err := (&Options{Resume: true}).Start(store, "resize", "cluster-1", params)
+	assert.EqualError(t, err, "there is no interrupted resize operation to resume for cluster cluster-1")
+
+	state, err := (&Options{}).Start(store, "resize", "cluster-1", params)
+	require.NoError(t, err)
+	state.Steps = []StepState{{Name: "first", Status: StatusDone}, {Name: "second", Status: StatusFailed}}
+	require.NoError(t, state.SetValue("machinepool", map[string]int{"replicas": 3}))
+	require.NoError(t, store.Save(state))
+
+	_, err = (&Options{}).Start(store, "resize", "cluster-1", params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "was interrupted at step second, run the command again with --resume")
+	assert.Contains(t, err.Error(), store.Path("cluster-1", "resize"))
+
+	_, err = (&Options{Resume: true}).Start(store, "resize", "cluster-1", map[string]string{"instance-type": "r5.4xlarge"})
+	assert.EqualError(t, err, `the interrupted resize operation of cluster cluster-1 was started with --instance-type="r5.2xlarge", run the command again with the same parameters to resume it`)
+
+	resumed, err := (&Options{Resume: true}).Start(store, "resize", "cluster-1", params)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDone, resumed.Step("first").Status)
+	var machinepool map[string]int
+	found, err := resumed.Value("machinepool", &machinepool)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, map[string]int{"replicas": 3}, machinepool)
+
+	resumed.Step("second").Status = StatusSkipped
+	require.NoError(t, store.Save(resumed))
+	fresh, err := (&Options{}).Start(store, "resize", "cluster-1", params)
+	require.NoError(t, err)
+	assert.Empty(t, fresh.Steps, "a finished operation can be run again")
+}
+
+func TestExecute(t *testing.T) {
+	var calls []string
+	runner, out := testRunner(t, Policy{OnFailure: Abort}, "")
+	state := NewState("resize", "cluster-1", nil)
+	state.Steps = []StepState{{Name: "first", Status: StatusDone}, {Name: "second", Status: StatusFailed, Error: "drain timed out"}}
+	steps := recorder(&calls, "")
+
+	require.NoError(t, (&Options{Plan: true}).Execute(context.Background(), runner, state, steps))
+	assert.Empty(t, calls, "only the plan is printed")
+	assert.Equal(t, `Plan of resize for cluster cluster-1:
+   1. done      Run first
+   2. failed    Run second
+                last error: drain timed out
+   3. pending   Run third
+
+`, out.String())
+
+	runner.Confirm = func() bool { return false }
+	assert.EqualError(t, (&Options{}).Execute(context.Background(), runner, state, steps), "aborted by user")
+	assert.Empty(t, calls)
+
+	require.NoError(t, (&Options{Resume: true}).Execute(context.Background(), runner, state, steps))
+	assert.Equal(t, []string{"second", "third"}, calls, "resumed runs aren't confirmed again")
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, (&Options{OnFailure: "abort"}).Validate())
+	assert.EqualError(t, (&Options{OnFailure: "ignore"}).Validate(), `invalid --on-failure "ignore" (must be one of: prompt, skip, force, abort)`)
+	assert.EqualError(t, (&Options{OnFailure: "skip", Retries: -1}).Validate(), "--retries must not be negative")
+}
+
+func TestStoreIgnoresMissingState(t *testing.T) {
+	store := NewStoreAt(t.TempDir())
+	state, err := store.Load("cluster-1", "resize")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(store.Path("cluster-1", "resize")), 0755))
+	require.NoError(t, os.WriteFile(store.Path("cluster-1", "resize"), []byte("{"), 0600))
+	_, err = store.Load("cluster-1", "resize")
+	assert.ErrorContains(t, err, "invalid state of resize")
+}
@@ -0,0 +1,138 @@
+// Package operationtest runs operations in tests, against fake clients and without prompts
+package operationtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/osdctl/pkg/operation"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// NewScheme returns a scheme registering the resources read and written by node replacement
+// operations, on the cluster and on Hive
+func NewScheme(t testing.TB) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	for _, install := range []func(*runtime.Scheme) error{
+		corev1.AddToScheme,
+		configv1.Install,
+		operatorv1.Install,
+		machinev1.Install,
+		machinev1beta1.Install,
+		hivev1.AddToScheme,
+	} {
+		if err := install(scheme); err != nil {
+			t.Fatalf("failed to build scheme: %v", err)
+		}
+	}
+	return scheme
+}
+
+// NewFakeClient returns a fake client holding the objects
+func NewFakeClient(t testing.TB, objs ...client.Object) client.Client {
+	t.Helper()
+	return fake.NewClientBuilder().WithScheme(NewScheme(t)).WithObjects(objs...).Build()
+}
+
+// Harness runs operations with a store in a temporary directory. A failed step aborts the
+// operation unless the policy says otherwise, prompts reading their answers from Answers.
+type Harness struct {
+	t      testing.TB
+	Store  *operation.Store
+	Policy operation.Policy
+	// Answers are the lines read by the prompts about failed steps
+	Answers []string
+	// Out holds the progress reported by the runs
+	Out *bytes.Buffer
+}
+
+// New returns a harness whose store is removed at the end of the test
+func New(t testing.TB) *Harness {
+	return &Harness{
+		t:      t,
+		Store:  operation.NewStoreAt(t.TempDir()),
+		Policy: operation.Policy{OnFailure: operation.Abort},
+		Out:    &bytes.Buffer{},
+	}
+}
+
+// Runner returns a runner with the store, policy and answers of the harness
+func (h *Harness) Runner() *operation.Runner {
+	input := ""
+	if len(h.Answers) > 0 {
+		input = strings.Join(h.Answers, "\n") + "\n"
+	}
+	return &operation.Runner{
+		Store:  h.Store,
+		Policy: h.Policy,
+		In:     strings.NewReader(input),
+		Out:    h.Out,
+	}
+}
+
+// Run runs the steps of an operation, resuming its saved state if it is unfinished
+func (h *Harness) Run(ctx context.Context, state *operation.State, steps []operation.Step) error {
+	h.t.Helper()
+	saved, err := h.Store.Load(state.ClusterID, state.Operation)
+	if err != nil {
+		h.t.Fatalf("failed to load state: %v", err)
+	}
+	if saved != nil && !saved.Finished() {
+		state = saved
+	}
+	return h.Runner().Run(ctx, state, steps)
+}
+
+// RunUntil runs the steps of an operation as if osdctl was killed right after the step named last
+// completed, the following steps being left for a resumed run
+func (h *Harness) RunUntil(ctx context.Context, state *operation.State, steps []operation.Step, last string) error {
+	h.t.Helper()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	interrupted := make([]operation.Step, len(steps))
+	copy(interrupted, steps)
+	for i, step := range interrupted {
+		if step.Name != last {
+			continue
+		}
+		run := step.Run
+		interrupted[i].Run = func(ctx context.Context) error {
+			err := run(ctx)
+			if err == nil {
+				cancel()
+			}
+			return err
+		}
+	}
+
+	if err := h.Run(ctx, state, interrupted); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+// State returns the saved state of an operation, failing the test if there is none
+func (h *Harness) State(clusterID, name string) *operation.State {
+	h.t.Helper()
+	state, err := h.Store.Load(clusterID, name)
+	if err != nil {
+		h.t.Fatalf("failed to load state: %v", err)
+	}
+	if state == nil {
+		h.t.Fatalf("no state saved for %s of cluster %s", name, clusterID)
+	}
+	return state
+}
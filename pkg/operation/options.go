@@ -0,0 +1,127 @@
+package operation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/pflag"
+)
+
+// Options are the flags of the commands running operations
+type Options struct {
+	Resume     bool
+	Plan       bool
+	OnFailure  string
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// AddFlags adds the flags controlling how an operation is run
+func (o *Options) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.Resume, "resume", false, "Resume the interrupted run of this operation on the cluster, skipping the steps already done")
+	flags.BoolVar(&o.Plan, "plan", false, "Print the steps of the operation and their progress, then exit without making changes")
+	flags.StringVar(&o.OnFailure, "on-failure", string(Prompt), "What to do when a step still fails after its retries: prompt, skip (skippable steps only, abort otherwise), force (steps with a forced alternative only, abort otherwise) or abort")
+	flags.IntVar(&o.Retries, "retries", 0, "Number of times a failing step is retried before --on-failure applies")
+	flags.DurationVar(&o.RetryDelay, "retry-delay", 30*time.Second, "Delay between the retries of a failing step")
+}
+
+// Validate checks the values of the flags
+func (o *Options) Validate() error {
+	if !slices.Contains(FailureActions, FailureAction(o.OnFailure)) {
+		return fmt.Errorf("invalid --on-failure %q (must be one of: prompt, skip, force, abort)", o.OnFailure)
+	}
+	if o.Retries < 0 {
+		return errors.New("--retries must not be negative")
+	}
+	if o.RetryDelay < 0 {
+		return errors.New("--retry-delay must not be negative")
+	}
+	return nil
+}
+
+// Start returns the state to run an operation with: the state of its interrupted run when
+// resuming, a new state otherwise. Starting over while a run is unfinished is refused, so that a
+// half-migrated cluster is not planned again from its current, partial, state.
+func (o *Options) Start(store *Store, operation, clusterID string, params map[string]string) (*State, error) {
+	state, err := store.Load(clusterID, operation)
+	if err != nil {
+		return nil, err
+	}
+	unfinished := state != nil && state.Current() != nil
+
+	switch {
+	case o.Resume && !unfinished:
+		return nil, fmt.Errorf("there is no interrupted %s operation to resume for cluster %s", operation, clusterID)
+	case o.Resume:
+		if !maps.Equal(state.Params, params) {
+			return nil, fmt.Errorf("the interrupted %s operation of cluster %s was started with %s, run the command again with the same parameters to resume it", operation, clusterID, formatParams(state.Params))
+		}
+		return state, nil
+	case unfinished:
+		return nil, fmt.Errorf("the %s operation of cluster %s was interrupted at step %s, run the command again with --resume to continue it, or remove %s to start over", operation, clusterID, state.Current().Name, store.Path(clusterID, operation))
+	}
+	return NewState(operation, clusterID, params), nil
+}
+
+// Runner returns a runner applying the failure policy of the flags, prompting on the terminal
+func (o *Options) Runner(store *Store) *Runner {
+	return &Runner{
+		Store: store,
+		Policy: Policy{
+			Retries:    o.Retries,
+			RetryDelay: o.RetryDelay,
+			OnFailure:  FailureAction(o.OnFailure),
+		},
+		In:      os.Stdin,
+		Out:     os.Stdout,
+		Confirm: utils.ConfirmPrompt,
+	}
+}
+
+// Execute prints the plan of the operation then, unless only the plan was requested, runs its
+// steps once the plan is confirmed. A resumed run isn't confirmed again.
+func (o *Options) Execute(ctx context.Context, runner *Runner, state *State, steps []Step) error {
+	WritePlan(runner.Out, state, steps)
+	if o.Plan {
+		return nil
+	}
+	if !o.Resume && runner.Confirm != nil && !runner.Confirm() {
+		return errors.New("aborted by user")
+	}
+	return runner.Run(ctx, state, steps)
+}
+
+// WritePlan prints the steps of an operation and their progress in the state
+func WritePlan(w io.Writer, state *State, steps []Step) {
+	fmt.Fprintf(w, "Plan of %s for cluster %s:\n", state.Operation, state.ClusterID)
+	for i, step := range steps {
+		status, lastError := StatusPending, ""
+		if progress := state.Step(step.Name); progress != nil {
+			status, lastError = progress.Status, progress.Error
+		}
+		fmt.Fprintf(w, "  %2d. %-9s %s\n", i+1, status, step.Description)
+		if lastError != "" {
+			fmt.Fprintf(w, "      %-9s last error: %s\n", "", lastError)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func formatParams(params map[string]string) string {
+	if len(params) == 0 {
+		return "no parameters"
+	}
+	var pairs []string
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		pairs = append(pairs, fmt.Sprintf("--%s=%q", key, params[key]))
+	}
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,203 @@
+package operation
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// FailureAction is what happens to a step that still fails after its retries
+type FailureAction string
+
+const (
+	// Prompt asks whether to retry, skip, force the step or cancel the operation
+	Prompt FailureAction = "prompt"
+	// Skip skips skippable steps and aborts on the others
+	Skip FailureAction = "skip"
+	// Force runs the alternative of the step once if it has one and aborts otherwise
+	Force FailureAction = "force"
+	// Abort stops the operation, it can be resumed later
+	Abort FailureAction = "abort"
+)
+
+// FailureActions are the supported failure actions
+var FailureActions = []FailureAction{Prompt, Skip, Force, Abort}
+
+// Policy decides what happens to failing steps
+type Policy struct {
+	// Retries is the number of times a failing step is run again before OnFailure applies
+	Retries    int
+	RetryDelay time.Duration
+	OnFailure  FailureAction
+}
+
+// action is the decision taken about a failed step
+type action int
+
+const (
+	actionRetry action = iota
+	actionSkip
+	actionForce
+	actionAbort
+)
+
+// Runner runs the steps of operations, saving their state after every change of a step
+type Runner struct {
+	Store  *Store
+	Policy Policy
+	// In and Out are used to report the progress and prompt about failed steps
+	In  io.Reader
+	Out io.Writer
+	// Confirm is asked before a new run starts, nil to start without confirmation
+	Confirm func() bool
+}
+
+// Run runs the steps that are neither done nor skipped in the state, in order. It stops at the
+// first step that fails and isn't skipped, or when the context is cancelled, the state then
+// telling where to resume.
+func (r *Runner) Run(ctx context.Context, state *State, steps []Step) error {
+	names := map[string]bool{}
+	for _, step := range steps {
+		if names[step.Name] {
+			return fmt.Errorf("step %s is defined twice in %s", step.Name, state.Operation)
+		}
+		names[step.Name] = true
+		if state.Step(step.Name) == nil {
+			state.Steps = append(state.Steps, StepState{Name: step.Name, Status: StatusPending, Updated: time.Now().UTC()})
+		}
+	}
+	if err := r.Store.Save(state); err != nil {
+		return err
+	}
+
+	prompt := bufio.NewReader(r.In)
+	for i, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		progress := state.Step(step.Name)
+		if progress.Status.finished() {
+			fmt.Fprintf(r.Out, "[%d/%d] %s: %s\n", i+1, len(steps), step.Description, progress.Status)
+			continue
+		}
+		fmt.Fprintf(r.Out, "[%d/%d] %s\n", i+1, len(steps), step.Description)
+		if err := r.runStep(ctx, state, step, prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) runStep(ctx context.Context, state *State, step Step, prompt *bufio.Reader) error {
+	run, forced, retries := step.Run, false, 0
+	for {
+		if err := r.record(state, step.Name, StatusRunning, nil); err != nil {
+			return err
+		}
+		err := run(ctx)
+		if err == nil {
+			return r.record(state, step.Name, StatusDone, nil)
+		}
+		if recordErr := r.record(state, step.Name, StatusFailed, err); recordErr != nil {
+			return recordErr
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s was interrupted: %w", step.Name, err)
+		}
+		fmt.Fprintf(r.Out, "%s failed: %v\n", step.Name, err)
+
+		if retries < r.Policy.Retries {
+			retries++
+			fmt.Fprintf(r.Out, "Retrying %s in %s (%d/%d)\n", step.Name, r.Policy.RetryDelay, retries, r.Policy.Retries)
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%s was interrupted: %w", step.Name, ctx.Err())
+			case <-time.After(r.Policy.RetryDelay):
+			}
+			continue
+		}
+
+		switch r.decide(step, forced, prompt) {
+		case actionRetry:
+			retries = 0
+		case actionForce:
+			run, forced, retries = step.Force, true, 0
+			fmt.Fprintf(r.Out, "Forcing %s\n", step.Name)
+		case actionSkip:
+			fmt.Fprintf(r.Out, "Skipping %s\n", step.Name)
+			return r.record(state, step.Name, StatusSkipped, err)
+		default:
+			return fmt.Errorf("%s failed: %w\nThe progress is saved in %s, run the command again with --resume to continue from this step", step.Name, err, r.Store.Path(state.ClusterID, state.Operation))
+		}
+	}
+}
+
+// decide returns what to do with a step that failed after its retries
+func (r *Runner) decide(step Step, forced bool, prompt *bufio.Reader) action {
+	canForce := step.Force != nil && !forced
+	switch r.Policy.OnFailure {
+	case Skip:
+		if step.Skippable {
+			return actionSkip
+		}
+	case Force:
+		if canForce {
+			return actionForce
+		}
+	case Prompt:
+		return r.prompt(step, canForce, prompt)
+	}
+	return actionAbort
+}
+
+// prompt asks what to do with a failed step until the answer is one of the allowed actions
+func (r *Runner) prompt(step Step, canForce bool, prompt *bufio.Reader) action {
+	choices := []string{"retry"}
+	if step.Skippable {
+		choices = append(choices, "skip")
+	}
+	if canForce {
+		choices = append(choices, "force")
+	}
+	choices = append(choices, "cancel")
+
+	for {
+		fmt.Fprintf(r.Out, "What do you want to do about %s? (%s):\n", step.Name, strings.Join(choices, "/"))
+		line, err := prompt.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			// Without a terminal to answer, the operation stops and can be resumed later
+			return actionAbort
+		}
+		switch answer := strings.ToLower(strings.TrimSpace(line)); {
+		case answer == "retry":
+			return actionRetry
+		case answer == "skip" && step.Skippable:
+			return actionSkip
+		case answer == "force" && canForce:
+			return actionForce
+		case answer == "cancel":
+			return actionAbort
+		}
+		fmt.Fprintf(r.Out, "Invalid response, expected %s (case-insensitive).\n", strings.Join(choices, ", "))
+	}
+}
+
+// record updates the progress of a step and saves the state
+func (r *Runner) record(state *State, name string, status Status, err error) error {
+	progress := state.Step(name)
+	progress.Status = status
+	progress.Updated = time.Now().UTC()
+	progress.Error = ""
+	if status == StatusRunning {
+		progress.Attempts++
+	}
+	if err != nil {
+		progress.Error = err.Error()
+	}
+	state.Updated = progress.Updated
+	return r.Store.Save(state)
+}
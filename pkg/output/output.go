@@ -0,0 +1,416 @@
+// Package output prints the results of osdctl commands in the format selected with --output,
+// so that the result of any command can be consumed the same way by people and scripts.
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats
+const (
+	Table      = "table"
+	Wide       = "wide"
+	JSON       = "json"
+	YAML       = "yaml"
+	CSV        = "csv"
+	Markdown   = "markdown"
+	JSONPath   = "jsonpath"
+	GoTemplate = "go-template"
+
+	// text is the former name of the table format, still accepted by commands which used it
+	text = "text"
+)
+
+// Formats lists the values accepted by --output
+var Formats = []string{Table, Wide, JSON, YAML, CSV, Markdown, JSONPath + "=<template>", GoTemplate + "=<template>"}
+
+// Column is a column of the table, wide, csv and markdown formats
+type Column struct {
+	Name string
+	// Wide columns are only printed by the wide, csv and markdown formats
+	Wide bool
+}
+
+// Result is the typed result of a command
+type Result struct {
+	// Object is printed by the json, yaml, jsonpath and go-template formats
+	Object interface{}
+	// Columns and Rows are printed by the table, wide, csv and markdown formats
+	Columns []Column
+	Rows    [][]string
+	// Text is printed by the table and wide formats when the result has no columns
+	Text string
+	// Markdown is printed by the markdown format when the result has no columns
+	Markdown string
+
+	// items are the elements of Object, in the order of Rows
+	items []interface{}
+}
+
+// NewTable returns the result of a command listing items, printed as one row per item
+func NewTable[T any](items []T, columns []Column, row func(T) []string) *Result {
+	result := &Result{Object: items, Columns: columns, items: make([]interface{}, 0, len(items))}
+	for _, item := range items {
+		result.items = append(result.items, item)
+		result.Rows = append(result.Rows, row(item))
+	}
+	return result
+}
+
+// NewObject returns the result of a command printing a single object, with text as its
+// human readable form
+func NewObject(object interface{}, text string) *Result {
+	return &Result{Object: object, Text: text}
+}
+
+// Options holds the output flags of a command
+type Options struct {
+	// Format is the value of --output, e.g. "wide" or "jsonpath={.items[*].id}"
+	Format    string
+	NoHeaders bool
+	SortBy    string
+
+	// formats are the formats supported by the command, all of them when empty
+	formats []string
+}
+
+// AddFlags adds --output, --no-headers and --sort-by to cmd. The current Format is the default.
+func (o *Options) AddFlags(cmd *cobra.Command) {
+	o.AddFormatFlag(cmd)
+	o.AddNoHeadersFlag(cmd)
+	cmd.Flags().StringVar(&o.SortBy, "sort-by", o.SortBy, "Sort the rows by the given column, e.g. --sort-by=name")
+}
+
+// AddFormatFlag adds --output to cmd. The current Format is the default. Commands which can't
+// print every format list the ones they support.
+func (o *Options) AddFormatFlag(cmd *cobra.Command, formats ...string) {
+	if o.Format == "" {
+		o.Format = Table
+	}
+	o.formats = formats
+	cmd.Flags().StringVarP(&o.Format, "output", "o", o.Format, "Output format. One of: "+strings.Join(o.supportedFormats(), ", "))
+}
+
+// supportedFormats lists the values accepted by --output for the command
+func (o *Options) supportedFormats() []string {
+	if len(o.formats) == 0 {
+		return Formats
+	}
+	var formats []string
+	for _, format := range o.formats {
+		if format == JSONPath || format == GoTemplate {
+			format += "=<template>"
+		}
+		formats = append(formats, format)
+	}
+	return formats
+}
+
+// AddNoHeadersFlag adds --no-headers to cmd
+func (o *Options) AddNoHeadersFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.NoHeaders, "no-headers", o.NoHeaders, "Don't print headers in the table, wide and csv formats")
+}
+
+// format splits the output flag into the format name and its template
+func (o *Options) format() (string, string) {
+	name, tmpl, _ := strings.Cut(o.Format, "=")
+	switch strings.ToLower(name) {
+	case "", text:
+		return Table, tmpl
+	default:
+		return strings.ToLower(name), tmpl
+	}
+}
+
+// Validate checks the output format and its template
+func (o *Options) Validate() error {
+	format, tmpl := o.format()
+	switch format {
+	case Table, Wide, JSON, YAML, CSV, Markdown:
+		if tmpl != "" {
+			return fmt.Errorf("output format %s doesn't take a template", format)
+		}
+	case JSONPath:
+		if _, err := parseJSONPath(tmpl); err != nil {
+			return err
+		}
+	case GoTemplate:
+		if _, err := parseGoTemplate(tmpl); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported output format '%s', valid formats are: %s", o.Format, strings.Join(o.supportedFormats(), ", "))
+	}
+	if len(o.formats) > 0 && !slices.Contains(o.formats, format) {
+		return fmt.Errorf("output format %s is not supported by this command, valid formats are: %s", format, strings.Join(o.supportedFormats(), ", "))
+	}
+	return nil
+}
+
+// FormatName returns the name of the selected format without its template, e.g. jsonpath for
+// -o jsonpath={.id}
+func (o *Options) FormatName() string {
+	format, _ := o.format()
+	return format
+}
+
+// IsStructured reports whether the format prints the object of results rather than rows or text
+func (o *Options) IsStructured() bool {
+	format, _ := o.format()
+	return format == JSON || format == YAML || format == JSONPath || format == GoTemplate
+}
+
+// Print writes the result in the selected format
+func (o *Options) Print(w io.Writer, result *Result) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	if err := result.sort(o.SortBy); err != nil {
+		return err
+	}
+
+	format, tmpl := o.format()
+	switch format {
+	case JSON:
+		out, err := json.MarshalIndent(result.object(), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case YAML:
+		out, err := yaml.Marshal(result.object())
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	case JSONPath:
+		data, err := result.data()
+		if err != nil {
+			return err
+		}
+		parser, _ := parseJSONPath(tmpl)
+		if err := parser.Execute(w, data); err != nil {
+			return fmt.Errorf("error executing jsonpath %q: %w", tmpl, err)
+		}
+		_, err = fmt.Fprintln(w)
+		return err
+	case GoTemplate:
+		data, err := result.data()
+		if err != nil {
+			return err
+		}
+		parser, _ := parseGoTemplate(tmpl)
+		if err := parser.Execute(w, data); err != nil {
+			return fmt.Errorf("error executing template %q: %w", tmpl, err)
+		}
+		return nil
+	case CSV:
+		if len(result.Columns) == 0 {
+			return fmt.Errorf("output format csv is not supported by this command")
+		}
+		writer := csv.NewWriter(w)
+		if !o.NoHeaders {
+			if err := writer.Write(result.headers(true)); err != nil {
+				return err
+			}
+		}
+		for _, row := range result.Rows {
+			if err := writer.Write(result.cells(row, true)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case Markdown:
+		if len(result.Columns) == 0 {
+			if result.Markdown == "" {
+				return fmt.Errorf("output format markdown is not supported by this command")
+			}
+			_, err := fmt.Fprintln(w, result.Markdown)
+			return err
+		}
+		// Markdown tables can't do without headers
+		headers := result.headers(true)
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(markdownCells(headers), " | ")); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(headers))); err != nil {
+			return err
+		}
+		for _, row := range result.Rows {
+			if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(markdownCells(result.cells(row, true)), " | ")); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		if len(result.Columns) == 0 {
+			_, err := fmt.Fprintln(w, result.Text)
+			return err
+		}
+		wide := format == Wide
+		p := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+		if !o.NoHeaders {
+			p.AddRow(result.headers(wide))
+		}
+		for _, row := range result.Rows {
+			p.AddRow(result.cells(row, wide))
+		}
+		return p.Flush()
+	}
+}
+
+// object returns the object to print, with the items in the order of the rows
+func (r *Result) object() interface{} {
+	if r.items != nil {
+		return r.items
+	}
+	return r.Object
+}
+
+// data returns the object as generic JSON data, so templates refer to fields by their JSON names
+func (r *Result) data() (interface{}, error) {
+	out, err := json.Marshal(r.object())
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (r *Result) headers(wide bool) []string {
+	var headers []string
+	for _, c := range r.Columns {
+		if wide || !c.Wide {
+			headers = append(headers, c.Name)
+		}
+	}
+	return headers
+}
+
+func (r *Result) cells(row []string, wide bool) []string {
+	var cells []string
+	for i, c := range r.Columns {
+		if !wide && c.Wide {
+			continue
+		}
+		cell := ""
+		if i < len(row) {
+			cell = row[i]
+		}
+		cells = append(cells, cell)
+	}
+	return cells
+}
+
+// sort orders the rows, and the items of tables, by the named column. Numbers are compared by
+// value, anything else as strings.
+func (r *Result) sort(column string) error {
+	if column == "" {
+		return nil
+	}
+	index := -1
+	var names []string
+	for i, c := range r.Columns {
+		if normalizeColumnName(c.Name) == normalizeColumnName(column) {
+			index = i
+		}
+		names = append(names, strings.ToLower(c.Name))
+	}
+	if index < 0 {
+		return fmt.Errorf("cannot sort by '%s', valid columns are: %s", column, strings.Join(names, ", "))
+	}
+
+	order := make([]int, len(r.Rows))
+	for i := range order {
+		order[i] = i
+	}
+	cell := func(i int) string {
+		if index < len(r.Rows[i]) {
+			return r.Rows[i][index]
+		}
+		return ""
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := cell(order[i]), cell(order[j])
+		if x, err := strconv.ParseFloat(a, 64); err == nil {
+			if y, err := strconv.ParseFloat(b, 64); err == nil {
+				return x < y
+			}
+		}
+		return a < b
+	})
+
+	rows := make([][]string, len(order))
+	for i, j := range order {
+		rows[i] = r.Rows[j]
+	}
+	if len(r.items) == len(r.Rows) {
+		items := make([]interface{}, len(order))
+		for i, j := range order {
+			items[i] = r.items[j]
+		}
+		r.items = items
+	}
+	r.Rows = rows
+	return nil
+}
+
+// markdownCells escapes the cells of a markdown table
+func markdownCells(cells []string) []string {
+	escaped := make([]string, len(cells))
+	replacer := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+	for i, cell := range cells {
+		escaped[i] = replacer.Replace(cell)
+	}
+	return escaped
+}
+
+// normalizeColumnName lets "LIMITED SUPPORT" be selected as limited-support, limited_support
+// or limitedsupport
+func normalizeColumnName(name string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(name))
+}
+
+func parseJSONPath(tmpl string) (*jsonpath.JSONPath, error) {
+	if tmpl == "" {
+		return nil, fmt.Errorf("output format jsonpath requires a template, e.g. -o jsonpath='{.id}'")
+	}
+	parser := jsonpath.New("output").AllowMissingKeys(true)
+	if err := parser.Parse(tmpl); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath %q: %w", tmpl, err)
+	}
+	return parser, nil
+}
+
+func parseGoTemplate(tmpl string) (*template.Template, error) {
+	if tmpl == "" {
+		return nil, fmt.Errorf("output format go-template requires a template, e.g. -o go-template='{{.id}}'")
+	}
+	parser, err := template.New("output").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %w", tmpl, err)
+	}
+	return parser, nil
+}
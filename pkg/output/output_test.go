@@ -0,0 +1,151 @@
+package output
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCluster struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Nodes int    `json:"nodes"`
+}
+
+func newTestResult() *Result {
+	clusters := []testCluster{
+		{ID: "c1", Name: "zeta", Nodes: 12},
+		{ID: "c2", Name: "alpha", Nodes: 3},
+	}
+	columns := []Column{{Name: "ID"}, {Name: "NAME"}, {Name: "NODE COUNT", Wide: true}}
+	return NewTable(clusters, columns, func(c testCluster) []string {
+		return []string{c.ID, c.Name, strconv.Itoa(c.Nodes)}
+	})
+}
+
+func TestPrint(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{
+			name: "table",
+			opts: Options{Format: Table},
+			expected: "ID                  NAME\n" +
+				"c1                  zeta\n" +
+				"c2                  alpha\n",
+		},
+		{
+			name: "former text format without headers",
+			opts: Options{Format: "text", NoHeaders: true},
+			expected: "c1                  zeta\n" +
+				"c2                  alpha\n",
+		},
+		{
+			name: "wide sorted numerically",
+			opts: Options{Format: Wide, SortBy: "node-count"},
+			expected: "ID                  NAME                NODE COUNT\n" +
+				"c2                  alpha               3\n" +
+				"c1                  zeta                12\n",
+		},
+		{
+			name:     "csv",
+			opts:     Options{Format: CSV, SortBy: "name"},
+			expected: "ID,NAME,NODE COUNT\nc2,alpha,3\nc1,zeta,12\n",
+		},
+		{
+			name:     "markdown",
+			opts:     Options{Format: Markdown, NoHeaders: true},
+			expected: "| ID | NAME | NODE COUNT |\n| --- | --- | --- |\n| c1 | zeta | 12 |\n| c2 | alpha | 3 |\n",
+		},
+		{
+			name:     "json sorted",
+			opts:     Options{Format: JSON, SortBy: "NAME"},
+			expected: "[\n  {\n    \"id\": \"c2\",\n    \"name\": \"alpha\",\n    \"nodes\": 3\n  },\n  {\n    \"id\": \"c1\",\n    \"name\": \"zeta\",\n    \"nodes\": 12\n  }\n]\n",
+		},
+		{
+			name:     "yaml",
+			opts:     Options{Format: YAML},
+			expected: "- id: c1\n  name: zeta\n  nodes: 12\n- id: c2\n  name: alpha\n  nodes: 3\n",
+		},
+		{
+			name:     "jsonpath",
+			opts:     Options{Format: "jsonpath={[*].nodes}"},
+			expected: "12 3\n",
+		},
+		{
+			name:     "go-template",
+			opts:     Options{Format: `go-template={{range .}}{{.name}}={{.nodes}};{{end}}`},
+			expected: "zeta=12;alpha=3;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, tt.opts.Print(&out, newTestResult()))
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestPrintObject(t *testing.T) {
+	result := NewObject(testCluster{ID: "c1", Name: "zeta"}, "Cluster zeta")
+
+	var out bytes.Buffer
+	require.NoError(t, (&Options{}).Print(&out, result))
+	assert.Equal(t, "Cluster zeta\n", out.String())
+
+	out.Reset()
+	require.NoError(t, (&Options{Format: "jsonpath={.name}"}).Print(&out, result))
+	assert.Equal(t, "zeta\n", out.String())
+
+	assert.EqualError(t, (&Options{Format: CSV}).Print(&out, result), "output format csv is not supported by this command")
+	assert.EqualError(t, (&Options{Format: Markdown}).Print(&out, result), "output format markdown is not supported by this command")
+}
+
+func TestPrintObjectMarkdown(t *testing.T) {
+	result := NewObject(testCluster{ID: "c1", Name: "zeta"}, "Cluster zeta")
+	result.Markdown = "## Cluster zeta"
+
+	var out bytes.Buffer
+	require.NoError(t, (&Options{Format: Markdown}).Print(&out, result))
+	assert.Equal(t, "## Cluster zeta\n", out.String())
+
+	out.Reset()
+	require.NoError(t, (&Options{}).Print(&out, result))
+	assert.Equal(t, "Cluster zeta\n", out.String())
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, (&Options{Format: "JSON"}).Validate())
+	assert.NoError(t, (&Options{Format: ""}).Validate())
+	assert.EqualError(t, (&Options{Format: "env"}).Validate(),
+		"unsupported output format 'env', valid formats are: table, wide, json, yaml, csv, markdown, jsonpath=<template>, go-template=<template>")
+	assert.EqualError(t, (&Options{Format: "jsonpath"}).Validate(),
+		"output format jsonpath requires a template, e.g. -o jsonpath='{.id}'")
+	assert.Error(t, (&Options{Format: "jsonpath={.id"}).Validate())
+	assert.Error(t, (&Options{Format: "go-template={{.id"}).Validate())
+	assert.EqualError(t, (&Options{Format: "yaml=x"}).Validate(), "output format yaml doesn't take a template")
+
+	err := (&Options{SortBy: "age"}).Print(&bytes.Buffer{}, newTestResult())
+	assert.EqualError(t, err, "cannot sort by 'age', valid columns are: id, name, node count")
+}
+
+func TestAddFormatFlagSupportedFormats(t *testing.T) {
+	cmd := &cobra.Command{}
+	o := &Options{}
+	o.AddFormatFlag(cmd, Table, JSON, JSONPath)
+
+	assert.Equal(t, "Output format. One of: table, json, jsonpath=<template>", cmd.Flags().Lookup("output").Usage)
+	require.NoError(t, cmd.Flags().Set("output", "jsonpath={.id}"))
+	assert.NoError(t, o.Validate())
+	require.NoError(t, cmd.Flags().Set("output", "text"))
+	assert.NoError(t, o.Validate())
+	require.NoError(t, cmd.Flags().Set("output", "csv"))
+	assert.EqualError(t, o.Validate(), "output format csv is not supported by this command, valid formats are: table, json, jsonpath=<template>")
+}
@@ -42,3 +42,15 @@ func (e *CloudSpec) Set(v string) error {
 func (*CloudSpec) Type() string {
 	return "CloudSpec"
 }
+
+// providerSpecKind returns the kind of the provider spec of the CredentialsRequests of the cloud
+func (e CloudSpec) providerSpecKind() string {
+	switch e {
+	case AWS:
+		return "AWSProviderSpec"
+	case GCP:
+		return "GCPProviderSpec"
+	default:
+		return ""
+	}
+}
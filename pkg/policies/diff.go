@@ -0,0 +1,275 @@
+package policies
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	cco "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Changes of a CredentialsRequest between two releases
+const (
+	CredentialsRequestAdded   = "added"
+	CredentialsRequestRemoved = "removed"
+	CredentialsRequestChanged = "changed"
+)
+
+// CredentialsRequestDiff lists the permissions a release grants to or revokes from the operator
+// of a CredentialsRequest: AWS actions, or GCP roles and permissions
+type CredentialsRequestDiff struct {
+	Name   string `json:"name"`
+	Change string `json:"change"`
+
+	AddedActions   []string       `json:"added_actions,omitempty"`
+	RemovedActions []string       `json:"removed_actions,omitempty"`
+	ChangedActions []ActionChange `json:"changed_actions,omitempty"`
+
+	AddedRoles         []string `json:"added_roles,omitempty"`
+	RemovedRoles       []string `json:"removed_roles,omitempty"`
+	AddedPermissions   []string `json:"added_permissions,omitempty"`
+	RemovedPermissions []string `json:"removed_permissions,omitempty"`
+}
+
+// ActionChange is an AWS action granted in both releases, whose effect, resources or conditions
+// changed. Grants are written as "<effect> <resource>", followed by " when <condition>" when
+// the statement has one.
+type ActionChange struct {
+	Action string   `json:"action"`
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+}
+
+// AddsPermissions reports whether the target release grants anything the base release didn't:
+// actions, roles and permissions, or an action allowed on more resources or under fewer
+// conditions. Narrowing the resources of an action, adding a condition or a Deny doesn't
+// grant anything new, removing a Deny does.
+func (d CredentialsRequestDiff) AddsPermissions() bool {
+	if len(d.AddedActions) > 0 || len(d.AddedRoles) > 0 || len(d.AddedPermissions) > 0 {
+		return true
+	}
+	for _, change := range d.ChangedActions {
+		if change.widens() {
+			return true
+		}
+	}
+	return false
+}
+
+// widens reports whether the action is allowed on a resource or under a condition no previous
+// Allow covered, or a previous Deny was removed
+func (c ActionChange) widens() bool {
+	for _, grant := range c.After {
+		effect, resource, condition := parseGrant(grant)
+		if effect != "Allow" {
+			continue
+		}
+		covered := false
+		for _, previous := range c.Before {
+			previousEffect, previousResource, previousCondition := parseGrant(previous)
+			if previousEffect == "Allow" && resourceCovers(previousResource, resource) &&
+				(previousCondition == "" || previousCondition == condition) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return true
+		}
+	}
+	for _, grant := range c.Before {
+		if effect, _, _ := parseGrant(grant); effect == "Deny" && !slices.Contains(c.After, grant) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGrant splits a grant written as "<effect> <resource>[ when <condition>]"
+func parseGrant(grant string) (effect, resource, condition string) {
+	effect, rest, _ := strings.Cut(grant, " ")
+	resource, condition, _ = strings.Cut(rest, " when ")
+	return effect, resource, condition
+}
+
+// resourceCovers reports whether an IAM resource pattern, where '*' and '?' are wildcards,
+// matches every resource the other pattern matches
+func resourceCovers(pattern, resource string) bool {
+	if pattern == resource || pattern == "*" {
+		return true
+	}
+	expr := strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern))
+	matched, err := regexp.MatchString("^"+expr+"$", resource)
+	return err == nil && matched
+}
+
+func (d CredentialsRequestDiff) empty() bool {
+	return len(d.AddedActions) == 0 && len(d.RemovedActions) == 0 && len(d.ChangedActions) == 0 &&
+		len(d.AddedRoles) == 0 && len(d.RemovedRoles) == 0 && len(d.AddedPermissions) == 0 && len(d.RemovedPermissions) == 0
+}
+
+// grantedPermissions are the permissions requested by a CredentialsRequest
+type grantedPermissions struct {
+	// actions maps the AWS actions to their sorted grants
+	actions     map[string][]string
+	roles       []string
+	permissions []string
+}
+
+// DiffCredentialsRequests compares the permissions of the CredentialsRequests of two releases,
+// leaving out the CredentialsRequests whose permissions didn't change
+func DiffCredentialsRequests(base, target []*cco.CredentialsRequest) ([]CredentialsRequestDiff, error) {
+	basePermissions, err := permissionsByName(base)
+	if err != nil {
+		return nil, err
+	}
+	targetPermissions, err := permissionsByName(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range basePermissions {
+		names = append(names, name)
+	}
+	for name := range targetPermissions {
+		if _, ok := basePermissions[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diffs []CredentialsRequestDiff
+	for _, name := range names {
+		before, inBase := basePermissions[name]
+		after, inTarget := targetPermissions[name]
+		diff := CredentialsRequestDiff{Name: name, Change: CredentialsRequestChanged}
+		switch {
+		case !inBase:
+			diff.Change = CredentialsRequestAdded
+		case !inTarget:
+			diff.Change = CredentialsRequestRemoved
+		}
+
+		for action, grants := range after.actions {
+			previous, ok := before.actions[action]
+			if !ok {
+				diff.AddedActions = append(diff.AddedActions, action)
+			} else if !slices.Equal(previous, grants) {
+				diff.ChangedActions = append(diff.ChangedActions, ActionChange{Action: action, Before: previous, After: grants})
+			}
+		}
+		for action := range before.actions {
+			if _, ok := after.actions[action]; !ok {
+				diff.RemovedActions = append(diff.RemovedActions, action)
+			}
+		}
+		sort.Strings(diff.AddedActions)
+		sort.Strings(diff.RemovedActions)
+		sort.Slice(diff.ChangedActions, func(i, j int) bool { return diff.ChangedActions[i].Action < diff.ChangedActions[j].Action })
+		diff.AddedRoles, diff.RemovedRoles = diffSorted(before.roles, after.roles)
+		diff.AddedPermissions, diff.RemovedPermissions = diffSorted(before.permissions, after.permissions)
+
+		if diff.Change == CredentialsRequestChanged && diff.empty() {
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// diffSorted returns the elements only found in after, and the ones only found in before
+func diffSorted(before, after []string) (added, removed []string) {
+	for _, s := range after {
+		if !slices.Contains(before, s) {
+			added = append(added, s)
+		}
+	}
+	for _, s := range before {
+		if !slices.Contains(after, s) {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+func permissionsByName(credReqs []*cco.CredentialsRequest) (map[string]grantedPermissions, error) {
+	permissions := map[string]grantedPermissions{}
+	for _, credReq := range credReqs {
+		p, err := credentialsRequestPermissions(credReq)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing CredentialsRequest '%s': %w", credReq.Name, err)
+		}
+		permissions[credReq.Name] = p
+	}
+	return permissions, nil
+}
+
+// credentialsRequestPermissions returns the permissions of a CredentialsRequest, read from its
+// AWS policy document or its GCP service account
+func credentialsRequestPermissions(credReq *cco.CredentialsRequest) (grantedPermissions, error) {
+	permissions := grantedPermissions{actions: map[string][]string{}}
+	if credReq.Spec.ProviderSpec == nil {
+		return permissions, fmt.Errorf("missing providerSpec")
+	}
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(credReq.Spec.ProviderSpec.Raw, &typeMeta); err != nil {
+		return permissions, err
+	}
+
+	switch typeMeta.Kind {
+	case AWS.providerSpecKind():
+		doc, err := AWSCredentialsRequestToPolicyDocument(credReq)
+		if err != nil {
+			return permissions, err
+		}
+		for _, statement := range doc.Statement {
+			effect := statement.Effect
+			if effect == "" {
+				effect = "Allow"
+			}
+			resource := statement.Resource
+			if resource == "" {
+				resource = "*"
+			}
+			grant := effect + " " + resource
+			if len(statement.PolicyCondition) > 0 {
+				condition, err := json.Marshal(statement.PolicyCondition)
+				if err != nil {
+					return permissions, err
+				}
+				grant += " when " + string(condition)
+			}
+			for _, action := range statement.Action {
+				if !slices.Contains(permissions.actions[action], grant) {
+					permissions.actions[action] = append(permissions.actions[action], grant)
+				}
+			}
+		}
+		for _, grants := range permissions.actions {
+			sort.Strings(grants)
+		}
+	case GCP.providerSpecKind():
+		sa, err := CredentialsRequestToWifServiceAccount(credReq)
+		if err != nil {
+			return permissions, err
+		}
+		for _, role := range sa.Roles {
+			if role.Predefined {
+				permissions.roles = append(permissions.roles, GCPRoleIDPrefix+role.Id)
+			} else {
+				permissions.permissions = append(permissions.permissions, role.Permissions...)
+			}
+		}
+		sort.Strings(permissions.roles)
+		sort.Strings(permissions.permissions)
+		permissions.permissions = slices.Compact(permissions.permissions)
+	default:
+		return permissions, fmt.Errorf("unsupported providerSpec kind %q", typeMeta.Kind)
+	}
+	return permissions, nil
+}
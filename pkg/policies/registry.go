@@ -0,0 +1,369 @@
+package policies
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Media types of the manifests of images and of multi-arch images
+const (
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// imageReference is a parsed pull spec, e.g. quay.io/openshift-release-dev/ocp-release:4.15.0-x86_64
+type imageReference struct {
+	Registry   string
+	Repository string
+	// Reference is the tag or digest of the image
+	Reference string
+}
+
+// parseImageReference parses a pull spec, images without a registry being pulled from docker.io
+func parseImageReference(pullSpec string) (imageReference, error) {
+	ref := imageReference{}
+	name := pullSpec
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Reference = name[:i], name[i+1:]
+	}
+	if ref.Reference == "" {
+		ref.Reference = "latest"
+	}
+
+	registry, repository, found := strings.Cut(name, "/")
+	if !found || !(strings.ContainsAny(registry, ".:") || registry == "localhost") {
+		registry, repository = "docker.io", name
+	}
+	if registry == "docker.io" {
+		registry = "registry-1.docker.io"
+	}
+	if repository == "" || strings.ContainsAny(repository, " \t") {
+		return imageReference{}, fmt.Errorf("invalid image pull spec %q", pullSpec)
+	}
+	ref.Registry = registry
+	ref.Repository = repository
+	return ref, nil
+}
+
+func (r imageReference) String() string {
+	if strings.HasPrefix(r.Reference, "sha256:") {
+		return r.Registry + "/" + r.Repository + "@" + r.Reference
+	}
+	return r.Registry + "/" + r.Repository + ":" + r.Reference
+}
+
+// descriptor points to a manifest or a layer of an image
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Platform  *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform,omitempty"`
+}
+
+// imageManifest holds both the manifests of images and the indexes of multi-arch images
+type imageManifest struct {
+	MediaType string       `json:"mediaType"`
+	Layers    []descriptor `json:"layers"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+// registryClient pulls images from registries implementing the OCI distribution API,
+// authenticating with the credentials of the container tools when the registry requires it
+type registryClient struct {
+	client      *http.Client
+	credentials map[string]string
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func newRegistryClient(client *http.Client) *registryClient {
+	return &registryClient{client: client, credentials: loadRegistryCredentials(), tokens: map[string]string{}}
+}
+
+// imageLayers returns the layers of the linux/amd64 image of a reference, from the base layer
+// to the top one
+func (c *registryClient) imageLayers(ctx context.Context, ref imageReference) ([]descriptor, error) {
+	reference := ref.Reference
+	for range 2 {
+		body, err := c.get(ctx, ref, "manifests/"+reference, strings.Join([]string{mediaTypeOCIIndex, mediaTypeDockerManifestList, mediaTypeOCIManifest, mediaTypeDockerManifest}, ", "))
+		if err != nil {
+			return nil, err
+		}
+		var manifest imageManifest
+		err = json.NewDecoder(body).Decode(&manifest)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest of %s: %w", ref, err)
+		}
+
+		if len(manifest.Manifests) == 0 {
+			return manifest.Layers, nil
+		}
+		reference = manifest.Manifests[0].Digest
+		for _, m := range manifest.Manifests {
+			if m.Platform != nil && m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+				reference = m.Digest
+				break
+			}
+		}
+	}
+	return nil, fmt.Errorf("invalid manifest of %s: nested image indexes", ref)
+}
+
+// walkLayer calls fn with the files of a layer, then checks the digest of the layer
+func (c *registryClient) walkLayer(ctx context.Context, ref imageReference, layer descriptor, fn func(header *tar.Header, r io.Reader) error) error {
+	algorithm, expected, _ := strings.Cut(layer.Digest, ":")
+	if algorithm != "sha256" {
+		return fmt.Errorf("unsupported digest %q of a layer of %s", layer.Digest, ref)
+	}
+
+	body, err := c.get(ctx, ref, "blobs/"+layer.Digest, "")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	reader := bufio.NewReader(io.TeeReader(body, hash))
+	var content io.Reader = reader
+	if magic, _ := reader.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("invalid layer %s of %s: %w", layer.Digest, ref, err)
+		}
+		defer gzipReader.Close()
+		content = gzipReader
+	}
+
+	if err := walkTar(content, fn); err != nil {
+		return fmt.Errorf("invalid layer %s of %s: %w", layer.Digest, ref, err)
+	}
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != expected {
+		return fmt.Errorf("digest mismatch of layer %s of %s", layer.Digest, ref)
+	}
+	return nil
+}
+
+// walkTar calls fn with the regular files of a tar archive
+func walkTar(r io.Reader, fn func(header *tar.Header, r io.Reader) error) error {
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(header, tarReader); err != nil {
+			return err
+		}
+	}
+}
+
+// get requests a path of the repository of an image, authenticating when the registry asks to
+func (c *registryClient) get(ctx context.Context, ref imageReference, path string, accept string) (io.ReadCloser, error) {
+	endpoint := fmt.Sprintf("https://%s/v2/%s/%s", ref.Registry, ref.Repository, path)
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		c.authorize(req, ref)
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pull %s: %w", ref, err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp.Body, nil
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			if err := c.authenticate(ctx, ref, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return nil, fmt.Errorf("failed to pull %s: %s returned %s", ref, path, resp.Status)
+	}
+}
+
+func (c *registryClient) authorize(req *http.Request, ref imageReference) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if token, ok := c.tokens[ref.Registry+"/"+ref.Repository]; ok {
+		req.Header.Set("Authorization", token)
+	}
+}
+
+// authenticate gets the authorization asked by the challenge of a registry: basic credentials,
+// or a bearer token requested with them when there are some
+func (c *registryClient) authenticate(ctx context.Context, ref imageReference, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	credentials := c.credentials[ref.Registry]
+
+	var authorization string
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if credentials == "" {
+			return fmt.Errorf("failed to pull %s: the registry requires credentials", ref)
+		}
+		authorization = "Basic " + credentials
+	case "bearer":
+		token, err := c.requestToken(ctx, ref, parseChallenge(params), credentials)
+		if err != nil {
+			return err
+		}
+		authorization = "Bearer " + token
+	default:
+		return fmt.Errorf("failed to pull %s: unsupported authentication %q", ref, challenge)
+	}
+
+	c.mu.Lock()
+	c.tokens[ref.Registry+"/"+ref.Repository] = authorization
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *registryClient) requestToken(ctx context.Context, ref imageReference, challenge map[string]string, credentials string) (string, error) {
+	realm, err := url.Parse(challenge["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("failed to pull %s: invalid authentication realm %q", ref, challenge["realm"])
+	}
+	query := realm.Query()
+	if service := challenge["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", fmt.Sprintf("repository:%s:pull", ref.Repository))
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if credentials != "" {
+		req.Header.Set("Authorization", "Basic "+credentials)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to authenticate to %s: %w", ref.Registry, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to authenticate to %s: %s", ref.Registry, resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to authenticate to %s: %w", ref.Registry, err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+	return "", fmt.Errorf("failed to authenticate to %s: no token returned", ref.Registry)
+}
+
+// parseChallenge parses the parameters of a WWW-Authenticate header, e.g.
+// realm="https://quay.io/v2/auth",service="quay.io"
+func parseChallenge(params string) map[string]string {
+	values := map[string]string{}
+	for params != "" {
+		var key, value string
+		key, params, _ = strings.Cut(params, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if strings.HasPrefix(params, `"`) {
+			value, params, _ = strings.Cut(params[1:], `"`)
+			_, params, _ = strings.Cut(params, ",")
+		} else {
+			value, params, _ = strings.Cut(params, ",")
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// loadRegistryCredentials reads the base64 encoded credentials of the registries from the auth
+// file of podman, or from the configuration of docker
+func loadRegistryCredentials() map[string]string {
+	var paths []string
+	if path := os.Getenv("REGISTRY_AUTH_FILE"); path != "" {
+		paths = append(paths, path)
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "containers", "auth.json"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".docker", "config.json"))
+	}
+
+	credentials := map[string]string{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path) //#nosec G304 -- Potential file inclusion via variable
+		if err != nil {
+			continue
+		}
+		var config struct {
+			Auths map[string]struct {
+				Auth string `json:"auth"`
+			} `json:"auths"`
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			continue
+		}
+		for registry, auth := range config.Auths {
+			if _, err := base64.StdEncoding.DecodeString(auth.Auth); err != nil || auth.Auth == "" {
+				continue
+			}
+			registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+			registry, _, _ = strings.Cut(registry, "/")
+			if registry == "index.docker.io" || registry == "docker.io" {
+				registry = "registry-1.docker.io"
+			}
+			if _, ok := credentials[registry]; !ok {
+				credentials[registry] = auth.Auth
+			}
+		}
+		if len(credentials) > 0 {
+			return credentials
+		}
+	}
+	return credentials
+}
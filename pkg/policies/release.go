@@ -0,0 +1,293 @@
+package policies
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// defaultReleaseRepository is the repository of the images of the released OCP versions
+	defaultReleaseRepository = "quay.io/openshift-release-dev/ocp-release"
+	// releaseManifestsDir is the directory holding the manifests of a release payload
+	releaseManifestsDir = "release-manifests"
+)
+
+// CredentialsRequestSource extracts the CredentialsRequests of OCP releases from their release
+// image, pulled from its registry or a mirror, or from a release payload on disk
+type CredentialsRequestSource struct {
+	// Mirror is a repository replacing the repository of the release images,
+	// e.g. mirror.example.com/ocp4/openshift-release-dev
+	Mirror string
+	// CacheDir holds the CredentialsRequests extracted per release and cloud,
+	// nothing is cached when it's empty
+	CacheDir string
+	Client   *http.Client
+}
+
+// NewCredentialsRequestSource returns a source pulling release images from the mirror when set,
+// caching the CredentialsRequests in the user cache directory when useCache is set
+func NewCredentialsRequestSource(mirror string, useCache bool) *CredentialsRequestSource {
+	source := &CredentialsRequestSource{Mirror: mirror, Client: &http.Client{}}
+	if useCache {
+		if cacheDir, err := os.UserCacheDir(); err == nil {
+			source.CacheDir = filepath.Join(cacheDir, "osdctl", "credentials-requests")
+		}
+	}
+	return source
+}
+
+// DownloadCredentialRequests extracts the CredentialsRequests of a release for the given cloud
+// to a directory and returns it, see CredentialsRequestSource.Download
+func DownloadCredentialRequests(version string, cloud CloudSpec) (string, error) {
+	return NewCredentialsRequestSource("", true).Download(version, cloud)
+}
+
+// Download extracts the CredentialsRequests of a release for the given cloud to a directory and
+// returns it. The release is either a released version, the pull spec of a release image, or
+// the path of a release payload on disk: a directory holding its manifests, or a tar archive of
+// its files.
+func (s *CredentialsRequestSource) Download(release string, cloud CloudSpec) (string, error) {
+	if _, err := os.Stat(release); err == nil {
+		return extractToTempDir(func(dir string) error {
+			return extractLocalCredentialsRequests(release, cloud, dir)
+		})
+	}
+
+	ref, err := s.releaseReference(release)
+	if err != nil {
+		return "", err
+	}
+	extract := func(dir string) error {
+		return extractImageCredentialsRequests(context.Background(), newRegistryClient(s.Client), ref, cloud, dir)
+	}
+	if s.CacheDir == "" {
+		return extractToTempDir(extract)
+	}
+
+	cached := filepath.Join(s.CacheDir, cacheKey(ref), cloud.String())
+	if _, err := os.Stat(cached); err == nil {
+		return cached, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(cached), 0750); err != nil {
+		return "", err
+	}
+	// The cache only holds complete extractions
+	tmp, err := os.MkdirTemp(filepath.Dir(cached), ".tmp-")
+	if err != nil {
+		return "", err
+	}
+	if err := extract(tmp); err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, cached); err != nil {
+		os.RemoveAll(tmp)
+		if _, statErr := os.Stat(cached); statErr != nil {
+			return "", err
+		}
+	}
+	return cached, nil
+}
+
+// releaseReference returns the release image of a version or pull spec, in the mirror if set
+func (s *CredentialsRequestSource) releaseReference(release string) (imageReference, error) {
+	pullSpec := release
+	if _, err := semver.NewVersion(release); err == nil {
+		pullSpec = fmt.Sprintf("%s:%s-x86_64", defaultReleaseRepository, release)
+	}
+	ref, err := parseImageReference(pullSpec)
+	if err != nil {
+		return imageReference{}, err
+	}
+
+	if s.Mirror != "" {
+		mirror, err := parseImageReference(s.Mirror)
+		if err != nil {
+			return imageReference{}, fmt.Errorf("invalid mirror: %w", err)
+		}
+		ref.Registry = mirror.Registry
+		ref.Repository = mirror.Repository
+	}
+	return ref, nil
+}
+
+// cacheKey returns the name of the cache directory of a release image
+func cacheKey(ref imageReference) string {
+	return strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(ref.String())
+}
+
+func extractToTempDir(extract func(dir string) error) (string, error) {
+	dir, err := os.MkdirTemp("", "osdctl-crs-")
+	if err != nil {
+		return "", err
+	}
+	if err := extract(dir); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// extractImageCredentialsRequests writes the CredentialsRequests of a release image to dir. The
+// layers are read from the top one, until one holds the release manifests.
+func extractImageCredentialsRequests(ctx context.Context, client *registryClient, ref imageReference, cloud CloudSpec, dir string) error {
+	layers, err := client.imageLayers(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		found := false
+		err := client.walkLayer(ctx, ref, layers[i], func(header *tar.Header, r io.Reader) error {
+			name, ok := releaseManifestName(header.Name)
+			if !ok {
+				return nil
+			}
+			found = true
+			return writeCredentialsRequests(name, r, cloud, dir)
+		})
+		if err != nil {
+			return err
+		}
+		if found {
+			return nil
+		}
+	}
+	return fmt.Errorf("no release manifests found in %s", ref)
+}
+
+// extractLocalCredentialsRequests writes the CredentialsRequests of a release payload on disk to
+// dir: either a directory holding the manifests or their release-manifests directory, or a
+// tar archive, optionally gzipped, of the files of the payload
+func extractLocalCredentialsRequests(payload string, cloud CloudSpec, dir string) error {
+	info, err := os.Stat(payload)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		f, err := os.Open(payload) //#nosec G304 -- Potential file inclusion via variable
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		reader := bufio.NewReader(f)
+		var content io.Reader = reader
+		if magic, _ := reader.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+			gzipReader, err := gzip.NewReader(reader)
+			if err != nil {
+				return fmt.Errorf("invalid release payload %s: %w", payload, err)
+			}
+			defer gzipReader.Close()
+			content = gzipReader
+		}
+		found := false
+		err = walkTar(content, func(header *tar.Header, r io.Reader) error {
+			name, ok := releaseManifestName(header.Name)
+			if !ok {
+				return nil
+			}
+			found = true
+			return writeCredentialsRequests(name, r, cloud, dir)
+		})
+		if err != nil {
+			return fmt.Errorf("invalid release payload %s: %w", payload, err)
+		}
+		if !found {
+			return fmt.Errorf("no release manifests found in %s", payload)
+		}
+		return nil
+	}
+
+	if manifests := filepath.Join(payload, releaseManifestsDir); isDir(manifests) {
+		payload = manifests
+	}
+	return filepath.WalkDir(payload, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isManifest(d.Name()) {
+			return nil
+		}
+		f, err := os.Open(path) //#nosec G304 -- Potential file inclusion via variable
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return writeCredentialsRequests(d.Name(), f, cloud, dir)
+	})
+}
+
+// releaseManifestName returns the name of a manifest of the release-manifests directory of a
+// payload, from the path of a file of an image layer
+func releaseManifestName(name string) (string, bool) {
+	dir, file := path.Split(strings.TrimPrefix(path.Clean("/"+name), "/"))
+	if dir != releaseManifestsDir+"/" || !isManifest(file) {
+		return "", false
+	}
+	return file, true
+}
+
+func isManifest(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml" || ext == ".json"
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// writeCredentialsRequests writes the CredentialsRequests of the cloud found in a manifest
+// file to dir, one file per CredentialsRequest
+func writeCredentialsRequests(name string, r io.Reader, cloud CloudSpec, dir string) error {
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(r))
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	for i := 0; ; i++ {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid manifest %s: %w", name, err)
+		}
+
+		var manifest struct {
+			Kind string `json:"kind"`
+			Spec struct {
+				ProviderSpec struct {
+					Kind string `json:"kind"`
+				} `json:"providerSpec"`
+			} `json:"spec"`
+		}
+		if len(bytes.TrimSpace(doc)) == 0 || yaml.Unmarshal(doc, &manifest) != nil {
+			continue
+		}
+		if manifest.Kind != "CredentialsRequest" || manifest.Spec.ProviderSpec.Kind != cloud.providerSpecKind() {
+			continue
+		}
+
+		file := base + ".yaml"
+		if i > 0 {
+			file = fmt.Sprintf("%s-%d.yaml", base, i)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), doc, 0600); err != nil {
+			return err
+		}
+	}
+}
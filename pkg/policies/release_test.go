@@ -0,0 +1,356 @@
+package policies
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	cco "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	awsCredentialsRequestYAML = `apiVersion: cloudcredential.openshift.io/v1
+kind: CredentialsRequest
+metadata:
+  name: openshift-machine-api-aws
+spec:
+  providerSpec:
+    apiVersion: cloudcredential.openshift.io/v1
+    kind: AWSProviderSpec
+    statementEntries:
+    - effect: Allow
+      action:
+      - ec2:DescribeInstances
+      - ec2:CreateTags
+      resource: "*"
+    - effect: Allow
+      action:
+      - iam:PassRole
+      resource: arn:aws:iam::*:role/*-worker-role
+`
+	gcpCredentialsRequestYAML = `apiVersion: cloudcredential.openshift.io/v1
+kind: CredentialsRequest
+metadata:
+  name: openshift-gcp-ccm
+spec:
+  providerSpec:
+    apiVersion: cloudcredential.openshift.io/v1
+    kind: GCPProviderSpec
+    predefinedRoles:
+    - roles/compute.loadBalancerAdmin
+    permissions:
+    - compute.instances.get
+`
+	configMapYAML = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: release-verification
+`
+)
+
+func tarLayer(t *testing.T, gzipped bool, files map[string]string) []byte {
+	var buf bytes.Buffer
+	var gzipWriter *gzip.Writer
+	var tarWriter *tar.Writer
+	if gzipped {
+		gzipWriter = gzip.NewWriter(&buf)
+		tarWriter = tar.NewWriter(gzipWriter)
+	} else {
+		tarWriter = tar.NewWriter(&buf)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}))
+		_, err := tarWriter.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	if gzipped {
+		require.NoError(t, gzipWriter.Close())
+	}
+	return buf.Bytes()
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// fakeRegistry serves a multi-arch release image from the given repository, requiring a bearer
+// token like quay.io does
+type fakeRegistry struct {
+	server     *httptest.Server
+	repository string
+	blobs      map[string][]byte
+	manifests  map[string][]byte
+	requests   atomic.Int32
+}
+
+func newFakeRegistry(t *testing.T, repository string, manifestFiles map[string]string) *fakeRegistry {
+	r := &fakeRegistry{repository: repository, blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+
+	baseLayer := tarLayer(t, false, map[string]string{"usr/bin/cluster-version-operator": "binary"})
+	files := map[string]string{}
+	for name, content := range manifestFiles {
+		files["release-manifests/"+name] = content
+	}
+	releaseLayer := tarLayer(t, true, files)
+	r.blobs[digest(baseLayer)] = baseLayer
+	r.blobs[digest(releaseLayer)] = releaseLayer
+
+	manifest, err := json.Marshal(map[string]interface{}{
+		"mediaType": mediaTypeOCIManifest,
+		"layers": []map[string]string{
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar", "digest": digest(baseLayer)},
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": digest(releaseLayer)},
+		},
+	})
+	require.NoError(t, err)
+	index, err := json.Marshal(map[string]interface{}{
+		"mediaType": mediaTypeOCIIndex,
+		"manifests": []map[string]interface{}{
+			{"mediaType": mediaTypeOCIManifest, "digest": "sha256:arm64", "platform": map[string]string{"os": "linux", "architecture": "arm64"}},
+			{"mediaType": mediaTypeOCIManifest, "digest": digest(manifest), "platform": map[string]string{"os": "linux", "architecture": "amd64"}},
+		},
+	})
+	require.NoError(t, err)
+	r.manifests["4.15.0-x86_64"] = index
+	r.manifests[digest(manifest)] = manifest
+
+	r.server = httptest.NewTLSServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *fakeRegistry) serve(w http.ResponseWriter, req *http.Request) {
+	r.requests.Add(1)
+	if req.URL.Path == "/token" {
+		if req.URL.Query().Get("scope") != "repository:"+r.repository+":pull" {
+			http.Error(w, "invalid scope", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"token":"secret"}`)
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer secret" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake"`, r.server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	prefix := "/v2/" + r.repository + "/"
+	kind, ref, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, prefix), "/")
+	var content []byte
+	switch {
+	case !strings.HasPrefix(req.URL.Path, prefix):
+	case kind == "manifests":
+		content = r.manifests[ref]
+	case kind == "blobs":
+		content = r.blobs[ref]
+	}
+	if content == nil {
+		http.NotFound(w, req)
+		return
+	}
+	_, _ = w.Write(content)
+}
+
+func (r *fakeRegistry) host() string {
+	return strings.TrimPrefix(r.server.URL, "https://")
+}
+
+func readDir(t *testing.T, dir string) map[string]string {
+	files := map[string]string{}
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		require.NoError(t, err)
+		files[entry.Name()] = string(content)
+	}
+	return files
+}
+
+func TestCredentialsRequestSourceDownload(t *testing.T) {
+	t.Setenv("REGISTRY_AUTH_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	registry := newFakeRegistry(t, "ocp/release", map[string]string{
+		"0000_30_machine-api-operator_00_credentials-request.yaml": awsCredentialsRequestYAML + "---\n" + gcpCredentialsRequestYAML,
+		"0000_50_cloud-credential-operator_gcp.yaml":               gcpCredentialsRequestYAML,
+		"0000_90_release-verification.yaml":                        configMapYAML,
+		"image-references":                                         "{}",
+	})
+
+	source := &CredentialsRequestSource{
+		Mirror:   registry.host() + "/ocp/release",
+		CacheDir: t.TempDir(),
+		Client:   registry.server.Client(),
+	}
+
+	dir, err := source.Download("4.15.0", AWS)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"0000_30_machine-api-operator_00_credentials-request.yaml": awsCredentialsRequestYAML}, readDir(t, dir))
+	credReqs, err := ParseCredentialsRequestsInDir(dir)
+	require.NoError(t, err)
+	require.Len(t, credReqs, 1)
+	assert.Equal(t, "openshift-machine-api-aws", credReqs[0].Name)
+
+	// The CredentialsRequests of a release are only pulled once
+	requests := registry.requests.Load()
+	cached, err := source.Download("4.15.0", AWS)
+	require.NoError(t, err)
+	assert.Equal(t, dir, cached)
+	assert.Equal(t, requests, registry.requests.Load())
+
+	dir, err = source.Download("4.15.0", GCP)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0000_30_machine-api-operator_00_credentials-request-1.yaml", "0000_50_cloud-credential-operator_gcp.yaml"}, sortedKeys(readDir(t, dir)))
+
+	_, err = source.Download("4.16.0", AWS)
+	assert.ErrorContains(t, err, "404 Not Found")
+}
+
+func TestCredentialsRequestSourceDownloadLocalPayload(t *testing.T) {
+	files := map[string]string{
+		"release-manifests/0000_30_machine-api-operator_00_credentials-request.yaml": awsCredentialsRequestYAML,
+		"release-manifests/0000_50_cloud-credential-operator_gcp.yaml":               gcpCredentialsRequestYAML,
+		"release-manifests/0000_90_release-verification.yaml":                        configMapYAML,
+	}
+	archive := filepath.Join(t.TempDir(), "release.tar.gz")
+	require.NoError(t, os.WriteFile(archive, tarLayer(t, true, files), 0600))
+
+	payload := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(payload, filepath.Dir(name)), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(payload, name), []byte(content), 0600))
+	}
+
+	source := &CredentialsRequestSource{CacheDir: t.TempDir()}
+	for _, release := range []string{archive, payload, filepath.Join(payload, releaseManifestsDir)} {
+		dir, err := source.Download(release, GCP)
+		require.NoError(t, err, release)
+		assert.Equal(t, map[string]string{"0000_50_cloud-credential-operator_gcp.yaml": gcpCredentialsRequestYAML}, readDir(t, dir), release)
+	}
+}
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		pullSpec string
+		expected imageReference
+	}{
+		{"quay.io/openshift-release-dev/ocp-release:4.15.0-x86_64", imageReference{"quay.io", "openshift-release-dev/ocp-release", "4.15.0-x86_64"}},
+		{"quay.io/openshift-release-dev/ocp-release@sha256:abc", imageReference{"quay.io", "openshift-release-dev/ocp-release", "sha256:abc"}},
+		{"localhost:5000/ocp/release", imageReference{"localhost:5000", "ocp/release", "latest"}},
+		{"library/busybox:1", imageReference{"registry-1.docker.io", "library/busybox", "1"}},
+	}
+	for _, tt := range tests {
+		ref, err := parseImageReference(tt.pullSpec)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, ref)
+	}
+}
+
+func TestDiffCredentialsRequests(t *testing.T) {
+	parse := func(manifests ...string) []*cco.CredentialsRequest {
+		dir := t.TempDir()
+		for i, manifest := range manifests {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.yaml", i)), []byte(manifest), 0600))
+		}
+		credReqs, err := ParseCredentialsRequestsInDir(dir)
+		require.NoError(t, err)
+		return credReqs
+	}
+
+	awsTarget := strings.NewReplacer(
+		"      - ec2:CreateTags\n", "      - ec2:DescribeImages\n",
+		"-worker-role", "-worker-role\n      policyCondition:\n        StringEquals:\n          aws:ResourceTag/red-hat-managed: \"true\"",
+	).Replace(awsCredentialsRequestYAML)
+	gcpTarget := strings.NewReplacer("compute.loadBalancerAdmin", "compute.viewer").Replace(gcpCredentialsRequestYAML)
+
+	diffs, err := DiffCredentialsRequests(parse(awsCredentialsRequestYAML, gcpCredentialsRequestYAML), parse(awsTarget, gcpTarget))
+	require.NoError(t, err)
+	assert.Equal(t, []CredentialsRequestDiff{
+		{
+			Name:         "openshift-gcp-ccm",
+			Change:       CredentialsRequestChanged,
+			AddedRoles:   []string{"roles/compute.viewer"},
+			RemovedRoles: []string{"roles/compute.loadBalancerAdmin"},
+		},
+		{
+			Name:           "openshift-machine-api-aws",
+			Change:         CredentialsRequestChanged,
+			AddedActions:   []string{"ec2:DescribeImages"},
+			RemovedActions: []string{"ec2:CreateTags"},
+			ChangedActions: []ActionChange{{
+				Action: "iam:PassRole",
+				Before: []string{"Allow arn:aws:iam::*:role/*-worker-role"},
+				After:  []string{`Allow arn:aws:iam::*:role/*-worker-role when {"StringEquals":{"aws:ResourceTag/red-hat-managed":"true"}}`},
+			}},
+		},
+	}, diffs)
+	assert.True(t, diffs[0].AddsPermissions())
+	assert.True(t, diffs[1].AddsPermissions())
+
+	diffs, err = DiffCredentialsRequests(parse(awsCredentialsRequestYAML, gcpCredentialsRequestYAML), parse(awsCredentialsRequestYAML))
+	require.NoError(t, err)
+	assert.Equal(t, []CredentialsRequestDiff{{
+		Name:               "openshift-gcp-ccm",
+		Change:             CredentialsRequestRemoved,
+		RemovedRoles:       []string{"roles/compute.loadBalancerAdmin"},
+		RemovedPermissions: []string{"compute.instances.get"},
+	}}, diffs)
+	assert.False(t, diffs[0].AddsPermissions())
+}
+
+func TestAddsPermissions(t *testing.T) {
+	const condition = ` when {"StringEquals":{"aws:ResourceTag/red-hat-managed":"true"}}`
+	tests := []struct {
+		name   string
+		before []string
+		after  []string
+		adds   bool
+	}{
+		{name: "narrowed resource", before: []string{"Allow *"}, after: []string{"Allow arn:aws:s3:::bucket/*"}},
+		{name: "narrowed resource pattern", before: []string{"Allow arn:aws:iam::*:role/*"}, after: []string{"Allow arn:aws:iam::*:role/*-worker-role"}},
+		{name: "added condition", before: []string{"Allow *"}, after: []string{"Allow *" + condition}},
+		{name: "added deny", before: []string{"Allow *"}, after: []string{"Allow *", "Deny arn:aws:s3:::secrets/*"}},
+		{name: "widened resource", before: []string{"Allow arn:aws:s3:::bucket/*"}, after: []string{"Allow *"}, adds: true},
+		{name: "other resource", before: []string{"Allow arn:aws:s3:::bucket/*"}, after: []string{"Allow arn:aws:s3:::other/*"}, adds: true},
+		{name: "removed condition", before: []string{"Allow *" + condition}, after: []string{"Allow *"}, adds: true},
+		{name: "removed deny", before: []string{"Allow *", "Deny arn:aws:s3:::secrets/*"}, after: []string{"Allow *"}, adds: true},
+		{name: "deny turned into allow", before: []string{"Deny *"}, after: []string{"Allow *"}, adds: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := CredentialsRequestDiff{ChangedActions: []ActionChange{{Action: "s3:GetObject", Before: tt.before, After: tt.after}}}
+			assert.Equal(t, tt.adds, diff.AddsPermissions())
+		})
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
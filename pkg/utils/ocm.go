@@ -175,6 +175,15 @@ func GenerateQuery(clusterIdentifier string) string {
 	}
 }
 
+// GenerateIDQuery returns the cluster search query matching the given internal cluster IDs
+func GenerateIDQuery(clusterIDs []string) string {
+	quoted := make([]string, len(clusterIDs))
+	for i, id := range clusterIDs {
+		quoted[i] = fmt.Sprintf("'%s'", id)
+	}
+	return fmt.Sprintf("id in (%s)", strings.Join(quoted, ", "))
+}
+
 // Finds the OCM Configuration file and returns the path to it.
 // ( Taken wholesale from openshift-online/ocm-cli )
 func getOCMConfigLocation() (string, error) {
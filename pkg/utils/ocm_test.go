@@ -64,6 +64,12 @@ func TestGenerateQuery(t *testing.T) {
 	}
 }
 
+func TestGenerateIDQuery(t *testing.T) {
+	if got, want := GenerateIDQuery([]string{"a", "b"}), "id in ('a', 'b')"; got != want {
+		t.Errorf("GenerateIDQuery() = %v, want %v", got, want)
+	}
+}
+
 // TestGetOcmConfigFromFilePath tests the GetOcmConfigFromFilePath function which loads
 // OCM configuration from a JSON file at the provided path. It validates that the function
 // correctly handles valid config files, non-existent files, empty files, and malformed JSON.
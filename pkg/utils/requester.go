@@ -2,10 +2,12 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -19,6 +21,8 @@ type Requester struct {
 	Data        string
 	Headers     map[string]string
 	SuccessCode int
+	// Context cancels the request when done, it defaults to context.Background()
+	Context context.Context
 }
 
 func (rh *Requester) Send() (string, error) {
@@ -26,12 +30,17 @@ func (rh *Requester) Send() (string, error) {
 		Timeout: time.Second * 600,
 	}
 
+	ctx := rh.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	var req *http.Request
 	var err error
 	if rh.Data != "" {
-		req, err = http.NewRequest(rh.Method, rh.Url, bytes.NewBuffer([]byte(rh.Data)))
+		req, err = http.NewRequestWithContext(ctx, rh.Method, rh.Url, bytes.NewBuffer([]byte(rh.Data)))
 	} else {
-		req, err = http.NewRequest(rh.Method, rh.Url, nil)
+		req, err = http.NewRequestWithContext(ctx, rh.Method, rh.Url, nil)
 	}
 
 	if err != nil {
@@ -55,14 +64,46 @@ func (rh *Requester) Send() (string, error) {
 	}
 
 	if resp.StatusCode != rh.SuccessCode {
+		reqErr := &RequestError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
 		var respErr responseError
 		err = json.Unmarshal(body, &respErr)
 		if err != nil || len(respErr.Records) == 0 {
-			return "", fmt.Errorf("request failed: %s: %s", resp.Status, body)
+			reqErr.message = fmt.Sprintf("request failed: %s: %s", resp.Status, body)
+		} else {
+			reqErr.message = fmt.Sprintf("request failed: %s %s", resp.Status, respErr.Records)
 		}
-
-		return "", fmt.Errorf("request failed: %s %s", resp.Status, respErr.Records)
+		return "", reqErr
 	}
 
 	return string(body), nil
 }
+
+// RequestError is returned by Send when the response doesn't have the expected status code
+type RequestError struct {
+	StatusCode int
+	// RetryAfter is the delay before retrying the request asked by the server, 0 when not set
+	RetryAfter time.Duration
+
+	message string
+}
+
+func (e *RequestError) Error() string {
+	return e.message
+}
+
+// parseRetryAfter parses a Retry-After header, either a number of seconds or a date
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && time.Until(date) > 0 {
+		return time.Until(date)
+	}
+	return 0
+}